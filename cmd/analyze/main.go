@@ -10,14 +10,34 @@ import (
 	"text/tabwriter"
 
 	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/app"
+	"github.com/ekinolik/jax-ov/internal/config"
+	"github.com/ekinolik/jax-ov/internal/market"
 )
 
 func main() {
+	app.SetupLogging("")
+
 	// Parse command-line flags
 	input := flag.String("input", "", "Input JSON file path (required)")
 	period := flag.Int("period", 5, "Time period in minutes (default: 5)")
+	anchor := flag.String("anchor", analysis.AnchorMidnight, "Period boundary anchor: midnight or open (default: midnight)")
 	output := flag.String("output", "", "Optional output JSON file path")
+	daily := flag.Bool("daily", false, "Show the day's cumulative totals (premium, volume, ratio, peak period, unique contracts) instead of each period")
+	quiet := app.QuietFlag(flag.CommandLine)
+	session := app.SessionFlag(flag.CommandLine)
+	timezone := app.TimezoneFlag(flag.CommandLine)
+	metrics := app.MetricsFlag(flag.CommandLine)
 	flag.Parse()
+	if err := config.ApplyFlagEnv(flag.CommandLine); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
+	if err := market.SetAnalysisTimezone(*timezone); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if err := analysis.EnablePeriodMetrics(*metrics); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
 
 	// Validate flags
 	if *input == "" {
@@ -27,9 +47,19 @@ func main() {
 	if *period <= 0 {
 		log.Fatal("Error: --period must be greater than 0")
 	}
+	sessions, err := market.ParseSessionSet(*session)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if err := analysis.ValidateAnchor(*anchor); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	opts := analysis.AggregateOptions{PeriodMinutes: *period, Anchor: *anchor, Sessions: sessions}
+
+	progress := app.NewProgress(*quiet)
 
 	// Read input file
-	fmt.Printf("Reading input file: %s\n", *input)
+	progress.Printf("Reading input file: %s\n", *input)
 	data, err := os.ReadFile(*input)
 	if err != nil {
 		log.Fatalf("Failed to read input file: %v", err)
@@ -41,27 +71,50 @@ func main() {
 		log.Fatalf("Failed to parse JSON: %v", err)
 	}
 
-	fmt.Printf("Loaded %d aggregates\n", len(aggregates))
-	fmt.Printf("Aggregating premiums by %d-minute periods...\n", *period)
+	progress.Printf("Loaded %d aggregates\n", len(aggregates))
+	if sessions != 0 {
+		aggregates = opts.Filter(aggregates)
+		progress.Printf("Kept %d aggregates in sessions: %s\n", len(aggregates), sessions)
+	}
+	progress.Printf("Aggregating premiums by %d-minute periods...\n", *period)
 
 	// Aggregate premiums
-	summaries, err := analysis.AggregatePremiums(aggregates, *period)
+	summaries, err := analysis.AggregatePremiumsWithOptions(aggregates, opts)
 	if err != nil {
 		log.Fatalf("Failed to aggregate premiums: %v", err)
 	}
 
-	fmt.Printf("Found %d time periods\n\n", len(summaries))
+	progress.Printf("Found %d time periods\n\n", len(summaries))
 
-	// Display table
-	displayTable(summaries)
+	// Daily mode reports the day's totals instead of each period
+	var result interface{} = summaries
+	if *daily {
+		ticker, dateStr := analysis.DailyKey(aggregates)
+		dailySummary := analysis.SummarizeDaily(ticker, dateStr, *period, summaries)
+		result = dailySummary
+		if !progress.Quiet() {
+			displayDaily(dailySummary)
+		}
+	}
+
+	// Quiet mode replaces the table with JSON on stdout, unless it is going to a file
+	if !progress.Quiet() {
+		if !*daily {
+			displayTable(summaries)
+		}
+	} else if *output == "" {
+		if err := app.PrintJSON(result); err != nil {
+			log.Fatalf("Failed to encode JSON: %v", err)
+		}
+	}
 
 	// Write JSON output if requested
 	if *output != "" {
-		fmt.Printf("\nWriting results to %s...\n", *output)
-		if err := writeJSONOutput(summaries, *output); err != nil {
+		progress.Printf("\nWriting results to %s...\n", *output)
+		if err := writeJSONOutput(result, *output); err != nil {
 			log.Fatalf("Failed to write JSON output: %v", err)
 		}
-		fmt.Printf("Successfully wrote results to %s\n", *output)
+		progress.Printf("Successfully wrote results to %s\n", *output)
 	}
 }
 
@@ -102,14 +155,38 @@ func formatCurrency(amount float64) string {
 }
 
 // formatRatio formats the call to put ratio
-func formatRatio(ratio float64) string {
-	if ratio < 0 {
+func formatRatio(ratio *float64) string {
+	if ratio == nil {
 		return "N/A" // Infinite ratio (no puts)
 	}
-	if ratio == 0 {
-		return "0.00"
+	return fmt.Sprintf("%.2f", *ratio)
+}
+
+// displayDaily displays a day's cumulative totals
+func displayDaily(daily analysis.DailySummary) {
+	loc := market.AnalysisLocation()
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "Ticker:\t%s\n", daily.Ticker)
+	fmt.Fprintf(w, "Date:\t%s\n", daily.Date)
+	fmt.Fprintf(w, "Periods with trades:\t%d\n", daily.Periods)
+	fmt.Fprintf(w, "Call Premium:\t$%s\n", formatCurrency(daily.CallPremium))
+	fmt.Fprintf(w, "Put Premium:\t$%s\n", formatCurrency(daily.PutPremium))
+	fmt.Fprintf(w, "Total Premium:\t$%s\n", formatCurrency(daily.TotalPremium))
+	fmt.Fprintf(w, "Call/Put Ratio:\t%s\n", formatRatio(daily.CallPutRatio))
+	fmt.Fprintf(w, "Call Volume:\t%d\n", daily.CallVolume)
+	fmt.Fprintf(w, "Put Volume:\t%d\n", daily.PutVolume)
+	fmt.Fprintf(w, "Unique Contracts:\t%d\n", daily.UniqueContracts)
+	if peak := daily.PeakPeriod; peak != nil {
+		fmt.Fprintf(w, "Peak Period:\t%s - %s (%s), $%s\n",
+			peak.PeriodStart.In(loc).Format("15:04"),
+			peak.PeriodEnd.In(loc).Format("15:04"),
+			peak.Session,
+			formatCurrency(peak.TotalPremium))
 	}
-	return fmt.Sprintf("%.2f", ratio)
+	if trade := daily.LargestTrade; trade != nil {
+		fmt.Fprintf(w, "Largest Trade:\t%s, $%s (%d contracts)\n", trade.Symbol, formatCurrency(trade.Premium), trade.Volume)
+	}
+	w.Flush()
 }
 
 // displayTable displays the premium summary in a formatted table
@@ -122,7 +199,7 @@ func displayTable(summaries []analysis.TimePeriodSummary) {
 
 	// Rows
 	for _, summary := range summaries {
-		timeStr := summary.PeriodStart.Format("2006-01-02 15:04:05")
+		timeStr := summary.PeriodStart.In(market.AnalysisLocation()).Format("2006-01-02 15:04:05")
 		callFormatted := formatCurrency(summary.CallPremium)
 		putFormatted := formatCurrency(summary.PutPremium)
 		totalFormatted := formatCurrency(summary.TotalPremium)
@@ -145,8 +222,8 @@ func displayTable(summaries []analysis.TimePeriodSummary) {
 	w.Flush()
 }
 
-// writeJSONOutput writes the summaries to a JSON file
-func writeJSONOutput(summaries []analysis.TimePeriodSummary, filename string) error {
+// writeJSONOutput writes the results (summaries or daily totals) to a JSON file
+func writeJSONOutput(results interface{}, filename string) error {
 	file, err := os.Create(filename)
 	if err != nil {
 		return err
@@ -155,5 +232,5 @@ func writeJSONOutput(summaries []analysis.TimePeriodSummary, filename string) er
 
 	encoder := json.NewEncoder(file)
 	encoder.SetIndent("", "  ")
-	return encoder.Encode(summaries)
+	return encoder.Encode(results)
 }
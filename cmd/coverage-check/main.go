@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/app"
+	"github.com/ekinolik/jax-ov/internal/config"
+	"github.com/ekinolik/jax-ov/internal/market"
+	"github.com/ekinolik/jax-ov/internal/rest"
+	"github.com/ekinolik/jax-ov/internal/server"
+)
+
+// CoverageReport compares logged option volume against the official session volume for one ticker and date
+type CoverageReport struct {
+	Date               string    `json:"date"`
+	Ticker             string    `json:"ticker"`
+	CheckedAt          time.Time `json:"checked_at"`
+	OfficialVolume     int64     `json:"official_volume"`
+	OfficialCallVolume int64     `json:"official_call_volume"`
+	OfficialPutVolume  int64     `json:"official_put_volume"`
+	LoggedVolume       int64     `json:"logged_volume"`
+	LoggedCallVolume   int64     `json:"logged_call_volume"`
+	LoggedPutVolume    int64     `json:"logged_put_volume"`
+	CoveragePct        float64   `json:"coverage_pct"`    // Logged / official volume * 100 (0 when there is no official volume)
+	Contracts          int       `json:"contracts"`       // Contracts with official volume on the date
+	StaleContracts     int       `json:"stale_contracts"` // Snapshot entries from another session, excluded from the totals
+}
+
+func main() {
+	app.SetupLogging("")
+
+	// Parse command-line flags
+	tickersStr := flag.String("tickers", "", "Comma-separated underlying tickers to check (required, e.g., AAPL,TSLA)")
+	logDir := flag.String("log-dir", "./logs", "Log directory path (default: ./logs)")
+	dateStr := flag.String("date", "", "Session date in YYYY-MM-DD format (default: today ET; must be the latest session)")
+	output := flag.String("output", "", "Coverage history file to append JSONL records to (default: <log-dir>/coverage.jsonl)")
+	quiet := app.QuietFlag(flag.CommandLine)
+	flag.Parse()
+	if err := config.ApplyFlagEnv(flag.CommandLine); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
+	progress := app.NewProgress(*quiet)
+
+	if *tickersStr == "" {
+		log.Fatal("Error: --tickers is required")
+	}
+	var tickers []string
+	for _, ticker := range strings.Split(*tickersStr, ",") {
+		if ticker = strings.ToUpper(strings.TrimSpace(ticker)); ticker != "" {
+			tickers = append(tickers, ticker)
+		}
+	}
+
+	if *dateStr == "" {
+		*dateStr = time.Now().In(market.Location).Format("2006-01-02")
+	}
+	date, err := time.ParseInLocation("2006-01-02", *dateStr, market.Location)
+	if err != nil {
+		log.Fatal("Error: --date must be in YYYY-MM-DD format")
+	}
+	if !market.IsTradingDay(date) {
+		log.Printf("%s is not a trading day, nothing to check", *dateStr)
+		return
+	}
+
+	if *output == "" {
+		*output = filepath.Join(*logDir, "coverage.jsonl")
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	restClient := rest.NewClient(cfg.APIKey)
+	ctx := context.Background()
+
+	reports := []CoverageReport{}
+	for _, ticker := range tickers {
+		report, err := checkTicker(ctx, restClient, *logDir, ticker, *dateStr)
+		if err != nil {
+			log.Printf("Error checking %s: %v", ticker, err)
+			continue
+		}
+		if report.StaleContracts > 0 {
+			log.Printf("%s: %d contracts in the snapshot are not from %s and were excluded", ticker, report.StaleContracts, *dateStr)
+		}
+		reports = append(reports, report)
+	}
+
+	if err := appendReports(*output, reports); err != nil {
+		log.Fatalf("Failed to write coverage history: %v", err)
+	}
+
+	// Quiet mode prints this run's records as JSON instead of the table
+	if progress.Quiet() {
+		if err := app.PrintJSON(reports); err != nil {
+			log.Fatalf("Failed to encode JSON: %v", err)
+		}
+		return
+	}
+	displayReports(reports)
+	progress.Printf("\nAppended %d records to %s\n", len(reports), *output)
+}
+
+// checkTicker fetches the official session volume for a ticker and compares it with the logged volume
+func checkTicker(ctx context.Context, restClient *rest.Client, logDir string, ticker string, dateStr string) (CoverageReport, error) {
+	report := CoverageReport{Date: dateStr, Ticker: ticker, CheckedAt: time.Now().UTC()}
+
+	volumes, err := restClient.GetOptionChainDailyVolume(ctx, ticker)
+	if err != nil {
+		return report, err
+	}
+	for _, contract := range volumes {
+		if contract.Volume == 0 {
+			continue
+		}
+		// The snapshot only reflects the latest session for each contract
+		if contract.LastUpdated.In(market.Location).Format("2006-01-02") != dateStr {
+			report.StaleContracts++
+			continue
+		}
+
+		report.Contracts++
+		report.OfficialVolume += contract.Volume
+		switch contract.ContractType {
+		case "call":
+			report.OfficialCallVolume += contract.Volume
+		case "put":
+			report.OfficialPutVolume += contract.Volume
+		}
+	}
+
+	logFile := server.GetLogFileForTickerAndDate(logDir, ticker, dateStr)
+	var aggregates []analysis.Aggregate
+	if _, err := os.Stat(logFile); err == nil {
+		aggregates, err = server.ReadLogFile(logFile)
+		if err != nil {
+			return report, err
+		}
+	}
+	logged := analysis.SummarizeDay(dateStr, aggregates)
+	report.LoggedCallVolume = logged.CallVolume
+	report.LoggedPutVolume = logged.PutVolume
+	report.LoggedVolume = logged.CallVolume + logged.PutVolume
+
+	if report.OfficialVolume > 0 {
+		report.CoveragePct = float64(report.LoggedVolume) / float64(report.OfficialVolume) * 100
+	}
+
+	return report, nil
+}
+
+// appendReports appends coverage reports to a JSONL history file
+func appendReports(filename string, reports []CoverageReport) error {
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", filename, err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, report := range reports {
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+	}
+	return nil
+}
+
+// displayReports prints coverage reports in a table format
+func displayReports(reports []CoverageReport) {
+	fmt.Printf("\n%-8s %-12s %15s %15s %10s %10s\n", "Ticker", "Date", "Official Vol", "Logged Vol", "Coverage", "Contracts")
+	fmt.Println(strings.Repeat("-", 75))
+	for _, report := range reports {
+		fmt.Printf("%-8s %-12s %15d %15d %9.1f%% %10d\n",
+			report.Ticker, report.Date, report.OfficialVolume, report.LoggedVolume, report.CoveragePct, report.Contracts)
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/app"
+	"github.com/ekinolik/jax-ov/internal/config"
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// FileReport summarizes expired-contract records found in a single log file
+type FileReport struct {
+	File             string `json:"file"`
+	TotalRecords     int    `json:"total_records"`
+	ExpiredRecords   int    `json:"expired_records"`
+	ExpiredContracts int    `json:"expired_contracts"`
+	ActiveContracts  int    `json:"active_contracts"`
+	UnparsedRecords  int    `json:"unparsed_records"`
+	Archived         bool   `json:"archived"`
+}
+
+func main() {
+	app.SetupLogging("")
+
+	// Parse command-line flags
+	logDir := flag.String("log-dir", "./logs", "Log directory path (default: ./logs)")
+	archiveDir := flag.String("archive-dir", "", "Directory to move expired contract records into (optional; report only if not set)")
+	asOfStr := flag.String("as-of", "", "Treat contracts expiring before this date as expired (YYYY-MM-DD, default: today ET)")
+	ticker := flag.String("ticker", "", "Only process log files for this underlying ticker (optional)")
+	output := flag.String("output", "", "Optional output JSON report path")
+	quiet := app.QuietFlag(flag.CommandLine)
+	flag.Parse()
+	if err := config.ApplyFlagEnv(flag.CommandLine); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
+	progress := app.NewProgress(*quiet)
+
+	// Resolve as-of date
+	asOf := time.Now().In(market.Location)
+	if *asOfStr != "" {
+		parsed, err := time.Parse("2006-01-02", *asOfStr)
+		if err != nil {
+			log.Fatal("Error: --as-of must be in YYYY-MM-DD format")
+		}
+		asOf = parsed
+	}
+	today := time.Now().In(market.Location).Format("2006-01-02")
+
+	// Find log files
+	entries, err := os.ReadDir(*logDir)
+	if err != nil {
+		log.Fatalf("Failed to read log directory: %v", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+		if *ticker != "" && !strings.HasPrefix(name, strings.ToUpper(*ticker)+"_") {
+			continue
+		}
+		// Never rewrite today's file; the logger is still appending to it
+		if strings.HasSuffix(name, "_"+today+".jsonl") {
+			continue
+		}
+		files = append(files, filepath.Join(*logDir, name))
+	}
+	sort.Strings(files)
+
+	progress.Printf("Scanning %d log files for contracts expired before %s\n", len(files), asOf.Format("2006-01-02"))
+
+	reports := []FileReport{}
+	totalExpired := 0
+	for _, file := range files {
+		report, err := processFile(file, asOf, *archiveDir)
+		if err != nil {
+			log.Printf("Error processing %s: %v", file, err)
+			continue
+		}
+		totalExpired += report.ExpiredRecords
+		if report.ExpiredRecords > 0 {
+			reports = append(reports, report)
+			action := "found"
+			if report.Archived {
+				action = "archived"
+			}
+			progress.Printf("%s: %s %d expired records (%d contracts), %d active contracts remain\n",
+				filepath.Base(file), action, report.ExpiredRecords, report.ExpiredContracts, report.ActiveContracts)
+		}
+	}
+
+	progress.Printf("Done: %d expired records in %d files\n", totalExpired, len(reports))
+
+	// Quiet mode prints the report as JSON, unless it is going to a file
+	if progress.Quiet() && *output == "" {
+		if err := app.PrintJSON(reports); err != nil {
+			log.Fatalf("Failed to encode JSON: %v", err)
+		}
+	}
+
+	if *output != "" {
+		data, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal report: %v", err)
+		}
+		if err := os.WriteFile(*output, data, 0644); err != nil {
+			log.Fatalf("Failed to write report: %v", err)
+		}
+		progress.Printf("Report written to %s\n", *output)
+	}
+}
+
+// processFile scans a log file for expired contracts and optionally moves their records to the archive directory
+func processFile(filename string, asOf time.Time, archiveDir string) (FileReport, error) {
+	report := FileReport{File: filename}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return report, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	var activeLines, expiredLines [][]byte
+	expiredContracts := make(map[string]bool)
+	activeContracts := make(map[string]bool)
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := append([]byte(nil), scanner.Bytes()...)
+		if len(line) == 0 {
+			continue
+		}
+		report.TotalRecords++
+
+		var agg analysis.Aggregate
+		if err := json.Unmarshal(line, &agg); err != nil {
+			// Keep lines we can't parse in place
+			report.UnparsedRecords++
+			activeLines = append(activeLines, line)
+			continue
+		}
+
+		expired, err := analysis.IsExpired(agg.Symbol, asOf)
+		if err != nil {
+			report.UnparsedRecords++
+			activeLines = append(activeLines, line)
+			continue
+		}
+
+		if expired {
+			expiredLines = append(expiredLines, line)
+			expiredContracts[agg.Symbol] = true
+		} else {
+			activeLines = append(activeLines, line)
+			activeContracts[agg.Symbol] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return report, fmt.Errorf("error reading log file: %w", err)
+	}
+
+	report.ExpiredRecords = len(expiredLines)
+	report.ExpiredContracts = len(expiredContracts)
+	report.ActiveContracts = len(activeContracts)
+
+	if archiveDir == "" || len(expiredLines) == 0 {
+		return report, nil
+	}
+
+	// Append expired records to the archive copy first so nothing is lost if the rewrite fails
+	if err := os.MkdirAll(archiveDir, 0755); err != nil {
+		return report, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	if err := appendLines(filepath.Join(archiveDir, filepath.Base(filename)), expiredLines); err != nil {
+		return report, fmt.Errorf("failed to write archive file: %w", err)
+	}
+
+	// Rewrite the source file with only active records
+	tmpFile := filename + ".tmp"
+	if err := os.Remove(tmpFile); err != nil && !os.IsNotExist(err) {
+		return report, fmt.Errorf("failed to remove stale temp file: %w", err)
+	}
+	if err := appendLines(tmpFile, activeLines); err != nil {
+		return report, fmt.Errorf("failed to write active records: %w", err)
+	}
+	if err := os.Rename(tmpFile, filename); err != nil {
+		return report, fmt.Errorf("failed to replace log file: %w", err)
+	}
+
+	report.Archived = true
+	return report, nil
+}
+
+// appendLines appends newline-terminated lines to a file, creating it if needed
+func appendLines(filename string, lines [][]byte) error {
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for _, line := range lines {
+		if _, err := writer.Write(line); err != nil {
+			return err
+		}
+		if err := writer.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/app"
+	"github.com/ekinolik/jax-ov/internal/config"
+	"github.com/ekinolik/jax-ov/internal/export"
+	"github.com/ekinolik/jax-ov/internal/market"
+	"github.com/ekinolik/jax-ov/internal/server"
+)
+
+// DayResult reports how many points were exported for one daily log file
+type DayResult struct {
+	Ticker string `json:"ticker"`
+	Date   string `json:"date"`
+	Points int    `json:"points"`
+	Error  string `json:"error,omitempty"`
+}
+
+// exporter turns daily log files into points and sends them to the writer
+type exporter struct {
+	logDir    string
+	opts      analysis.AggregateOptions
+	baselines *server.VolumeBaselines
+	writer    export.Writer
+}
+
+func main() {
+	app.SetupLogging("")
+
+	// Parse command-line flags
+	logDir := flag.String("log-dir", "./logs", "Log directory path (default: ./logs)")
+	tickersStr := flag.String("tickers", "", "Comma-separated tickers to export (default: all tickers with log files in the date range)")
+	from := flag.String("from", "", "First date to export (YYYY-MM-DD, default: the most recent trading session)")
+	to := flag.String("to", "", "Last date to export (YYYY-MM-DD, default: --from)")
+	period := flag.Int("period", 5, "Time period in minutes (default: 5)")
+	anchor := flag.String("anchor", analysis.AnchorMidnight, "Period boundary anchor: midnight or open (default: midnight)")
+	session := app.SessionFlag(flag.CommandLine)
+	format := flag.String("format", export.FormatInflux, "Output format: influx (line protocol) or prometheus (remote-write) (default: influx)")
+	url := flag.String("url", "", "InfluxDB write URL or Prometheus remote-write URL to push to; required for prometheus (default: write line protocol to --output)")
+	output := flag.String("output", "", "File to write line protocol to when --url is not set (default: stdout)")
+	measurement := flag.String("measurement", export.DefaultMeasurement, "InfluxDB measurement name (default: premium_flow)")
+	advDays := flag.Int("adv-days", server.DefaultBaselineDays, "Trading days averaged into each ticker's average daily volume for relative flow metrics (default: 20)")
+	follow := flag.Bool("follow", false, "After the history, keep exporting today's periods as they complete (default: false)")
+	interval := flag.Duration("interval", 30*time.Second, "How often --follow checks for completed periods (default: 30s)")
+	quiet := app.QuietFlag(flag.CommandLine)
+	timezone := app.TimezoneFlag(flag.CommandLine)
+	flag.Parse()
+	if err := config.ApplyFlagEnv(flag.CommandLine); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
+	if err := market.SetAnalysisTimezone(*timezone); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	// Validate flags
+	if *period <= 0 {
+		log.Fatal("Error: --period must be greater than 0")
+	}
+	sessions, err := market.ParseSessionSet(*session)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if err := analysis.ValidateAnchor(*anchor); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if *follow && *interval <= 0 {
+		log.Fatal("Error: --interval must be greater than 0")
+	}
+	if *from == "" {
+		*from = market.DefaultDate()
+	}
+	if *to == "" {
+		*to = *from
+	}
+	for _, date := range []string{*from, *to} {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			log.Fatalf("Error: invalid date %q, expected YYYY-MM-DD", date)
+		}
+	}
+	if *to < *from {
+		log.Fatal("Error: --to must not be before --from")
+	}
+
+	// Line protocol on stdout is the command's output, so progress messages and the quiet-mode report are suppressed
+	var out io.Writer = os.Stdout
+	toStdout := *url == "" && *output == ""
+	if *url == "" && *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("Failed to create output file: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	progress := app.NewProgress(*quiet || toStdout)
+
+	writer, err := export.NewWriter(export.Config{
+		Format:      *format,
+		URL:         *url,
+		Token:       config.LoadExportToken(),
+		Measurement: *measurement,
+		Output:      out,
+	})
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	e := &exporter{
+		logDir:    *logDir,
+		opts:      analysis.AggregateOptions{PeriodMinutes: *period, Anchor: *anchor, Sessions: sessions},
+		baselines: server.NewVolumeBaselines(server.NewRollupCache(*logDir), *advDays),
+		writer:    writer,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	days, err := findDays(*logDir, app.ParseTickers(*tickersStr), *from, *to)
+	if err != nil {
+		log.Fatalf("Failed to list log files: %v", err)
+	}
+	progress.Printf("Exporting %d daily files as %s...\n", len(days), *format)
+
+	// Today's in-progress period is left for --follow, so a live point is only ever written once it is complete
+	now := time.Now()
+	results := make([]DayResult, 0, len(days))
+	exported := make(map[string]time.Time) // Key: ticker and date; end of the last period exported
+	failed := 0
+	for _, day := range days {
+		result := DayResult{Ticker: day.ticker, Date: day.date}
+		last, err := e.exportDay(ctx, day, time.Time{}, now)
+		if err != nil {
+			result.Error = err.Error()
+			failed++
+			log.Printf("Failed to export %s %s: %v", day.ticker, day.date, err)
+		} else {
+			progress.Printf("  %s %s: %d points\n", day.ticker, day.date, last.points)
+		}
+		result.Points = last.points
+		exported[day.ticker+"_"+day.date] = last.end
+		results = append(results, result)
+	}
+
+	if progress.Quiet() && !toStdout {
+		if err := app.PrintJSON(results); err != nil {
+			log.Fatalf("Failed to encode JSON: %v", err)
+		}
+	}
+	if !*follow {
+		if failed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	progress.Printf("Following today's log files every %s (Ctrl+C to stop)...\n", *interval)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			progress.Println("Stopped")
+			return
+		case <-ticker.C:
+		}
+
+		today := market.Today()
+		days, err := findDays(*logDir, app.ParseTickers(*tickersStr), today, today)
+		if err != nil {
+			log.Printf("Failed to list log files: %v", err)
+			continue
+		}
+		for _, day := range days {
+			key := day.ticker + "_" + day.date
+			last, err := e.exportDay(ctx, day, exported[key], time.Now())
+			if err != nil {
+				log.Printf("Failed to export %s %s: %v", day.ticker, day.date, err)
+				continue
+			}
+			if last.points > 0 {
+				exported[key] = last.end
+				progress.Printf("  %s %s: %d points\n", day.ticker, day.date, last.points)
+			}
+		}
+	}
+}
+
+// dayExport is how much of a day was exported
+type dayExport struct {
+	points int
+	end    time.Time // End of the last period exported (or the previous end when nothing new was)
+}
+
+// exportDay writes a day's completed periods that end after since
+// Periods still in progress at now are skipped so they can be written once they complete
+func (e *exporter) exportDay(ctx context.Context, day dayFile, since time.Time, now time.Time) (dayExport, error) {
+	result := dayExport{end: since}
+	summaries, err := server.AnalyzeTickerAndDateWithOptions(e.logDir, day.ticker, day.date, e.opts)
+	if err != nil {
+		return result, err
+	}
+	summaries, err = e.baselines.Apply(day.ticker, day.date, summaries)
+	if err != nil {
+		log.Printf("Exporting %s %s without relative flow: %v", day.ticker, day.date, err)
+	}
+
+	var points []export.Point
+	for _, summary := range summaries {
+		if !summary.PeriodEnd.After(since) || summary.PeriodEnd.After(now) {
+			continue
+		}
+		points = append(points, export.SummaryPoint(day.ticker, e.opts.PeriodMinutes, summary))
+		result.end = summary.PeriodEnd
+	}
+	if err := e.writer.Write(ctx, points); err != nil {
+		return dayExport{end: since}, err
+	}
+	result.points = len(points)
+	return result, nil
+}
+
+// dayFile is a daily log file found in the log directory
+type dayFile struct {
+	ticker string
+	date   string
+}
+
+// findDays lists the daily log files in logDir (TICKER_YYYY-MM-DD.jsonl) for the given tickers and date range,
+// sorted by date then ticker
+func findDays(logDir string, tickers map[string]bool, from string, to string) ([]dayFile, error) {
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log directory: %w", err)
+	}
+
+	var days []dayFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+
+		// Format: TICKER_YYYY-MM-DD.jsonl
+		separator := strings.LastIndex(name, "_")
+		if separator <= 0 {
+			continue
+		}
+		ticker := name[:separator]
+		dateStr := strings.TrimSuffix(name[separator+1:], ".jsonl")
+		if _, err := time.Parse("2006-01-02", dateStr); err != nil {
+			continue
+		}
+		if tickers != nil && !tickers[ticker] {
+			continue
+		}
+		if dateStr < from || dateStr > to {
+			continue
+		}
+
+		days = append(days, dayFile{ticker: ticker, date: dateStr})
+	}
+
+	sort.Slice(days, func(i, j int) bool {
+		if days[i].date != days[j].date {
+			return days[i].date < days[j].date
+		}
+		return days[i].ticker < days[j].ticker
+	})
+	return days, nil
+}
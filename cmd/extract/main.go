@@ -10,15 +10,29 @@ import (
 	"time"
 
 	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/app"
+	"github.com/ekinolik/jax-ov/internal/config"
+	"github.com/ekinolik/jax-ov/internal/market"
 )
 
 func main() {
+	app.SetupLogging("")
+
 	// Parse command-line flags
 	input := flag.String("input", "", "Input JSON file path (required)")
 	timeStr := flag.String("time", "", "Start time in HH:MM format (required, e.g., 9:46)")
 	period := flag.Int("period", 1, "Time period in minutes (default: 1)")
 	dateStr := flag.String("date", "", "Date in YYYY-MM-DD format (optional, defaults to today)")
+	quiet := app.QuietFlag(flag.CommandLine) // Output is already JSON only; accepted for consistency with the other CLIs
+	timezone := app.TimezoneFlag(flag.CommandLine)
 	flag.Parse()
+	if err := config.ApplyFlagEnv(flag.CommandLine); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
+	if err := market.SetAnalysisTimezone(*timezone); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	app.NewProgress(*quiet)
 
 	// Validate flags
 	if *input == "" {
@@ -54,28 +68,26 @@ func main() {
 		log.Fatal("Error: minute must be between 0 and 59")
 	}
 
-	// Load timezone (Pacific Time)
-	loc, err := time.LoadLocation("America/Los_Angeles")
-	if err != nil {
-		log.Fatalf("Error: failed to load timezone: %v", err)
-	}
+	// Times are interpreted in the analysis timezone (--timezone)
+	loc := market.AnalysisLocation()
 
 	// Parse date or use today
 	var date time.Time
 	if *dateStr != "" {
-		// Parse date string and interpret it in Pacific Time
+		// Parse date string and interpret it in the analysis timezone
 		dateStrWithTime := *dateStr + " 00:00:00"
+		var err error
 		date, err = time.ParseInLocation("2006-01-02 15:04:05", dateStrWithTime, loc)
 		if err != nil {
 			log.Fatalf("Error: invalid date format. Use YYYY-MM-DD format: %v", err)
 		}
 	} else {
-		// Use today in Pacific Time
+		// Use today in the analysis timezone
 		now := time.Now().In(loc)
 		date = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
 	}
 
-	// Create start time in Pacific Time
+	// Create start time in the analysis timezone
 	startTime := time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, loc)
 	endTime := startTime.Add(time.Duration(*period) * time.Minute)
 
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/app"
+	"github.com/ekinolik/jax-ov/internal/config"
+	"github.com/ekinolik/jax-ov/internal/logger"
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// maxExamples is how many problem lines are quoted per file
+const maxExamples = 5
+
+// FileReport describes the problems found in one log file
+type FileReport struct {
+	File       string   `json:"file"`
+	Ticker     string   `json:"ticker,omitempty"` // From the file name (empty when misnamed)
+	Date       string   `json:"date,omitempty"`
+	Lines      int      `json:"lines"`              // Non-blank lines
+	Records    int      `json:"records"`            // Valid, unique records
+	Malformed  int      `json:"malformed"`          // Lines that aren't JSON aggregates
+	Invalid    int      `json:"invalid"`            // Aggregates failing validation (symbol, timestamps, negative values)
+	Duplicates int      `json:"duplicates"`         // Records repeating an earlier one (same symbol, start, and end)
+	OutOfOrder int      `json:"out_of_order"`       // Records starting before an earlier record, beyond --order-tolerance
+	Misrouted  int      `json:"misrouted"`          // Valid records whose underlying or start date belongs in another file
+	Misnamed   bool     `json:"misnamed"`           // Name isn't TICKER_YYYY-MM-DD.jsonl
+	Examples   []string `json:"examples,omitempty"` // First few problems, with line numbers
+	Repaired   []string `json:"repaired,omitempty"` // Repaired files written with this file's records
+	Error      string   `json:"error,omitempty"`
+
+	targets map[string]bool // Files this file's valid records belong in
+}
+
+// Clean reports whether the file has no problems
+func (r *FileReport) Clean() bool {
+	return r.Error == "" && !r.Misnamed && r.Malformed+r.Invalid+r.Duplicates+r.OutOfOrder+r.Misrouted == 0
+}
+
+// example records a problem line, keeping only the first few
+func (r *FileReport) example(lineNum int, format string, args ...interface{}) {
+	if len(r.Examples) < maxExamples {
+		r.Examples = append(r.Examples, fmt.Sprintf("line %d: %s", lineNum, fmt.Sprintf(format, args...)))
+	}
+}
+
+// recordKey identifies an aggregate record for de-duplication
+type recordKey struct {
+	symbol string
+	start  int64
+	end    int64
+}
+
+func main() {
+	app.SetupLogging("")
+
+	// Parse command-line flags
+	logDir := flag.String("log-dir", "./logs", "Log directory to check (default: ./logs)")
+	repairDir := flag.String("repair-dir", "", "Directory to write repaired copies of files with problems to (default: report only)")
+	tickers := flag.String("tickers", "", "Comma-separated tickers to check (default: all files in the log directory)")
+	from := flag.String("from", "", "First date to check (YYYY-MM-DD, optional)")
+	to := flag.String("to", "", "Last date to check (YYYY-MM-DD, optional)")
+	orderTolerance := flag.Duration("order-tolerance", 0, "How far a record's start may fall behind an earlier record's before it counts as out of order (default: 0)")
+	quiet := app.QuietFlag(flag.CommandLine)
+	timezone := app.TimezoneFlag(flag.CommandLine)
+	mergeIndexRoots := app.IndexRootsFlag(flag.CommandLine)
+	flag.Parse()
+	if err := config.ApplyFlagEnv(flag.CommandLine); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
+	if err := market.SetAnalysisTimezone(*timezone); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	analysis.SetIndexRootMerging(*mergeIndexRoots)
+	progress := app.NewProgress(*quiet)
+
+	// Validate flags
+	for _, date := range []string{*from, *to} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			log.Fatalf("Error: invalid date %q, expected YYYY-MM-DD", date)
+		}
+	}
+	if *orderTolerance < 0 {
+		log.Fatal("Error: --order-tolerance must not be negative")
+	}
+	if *repairDir != "" {
+		same, err := sameDir(*logDir, *repairDir)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		if same {
+			log.Fatal("Error: --repair-dir must differ from --log-dir; originals are never modified")
+		}
+		if err := os.MkdirAll(*repairDir, 0755); err != nil {
+			log.Fatalf("Failed to create repair directory: %v", err)
+		}
+	}
+
+	files, compressed, err := findFiles(*logDir, app.ParseTickers(*tickers), *from, *to)
+	if err != nil {
+		log.Fatalf("Failed to list log files: %v", err)
+	}
+	progress.Printf("Checking %d log files in %s...\n", len(files), *logDir)
+	if compressed > 0 {
+		progress.Printf("Skipping %d compressed (.jsonl.gz) files; gunzip them to check them\n", compressed)
+	}
+
+	loc := market.AnalysisLocation()
+	reports := make([]*FileReport, 0, len(files))
+	for _, file := range files {
+		report := checkFile(*logDir, file, loc, *orderTolerance)
+		reports = append(reports, report)
+		if !report.Clean() {
+			progress.Printf("  %s\n", describe(report))
+		}
+	}
+
+	if *repairDir != "" {
+		if err := repair(*logDir, *repairDir, reports, loc); err != nil {
+			log.Fatalf("Repair failed: %v", err)
+		}
+	}
+
+	// Totals across every file checked
+	var problems, repaired int
+	var totals FileReport
+	for _, report := range reports {
+		if !report.Clean() {
+			problems++
+		}
+		repaired += len(report.Repaired)
+		totals.Lines += report.Lines
+		totals.Records += report.Records
+		totals.Malformed += report.Malformed
+		totals.Invalid += report.Invalid
+		totals.Duplicates += report.Duplicates
+		totals.OutOfOrder += report.OutOfOrder
+		totals.Misrouted += report.Misrouted
+	}
+	progress.Printf("\nFiles: %d checked, %d with problems\n", len(reports), problems)
+	progress.Printf("Lines: %d, valid records: %d, malformed: %d, invalid: %d, duplicates: %d, out of order: %d, misrouted: %d\n",
+		totals.Lines, totals.Records, totals.Malformed, totals.Invalid, totals.Duplicates, totals.OutOfOrder, totals.Misrouted)
+	if *repairDir != "" {
+		progress.Printf("Repaired copies written to %s\n", *repairDir)
+	}
+
+	if progress.Quiet() {
+		if err := app.PrintJSON(reports); err != nil {
+			log.Fatalf("Failed to encode JSON: %v", err)
+		}
+	}
+	if problems > 0 {
+		os.Exit(1)
+	}
+}
+
+// describe summarizes a file's problems on one line
+func describe(report *FileReport) string {
+	if report.Error != "" {
+		return fmt.Sprintf("%s: %s", report.File, report.Error)
+	}
+	var problems []string
+	if report.Misnamed {
+		problems = append(problems, "misnamed")
+	}
+	for _, count := range []struct {
+		n    int
+		name string
+	}{
+		{report.Malformed, "malformed"},
+		{report.Invalid, "invalid"},
+		{report.Duplicates, "duplicates"},
+		{report.OutOfOrder, "out of order"},
+		{report.Misrouted, "misrouted"},
+	} {
+		if count.n > 0 {
+			problems = append(problems, fmt.Sprintf("%d %s", count.n, count.name))
+		}
+	}
+	return fmt.Sprintf("%s: %s", report.File, strings.Join(problems, ", "))
+}
+
+// parseFileName splits a log file name (TICKER_YYYY-MM-DD.jsonl) into its ticker and date
+func parseFileName(name string) (ticker string, date string, ok bool) {
+	if !strings.HasSuffix(name, ".jsonl") {
+		return "", "", false
+	}
+	separator := strings.LastIndex(name, "_")
+	if separator <= 0 {
+		return "", "", false
+	}
+	ticker = name[:separator]
+	date = strings.TrimSuffix(name[separator+1:], ".jsonl")
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return "", "", false
+	}
+	if ticker != strings.ToUpper(ticker) {
+		return "", "", false
+	}
+	return ticker, date, true
+}
+
+// findFiles lists the .jsonl files in logDir, sorted by name, and counts the compressed ones it skips
+// Misnamed files are always included; the ticker and date filters apply to the others
+func findFiles(logDir string, tickers map[string]bool, from string, to string) ([]string, int, error) {
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read log directory: %w", err)
+	}
+
+	var files []string
+	compressed := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if strings.HasSuffix(name, ".jsonl.gz") {
+			compressed++
+			continue
+		}
+		if !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+		if ticker, date, ok := parseFileName(name); ok {
+			if tickers != nil && !tickers[ticker] {
+				continue
+			}
+			if (from != "" && date < from) || (to != "" && date > to) {
+				continue
+			}
+		}
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	return files, compressed, nil
+}
+
+// targetFile returns the log file name an aggregate belongs in: its underlying and its start date in loc
+func targetFile(agg analysis.Aggregate, loc *time.Location) string {
+	underlying, _ := logger.ExtractUnderlyingSymbol(agg.Symbol)
+	date := time.UnixMilli(agg.StartTimestamp).In(loc).Format("2006-01-02")
+	return fmt.Sprintf("%s_%s.jsonl", underlying, date)
+}
+
+// scanFile calls visit with each valid, unique aggregate in a log file, and the report's counters for every line
+func scanFile(path string, report *FileReport, loc *time.Location, tolerance time.Duration, visit func(agg analysis.Aggregate)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	seen := make(map[recordKey]bool)
+	var latestStart int64
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		report.Lines++
+
+		var agg analysis.Aggregate
+		if err := json.Unmarshal(line, &agg); err != nil {
+			report.Malformed++
+			report.example(lineNum, "malformed: %v", err)
+			continue
+		}
+		if err := logger.ValidateAggregate(agg); err != nil {
+			report.Invalid++
+			report.example(lineNum, "invalid: %v", err)
+			continue
+		}
+		key := recordKey{symbol: agg.Symbol, start: agg.StartTimestamp, end: agg.EndTimestamp}
+		if seen[key] {
+			report.Duplicates++
+			report.example(lineNum, "duplicate of an earlier %s record starting %d", agg.Symbol, agg.StartTimestamp)
+			continue
+		}
+		seen[key] = true
+
+		if agg.StartTimestamp < latestStart-tolerance.Milliseconds() {
+			report.OutOfOrder++
+			report.example(lineNum, "%s starts %s before an earlier record", agg.Symbol, time.Duration(latestStart-agg.StartTimestamp)*time.Millisecond)
+		}
+		latestStart = max(latestStart, agg.StartTimestamp)
+
+		if target := targetFile(agg, loc); target != report.File {
+			report.Misrouted++
+			if !report.Misnamed {
+				report.example(lineNum, "%s belongs in %s", agg.Symbol, target)
+			}
+		}
+		report.Records++
+		visit(agg)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading log file: %w", err)
+	}
+	return nil
+}
+
+// checkFile scans one log file and reports its problems
+func checkFile(logDir string, name string, loc *time.Location, tolerance time.Duration) *FileReport {
+	report := &FileReport{File: name, targets: make(map[string]bool)}
+	var ok bool
+	if report.Ticker, report.Date, ok = parseFileName(name); !ok {
+		report.Misnamed = true
+	}
+
+	err := scanFile(filepath.Join(logDir, name), report, loc, tolerance, func(agg analysis.Aggregate) {
+		report.targets[targetFile(agg, loc)] = true
+	})
+	if err != nil {
+		report.Error = err.Error()
+	}
+	return report
+}
+
+// repair writes repaired copies of the files with problems to repairDir
+// Each repaired file holds the valid, unique records that belong in it from every checked file, in start time order,
+// so records moved out of misnamed or misrouted files join the records already in their proper file
+func repair(logDir string, repairDir string, reports []*FileReport, loc *time.Location) error {
+	// Files to write: everything a problem file's records belong in
+	targets := make(map[string]bool)
+	for _, report := range reports {
+		if report.Clean() || report.Error != "" {
+			continue
+		}
+		for target := range report.targets {
+			targets[target] = true
+		}
+	}
+	names := make([]string, 0, len(targets))
+	for target := range targets {
+		names = append(names, target)
+	}
+	sort.Strings(names)
+
+	for _, target := range names {
+		seen := make(map[recordKey]bool)
+		var records []analysis.Aggregate
+		var sources []*FileReport
+		for _, report := range reports {
+			if !report.targets[target] {
+				continue
+			}
+			sources = append(sources, report)
+			var scratch FileReport
+			scratch.File = report.File
+			err := scanFile(filepath.Join(logDir, report.File), &scratch, loc, 0, func(agg analysis.Aggregate) {
+				key := recordKey{symbol: agg.Symbol, start: agg.StartTimestamp, end: agg.EndTimestamp}
+				if targetFile(agg, loc) != target || seen[key] {
+					return
+				}
+				seen[key] = true
+				records = append(records, agg)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to re-read %s: %w", report.File, err)
+			}
+		}
+		sort.SliceStable(records, func(i, j int) bool {
+			return records[i].StartTimestamp < records[j].StartTimestamp
+		})
+
+		if err := writeRecords(filepath.Join(repairDir, target), records); err != nil {
+			return err
+		}
+		for _, source := range sources {
+			source.Repaired = append(source.Repaired, target)
+		}
+	}
+	return nil
+}
+
+// writeRecords writes aggregates to a log file as JSONL, via a temp file renamed into place
+func writeRecords(path string, records []analysis.Aggregate) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, agg := range records {
+		if err := encoder.Encode(agg); err != nil {
+			return fmt.Errorf("failed to encode aggregate: %w", err)
+		}
+	}
+
+	tmpFile := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err := os.WriteFile(tmpFile, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := os.Rename(tmpFile, path); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// sameDir reports whether two paths name the same directory (a missing b is never the same)
+func sameDir(a string, b string) (bool, error) {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat log directory: %w", err)
+	}
+	infoB, err := os.Stat(b)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat repair directory: %w", err)
+	}
+	return os.SameFile(infoA, infoB), nil
+}
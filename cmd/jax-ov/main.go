@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/ekinolik/jax-ov/internal/app"
+	"github.com/ekinolik/jax-ov/internal/app/loggerapp"
+	"github.com/ekinolik/jax-ov/internal/app/mockloggerapp"
+	"github.com/ekinolik/jax-ov/internal/app/notificationsapp"
+	"github.com/ekinolik/jax-ov/internal/app/reconstructapp"
+	"github.com/ekinolik/jax-ov/internal/app/serverapp"
+)
+
+// subcommand describes a single jax-ov subcommand
+type subcommand struct {
+	description string
+	run         func(args []string)
+}
+
+// subcommands maps subcommand names to their implementations
+var subcommands = map[string]subcommand{
+	"serve":       {"Run the analysis WebSocket/HTTP server (same as cmd/server)", serverapp.Run},
+	"log":         {"Run the WebSocket data logger (same as cmd/logger)", loggerapp.Run},
+	"notify":      {"Run the push notifications service (same as cmd/notifications)", notificationsapp.Run},
+	"reconstruct": {"Reconstruct a historical feed via REST (same as cmd/reconstruct)", reconstructapp.Run},
+	"mock":        {"Generate mock aggregates into the log directory (same as cmd/mock-logger)", mockloggerapp.Run},
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		usage()
+		if len(os.Args) < 2 {
+			os.Exit(2)
+		}
+		return
+	}
+
+	name := os.Args[1]
+	cmd, ok := subcommands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown subcommand: %s\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+
+	app.SetupLogging(name)
+	cmd.run(os.Args[2:])
+}
+
+// usage prints the list of available subcommands
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: jax-ov <subcommand> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Subcommands:")
+
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", name, subcommands[name].description)
+	}
+
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Run 'jax-ov <subcommand> -h' for subcommand flags.")
+}
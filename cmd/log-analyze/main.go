@@ -2,25 +2,69 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"text/tabwriter"
 	"time"
 
 	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/app"
+	"github.com/ekinolik/jax-ov/internal/config"
+	"github.com/ekinolik/jax-ov/internal/market"
+	"github.com/ekinolik/jax-ov/internal/marketdata"
 )
 
 func main() {
+	app.SetupLogging("")
+
 	// Parse command-line flags
 	input := flag.String("input", "", "Input JSONL log file path (required)")
 	period := flag.Int("period", 5, "Time period in minutes (default: 5)")
+	anchor := flag.String("anchor", analysis.AnchorMidnight, "Period boundary anchor: midnight or open (default: midnight)")
 	output := flag.String("output", "", "Optional output JSON file path")
-	quiet := flag.Bool("quiet", false, "Suppress informational output (only show errors)")
+	daily := flag.Bool("daily", false, "Show the day's cumulative totals (premium, volume, ratio, peak period, unique contracts) instead of each period")
+	quiet := app.QuietFlag(flag.CommandLine)
+	byExpiration := flag.Bool("by-expiration", false, "Break each period's call/put premium into expiration buckets (0DTE, weekly, monthly, LEAPS)")
+	byStrike := flag.Bool("by-strike", false, "Show each period's strike ladder: call/put premium and volume at every strike traded")
+	spotVendor := flag.String("spot-vendor", "", "Market-data vendor for underlying prices, massive or stub; adds delta-weighted premium and net gamma to each period (default: disabled)")
+	iv := flag.Float64("iv", analysis.DefaultIV, "Fallback implied volatility for greeks when it can't be solved from a contract's price (default: 0.30)")
+	maxMoneyness := flag.Float64("max-moneyness", 0, "Only include strikes within this percent of the underlying's price at each trade, e.g. 10 (requires --spot-vendor; default: 0, every strike)")
+	rate := flag.Float64("rate", analysis.DefaultRiskFreeRate, "Annualized risk-free rate for greeks (default: 0.04)")
+	oiVendor := flag.String("oi-vendor", "", "Market-data vendor for open interest, massive or stub; adds volume/OI ratios to each period and contract (default: disabled)")
+	oiDir := flag.String("oi-dir", "", "Directory open interest snapshots are saved to and read from (default: <log-dir>/open-interest)")
+	zeroFill := flag.Bool("zero-fill", false, "Include empty periods from the market open to the last trade, so every period in the range is listed")
+	byContract := flag.Bool("by-contract", false, "Show the day's totals per contract (volume, premium, and with --oi-vendor, open interest and volume/OI ratio)")
+	rollup := flag.String("rollup", "", "Rollup mode: 'daily', 'weekly', or 'monthly' totals across a log directory (requires --log-dir and --ticker)")
+	logDir := flag.String("log-dir", "./logs", "Log directory path for --rollup; --oi-dir defaults to its open-interest subdirectory (default: ./logs)")
+	ticker := flag.String("ticker", "", "Underlying ticker for --rollup (e.g., AAPL)")
+	from := flag.String("from", "", "First date to include in --rollup (YYYY-MM-DD, optional)")
+	to := flag.String("to", "", "Last date to include in --rollup (YYYY-MM-DD, optional)")
+	session := app.SessionFlag(flag.CommandLine)
+	timezone := app.TimezoneFlag(flag.CommandLine)
+	metrics := app.MetricsFlag(flag.CommandLine)
 	flag.Parse()
+	if err := config.ApplyFlagEnv(flag.CommandLine); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
+	if err := market.SetAnalysisTimezone(*timezone); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if err := analysis.EnablePeriodMetrics(*metrics); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	progress := app.NewProgress(*quiet)
+
+	// Rollup mode reads every daily file for the ticker instead of a single input
+	if *rollup != "" {
+		runRollup(*logDir, strings.ToUpper(*ticker), *from, *to, *rollup, *output, progress)
+		return
+	}
 
 	// Validate flags
 	if *input == "" {
@@ -30,48 +74,207 @@ func main() {
 	if *period <= 0 {
 		log.Fatal("Error: --period must be greater than 0")
 	}
+	sessions, err := market.ParseSessionSet(*session)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if err := analysis.ValidateAnchor(*anchor); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if *maxMoneyness < 0 {
+		log.Fatal("Error: --max-moneyness must not be negative")
+	}
+	if *maxMoneyness > 0 && *spotVendor == "" {
+		log.Fatal("Error: --max-moneyness requires --spot-vendor")
+	}
+	opts := analysis.AggregateOptions{PeriodMinutes: *period, Anchor: *anchor, Sessions: sessions, MaxMoneyness: *maxMoneyness}
 
 	// Read JSONL file
-	if !*quiet {
-		fmt.Printf("Reading log file: %s\n", *input)
-	}
+	progress.Printf("Reading log file: %s\n", *input)
 	aggregates, err := readJSONLFile(*input)
 	if err != nil {
 		log.Fatalf("Failed to read log file: %v", err)
 	}
 
-	if !*quiet {
-		fmt.Printf("Loaded %d aggregates\n", len(aggregates))
-		fmt.Printf("Aggregating premiums by %d-minute periods...\n", *period)
+	progress.Printf("Loaded %d aggregates\n", len(aggregates))
+
+	// Every mode below sees only the requested sessions
+	if sessions != 0 {
+		aggregates = opts.Filter(aggregates)
+		progress.Printf("Kept %d aggregates in sessions: %s\n", len(aggregates), sessions)
+	}
+
+	// Far-from-the-money strikes are dropped using the underlying's price at each trade
+	var closes map[int64]float64
+	if *maxMoneyness > 0 {
+		progress.Printf("Loading %s prices...\n", *spotVendor)
+		closes, err = loadCloses(aggregates, *spotVendor)
+		if err != nil {
+			log.Fatalf("Failed to load prices: %v", err)
+		}
+		analysis.TagMoneyness(aggregates, closes)
+		aggregates = opts.Filter(aggregates)
+		progress.Printf("Kept %d aggregates within %g%% of spot\n", len(aggregates), *maxMoneyness)
 	}
 
+	if *byExpiration {
+		runByExpiration(aggregates, opts, *output, progress)
+		return
+	}
+	if *byStrike {
+		runByStrike(aggregates, opts, *output, progress)
+		return
+	}
+
+	// Open interest is looked up once for the log's underlying and date, then applied to periods or contracts
+	var openInterest map[string]int64
+	if *oiVendor != "" {
+		progress.Printf("Loading open interest from %s...\n", *oiVendor)
+		if *oiDir == "" {
+			*oiDir = filepath.Join(*logDir, "open-interest")
+		}
+		openInterest, err = loadOpenInterest(aggregates, *oiVendor, *oiDir)
+		if err != nil {
+			log.Fatalf("Failed to load open interest: %v", err)
+		}
+	}
+	if *byContract {
+		runByContract(aggregates, openInterest, *output, progress)
+		return
+	}
+
+	progress.Printf("Aggregating premiums by %d-minute periods...\n", *period)
+
 	// Aggregate premiums
-	summaries, err := analysis.AggregatePremiums(aggregates, *period)
+	summaries, err := analysis.AggregatePremiumsWithOptions(aggregates, opts)
 	if err != nil {
 		log.Fatalf("Failed to aggregate premiums: %v", err)
 	}
 
-	if !*quiet {
-		fmt.Printf("Found %d time periods\n\n", len(summaries))
+	// Greeks need the underlying's price at each trade
+	if *spotVendor != "" {
+		progress.Printf("Computing greeks from %s prices...\n", *spotVendor)
+		if closes == nil {
+			closes, err = loadCloses(aggregates, *spotVendor)
+			if err != nil {
+				log.Fatalf("Failed to compute greeks: %v", err)
+			}
+		}
+		analysis.ApplyGreeks(summaries, aggregates, opts, closes, analysis.GreeksConfig{DefaultIV: *iv, RiskFreeRate: *rate})
 	}
 
-	// Display table only if not quiet
-	if !*quiet {
+	if openInterest != nil {
+		analysis.ApplyOpenInterest(summaries, aggregates, opts, openInterest)
+	}
+
+	progress.Printf("Found %d time periods\n\n", len(summaries))
+
+	// Daily mode reports the day's totals instead of each period
+	if *daily {
+		ticker, dateStr := analysis.DailyKey(aggregates)
+		runDaily(analysis.SummarizeDaily(ticker, dateStr, *period, summaries), *output, progress)
+		return
+	}
+
+	// Empty periods are added after the daily totals, which only count periods with trades
+	if *zeroFill {
+		summaries = analysis.ZeroFill(summaries, opts, time.Time{})
+	}
+
+	// Quiet mode replaces the table with JSON on stdout, unless it is going to a file
+	if !progress.Quiet() {
 		displayTable(summaries)
+		if *spotVendor != "" {
+			fmt.Println()
+			displayGreeksTable(summaries)
+		}
+		if openInterest != nil {
+			fmt.Println()
+			displayOpenInterestTable(summaries)
+		}
+	} else if *output == "" {
+		if err := app.PrintJSON(summaries); err != nil {
+			log.Fatalf("Failed to encode JSON: %v", err)
+		}
 	}
 
 	// Write JSON output if requested
 	if *output != "" {
-		if !*quiet {
-			fmt.Printf("\nWriting results to %s...\n", *output)
-		}
+		progress.Printf("\nWriting results to %s...\n", *output)
 		if err := writeJSONOutput(summaries, *output); err != nil {
 			log.Fatalf("Failed to write JSON output: %v", err)
 		}
-		if !*quiet {
-			fmt.Printf("Successfully wrote results to %s\n", *output)
+		progress.Printf("Successfully wrote results to %s\n", *output)
+	}
+}
+
+// loadCloses fetches the underlying's one-minute closes (keyed by bar start, Unix ms) for the log's date
+// The underlying and date are taken from the first aggregate with a parseable symbol
+func loadCloses(aggregates []analysis.Aggregate, vendor string) (map[int64]float64, error) {
+	apiKey, err := vendorAPIKey(vendor)
+	if err != nil {
+		return nil, err
+	}
+	spot, err := marketdata.NewSpotSource(vendor, apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	closes := make(map[int64]float64)
+	for _, agg := range aggregates {
+		parsed, err := analysis.ParseOptionSymbol(agg.Symbol)
+		if err != nil {
+			continue
+		}
+		date := time.UnixMilli(agg.StartTimestamp).In(market.Location)
+		bars, err := spot.PriceBars(context.Background(), parsed.Underlying, date)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch prices for %s: %w", parsed.Underlying, err)
+		}
+		for _, bar := range bars {
+			closes[bar.Start.UnixMilli()] = bar.Close
 		}
+		break
 	}
+	return closes, nil
+}
+
+// vendorAPIKey validates a market-data vendor and returns the API key it needs (none for the stub vendor)
+func vendorAPIKey(vendor string) (string, error) {
+	if err := marketdata.ValidateVendor(vendor); err != nil {
+		return "", err
+	}
+	if vendor == marketdata.VendorStub {
+		return "", nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return "", fmt.Errorf("failed to load configuration: %w", err)
+	}
+	return cfg.APIKey, nil
+}
+
+// loadOpenInterest returns the open interest snapshot for the log's underlying and date, taken from the first
+// aggregate with a parseable symbol. Snapshots are saved to dir, since the vendor only has the most recent session's
+func loadOpenInterest(aggregates []analysis.Aggregate, vendor string, dir string) (map[string]int64, error) {
+	apiKey, err := vendorAPIKey(vendor)
+	if err != nil {
+		return nil, err
+	}
+	source, err := marketdata.NewOpenInterestSource(vendor, apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	underlying, date := analysis.DailyKey(aggregates)
+	if underlying == "" {
+		return nil, nil
+	}
+	snapshot, err := marketdata.NewOpenInterestCache(source, dir).Get(context.Background(), underlying, date)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.OpenInterest, nil
 }
 
 // readJSONLFile reads a JSONL log file and returns all aggregates
@@ -138,35 +341,27 @@ func formatCurrency(amount float64) string {
 }
 
 // formatRatio formats the call to put ratio
-func formatRatio(ratio float64) string {
-	if ratio < 0 {
+func formatRatio(ratio *float64) string {
+	if ratio == nil {
 		return "N/A" // Infinite ratio (no puts)
 	}
-	if ratio == 0 {
-		return "0.00"
-	}
-	return fmt.Sprintf("%.2f", ratio)
+	return fmt.Sprintf("%.2f", *ratio)
 }
 
 // displayTable displays the premium summary in a formatted table
 func displayTable(summaries []analysis.TimePeriodSummary) {
-	// Load Pacific timezone
-	pacificTZ, err := time.LoadLocation("America/Los_Angeles")
-	if err != nil {
-		log.Fatalf("Failed to load Pacific timezone: %v", err)
-	}
+	loc := market.AnalysisLocation()
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
 
 	// Header
-	fmt.Fprintln(w, "Time Period (PT)\t\tCall Premium\tPut Premium\tTotal Premium\tCall/Put Ratio")
+	fmt.Fprintf(w, "Time Period (%s)\t\tCall Premium\tPut Premium\tTotal Premium\tCall/Put Ratio\n", loc)
 	fmt.Fprintln(w, "-------------------\t\t------------\t-----------\t-------------\t-------------")
 
 	// Rows
 	for _, summary := range summaries {
-		// Convert to Pacific timezone before formatting
-		timeInPacific := summary.PeriodStart.In(pacificTZ)
-		timeStr := timeInPacific.Format("2006-01-02 15:04:05")
+		// Convert to the analysis timezone before formatting
+		timeStr := summary.PeriodStart.In(loc).Format("2006-01-02 15:04:05")
 		callFormatted := formatCurrency(summary.CallPremium)
 		putFormatted := formatCurrency(summary.PutPremium)
 		totalFormatted := formatCurrency(summary.TotalPremium)
@@ -189,6 +384,105 @@ func displayTable(summaries []analysis.TimePeriodSummary) {
 	w.Flush()
 }
 
+// displayGreeksTable displays each period's delta-weighted premium and net gamma
+func displayGreeksTable(summaries []analysis.TimePeriodSummary) {
+	loc := market.AnalysisLocation()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintf(w, "Time Period (%s)\tDelta Call Premium\tDelta Put Premium\tNet Gamma\t\n", loc)
+	fmt.Fprintln(w, "-------------------\t------------------\t-----------------\t---------\t")
+
+	for _, summary := range summaries {
+		if summary.Greeks == nil {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t$%s\t$%s\t%.2f\t\n",
+			summary.PeriodStart.In(loc).Format("2006-01-02 15:04:05"),
+			formatCurrency(summary.Greeks.DeltaCallPremium),
+			formatCurrency(summary.Greeks.DeltaPutPremium),
+			summary.Greeks.NetGamma)
+	}
+
+	w.Flush()
+}
+
+// displayOpenInterestTable displays each period's volume against the open interest of the contracts it traded
+func displayOpenInterestTable(summaries []analysis.TimePeriodSummary) {
+	loc := market.AnalysisLocation()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintf(w, "Time Period (%s)\tCall Vol/OI\tPut Vol/OI\tTop Contract\tVol/OI\t\n", loc)
+	fmt.Fprintln(w, "-------------------\t-----------\t----------\t------------\t------\t")
+
+	for _, summary := range summaries {
+		oi := summary.OpenInterest
+		if oi == nil {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%.2f\t%.2f\t%s\t%.2f\t\n",
+			summary.PeriodStart.In(loc).Format("2006-01-02 15:04:05"),
+			oi.CallVolumeOIRatio,
+			oi.PutVolumeOIRatio,
+			oi.TopContract.Symbol,
+			oi.TopContract.VolumeOIRatio)
+	}
+
+	w.Flush()
+}
+
+// runByContract totals the day per contract, adds open interest when available, and displays or writes the totals
+func runByContract(aggregates []analysis.Aggregate, openInterest map[string]int64, output string, progress *app.Progress) {
+	totals := analysis.TotalByContract(aggregates)
+	if openInterest != nil {
+		analysis.ApplyContractOpenInterest(totals, openInterest)
+	}
+
+	progress.Printf("Found %d contracts\n\n", len(totals))
+	if !progress.Quiet() {
+		displayContractTable(totals, openInterest != nil)
+	} else if output == "" {
+		if err := app.PrintJSON(totals); err != nil {
+			log.Fatalf("Failed to encode JSON: %v", err)
+		}
+	}
+
+	if output != "" {
+		if err := writeJSONValue(totals, output); err != nil {
+			log.Fatalf("Failed to write JSON output: %v", err)
+		}
+		progress.Printf("\nSuccessfully wrote results to %s\n", output)
+	}
+}
+
+// displayContractTable displays per-contract totals, highest premium first, with open interest columns when loaded
+func displayContractTable(totals []analysis.ContractTotal, withOpenInterest bool) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+
+	if withOpenInterest {
+		fmt.Fprintln(w, "Contract\tVolume\tPremium\tOpen Interest\tVol/OI\t")
+		fmt.Fprintln(w, "--------\t------\t-------\t-------------\t------\t")
+	} else {
+		fmt.Fprintln(w, "Contract\tVolume\tPremium\t")
+		fmt.Fprintln(w, "--------\t------\t-------\t")
+	}
+
+	for _, total := range totals {
+		fmt.Fprintf(w, "%s\t%d\t$%s\t", total.Symbol, total.Volume, formatCurrency(total.Premium))
+		if withOpenInterest {
+			if total.OpenInterest > 0 {
+				fmt.Fprintf(w, "%d\t%.2f\t", total.OpenInterest, total.VolumeOIRatio)
+			} else {
+				fmt.Fprint(w, "-\t-\t")
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	w.Flush()
+}
+
 // writeJSONOutput writes the summaries to a JSON file
 func writeJSONOutput(summaries []analysis.TimePeriodSummary, filename string) error {
 	file, err := os.Create(filename)
@@ -201,3 +495,222 @@ func writeJSONOutput(summaries []analysis.TimePeriodSummary, filename string) er
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(summaries)
 }
+
+// runByExpiration aggregates premiums by period and expiration bucket and displays or writes them
+func runByExpiration(aggregates []analysis.Aggregate, opts analysis.AggregateOptions, output string, progress *app.Progress) {
+	progress.Printf("Aggregating premiums by %d-minute periods and expiration...\n", opts.PeriodMinutes)
+
+	summaries, err := analysis.AggregatePremiumsByExpiration(aggregates, opts)
+	if err != nil {
+		log.Fatalf("Failed to aggregate premiums: %v", err)
+	}
+
+	progress.Printf("Found %d time periods\n\n", len(summaries))
+	if !progress.Quiet() {
+		displayExpirationTable(summaries)
+	} else if output == "" {
+		if err := app.PrintJSON(summaries); err != nil {
+			log.Fatalf("Failed to encode JSON: %v", err)
+		}
+	}
+
+	if output != "" {
+		if err := writeJSONValue(summaries, output); err != nil {
+			log.Fatalf("Failed to write JSON output: %v", err)
+		}
+		progress.Printf("\nSuccessfully wrote results to %s\n", output)
+	}
+}
+
+// displayExpirationTable displays each period's call and put premium per expiration bucket
+func displayExpirationTable(summaries []analysis.ExpirationSummary) {
+	loc := market.AnalysisLocation()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintf(w, "Time Period (%s)\t0DTE Call\t0DTE Put\tWeekly Call\tWeekly Put\tMonthly Call\tMonthly Put\tLEAPS Call\tLEAPS Put\t\n", loc)
+	fmt.Fprintln(w, "-------------------\t---------\t--------\t-----------\t----------\t------------\t-----------\t----------\t---------\t")
+
+	for _, summary := range summaries {
+		fmt.Fprintf(w, "%s\t", summary.PeriodStart.In(loc).Format("2006-01-02 15:04:05"))
+		for _, name := range []string{analysis.DTEZero, analysis.DTEWeekly, analysis.DTEMonthly, analysis.DTELeaps} {
+			bucket := summary.Buckets.Bucket(name)
+			fmt.Fprintf(w, "$%s\t$%s\t", formatCurrency(bucket.CallPremium), formatCurrency(bucket.PutPremium))
+		}
+		fmt.Fprintln(w)
+	}
+
+	w.Flush()
+}
+
+// runByStrike builds per-period strike ladders and displays or writes them
+func runByStrike(aggregates []analysis.Aggregate, opts analysis.AggregateOptions, output string, progress *app.Progress) {
+	progress.Printf("Aggregating premiums by %d-minute periods and strike...\n", opts.PeriodMinutes)
+
+	ladders, err := analysis.AggregateByStrike(aggregates, opts)
+	if err != nil {
+		log.Fatalf("Failed to aggregate premiums: %v", err)
+	}
+
+	progress.Printf("Found %d time periods\n\n", len(ladders))
+	if !progress.Quiet() {
+		displayStrikeLadders(ladders)
+	} else if output == "" {
+		if err := app.PrintJSON(ladders); err != nil {
+			log.Fatalf("Failed to encode JSON: %v", err)
+		}
+	}
+
+	if output != "" {
+		if err := writeJSONValue(ladders, output); err != nil {
+			log.Fatalf("Failed to write JSON output: %v", err)
+		}
+		progress.Printf("\nSuccessfully wrote results to %s\n", output)
+	}
+}
+
+// displayStrikeLadders displays one strike table per period
+func displayStrikeLadders(ladders []analysis.StrikeLadder) {
+	loc := market.AnalysisLocation()
+
+	for i, ladder := range ladders {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s\n", ladder.PeriodStart.In(loc).Format("2006-01-02 15:04:05 MST"))
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "Strike\tCall Premium\tCall Volume\tPut Premium\tPut Volume\t")
+		fmt.Fprintln(w, "------\t------------\t-----------\t-----------\t----------\t")
+		for _, level := range ladder.Strikes {
+			fmt.Fprintf(w, "%.2f\t$%s\t%d\t$%s\t%d\t\n",
+				level.Strike,
+				formatCurrency(level.CallPremium),
+				level.CallVolume,
+				formatCurrency(level.PutPremium),
+				level.PutVolume)
+		}
+		w.Flush()
+	}
+}
+
+// runDaily displays or writes a day's cumulative totals
+func runDaily(daily analysis.DailySummary, output string, progress *app.Progress) {
+	if !progress.Quiet() {
+		displayDaily(daily)
+	} else if output == "" {
+		if err := app.PrintJSON(daily); err != nil {
+			log.Fatalf("Failed to encode JSON: %v", err)
+		}
+	}
+
+	if output != "" {
+		if err := writeJSONValue(daily, output); err != nil {
+			log.Fatalf("Failed to write JSON output: %v", err)
+		}
+		progress.Printf("\nSuccessfully wrote results to %s\n", output)
+	}
+}
+
+// displayDaily displays a day's cumulative totals
+func displayDaily(daily analysis.DailySummary) {
+	loc := market.AnalysisLocation()
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "Ticker:\t%s\n", daily.Ticker)
+	fmt.Fprintf(w, "Date:\t%s\n", daily.Date)
+	fmt.Fprintf(w, "Periods with trades:\t%d\n", daily.Periods)
+	fmt.Fprintf(w, "Call Premium:\t$%s\n", formatCurrency(daily.CallPremium))
+	fmt.Fprintf(w, "Put Premium:\t$%s\n", formatCurrency(daily.PutPremium))
+	fmt.Fprintf(w, "Total Premium:\t$%s\n", formatCurrency(daily.TotalPremium))
+	fmt.Fprintf(w, "Call/Put Ratio:\t%s\n", formatRatio(daily.CallPutRatio))
+	fmt.Fprintf(w, "Call Volume:\t%d\n", daily.CallVolume)
+	fmt.Fprintf(w, "Put Volume:\t%d\n", daily.PutVolume)
+	fmt.Fprintf(w, "Unique Contracts:\t%d\n", daily.UniqueContracts)
+	if peak := daily.PeakPeriod; peak != nil {
+		fmt.Fprintf(w, "Peak Period:\t%s - %s (%s), $%s\n",
+			peak.PeriodStart.In(loc).Format("15:04"),
+			peak.PeriodEnd.In(loc).Format("15:04"),
+			peak.Session,
+			formatCurrency(peak.TotalPremium))
+	}
+	if trade := daily.LargestTrade; trade != nil {
+		fmt.Fprintf(w, "Largest Trade:\t%s, $%s (%d contracts)\n", trade.Symbol, formatCurrency(trade.Premium), trade.Volume)
+	}
+	w.Flush()
+}
+
+// runRollup computes daily, weekly, or monthly rollups for a ticker across a log directory
+func runRollup(logDir string, ticker string, from string, to string, granularity string, output string, progress *app.Progress) {
+	if ticker == "" {
+		log.Fatal("Error: --ticker is required with --rollup")
+	}
+	if err := analysis.ValidateGranularity(granularity); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	progress.Printf("Computing %s rollups for %s from %s...\n", granularity, ticker, logDir)
+
+	rollups, err := analysis.RollupLogDir(logDir, ticker, from, to, granularity, func(dateStr string) (analysis.RollupSummary, error) {
+		aggregates, err := readJSONLFile(analysis.LogFilePath(logDir, ticker, dateStr))
+		if err != nil {
+			return analysis.RollupSummary{}, err
+		}
+		return analysis.SummarizeDay(dateStr, aggregates), nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to compute rollups: %v", err)
+	}
+
+	progress.Printf("Found %d %s windows\n\n", len(rollups), granularity)
+	if !progress.Quiet() {
+		displayRollupTable(rollups)
+	} else if output == "" {
+		if err := app.PrintJSON(rollups); err != nil {
+			log.Fatalf("Failed to encode JSON: %v", err)
+		}
+	}
+
+	if output != "" {
+		if err := writeJSONValue(rollups, output); err != nil {
+			log.Fatalf("Failed to write JSON output: %v", err)
+		}
+		progress.Printf("\nSuccessfully wrote results to %s\n", output)
+	}
+}
+
+// displayRollupTable displays rollup totals in a formatted table
+func displayRollupTable(rollups []analysis.RollupSummary) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(w, "Window\tDays\tCall Premium\tPut Premium\tTotal Premium\tCall/Put Ratio\t")
+	fmt.Fprintln(w, "-----------------------\t----\t------------\t-----------\t-------------\t-------------\t")
+
+	for _, rollup := range rollups {
+		window := rollup.StartDate
+		if rollup.EndDate != rollup.StartDate {
+			window = rollup.StartDate + " - " + rollup.EndDate
+		}
+		fmt.Fprintf(w, "%s\t%d\t$%s\t$%s\t$%s\t%s\t\n",
+			window,
+			rollup.Days,
+			formatCurrency(rollup.CallPremium),
+			formatCurrency(rollup.PutPremium),
+			formatCurrency(rollup.TotalPremium),
+			formatRatio(rollup.CallPutRatio))
+	}
+
+	w.Flush()
+}
+
+// writeJSONValue writes any value to a JSON file
+func writeJSONValue(value interface{}, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
@@ -11,17 +12,25 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/ekinolik/jax-ov/internal/app"
 	"github.com/ekinolik/jax-ov/internal/config"
 	"github.com/ekinolik/jax-ov/internal/websocket"
 	"github.com/massive-com/client-go/v2/websocket/models"
 )
 
 func main() {
+	app.SetupLogging("")
+
 	// Parse command-line flags
 	ticker := flag.String("ticker", "", "Underlying stock ticker (required, e.g., AAPL)")
 	mode := flag.String("mode", "all", "Subscription mode: 'all' or 'contract' (default: 'all')")
 	contract := flag.String("contract", "", "Specific option contract symbol (required if mode is 'contract')")
+	quiet := app.QuietFlag(flag.CommandLine)
 	flag.Parse()
+	if err := config.ApplyFlagEnv(flag.CommandLine); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
+	progress := app.NewProgress(*quiet)
 
 	// Validate flags
 	if *ticker == "" {
@@ -73,12 +82,12 @@ func main() {
 	}
 
 	if *mode == "all" {
-		fmt.Printf("Subscribed to: %s (filtering for %s*)\n", subscriptionTicker, filterPrefix)
+		progress.Printf("Subscribed to: %s (filtering for %s*)\n", subscriptionTicker, filterPrefix)
 	} else {
-		fmt.Printf("Subscribed to: %s\n", subscriptionTicker)
+		progress.Printf("Subscribed to: %s\n", subscriptionTicker)
 	}
-	fmt.Println("Streaming options aggregate data... (Press Ctrl+C to stop)")
-	fmt.Println()
+	progress.Println("Streaming options aggregate data... (Press Ctrl+C to stop)")
+	progress.Println()
 
 	// Set up context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -90,10 +99,13 @@ func main() {
 
 	go func() {
 		<-sigChan
-		fmt.Println("\nShutting down...")
+		progress.Println("\nShutting down...")
 		cancel()
 	}()
 
+	// Quiet mode prints one JSON aggregate per line, the same format the logger writes
+	encoder := json.NewEncoder(os.Stdout)
+
 	// Define handler for incoming messages
 	handler := func(agg models.EquityAgg) {
 		// Filter by ticker prefix if mode is "all"
@@ -101,6 +113,12 @@ func main() {
 			return // Skip this message, it doesn't match our filter
 		}
 
+		if progress.Quiet() {
+			if err := encoder.Encode(agg); err != nil {
+				log.Printf("Error encoding aggregate: %v", err)
+			}
+			return
+		}
 		printAggregate(agg)
 	}
 
@@ -8,19 +8,32 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 	"time"
 
 	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/app"
+	"github.com/ekinolik/jax-ov/internal/config"
+	"github.com/ekinolik/jax-ov/internal/market"
 )
 
 func main() {
+	app.SetupLogging("")
+
 	// Parse command-line flags
 	logDir := flag.String("log-dir", "", "Log directory path (required)")
 	percentileFlag := flag.Float64("percentile", 90.0, "Percentile to use for outlier detection (0-100, default: 90.0)")
 	multipleFlag := flag.Float64("multiple", 10.0, "Multiple of percentile to use as outlier threshold (default: 10.0)")
+	quiet := app.QuietFlag(flag.CommandLine)
+	timezone := app.TimezoneFlag(flag.CommandLine)
 	flag.Parse()
+	if err := config.ApplyFlagEnv(flag.CommandLine); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
+	if err := market.SetAnalysisTimezone(*timezone); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	progress := app.NewProgress(*quiet)
 
 	// Validate flags
 	if *logDir == "" {
@@ -45,6 +58,7 @@ func main() {
 	}
 
 	headerPrinted := false
+	encoder := json.NewEncoder(os.Stdout)
 
 	// Process each file
 	for _, file := range files {
@@ -63,6 +77,16 @@ func main() {
 		// Read and process the file, printing findings as they're found
 		findings := processFile(filePath, ticker, percentileValue, *multipleFlag)
 
+		// Quiet mode prints one JSON finding per line instead of the table
+		if progress.Quiet() {
+			for _, finding := range findings {
+				if err := encoder.Encode(finding); err != nil {
+					log.Fatalf("Failed to encode JSON: %v", err)
+				}
+			}
+			continue
+		}
+
 		// Print header only once, when we have our first finding
 		if len(findings) > 0 && !headerPrinted {
 			printFindingsHeader()
@@ -78,15 +102,15 @@ func main() {
 
 // Finding represents an outlier transaction finding
 type Finding struct {
-	Ticker     string
-	Type       string // "CALL" or "PUT"
-	Expiration string // "YYYY-MM-DD"
-	Strike     string // Formatted strike price
-	Premium    float64
-	Volume     int64
-	Date       string // "YYYY-MM-DD"
-	Time       string // "HH:MM:SS"
-	Multiple   float64
+	Ticker     string  `json:"ticker"`
+	Type       string  `json:"type"`       // "CALL" or "PUT"
+	Expiration string  `json:"expiration"` // "YYYY-MM-DD"
+	Strike     string  `json:"strike"`     // Formatted strike price
+	Premium    float64 `json:"premium"`
+	Volume     int64   `json:"volume"`
+	Date       string  `json:"date"` // "YYYY-MM-DD"
+	Time       string  `json:"time"` // "HH:MM:SS"
+	Multiple   float64 `json:"multiple"`
 }
 
 // extractTickerFromFilename extracts the ticker from a filename like "AAPL_2025-12-06.jsonl"
@@ -116,9 +140,9 @@ func processFile(filePath, ticker string, percentileValue, multiple float64) []F
 		return nil
 	}
 
-	// Separate call and put transactions with premiums
-	var callPremiums []float64
-	var putPremiums []float64
+	// Separate call and put transactions, tracking each side's premium distribution
+	callDigest := analysis.NewTDigest(analysis.DefaultCompression)
+	putDigest := analysis.NewTDigest(analysis.DefaultCompression)
 	var callTransactions []TransactionWithPremium
 	var putTransactions []TransactionWithPremium
 
@@ -139,10 +163,10 @@ func processFile(filePath, ticker string, percentileValue, multiple float64) []F
 		}
 
 		if optionType == "call" {
-			callPremiums = append(callPremiums, premium)
+			callDigest.Add(premium)
 			callTransactions = append(callTransactions, tx)
 		} else if optionType == "put" {
-			putPremiums = append(putPremiums, premium)
+			putDigest.Add(premium)
 			putTransactions = append(putTransactions, tx)
 		}
 	}
@@ -150,15 +174,15 @@ func processFile(filePath, ticker string, percentileValue, multiple float64) []F
 	var findings []Finding
 
 	// Calculate percentile and find outliers for calls
-	if len(callPremiums) > 0 {
-		callP := calculatePercentile(callPremiums, percentileValue)
+	if callDigest.Count() > 0 {
+		callP := callDigest.Quantile(percentileValue)
 		callOutliers := findOutliers(callTransactions, callP, multiple)
 		findings = append(findings, convertToFindings(callOutliers, ticker, callP)...)
 	}
 
 	// Calculate percentile and find outliers for puts
-	if len(putPremiums) > 0 {
-		putP := calculatePercentile(putPremiums, percentileValue)
+	if putDigest.Count() > 0 {
+		putP := putDigest.Quantile(percentileValue)
 		putOutliers := findOutliers(putTransactions, putP, multiple)
 		findings = append(findings, convertToFindings(putOutliers, ticker, putP)...)
 	}
@@ -199,38 +223,6 @@ func readJSONLFile(filename string) ([]analysis.Aggregate, error) {
 	return aggregates, nil
 }
 
-// calculatePercentile calculates a single percentile value for a slice of premiums
-func calculatePercentile(premiums []float64, p float64) float64 {
-	if len(premiums) == 0 {
-		return 0
-	}
-
-	// Create a copy and sort
-	sorted := make([]float64, len(premiums))
-	copy(sorted, premiums)
-	sort.Float64s(sorted)
-
-	return percentile(sorted, p)
-}
-
-// percentile calculates the value at the given percentile (0.0 to 1.0)
-func percentile(sorted []float64, p float64) float64 {
-	if len(sorted) == 0 {
-		return 0
-	}
-
-	index := p * float64(len(sorted)-1)
-	lower := int(index)
-	upper := lower + 1
-
-	if upper >= len(sorted) {
-		return sorted[len(sorted)-1]
-	}
-
-	weight := index - float64(lower)
-	return sorted[lower]*(1-weight) + sorted[upper]*weight
-}
-
 // findOutliers finds transactions where premium is >= multiplier times the threshold value
 func findOutliers(transactions []TransactionWithPremium, threshold float64, multiplier float64) []TransactionWithPremium {
 	if threshold == 0 {
@@ -249,114 +241,20 @@ func findOutliers(transactions []TransactionWithPremium, threshold float64, mult
 	return outliers
 }
 
-// OptionDetails holds parsed option contract details
-type OptionDetails struct {
-	Type       string // "CALL" or "PUT"
-	Expiration string // "YYYY-MM-DD"
-	Strike     string // Formatted strike price
-}
-
-// parseOptionSymbol parses an option contract symbol into its components
-// Format: O:{UNDERLYING}{EXPIRATION}{C|P}{STRIKE}
-// Example: O:AAPL230616C00150000 -> CALL, 2023-06-16, 150.00
-func parseOptionSymbol(symbol string) (OptionDetails, error) {
-	// Remove "O:" prefix if present
-	symbol = strings.TrimPrefix(symbol, "O:")
-
-	if len(symbol) < 7 {
-		return OptionDetails{}, fmt.Errorf("invalid symbol format: %s", symbol)
-	}
-
-	// Find the C or P that indicates call/put
-	var callPutIndex int = -1
-	var optionType string
-
-	for i := len(symbol) - 1; i >= 0; i-- {
-		if symbol[i] == 'C' {
-			if i+1 < len(symbol) && symbol[i+1] >= '0' && symbol[i+1] <= '9' {
-				callPutIndex = i
-				optionType = "CALL"
-				break
-			}
-		}
-		if symbol[i] == 'P' {
-			if i+1 < len(symbol) && symbol[i+1] >= '0' && symbol[i+1] <= '9' {
-				callPutIndex = i
-				optionType = "PUT"
-				break
-			}
-		}
-	}
-
-	if callPutIndex == -1 {
-		return OptionDetails{}, fmt.Errorf("could not find call/put indicator in: %s", symbol)
-	}
-
-	// Extract components
-	// Everything before callPutIndex-6 is the underlying (expiration is 6 digits: YYMMDD)
-	expirationStart := callPutIndex - 6
-	if expirationStart < 0 {
-		return OptionDetails{}, fmt.Errorf("invalid symbol format: %s", symbol)
-	}
-
-	expirationStr := symbol[expirationStart:callPutIndex]
-	strikeStr := symbol[callPutIndex+1:]
-
-	// Parse expiration (YYMMDD -> YYYY-MM-DD)
-	if len(expirationStr) != 6 {
-		return OptionDetails{}, fmt.Errorf("invalid expiration format: %s", expirationStr)
-	}
-
-	year := "20" + expirationStr[0:2]
-	month := expirationStr[2:4]
-	day := expirationStr[4:6]
-	expiration := fmt.Sprintf("%s-%s-%s", year, month, day)
-
-	// Parse strike (option strikes are stored with last 3 digits as decimal part)
-	// Example: "00150000" -> 150.000, "220500" -> 220.500
-	strike := strings.TrimLeft(strikeStr, "0")
-	if strike == "" {
-		strike = "0"
-	}
-
-	// Pad with zeros to ensure we have at least 3 digits for decimal part
-	for len(strike) < 3 {
-		strike = "0" + strike
-	}
-
-	// Insert decimal point 3 digits from the right
-	strike = strike[:len(strike)-3] + "." + strike[len(strike)-3:]
-
-	// Ensure exactly 3 decimal places
-	parts := strings.Split(strike, ".")
-	if len(parts) == 2 {
-		for len(parts[1]) < 3 {
-			parts[1] += "0"
-		}
-		strike = parts[0] + "." + parts[1]
-	}
-
-	return OptionDetails{
-		Type:       optionType,
-		Expiration: expiration,
-		Strike:     strike,
-	}, nil
-}
-
 // convertToFindings converts transactions to Finding structs
 func convertToFindings(transactions []TransactionWithPremium, ticker string, threshold float64) []Finding {
 	var findings []Finding
 
 	for _, tx := range transactions {
 		// Parse option symbol
-		details, err := parseOptionSymbol(tx.Aggregate.Symbol)
+		contract, err := analysis.ParseOptionSymbol(tx.Aggregate.Symbol)
 		if err != nil {
 			// Skip if we can't parse
 			continue
 		}
 
 		// Extract date and time from timestamp
-		timestamp := time.Unix(0, tx.Aggregate.StartTimestamp*int64(time.Millisecond))
+		timestamp := time.Unix(0, tx.Aggregate.StartTimestamp*int64(time.Millisecond)).In(market.AnalysisLocation())
 		date := timestamp.Format("2006-01-02")
 		timeStr := timestamp.Format("15:04:05")
 
@@ -365,9 +263,9 @@ func convertToFindings(transactions []TransactionWithPremium, ticker string, thr
 
 		findings = append(findings, Finding{
 			Ticker:     ticker,
-			Type:       details.Type,
-			Expiration: details.Expiration,
-			Strike:     details.Strike,
+			Type:       strings.ToUpper(contract.Type),
+			Expiration: contract.ExpirationDate(),
+			Strike:     fmt.Sprintf("%.3f", contract.Strike),
 			Premium:    tx.Premium,
 			Volume:     tx.Aggregate.Volume,
 			Date:       date,
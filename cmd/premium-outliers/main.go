@@ -12,14 +12,28 @@ import (
 	"time"
 
 	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/app"
+	"github.com/ekinolik/jax-ov/internal/config"
+	"github.com/ekinolik/jax-ov/internal/market"
 )
 
 func main() {
+	app.SetupLogging("")
+
 	// Parse command-line flags
 	input := flag.String("input", "", "Input JSONL log file path (required)")
 	percentileFlag := flag.Float64("percentile", 90.0, "Percentile to use for outlier detection (0-100, default: 90.0)")
 	multipleFlag := flag.Float64("multiple", 10.0, "Multiple of percentile to use as outlier threshold (default: 10.0)")
+	quiet := app.QuietFlag(flag.CommandLine)
+	timezone := app.TimezoneFlag(flag.CommandLine)
 	flag.Parse()
+	if err := config.ApplyFlagEnv(flag.CommandLine); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
+	if err := market.SetAnalysisTimezone(*timezone); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	progress := app.NewProgress(*quiet)
 
 	// Validate flags
 	if *input == "" {
@@ -38,17 +52,17 @@ func main() {
 	percentileValue := *percentileFlag / 100.0
 
 	// Read JSONL file
-	fmt.Printf("Reading log file: %s\n", *input)
+	progress.Printf("Reading log file: %s\n", *input)
 	aggregates, err := readJSONLFile(*input)
 	if err != nil {
 		log.Fatalf("Failed to read log file: %v", err)
 	}
 
-	fmt.Printf("Loaded %d aggregates\n", len(aggregates))
+	progress.Printf("Loaded %d aggregates\n", len(aggregates))
 
-	// Separate call and put transactions with premiums
-	var callPremiums []float64
-	var putPremiums []float64
+	// Separate call and put transactions, tracking each side's premium distribution
+	callDigest := analysis.NewTDigest(analysis.DefaultCompression)
+	putDigest := analysis.NewTDigest(analysis.DefaultCompression)
 	var callTransactions []TransactionWithPremium
 	var putTransactions []TransactionWithPremium
 
@@ -69,21 +83,37 @@ func main() {
 		}
 
 		if optionType == "call" {
-			callPremiums = append(callPremiums, premium)
+			callDigest.Add(premium)
 			callTransactions = append(callTransactions, tx)
 		} else if optionType == "put" {
-			putPremiums = append(putPremiums, premium)
+			putDigest.Add(premium)
 			putTransactions = append(putTransactions, tx)
 		}
 	}
 
 	// Calculate standard percentiles (p25, p50, p75, p90, p99)
-	callP25, callP50, callP75, callP90, callP99 := calculatePercentiles(callPremiums)
-	putP25, putP50, putP75, putP90, putP99 := calculatePercentiles(putPremiums)
+	callP25, callP50, callP75, callP90, callP99 := calculatePercentiles(callDigest)
+	putP25, putP50, putP75, putP90, putP99 := calculatePercentiles(putDigest)
 
 	// Calculate the requested percentile for outlier detection
-	callRequestedP := calculatePercentile(callPremiums, percentileValue)
-	putRequestedP := calculatePercentile(putPremiums, percentileValue)
+	callRequestedP := callDigest.Quantile(percentileValue)
+	putRequestedP := putDigest.Quantile(percentileValue)
+
+	// Quiet mode prints the statistics and outliers as JSON instead
+	if progress.Quiet() {
+		report := OutlierReport{
+			Percentile:   *percentileFlag,
+			Multiple:     *multipleFlag,
+			Calls:        PremiumStats{P25: callP25, P50: callP50, P75: callP75, P90: callP90, P99: callP99, Requested: callRequestedP, Transactions: callDigest.Count()},
+			Puts:         PremiumStats{P25: putP25, P50: putP50, P75: putP75, P90: putP90, P99: putP99, Requested: putRequestedP, Transactions: putDigest.Count()},
+			CallOutliers: append([]TransactionWithPremium{}, findOutliers(callTransactions, callRequestedP, *multipleFlag)...),
+			PutOutliers:  append([]TransactionWithPremium{}, findOutliers(putTransactions, putRequestedP, *multipleFlag)...),
+		}
+		if err := app.PrintJSON(report); err != nil {
+			log.Fatalf("Failed to encode JSON: %v", err)
+		}
+		return
+	}
 
 	// Print statistics
 	fmt.Printf("\n=== Premium Statistics ===\n")
@@ -94,7 +124,7 @@ func main() {
 	fmt.Printf("  P90: $%s\n", formatCurrency(callP90))
 	fmt.Printf("  P99: $%s\n", formatCurrency(callP99))
 	fmt.Printf("  P%.1f: $%s\n", *percentileFlag, formatCurrency(callRequestedP))
-	fmt.Printf("  Total Transactions: %d\n", len(callPremiums))
+	fmt.Printf("  Total Transactions: %d\n", callDigest.Count())
 
 	fmt.Printf("\nPut Premiums:\n")
 	fmt.Printf("  P25: $%s\n", formatCurrency(putP25))
@@ -103,7 +133,7 @@ func main() {
 	fmt.Printf("  P90: $%s\n", formatCurrency(putP90))
 	fmt.Printf("  P99: $%s\n", formatCurrency(putP99))
 	fmt.Printf("  P%.1f: $%s\n", *percentileFlag, formatCurrency(putRequestedP))
-	fmt.Printf("  Total Transactions: %d\n", len(putPremiums))
+	fmt.Printf("  Total Transactions: %d\n", putDigest.Count())
 
 	// Find outliers using requested percentile and multiple
 	fmt.Printf("\n=== Outliers (%.1fx P%.1f) ===\n", *multipleFlag, *percentileFlag)
@@ -127,8 +157,29 @@ func main() {
 
 // TransactionWithPremium holds an aggregate transaction with its calculated premium
 type TransactionWithPremium struct {
-	Aggregate analysis.Aggregate
-	Premium   float64
+	Aggregate analysis.Aggregate `json:"aggregate"`
+	Premium   float64            `json:"premium"`
+}
+
+// PremiumStats holds the premium percentiles for one option type
+type PremiumStats struct {
+	P25          float64 `json:"p25"`
+	P50          float64 `json:"p50"`
+	P75          float64 `json:"p75"`
+	P90          float64 `json:"p90"`
+	P99          float64 `json:"p99"`
+	Requested    float64 `json:"requested"` // The --percentile value
+	Transactions int     `json:"transactions"`
+}
+
+// OutlierReport is the --quiet output: premium statistics and outliers for calls and puts
+type OutlierReport struct {
+	Percentile   float64                  `json:"percentile"`
+	Multiple     float64                  `json:"multiple"`
+	Calls        PremiumStats             `json:"calls"`
+	Puts         PremiumStats             `json:"puts"`
+	CallOutliers []TransactionWithPremium `json:"call_outliers"`
+	PutOutliers  []TransactionWithPremium `json:"put_outliers"`
 }
 
 // readJSONLFile reads a JSONL log file and returns all aggregates
@@ -158,57 +209,9 @@ func readJSONLFile(filename string) ([]analysis.Aggregate, error) {
 	return aggregates, nil
 }
 
-// calculatePercentiles calculates p25, p50 (median), p75, p90, and p99 for a slice of premiums
-func calculatePercentiles(premiums []float64) (p25, p50, p75, p90, p99 float64) {
-	if len(premiums) == 0 {
-		return 0, 0, 0, 0, 0
-	}
-
-	// Create a copy and sort
-	sorted := make([]float64, len(premiums))
-	copy(sorted, premiums)
-	sort.Float64s(sorted)
-
-	// Calculate percentiles
-	p25 = percentile(sorted, 0.25)
-	p50 = percentile(sorted, 0.50)
-	p75 = percentile(sorted, 0.75)
-	p90 = percentile(sorted, 0.90)
-	p99 = percentile(sorted, 0.99)
-
-	return p25, p50, p75, p90, p99
-}
-
-// percentile calculates the value at the given percentile (0.0 to 1.0)
-func percentile(sorted []float64, p float64) float64 {
-	if len(sorted) == 0 {
-		return 0
-	}
-
-	index := p * float64(len(sorted)-1)
-	lower := int(index)
-	upper := lower + 1
-
-	if upper >= len(sorted) {
-		return sorted[len(sorted)-1]
-	}
-
-	weight := index - float64(lower)
-	return sorted[lower]*(1-weight) + sorted[upper]*weight
-}
-
-// calculatePercentile calculates a single percentile value for a slice of premiums
-func calculatePercentile(premiums []float64, p float64) float64 {
-	if len(premiums) == 0 {
-		return 0
-	}
-
-	// Create a copy and sort
-	sorted := make([]float64, len(premiums))
-	copy(sorted, premiums)
-	sort.Float64s(sorted)
-
-	return percentile(sorted, p)
+// calculatePercentiles returns p25, p50 (median), p75, p90, and p99 of a premium distribution
+func calculatePercentiles(digest *analysis.TDigest) (p25, p50, p75, p90, p99 float64) {
+	return digest.Quantile(0.25), digest.Quantile(0.50), digest.Quantile(0.75), digest.Quantile(0.90), digest.Quantile(0.99)
 }
 
 // findOutliers finds transactions where premium is >= multiplier times the threshold value
@@ -229,100 +232,6 @@ func findOutliers(transactions []TransactionWithPremium, threshold float64, mult
 	return outliers
 }
 
-// OptionDetails holds parsed option contract details
-type OptionDetails struct {
-	Type       string // "CALL" or "PUT"
-	Expiration string // "YYYY-MM-DD"
-	Strike     string // Formatted strike price
-}
-
-// parseOptionSymbol parses an option contract symbol into its components
-// Format: O:{UNDERLYING}{EXPIRATION}{C|P}{STRIKE}
-// Example: O:AAPL230616C00150000 -> CALL, 2023-06-16, 150.00
-func parseOptionSymbol(symbol string) (OptionDetails, error) {
-	// Remove "O:" prefix if present
-	symbol = strings.TrimPrefix(symbol, "O:")
-
-	if len(symbol) < 7 {
-		return OptionDetails{}, fmt.Errorf("invalid symbol format: %s", symbol)
-	}
-
-	// Find the C or P that indicates call/put
-	var callPutIndex int = -1
-	var optionType string
-
-	for i := len(symbol) - 1; i >= 0; i-- {
-		if symbol[i] == 'C' {
-			if i+1 < len(symbol) && symbol[i+1] >= '0' && symbol[i+1] <= '9' {
-				callPutIndex = i
-				optionType = "CALL"
-				break
-			}
-		}
-		if symbol[i] == 'P' {
-			if i+1 < len(symbol) && symbol[i+1] >= '0' && symbol[i+1] <= '9' {
-				callPutIndex = i
-				optionType = "PUT"
-				break
-			}
-		}
-	}
-
-	if callPutIndex == -1 {
-		return OptionDetails{}, fmt.Errorf("could not find call/put indicator in: %s", symbol)
-	}
-
-	// Extract components
-	// Everything before callPutIndex-6 is the underlying (expiration is 6 digits: YYMMDD)
-	expirationStart := callPutIndex - 6
-	if expirationStart < 0 {
-		return OptionDetails{}, fmt.Errorf("invalid symbol format: %s", symbol)
-	}
-
-	expirationStr := symbol[expirationStart:callPutIndex]
-	strikeStr := symbol[callPutIndex+1:]
-
-	// Parse expiration (YYMMDD -> YYYY-MM-DD)
-	if len(expirationStr) != 6 {
-		return OptionDetails{}, fmt.Errorf("invalid expiration format: %s", expirationStr)
-	}
-
-	year := "20" + expirationStr[0:2]
-	month := expirationStr[2:4]
-	day := expirationStr[4:6]
-	expiration := fmt.Sprintf("%s-%s-%s", year, month, day)
-
-	// Parse strike (option strikes are stored with last 3 digits as decimal part)
-	// Example: "00150000" -> 150.000, "220500" -> 220.500
-	strike := strings.TrimLeft(strikeStr, "0")
-	if strike == "" {
-		strike = "0"
-	}
-
-	// Pad with zeros to ensure we have at least 3 digits for decimal part
-	for len(strike) < 3 {
-		strike = "0" + strike
-	}
-
-	// Insert decimal point 3 digits from the right
-	strike = strike[:len(strike)-3] + "." + strike[len(strike)-3:]
-
-	// Ensure exactly 3 decimal places
-	parts := strings.Split(strike, ".")
-	if len(parts) == 2 {
-		for len(parts[1]) < 3 {
-			parts[1] += "0"
-		}
-		strike = parts[0] + "." + parts[1]
-	}
-
-	return OptionDetails{
-		Type:       optionType,
-		Expiration: expiration,
-		Strike:     strike,
-	}, nil
-}
-
 // printOutliers prints outlier transactions in a formatted table
 func printOutliers(outliers []TransactionWithPremium, threshold float64) {
 	// Sort by premium descending
@@ -336,11 +245,11 @@ func printOutliers(outliers []TransactionWithPremium, threshold float64) {
 
 	for _, tx := range outliers {
 		multiple := tx.Premium / threshold
-		timestamp := time.Unix(0, tx.Aggregate.StartTimestamp*int64(time.Millisecond))
+		timestamp := time.Unix(0, tx.Aggregate.StartTimestamp*int64(time.Millisecond)).In(market.AnalysisLocation())
 		timeStr := timestamp.Format("15:04:05")
 
 		// Parse option symbol
-		details, err := parseOptionSymbol(tx.Aggregate.Symbol)
+		contract, err := analysis.ParseOptionSymbol(tx.Aggregate.Symbol)
 		if err != nil {
 			// If parsing fails, fall back to showing the raw symbol
 			fmt.Printf("  %-6s %-12s %-12s %-15s %-12d %-10.2f %-12s %-10.2fx\n",
@@ -356,9 +265,9 @@ func printOutliers(outliers []TransactionWithPremium, threshold float64) {
 		}
 
 		fmt.Printf("  %-6s %-12s %-12s %-15s %-12d %-10.2f %-12s %-10.2fx\n",
-			details.Type,
-			details.Expiration,
-			details.Strike,
+			strings.ToUpper(contract.Type),
+			contract.ExpirationDate(),
+			fmt.Sprintf("%.3f", contract.Strike),
 			"$"+formatCurrency(tx.Premium),
 			tx.Aggregate.Volume,
 			tx.Aggregate.VWAP,
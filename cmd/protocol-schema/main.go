@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ekinolik/jax-ov/internal/app"
+	"github.com/ekinolik/jax-ov/internal/config"
+	"github.com/ekinolik/jax-ov/internal/doccomments"
+	"github.com/ekinolik/jax-ov/internal/server"
+)
+
+func main() {
+	app.SetupLogging("")
+
+	// Parse command-line flags
+	output := flag.String("output", "analyze.schema.json", "Output JSON Schema file path (default: analyze.schema.json)")
+	source := flag.String("source", "", "Comma-separated Go package directories whose doc comments become schema descriptions (default: none)")
+	quiet := app.QuietFlag(flag.CommandLine)
+	flag.Parse()
+	if err := config.ApplyFlagEnv(flag.CommandLine); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
+	progress := app.NewProgress(*quiet)
+
+	var comments map[string]string
+	if *source != "" {
+		var err error
+		comments, err = doccomments.Read(strings.Split(*source, ",")...)
+		if err != nil {
+			log.Fatalf("Failed to read doc comments: %v", err)
+		}
+	}
+
+	schema, err := server.ProtocolSchema(comments)
+	if err != nil {
+		log.Fatalf("Failed to encode schema: %v", err)
+	}
+	if err := os.WriteFile(*output, schema, 0644); err != nil {
+		log.Fatalf("Failed to write output file: %v", err)
+	}
+	progress.Printf("Saved /analyze protocol schema to: %s\n", *output)
+}
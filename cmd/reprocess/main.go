@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/app"
+	"github.com/ekinolik/jax-ov/internal/config"
+	"github.com/ekinolik/jax-ov/internal/market"
+	"github.com/ekinolik/jax-ov/internal/marketdata"
+	"github.com/ekinolik/jax-ov/internal/server"
+)
+
+// Result of reprocessing one daily file
+const (
+	StatusWritten = "written"
+	StatusCurrent = "current" // Sidecar already up to date, skipped
+	StatusEmpty   = "empty"   // No aggregates in the file
+	StatusFailed  = "failed"
+)
+
+// DayResult reports what happened to one daily log file
+type DayResult struct {
+	Ticker    string `json:"ticker"`
+	Date      string `json:"date"`
+	Status    string `json:"status"`
+	Sidecar   string `json:"sidecar,omitempty"`
+	Summaries int    `json:"summaries"`
+	Error     string `json:"error,omitempty"`
+}
+
+// dayFile is a daily log file found in the archive
+type dayFile struct {
+	ticker string
+	date   string
+	path   string
+}
+
+func main() {
+	app.SetupLogging("")
+
+	// Parse command-line flags
+	logDir := flag.String("log-dir", "./logs", "Log directory (archive of TICKER_YYYY-MM-DD.jsonl files) to reprocess (default: ./logs)")
+	outputDir := flag.String("output-dir", "", "Directory to write summary sidecars to (default: alongside the log files)")
+	tickers := flag.String("tickers", "", "Comma-separated tickers to reprocess (default: all tickers in the log directory)")
+	from := flag.String("from", "", "First date to reprocess (YYYY-MM-DD, optional)")
+	to := flag.String("to", "", "Last date to reprocess (YYYY-MM-DD, optional)")
+	period := flag.Int("period", 5, "Time period in minutes (default: 5)")
+	anchor := flag.String("anchor", analysis.AnchorMidnight, "Period boundary anchor: midnight or open (default: midnight)")
+	session := app.SessionFlag(flag.CommandLine)
+	force := flag.Bool("force", false, "Rewrite sidecars even when they are already current for this summary version and log file")
+	spotVendor := flag.String("spot-vendor", "", "Market-data vendor for underlying prices, massive or stub; adds greeks to each period (default: disabled)")
+	iv := flag.Float64("iv", analysis.DefaultIV, "Fallback implied volatility for greeks when it can't be solved from a contract's price (default: 0.30)")
+	rate := flag.Float64("rate", analysis.DefaultRiskFreeRate, "Annualized risk-free rate for greeks (default: 0.04)")
+	quiet := app.QuietFlag(flag.CommandLine)
+	timezone := app.TimezoneFlag(flag.CommandLine)
+	metrics := app.MetricsFlag(flag.CommandLine)
+	flag.Parse()
+	if err := config.ApplyFlagEnv(flag.CommandLine); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
+	if err := market.SetAnalysisTimezone(*timezone); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if err := analysis.EnablePeriodMetrics(*metrics); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	progress := app.NewProgress(*quiet)
+
+	// Validate flags
+	if *period <= 0 {
+		log.Fatal("Error: --period must be greater than 0")
+	}
+	sessions, err := market.ParseSessionSet(*session)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if err := analysis.ValidateAnchor(*anchor); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	for _, date := range []string{*from, *to} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			log.Fatalf("Error: invalid date %q, expected YYYY-MM-DD", date)
+		}
+	}
+	if *outputDir == "" {
+		*outputDir = *logDir
+	} else if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		log.Fatalf("Failed to create output directory: %v", err)
+	}
+
+	var spot marketdata.SpotSource
+	if *spotVendor != "" {
+		var err error
+		spot, err = newSpotSource(*spotVendor)
+		if err != nil {
+			log.Fatalf("Failed to create spot price source: %v", err)
+		}
+	}
+
+	days, err := findDays(*logDir, app.ParseTickers(*tickers), *from, *to)
+	if err != nil {
+		log.Fatalf("Failed to list log files: %v", err)
+	}
+
+	opts := analysis.AggregateOptions{PeriodMinutes: *period, Anchor: *anchor, Sessions: sessions}
+	greeksCfg := analysis.GreeksConfig{DefaultIV: *iv, RiskFreeRate: *rate}
+	progress.Printf("Reprocessing %d daily files with summary version %d...\n", len(days), analysis.SummaryVersion)
+
+	var results []DayResult
+	counts := make(map[string]int)
+	for _, day := range days {
+		result := reprocessDay(day, *outputDir, opts, *force, spot, greeksCfg)
+		results = append(results, result)
+		counts[result.Status]++
+
+		switch result.Status {
+		case StatusFailed:
+			log.Printf("Failed to reprocess %s %s: %s", day.ticker, day.date, result.Error)
+		case StatusWritten:
+			progress.Printf("  %s %s: %d periods -> %s\n", day.ticker, day.date, result.Summaries, result.Sidecar)
+		}
+	}
+
+	progress.Printf("\nWritten: %d, already current: %d, empty: %d, failed: %d\n",
+		counts[StatusWritten], counts[StatusCurrent], counts[StatusEmpty], counts[StatusFailed])
+	if progress.Quiet() {
+		if err := app.PrintJSON(results); err != nil {
+			log.Fatalf("Failed to encode JSON: %v", err)
+		}
+	}
+	if counts[StatusFailed] > 0 {
+		os.Exit(1)
+	}
+}
+
+// newSpotSource creates the underlying price source for greeks, loading the API key for real vendors
+func newSpotSource(vendor string) (marketdata.SpotSource, error) {
+	if err := marketdata.ValidateVendor(vendor); err != nil {
+		return nil, err
+	}
+	var apiKey string
+	if vendor != marketdata.VendorStub {
+		cfg, err := config.Load()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load configuration: %w", err)
+		}
+		apiKey = cfg.APIKey
+	}
+	return marketdata.NewSpotSource(vendor, apiKey)
+}
+
+// findDays lists the daily log files in logDir (TICKER_YYYY-MM-DD.jsonl) for the given tickers and date range,
+// sorted by ticker then date
+func findDays(logDir string, tickers map[string]bool, from string, to string) ([]dayFile, error) {
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log directory: %w", err)
+	}
+
+	var days []dayFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+
+		// Format: TICKER_YYYY-MM-DD.jsonl
+		separator := strings.LastIndex(name, "_")
+		if separator <= 0 {
+			continue
+		}
+		ticker := name[:separator]
+		dateStr := strings.TrimSuffix(name[separator+1:], ".jsonl")
+		if _, err := time.Parse("2006-01-02", dateStr); err != nil {
+			continue
+		}
+		if tickers != nil && !tickers[ticker] {
+			continue
+		}
+		if (from != "" && dateStr < from) || (to != "" && dateStr > to) {
+			continue
+		}
+
+		days = append(days, dayFile{ticker: ticker, date: dateStr, path: filepath.Join(logDir, name)})
+	}
+
+	sort.Slice(days, func(i, j int) bool {
+		if days[i].ticker != days[j].ticker {
+			return days[i].ticker < days[j].ticker
+		}
+		return days[i].date < days[j].date
+	})
+	return days, nil
+}
+
+// reprocessDay runs the current analysis over one daily log file and writes its summary sidecar
+// The file is skipped when its sidecar is already current, unless force is set
+func reprocessDay(day dayFile, outputDir string, opts analysis.AggregateOptions, force bool, spot marketdata.SpotSource, greeksCfg analysis.GreeksConfig) DayResult {
+	result := DayResult{Ticker: day.ticker, Date: day.date}
+	sidecarPath := filepath.Join(outputDir, analysis.SidecarFileName(day.ticker, day.date, opts))
+	fail := func(err error) DayResult {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	info, err := os.Stat(day.path)
+	if err != nil {
+		return fail(err)
+	}
+	if !force {
+		if existing, err := analysis.ReadSidecar(sidecarPath); err == nil && existing.Current(info.Size(), info.ModTime()) && existing.Greeks == (spot != nil) && slices.Equal(existing.Metrics, analysis.EnabledPeriodMetrics()) {
+			result.Status = StatusCurrent
+			result.Sidecar = sidecarPath
+			result.Summaries = len(existing.Summaries)
+			return result
+		}
+	}
+
+	aggregates, err := server.ReadLogFile(day.path)
+	if err != nil {
+		return fail(err)
+	}
+	if len(aggregates) == 0 {
+		result.Status = StatusEmpty
+		return result
+	}
+
+	summaries, err := analysis.AggregatePremiumsWithOptions(aggregates, opts)
+	if err != nil {
+		return fail(fmt.Errorf("failed to aggregate premiums: %w", err))
+	}
+	if spot != nil {
+		if err := applyGreeks(summaries, aggregates, opts, day, spot, greeksCfg); err != nil {
+			return fail(err)
+		}
+	}
+
+	sidecar := analysis.SummarySidecar{
+		Version:       analysis.SummaryVersion,
+		Ticker:        day.ticker,
+		Date:          day.date,
+		PeriodMinutes: opts.PeriodMinutes,
+		Anchor:        opts.Anchor,
+		Greeks:        spot != nil,
+		Metrics:       analysis.EnabledPeriodMetrics(),
+		SourceSize:    info.Size(),
+		SourceModTime: info.ModTime(),
+		GeneratedAt:   time.Now(),
+		Summaries:     summaries,
+	}
+	if opts.Sessions != 0 {
+		sidecar.Sessions = opts.Sessions.String()
+	}
+	if err := analysis.WriteSidecar(sidecarPath, sidecar); err != nil {
+		return fail(err)
+	}
+
+	result.Status = StatusWritten
+	result.Sidecar = sidecarPath
+	result.Summaries = len(summaries)
+	return result
+}
+
+// applyGreeks fetches the underlying's one-minute bars for every trading date in the file and sets each summary's greeks
+// Log files are named by analysis-timezone date, which need not match the ET trading date
+func applyGreeks(summaries []analysis.TimePeriodSummary, aggregates []analysis.Aggregate, opts analysis.AggregateOptions, day dayFile, spot marketdata.SpotSource, cfg analysis.GreeksConfig) error {
+	closes := make(map[int64]float64)
+	fetched := make(map[string]bool)
+	for _, agg := range aggregates {
+		date := time.UnixMilli(agg.StartTimestamp).In(market.Location)
+		key := date.Format("2006-01-02")
+		if fetched[key] {
+			continue
+		}
+		fetched[key] = true
+
+		bars, err := spot.PriceBars(context.Background(), day.ticker, date)
+		if err != nil {
+			return fmt.Errorf("failed to fetch prices for %s on %s: %w", day.ticker, key, err)
+		}
+		for _, bar := range bars {
+			closes[bar.Start.UnixMilli()] = bar.Close
+		}
+	}
+
+	analysis.ApplyGreeks(summaries, aggregates, opts, closes, cfg)
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/app"
+	"github.com/ekinolik/jax-ov/internal/config"
+	"github.com/ekinolik/jax-ov/internal/market"
+	"github.com/ekinolik/jax-ov/internal/server"
+	"github.com/ekinolik/jax-ov/internal/sheets"
+)
+
+// dailyHeader is the header row written with --header, matching the columns of dailyRow
+var dailyHeader = []interface{}{"Date", "Ticker", "Call Premium", "Put Premium", "Total Premium", "Call/Put Ratio", "Call Volume", "Put Volume"}
+
+func main() {
+	app.SetupLogging("")
+
+	// Parse command-line flags
+	tickersStr := flag.String("tickers", "", "Comma-separated underlying tickers to export (required, e.g., AAPL,TSLA)")
+	logDir := flag.String("log-dir", "./logs", "Log directory path (default: ./logs)")
+	dateStr := flag.String("date", "", "Date to export in YYYY-MM-DD format (default: today in the analysis timezone)")
+	sheet := flag.String("sheet", "Daily", "Sheet (tab) to append rows to (default: Daily)")
+	header := flag.Bool("header", false, "Append a header row before the data, for a new sheet (default: false)")
+	quiet := app.QuietFlag(flag.CommandLine)
+	timezone := app.TimezoneFlag(flag.CommandLine)
+	flag.Parse()
+	if err := config.ApplyFlagEnv(flag.CommandLine); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
+	if err := market.SetAnalysisTimezone(*timezone); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	progress := app.NewProgress(*quiet)
+
+	if *tickersStr == "" {
+		log.Fatal("Error: --tickers is required")
+	}
+	var tickers []string
+	for _, ticker := range strings.Split(*tickersStr, ",") {
+		if ticker = strings.ToUpper(strings.TrimSpace(ticker)); ticker != "" {
+			tickers = append(tickers, ticker)
+		}
+	}
+
+	if *dateStr == "" {
+		*dateStr = market.Today()
+	}
+	if _, err := time.Parse("2006-01-02", *dateStr); err != nil {
+		log.Fatal("Error: --date must be in YYYY-MM-DD format")
+	}
+
+	// Load configuration
+	sheetsConfig, err := config.LoadSheets()
+	if err != nil {
+		log.Fatalf("Failed to load Google Sheets configuration: %v", err)
+	}
+	creds, err := sheets.LoadCredentials(sheetsConfig.CredentialsFile)
+	if err != nil {
+		log.Fatalf("Failed to load Google Sheets credentials: %v", err)
+	}
+	client := sheets.NewClient(creds, sheetsConfig.SpreadsheetID)
+
+	var rows [][]interface{}
+	if *header {
+		rows = append(rows, dailyHeader)
+	}
+	for _, ticker := range tickers {
+		logFile := server.GetLogFileForTickerAndDate(*logDir, ticker, *dateStr)
+		if _, err := os.Stat(logFile); err != nil {
+			log.Printf("No log file for %s on %s, skipping", ticker, *dateStr)
+			continue
+		}
+		aggregates, err := server.ReadLogFile(logFile)
+		if err != nil {
+			log.Printf("Error reading %s: %v", logFile, err)
+			continue
+		}
+		rows = append(rows, dailyRow(ticker, analysis.SummarizeDay(*dateStr, aggregates)))
+	}
+
+	if err := client.AppendRows(context.Background(), *sheet, rows); err != nil {
+		log.Fatalf("Failed to append rows: %v", err)
+	}
+	progress.Printf("Appended %d rows to sheet %q\n", len(rows), *sheet)
+
+	// Quiet mode prints the appended rows as JSON
+	if progress.Quiet() {
+		if rows == nil {
+			rows = [][]interface{}{}
+		}
+		if err := app.PrintJSON(rows); err != nil {
+			log.Fatalf("Failed to encode JSON: %v", err)
+		}
+	}
+}
+
+// dailyRow formats a ticker's daily totals as a sheet row
+// An infinite call/put ratio (no put premium) is left blank
+func dailyRow(ticker string, day analysis.RollupSummary) []interface{} {
+	var ratio interface{} = ""
+	if day.CallPutRatio != nil {
+		ratio = *day.CallPutRatio
+	}
+	return []interface{}{day.StartDate, ticker, day.CallPremium, day.PutPremium, day.TotalPremium, ratio, day.CallVolume, day.PutVolume}
+}
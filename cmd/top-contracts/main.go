@@ -12,6 +12,8 @@ import (
 	"text/tabwriter"
 
 	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/app"
+	"github.com/ekinolik/jax-ov/internal/config"
 )
 
 // ContractSummary represents aggregated premium data for a single contract
@@ -23,21 +25,18 @@ type ContractSummary struct {
 	TransactionCount int     `json:"transaction_count"`
 }
 
-// ContractDetails represents parsed contract information
-type ContractDetails struct {
-	Underlying string
-	Expiration string
-	Strike     string
-	Type       string
-	FullSymbol string
-}
-
 func main() {
+	app.SetupLogging("")
+
 	// Parse command-line flags
 	input := flag.String("input", "", "Input JSON or JSONL file path (required)")
 	topN := flag.Int("top", 5, "Number of top contracts to display (default: 5)")
 	output := flag.String("output", "", "Optional output JSON file path")
+	quiet := app.QuietFlag(flag.CommandLine)
 	flag.Parse()
+	if err := config.ApplyFlagEnv(flag.CommandLine); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
 
 	// Validate flags
 	if *input == "" {
@@ -48,15 +47,17 @@ func main() {
 		log.Fatal("Error: --top must be greater than 0")
 	}
 
+	progress := app.NewProgress(*quiet)
+
 	// Read aggregates from file
-	fmt.Printf("Reading file: %s\n", *input)
+	progress.Printf("Reading file: %s\n", *input)
 	aggregates, err := readAggregates(*input)
 	if err != nil {
 		log.Fatalf("Failed to read file: %v", err)
 	}
 
-	fmt.Printf("Loaded %d aggregates\n", len(aggregates))
-	fmt.Printf("Calculating premiums per contract...\n")
+	progress.Printf("Loaded %d aggregates\n", len(aggregates))
+	progress.Printf("Calculating premiums per contract...\n")
 
 	// Group by contract and calculate total premium
 	contractMap := make(map[string]*ContractSummary)
@@ -106,19 +107,25 @@ func main() {
 	}
 	topContracts := contracts[:*topN]
 
-	fmt.Printf("Found %d unique contracts\n", len(contracts))
-	fmt.Printf("Top %d contracts by premium:\n\n", *topN)
+	progress.Printf("Found %d unique contracts\n", len(contracts))
+	progress.Printf("Top %d contracts by premium:\n\n", *topN)
 
-	// Display table
-	displayTable(topContracts)
+	// Quiet mode replaces the table with JSON on stdout, unless it is going to a file
+	if !progress.Quiet() {
+		displayTable(topContracts)
+	} else if *output == "" {
+		if err := app.PrintJSON(topContracts); err != nil {
+			log.Fatalf("Failed to encode JSON: %v", err)
+		}
+	}
 
 	// Write JSON output if requested
 	if *output != "" {
-		fmt.Printf("\nWriting results to %s...\n", *output)
+		progress.Printf("\nWriting results to %s...\n", *output)
 		if err := writeJSONOutput(topContracts, *output); err != nil {
 			log.Fatalf("Failed to write JSON output: %v", err)
 		}
-		fmt.Printf("Successfully wrote results to %s\n", *output)
+		progress.Printf("Successfully wrote results to %s\n", *output)
 	}
 }
 
@@ -216,99 +223,6 @@ func formatCurrency(amount float64) string {
 	return result.String()
 }
 
-// parseContractSymbol parses an option contract symbol into its components
-// Format: O:{UNDERLYING}{EXPIRATION}{C|P}{STRIKE}
-// Example: O:AAPL230616C00150000 -> AAPL, 2023-06-16, 150.00, CALL
-func parseContractSymbol(symbol string) (ContractDetails, error) {
-	// Remove "O:" prefix if present
-	symbol = strings.TrimPrefix(symbol, "O:")
-
-	if len(symbol) < 7 {
-		return ContractDetails{}, fmt.Errorf("invalid symbol format: %s", symbol)
-	}
-
-	// Find the C or P that indicates call/put
-	// It should be followed by digits (strike price)
-	var callPutIndex int = -1
-	var optionType string
-
-	for i := len(symbol) - 1; i >= 0; i-- {
-		if symbol[i] == 'C' {
-			if i+1 < len(symbol) && symbol[i+1] >= '0' && symbol[i+1] <= '9' {
-				callPutIndex = i
-				optionType = "CALL"
-				break
-			}
-		}
-		if symbol[i] == 'P' {
-			if i+1 < len(symbol) && symbol[i+1] >= '0' && symbol[i+1] <= '9' {
-				callPutIndex = i
-				optionType = "PUT"
-				break
-			}
-		}
-	}
-
-	if callPutIndex == -1 {
-		return ContractDetails{}, fmt.Errorf("could not find call/put indicator in: %s", symbol)
-	}
-
-	// Extract components
-	// Everything before callPutIndex-6 is the underlying (expiration is 6 digits: YYMMDD)
-	expirationStart := callPutIndex - 6
-	if expirationStart < 0 {
-		return ContractDetails{}, fmt.Errorf("invalid symbol format: %s", symbol)
-	}
-
-	underlying := symbol[:expirationStart]
-	expirationStr := symbol[expirationStart:callPutIndex]
-	strikeStr := symbol[callPutIndex+1:]
-
-	// Parse expiration (YYMMDD -> YYYY-MM-DD)
-	if len(expirationStr) != 6 {
-		return ContractDetails{}, fmt.Errorf("invalid expiration format: %s", expirationStr)
-	}
-
-	// Parse year (assume 20XX for years 00-99, could be 19XX for very old contracts)
-	year := "20" + expirationStr[0:2]
-	month := expirationStr[2:4]
-	day := expirationStr[4:6]
-	expiration := fmt.Sprintf("%s-%s-%s", year, month, day)
-
-	// Parse strike (option strikes are stored with last 3 digits as decimal part)
-	// Example: "00220000" -> 220.000, "220500" -> 220.500
-	// The strike is stored as an integer where the last 3 digits represent thousandths
-	strike := strings.TrimLeft(strikeStr, "0")
-	if strike == "" {
-		strike = "0"
-	}
-
-	// Pad with zeros to ensure we have at least 3 digits for decimal part
-	for len(strike) < 3 {
-		strike = "0" + strike
-	}
-
-	// Insert decimal point 3 digits from the right
-	strike = strike[:len(strike)-3] + "." + strike[len(strike)-3:]
-
-	// Ensure exactly 3 decimal places
-	parts := strings.Split(strike, ".")
-	if len(parts) == 2 {
-		for len(parts[1]) < 3 {
-			parts[1] += "0"
-		}
-		strike = parts[0] + "." + parts[1]
-	}
-
-	return ContractDetails{
-		Underlying: underlying,
-		Expiration: expiration,
-		Strike:     strike,
-		Type:       optionType,
-		FullSymbol: "O:" + symbol,
-	}, nil
-}
-
 // displayTable displays the top contracts in a formatted table
 func displayTable(contracts []ContractSummary) {
 	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
@@ -324,7 +238,7 @@ func displayTable(contracts []ContractSummary) {
 		volumeFormatted := formatCurrency(float64(contract.TotalVolume))
 
 		// Parse contract symbol
-		details, err := parseContractSymbol(contract.Symbol)
+		details, err := analysis.ParseOptionSymbol(contract.Symbol)
 		if err != nil {
 			// If parsing fails, fall back to showing full symbol
 			premiumPadded := fmt.Sprintf("%25s", "$"+premiumFormatted)
@@ -346,9 +260,9 @@ func displayTable(contracts []ContractSummary) {
 		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t\t%s\t\t%d\n",
 			rank,
 			details.Underlying,
-			details.Expiration,
-			details.Strike,
-			details.Type,
+			details.ExpirationDate(),
+			fmt.Sprintf("%.3f", details.Strike),
+			strings.ToUpper(details.Type),
 			premiumPadded,
 			volumePadded,
 			contract.TransactionCount)
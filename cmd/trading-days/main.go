@@ -3,30 +3,34 @@ package main
 import (
 	"encoding/json"
 	"flag"
-	"fmt"
 	"log"
 	"os"
-	"sort"
 	"time"
 
-	"github.com/scmhub/calendar"
+	"github.com/ekinolik/jax-ov/internal/app"
+	"github.com/ekinolik/jax-ov/internal/config"
+	"github.com/ekinolik/jax-ov/internal/market"
 )
 
-type TradingDaysData struct {
-	GeneratedDate string   `json:"generated_date"`
-	Year          int      `json:"year"`
-	TradingDays   []string `json:"trading_days"`
-}
-
 func main() {
+	app.SetupLogging("")
+
 	// Parse command-line flags
+	now := time.Now()
 	output := flag.String("output", "trading-days.json", "Output JSON file path (default: trading-days.json)")
-	load := flag.String("load", "", "Load JSON file and get past N trading days")
-	past := flag.Int("past", 0, "Number of past trading days to retrieve (required if --load is used)")
+	fromYear := flag.Int("from-year", now.Year(), "First year to generate (default: current year)")
+	toYear := flag.Int("to-year", now.Year()+1, "Last year to generate (default: next year)")
+	load := flag.String("load", "", "Load JSON file for --past instead of the trading days built into the binary")
+	past := flag.Int("past", 0, "Number of past trading days to retrieve (uses --load, or the built-in trading days)")
+	quiet := app.QuietFlag(flag.CommandLine)
 	flag.Parse()
+	if err := config.ApplyFlagEnv(flag.CommandLine); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
+	progress := app.NewProgress(*quiet)
 
-	// If --load is provided, load and filter
-	if *load != "" {
+	// If --past or --load is provided, look up past trading days
+	if *past > 0 || *load != "" {
 		if *past <= 0 {
 			log.Fatal("Error: --past must be greater than 0 when using --load")
 		}
@@ -34,49 +38,16 @@ func main() {
 		return
 	}
 
-	// Otherwise, fetch and create trading days JSON
-	fetchTradingDays(*output)
+	// Otherwise, generate the trading days JSON
+	if *toYear < *fromYear {
+		log.Fatal("Error: --to-year must not be before --from-year")
+	}
+	fetchTradingDays(*output, *fromYear, *toYear, progress)
 }
 
-// fetchTradingDays fetches trading days for current and next year and saves to JSON
-func fetchTradingDays(outputFile string) {
-	now := time.Now()
-	currentYear := now.Year()
-	nextYear := currentYear + 1
-
-	// Initialize calendar with both years to ensure holidays are calculated correctly
-	cal := calendar.XNYS(currentYear, nextYear)
-
-	var allTradingDays []string
-
-	// Get trading days for current year
-	currentYearDays := getTradingDaysForYear(cal, currentYear)
-	allTradingDays = append(allTradingDays, currentYearDays...)
-
-	// Get trading days for next year
-	nextYearDays := getTradingDaysForYear(cal, nextYear)
-	allTradingDays = append(allTradingDays, nextYearDays...)
-
-	// Sort all trading days
-	sort.Strings(allTradingDays)
-
-	// Create output structure
-	output := map[string]interface{}{
-		"generated_date": now.Format("2006-01-02"),
-		"years": map[string]interface{}{
-			fmt.Sprintf("%d", currentYear): TradingDaysData{
-				GeneratedDate: now.Format("2006-01-02"),
-				Year:          currentYear,
-				TradingDays:   currentYearDays,
-			},
-			fmt.Sprintf("%d", nextYear): TradingDaysData{
-				GeneratedDate: now.Format("2006-01-02"),
-				Year:          nextYear,
-				TradingDays:   nextYearDays,
-			},
-		},
-		"all_trading_days": allTradingDays,
-	}
+// fetchTradingDays generates trading days for fromYear through toYear and saves them to JSON
+func fetchTradingDays(outputFile string, fromYear int, toYear int, progress *app.Progress) {
+	days := market.GenerateTradingDays(fromYear, toYear, time.Now())
 
 	// Write to JSON file
 	file, err := os.Create(outputFile)
@@ -87,112 +58,37 @@ func fetchTradingDays(outputFile string) {
 
 	encoder := json.NewEncoder(file)
 	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(output); err != nil {
+	if err := encoder.Encode(days); err != nil {
 		log.Fatalf("Failed to encode JSON: %v", err)
 	}
 
-	fmt.Printf("Generated trading days for %d and %d\n", currentYear, nextYear)
-	fmt.Printf("Total trading days: %d\n", len(allTradingDays))
-	fmt.Printf("Saved to: %s\n", outputFile)
-}
-
-// getTradingDaysForYear gets all trading days for a given year using the provided calendar
-func getTradingDaysForYear(cal *calendar.Calendar, year int) []string {
-	var tradingDays []string
-
-	// Load New York timezone for market hours
-	nyTZ, _ := time.LoadLocation("America/New_York")
-
-	// Start from January 1st of the year
-	startDate := time.Date(year, time.January, 1, 0, 0, 0, 0, nyTZ)
-	// End at December 31st of the year
-	endDate := time.Date(year, time.December, 31, 23, 59, 59, 999, nyTZ)
-
-	// Iterate through each day in the year
-	currentDate := startDate
-	for currentDate.Before(endDate) || currentDate.Equal(endDate) {
-		// Check if the market is open at 10:00 AM ET (during market hours)
-		// This correctly excludes holidays, weekends, and early closes
-		checkTime := time.Date(currentDate.Year(), currentDate.Month(), currentDate.Day(), 10, 0, 0, 0, nyTZ)
-		if cal.IsOpen(checkTime) {
-			tradingDays = append(tradingDays, currentDate.Format("2006-01-02"))
-		}
-		currentDate = currentDate.AddDate(0, 0, 1)
-	}
-
-	return tradingDays
+	progress.Printf("Generated trading days for %d through %d\n", fromYear, toYear)
+	progress.Printf("Total trading days: %d\n", len(days.AllTradingDays))
+	progress.Printf("Saved to: %s\n", outputFile)
 }
 
-// getPastTradingDays loads JSON file and returns the past N trading days
+// getPastTradingDays prints the past N trading days as a JSON array, from a JSON file or the built-in data
 func getPastTradingDays(jsonFile string, n int) {
-	// Read JSON file
-	data, err := os.ReadFile(jsonFile)
-	if err != nil {
-		log.Fatalf("Failed to read JSON file: %v", err)
-	}
-
-	// Parse JSON
-	var dataMap map[string]interface{}
-	if err := json.Unmarshal(data, &dataMap); err != nil {
-		log.Fatalf("Failed to parse JSON: %v", err)
-	}
-
-	// Get all_trading_days array
-	allTradingDaysInterface, ok := dataMap["all_trading_days"]
-	if !ok {
-		log.Fatal("Error: 'all_trading_days' field not found in JSON")
-	}
-
-	allTradingDays, ok := allTradingDaysInterface.([]interface{})
-	if !ok {
-		log.Fatal("Error: 'all_trading_days' is not an array")
-	}
-
-	// Convert to string slice
-	var tradingDays []string
-	for _, day := range allTradingDays {
-		if dayStr, ok := day.(string); ok {
-			tradingDays = append(tradingDays, dayStr)
+	var days *market.TradingDays
+	if jsonFile != "" {
+		var err error
+		days, err = market.LoadTradingDays(jsonFile)
+		if err != nil {
+			log.Fatalf("Failed to load trading days: %v", err)
 		}
+	} else {
+		// Extend the built-in data if this binary is older than the current year
+		market.RefreshTradingDays(time.Now())
+		days = market.CurrentTradingDays()
 	}
 
-	// Sort to ensure chronological order
-	sort.Strings(tradingDays)
-
-	// Get today's date
-	now := time.Now()
-	todayStr := now.Format("2006-01-02")
-
-	// Find today's position in the array (or the most recent trading day before/on today)
-	todayIndex := -1
-	for i, day := range tradingDays {
-		if day <= todayStr {
-			todayIndex = i
-		} else {
-			break
-		}
-	}
-
-	// If no trading days found up to today, error
-	if todayIndex == -1 {
+	// Get past N trading days, including today if it's a trading day
+	today := time.Now().In(market.Location).Format("2006-01-02")
+	pastDays := days.Past(today, n)
+	if len(pastDays) == 0 {
 		log.Fatal("Error: Could not find today or a past trading day in the data")
 	}
 
-	// Get past N trading days (including today if it's a trading day)
-	// We want the last N trading days ending at todayIndex
-	startIndex := todayIndex - n + 1
-	if startIndex < 0 {
-		startIndex = 0
-	}
-
-	// Ensure we don't go beyond available data
-	endIndex := todayIndex + 1
-	if endIndex > len(tradingDays) {
-		endIndex = len(tradingDays)
-	}
-
-	pastDays := tradingDays[startIndex:endIndex]
-
 	// Output as JSON
 	encoder := json.NewEncoder(os.Stdout)
 	encoder.SetIndent("", "  ")
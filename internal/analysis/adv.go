@@ -0,0 +1,64 @@
+package analysis
+
+// RegularSessionMinutes is the length of the regular session (09:30-16:00 ET), over which FlowMultiple spreads daily volume
+const RegularSessionMinutes = 390
+
+// VolumeBaseline is a ticker's average daily option volume over the trading days before a date
+type VolumeBaseline struct {
+	Days          int     `json:"days"` // Trading days with data in the average
+	AverageVolume float64 `json:"average_volume"`
+}
+
+// RelativeFlow expresses a period's volume against the ticker's average daily volume (ADV)
+type RelativeFlow struct {
+	AverageDailyVolume float64 `json:"average_daily_volume"`
+	BaselineDays       int     `json:"baseline_days"`  // Trading days behind AverageDailyVolume
+	PercentOfADV       float64 `json:"percent_of_adv"` // Period volume as a percentage of ADV
+	// Period volume against ADV spread evenly over the regular session: 3 means 3× the normal flow for a period this long
+	FlowMultiple float64 `json:"flow_multiple"`
+}
+
+// AverageDailyVolume averages the call and put volume of daily rollups, skipping days without data
+// The result has no days when none of them had data
+func AverageDailyVolume(days []RollupSummary) VolumeBaseline {
+	var baseline VolumeBaseline
+	var total int64
+	for _, day := range days {
+		if day.Days == 0 {
+			continue
+		}
+		baseline.Days++
+		total += day.CallVolume + day.PutVolume
+	}
+	if baseline.Days > 0 {
+		baseline.AverageVolume = float64(total) / float64(baseline.Days)
+	}
+	return baseline
+}
+
+// Apply sets a summary's relative flow from the baseline
+// A nil baseline, or one without volume, leaves the summary without relative flow
+func (b *VolumeBaseline) Apply(summary *TimePeriodSummary) {
+	summary.Relative = nil
+	if b == nil || b.AverageVolume <= 0 {
+		return
+	}
+
+	volume := float64(summary.CallVolume + summary.PutVolume)
+	relative := &RelativeFlow{
+		AverageDailyVolume: b.AverageVolume,
+		BaselineDays:       b.Days,
+		PercentOfADV:       volume / b.AverageVolume * 100,
+	}
+	if minutes := summary.PeriodEnd.Sub(summary.PeriodStart).Minutes(); minutes > 0 {
+		relative.FlowMultiple = volume / (b.AverageVolume * minutes / RegularSessionMinutes)
+	}
+	summary.Relative = relative
+}
+
+// ApplyVolumeBaseline sets each summary's relative flow from the baseline
+func ApplyVolumeBaseline(summaries []TimePeriodSummary, baseline *VolumeBaseline) {
+	for i := range summaries {
+		baseline.Apply(&summaries[i])
+	}
+}
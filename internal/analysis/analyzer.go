@@ -2,10 +2,97 @@ package analysis
 
 import (
 	"fmt"
-	"strings"
+	"math"
+	"sort"
 	"time"
+
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// Period anchors control where period boundaries start
+const (
+	AnchorMidnight = "midnight" // Boundaries aligned to wall-clock minutes since midnight (default)
+	AnchorOpen     = "open"     // Boundaries aligned to the 09:30 ET market open
 )
 
+// AggregateOptions controls how aggregates are bucketed into time periods
+type AggregateOptions struct {
+	PeriodMinutes int
+	Anchor        string            // AnchorMidnight (or empty) or AnchorOpen
+	Sessions      market.SessionSet // Only include aggregates traded in these sessions (zero value includes all)
+	MaxDTE        DTELimit          // Only include contracts expiring within this many days (zero value includes all)
+	MaxMoneyness  float64           // Only include strikes within this percent of spot (0 includes all); needs aggregates tagged by TagMoneyness
+}
+
+// Includes reports whether an aggregate passes the option filters
+func (o AggregateOptions) Includes(agg Aggregate) bool {
+	if o.Sessions != 0 && !o.Sessions.Contains(market.SessionForTime(time.UnixMilli(agg.StartTimestamp))) {
+		return false
+	}
+	if o.MaxMoneyness > 0 && (agg.MoneynessPct == nil || math.Abs(*agg.MoneynessPct) > o.MaxMoneyness) {
+		return false
+	}
+	return o.MaxDTE.Allows(agg)
+}
+
+// Filter returns the aggregates that pass the option filters, or the input itself when there are no filters
+func (o AggregateOptions) Filter(aggregates []Aggregate) []Aggregate {
+	if o.Sessions == 0 && !o.MaxDTE.Set && o.MaxMoneyness == 0 {
+		return aggregates
+	}
+	filtered := make([]Aggregate, 0, len(aggregates))
+	for _, agg := range aggregates {
+		if o.Includes(agg) {
+			filtered = append(filtered, agg)
+		}
+	}
+	return filtered
+}
+
+// NewPeriodSummary creates an empty summary for the period [periodStart, periodEnd) in Unix milliseconds,
+// with an instance of each enabled plugin metric
+func NewPeriodSummary(periodStart int64, periodEnd int64) *TimePeriodSummary {
+	start := time.Unix(0, periodStart*int64(time.Millisecond))
+	return &TimePeriodSummary{
+		PeriodStart: start,
+		PeriodEnd:   time.Unix(0, periodEnd*int64(time.Millisecond)),
+		Session:     market.SessionForTime(start),
+		metrics:     newPeriodMetrics(),
+	}
+}
+
+// Clone returns a copy of the summary that can be updated (e.g. by incremental live updates) without changing the original
+func (s TimePeriodSummary) Clone() TimePeriodSummary {
+	if s.contracts != nil {
+		contracts := make(map[string]bool, len(s.contracts))
+		for symbol := range s.contracts {
+			contracts[symbol] = true
+		}
+		s.contracts = contracts
+	}
+	if s.ExpirySkew != nil {
+		skew := make(map[string]ExpirySkew, len(s.ExpirySkew))
+		for date, expiry := range s.ExpirySkew {
+			skew[date] = expiry
+		}
+		s.ExpirySkew = skew
+	}
+	if s.Greeks != nil {
+		greeks := *s.Greeks
+		s.Greeks = &greeks
+	}
+	s.PremiumDistribution = s.PremiumDistribution.clone()
+	if s.Metrics != nil {
+		metrics := make(map[string]interface{}, len(s.Metrics))
+		for name, value := range s.Metrics {
+			metrics[name] = value
+		}
+		s.Metrics = metrics
+	}
+	s.metrics = cloneMetrics(s.metrics)
+	return s
+}
+
 // Aggregate represents a single aggregate from the reconstructed JSON
 type Aggregate struct {
 	EventType         string  `json:"ev"`
@@ -22,6 +109,22 @@ type Aggregate struct {
 	AverageSize       int64   `json:"z"`
 	StartTimestamp    int64   `json:"s"`
 	EndTimestamp      int64   `json:"e"`
+
+	// When the logger received the aggregate (Unix ms), for end-to-end latency tracking; 0 in logs written before
+	// ingest times were stamped
+	IngestedAt int64 `json:"ingest,omitempty"`
+
+	// Strike's percent from the underlying's spot price (see TagMoneyness); only set where spot prices are available
+	MoneynessPct *float64 `json:"moneyness_pct,omitempty"`
+}
+
+// IngestTime returns when the logger received the aggregate, or the zero time for aggregates logged before ingest
+// times were stamped
+func (a Aggregate) IngestTime() time.Time {
+	if a.IngestedAt == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(a.IngestedAt)
 }
 
 // TimePeriodSummary represents aggregated premium data for a time period
@@ -31,46 +134,106 @@ type TimePeriodSummary struct {
 	CallPremium  float64   `json:"call_premium"`
 	PutPremium   float64   `json:"put_premium"`
 	TotalPremium float64   `json:"total_premium"`
-	CallPutRatio float64   `json:"call_put_ratio"`
-	CallVolume   int64     `json:"call_volume"`
-	PutVolume    int64     `json:"put_volume"`
+
+	// Call/put ratios (see Ratio and LogRatio); the plain ratios are null when only calls traded
+	CallPutRatio       *float64 `json:"call_put_ratio"`        // Call premium / put premium
+	CallPutLogRatio    float64  `json:"call_put_log_ratio"`    // ln((call premium + 1) / (put premium + 1)), always finite
+	CallPutVolumeRatio *float64 `json:"call_put_volume_ratio"` // Call volume / put volume
+
+	CallVolume int64  `json:"call_volume"`
+	PutVolume  int64  `json:"put_volume"`
+	Session    string `json:"session"` // Trading session of the period start: premarket, regular, afterhours, or closed
+
+	// Volume-weighted average strike of each side (0 without volume), to compare where flow concentrates against spot
+	CallAvgStrike float64 `json:"call_avg_strike"`
+	PutAvgStrike  float64 `json:"put_avg_strike"`
+
+	SizeBuckets       SizeBuckets       `json:"size_buckets"`       // Premium split by the trade-size class of each aggregate
+	ExpirationBuckets ExpirationBuckets `json:"expiration_buckets"` // Premium split by days to expiration of each aggregate's contract
+	SideFlow          SideFlow          `json:"side_flow"`          // Premium split by the inferred side (bought or sold) of each aggregate
+
+	// Call/put premium and ratio for each expiration traded in the period, keyed by expiration date (YYYY-MM-DD)
+	ExpirySkew map[string]ExpirySkew `json:"expiry_skew,omitempty"`
+
+	// Breadth: how many contracts traded, alongside how much premium
+	UniqueContracts     int             `json:"unique_contracts"`      // Distinct contracts traded in the period
+	UniqueCallContracts int             `json:"unique_call_contracts"` // Distinct call contracts traded in the period
+	UniquePutContracts  int             `json:"unique_put_contracts"`  // Distinct put contracts traded in the period
+	NewContracts        int             `json:"new_contracts"`         // Contracts whose first trade of the day is in the period
+	contracts           map[string]bool // Distinct contracts behind UniqueContracts (kept for Merge and incremental updates)
+
+	// Single aggregate with the most premium, to show whether one print drove the period
+	LargestTrade *LargestTrade `json:"largest_trade,omitempty"`
+
+	// Count, median, p90, and largest trade premium on each side, to tell many small prints from a few large ones
+	PremiumDistribution PremiumDistribution `json:"premium_distribution"`
+
+	// Call and put premium against the rolling baseline of earlier periods (nil until enough periods have passed)
+	Anomaly *AnomalyScores `json:"anomaly,omitempty"`
+
+	// Contracts that accumulated premium in each of the last MinPositionPeriods or more periods, largest first (see
+	// PositionTracker)
+	PositionBuilding []PositionBuild `json:"position_building,omitempty"`
+
+	// Call premium, put premium, and call/put ratio against the period before it (see DiffSummaries); set as
+	// summaries are sent over /analyze (nil elsewhere)
+	Change *PeriodChange `json:"change,omitempty"`
+
+	// Greeks need the underlying's price, so they are only set when spot prices are available (see ApplyGreeks)
+	Greeks *PeriodGreeks `json:"greeks,omitempty"`
+
+	// Volume against the ticker's average daily volume, so flow compares across tickers (nil without a baseline)
+	Relative *RelativeFlow `json:"relative,omitempty"`
+
+	// Premium against the ticker's normal premium at this time of day (nil without a baseline, see TimeOfDayBaseline)
+	VersusNormal *VersusNormal `json:"vs_normal,omitempty"`
+
+	// Volume against the open interest of the contracts traded (nil without open interest data, see ApplyOpenInterest)
+	OpenInterest *PeriodOpenInterest `json:"open_interest,omitempty"`
+
+	// Walls are cumulative for the day through the end of the period
+	CallWall *StrikePremium `json:"call_wall,omitempty"` // Strike with the most call premium so far
+	PutWall  *StrikePremium `json:"put_wall,omitempty"`  // Strike with the most put premium so far
+
+	// Times a live update corrected the period after it was reported complete, because aggregates for it arrived late
+	// (0 for the original; see IncrementalAggregator)
+	Revision int `json:"revision,omitempty"`
+
+	// Per-connection message number set by the server as each summary is sent over /analyze, which clients echo
+	// back in heartbeats (0 elsewhere)
+	Seq int64 `json:"seq,omitempty"`
+
+	// Ticker or group the summary is for, set by the server as each summary is sent over /analyze, so clients
+	// following several tickers on one connection can tell them apart (empty elsewhere)
+	Ticker string `json:"ticker,omitempty"`
+
+	// Values of the enabled plugin metrics, keyed by metric name (see PeriodMetric)
+	Metrics map[string]interface{} `json:"metrics,omitempty"`
+	metrics []PeriodMetric         // Instances behind Metrics (kept for Merge and incremental updates)
+
+	// Ingest time (Unix ms) of the newest aggregate behind a live update (see IngestedAt)
+	ingestedAt int64
+}
+
+// IngestedAt returns when the logger received the newest aggregate behind a live update from an
+// IncrementalAggregator, for end-to-end latency tracking. It is zero for summaries built from stored logs, and for
+// periods reported by CompletedPeriods, whose aggregates were already sent as the current period
+func (s TimePeriodSummary) IngestedAt() time.Time {
+	if s.ingestedAt == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(s.ingestedAt)
 }
 
 // ParseOptionType extracts the option type (call/put) from the symbol
-// Format: O:{UNDERLYING}{EXPIRATION}{C|P}{STRIKE}
 // Example: "O:AAPL230616C00150000" -> "call"
 // Example: "O:AAPL230616P00150000" -> "put"
-// The expiration date is typically 6 digits (YYMMDD), followed by C or P
 func ParseOptionType(symbol string) (string, error) {
-	// Remove "O:" prefix if present
-	symbol = strings.TrimPrefix(symbol, "O:")
-
-	if len(symbol) < 7 {
-		return "", fmt.Errorf("invalid option symbol format: %s", symbol)
+	parsed, err := ParseOptionSymbol(symbol)
+	if err != nil {
+		return "", err
 	}
-
-	// The format is: TICKER + YYMMDD + C/P + STRIKE
-	// We need to find the C or P character. It should appear after the expiration date.
-	// Since ticker length varies, we'll search from the end backwards
-	// looking for C or P followed by digits (the strike price)
-
-	// Look for C or P that is followed by digits (strike price)
-	for i := len(symbol) - 1; i >= 0; i-- {
-		if symbol[i] == 'C' {
-			// Check if followed by digits (strike price)
-			if i+1 < len(symbol) && symbol[i+1] >= '0' && symbol[i+1] <= '9' {
-				return "call", nil
-			}
-		}
-		if symbol[i] == 'P' {
-			// Check if followed by digits (strike price)
-			if i+1 < len(symbol) && symbol[i+1] >= '0' && symbol[i+1] <= '9' {
-				return "put", nil
-			}
-		}
-	}
-
-	return "", fmt.Errorf("could not determine option type from symbol: %s", symbol)
+	return parsed.Type, nil
 }
 
 // CalculatePremium calculates premium as volume × VWAP × 100
@@ -78,9 +241,9 @@ func CalculatePremium(volume int64, vw float64) float64 {
 	return float64(volume) * vw * 100
 }
 
-// RoundDownToPeriod rounds a timestamp down to the nearest N-minute boundary
+// RoundDownToPeriod rounds a timestamp down to the nearest N-minute boundary, counted from midnight in the analysis timezone
 func RoundDownToPeriod(timestamp int64, minutes int) int64 {
-	t := time.Unix(0, timestamp*int64(time.Millisecond))
+	t := time.UnixMilli(timestamp).In(market.AnalysisLocation())
 
 	// Calculate minutes since start of day
 	totalMinutes := t.Hour()*60 + t.Minute()
@@ -95,14 +258,109 @@ func RoundDownToPeriod(timestamp int64, minutes int) int64 {
 	return rounded.UnixMilli()
 }
 
+// ValidateAnchor checks that an anchor name is supported
+func ValidateAnchor(anchor string) error {
+	switch anchor {
+	case "", AnchorMidnight, AnchorOpen:
+		return nil
+	}
+	return fmt.Errorf("invalid anchor %q, expected %q or %q", anchor, AnchorMidnight, AnchorOpen)
+}
+
+// RoundDownToAnchoredPeriod rounds a timestamp down to the start of its N-minute period
+// For AnchorOpen, periods are counted from 09:30 ET so 5-minute bars are 09:30-09:35, 09:35-09:40, ...
+// Timestamps before the open are bucketed backwards from the open using the same period length
+func RoundDownToAnchoredPeriod(timestamp int64, minutes int, anchor string) int64 {
+	if anchor != AnchorOpen {
+		return RoundDownToPeriod(timestamp, minutes)
+	}
+
+	open := market.OpenTime(time.UnixMilli(timestamp)).UnixMilli()
+	periodMillis := int64(minutes) * 60 * 1000
+
+	// Floor division so pre-market timestamps land in the period before the open
+	offset := timestamp - open
+	periods := offset / periodMillis
+	if offset < 0 && offset%periodMillis != 0 {
+		periods--
+	}
+
+	return open + periods*periodMillis
+}
+
 // AggregatePremiums aggregates premiums by time period, separated by call/put
 func AggregatePremiums(aggregates []Aggregate, periodMinutes int) ([]TimePeriodSummary, error) {
-	// Map to store premiums by time period
-	periodMap := make(map[int64]*TimePeriodSummary)
+	return AggregatePremiumsWithOptions(aggregates, AggregateOptions{PeriodMinutes: periodMinutes})
+}
+
+// AggregatePremiumsWithOptions aggregates premiums by time period using the given bucketing options
+func AggregatePremiumsWithOptions(aggregates []Aggregate, opts AggregateOptions) ([]TimePeriodSummary, error) {
+	if opts.PeriodMinutes <= 0 {
+		return nil, fmt.Errorf("period must be greater than 0")
+	}
+	return bucketAggregates(aggregates, opts).summaries(), nil
+}
+
+// periodBuckets holds per-period totals before the day-so-far metrics (walls, new contracts, anomaly scores, position
+// building) are applied
+type periodBuckets struct {
+	periods map[int64]*TimePeriodSummary // Key: period start (Unix ms)
+	// Per-period strike premiums, merged in time order to give each period the day's walls so far
+	walls map[int64]*WallTracker
+	// Per-period traded contracts, counted once periods are in time order (aggregates may arrive out of order)
+	contracts map[int64]map[string]bool
+	// Per-period contract premiums, for contracts building positions across consecutive periods
+	positions *PositionTracker
+}
+
+// newPeriodBuckets creates empty buckets sized for about periodHint periods
+func newPeriodBuckets(periodHint int) *periodBuckets {
+	return &periodBuckets{
+		periods:   make(map[int64]*TimePeriodSummary, periodHint),
+		walls:     make(map[int64]*WallTracker, periodHint),
+		contracts: make(map[int64]map[string]bool, periodHint),
+		positions: NewPositionTracker(),
+	}
+}
+
+// period returns the bucket for a period, creating it if needed
+func (b *periodBuckets) period(periodStart int64, periodEnd int64) (*TimePeriodSummary, *WallTracker, map[string]bool) {
+	summary, exists := b.periods[periodStart]
+	if !exists {
+		summary = NewPeriodSummary(periodStart, periodEnd)
+		b.periods[periodStart] = summary
+		b.walls[periodStart] = NewWallTracker()
+		b.contracts[periodStart] = make(map[string]bool)
+	}
+	return summary, b.walls[periodStart], b.contracts[periodStart]
+}
+
+// bucketAggregates adds each aggregate passing the options to its period's bucket (opts.PeriodMinutes must be positive)
+func bucketAggregates(aggregates []Aggregate, opts AggregateOptions) *periodBuckets {
+	periodMinutes := opts.PeriodMinutes
+
+	// Maps are sized for a day of periods (or fewer, for small inputs) so they don't grow while aggregating
+	periodHint := 24*60/periodMinutes + 1
+	if len(aggregates) < periodHint {
+		periodHint = len(aggregates)
+	}
+	buckets := newPeriodBuckets(periodHint)
+
+	// Log files are in time order, so most aggregates fall in the same period as the one before;
+	// reusing its bounds skips the timezone conversion in RoundDownToAnchoredPeriod
+	var periodStart, periodEnd int64
+	var summary *TimePeriodSummary
+	var walls *WallTracker
+	var contracts map[string]bool
 
 	for _, agg := range aggregates {
-		// Determine option type
-		optionType, err := ParseOptionType(agg.Symbol)
+		// Skip aggregates excluded by the options (e.g. session filter)
+		if !opts.Includes(agg) {
+			continue
+		}
+
+		// Determine option type and strike
+		contract, err := ParseOptionSymbol(agg.Symbol)
 		if err != nil {
 			// Skip aggregates we can't parse (log but continue)
 			continue
@@ -111,57 +369,85 @@ func AggregatePremiums(aggregates []Aggregate, periodMinutes int) ([]TimePeriodS
 		// Calculate premium
 		premium := CalculatePremium(agg.Volume, agg.VWAP)
 
-		// Round down to time period
-		periodStart := RoundDownToPeriod(agg.StartTimestamp, periodMinutes)
-		periodEnd := periodStart + int64(periodMinutes*60*1000) // Add period duration in milliseconds
-
-		// Get or create period summary
-		summary, exists := periodMap[periodStart]
-		if !exists {
-			summary = &TimePeriodSummary{
-				PeriodStart: time.Unix(0, periodStart*int64(time.Millisecond)),
-				PeriodEnd:   time.Unix(0, periodEnd*int64(time.Millisecond)),
-			}
-			periodMap[periodStart] = summary
+		// Round down to time period, unless the aggregate is in the same period as the previous one
+		if summary == nil || agg.StartTimestamp < periodStart || agg.StartTimestamp >= periodEnd {
+			periodStart = RoundDownToAnchoredPeriod(agg.StartTimestamp, periodMinutes, opts.Anchor)
+			periodEnd = periodStart + int64(periodMinutes*60*1000) // Add period duration in milliseconds
+			summary, walls, contracts = buckets.period(periodStart, periodEnd)
 		}
+		walls.Add(agg)
+		contracts[agg.Symbol] = true
+		buckets.positions.add(periodStart, agg.Symbol, premium, agg.Volume)
+		summary.addFlow(agg, contract, premium)
+	}
 
-		// Add premium and volume to appropriate type
-		if optionType == "call" {
-			summary.CallPremium += premium
-			summary.CallVolume += agg.Volume
-		} else if optionType == "put" {
-			summary.PutPremium += premium
-			summary.PutVolume += agg.Volume
-		}
+	return buckets
+}
 
-		// Update total
-		summary.TotalPremium = summary.CallPremium + summary.PutPremium
-
-		// Calculate call to put ratio
-		if summary.PutPremium > 0 {
-			summary.CallPutRatio = summary.CallPremium / summary.PutPremium
-		} else if summary.CallPremium > 0 {
-			// If put premium is 0 but call premium exists, ratio is infinity (represented as -1 or a large number)
-			summary.CallPutRatio = -1 // Use -1 to indicate infinite ratio
-		} else {
-			summary.CallPutRatio = 0 // Both are zero
-		}
+// addFlow adds an aggregate's premium and volume to the period, except for the values derived from the final
+// premiums (see refreshTotals)
+func (s *TimePeriodSummary) addFlow(agg Aggregate, contract Contract, premium float64) {
+	optionType := contract.Type
+
+	// Add premium and volume to appropriate type
+	if optionType == "call" {
+		s.CallPremium += premium
+	} else if optionType == "put" {
+		s.PutPremium += premium
 	}
+	s.AddVolume(optionType, contract.Strike, agg.Volume)
+	s.SizeBuckets.Add(optionType, premium)
+	s.ExpirationBuckets.Add(agg, optionType, premium)
+	s.AddExpiry(contract.Expiration, optionType, premium)
+	s.SideFlow.Add(agg, optionType, premium)
+	s.AddTrade(agg, premium)
+	s.PremiumDistribution.Add(optionType, premium)
+	s.UpdateMetrics(agg)
+}
 
-	// Convert map to sorted slice
-	result := make([]TimePeriodSummary, 0, len(periodMap))
-	for _, summary := range periodMap {
+// refreshTotals computes the values derived from the period's premiums: the total, the call/put ratios, the premium
+// distribution, and the plugin metrics
+func (s *TimePeriodSummary) refreshTotals() {
+	// Update total
+	s.TotalPremium = s.CallPremium + s.PutPremium
+	s.PremiumDistribution.Compute()
+	s.FinalizeMetrics()
+	s.computeRatios()
+}
+
+// summaries returns the buckets as period summaries sorted by start time, with each period's totals computed
+// from its final premiums and the day-so-far metrics applied in time order
+// Summaries are copies, but must be taken only once: counting contracts fills the buckets' contract sets
+func (b *periodBuckets) summaries() []TimePeriodSummary {
+	result := make([]TimePeriodSummary, 0, len(b.periods))
+	for _, summary := range b.periods {
+		summary.refreshTotals()
 		result = append(result, *summary)
 	}
 
 	// Sort by period start time
-	for i := 0; i < len(result)-1; i++ {
-		for j := i + 1; j < len(result); j++ {
-			if result[i].PeriodStart.After(result[j].PeriodStart) {
-				result[i], result[j] = result[j], result[i]
-			}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].PeriodStart.Before(result[j].PeriodStart)
+	})
+
+	dayWalls := NewWallTracker()
+	for i := range result {
+		dayWalls.Merge(b.walls[result[i].PeriodStart.UnixMilli()])
+		dayWalls.Apply(&result[i])
+	}
+
+	// Count each period's contracts in time order so a contract is new only in the period it first traded
+	seen := NewContractTracker()
+	for i := range result {
+		for symbol := range b.contracts[result[i].PeriodStart.UnixMilli()] {
+			result[i].AddContract(symbol, seen.Add(symbol))
 		}
 	}
 
-	return result, nil
+	ApplyAnomalyScores(result, DefaultAnomalyWindow)
+	for i := range result {
+		b.positions.Apply(&result[i])
+	}
+
+	return result
 }
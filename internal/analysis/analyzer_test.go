@@ -0,0 +1,124 @@
+package analysis
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// benchmarkAggregates is the size of the generated fixture: about a full regular session of one-second aggregates
+// for a heavily traded underlying
+const benchmarkAggregates = 1_000_000
+
+// sessionFixture generates a log file of n one-second aggregates spread evenly over a regular session across
+// contracts option contracts, in time order, as the logger writes it
+func sessionFixture(n int, contracts int) []byte {
+	rng := rand.New(rand.NewSource(1))
+	symbols := make([]string, contracts)
+	expirations := []string{"251128", "251205", "251219", "260116", "260320", "261218"}
+	for i := range symbols {
+		optionType := "C"
+		if i%2 == 1 {
+			optionType = "P"
+		}
+		strike := 100000 + (i/2/len(expirations))*2500
+		symbols[i] = fmt.Sprintf("O:AAPL%s%s%08d", expirations[(i/2)%len(expirations)], optionType, strike)
+	}
+
+	open := time.Date(2025, 11, 26, 9, 30, 0, 0, time.FixedZone("EST", -5*60*60)).UnixMilli()
+	sessionMillis := int64(6*60+30) * 60 * 1000
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for i := 0; i < n; i++ {
+		start := open + int64(i)*sessionMillis/int64(n)/1000*1000
+		vwap := 0.05 + rng.Float64()*20
+		agg := Aggregate{
+			EventType:      "A",
+			Symbol:         symbols[rng.Intn(len(symbols))],
+			Volume:         int64(1 + rng.Intn(50)),
+			VWAP:           vwap,
+			Open:           vwap,
+			High:           vwap,
+			Low:            vwap,
+			Close:          vwap,
+			StartTimestamp: start,
+			EndTimestamp:   start + 1000,
+		}
+		encoder.Encode(agg)
+	}
+	return buf.Bytes()
+}
+
+// decodeFixture reads a generated log file back into aggregates
+func decodeFixture(tb testing.TB, data []byte) []Aggregate {
+	tb.Helper()
+	aggregates := make([]Aggregate, 0, bytes.Count(data, []byte("\n")))
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var agg Aggregate
+		if err := json.Unmarshal(scanner.Bytes(), &agg); err != nil {
+			tb.Fatalf("decode fixture: %v", err)
+		}
+		aggregates = append(aggregates, agg)
+	}
+	if err := scanner.Err(); err != nil {
+		tb.Fatalf("read fixture: %v", err)
+	}
+	return aggregates
+}
+
+// BenchmarkAggregatePremiums aggregates a generated 1M-line session log file (3,000 contracts) into 1-, 5-, and
+// 60-minute periods; the fixture is generated and decoded before timing starts
+func BenchmarkAggregatePremiums(b *testing.B) {
+	aggregates := decodeFixture(b, sessionFixture(benchmarkAggregates, 3000))
+	for _, periodMinutes := range []int{1, 5, 60} {
+		b.Run(fmt.Sprintf("period=%dm", periodMinutes), func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := AggregatePremiums(aggregates, periodMinutes); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkBucketAggregates times only the bucketing pass over the 1M-line fixture, without the sort and the
+// day-so-far metrics applied to the summaries
+func BenchmarkBucketAggregates(b *testing.B) {
+	aggregates := decodeFixture(b, sessionFixture(benchmarkAggregates, 3000))
+	opts := AggregateOptions{PeriodMinutes: 1}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bucketAggregates(aggregates, opts)
+	}
+}
+
+func TestAggregatePremiumsSortsPeriods(t *testing.T) {
+	aggregates := []Aggregate{
+		{Symbol: "O:AAPL251128C00150000", Volume: 10, VWAP: 2, StartTimestamp: 1764170400000, EndTimestamp: 1764170401000},
+		{Symbol: "O:AAPL251128P00150000", Volume: 5, VWAP: 1, StartTimestamp: 1764167400000, EndTimestamp: 1764167401000},
+		{Symbol: "O:AAPL251128C00150000", Volume: 1, VWAP: 4, StartTimestamp: 1764168000000, EndTimestamp: 1764168001000},
+	}
+	summaries, err := AggregatePremiums(aggregates, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summaries) != 3 {
+		t.Fatalf("got %d periods, want 3", len(summaries))
+	}
+	for i := 1; i < len(summaries); i++ {
+		if !summaries[i-1].PeriodStart.Before(summaries[i].PeriodStart) {
+			t.Errorf("period %d starts %v, not after period %d at %v", i, summaries[i].PeriodStart, i-1, summaries[i-1].PeriodStart)
+		}
+	}
+	if summaries[0].PutPremium != 500 || summaries[1].CallPremium != 400 || summaries[2].CallPremium != 2000 {
+		t.Errorf("premiums = %v/%v/%v, want put 500, call 400, call 2000", summaries[0].PutPremium, summaries[1].CallPremium, summaries[2].CallPremium)
+	}
+}
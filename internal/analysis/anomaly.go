@@ -0,0 +1,133 @@
+package analysis
+
+import "math"
+
+// Rolling baseline limits for anomaly scores
+const (
+	DefaultAnomalyWindow = 20 // Prior periods in the baseline
+	MinAnomalyPeriods    = 5  // Fewer prior periods than this (e.g. early in the day) leave a period without scores
+)
+
+// AnomalyScores compare a period's call and put premium with the rolling baseline of the periods before it
+// Z-scores are the number of standard deviations above (positive) or below (negative) the baseline mean;
+// a flat baseline (standard deviation 0) gives a z-score of 0
+type AnomalyScores struct {
+	Periods           int     `json:"periods"` // Prior periods in the baseline (up to the window size)
+	CallPremiumMean   float64 `json:"call_premium_mean"`
+	CallPremiumStdDev float64 `json:"call_premium_stddev"`
+	CallPremiumZ      float64 `json:"call_premium_z"`
+	PutPremiumMean    float64 `json:"put_premium_mean"`
+	PutPremiumStdDev  float64 `json:"put_premium_stddev"`
+	PutPremiumZ       float64 `json:"put_premium_z"`
+}
+
+// anomalyPoint is the premium recorded for one period
+type anomalyPoint struct {
+	call float64
+	put  float64
+}
+
+// AnomalyTracker keeps the recent periods' call and put premium to score later periods against
+// Periods without a summary (no trades) count as zero premium, so a burst after a quiet stretch stands out;
+// the baseline never reaches back before the first period recorded, which is taken as the start of the day
+type AnomalyTracker struct {
+	window  int
+	periods map[int64]anomalyPoint // Key: period start (Unix ms)
+	first   int64                  // Start of the earliest period recorded
+	latest  int64                  // Start of the latest period recorded
+}
+
+// NewAnomalyTracker creates an empty tracker with a baseline of window prior periods
+func NewAnomalyTracker(window int) *AnomalyTracker {
+	return &AnomalyTracker{window: window, periods: make(map[int64]anomalyPoint)}
+}
+
+// Record stores a period's premium, replacing any earlier values for the same period (e.g. an in-progress update)
+// Periods too old to be in any later period's baseline are dropped
+func (t *AnomalyTracker) Record(summary TimePeriodSummary) {
+	start := summary.PeriodStart.UnixMilli()
+	if len(t.periods) == 0 || start < t.first {
+		t.first = start
+	}
+	if start > t.latest {
+		t.latest = start
+	}
+	t.periods[start] = anomalyPoint{call: summary.CallPremium, put: summary.PutPremium}
+
+	oldest := t.latest - int64(t.window)*summary.PeriodEnd.Sub(summary.PeriodStart).Milliseconds()
+	for periodStart := range t.periods {
+		if periodStart < oldest {
+			delete(t.periods, periodStart)
+		}
+	}
+}
+
+// Apply sets a summary's anomaly scores from the window of periods before it
+// Summaries with fewer than MinAnomalyPeriods prior periods get nil scores
+func (t *AnomalyTracker) Apply(summary *TimePeriodSummary) {
+	summary.Anomaly = nil
+	length := summary.PeriodEnd.Sub(summary.PeriodStart).Milliseconds()
+	if length <= 0 || len(t.periods) == 0 {
+		return
+	}
+
+	start := summary.PeriodStart.UnixMilli()
+	var calls, puts []float64
+	for k := 1; k <= t.window; k++ {
+		periodStart := start - int64(k)*length
+		if periodStart < t.first {
+			break
+		}
+		point := t.periods[periodStart]
+		calls = append(calls, point.call)
+		puts = append(puts, point.put)
+	}
+	if len(calls) < MinAnomalyPeriods {
+		return
+	}
+
+	callMean, callStdDev := meanStdDev(calls)
+	putMean, putStdDev := meanStdDev(puts)
+	summary.Anomaly = &AnomalyScores{
+		Periods:           len(calls),
+		CallPremiumMean:   callMean,
+		CallPremiumStdDev: callStdDev,
+		CallPremiumZ:      zScore(summary.CallPremium, callMean, callStdDev),
+		PutPremiumMean:    putMean,
+		PutPremiumStdDev:  putStdDev,
+		PutPremiumZ:       zScore(summary.PutPremium, putMean, putStdDev),
+	}
+}
+
+// ApplyAnomalyScores sets each summary's anomaly scores from the window of periods before it
+// Summaries must be sorted by period start
+func ApplyAnomalyScores(summaries []TimePeriodSummary, window int) {
+	tracker := NewAnomalyTracker(window)
+	for i := range summaries {
+		tracker.Apply(&summaries[i])
+		tracker.Record(summaries[i])
+	}
+}
+
+// meanStdDev returns the mean and population standard deviation of values
+func meanStdDev(values []float64) (float64, float64) {
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	mean := sum / float64(len(values))
+
+	var squares float64
+	for _, value := range values {
+		squares += (value - mean) * (value - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)))
+}
+
+// zScore returns how many standard deviations value is from mean (0 when stdDev is 0)
+func zScore(value float64, mean float64, stdDev float64) float64 {
+	if stdDev == 0 {
+		return 0
+	}
+	return (value - mean) / stdDev
+}
@@ -0,0 +1,70 @@
+package analysis
+
+// MetricAvgOptionPrice is the built-in plugin metric for each side's volume-weighted average contract price
+const MetricAvgOptionPrice = "avg_option_price"
+
+func init() {
+	RegisterPeriodMetric(MetricAvgOptionPrice, func() PeriodMetric { return &avgOptionPrice{} })
+}
+
+// AvgOptionPrice is the avg_option_price metric's value: the volume-weighted average price paid per contract
+// (before the ×100 multiplier) on each side, 0 without volume
+// It tells whether a side's premium came from cheap far-from-the-money contracts or expensive ones
+type AvgOptionPrice struct {
+	Call float64 `json:"call"`
+	Put  float64 `json:"put"`
+}
+
+// avgOptionPrice accumulates each side's premium and volume for the avg_option_price metric
+type avgOptionPrice struct {
+	callPremium float64
+	callVolume  int64
+	putPremium  float64
+	putVolume   int64
+}
+
+// Name returns the metric's registered name
+func (m *avgOptionPrice) Name() string {
+	return MetricAvgOptionPrice
+}
+
+// Update adds an aggregate's premium and volume to its side
+func (m *avgOptionPrice) Update(agg Aggregate) {
+	optionType, err := ParseOptionType(agg.Symbol)
+	if err != nil {
+		return
+	}
+	premium := CalculatePremium(agg.Volume, agg.VWAP)
+	switch optionType {
+	case "call":
+		m.callPremium += premium
+		m.callVolume += agg.Volume
+	case "put":
+		m.putPremium += premium
+		m.putVolume += agg.Volume
+	}
+}
+
+// Merge adds another period's premium and volume
+func (m *avgOptionPrice) Merge(other PeriodMetric) {
+	add, ok := other.(*avgOptionPrice)
+	if !ok {
+		return
+	}
+	m.callPremium += add.callPremium
+	m.callVolume += add.callVolume
+	m.putPremium += add.putPremium
+	m.putVolume += add.putVolume
+}
+
+// Finalize sets the summary's avg_option_price
+func (m *avgOptionPrice) Finalize(summary *TimePeriodSummary) {
+	var value AvgOptionPrice
+	if m.callVolume > 0 {
+		value.Call = m.callPremium / float64(m.callVolume) / 100
+	}
+	if m.putVolume > 0 {
+		value.Put = m.putPremium / float64(m.putVolume) / 100
+	}
+	summary.SetMetric(MetricAvgOptionPrice, value)
+}
@@ -0,0 +1,33 @@
+package analysis
+
+// AddVolume adds an aggregate's volume to the call or put side of the summary and folds its strike into that side's
+// volume-weighted average strike
+func (s *TimePeriodSummary) AddVolume(optionType string, strike float64, volume int64) {
+	switch optionType {
+	case "call":
+		s.CallAvgStrike = weightedStrike(s.CallAvgStrike, s.CallVolume, strike, volume)
+		s.CallVolume += volume
+	case "put":
+		s.PutAvgStrike = weightedStrike(s.PutAvgStrike, s.PutVolume, strike, volume)
+		s.PutVolume += volume
+	}
+}
+
+// weightedStrike combines two volume-weighted average strikes, keeping the current average when neither has volume
+// Averages carry their own weight in the side's volume, so summaries decoded from JSON merge the same as fresh ones
+func weightedStrike(avg float64, volume int64, addAvg float64, addVolume int64) float64 {
+	total := volume + addVolume
+	if total <= 0 {
+		return avg
+	}
+	return (avg*float64(volume) + addAvg*float64(addVolume)) / float64(total)
+}
+
+// ClearStrikeLevels zeroes the average strikes and walls, for summaries that merge several underlyings, whose strikes
+// aren't on a common price scale
+func (s *TimePeriodSummary) ClearStrikeLevels() {
+	s.CallAvgStrike = 0
+	s.PutAvgStrike = 0
+	s.CallWall = nil
+	s.PutWall = nil
+}
@@ -0,0 +1,103 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ContractTracker records the contracts traded so far in a day, to tell which ones a period traded for the first time
+type ContractTracker struct {
+	seen map[string]bool
+}
+
+// NewContractTracker creates an empty contract tracker
+func NewContractTracker() *ContractTracker {
+	return &ContractTracker{seen: make(map[string]bool)}
+}
+
+// Add records a contract and reports whether it is the first time it has traded
+func (t *ContractTracker) Add(symbol string) bool {
+	if t.seen[symbol] {
+		return false
+	}
+	t.seen[symbol] = true
+	return true
+}
+
+// AddPeriod records every contract a summary traded, e.g. to seed the tracker from summaries computed earlier in the day
+func (t *ContractTracker) AddPeriod(summary TimePeriodSummary) {
+	for symbol := range summary.contracts {
+		t.seen[symbol] = true
+	}
+}
+
+// AddContract records a contract traded in the period, updating UniqueContracts and the call or put count
+// isNew marks its first trade of the day (see ContractTracker) and increments NewContracts
+func (s *TimePeriodSummary) AddContract(symbol string, isNew bool) {
+	if s.contracts == nil {
+		s.contracts = make(map[string]bool)
+	}
+	if !s.contracts[symbol] {
+		s.contracts[symbol] = true
+		s.UniqueContracts = len(s.contracts)
+		if contract, err := ParseOptionSymbol(symbol); err == nil {
+			if contract.Type == "call" {
+				s.UniqueCallContracts++
+			} else if contract.Type == "put" {
+				s.UniquePutContracts++
+			}
+		}
+	}
+	if isNew {
+		s.NewContracts++
+	}
+}
+
+// ContractIndex lists the contracts traded across summaries, sorted, and for each summary the indexes of its contracts
+// in that list. Contract sets aren't part of a summary's JSON, so this is how they are saved with it (see RestoreContracts)
+func ContractIndex(summaries []TimePeriodSummary) (symbols []string, sets [][]int) {
+	seen := make(map[string]bool)
+	for _, summary := range summaries {
+		for symbol := range summary.contracts {
+			if !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	sort.Strings(symbols)
+
+	index := make(map[string]int, len(symbols))
+	for i, symbol := range symbols {
+		index[symbol] = i
+	}
+	sets = make([][]int, len(summaries))
+	for i, summary := range summaries {
+		set := make([]int, 0, len(summary.contracts))
+		for symbol := range summary.contracts {
+			set = append(set, index[symbol])
+		}
+		sort.Ints(set)
+		sets[i] = set
+	}
+	return symbols, sets
+}
+
+// RestoreContracts sets each summary's contracts from ContractIndex output, leaving its counts unchanged,
+// so summaries read back from JSON can seed a ContractTracker or take incremental updates
+func RestoreContracts(summaries []TimePeriodSummary, symbols []string, sets [][]int) error {
+	if len(sets) != len(summaries) {
+		return fmt.Errorf("contract sets for %d summaries, expected %d", len(sets), len(summaries))
+	}
+	for i := range summaries {
+		contracts := make(map[string]bool, len(sets[i]))
+		for _, index := range sets[i] {
+			if index < 0 || index >= len(symbols) {
+				return fmt.Errorf("contract index %d out of range", index)
+			}
+			contracts[symbols[index]] = true
+		}
+		summaries[i].contracts = contracts
+	}
+	return nil
+}
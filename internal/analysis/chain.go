@@ -0,0 +1,107 @@
+package analysis
+
+import (
+	"sort"
+	"time"
+)
+
+// ChainContract is one contract's trading for the day, as a row of an option chain view
+type ChainContract struct {
+	Symbol           string  `json:"symbol"`
+	OptionType       string  `json:"option_type"` // "call" or "put"
+	Strike           float64 `json:"strike"`
+	Volume           int64   `json:"volume"`
+	Premium          float64 `json:"premium"`
+	LastVWAP         float64 `json:"last_vwap"`      // VWAP of the contract's latest transaction
+	LastTimestamp    int64   `json:"last_timestamp"` // Start of the latest transaction (Unix ms)
+	TransactionCount int     `json:"transaction_count"`
+
+	// Set only with open interest data (see ApplyChainOpenInterest)
+	OpenInterest  int64   `json:"open_interest,omitempty"`
+	VolumeOIRatio float64 `json:"volume_oi_ratio,omitempty"`
+
+	// Volatility implied by the latest transaction's VWAP at the underlying's price at the time; set only with spot
+	// prices, and only when a volatility between 1% and 500% matches (see ApplyChainVolatility)
+	ImpliedVolatility float64 `json:"implied_volatility,omitempty"`
+}
+
+// BuildChain totals the day's transactions in each contract expiring on expiration (zero for every expiration),
+// ordered by strike with the call before the put
+// Transactions whose symbol can't be parsed are skipped
+func BuildChain(aggregates []Aggregate, expiration time.Time) []ChainContract {
+	contracts := make(map[string]*ChainContract)
+	for _, agg := range aggregates {
+		parsed, err := ParseOptionSymbol(agg.Symbol)
+		if err != nil {
+			continue
+		}
+		if !expiration.IsZero() && !parsed.Expiration.Equal(expiration) {
+			continue
+		}
+
+		contract, exists := contracts[agg.Symbol]
+		if !exists {
+			contract = &ChainContract{Symbol: agg.Symbol, OptionType: parsed.Type, Strike: parsed.Strike}
+			contracts[agg.Symbol] = contract
+		}
+		contract.Volume += agg.Volume
+		contract.Premium += CalculatePremium(agg.Volume, agg.VWAP)
+		contract.TransactionCount++
+		if !exists || agg.StartTimestamp >= contract.LastTimestamp {
+			contract.LastTimestamp = agg.StartTimestamp
+			contract.LastVWAP = agg.VWAP
+		}
+	}
+
+	chain := make([]ChainContract, 0, len(contracts))
+	for _, contract := range contracts {
+		chain = append(chain, *contract)
+	}
+	sort.Slice(chain, func(i, j int) bool {
+		if chain[i].Strike != chain[j].Strike {
+			return chain[i].Strike < chain[j].Strike
+		}
+		if chain[i].OptionType != chain[j].OptionType {
+			return chain[i].OptionType == "call"
+		}
+		return chain[i].Symbol < chain[j].Symbol
+	})
+	return chain
+}
+
+// ApplyChainOpenInterest sets each contract's open interest and volume/OI ratio; contracts without open interest
+// are left unset
+func ApplyChainOpenInterest(chain []ChainContract, openInterest map[string]int64) {
+	for i := range chain {
+		oi := openInterest[chain[i].Symbol]
+		if oi <= 0 {
+			continue
+		}
+		chain[i].OpenInterest = oi
+		chain[i].VolumeOIRatio = float64(chain[i].Volume) / float64(oi)
+	}
+}
+
+// ApplyChainVolatility sets each contract's implied volatility from its latest transaction
+// closes maps one-minute bar starts (Unix ms) to the underlying's close; each contract uses the latest close at or
+// before its latest transaction, as in ApplyGreeks. Contracts with no earlier close or no matching volatility are
+// left unset
+func ApplyChainVolatility(chain []ChainContract, closes map[int64]float64, rate float64) {
+	if len(closes) == 0 {
+		return
+	}
+	prices := newSpotSeries(closes)
+	for i := range chain {
+		spot, ok := prices.at(chain[i].LastTimestamp)
+		if !ok {
+			continue
+		}
+		years, err := YearsToExpiration(Aggregate{Symbol: chain[i].Symbol, StartTimestamp: chain[i].LastTimestamp})
+		if err != nil {
+			continue
+		}
+		if iv, ok := ImpliedVolatility(chain[i].OptionType, chain[i].LastVWAP, spot, chain[i].Strike, years, rate); ok {
+			chain[i].ImpliedVolatility = iv
+		}
+	}
+}
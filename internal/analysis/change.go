@@ -0,0 +1,60 @@
+package analysis
+
+// PeriodChange is a period's flow against the period just before it, to show the momentum of flow rather than its level
+// Percent changes are null when the prior period's value was 0, and ratio changes when either ratio is infinite
+type PeriodChange struct {
+	CallPremium     float64  `json:"call_premium"`       // Change in call premium
+	CallPremiumPct  *float64 `json:"call_premium_pct"`   // Percent change in call premium
+	PutPremium      float64  `json:"put_premium"`        // Change in put premium
+	PutPremiumPct   *float64 `json:"put_premium_pct"`    // Percent change in put premium
+	CallPutRatio    *float64 `json:"call_put_ratio"`     // Change in the call/put premium ratio
+	CallPutRatioPct *float64 `json:"call_put_ratio_pct"` // Percent change in the call/put premium ratio
+}
+
+// DiffSummaries returns the change in call premium, put premium, and call/put ratio from previous to current
+func DiffSummaries(previous TimePeriodSummary, current TimePeriodSummary) PeriodChange {
+	change := PeriodChange{
+		CallPremium:    current.CallPremium - previous.CallPremium,
+		CallPremiumPct: percentChange(previous.CallPremium, current.CallPremium),
+		PutPremium:     current.PutPremium - previous.PutPremium,
+		PutPremiumPct:  percentChange(previous.PutPremium, current.PutPremium),
+	}
+	if previous.CallPutRatio != nil && current.CallPutRatio != nil {
+		ratioChange := *current.CallPutRatio - *previous.CallPutRatio
+		change.CallPutRatio = &ratioChange
+		change.CallPutRatioPct = percentChange(*previous.CallPutRatio, *current.CallPutRatio)
+	}
+	return change
+}
+
+// ChangeFrom returns current's change from the period before it, or from an empty period when nothing traded in
+// that period (previous is nil)
+func ChangeFrom(previous *TimePeriodSummary, current TimePeriodSummary) *PeriodChange {
+	if previous == nil {
+		empty := TimePeriodSummary{CallPutRatio: Ratio(0, 0)}
+		previous = &empty
+	}
+	change := DiffSummaries(*previous, current)
+	return &change
+}
+
+// ApplyPeriodChanges sets each summary's change from the period before it, in time order
+// A period following a gap (no trades in the period before it, e.g. without zero fill) is compared with an empty period
+func ApplyPeriodChanges(summaries []TimePeriodSummary) {
+	for i := range summaries {
+		var previous *TimePeriodSummary
+		if i > 0 && summaries[i-1].PeriodEnd.Equal(summaries[i].PeriodStart) {
+			previous = &summaries[i-1]
+		}
+		summaries[i].Change = ChangeFrom(previous, summaries[i])
+	}
+}
+
+// percentChange returns the percent change from before to after, or nil when before is 0
+func percentChange(before float64, after float64) *float64 {
+	if before == 0 {
+		return nil
+	}
+	pct := (after - before) / before * 100
+	return &pct
+}
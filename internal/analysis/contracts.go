@@ -0,0 +1,135 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Transaction sort orders
+const (
+	SortPremium = "premium" // Highest premium first
+	SortTime    = "time"    // Oldest first
+	SortVolume  = "volume"  // Highest volume first
+)
+
+// AggregateByContract groups transactions into one total per option contract
+const AggregateByContract = "contract"
+
+// ValidateSort checks that a transaction sort order is supported
+func ValidateSort(sortBy string) error {
+	switch sortBy {
+	case SortPremium, SortTime, SortVolume:
+		return nil
+	}
+	return fmt.Errorf("invalid sort %q, expected %q, %q, or %q", sortBy, SortPremium, SortTime, SortVolume)
+}
+
+// ValidateAggregation checks that a transaction aggregation mode is supported
+func ValidateAggregation(aggregate string) error {
+	if aggregate != AggregateByContract {
+		return fmt.Errorf("invalid aggregate %q, expected %q", aggregate, AggregateByContract)
+	}
+	return nil
+}
+
+// ContractTotal is the combined volume and premium of one option contract over a window
+type ContractTotal struct {
+	Symbol           string  `json:"symbol"`
+	OptionType       string  `json:"option_type"`
+	Strike           float64 `json:"strike"`
+	Expiration       string  `json:"expiration"` // YYYY-MM-DD
+	Volume           int64   `json:"volume"`
+	Premium          float64 `json:"premium"`
+	VWAP             float64 `json:"vwap"` // Volume-weighted across the contract's transactions
+	TransactionCount int     `json:"transaction_count"`
+	FirstTimestamp   int64   `json:"first_timestamp"` // Start of the earliest transaction (Unix ms)
+	LastTimestamp    int64   `json:"last_timestamp"`  // End of the latest transaction (Unix ms)
+
+	// Set only with open interest data (see ApplyContractOpenInterest)
+	OpenInterest  int64   `json:"open_interest,omitempty"`   // Contracts open at the start of the session
+	VolumeOIRatio float64 `json:"volume_oi_ratio,omitempty"` // Volume / open interest; above 1 means more traded than was open
+}
+
+// TotalByContract combines transactions into one total per contract
+// Transactions whose symbol can't be parsed are skipped; totals are returned by premium, highest first
+func TotalByContract(aggregates []Aggregate) []ContractTotal {
+	totals := make(map[string]*ContractTotal)
+	for _, agg := range aggregates {
+		parsed, err := ParseOptionSymbol(agg.Symbol)
+		if err != nil {
+			continue
+		}
+
+		total, exists := totals[agg.Symbol]
+		if !exists {
+			total = &ContractTotal{
+				Symbol:         agg.Symbol,
+				OptionType:     parsed.Type,
+				Strike:         parsed.Strike,
+				Expiration:     parsed.Expiration.Format("2006-01-02"),
+				FirstTimestamp: agg.StartTimestamp,
+				LastTimestamp:  agg.EndTimestamp,
+			}
+			totals[agg.Symbol] = total
+		}
+
+		total.Volume += agg.Volume
+		total.Premium += CalculatePremium(agg.Volume, agg.VWAP)
+		total.TransactionCount++
+		if agg.StartTimestamp < total.FirstTimestamp {
+			total.FirstTimestamp = agg.StartTimestamp
+		}
+		if agg.EndTimestamp > total.LastTimestamp {
+			total.LastTimestamp = agg.EndTimestamp
+		}
+	}
+
+	result := make([]ContractTotal, 0, len(totals))
+	for _, total := range totals {
+		if total.Volume > 0 {
+			total.VWAP = total.Premium / float64(total.Volume) / 100
+		}
+		result = append(result, *total)
+	}
+	SortContractTotals(result, SortPremium)
+	return result
+}
+
+// SortAggregates orders transactions in place by premium or volume (highest first) or time (oldest first)
+// Ties keep their existing order
+func SortAggregates(aggregates []Aggregate, sortBy string) {
+	sort.SliceStable(aggregates, func(i, j int) bool {
+		a, b := aggregates[i], aggregates[j]
+		switch sortBy {
+		case SortPremium:
+			return CalculatePremium(a.Volume, a.VWAP) > CalculatePremium(b.Volume, b.VWAP)
+		case SortVolume:
+			return a.Volume > b.Volume
+		default:
+			return a.StartTimestamp < b.StartTimestamp
+		}
+	})
+}
+
+// SortContractTotals orders contract totals in place by premium or volume (highest first) or first trade time (oldest first)
+// Ties are broken by symbol so the order is stable across requests
+func SortContractTotals(totals []ContractTotal, sortBy string) {
+	sort.Slice(totals, func(i, j int) bool {
+		a, b := totals[i], totals[j]
+		switch sortBy {
+		case SortVolume:
+			if a.Volume != b.Volume {
+				return a.Volume > b.Volume
+			}
+		case SortTime:
+			if a.FirstTimestamp != b.FirstTimestamp {
+				return a.FirstTimestamp < b.FirstTimestamp
+			}
+		default:
+			if a.Premium != b.Premium {
+				return a.Premium > b.Premium
+			}
+		}
+		return a.Symbol < b.Symbol
+	})
+}
@@ -0,0 +1,102 @@
+package analysis
+
+import (
+	"math"
+	"time"
+)
+
+// FlowSample pairs a period's net premium with the underlying's return over the following periods
+type FlowSample struct {
+	PeriodStart   time.Time `json:"period_start"`
+	NetPremium    float64   `json:"net_premium"`    // Call premium minus put premium
+	ForwardReturn float64   `json:"forward_return"` // Underlying return from the period's close to the close horizon periods later
+}
+
+// CorrelationPoint is the correlation of the window of samples ending at one period
+type CorrelationPoint struct {
+	PeriodStart time.Time `json:"period_start"`
+	Correlation float64   `json:"correlation"`
+	Samples     int       `json:"samples"`
+}
+
+// FlowSamples pairs each period's net premium with the underlying's return over the next horizon periods
+// closes maps a period start (Unix milliseconds) to the underlying's last price in that period;
+// periods without a close at either end are skipped
+func FlowSamples(summaries []TimePeriodSummary, closes map[int64]float64, horizon int) []FlowSample {
+	if horizon <= 0 {
+		horizon = 1
+	}
+
+	samples := make([]FlowSample, 0, len(summaries))
+	for _, summary := range summaries {
+		length := summary.PeriodEnd.Sub(summary.PeriodStart)
+		start, ok := closes[summary.PeriodStart.UnixMilli()]
+		if !ok || start <= 0 {
+			continue
+		}
+		end, ok := closes[summary.PeriodStart.Add(time.Duration(horizon)*length).UnixMilli()]
+		if !ok {
+			continue
+		}
+
+		samples = append(samples, FlowSample{
+			PeriodStart:   summary.PeriodStart,
+			NetPremium:    summary.CallPremium - summary.PutPremium,
+			ForwardReturn: end/start - 1,
+		})
+	}
+	return samples
+}
+
+// RollingCorrelation computes the correlation of net premium and forward return over each window of samples
+// Samples must be in time order; windows where either series is constant have no correlation and are skipped
+func RollingCorrelation(samples []FlowSample, window int) []CorrelationPoint {
+	var points []CorrelationPoint
+	if window < 2 {
+		return points
+	}
+
+	for end := window; end <= len(samples); end++ {
+		correlation, ok := FlowCorrelation(samples[end-window : end])
+		if !ok {
+			continue
+		}
+		points = append(points, CorrelationPoint{
+			PeriodStart: samples[end-1].PeriodStart,
+			Correlation: correlation,
+			Samples:     window,
+		})
+	}
+	return points
+}
+
+// FlowCorrelation returns the Pearson correlation of net premium and forward return across samples
+// ok is false with fewer than two samples or when either series is constant
+func FlowCorrelation(samples []FlowSample) (correlation float64, ok bool) {
+	n := float64(len(samples))
+	if n < 2 {
+		return 0, false
+	}
+
+	var meanX, meanY float64
+	for _, sample := range samples {
+		meanX += sample.NetPremium
+		meanY += sample.ForwardReturn
+	}
+	meanX /= n
+	meanY /= n
+
+	var covariance, varianceX, varianceY float64
+	for _, sample := range samples {
+		dx := sample.NetPremium - meanX
+		dy := sample.ForwardReturn - meanY
+		covariance += dx * dy
+		varianceX += dx * dx
+		varianceY += dy * dy
+	}
+	if varianceX == 0 || varianceY == 0 {
+		return 0, false
+	}
+
+	return covariance / math.Sqrt(varianceX*varianceY), true
+}
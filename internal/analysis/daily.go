@@ -0,0 +1,90 @@
+package analysis
+
+import (
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// PeakPeriod identifies the period of a day with the most total premium
+type PeakPeriod struct {
+	PeriodStart  time.Time `json:"period_start"`
+	PeriodEnd    time.Time `json:"period_end"`
+	Session      string    `json:"session"`
+	TotalPremium float64   `json:"total_premium"`
+	CallPutRatio *float64  `json:"call_put_ratio"`
+}
+
+// DailySummary rolls up every period of a ticker and date into day totals, so clients don't have to sum periods
+type DailySummary struct {
+	Ticker          string        `json:"ticker"`
+	Date            string        `json:"date"`
+	PeriodMinutes   int           `json:"period_minutes"` // Length of the periods the peak period is chosen from
+	Periods         int           `json:"periods"`        // Periods with trades
+	CallPremium     float64       `json:"call_premium"`
+	PutPremium      float64       `json:"put_premium"`
+	TotalPremium    float64       `json:"total_premium"`
+	CallPutRatio    *float64      `json:"call_put_ratio"` // Null when there is call premium but no put premium
+	CallVolume      int64         `json:"call_volume"`
+	PutVolume       int64         `json:"put_volume"`
+	UniqueContracts int           `json:"unique_contracts"` // Distinct contracts traded during the day
+	PeakPeriod      *PeakPeriod   `json:"peak_period,omitempty"`
+	LargestTrade    *LargestTrade `json:"largest_trade,omitempty"`
+}
+
+// SummarizeDaily rolls up a day's period summaries (as returned by AggregatePremiumsWithOptions) into day totals
+// Ties for the peak period keep the earlier one
+func SummarizeDaily(ticker string, dateStr string, periodMinutes int, summaries []TimePeriodSummary) DailySummary {
+	daily := DailySummary{
+		Ticker:        ticker,
+		Date:          dateStr,
+		PeriodMinutes: periodMinutes,
+	}
+
+	var total TimePeriodSummary
+	for _, summary := range summaries {
+		total.Merge(summary)
+		daily.Periods++
+
+		if daily.PeakPeriod == nil || summary.TotalPremium > daily.PeakPeriod.TotalPremium {
+			daily.PeakPeriod = &PeakPeriod{
+				PeriodStart:  summary.PeriodStart,
+				PeriodEnd:    summary.PeriodEnd,
+				Session:      summary.Session,
+				TotalPremium: summary.TotalPremium,
+				CallPutRatio: summary.CallPutRatio,
+			}
+		}
+	}
+
+	daily.CallPremium = total.CallPremium
+	daily.PutPremium = total.PutPremium
+	daily.TotalPremium = total.TotalPremium
+	daily.CallPutRatio = total.CallPutRatio
+	daily.CallVolume = total.CallVolume
+	daily.PutVolume = total.PutVolume
+	daily.LargestTrade = total.LargestTrade
+
+	// Summaries decoded from JSON (e.g. sidecars) don't carry their contract sets, but each contract is new in exactly
+	// one period of the day, so the new contract counts add up to the same total
+	daily.UniqueContracts = total.UniqueContracts
+	if daily.UniqueContracts == 0 {
+		daily.UniqueContracts = total.NewContracts
+	}
+
+	return daily
+}
+
+// DailyKey returns the underlying ticker and date (YYYY-MM-DD in the analysis timezone) of a day's aggregates,
+// taken from the first aggregate with a parseable option symbol (empty strings if there is none)
+func DailyKey(aggregates []Aggregate) (string, string) {
+	for _, agg := range aggregates {
+		contract, err := ParseOptionSymbol(agg.Symbol)
+		if err != nil {
+			continue
+		}
+		date := time.UnixMilli(agg.StartTimestamp).In(market.AnalysisLocation()).Format("2006-01-02")
+		return contract.Underlying, date
+	}
+	return "", ""
+}
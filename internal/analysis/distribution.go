@@ -0,0 +1,99 @@
+package analysis
+
+// TradeDistribution describes the premiums of one side's individual trades (aggregates) in a period, so a total
+// built from many small prints can be told apart from one built from a few large ones
+type TradeDistribution struct {
+	Count  int     `json:"count"`  // Trades in the period
+	Median float64 `json:"median"` // Median trade premium
+	P90    float64 `json:"p90"`    // 90th percentile trade premium
+	Max    float64 `json:"max"`    // Largest trade premium
+
+	digest *TDigest // Premiums behind Median and P90 (kept for Merge and incremental updates)
+}
+
+// PremiumDistribution holds the trade premium distribution of a period's calls and puts
+// Median and P90 are t-digest estimates, exact for periods with fewer than about 50 trades per side. Summaries decoded
+// from JSON don't carry the digests: merging them or adding trades keeps Count and Max exact, but Median and P90 then
+// only reflect the trades added since
+type PremiumDistribution struct {
+	Call TradeDistribution `json:"call"`
+	Put  TradeDistribution `json:"put"`
+}
+
+// Add records one aggregate's premium; call Compute before reading Median and P90
+func (d *PremiumDistribution) Add(optionType string, premium float64) {
+	if side := d.side(optionType); side != nil {
+		side.add(premium)
+	}
+}
+
+// Merge adds another period's trades; call Compute before reading Median and P90
+func (d *PremiumDistribution) Merge(other PremiumDistribution) {
+	d.Call.merge(other.Call)
+	d.Put.merge(other.Put)
+}
+
+// Compute updates each side's Median and P90 from the trades recorded so far
+func (d *PremiumDistribution) Compute() {
+	d.Call.compute()
+	d.Put.compute()
+}
+
+// clone returns a copy whose digests can be added to without changing the original
+func (d PremiumDistribution) clone() PremiumDistribution {
+	if d.Call.digest != nil {
+		d.Call.digest = d.Call.digest.Clone()
+	}
+	if d.Put.digest != nil {
+		d.Put.digest = d.Put.digest.Clone()
+	}
+	return d
+}
+
+// side returns the distribution for an option type (nil for an unknown type)
+func (d *PremiumDistribution) side(optionType string) *TradeDistribution {
+	switch optionType {
+	case "call":
+		return &d.Call
+	case "put":
+		return &d.Put
+	}
+	return nil
+}
+
+// add records one trade's premium
+func (t *TradeDistribution) add(premium float64) {
+	if t.digest == nil {
+		t.digest = NewTDigest(DefaultCompression)
+	}
+	t.digest.Add(premium)
+	t.Count++
+	if premium > t.Max {
+		t.Max = premium
+	}
+}
+
+// merge adds another distribution's trades
+func (t *TradeDistribution) merge(other TradeDistribution) {
+	if other.digest != nil {
+		if t.digest == nil {
+			t.digest = NewTDigest(DefaultCompression)
+		}
+		t.digest.Merge(other.digest)
+	}
+	t.Count += other.Count
+	if other.Max > t.Max {
+		t.Max = other.Max
+	}
+}
+
+// compute sets Median and P90 from the digest, leaving distributions decoded from JSON unchanged until trades are added
+func (t *TradeDistribution) compute() {
+	if t.digest == nil || t.digest.Count() == 0 {
+		return
+	}
+	t.Median = t.digest.Quantile(0.5)
+	t.P90 = t.digest.Quantile(0.9)
+	// Cached histories hold many periods, so don't keep the emptied buffer's capacity around
+	t.digest.buffer = nil
+}
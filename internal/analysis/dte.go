@@ -0,0 +1,159 @@
+package analysis
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// Expiration buckets, by calendar days from the trade date (ET) to the contract's expiration
+const (
+	DTEZero    = "0dte"    // Expires the day it trades
+	DTEWeekly  = "weekly"  // 1 to 7 days
+	DTEMonthly = "monthly" // 8 days to a year
+	DTELeaps   = "leaps"   // More than a year
+)
+
+// Expiration bucket boundaries in days to expiration
+const (
+	WeeklyMaxDTE  = 7
+	MonthlyMaxDTE = 365
+)
+
+// DaysToExpiration returns the calendar days from an aggregate's trade date in ET to its contract's expiration
+func DaysToExpiration(agg Aggregate) (int, error) {
+	expiration, err := ParseExpiration(agg.Symbol)
+	if err != nil {
+		return 0, err
+	}
+	traded := time.UnixMilli(agg.StartTimestamp).In(market.Location)
+	tradeDate := time.Date(traded.Year(), traded.Month(), traded.Day(), 0, 0, 0, 0, time.UTC)
+	return int(expiration.Sub(tradeDate).Hours() / 24), nil
+}
+
+// DTELimit restricts analysis to contracts expiring within a number of days of the trade (see DaysToExpiration)
+// The zero value has no limit; a limit of 0 days keeps only same-day (0DTE) expirations
+type DTELimit struct {
+	Days int  // Inclusive maximum days to expiration
+	Set  bool // Whether the limit applies
+}
+
+// ParseDTELimit parses a maximum days to expiration (e.g. the max_dte query parameter); empty means no limit
+func ParseDTELimit(value string) (DTELimit, error) {
+	if value == "" {
+		return DTELimit{}, nil
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		return DTELimit{}, fmt.Errorf("invalid max_dte %q, must be a non-negative number of days", value)
+	}
+	return DTELimit{Days: days, Set: true}, nil
+}
+
+// Allows reports whether an aggregate's contract is within the limit
+// Late prints after expiration count as 0DTE; aggregates whose expiration can't be parsed only pass without a limit
+func (l DTELimit) Allows(agg Aggregate) bool {
+	if !l.Set {
+		return true
+	}
+	dte, err := DaysToExpiration(agg)
+	return err == nil && dte <= l.Days
+}
+
+// String returns the limit in days, or "" without one
+func (l DTELimit) String() string {
+	if !l.Set {
+		return ""
+	}
+	return strconv.Itoa(l.Days)
+}
+
+// ClassifyDTE returns the expiration bucket for a number of days to expiration
+// Contracts traded after their expiration date (late prints) count as 0DTE
+func ClassifyDTE(dte int) string {
+	switch {
+	case dte <= 0:
+		return DTEZero
+	case dte <= WeeklyMaxDTE:
+		return DTEWeekly
+	case dte <= MonthlyMaxDTE:
+		return DTEMonthly
+	default:
+		return DTELeaps
+	}
+}
+
+// ExpirationPremium is the call and put premium attributed to one expiration bucket
+type ExpirationPremium struct {
+	CallPremium float64 `json:"call_premium"`
+	PutPremium  float64 `json:"put_premium"`
+}
+
+// ExpirationBuckets splits a period's premium by days to expiration
+// Same-day (0DTE) flow is mostly short-term gamma trading; LEAPS flow is longer-dated positioning
+type ExpirationBuckets struct {
+	ZeroDTE ExpirationPremium `json:"0dte"`
+	Weekly  ExpirationPremium `json:"weekly"`
+	Monthly ExpirationPremium `json:"monthly"`
+	Leaps   ExpirationPremium `json:"leaps"`
+}
+
+// Add attributes one aggregate's premium to its expiration bucket
+// Aggregates whose expiration can't be parsed are not counted
+func (b *ExpirationBuckets) Add(agg Aggregate, optionType string, premium float64) {
+	dte, err := DaysToExpiration(agg)
+	if err != nil {
+		return
+	}
+	bucket := b.Bucket(ClassifyDTE(dte))
+	if optionType == "call" {
+		bucket.CallPremium += premium
+	} else if optionType == "put" {
+		bucket.PutPremium += premium
+	}
+}
+
+// Bucket returns the premium for an expiration bucket (nil for an unknown bucket)
+func (b *ExpirationBuckets) Bucket(name string) *ExpirationPremium {
+	switch name {
+	case DTEZero:
+		return &b.ZeroDTE
+	case DTEWeekly:
+		return &b.Weekly
+	case DTEMonthly:
+		return &b.Monthly
+	case DTELeaps:
+		return &b.Leaps
+	}
+	return nil
+}
+
+// ExpirationSummary is one period's premium broken down by expiration bucket
+type ExpirationSummary struct {
+	PeriodStart time.Time         `json:"period_start"`
+	PeriodEnd   time.Time         `json:"period_end"`
+	Session     string            `json:"session"`
+	Buckets     ExpirationBuckets `json:"buckets"`
+}
+
+// AggregatePremiumsByExpiration groups each period's call and put premium into expiration buckets (0DTE, weekly, monthly, LEAPS)
+// Periods are bucketed the same way as AggregatePremiumsWithOptions and returned in time order
+func AggregatePremiumsByExpiration(aggregates []Aggregate, opts AggregateOptions) ([]ExpirationSummary, error) {
+	summaries, err := AggregatePremiumsWithOptions(aggregates, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ExpirationSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		result = append(result, ExpirationSummary{
+			PeriodStart: summary.PeriodStart,
+			PeriodEnd:   summary.PeriodEnd,
+			Session:     summary.Session,
+			Buckets:     summary.ExpirationBuckets,
+		})
+	}
+	return result, nil
+}
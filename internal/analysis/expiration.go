@@ -0,0 +1,37 @@
+package analysis
+
+import "time"
+
+// ParseExpiration extracts the expiration date from an option symbol
+// Example: "O:AAPL230616C00150000" -> 2023-06-16
+func ParseExpiration(symbol string) (time.Time, error) {
+	parsed, err := ParseOptionSymbol(symbol)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parsed.Expiration, nil
+}
+
+// IsExpired reports whether a contract expired before the given date (contracts are live through their expiration day)
+func IsExpired(symbol string, asOf time.Time) (bool, error) {
+	expiration, err := ParseExpiration(symbol)
+	if err != nil {
+		return false, err
+	}
+	asOfDate := time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, time.UTC)
+	return expiration.Before(asOfDate), nil
+}
+
+// ActiveAggregates returns aggregates for contracts that have not expired as of the given date
+// Aggregates with unparseable symbols are dropped
+func ActiveAggregates(aggregates []Aggregate, asOf time.Time) []Aggregate {
+	active := make([]Aggregate, 0, len(aggregates))
+	for _, agg := range aggregates {
+		expired, err := IsExpired(agg.Symbol, asOf)
+		if err != nil || expired {
+			continue
+		}
+		active = append(active, agg)
+	}
+	return active
+}
@@ -0,0 +1,49 @@
+package analysis
+
+import (
+	"time"
+)
+
+// ExpirySkew is the call and put premium a period traded in one expiration
+// Expiration buckets (see ExpirationBuckets) group many expirations together; the per-expiry ratio tells a spike
+// driven by same-day contracts apart from one driven by a single far-dated expiration
+type ExpirySkew struct {
+	CallPremium  float64  `json:"call_premium"`
+	PutPremium   float64  `json:"put_premium"`
+	CallPutRatio *float64 `json:"call_put_ratio"` // Null when there is call premium but no put premium
+}
+
+// AddExpiry attributes an aggregate's premium to its contract's expiration in ExpirySkew and updates that expiration's ratio
+func (s *TimePeriodSummary) AddExpiry(expiration time.Time, optionType string, premium float64) {
+	if s.ExpirySkew == nil {
+		s.ExpirySkew = make(map[string]ExpirySkew)
+	}
+	date := expiration.Format("2006-01-02")
+	skew := s.ExpirySkew[date]
+	skew.add(optionType, premium)
+	s.ExpirySkew[date] = skew
+}
+
+// add adds premium to one side and recomputes the ratio
+func (e *ExpirySkew) add(optionType string, premium float64) {
+	if optionType == "call" {
+		e.CallPremium += premium
+	} else if optionType == "put" {
+		e.PutPremium += premium
+	}
+
+	e.CallPutRatio = Ratio(e.CallPremium, e.PutPremium)
+}
+
+// mergeExpirySkew adds another period's per-expiration premium into the summary
+func (s *TimePeriodSummary) mergeExpirySkew(other map[string]ExpirySkew) {
+	for date, add := range other {
+		if s.ExpirySkew == nil {
+			s.ExpirySkew = make(map[string]ExpirySkew, len(other))
+		}
+		skew := s.ExpirySkew[date]
+		skew.add("call", add.CallPremium)
+		skew.add("put", add.PutPremium)
+		s.ExpirySkew[date] = skew
+	}
+}
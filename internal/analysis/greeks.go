@@ -0,0 +1,193 @@
+package analysis
+
+import (
+	"math"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// Default Black-Scholes inputs
+const (
+	DefaultIV           = 0.30 // Volatility used when a contract's implied volatility can't be solved from its price
+	DefaultRiskFreeRate = 0.04
+)
+
+// minYearsToExpiration floors time to expiration so contracts near their final minutes don't divide by ~0
+const minYearsToExpiration = 15.0 / (365 * 24 * 60)
+
+// Greeks are a contract's sensitivities to the underlying price
+type Greeks struct {
+	Delta float64 `json:"delta"` // Change in option price per $1 move in the underlying (0 to 1 for calls, -1 to 0 for puts)
+	Gamma float64 `json:"gamma"` // Change in delta per $1 move in the underlying
+}
+
+// GreeksConfig holds the Black-Scholes inputs not carried by the aggregates
+type GreeksConfig struct {
+	DefaultIV    float64 // Fallback volatility (annualized, e.g. 0.30)
+	RiskFreeRate float64 // Annualized, continuously compounded
+}
+
+// PeriodGreeks holds a period's premium weighted by delta and its net gamma
+// Raw premium over-weights cheap far out-of-the-money contracts; weighting by |delta| counts premium by its
+// directional exposure instead
+type PeriodGreeks struct {
+	DeltaCallPremium float64 `json:"delta_call_premium"` // Sum of call premium × delta
+	DeltaPutPremium  float64 `json:"delta_put_premium"`  // Sum of put premium × |delta|
+	NetGamma         float64 `json:"net_gamma"`          // Call minus put gamma in shares per $1 move (gamma × volume × 100)
+}
+
+// normCDF is the standard normal cumulative distribution function
+func normCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// normPDF is the standard normal probability density function
+func normPDF(x float64) float64 {
+	return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+}
+
+// d1d2 returns the Black-Scholes d1 and d2 terms
+func d1d2(spot float64, strike float64, years float64, iv float64, rate float64) (float64, float64) {
+	volSqrtT := iv * math.Sqrt(years)
+	d1 := (math.Log(spot/strike) + (rate+iv*iv/2)*years) / volSqrtT
+	return d1, d1 - volSqrtT
+}
+
+// BlackScholesPrice returns the theoretical price of a European call or put
+func BlackScholesPrice(optionType string, spot float64, strike float64, years float64, iv float64, rate float64) float64 {
+	d1, d2 := d1d2(spot, strike, years, iv, rate)
+	discount := strike * math.Exp(-rate*years)
+	if optionType == "call" {
+		return spot*normCDF(d1) - discount*normCDF(d2)
+	}
+	return discount*normCDF(-d2) - spot*normCDF(-d1)
+}
+
+// BlackScholesGreeks returns the delta and gamma of a European call or put
+// Invalid inputs (non-positive spot, strike, time, or volatility) return zero greeks
+func BlackScholesGreeks(optionType string, spot float64, strike float64, years float64, iv float64, rate float64) Greeks {
+	if spot <= 0 || strike <= 0 || years <= 0 || iv <= 0 {
+		return Greeks{}
+	}
+	d1, _ := d1d2(spot, strike, years, iv, rate)
+	delta := normCDF(d1)
+	if optionType == "put" {
+		delta--
+	}
+	return Greeks{
+		Delta: delta,
+		Gamma: normPDF(d1) / (spot * iv * math.Sqrt(years)),
+	}
+}
+
+// ImpliedVolatility solves for the volatility that prices a contract at price, by bisection
+// Returns false when no volatility between 1% and 500% matches (e.g. a price below intrinsic value)
+func ImpliedVolatility(optionType string, price float64, spot float64, strike float64, years float64, rate float64) (float64, bool) {
+	if price <= 0 || spot <= 0 || strike <= 0 || years <= 0 {
+		return 0, false
+	}
+
+	low, high := 0.01, 5.0
+	if price < BlackScholesPrice(optionType, spot, strike, years, low, rate) || price > BlackScholesPrice(optionType, spot, strike, years, high, rate) {
+		return 0, false
+	}
+	for i := 0; i < 100 && high-low > 1e-6; i++ {
+		mid := (low + high) / 2
+		if BlackScholesPrice(optionType, spot, strike, years, mid, rate) < price {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return (low + high) / 2, true
+}
+
+// YearsToExpiration returns the time from an aggregate's trade to its contract's 16:00 ET expiration, in years
+func YearsToExpiration(agg Aggregate) (float64, error) {
+	expiration, err := ParseExpiration(agg.Symbol)
+	if err != nil {
+		return 0, err
+	}
+	expiry := time.Date(expiration.Year(), expiration.Month(), expiration.Day(), 16, 0, 0, 0, market.Location)
+	years := expiry.Sub(time.UnixMilli(agg.StartTimestamp)).Hours() / (365 * 24)
+	return math.Max(years, minYearsToExpiration), nil
+}
+
+// ContractGreeks computes an aggregate's greeks at the given underlying spot price
+// Volatility is implied from the aggregate's VWAP when possible, otherwise cfg.DefaultIV is used
+func ContractGreeks(agg Aggregate, spot float64, cfg GreeksConfig) (Greeks, bool) {
+	parsed, err := ParseOptionSymbol(agg.Symbol)
+	if err != nil || spot <= 0 {
+		return Greeks{}, false
+	}
+	years, err := YearsToExpiration(agg)
+	if err != nil {
+		return Greeks{}, false
+	}
+
+	iv, ok := ImpliedVolatility(parsed.Type, agg.VWAP, spot, parsed.Strike, years, cfg.RiskFreeRate)
+	if !ok {
+		iv = cfg.DefaultIV
+	}
+	return BlackScholesGreeks(parsed.Type, spot, parsed.Strike, years, iv, cfg.RiskFreeRate), true
+}
+
+// Add accumulates one aggregate's delta-weighted premium and gamma
+func (g *PeriodGreeks) Add(agg Aggregate, greeks Greeks) {
+	premium := CalculatePremium(agg.Volume, agg.VWAP)
+	gamma := greeks.Gamma * float64(agg.Volume) * 100
+	if greeks.Delta >= 0 {
+		g.DeltaCallPremium += premium * greeks.Delta
+		g.NetGamma += gamma
+	} else {
+		g.DeltaPutPremium += premium * -greeks.Delta
+		g.NetGamma -= gamma
+	}
+}
+
+// Merge adds another period's greeks into g
+func (g *PeriodGreeks) Merge(other PeriodGreeks) {
+	g.DeltaCallPremium += other.DeltaCallPremium
+	g.DeltaPutPremium += other.DeltaPutPremium
+	g.NetGamma += other.NetGamma
+}
+
+// ApplyGreeks sets each summary's Greeks from the aggregates it was built from
+// closes maps one-minute bar starts (Unix ms) to the underlying's close; each aggregate uses the latest close at or
+// before its trade. Aggregates with no earlier close are skipped, and summaries with none priced keep nil Greeks
+func ApplyGreeks(summaries []TimePeriodSummary, aggregates []Aggregate, opts AggregateOptions, closes map[int64]float64, cfg GreeksConfig) {
+	if len(closes) == 0 || opts.PeriodMinutes <= 0 {
+		return
+	}
+
+	prices := newSpotSeries(closes)
+	byPeriod := make(map[int64]*TimePeriodSummary, len(summaries))
+	for i := range summaries {
+		byPeriod[summaries[i].PeriodStart.UnixMilli()] = &summaries[i]
+	}
+
+	for _, agg := range aggregates {
+		if !opts.Includes(agg) {
+			continue
+		}
+		summary, exists := byPeriod[RoundDownToAnchoredPeriod(agg.StartTimestamp, opts.PeriodMinutes, opts.Anchor)]
+		if !exists {
+			continue
+		}
+
+		spot, ok := prices.at(agg.StartTimestamp)
+		if !ok {
+			continue
+		}
+		greeks, ok := ContractGreeks(agg, spot, cfg)
+		if !ok {
+			continue
+		}
+
+		if summary.Greeks == nil {
+			summary.Greeks = &PeriodGreeks{}
+		}
+		summary.Greeks.Add(agg, greeks)
+	}
+}
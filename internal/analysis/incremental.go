@@ -0,0 +1,183 @@
+package analysis
+
+import (
+	"sort"
+	"time"
+)
+
+// DefaultLateGrace is how long after a period closes a late aggregate may still reopen it
+const DefaultLateGrace = 5 * time.Minute
+
+// IncrementalAggregator builds period summaries from aggregates as they arrive, e.g. while tailing a log file
+// Each aggregate updates its own period, so aggregates that arrive late still land in the right one. A period
+// counts as completed once every aggregate for it should have arrived (see PeriodSettled), and is reported by
+// CompletedPeriods once; a late aggregate within the grace window (see SetLateGrace) reopens it, so it is reported
+// again with the corrected totals as a revision. Later aggregates are dropped and counted (see LateDropped)
+// It is not safe for concurrent use
+type IncrementalAggregator struct {
+	opts      AggregateOptions
+	timespan  string                       // Timespan of the incoming aggregates (TimespanSecond or TimespanMinute)
+	periods   map[int64]*TimePeriodSummary // Key: period start (Unix ms)
+	reported  map[int64]bool               // Completed periods already returned by CompletedPeriods
+	current   *TimePeriodSummary           // Period with the latest start
+	contracts *ContractTracker             // Contracts traded so far in the day, for NewContracts
+	cutoff    int64                        // Aggregates for periods ending at or before this (Unix ms) are dropped (see Prune)
+	revised   map[int64]bool               // Reported periods reopened by a late aggregate, to report as a revision
+	grace     int64                        // Late-data grace window (ms)
+	watermark int64                        // Latest aggregate start seen (Unix ms), the stream's own clock for lateness
+	late      int                          // Aggregates dropped for arriving after the grace window
+}
+
+// NewIncrementalAggregator creates an aggregator bucketing aggregates of the given timespan by opts
+// Aggregates excluded by opts (e.g. session or days-to-expiration filters) are ignored
+func NewIncrementalAggregator(opts AggregateOptions, timespan string) *IncrementalAggregator {
+	return &IncrementalAggregator{
+		opts:      opts,
+		timespan:  timespan,
+		periods:   make(map[int64]*TimePeriodSummary),
+		reported:  make(map[int64]bool),
+		contracts: NewContractTracker(),
+		revised:   make(map[int64]bool),
+		grace:     DefaultLateGrace.Milliseconds(),
+	}
+}
+
+// SetLateGrace sets how long after a period closes (its end plus one timespan, see PeriodSettled) an aggregate for
+// it is still accepted, measured against the latest aggregate seen rather than the wall clock so replays behave the
+// same. A negative grace is treated as 0
+func (a *IncrementalAggregator) SetLateGrace(grace time.Duration) {
+	a.grace = max(grace, 0).Milliseconds()
+}
+
+// LateDropped returns how many aggregates were dropped for arriving after their period's grace window
+func (a *IncrementalAggregator) LateDropped() int {
+	return a.late
+}
+
+// Restore starts the aggregator from summaries computed earlier in the day (e.g. the history for a new stream)
+// Summaries are cloned, so they may be shared with a cache. Periods already completed at now count as reported, and
+// every contract they traded as seen. Plugin metrics that can't be cloned are dropped from a restored period if it is
+// updated again
+func (a *IncrementalAggregator) Restore(summaries []TimePeriodSummary, now time.Time) {
+	for _, summary := range summaries {
+		restored := summary.Clone()
+		start := restored.PeriodStart.UnixMilli()
+		a.periods[start] = &restored
+		a.contracts.AddPeriod(restored)
+		if PeriodSettled(restored.PeriodEnd, now, a.timespan) {
+			a.reported[start] = true
+		}
+		if a.current == nil || restored.PeriodStart.After(a.current.PeriodStart) {
+			a.current = &restored
+		}
+		a.watermark = max(a.watermark, start)
+	}
+}
+
+// AddAggregate adds an aggregate to its period and returns the updated period, or nil if the aggregate is excluded
+// by the options, can't be parsed, is for a period dropped by Prune, or arrived after its period's grace window
+// The returned summary belongs to the aggregator and changes with later aggregates; copy it (see Clone) before
+// sharing it with another goroutine
+func (a *IncrementalAggregator) AddAggregate(agg Aggregate) *TimePeriodSummary {
+	if !a.opts.Includes(agg) {
+		return nil
+	}
+	contract, err := ParseOptionSymbol(agg.Symbol)
+	if err != nil {
+		return nil
+	}
+
+	periodStart := RoundDownToAnchoredPeriod(agg.StartTimestamp, a.opts.PeriodMinutes, a.opts.Anchor)
+	periodEnd := periodStart + int64(a.opts.PeriodMinutes*60*1000)
+	if periodEnd <= a.cutoff {
+		return nil
+	}
+	if periodEnd+TimespanDuration(a.timespan).Milliseconds()+a.grace <= a.watermark {
+		a.late++
+		return nil
+	}
+	a.watermark = max(a.watermark, agg.StartTimestamp)
+	summary, exists := a.periods[periodStart]
+	if !exists {
+		summary = NewPeriodSummary(periodStart, periodEnd)
+		a.periods[periodStart] = summary
+	}
+	if a.current == nil || summary.PeriodStart.After(a.current.PeriodStart) {
+		a.current = summary
+	}
+
+	summary.addFlow(agg, contract, CalculatePremium(agg.Volume, agg.VWAP))
+	summary.AddContract(agg.Symbol, a.contracts.Add(agg.Symbol))
+	summary.refreshTotals()
+	summary.ingestedAt = agg.IngestedAt
+	if a.reported[periodStart] {
+		delete(a.reported, periodStart)
+		a.revised[periodStart] = true
+	}
+	return summary
+}
+
+// CurrentPeriod returns the period with the latest start seen so far, or nil before the first aggregate
+// Like AddAggregate's result, it belongs to the aggregator
+func (a *IncrementalAggregator) CurrentPeriod() *TimePeriodSummary {
+	return a.current
+}
+
+// CompletedPeriods returns copies of the periods completed by now that haven't been returned before, oldest first
+// Periods reported before and reopened by a late aggregate are returned again with their Revision incremented. The
+// copies carry no ingest time (see IngestedAt)
+func (a *IncrementalAggregator) CompletedPeriods(now time.Time) []TimePeriodSummary {
+	var completed []TimePeriodSummary
+	for start, summary := range a.periods {
+		if a.reported[start] || !PeriodSettled(summary.PeriodEnd, now, a.timespan) {
+			continue
+		}
+		a.reported[start] = true
+		if a.revised[start] {
+			delete(a.revised, start)
+			summary.Revision++
+		}
+		period := summary.Clone()
+		period.ingestedAt = 0
+		completed = append(completed, period)
+	}
+	sort.Slice(completed, func(i, j int) bool {
+		return completed[i].PeriodStart.Before(completed[j].PeriodStart)
+	})
+	return completed
+}
+
+// Previous returns the period immediately before summary, or nil when nothing traded in it (or it was pruned)
+// Like AddAggregate's result, it belongs to the aggregator
+func (a *IncrementalAggregator) Previous(summary TimePeriodSummary) *TimePeriodSummary {
+	length := summary.PeriodEnd.Sub(summary.PeriodStart)
+	return a.periods[summary.PeriodStart.Add(-length).UnixMilli()]
+}
+
+// Periods returns every period the aggregator holds, oldest first
+// Summaries are shallow copies for reading (e.g. Resample); they share maps with the aggregator's periods
+func (a *IncrementalAggregator) Periods() []TimePeriodSummary {
+	periods := make([]TimePeriodSummary, 0, len(a.periods))
+	for _, summary := range a.periods {
+		periods = append(periods, *summary)
+	}
+	sort.Slice(periods, func(i, j int) bool {
+		return periods[i].PeriodStart.Before(periods[j].PeriodStart)
+	})
+	return periods
+}
+
+// Prune drops the periods that ended before cutoff to bound memory; aggregates for them that arrive later are
+// ignored. The current period is never dropped
+func (a *IncrementalAggregator) Prune(cutoff time.Time) {
+	for start, summary := range a.periods {
+		if summary.PeriodEnd.Before(cutoff) && summary != a.current {
+			delete(a.periods, start)
+			delete(a.reported, start)
+			delete(a.revised, start)
+			if end := summary.PeriodEnd.UnixMilli(); end > a.cutoff {
+				a.cutoff = end
+			}
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package analysis
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// eastern is Eastern Standard Time, for writing test times as the exchange sees them in late November
+var eastern = time.FixedZone("EST", -5*60*60)
+
+// at returns the time on an Eastern date, e.g. at(2025, 11, 26, "09:31:10")
+func at(year int, month time.Month, day int, clock string) time.Time {
+	parsed, err := time.Parse("15:04:05", clock)
+	if err != nil {
+		panic(err)
+	}
+	return time.Date(year, month, day, parsed.Hour(), parsed.Minute(), parsed.Second(), 0, eastern)
+}
+
+// onWednesday returns a time on Wednesday 2025-11-26, a full trading day
+func onWednesday(clock string) time.Time {
+	return at(2025, 11, 26, clock)
+}
+
+// secondAggregate returns a one-second aggregate for symbol starting at start
+func secondAggregate(symbol string, start time.Time, volume int64, vwap float64) Aggregate {
+	return Aggregate{
+		EventType:      "A",
+		Symbol:         symbol,
+		Volume:         volume,
+		VWAP:           vwap,
+		StartTimestamp: start.UnixMilli(),
+		EndTimestamp:   start.Add(time.Second).UnixMilli(),
+	}
+}
+
+// periodStarts returns the start of each summary, in ET, for error messages and comparisons
+func periodStarts(summaries []TimePeriodSummary) []string {
+	starts := make([]string, len(summaries))
+	for i, summary := range summaries {
+		starts[i] = summary.PeriodStart.In(eastern).Format("15:04")
+	}
+	return starts
+}
+
+func TestIncrementalAggregatorRollover(t *testing.T) {
+	const call = "O:AAPL251128C00150000"
+	const put = "O:AAPL251128P00150000"
+	aggregator := NewIncrementalAggregator(AggregateOptions{PeriodMinutes: 5}, TimespanSecond)
+
+	first := aggregator.AddAggregate(secondAggregate(call, onWednesday("09:31:10"), 10, 2))
+	if first == nil || !first.PeriodStart.Equal(onWednesday("09:30:00")) || !first.PeriodEnd.Equal(onWednesday("09:35:00")) {
+		t.Fatalf("first aggregate's period = %+v, want 09:30-09:35", first)
+	}
+	if aggregator.CurrentPeriod() != first {
+		t.Error("CurrentPeriod isn't the first aggregate's period")
+	}
+
+	// The period completes one timespan after it ends, when its last aggregate has arrived
+	if completed := aggregator.CompletedPeriods(onWednesday("09:35:00")); len(completed) != 0 {
+		t.Errorf("completed at the period end = %v, want none until its last second arrives", periodStarts(completed))
+	}
+	completed := aggregator.CompletedPeriods(onWednesday("09:35:01"))
+	if len(completed) != 1 || completed[0].CallPremium != 2000 || completed[0].Revision != 0 {
+		t.Fatalf("completed = %+v, want the 09:30 period with $2,000 of calls", completed)
+	}
+	if again := aggregator.CompletedPeriods(onWednesday("09:35:02")); len(again) != 0 {
+		t.Errorf("completed periods reported again: %v", periodStarts(again))
+	}
+
+	// The next period becomes current
+	second := aggregator.AddAggregate(secondAggregate(put, onWednesday("09:36:00"), 5, 1))
+	if aggregator.CurrentPeriod() != second || !second.PeriodStart.Equal(onWednesday("09:35:00")) {
+		t.Fatalf("current period after rollover = %+v, want 09:35", aggregator.CurrentPeriod())
+	}
+	if previous := aggregator.Previous(*second); previous != first {
+		t.Errorf("Previous(09:35) = %+v, want the 09:30 period", previous)
+	}
+
+	// A late aggregate within the grace window reopens its period without changing the current one
+	reopened := aggregator.AddAggregate(secondAggregate(call, onWednesday("09:34:59"), 1, 4))
+	if reopened != first || aggregator.CurrentPeriod() != second {
+		t.Fatalf("late aggregate updated %+v, current %+v, want the 09:30 period updated and 09:35 current", reopened, aggregator.CurrentPeriod())
+	}
+	completed = aggregator.CompletedPeriods(onWednesday("09:40:01"))
+	if got := periodStarts(completed); !reflect.DeepEqual(got, []string{"09:30", "09:35"}) {
+		t.Fatalf("completed after rollover = %v, want [09:30 09:35]", got)
+	}
+	if completed[0].CallPremium != 2400 || completed[0].Revision != 1 {
+		t.Errorf("revised 09:30 period = $%v calls, revision %d, want $2,400, revision 1", completed[0].CallPremium, completed[0].Revision)
+	}
+	if completed[1].PutPremium != 500 || completed[1].Revision != 0 {
+		t.Errorf("09:35 period = $%v puts, revision %d, want $500, revision 0", completed[1].PutPremium, completed[1].Revision)
+	}
+	if completed[1].NewContracts != 1 || completed[0].NewContracts != 1 {
+		t.Errorf("new contracts = %d, %d, want the call new at 09:30 and the put at 09:35", completed[0].NewContracts, completed[1].NewContracts)
+	}
+
+	// Once the stream is past the grace window, aggregates for the period are dropped and counted
+	aggregator.AddAggregate(secondAggregate(call, onWednesday("09:50:00"), 1, 1))
+	if dropped := aggregator.AddAggregate(secondAggregate(call, onWednesday("09:34:30"), 1, 1)); dropped != nil {
+		t.Errorf("aggregate after the grace window updated %+v, want it dropped", dropped)
+	}
+	if aggregator.LateDropped() != 1 {
+		t.Errorf("LateDropped = %d, want 1", aggregator.LateDropped())
+	}
+
+	// Pruned periods ignore later aggregates without counting them as late
+	aggregator.SetLateGrace(time.Hour)
+	aggregator.Prune(onWednesday("09:45:00"))
+	if got := periodStarts(aggregator.Periods()); !reflect.DeepEqual(got, []string{"09:50"}) {
+		t.Errorf("periods after Prune = %v, want [09:50]", got)
+	}
+	if pruned := aggregator.AddAggregate(secondAggregate(put, onWednesday("09:37:00"), 1, 1)); pruned != nil {
+		t.Errorf("aggregate for a pruned period updated %+v, want it ignored", pruned)
+	}
+	if aggregator.LateDropped() != 1 {
+		t.Errorf("LateDropped after Prune = %d, want 1", aggregator.LateDropped())
+	}
+}
+
+func TestIncrementalAggregatorMinuteTimespan(t *testing.T) {
+	aggregator := NewIncrementalAggregator(AggregateOptions{PeriodMinutes: 5}, TimespanMinute)
+	aggregator.AddAggregate(Aggregate{
+		EventType:      "AM",
+		Symbol:         "O:AAPL251128C00150000",
+		Volume:         1,
+		VWAP:           1,
+		StartTimestamp: onWednesday("09:34:00").UnixMilli(),
+		EndTimestamp:   onWednesday("09:35:00").UnixMilli(),
+	})
+	// The 09:34 minute aggregate is published after 09:35, so the period waits a minute past its end
+	if completed := aggregator.CompletedPeriods(onWednesday("09:35:30")); len(completed) != 0 {
+		t.Errorf("completed at 09:35:30 = %v, want none before its last minute closes", periodStarts(completed))
+	}
+	if completed := aggregator.CompletedPeriods(onWednesday("09:36:00")); len(completed) != 1 {
+		t.Errorf("completed at 09:36 = %v, want the 09:30 period", periodStarts(completed))
+	}
+}
+
+func TestIncrementalAggregatorAnchors(t *testing.T) {
+	tests := []struct {
+		name          string
+		periodMinutes int
+		anchor        string
+		start         string
+		wantStart     string
+	}{
+		// Midnight anchoring aligns to the analysis timezone's clock (Pacific by default): hours start on the hour
+		{"hourly from midnight", 60, AnchorMidnight, "09:45:00", "09:00"},
+		{"hourly from midnight, empty anchor", 60, "", "09:45:00", "09:00"},
+		{"hourly from the open", 60, AnchorOpen, "09:45:00", "09:30"},
+		{"hourly from the open, last second", 60, AnchorOpen, "10:29:59", "09:30"},
+		{"hourly from the open, boundary", 60, AnchorOpen, "10:30:00", "10:30"},
+		{"premarket floors before the open", 60, AnchorOpen, "09:15:00", "08:30"},
+		{"30 minutes from midnight", 30, AnchorMidnight, "09:45:00", "09:30"},
+		{"7 minutes from the open", 7, AnchorOpen, "09:44:00", "09:44"},
+		{"7 minutes from the open, mid-period", 7, AnchorOpen, "09:50:59", "09:44"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := AggregateOptions{PeriodMinutes: tt.periodMinutes, Anchor: tt.anchor}
+			aggregator := NewIncrementalAggregator(opts, TimespanSecond)
+			agg := secondAggregate("O:AAPL251128C00150000", onWednesday(tt.start), 1, 1)
+			summary := aggregator.AddAggregate(agg)
+			if summary == nil {
+				t.Fatal("aggregate was not added")
+			}
+			if got := summary.PeriodStart.In(eastern).Format("15:04"); got != tt.wantStart {
+				t.Errorf("period start = %s, want %s", got, tt.wantStart)
+			}
+			if got := summary.PeriodEnd.Sub(summary.PeriodStart); got != time.Duration(tt.periodMinutes)*time.Minute {
+				t.Errorf("period length = %v, want %d minutes", got, tt.periodMinutes)
+			}
+
+			// The batch aggregator buckets the same aggregate into the same period
+			batch, err := AggregatePremiumsWithOptions([]Aggregate{agg}, opts)
+			if err != nil || len(batch) != 1 || !batch[0].PeriodStart.Equal(summary.PeriodStart) {
+				t.Errorf("batch period = %+v, %v, want it to start at %v", batch, err, summary.PeriodStart)
+			}
+		})
+	}
+}
+
+func TestIncrementalAggregatorSessions(t *testing.T) {
+	regular, err := market.ParseSessionSet(market.SessionRegular)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		sessions    market.SessionSet
+		start       time.Time
+		wantSession string // Empty when the aggregate is excluded
+	}{
+		{"last premarket second", regular, onWednesday("09:29:59"), ""},
+		{"open", regular, onWednesday("09:30:00"), market.SessionRegular},
+		{"last regular second", regular, onWednesday("15:59:59"), market.SessionRegular},
+		{"close", regular, onWednesday("16:00:00"), ""},
+		{"early close", regular, at(2025, 11, 28, "13:00:00"), ""},
+		{"before the early close", regular, at(2025, 11, 28, "12:59:59"), market.SessionRegular},
+		{"holiday", regular, at(2025, 11, 27, "10:00:00"), ""},
+		{"premarket unfiltered", 0, onWednesday("09:29:59"), market.SessionPremarket},
+		{"after hours unfiltered", 0, onWednesday("16:00:00"), market.SessionAfterHours},
+		{"holiday unfiltered", 0, at(2025, 11, 27, "10:00:00"), market.SessionClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aggregator := NewIncrementalAggregator(AggregateOptions{PeriodMinutes: 1, Anchor: AnchorOpen, Sessions: tt.sessions}, TimespanSecond)
+			summary := aggregator.AddAggregate(secondAggregate("O:AAPL251205C00150000", tt.start, 1, 1))
+			switch {
+			case tt.wantSession == "" && summary != nil:
+				t.Errorf("aggregate added to the %s period, want it excluded", summary.Session)
+			case tt.wantSession == "" && aggregator.CurrentPeriod() != nil:
+				t.Error("excluded aggregate created a current period")
+			case tt.wantSession != "" && summary == nil:
+				t.Error("aggregate was excluded")
+			case tt.wantSession != "" && summary.Session != tt.wantSession:
+				t.Errorf("session = %s, want %s", summary.Session, tt.wantSession)
+			}
+		})
+	}
+}
+
+func TestIncrementalAggregatorEmptyPeriods(t *testing.T) {
+	aggregator := NewIncrementalAggregator(AggregateOptions{PeriodMinutes: 5}, TimespanSecond)
+	if aggregator.CurrentPeriod() != nil {
+		t.Error("CurrentPeriod before any aggregate isn't nil")
+	}
+	if completed := aggregator.CompletedPeriods(onWednesday("16:00:00")); len(completed) != 0 {
+		t.Errorf("completed without aggregates = %v, want none", periodStarts(completed))
+	}
+	if summary := aggregator.AddAggregate(secondAggregate("not a symbol", onWednesday("09:31:00"), 1, 1)); summary != nil {
+		t.Errorf("unparseable aggregate added to %+v", summary)
+	}
+	if aggregator.CurrentPeriod() != nil {
+		t.Error("unparseable aggregate created a current period")
+	}
+
+	// Nothing trades from 09:35 to 09:45: those periods are never created or reported
+	aggregator.AddAggregate(secondAggregate("O:AAPL251128C00150000", onWednesday("09:31:00"), 1, 1))
+	last := aggregator.AddAggregate(secondAggregate("O:AAPL251128C00150000", onWednesday("09:46:00"), 1, 1))
+	completed := aggregator.CompletedPeriods(onWednesday("09:50:01"))
+	if got := periodStarts(completed); !reflect.DeepEqual(got, []string{"09:30", "09:45"}) {
+		t.Errorf("completed = %v, want [09:30 09:45] without the empty periods between", got)
+	}
+	if previous := aggregator.Previous(*last); previous != nil {
+		t.Errorf("Previous(09:45) = %+v, want nil for the empty 09:40 period", previous)
+	}
+	if completed[1].NewContracts != 0 || completed[1].UniqueContracts != 1 {
+		t.Errorf("09:45 period has %d new of %d contracts, want 0 of 1 (first traded at 09:30)", completed[1].NewContracts, completed[1].UniqueContracts)
+	}
+}
+
+func TestIncrementalAggregatorRestore(t *testing.T) {
+	const call = "O:AAPL251128C00150000"
+	history, err := AggregatePremiums([]Aggregate{
+		secondAggregate(call, onWednesday("09:31:00"), 1, 1),
+		secondAggregate(call, onWednesday("09:36:00"), 1, 1),
+	}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aggregator := NewIncrementalAggregator(AggregateOptions{PeriodMinutes: 5}, TimespanSecond)
+	aggregator.Restore(history, onWednesday("09:37:00"))
+	if current := aggregator.CurrentPeriod(); current == nil || !current.PeriodStart.Equal(onWednesday("09:35:00")) {
+		t.Fatalf("current after Restore = %+v, want 09:35", current)
+	}
+	// The restored 09:30 period was complete when restored, so only 09:35 is reported
+	if got := periodStarts(aggregator.CompletedPeriods(onWednesday("09:40:01"))); !reflect.DeepEqual(got, []string{"09:35"}) {
+		t.Errorf("completed after Restore = %v, want [09:35]", got)
+	}
+	updated := aggregator.AddAggregate(secondAggregate(call, onWednesday("09:41:00"), 1, 1))
+	if updated.NewContracts != 0 {
+		t.Errorf("restored contract counted as new again")
+	}
+	if history[1].CallVolume != 1 {
+		t.Errorf("Restore changed the history it was given")
+	}
+}
+
+// TestIncrementalAggregatorMatchesBatch feeds a generated session log through the aggregator and checks that every
+// period matches AggregatePremiumsWithOptions over the same aggregates
+func TestIncrementalAggregatorMatchesBatch(t *testing.T) {
+	aggregates := decodeFixture(t, sessionFixture(20000, 200))
+	regular, err := market.ParseSessionSet(market.SessionRegular)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, opts := range []AggregateOptions{
+		{PeriodMinutes: 1},
+		{PeriodMinutes: 5},
+		{PeriodMinutes: 60, Anchor: AnchorOpen},
+		{PeriodMinutes: 15, Anchor: AnchorOpen, Sessions: regular},
+	} {
+		batch, err := AggregatePremiumsWithOptions(aggregates, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		aggregator := NewIncrementalAggregator(opts, TimespanSecond)
+		var completed []TimePeriodSummary
+		for i, agg := range aggregates {
+			aggregator.AddAggregate(agg)
+			// Collect completed periods as a live stream would, every simulated minute
+			if i%2500 == 0 {
+				completed = append(completed, aggregator.CompletedPeriods(time.UnixMilli(agg.StartTimestamp))...)
+			}
+		}
+		completed = append(completed, aggregator.CompletedPeriods(onWednesday("20:00:00"))...)
+
+		if len(completed) != len(batch) {
+			t.Fatalf("%+v: %d incremental periods, %d batch", opts, len(completed), len(batch))
+		}
+		for i := range batch {
+			if diff := summaryDifference(completed[i], batch[i]); diff != "" {
+				t.Errorf("%+v: period %s: %s", opts, batch[i].PeriodStart.In(eastern).Format("15:04"), diff)
+			}
+		}
+	}
+}
+
+// summaryDifference describes the first field that differs between an incremental and a batch summary, or returns ""
+// Day-so-far fields only the batch computes (walls, anomaly scores, position building) aren't compared, and trade
+// premium quantiles are compared within the t-digest's accuracy
+func summaryDifference(incremental TimePeriodSummary, batch TimePeriodSummary) string {
+	floats := []struct {
+		name string
+		a, b float64
+	}{
+		{"call premium", incremental.CallPremium, batch.CallPremium},
+		{"put premium", incremental.PutPremium, batch.PutPremium},
+		{"total premium", incremental.TotalPremium, batch.TotalPremium},
+		{"call/put log ratio", incremental.CallPutLogRatio, batch.CallPutLogRatio},
+		{"call average strike", incremental.CallAvgStrike, batch.CallAvgStrike},
+		{"put average strike", incremental.PutAvgStrike, batch.PutAvgStrike},
+	}
+	for _, f := range floats {
+		if math.Abs(f.a-f.b) > 1e-9*math.Max(1, math.Abs(f.b)) {
+			return f.name + " differs"
+		}
+	}
+	switch {
+	case !incremental.PeriodStart.Equal(batch.PeriodStart) || !incremental.PeriodEnd.Equal(batch.PeriodEnd):
+		return "bounds differ"
+	case incremental.CallVolume != batch.CallVolume || incremental.PutVolume != batch.PutVolume:
+		return "volume differs"
+	case incremental.Session != batch.Session:
+		return "session differs"
+	case incremental.UniqueContracts != batch.UniqueContracts || incremental.UniqueCallContracts != batch.UniqueCallContracts || incremental.UniquePutContracts != batch.UniquePutContracts:
+		return "unique contracts differ"
+	case incremental.NewContracts != batch.NewContracts:
+		return "new contracts differ"
+	case !reflect.DeepEqual(incremental.CallPutRatio, batch.CallPutRatio) || !reflect.DeepEqual(incremental.CallPutVolumeRatio, batch.CallPutVolumeRatio):
+		return "call/put ratios differ"
+	case !reflect.DeepEqual(incremental.SizeBuckets, batch.SizeBuckets):
+		return "size buckets differ"
+	case !reflect.DeepEqual(incremental.ExpirationBuckets, batch.ExpirationBuckets):
+		return "expiration buckets differ"
+	case !reflect.DeepEqual(incremental.SideFlow, batch.SideFlow):
+		return "side flow differs"
+	case !reflect.DeepEqual(incremental.ExpirySkew, batch.ExpirySkew):
+		return "expiry skew differs"
+	case !reflect.DeepEqual(incremental.LargestTrade, batch.LargestTrade):
+		return "largest trade differs"
+	}
+	for _, side := range []struct {
+		name string
+		a, b TradeDistribution
+	}{
+		{"call", incremental.PremiumDistribution.Call, batch.PremiumDistribution.Call},
+		{"put", incremental.PremiumDistribution.Put, batch.PremiumDistribution.Put},
+	} {
+		if side.a.Count != side.b.Count || side.a.Max != side.b.Max {
+			return side.name + " trade count or largest trade differs"
+		}
+		// The digest is compressed at different points when it's read after every trade, so the estimates agree
+		// to within its accuracy rather than exactly
+		if math.Abs(side.a.Median-side.b.Median) > 0.02*side.b.Median || math.Abs(side.a.P90-side.b.P90) > 0.02*side.b.P90 {
+			return side.name + " trade median or p90 differs by more than 2%"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// StrikeLevel is the call and put premium and volume traded at one strike
+type StrikeLevel struct {
+	Strike      float64 `json:"strike"`
+	CallPremium float64 `json:"call_premium"`
+	PutPremium  float64 `json:"put_premium"`
+	CallVolume  int64   `json:"call_volume"`
+	PutVolume   int64   `json:"put_volume"`
+}
+
+// StrikeLadder is one period's premium and volume broken down by strike
+// Strikes are listed lowest first and only strikes that traded in the period are included
+type StrikeLadder struct {
+	PeriodStart time.Time     `json:"period_start"`
+	PeriodEnd   time.Time     `json:"period_end"`
+	Session     string        `json:"session"`
+	Strikes     []StrikeLevel `json:"strikes"`
+}
+
+// AggregateByStrike builds a strike ladder for each period, with call/put premium and volume at every strike traded
+// Periods are bucketed the same way as AggregatePremiumsWithOptions and returned in time order;
+// the ladders are per period, not cumulative (see WallTracker for the day's running totals)
+func AggregateByStrike(aggregates []Aggregate, opts AggregateOptions) ([]StrikeLadder, error) {
+	periodMinutes := opts.PeriodMinutes
+	if periodMinutes <= 0 {
+		return nil, fmt.Errorf("period must be greater than 0")
+	}
+
+	// Map: periodStart -> strike -> level
+	periodMap := make(map[int64]map[float64]*StrikeLevel)
+
+	for _, agg := range aggregates {
+		if !opts.Includes(agg) {
+			continue
+		}
+
+		parsed, err := ParseOptionSymbol(agg.Symbol)
+		if err != nil {
+			continue
+		}
+
+		periodStart := RoundDownToAnchoredPeriod(agg.StartTimestamp, periodMinutes, opts.Anchor)
+		strikes, exists := periodMap[periodStart]
+		if !exists {
+			strikes = make(map[float64]*StrikeLevel)
+			periodMap[periodStart] = strikes
+		}
+		level, exists := strikes[parsed.Strike]
+		if !exists {
+			level = &StrikeLevel{Strike: parsed.Strike}
+			strikes[parsed.Strike] = level
+		}
+
+		premium := CalculatePremium(agg.Volume, agg.VWAP)
+		if parsed.Type == "call" {
+			level.CallPremium += premium
+			level.CallVolume += agg.Volume
+		} else {
+			level.PutPremium += premium
+			level.PutVolume += agg.Volume
+		}
+	}
+
+	result := make([]StrikeLadder, 0, len(periodMap))
+	for periodStart, strikes := range periodMap {
+		start := time.UnixMilli(periodStart)
+		ladder := StrikeLadder{
+			PeriodStart: start,
+			PeriodEnd:   start.Add(time.Duration(periodMinutes) * time.Minute),
+			Session:     market.SessionForTime(start),
+			Strikes:     make([]StrikeLevel, 0, len(strikes)),
+		}
+		for _, level := range strikes {
+			ladder.Strikes = append(ladder.Strikes, *level)
+		}
+		sort.Slice(ladder.Strikes, func(i, j int) bool {
+			return ladder.Strikes[i].Strike < ladder.Strikes[j].Strike
+		})
+		result = append(result, ladder)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].PeriodStart.Before(result[j].PeriodStart)
+	})
+
+	return result, nil
+}
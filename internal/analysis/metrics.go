@@ -0,0 +1,222 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// PeriodMetric is a per-period metric plugin: one instance is created for each period, sees every aggregate
+// bucketed into it, and writes its result to the period's summary (see SetMetric)
+// Metrics are registered with RegisterPeriodMetric and enabled with EnablePeriodMetrics, so new ones don't need
+// changes to AggregatePremiums or its callers
+type PeriodMetric interface {
+	// Name is the key the metric is registered, enabled, and reported under
+	Name() string
+	// Update adds an aggregate in the period
+	Update(agg Aggregate)
+	// Finalize writes the metric's value for the aggregates seen so far; it may be called more than once
+	Finalize(summary *TimePeriodSummary)
+}
+
+// MergeablePeriodMetric is a PeriodMetric that can absorb another period's instance of the same metric
+// Only mergeable metrics are carried through Merge and Resample (e.g. 1-minute periods combined into 5-minute ones)
+// and kept by Clone for live updates; others are dropped from periods built that way
+type MergeablePeriodMetric interface {
+	PeriodMetric
+	// Merge adds the aggregates seen by other, which is an instance of the same metric
+	Merge(other PeriodMetric)
+}
+
+// PeriodMetricFactory creates an empty instance of a metric for one period
+type PeriodMetricFactory func() PeriodMetric
+
+var (
+	metricsMu       sync.RWMutex
+	metricFactories = make(map[string]PeriodMetricFactory)
+
+	enabledMetrics atomic.Pointer[[]string]
+)
+
+// RegisterPeriodMetric makes a metric available under name, typically from an init function
+// It panics if name is empty or already registered, since that is a programming error
+func RegisterPeriodMetric(name string, factory PeriodMetricFactory) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	if name == "" || factory == nil {
+		panic("analysis: RegisterPeriodMetric needs a name and a factory")
+	}
+	if _, exists := metricFactories[name]; exists {
+		panic("analysis: RegisterPeriodMetric called twice for " + name)
+	}
+	metricFactories[name] = factory
+}
+
+// PeriodMetricNames returns the registered metrics, sorted
+func PeriodMetricNames() []string {
+	metricsMu.RLock()
+	defer metricsMu.RUnlock()
+	names := make([]string, 0, len(metricFactories))
+	for name := range metricFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EnablePeriodMetrics sets the metrics computed for every period from a comma-separated list of registered names
+// (empty for none), replacing any enabled before
+func EnablePeriodMetrics(list string) error {
+	var names []string
+	seen := make(map[string]bool)
+	metricsMu.RLock()
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if _, exists := metricFactories[name]; !exists {
+			metricsMu.RUnlock()
+			return fmt.Errorf("unknown metric %q (must be one of %v)", name, PeriodMetricNames())
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	metricsMu.RUnlock()
+
+	sort.Strings(names)
+	enabledMetrics.Store(&names)
+	return nil
+}
+
+// EnabledPeriodMetrics returns the metrics computed for every period, sorted
+func EnabledPeriodMetrics() []string {
+	if names := enabledMetrics.Load(); names != nil {
+		return *names
+	}
+	return nil
+}
+
+// newPeriodMetrics creates an instance of each enabled metric for a new period
+func newPeriodMetrics() []PeriodMetric {
+	names := EnabledPeriodMetrics()
+	if len(names) == 0 {
+		return nil
+	}
+	metrics := make([]PeriodMetric, 0, len(names))
+	for _, name := range names {
+		if metric := newPeriodMetric(name); metric != nil {
+			metrics = append(metrics, metric)
+		}
+	}
+	return metrics
+}
+
+// newPeriodMetric creates an instance of a registered metric (nil if it isn't registered)
+func newPeriodMetric(name string) PeriodMetric {
+	metricsMu.RLock()
+	factory := metricFactories[name]
+	metricsMu.RUnlock()
+	if factory == nil {
+		return nil
+	}
+	return factory()
+}
+
+// SetMetric records a metric's value for the period, for PeriodMetric.Finalize
+func (s *TimePeriodSummary) SetMetric(name string, value interface{}) {
+	if s.Metrics == nil {
+		s.Metrics = make(map[string]interface{})
+	}
+	s.Metrics[name] = value
+}
+
+// UpdateMetrics adds an aggregate to each of the period's metric instances (created by NewPeriodSummary)
+// A metric whose instance was dropped (by Clone or Merge, or a summary decoded from JSON) can't be continued,
+// so its value is removed rather than left describing only part of the period
+func (s *TimePeriodSummary) UpdateMetrics(agg Aggregate) {
+	for _, metric := range s.metrics {
+		metric.Update(agg)
+	}
+	if len(s.Metrics) > len(s.metrics) {
+		for name := range s.Metrics {
+			if s.metric(name) == nil {
+				delete(s.Metrics, name)
+			}
+		}
+	}
+}
+
+// FinalizeMetrics writes each metric instance's value to the summary
+func (s *TimePeriodSummary) FinalizeMetrics() {
+	for _, metric := range s.metrics {
+		metric.Finalize(s)
+	}
+}
+
+// metric returns the period's instance of a metric, or nil
+func (s *TimePeriodSummary) metric(name string) PeriodMetric {
+	for _, metric := range s.metrics {
+		if metric.Name() == name {
+			return metric
+		}
+	}
+	return nil
+}
+
+// mergeMetrics merges another period's metric instances into the summary's and finalizes them
+// Only mergeable metrics both periods have instances of are combined; the rest are dropped, since their values
+// would only describe one of the periods. Like the rest of Merge, it updates the summary's instances in place, so
+// Clone summaries shared with a cache first
+func (s *TimePeriodSummary) mergeMetrics(other TimePeriodSummary) {
+	if len(s.metrics) == 0 && len(s.Metrics) == 0 {
+		return
+	}
+
+	kept := make([]PeriodMetric, 0, len(s.metrics))
+	for _, metric := range s.metrics {
+		mergeable, ok := metric.(MergeablePeriodMetric)
+		add := other.metric(metric.Name())
+		if !ok || add == nil {
+			continue
+		}
+		mergeable.Merge(add)
+		kept = append(kept, metric)
+	}
+	s.metrics = kept
+	for name := range s.Metrics {
+		if s.metric(name) == nil {
+			delete(s.Metrics, name)
+		}
+	}
+	s.FinalizeMetrics()
+}
+
+// cloneMetrics returns copies of the mergeable metric instances, which can be updated without changing the originals
+func cloneMetrics(metrics []PeriodMetric) []PeriodMetric {
+	if metrics == nil {
+		return nil
+	}
+	cloned := make([]PeriodMetric, 0, len(metrics))
+	for _, metric := range metrics {
+		if copied := cloneMetric(metric); copied != nil {
+			cloned = append(cloned, copied)
+		}
+	}
+	return cloned
+}
+
+// cloneMetric copies a mergeable metric by merging it into a new instance (nil for metrics that can't be copied)
+func cloneMetric(metric PeriodMetric) PeriodMetric {
+	if _, ok := metric.(MergeablePeriodMetric); !ok {
+		return nil
+	}
+	copied, ok := newPeriodMetric(metric.Name()).(MergeablePeriodMetric)
+	if !ok {
+		return nil
+	}
+	copied.Merge(metric)
+	return copied
+}
@@ -0,0 +1,163 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// MoneynessPct returns how far a strike is from spot, as a percent of spot: positive above spot, negative below
+// Calls above spot and puts below it are out of the money
+func MoneynessPct(strike float64, spot float64) float64 {
+	return (strike - spot) / spot * 100
+}
+
+// MoneynessBand selects aggregates by their strike's distance from spot (see MoneynessPct)
+// Nil bounds are open, so the zero value matches every aggregate
+type MoneynessBand struct {
+	Min *float64 // Inclusive lower bound in percent, e.g. 5 for strikes at least 5% above spot
+	Max *float64 // Inclusive upper bound in percent, e.g. -5 for strikes at least 5% below spot
+}
+
+// ParseMoneynessBand builds a moneyness band from its string form (e.g. query parameters)
+// Empty strings leave that side of the band open
+func ParseMoneynessBand(minPct string, maxPct string) (MoneynessBand, error) {
+	var band MoneynessBand
+	if minPct != "" {
+		value, err := strconv.ParseFloat(minPct, 64)
+		if err != nil {
+			return band, fmt.Errorf("invalid moneyness_min %q, must be a percent from spot", minPct)
+		}
+		band.Min = &value
+	}
+	if maxPct != "" {
+		value, err := strconv.ParseFloat(maxPct, 64)
+		if err != nil {
+			return band, fmt.Errorf("invalid moneyness_max %q, must be a percent from spot", maxPct)
+		}
+		band.Max = &value
+	}
+	if err := band.Validate(); err != nil {
+		return band, err
+	}
+	return band, nil
+}
+
+// Validate checks that the band's bounds are in order
+func (b MoneynessBand) Validate() error {
+	if b.Min != nil && b.Max != nil && *b.Max < *b.Min {
+		return fmt.Errorf("moneyness_max must not be less than moneyness_min")
+	}
+	return nil
+}
+
+// IsZero reports whether the band matches every aggregate
+func (b MoneynessBand) IsZero() bool {
+	return b.Min == nil && b.Max == nil
+}
+
+// Contains reports whether a moneyness percent is within the band
+func (b MoneynessBand) Contains(pct float64) bool {
+	if b.Min != nil && pct < *b.Min {
+		return false
+	}
+	if b.Max != nil && pct > *b.Max {
+		return false
+	}
+	return true
+}
+
+// Matches reports whether a tagged aggregate is within the band
+// Untagged aggregates (no spot price) only match an empty band
+func (b MoneynessBand) Matches(agg Aggregate) bool {
+	if b.IsZero() {
+		return true
+	}
+	return agg.MoneynessPct != nil && b.Contains(*agg.MoneynessPct)
+}
+
+// String returns the band in a form usable as a map key, e.g. "5:" or "-10:-5"
+func (b MoneynessBand) String() string {
+	var minStr, maxStr string
+	if b.Min != nil {
+		minStr = strconv.FormatFloat(*b.Min, 'f', -1, 64)
+	}
+	if b.Max != nil {
+		maxStr = strconv.FormatFloat(*b.Max, 'f', -1, 64)
+	}
+	return minStr + ":" + maxStr
+}
+
+// ParseMaxMoneyness parses a maximum distance from spot in percent (e.g. the max_moneyness query parameter)
+// Empty means no limit
+func ParseMaxMoneyness(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	pct, err := strconv.ParseFloat(value, 64)
+	if err != nil || !(pct > 0) || math.IsInf(pct, 1) {
+		return 0, fmt.Errorf("invalid max_moneyness %q, must be a positive percent from spot", value)
+	}
+	return pct, nil
+}
+
+// TagMoneyness sets each aggregate's MoneynessPct from the underlying's price at its trade
+// closes maps one-minute bar starts (Unix ms) to the underlying's close; each aggregate uses the latest close at or
+// before its trade, as in ApplyGreeks. Aggregates with no earlier close or an unparseable symbol are left untagged
+func TagMoneyness(aggregates []Aggregate, closes map[int64]float64) {
+	prices := newSpotSeries(closes)
+	for i := range aggregates {
+		spot, ok := prices.at(aggregates[i].StartTimestamp)
+		if !ok {
+			continue
+		}
+		strike, err := ParseStrike(aggregates[i].Symbol)
+		if err != nil {
+			continue
+		}
+		pct := MoneynessPct(strike, spot)
+		aggregates[i].MoneynessPct = &pct
+	}
+}
+
+// spotSeries looks up the underlying's price at a point in time from one-minute bar closes
+type spotSeries struct {
+	starts []int64 // Bar starts (Unix ms), oldest first
+	closes map[int64]float64
+}
+
+// newSpotSeries indexes bar closes keyed by bar start (Unix ms)
+func newSpotSeries(closes map[int64]float64) spotSeries {
+	starts := make([]int64, 0, len(closes))
+	for start := range closes {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+	return spotSeries{starts: starts, closes: closes}
+}
+
+// at returns the close of the latest bar starting at or before timestamp (Unix ms)
+func (s spotSeries) at(timestamp int64) (float64, bool) {
+	i := sort.Search(len(s.starts), func(i int) bool { return s.starts[i] > timestamp }) - 1
+	if i < 0 || s.closes[s.starts[i]] <= 0 {
+		return 0, false
+	}
+	return s.closes[s.starts[i]], true
+}
+
+// TagMoneynessAtSpot sets each aggregate's MoneynessPct from a single spot price, e.g. the latest quote for live
+// aggregates. Aggregates with an unparseable symbol are left untagged
+func TagMoneynessAtSpot(aggregates []Aggregate, spot float64) {
+	if spot <= 0 {
+		return
+	}
+	for i := range aggregates {
+		strike, err := ParseStrike(aggregates[i].Symbol)
+		if err != nil {
+			continue
+		}
+		pct := MoneynessPct(strike, spot)
+		aggregates[i].MoneynessPct = &pct
+	}
+}
@@ -0,0 +1,72 @@
+package analysis
+
+import (
+	"fmt"
+)
+
+// MultiPeriodMinutes are the resolutions AggregateMultiPeriod produces when no periods are given
+var MultiPeriodMinutes = []int{1, 5, 15, 60}
+
+// AggregateMultiPeriod aggregates premiums at several period lengths (minutes) with a single pass over the aggregates
+// Aggregates are bucketed once at the finest length every period is a multiple of, and coarser periods are rolled up
+// from those buckets, so each result matches AggregatePremiumsWithOptions for that period length (premium sums can
+// differ in the last digits, since they are added in a different order)
+// opts.PeriodMinutes is ignored; no periods means MultiPeriodMinutes. Results are keyed by period length
+func AggregateMultiPeriod(aggregates []Aggregate, opts AggregateOptions, periods ...int) (map[int][]TimePeriodSummary, error) {
+	if len(periods) == 0 {
+		periods = MultiPeriodMinutes
+	}
+	base := 0
+	for _, minutes := range periods {
+		if minutes <= 0 {
+			return nil, fmt.Errorf("period must be greater than 0")
+		}
+		base = gcd(base, minutes)
+	}
+
+	opts.PeriodMinutes = base
+	fine := bucketAggregates(aggregates, opts)
+
+	// Roll every coarser length up before finishing any of them: finishing fills the buckets' contract sets
+	buckets := make(map[int]*periodBuckets, len(periods))
+	for _, minutes := range periods {
+		if _, done := buckets[minutes]; done {
+			continue
+		}
+		if minutes == base {
+			buckets[minutes] = fine
+			continue
+		}
+		buckets[minutes] = fine.rollUp(minutes, opts.Anchor)
+	}
+
+	result := make(map[int][]TimePeriodSummary, len(buckets))
+	for minutes, b := range buckets {
+		result[minutes] = b.summaries()
+	}
+	return result, nil
+}
+
+// rollUp combines the buckets into periods of the given length, which must be a multiple of the buckets' length
+func (b *periodBuckets) rollUp(minutes int, anchor string) *periodBuckets {
+	rolled := newPeriodBuckets(len(b.periods)/2 + 1)
+	for fineStart, fineSummary := range b.periods {
+		periodStart := RoundDownToAnchoredPeriod(fineStart, minutes, anchor)
+		summary, walls, contracts := rolled.period(periodStart, periodStart+int64(minutes*60*1000))
+		summary.Merge(*fineSummary)
+		walls.Merge(b.walls[fineStart])
+		rolled.positions.mergePeriod(b.positions, fineStart, periodStart)
+		for symbol := range b.contracts[fineStart] {
+			contracts[symbol] = true
+		}
+	}
+	return rolled
+}
+
+// gcd returns the greatest common divisor of a and b (gcd(0, n) is n)
+func gcd(a int, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,109 @@
+package analysis
+
+import (
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// NormalPremium is a ticker's average premium for one time-of-day period over the baseline's trading days
+type NormalPremium struct {
+	CallPremium  float64
+	PutPremium   float64
+	TotalPremium float64
+}
+
+// TimeOfDayBaseline is a ticker's normal premium for each time-of-day period (e.g. 10:00-10:05), averaged over the
+// trading days before a date
+// Days without trades in a period count as zero premium for it, so quiet periods stay quiet in the average
+type TimeOfDayBaseline struct {
+	Days    int                   // Trading days with data in the average
+	periods map[int]NormalPremium // Key: minutes since midnight of the period start in the analysis timezone
+}
+
+// VersusNormal compares a period's premium with the normal premium for the same time of day
+// Multiples are null when the period has no normal premium on that side (e.g. it never traded in the baseline)
+type VersusNormal struct {
+	BaselineDays         int      `json:"baseline_days"` // Trading days behind the normal premiums
+	NormalCallPremium    float64  `json:"normal_call_premium"`
+	NormalPutPremium     float64  `json:"normal_put_premium"`
+	NormalTotalPremium   float64  `json:"normal_total_premium"`
+	CallPremiumMultiple  *float64 `json:"call_premium_multiple"` // Call premium as a multiple of normal: 3 means 3× normal
+	PutPremiumMultiple   *float64 `json:"put_premium_multiple"`
+	TotalPremiumMultiple *float64 `json:"total_premium_multiple"`
+}
+
+// minuteOfDay returns the minutes since midnight of t in the analysis timezone
+func minuteOfDay(t time.Time) int {
+	local := t.In(market.AnalysisLocation())
+	return local.Hour()*60 + local.Minute()
+}
+
+// AverageTimeOfDay averages each time-of-day period's premium over days of summaries (one slice per trading day,
+// all of the same period length), skipping days without summaries
+// The result has no days when none of them had data
+func AverageTimeOfDay(days [][]TimePeriodSummary) TimeOfDayBaseline {
+	baseline := TimeOfDayBaseline{periods: make(map[int]NormalPremium)}
+	for _, summaries := range days {
+		if len(summaries) == 0 {
+			continue
+		}
+		baseline.Days++
+		for _, summary := range summaries {
+			minute := minuteOfDay(summary.PeriodStart)
+			normal := baseline.periods[minute]
+			normal.CallPremium += summary.CallPremium
+			normal.PutPremium += summary.PutPremium
+			normal.TotalPremium += summary.TotalPremium
+			baseline.periods[minute] = normal
+		}
+	}
+	for minute, normal := range baseline.periods {
+		normal.CallPremium /= float64(baseline.Days)
+		normal.PutPremium /= float64(baseline.Days)
+		normal.TotalPremium /= float64(baseline.Days)
+		baseline.periods[minute] = normal
+	}
+	return baseline
+}
+
+// Normal returns the normal premium for the period starting at the same time of day as start
+func (b *TimeOfDayBaseline) Normal(start time.Time) NormalPremium {
+	return b.periods[minuteOfDay(start)]
+}
+
+// Apply sets a summary's comparison with normal from the baseline
+// A nil baseline, or one without days, leaves the summary without a comparison
+func (b *TimeOfDayBaseline) Apply(summary *TimePeriodSummary) {
+	summary.VersusNormal = nil
+	if b == nil || b.Days == 0 {
+		return
+	}
+
+	normal := b.Normal(summary.PeriodStart)
+	summary.VersusNormal = &VersusNormal{
+		BaselineDays:         b.Days,
+		NormalCallPremium:    normal.CallPremium,
+		NormalPutPremium:     normal.PutPremium,
+		NormalTotalPremium:   normal.TotalPremium,
+		CallPremiumMultiple:  multipleOf(summary.CallPremium, normal.CallPremium),
+		PutPremiumMultiple:   multipleOf(summary.PutPremium, normal.PutPremium),
+		TotalPremiumMultiple: multipleOf(summary.TotalPremium, normal.TotalPremium),
+	}
+}
+
+// multipleOf returns value as a multiple of normal, or nil when there is no normal value to compare with
+func multipleOf(value float64, normal float64) *float64 {
+	if normal <= 0 {
+		return nil
+	}
+	multiple := value / normal
+	return &multiple
+}
+
+// ApplyTimeOfDayBaseline sets each summary's comparison with normal from the baseline
+func ApplyTimeOfDayBaseline(summaries []TimePeriodSummary, baseline *TimeOfDayBaseline) {
+	for i := range summaries {
+		baseline.Apply(&summaries[i])
+	}
+}
@@ -0,0 +1,105 @@
+package analysis
+
+import (
+	"sort"
+)
+
+// PeriodOpenInterest compares a period's volume with the open interest of the contracts it traded
+// A volume/OI ratio near or above 1 means the period traded as many contracts as were open at the start of the day,
+// which usually means new positions are being opened: the classic unusual-activity signal that premium alone misses
+type PeriodOpenInterest struct {
+	CallOpenInterest  int64             `json:"call_open_interest"`   // Open interest of the calls traded in the period
+	PutOpenInterest   int64             `json:"put_open_interest"`    // Open interest of the puts traded in the period
+	CallVolumeOIRatio float64           `json:"call_volume_oi_ratio"` // Period volume in those calls / their open interest
+	PutVolumeOIRatio  float64           `json:"put_volume_oi_ratio"`  // Period volume in those puts / their open interest
+	TopContract       *ContractVolumeOI `json:"top_contract,omitempty"`
+}
+
+// ContractVolumeOI is the contract traded against the least open interest in a period
+type ContractVolumeOI struct {
+	Symbol        string  `json:"symbol"`
+	Volume        int64   `json:"volume"`
+	OpenInterest  int64   `json:"open_interest"`
+	VolumeOIRatio float64 `json:"volume_oi_ratio"`
+}
+
+// ApplyContractOpenInterest sets each contract total's open interest and volume/OI ratio
+// openInterest maps option symbols to open interest at the start of the session; contracts missing from it,
+// or with none open (e.g. listed that day), are left without either
+func ApplyContractOpenInterest(totals []ContractTotal, openInterest map[string]int64) {
+	for i := range totals {
+		oi := openInterest[totals[i].Symbol]
+		if oi <= 0 {
+			continue
+		}
+		totals[i].OpenInterest = oi
+		totals[i].VolumeOIRatio = float64(totals[i].Volume) / float64(oi)
+	}
+}
+
+// ApplyOpenInterest sets each summary's OpenInterest from the aggregates it was built from
+// Only contracts with open interest count toward a side's ratio; summaries with none keep nil OpenInterest.
+// Ties for the top contract keep the first symbol in sort order, so results are stable
+func ApplyOpenInterest(summaries []TimePeriodSummary, aggregates []Aggregate, opts AggregateOptions, openInterest map[string]int64) {
+	if len(openInterest) == 0 || opts.PeriodMinutes <= 0 {
+		return
+	}
+
+	byPeriod := make(map[int64]*TimePeriodSummary, len(summaries))
+	for i := range summaries {
+		byPeriod[summaries[i].PeriodStart.UnixMilli()] = &summaries[i]
+	}
+
+	// Volume per period and contract, for contracts with open interest
+	volumes := make(map[*TimePeriodSummary]map[string]int64)
+	for _, agg := range aggregates {
+		if !opts.Includes(agg) || openInterest[agg.Symbol] <= 0 {
+			continue
+		}
+		summary, exists := byPeriod[RoundDownToAnchoredPeriod(agg.StartTimestamp, opts.PeriodMinutes, opts.Anchor)]
+		if !exists {
+			continue
+		}
+		if volumes[summary] == nil {
+			volumes[summary] = make(map[string]int64)
+		}
+		volumes[summary][agg.Symbol] += agg.Volume
+	}
+
+	for summary, contracts := range volumes {
+		symbols := make([]string, 0, len(contracts))
+		for symbol := range contracts {
+			symbols = append(symbols, symbol)
+		}
+		sort.Strings(symbols)
+
+		result := &PeriodOpenInterest{}
+		var callVolume, putVolume int64
+		for _, symbol := range symbols {
+			optionType, err := ParseOptionType(symbol)
+			if err != nil {
+				continue
+			}
+			volume, oi := contracts[symbol], openInterest[symbol]
+			if optionType == "call" {
+				callVolume += volume
+				result.CallOpenInterest += oi
+			} else {
+				putVolume += volume
+				result.PutOpenInterest += oi
+			}
+
+			ratio := float64(volume) / float64(oi)
+			if result.TopContract == nil || ratio > result.TopContract.VolumeOIRatio {
+				result.TopContract = &ContractVolumeOI{Symbol: symbol, Volume: volume, OpenInterest: oi, VolumeOIRatio: ratio}
+			}
+		}
+		if result.CallOpenInterest > 0 {
+			result.CallVolumeOIRatio = float64(callVolume) / float64(result.CallOpenInterest)
+		}
+		if result.PutOpenInterest > 0 {
+			result.PutVolumeOIRatio = float64(putVolume) / float64(result.PutOpenInterest)
+		}
+		summary.OpenInterest = result
+	}
+}
@@ -0,0 +1,100 @@
+package analysis
+
+import (
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// Outlier detection defaults, matching the premium-outliers commands
+const (
+	DefaultOutlierPercentile = 90.0 // Premium percentile (0-100) trades are compared against
+	DefaultOutlierMultiple   = 10.0 // Multiple of that percentile a trade's premium must reach
+	DefaultOutlierMinSamples = 100  // Trades a ticker and side must have before any is flagged
+)
+
+// Outlier is a trade whose premium was at least a multiple of its ticker and side's premium percentile at the time
+type Outlier struct {
+	Ticker     string    `json:"ticker"`
+	Symbol     string    `json:"symbol"`
+	Type       string    `json:"type"`       // "call" or "put"
+	Expiration string    `json:"expiration"` // YYYY-MM-DD
+	Strike     float64   `json:"strike"`
+	Timestamp  time.Time `json:"timestamp"` // Start of the aggregate
+	Volume     int64     `json:"volume"`
+	VWAP       float64   `json:"vwap"`
+	Premium    float64   `json:"premium"`
+	Threshold  float64   `json:"threshold"` // Premium percentile for the ticker and side before this trade
+	Multiple   float64   `json:"multiple"`  // Premium / Threshold
+}
+
+// OutlierDetector flags premium outliers in a live stream of aggregates, applying the premium-outliers rule
+// incrementally: a trade is an outlier when its premium is at least multiple times the percentile of the trades seen
+// so far that day for its ticker and side. Each trade is compared before it is recorded, and distributions restart
+// when the date (analysis timezone) changes, so they cover the same trades as the daily log files
+// An OutlierDetector is not safe for concurrent use
+type OutlierDetector struct {
+	quantile   float64
+	multiple   float64
+	minSamples int
+
+	date    string
+	digests map[string]*TDigest // Keyed by ticker and option type
+}
+
+// NewOutlierDetector creates a detector for a percentile (0-100) and multiple; trades are only flagged once their
+// ticker and side have minSamples earlier trades, so the first prints of the day aren't all outliers
+func NewOutlierDetector(percentile float64, multiple float64, minSamples int) *OutlierDetector {
+	return &OutlierDetector{
+		quantile:   percentile / 100.0,
+		multiple:   multiple,
+		minSamples: minSamples,
+		digests:    make(map[string]*TDigest),
+	}
+}
+
+// Check records an aggregate's premium and returns it as an Outlier if it is one
+// Aggregates whose symbol can't be parsed are ignored
+func (d *OutlierDetector) Check(agg Aggregate) (*Outlier, bool) {
+	contract, err := ParseOptionSymbol(agg.Symbol)
+	if err != nil {
+		return nil, false
+	}
+
+	timestamp := time.UnixMilli(agg.StartTimestamp)
+	if date := timestamp.In(market.AnalysisLocation()).Format("2006-01-02"); date != d.date {
+		d.date = date
+		d.digests = make(map[string]*TDigest)
+	}
+
+	key := contract.Underlying + ":" + contract.Type
+	digest, exists := d.digests[key]
+	if !exists {
+		digest = NewTDigest(DefaultCompression)
+		d.digests[key] = digest
+	}
+
+	premium := CalculatePremium(agg.Volume, agg.VWAP)
+	var threshold float64
+	if digest.Count() >= d.minSamples {
+		threshold = digest.Quantile(d.quantile)
+	}
+	digest.Add(premium)
+
+	if threshold == 0 || premium < threshold*d.multiple {
+		return nil, false
+	}
+	return &Outlier{
+		Ticker:     contract.Underlying,
+		Symbol:     agg.Symbol,
+		Type:       contract.Type,
+		Expiration: contract.ExpirationDate(),
+		Strike:     contract.Strike,
+		Timestamp:  timestamp,
+		Volume:     agg.Volume,
+		VWAP:       agg.VWAP,
+		Premium:    premium,
+		Threshold:  threshold,
+		Multiple:   premium / threshold,
+	}, true
+}
@@ -0,0 +1,130 @@
+package analysis
+
+import (
+	"sort"
+	"time"
+)
+
+// Position-building limits
+const (
+	MinPositionPeriods  = 3     // Consecutive periods a contract must accumulate premium in before it is flagged
+	MinPositionPremium  = 25000 // Premium a contract must trade in each of those periods
+	MaxPositionBuilders = 5     // Contracts listed per period, largest accumulation first
+)
+
+// PositionBuild is a contract that traded at least MinPositionPremium in each of the last MinPositionPeriods or more
+// consecutive periods through the summary's period: steady accumulation, as opposed to a one-shot print
+type PositionBuild struct {
+	Symbol        string    `json:"symbol"`
+	Type          string    `json:"type"`       // "call" or "put"
+	Expiration    string    `json:"expiration"` // YYYY-MM-DD
+	Strike        float64   `json:"strike"`
+	Periods       int       `json:"periods"`        // Consecutive periods in the run, through this one
+	Since         time.Time `json:"since"`          // Start of the run's first period
+	Premium       float64   `json:"premium"`        // Premium over the whole run
+	Volume        int64     `json:"volume"`         // Volume over the whole run
+	PeriodPremium float64   `json:"period_premium"` // Premium in this period
+}
+
+// positionFlow is one contract's premium and volume in one period
+type positionFlow struct {
+	premium float64
+	volume  int64
+}
+
+// PositionTracker keeps each period's per-contract premium to find contracts building a position across consecutive
+// periods. Periods without trades in a contract (or without a summary) end its run
+// A PositionTracker is not safe for concurrent use
+type PositionTracker struct {
+	periods map[int64]map[string]*positionFlow // Key: period start (Unix ms), then contract symbol
+	// Contracts that reached MinPositionPremium in each period, so Apply only walks back the candidates
+	qualified map[int64]map[string]bool
+}
+
+// NewPositionTracker creates an empty position tracker
+func NewPositionTracker() *PositionTracker {
+	return &PositionTracker{
+		periods:   make(map[int64]map[string]*positionFlow),
+		qualified: make(map[int64]map[string]bool),
+	}
+}
+
+// Add records an aggregate's premium in the period starting at periodStart (Unix ms)
+func (t *PositionTracker) Add(periodStart int64, agg Aggregate) {
+	t.add(periodStart, agg.Symbol, CalculatePremium(agg.Volume, agg.VWAP), agg.Volume)
+}
+
+// add records premium and volume for a contract in a period
+func (t *PositionTracker) add(periodStart int64, symbol string, premium float64, volume int64) {
+	flows, exists := t.periods[periodStart]
+	if !exists {
+		flows = make(map[string]*positionFlow)
+		t.periods[periodStart] = flows
+		t.qualified[periodStart] = make(map[string]bool)
+	}
+	flow, exists := flows[symbol]
+	if !exists {
+		flow = &positionFlow{}
+		flows[symbol] = flow
+	}
+	flow.premium += premium
+	flow.volume += volume
+	if flow.premium >= MinPositionPremium {
+		t.qualified[periodStart][symbol] = true
+	}
+}
+
+// Apply sets a summary's position builders from the periods recorded through its period
+func (t *PositionTracker) Apply(summary *TimePeriodSummary) {
+	summary.PositionBuilding = nil
+	length := summary.PeriodEnd.Sub(summary.PeriodStart).Milliseconds()
+	start := summary.PeriodStart.UnixMilli()
+	if length <= 0 || len(t.qualified[start]) == 0 {
+		return
+	}
+
+	var builds []PositionBuild
+	for symbol := range t.qualified[start] {
+		flow := t.periods[start][symbol]
+		build := PositionBuild{Symbol: symbol, PeriodPremium: flow.premium}
+		first := start
+		for periodStart := start; t.qualified[periodStart][symbol]; periodStart -= length {
+			earlier := t.periods[periodStart][symbol]
+			build.Periods++
+			build.Premium += earlier.premium
+			build.Volume += earlier.volume
+			first = periodStart
+		}
+		if build.Periods < MinPositionPeriods {
+			continue
+		}
+		contract, err := ParseOptionSymbol(symbol)
+		if err != nil {
+			continue
+		}
+		build.Type = contract.Type
+		build.Expiration = contract.Expiration.Format("2006-01-02")
+		build.Strike = contract.Strike
+		build.Since = time.UnixMilli(first)
+		builds = append(builds, build)
+	}
+
+	sort.Slice(builds, func(i, j int) bool {
+		if builds[i].Premium != builds[j].Premium {
+			return builds[i].Premium > builds[j].Premium
+		}
+		return builds[i].Symbol < builds[j].Symbol
+	})
+	if len(builds) > MaxPositionBuilders {
+		builds = builds[:MaxPositionBuilders]
+	}
+	summary.PositionBuilding = builds
+}
+
+// mergePeriod adds another tracker's contract premiums for the period starting at from into t's period starting at to
+// (used to roll fine periods up into coarser ones)
+func (t *PositionTracker) mergePeriod(other *PositionTracker, from int64, to int64) {
+	for symbol, flow := range other.periods[from] {
+		t.add(to, symbol, flow.premium, flow.volume)
+	}
+}
@@ -0,0 +1,166 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultCompression bounds a t-digest to roughly this many centroids; higher is more accurate and uses more memory
+const DefaultCompression = 100
+
+// centroid is a cluster of values summarized by their mean and count
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+// TDigest estimates quantiles of a stream of values in bounded memory, without keeping or re-sorting every value
+// Values are buffered and periodically merged into centroids that are small near the tails and larger near the
+// median, so extreme quantiles (p99) stay accurate. Small samples (under about compression/2 values) are never
+// merged and give exact quantiles, using linear interpolation between the closest ranks
+// A TDigest is not safe for concurrent use
+type TDigest struct {
+	compression float64
+	centroids   []centroid // Merged centroids, sorted by mean
+	buffer      []float64  // Values added since the last merge
+	count       float64
+	min         float64
+	max         float64
+}
+
+// NewTDigest creates an empty digest (compression <= 0 uses DefaultCompression)
+func NewTDigest(compression float64) *TDigest {
+	if compression <= 0 {
+		compression = DefaultCompression
+	}
+	return &TDigest{compression: compression, min: math.Inf(1), max: math.Inf(-1)}
+}
+
+// Add records a value
+func (d *TDigest) Add(value float64) {
+	if math.IsNaN(value) {
+		return
+	}
+	d.buffer = append(d.buffer, value)
+	d.count++
+	d.min = math.Min(d.min, value)
+	d.max = math.Max(d.max, value)
+	if len(d.buffer) >= int(5*d.compression) {
+		d.merge()
+	}
+}
+
+// Merge adds every value recorded by another digest, e.g. to combine the digests of adjacent periods
+// The other digest is merged first but otherwise unchanged
+func (d *TDigest) Merge(other *TDigest) {
+	if other == nil || other.count == 0 {
+		return
+	}
+	other.merge()
+	d.merge()
+
+	all := make([]centroid, 0, len(d.centroids)+len(other.centroids))
+	all = append(all, d.centroids...)
+	all = append(all, other.centroids...)
+	d.count += other.count
+	d.min = math.Min(d.min, other.min)
+	d.max = math.Max(d.max, other.max)
+	d.compress(all)
+}
+
+// Clone returns a copy of the digest that can be added to without changing the original
+func (d *TDigest) Clone() *TDigest {
+	clone := *d
+	clone.centroids = append([]centroid(nil), d.centroids...)
+	clone.buffer = append([]float64(nil), d.buffer...)
+	return &clone
+}
+
+// Count returns the number of values recorded
+func (d *TDigest) Count() int {
+	return int(d.count)
+}
+
+// Quantile estimates the value at quantile q (0.0 to 1.0); an empty digest returns 0
+func (d *TDigest) Quantile(q float64) float64 {
+	if d.count == 0 {
+		return 0
+	}
+	d.merge()
+	if q <= 0 {
+		return d.min
+	}
+	if q >= 1 {
+		return d.max
+	}
+
+	// Each centroid sits at the middle of its rank range; the target rank matches the exact percentile's
+	// q × (n-1) interpolation when every centroid holds a single value
+	target := q*(d.count-1) + 0.5
+	cumulative := 0.0
+	var prevCenter, prevMean float64
+	for i, c := range d.centroids {
+		center := cumulative + c.weight/2
+		if target <= center {
+			if i == 0 {
+				// Between the minimum and the first centroid
+				return interpolate(d.min, c.mean, 0.5, center, target)
+			}
+			return interpolate(prevMean, c.mean, prevCenter, center, target)
+		}
+		prevCenter, prevMean = center, c.mean
+		cumulative += c.weight
+	}
+	// Between the last centroid and the maximum
+	return interpolate(prevMean, d.max, prevCenter, d.count-0.5, target)
+}
+
+// interpolate returns the value at position x on the line from (x0, y0) to (x1, y1), clamped to its ends
+func interpolate(y0 float64, y1 float64, x0 float64, x1 float64, x float64) float64 {
+	if x1 <= x0 || x <= x0 {
+		return y0
+	}
+	if x >= x1 {
+		return y1
+	}
+	return y0 + (y1-y0)*(x-x0)/(x1-x0)
+}
+
+// merge folds buffered values into the centroids, combining neighbors while they stay within the size bound
+// The bound 4·n·q(1-q)/compression shrinks toward the tails, so the smallest and largest values stay exact
+func (d *TDigest) merge() {
+	if len(d.buffer) == 0 {
+		return
+	}
+
+	all := make([]centroid, 0, len(d.centroids)+len(d.buffer))
+	all = append(all, d.centroids...)
+	for _, value := range d.buffer {
+		all = append(all, centroid{mean: value, weight: 1})
+	}
+	d.buffer = d.buffer[:0]
+	d.compress(all)
+}
+
+// compress sorts centroids covering every recorded value and sets the digest's centroids to them, combined within the size bound
+func (d *TDigest) compress(all []centroid) {
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+
+	merged := all[:1]
+	before := 0.0 // Weight of the centroids before the current one
+	for _, next := range all[1:] {
+		current := &merged[len(merged)-1]
+		q0 := before / d.count
+		q2 := (before + current.weight + next.weight) / d.count
+		limit := 4 * d.count * math.Min(q0*(1-q0), q2*(1-q2)) / d.compression
+		if current.weight+next.weight <= limit {
+			weight := current.weight + next.weight
+			current.mean += (next.mean - current.mean) * next.weight / weight
+			current.weight = weight
+			continue
+		}
+		before += current.weight
+		merged = append(merged, next)
+	}
+	d.centroids = merged
+}
@@ -0,0 +1,39 @@
+package analysis
+
+import (
+	"math"
+)
+
+// Ratio returns a call/put (or put/call) ratio, nil when only the numerator's side traded (an infinite ratio, which
+// JSON can't carry) and 0 when neither side did
+func Ratio(numerator, denominator float64) *float64 {
+	ratio := 0.0
+	if denominator > 0 {
+		ratio = numerator / denominator
+	} else if numerator > 0 {
+		return nil
+	}
+	return &ratio
+}
+
+// RatioValue returns a ratio from Ratio as a number, with +Inf for nil (only the numerator's side traded)
+func RatioValue(ratio *float64) float64 {
+	if ratio == nil {
+		return math.Inf(1)
+	}
+	return *ratio
+}
+
+// LogRatio returns ln((call + 1) / (put + 1)): 0 when the sides are balanced or neither traded, positive when calls
+// lead and negative when puts lead, with the same magnitude for the same imbalance either way
+// Unlike the plain ratio it is always finite, so it can be averaged and charted without special cases
+func LogRatio(call, put float64) float64 {
+	return math.Log((call + 1) / (put + 1))
+}
+
+// computeRatios recomputes the period's call/put ratios from its premiums and volumes
+func (s *TimePeriodSummary) computeRatios() {
+	s.CallPutRatio = Ratio(s.CallPremium, s.PutPremium)
+	s.CallPutLogRatio = LogRatio(s.CallPremium, s.PutPremium)
+	s.CallPutVolumeRatio = Ratio(float64(s.CallVolume), float64(s.PutVolume))
+}
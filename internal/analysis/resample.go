@@ -0,0 +1,75 @@
+package analysis
+
+import (
+	"sort"
+)
+
+// Merge adds another period's premium, volume (with its average strikes), contracts, largest trade, premium distribution, size and expiration buckets, per-expiry skew, side flow, greeks, and mergeable plugin metrics into the summary and recomputes its ratio
+// Period bounds, session, walls, anomaly scores, relative flow, and open interest are left unchanged; they depend on other periods
+// (or the period length) and are applied separately
+func (s *TimePeriodSummary) Merge(other TimePeriodSummary) {
+	s.CallPremium += other.CallPremium
+	s.PutPremium += other.PutPremium
+	s.CallAvgStrike = weightedStrike(s.CallAvgStrike, s.CallVolume, other.CallAvgStrike, other.CallVolume)
+	s.PutAvgStrike = weightedStrike(s.PutAvgStrike, s.PutVolume, other.PutAvgStrike, other.PutVolume)
+	s.CallVolume += other.CallVolume
+	s.PutVolume += other.PutVolume
+	for _, class := range []string{SizeRetail, SizeMid, SizeInstitutional} {
+		bucket, add := s.SizeBuckets.Class(class), other.SizeBuckets.Class(class)
+		bucket.CallPremium += add.CallPremium
+		bucket.PutPremium += add.PutPremium
+	}
+	for _, name := range []string{DTEZero, DTEWeekly, DTEMonthly, DTELeaps} {
+		bucket, add := s.ExpirationBuckets.Bucket(name), other.ExpirationBuckets.Bucket(name)
+		bucket.CallPremium += add.CallPremium
+		bucket.PutPremium += add.PutPremium
+	}
+	s.mergeExpirySkew(other.ExpirySkew)
+	s.SideFlow.Merge(other.SideFlow)
+	// Contracts new in either period are new in the merged one, since each contract is new only once a day
+	for symbol := range other.contracts {
+		s.AddContract(symbol, false)
+	}
+	s.NewContracts += other.NewContracts
+	if other.LargestTrade != nil {
+		s.AddTrade(Aggregate{Symbol: other.LargestTrade.Symbol, Volume: other.LargestTrade.Volume, StartTimestamp: other.LargestTrade.Timestamp}, other.LargestTrade.Premium)
+	}
+	s.PremiumDistribution.Merge(other.PremiumDistribution)
+	s.PremiumDistribution.Compute()
+	if other.Greeks != nil {
+		if s.Greeks == nil {
+			s.Greeks = &PeriodGreeks{}
+		}
+		s.Greeks.Merge(*other.Greeks)
+	}
+	s.mergeMetrics(other)
+	s.ingestedAt = max(s.ingestedAt, other.ingestedAt)
+
+	s.TotalPremium = s.CallPremium + s.PutPremium
+	s.computeRatios()
+}
+
+// Resample combines summaries into periods of the given length in minutes, oldest first
+// Input periods must nest within the output periods (e.g. 1-minute summaries into 5- or 15-minute periods),
+// which holds for any midnight-anchored periods whose length divides the output length
+func Resample(summaries []TimePeriodSummary, minutes int) []TimePeriodSummary {
+	periods := make(map[int64]*TimePeriodSummary)
+	for _, summary := range summaries {
+		periodStart := RoundDownToPeriod(summary.PeriodStart.UnixMilli(), minutes)
+		period, exists := periods[periodStart]
+		if !exists {
+			period = NewPeriodSummary(periodStart, periodStart+int64(minutes*60*1000))
+			periods[periodStart] = period
+		}
+		period.Merge(summary)
+	}
+
+	resampled := make([]TimePeriodSummary, 0, len(periods))
+	for _, period := range periods {
+		resampled = append(resampled, *period)
+	}
+	sort.Slice(resampled, func(i, j int) bool {
+		return resampled[i].PeriodStart.Before(resampled[j].PeriodStart)
+	})
+	return resampled
+}
@@ -0,0 +1,199 @@
+package analysis
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Rollup granularities
+const (
+	GranularityDaily   = "daily"
+	GranularityWeekly  = "weekly"
+	GranularityMonthly = "monthly"
+)
+
+// RollupSummary represents premium totals over a multi-day window (day, week, or month)
+type RollupSummary struct {
+	Granularity  string   `json:"granularity"`
+	StartDate    string   `json:"start_date"` // First calendar date of the window (YYYY-MM-DD)
+	EndDate      string   `json:"end_date"`   // Last calendar date of the window (YYYY-MM-DD)
+	Days         int      `json:"days"`       // Number of days with data in the window
+	CallPremium  float64  `json:"call_premium"`
+	PutPremium   float64  `json:"put_premium"`
+	TotalPremium float64  `json:"total_premium"`
+	CallPutRatio *float64 `json:"call_put_ratio"` // Null when there is call premium but no put premium
+	CallVolume   int64    `json:"call_volume"`
+	PutVolume    int64    `json:"put_volume"`
+}
+
+// ValidateGranularity checks that a rollup granularity is supported
+func ValidateGranularity(granularity string) error {
+	switch granularity {
+	case GranularityDaily, GranularityWeekly, GranularityMonthly:
+		return nil
+	}
+	return fmt.Errorf("invalid granularity %q, expected %q, %q, or %q", granularity, GranularityDaily, GranularityWeekly, GranularityMonthly)
+}
+
+// SummarizeDay rolls up all aggregates for a single date into daily totals
+func SummarizeDay(dateStr string, aggregates []Aggregate) RollupSummary {
+	day := RollupSummary{
+		Granularity: GranularityDaily,
+		StartDate:   dateStr,
+		EndDate:     dateStr,
+	}
+
+	for _, agg := range aggregates {
+		optionType, err := ParseOptionType(agg.Symbol)
+		if err != nil {
+			continue
+		}
+
+		premium := CalculatePremium(agg.Volume, agg.VWAP)
+		if optionType == "call" {
+			day.CallPremium += premium
+			day.CallVolume += agg.Volume
+		} else if optionType == "put" {
+			day.PutPremium += premium
+			day.PutVolume += agg.Volume
+		}
+	}
+
+	if len(aggregates) > 0 {
+		day.Days = 1
+	}
+	day.finalize()
+
+	return day
+}
+
+// LogFilePath returns the log file path for a ticker and date
+// Format: SYMBOL_YYYY-MM-DD.jsonl
+func LogFilePath(logDir string, ticker string, dateStr string) string {
+	return filepath.Join(logDir, fmt.Sprintf("%s_%s.jsonl", ticker, dateStr))
+}
+
+// ListDatesForTicker returns all dates (YYYY-MM-DD, sorted) that have a log file for the ticker
+func ListDatesForTicker(logDir string, ticker string) ([]string, error) {
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log directory: %w", err)
+	}
+
+	prefix := ticker + "_"
+	var dates []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+
+		dateStr := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".jsonl")
+		if _, err := time.Parse("2006-01-02", dateStr); err != nil {
+			continue
+		}
+		dates = append(dates, dateStr)
+	}
+
+	sort.Strings(dates)
+	return dates, nil
+}
+
+// RollupLogDir returns daily, weekly, or monthly totals for a ticker's log files between fromDate and toDate
+// (inclusive), getting each date's totals from dailyTotals
+// Empty fromDate/toDate leave the range open on that side
+func RollupLogDir(logDir string, ticker string, fromDate string, toDate string, granularity string, dailyTotals func(dateStr string) (RollupSummary, error)) ([]RollupSummary, error) {
+	if err := ValidateGranularity(granularity); err != nil {
+		return nil, err
+	}
+
+	dates, err := ListDatesForTicker(logDir, ticker)
+	if err != nil {
+		return nil, err
+	}
+
+	var days []RollupSummary
+	for _, dateStr := range dates {
+		if (fromDate != "" && dateStr < fromDate) || (toDate != "" && dateStr > toDate) {
+			continue
+		}
+
+		day, err := dailyTotals(dateStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize %s: %w", filepath.Base(LogFilePath(logDir, ticker, dateStr)), err)
+		}
+		days = append(days, day)
+	}
+
+	return RollupDays(days, granularity)
+}
+
+// RollupDays groups daily totals into weekly (Monday-Sunday) or monthly windows
+// Daily rollups are returned sorted by date
+func RollupDays(days []RollupSummary, granularity string) ([]RollupSummary, error) {
+	if err := ValidateGranularity(granularity); err != nil {
+		return nil, err
+	}
+
+	sorted := make([]RollupSummary, len(days))
+	copy(sorted, days)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].StartDate < sorted[j].StartDate
+	})
+
+	if granularity == GranularityDaily {
+		return sorted, nil
+	}
+
+	var result []RollupSummary
+	for _, day := range sorted {
+		date, err := time.Parse("2006-01-02", day.StartDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date in daily rollup: %s", day.StartDate)
+		}
+
+		start, end := rollupWindow(date, granularity)
+		startStr := start.Format("2006-01-02")
+
+		if len(result) == 0 || result[len(result)-1].StartDate != startStr {
+			result = append(result, RollupSummary{
+				Granularity: granularity,
+				StartDate:   startStr,
+				EndDate:     end.Format("2006-01-02"),
+			})
+		}
+
+		window := &result[len(result)-1]
+		window.Days += day.Days
+		window.CallPremium += day.CallPremium
+		window.PutPremium += day.PutPremium
+		window.CallVolume += day.CallVolume
+		window.PutVolume += day.PutVolume
+		window.finalize()
+	}
+
+	return result, nil
+}
+
+// rollupWindow returns the first and last date of the week or month containing date
+func rollupWindow(date time.Time, granularity string) (time.Time, time.Time) {
+	if granularity == GranularityMonthly {
+		start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, -1)
+	}
+
+	// Weeks start on Monday
+	offset := (int(date.Weekday()) + 6) % 7
+	start := date.AddDate(0, 0, -offset)
+	return start, start.AddDate(0, 0, 6)
+}
+
+// finalize recomputes derived totals (total premium and call/put ratio)
+func (r *RollupSummary) finalize() {
+	r.TotalPremium = r.CallPremium + r.PutPremium
+	r.CallPutRatio = Ratio(r.CallPremium, r.PutPremium)
+}
@@ -0,0 +1,54 @@
+package analysis
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRollupLogDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"AAPL_2025-11-24.jsonl", "AAPL_2025-11-26.jsonl", "AAPL_2025-12-01.jsonl", "AAPL_notes.jsonl", "MSFT_2025-11-25.jsonl", "AAPL_2025-11-25.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dates, err := ListDatesForTicker(dir, "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"2025-11-24", "2025-11-26", "2025-12-01"}; !reflect.DeepEqual(dates, want) {
+		t.Errorf("ListDatesForTicker = %v, want %v", dates, want)
+	}
+
+	// Every date in the range counts $100 of call premium, so a window's premium is $100 per day it holds
+	var summarized []string
+	dailyTotals := func(dateStr string) (RollupSummary, error) {
+		summarized = append(summarized, dateStr)
+		return SummarizeDay(dateStr, []Aggregate{{Symbol: "O:AAPL251128C00150000", Volume: 1, VWAP: 1}}), nil
+	}
+	rollups, err := RollupLogDir(dir, "AAPL", "2025-11-25", "", GranularityWeekly, dailyTotals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"2025-11-26", "2025-12-01"}; !reflect.DeepEqual(summarized, want) {
+		t.Errorf("summarized %v, want only the dates from 2025-11-25 on: %v", summarized, want)
+	}
+	if len(rollups) != 2 || rollups[0].StartDate != "2025-11-24" || rollups[1].StartDate != "2025-12-01" {
+		t.Fatalf("rollups = %+v, want the weeks of 2025-11-24 and 2025-12-01", rollups)
+	}
+	if rollups[0].Days != 1 || rollups[0].CallPremium != 100 {
+		t.Errorf("first week = %d days, $%.2f call premium, want 1 day, $100.00", rollups[0].Days, rollups[0].CallPremium)
+	}
+
+	failure := errors.New("unreadable")
+	_, err = RollupLogDir(dir, "AAPL", "", "", GranularityDaily, func(string) (RollupSummary, error) {
+		return RollupSummary{}, failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("error = %v, want the daily totals error", err)
+	}
+}
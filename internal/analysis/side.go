@@ -0,0 +1,65 @@
+package analysis
+
+// Inferred trade sides
+const (
+	SideBought  = "bought"  // Volume traded near the bar's high, i.e. buyers lifting the offer
+	SideSold    = "sold"    // Volume traded near the bar's low, i.e. sellers hitting the bid
+	SideUnknown = "unknown" // VWAP mid-range, or a bar with no range
+)
+
+// Where the VWAP must sit within the bar's low-to-high range (0 = low, 1 = high) to infer a side
+const (
+	BoughtMinPosition = 0.6
+	SoldMaxPosition   = 0.4
+)
+
+// ClassifySide infers whether an aggregate's volume was likely bought or sold from where its VWAP sits in the
+// bar's range: trades printing near the high are taken as buys at the ask, near the low as sells at the bid
+// This is a heuristic without quotes; single-print bars (high == low) carry no range and are unknown
+func ClassifySide(agg Aggregate) string {
+	barRange := agg.High - agg.Low
+	if barRange <= 0 || agg.VWAP <= 0 {
+		return SideUnknown
+	}
+
+	position := (agg.VWAP - agg.Low) / barRange
+	switch {
+	case position >= BoughtMinPosition:
+		return SideBought
+	case position <= SoldMaxPosition:
+		return SideSold
+	default:
+		return SideUnknown
+	}
+}
+
+// SideFlow splits a period's premium by inferred side (see ClassifySide)
+// Premium whose side is unknown is left out, so the four fields need not add up to the period's total
+type SideFlow struct {
+	BoughtCallPremium float64 `json:"bought_call_premium"`
+	SoldCallPremium   float64 `json:"sold_call_premium"`
+	BoughtPutPremium  float64 `json:"bought_put_premium"`
+	SoldPutPremium    float64 `json:"sold_put_premium"`
+}
+
+// Add attributes one aggregate's premium to its inferred side
+func (f *SideFlow) Add(agg Aggregate, optionType string, premium float64) {
+	switch side := ClassifySide(agg); {
+	case optionType == "call" && side == SideBought:
+		f.BoughtCallPremium += premium
+	case optionType == "call" && side == SideSold:
+		f.SoldCallPremium += premium
+	case optionType == "put" && side == SideBought:
+		f.BoughtPutPremium += premium
+	case optionType == "put" && side == SideSold:
+		f.SoldPutPremium += premium
+	}
+}
+
+// Merge adds another period's side flow into f
+func (f *SideFlow) Merge(other SideFlow) {
+	f.BoughtCallPremium += other.BoughtCallPremium
+	f.SoldCallPremium += other.SoldCallPremium
+	f.BoughtPutPremium += other.BoughtPutPremium
+	f.SoldPutPremium += other.SoldPutPremium
+}
@@ -0,0 +1,90 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// SummaryVersion identifies the set of metrics in a TimePeriodSummary
+// Bump it whenever a summary field is added or its computation changes, so reprocessing rewrites older sidecars
+const SummaryVersion = 7
+
+// SummarySidecar holds a day's precomputed period summaries for one ticker, stored next to its log file
+// SourceSize and SourceModTime record the log file the summaries were computed from, to tell when they are stale
+type SummarySidecar struct {
+	Version       int                 `json:"version"`
+	Ticker        string              `json:"ticker"`
+	Date          string              `json:"date"`
+	PeriodMinutes int                 `json:"period_minutes"`
+	Anchor        string              `json:"anchor"`
+	Sessions      string              `json:"sessions,omitempty"` // Session filter, empty for every session
+	MaxDTE        string              `json:"max_dte,omitempty"`  // Days-to-expiration limit, empty for every expiration
+	Greeks        bool                `json:"greeks"`             // Whether summaries carry greeks (requires underlying prices)
+	Metrics       []string            `json:"metrics,omitempty"`  // Plugin metrics the summaries carry (see EnablePeriodMetrics)
+	SourceSize    int64               `json:"source_size"`
+	SourceModTime time.Time           `json:"source_mod_time"`
+	GeneratedAt   time.Time           `json:"generated_at"`
+	Summaries     []TimePeriodSummary `json:"summaries"`
+
+	// Contracts traded in each summary, as indexes into Symbols (see ContractIndex); optional, since only
+	// consumers that continue a day's summaries (like the server's history cache) need them
+	Symbols   []string `json:"symbols,omitempty"`
+	Contracts [][]int  `json:"contracts,omitempty"`
+}
+
+// SidecarFileName returns the summary sidecar name for a ticker, date, and bucketing, e.g. "AAPL_2025-11-28.5m.v4.summary.json"
+// Open-anchored periods get an "-open" suffix on the period so they don't overwrite midnight-anchored ones,
+// session-filtered summaries a suffix naming the sessions (e.g. "5m-regular"), and DTE-limited ones their limit
+// (e.g. "5m-dte7")
+func SidecarFileName(ticker string, dateStr string, opts AggregateOptions) string {
+	period := fmt.Sprintf("%dm", opts.PeriodMinutes)
+	if opts.Anchor == AnchorOpen {
+		period += "-" + AnchorOpen
+	}
+	if opts.Sessions != 0 {
+		period += "-" + strings.ReplaceAll(opts.Sessions.String(), ",", "+")
+	}
+	if opts.MaxDTE.Set {
+		period += "-dte" + opts.MaxDTE.String()
+	}
+	return fmt.Sprintf("%s_%s.%s.v%d.summary.json", ticker, dateStr, period, SummaryVersion)
+}
+
+// Current reports whether the sidecar was written by this summary version from a log file of the given size and modification time
+func (s SummarySidecar) Current(sourceSize int64, sourceModTime time.Time) bool {
+	return s.Version == SummaryVersion && s.SourceSize == sourceSize && s.SourceModTime.Equal(sourceModTime)
+}
+
+// ReadSidecar reads a summary sidecar
+func ReadSidecar(path string) (SummarySidecar, error) {
+	var sidecar SummarySidecar
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return sidecar, err
+	}
+	if err := json.Unmarshal(data, &sidecar); err != nil {
+		return sidecar, fmt.Errorf("failed to parse sidecar: %w", err)
+	}
+	return sidecar, nil
+}
+
+// WriteSidecar writes a summary sidecar atomically, so readers never see a partial file
+func WriteSidecar(path string, sidecar SummarySidecar) error {
+	data, err := json.MarshalIndent(sidecar, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sidecar: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write sidecar: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write sidecar: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package analysis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Contract holds the fields encoded in an OCC option contract symbol
+type Contract struct {
+	Root       string    // OCC root as traded, e.g. "SPXW" or the adjusted "AAPL1"
+	Underlying string    // Ticker the contract is filed under: the root, or its index when index roots are merged (see SetIndexRootMerging)
+	Adjusted   bool      // Root carries an adjustment digit (non-standard deliverable after a split, merger, or special dividend)
+	Expiration time.Time // Expiration date (UTC midnight)
+	Strike     float64
+	Type       string // "call" or "put"
+}
+
+// IndexRoots maps the OCC roots of alternate index option series (weeklys and PM-settled contracts) to their index,
+// used to file and analyze them with the standard series when index roots are merged
+var IndexRoots = map[string]string{
+	"SPXW":  "SPX",
+	"SPXPM": "SPX",
+	"NDXP":  "NDX",
+	"RUTW":  "RUT",
+	"VIXW":  "VIX",
+	"XSPW":  "XSP",
+	"DJXW":  "DJX",
+}
+
+// mergeIndexRoots is whether index series roots resolve to their index through IndexRoots
+var mergeIndexRoots atomic.Bool
+
+// SetIndexRootMerging sets whether contracts on an IndexRoots series are filed under their index (SPXW -> SPX)
+// instead of their own root. It is off by default, matching the log files written before index series were
+// recognized; every service sharing a log directory must use the same setting
+func SetIndexRootMerging(enabled bool) {
+	mergeIndexRoots.Store(enabled)
+}
+
+// ParseOptionSymbol splits an OCC option symbol into its root, underlying, expiration, type, and strike
+// Format: [O:]{ROOT}{YYMMDD}{C|P}{STRIKE}, where STRIKE is the price × 1000 (zero-padded to 8 digits by OCC)
+// Example: "O:AAPL230616C00150000" -> AAPL, 2023-06-16, 150, call
+// The root may contain digits (e.g. adjusted "AAPL1" contracts), so the type is the last C or P followed by the strike
+// digits. Roots space-padded to six characters (the OSI layout, "SPXW  241220C05000000") and lowercase symbols are
+// accepted. Adjusted roots ("AAPL1") keep their own underlying, since their deliverable differs from the standard
+// series, and index series roots ("SPXW") resolve to their index only with SetIndexRootMerging
+// It runs for every aggregate analyzed, so it avoids time.Parse and strconv
+func ParseOptionSymbol(symbol string) (Contract, error) {
+	trimmed := symbol
+	if len(trimmed) >= 2 && (trimmed[0] == 'O' || trimmed[0] == 'o') && trimmed[1] == ':' {
+		trimmed = trimmed[2:]
+	}
+	for i := 0; i < len(trimmed); i++ {
+		if trimmed[i] >= 'a' && trimmed[i] <= 'z' {
+			trimmed = strings.ToUpper(trimmed)
+			break
+		}
+	}
+
+	// Find the C or P followed by the strike digits, searching from the end
+	typeIndex := -1
+	for i := len(trimmed) - 2; i >= 0; i-- {
+		if (trimmed[i] == 'C' || trimmed[i] == 'P') && isDigit(trimmed[i+1]) {
+			typeIndex = i
+			break
+		}
+	}
+	if typeIndex < 0 {
+		return Contract{}, fmt.Errorf("could not find call/put indicator in option symbol: %s", symbol)
+	}
+	if typeIndex < 7 {
+		// At least one character of root before the 6-digit expiration
+		return Contract{}, fmt.Errorf("invalid option symbol format: %s", symbol)
+	}
+
+	root := strings.TrimRight(trimmed[:typeIndex-6], " ")
+	if !validRoot(root) {
+		return Contract{}, fmt.Errorf("invalid root in option symbol: %s", symbol)
+	}
+
+	expiration, ok := parseYYMMDD(trimmed[typeIndex-6 : typeIndex])
+	if !ok {
+		return Contract{}, fmt.Errorf("invalid expiration in option symbol: %s", symbol)
+	}
+
+	var strike int64
+	digits := trimmed[typeIndex+1:]
+	if len(digits) > 15 {
+		return Contract{}, fmt.Errorf("invalid strike in option symbol: %s", symbol)
+	}
+	for i := 0; i < len(digits); i++ {
+		if !isDigit(digits[i]) {
+			return Contract{}, fmt.Errorf("invalid strike in option symbol: %s", symbol)
+		}
+		strike = strike*10 + int64(digits[i]-'0')
+	}
+
+	optionType := "call"
+	if trimmed[typeIndex] == 'P' {
+		optionType = "put"
+	}
+	underlying, adjusted := underlyingForRoot(root)
+	return Contract{
+		Root:       root,
+		Underlying: underlying,
+		Adjusted:   adjusted,
+		Expiration: expiration,
+		Strike:     float64(strike) / 1000,
+		Type:       optionType,
+	}, nil
+}
+
+// validRoot reports whether an OCC root is made of letters, digits, and dots (e.g. "BRK.B") with at least one letter
+func validRoot(root string) bool {
+	letters := 0
+	for i := 0; i < len(root); i++ {
+		switch {
+		case root[i] >= 'A' && root[i] <= 'Z':
+			letters++
+		case isDigit(root[i]) || root[i] == '.':
+		default:
+			return false
+		}
+	}
+	return letters > 0
+}
+
+// underlyingForRoot returns the ticker a root's contracts are filed under and whether the root is adjusted
+// Adjusted roots are the underlying's root followed by a digit (AAPL1, AAPL2, ...)
+func underlyingForRoot(root string) (string, bool) {
+	if isDigit(root[len(root)-1]) {
+		return root, true
+	}
+	if mergeIndexRoots.Load() {
+		if index, ok := IndexRoots[root]; ok {
+			return index, false
+		}
+	}
+	return root, false
+}
+
+// ExpirationDate returns the expiration as YYYY-MM-DD
+func (c Contract) ExpirationDate() string {
+	return c.Expiration.Format("2006-01-02")
+}
+
+// isDigit reports whether b is an ASCII digit
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// parseYYMMDD parses a 6-digit YYMMDD date (years 2000-2099) as UTC midnight, rejecting dates that don't exist
+func parseYYMMDD(value string) (time.Time, bool) {
+	for i := 0; i < len(value); i++ {
+		if !isDigit(value[i]) {
+			return time.Time{}, false
+		}
+	}
+	year := 2000 + int(value[0]-'0')*10 + int(value[1]-'0')
+	month := time.Month(int(value[2]-'0')*10 + int(value[3]-'0'))
+	day := int(value[4]-'0')*10 + int(value[5]-'0')
+
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if date.Month() != month || date.Day() != day {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// ParseStrike extracts the strike price from an option symbol
+// Example: "O:AAPL230616C00150000" -> 150
+func ParseStrike(symbol string) (float64, error) {
+	parsed, err := ParseOptionSymbol(symbol)
+	if err != nil {
+		return 0, err
+	}
+	return parsed.Strike, nil
+}
+
+// ContractFilter selects option contracts by strike range, expiration, and type
+// Zero-valued fields match every contract
+type ContractFilter struct {
+	StrikeMin  float64   // Inclusive; 0 for no lower bound
+	StrikeMax  float64   // Inclusive; 0 for no upper bound
+	Expiration time.Time // Zero for any expiration
+	Type       string    // "call", "put", or empty for both
+}
+
+// ParseContractFilter builds a contract filter from its string form (e.g. query parameters)
+// Empty strings leave that part of the filter open; expiration is YYYY-MM-DD
+func ParseContractFilter(strikeMin string, strikeMax string, expiration string, optionType string) (ContractFilter, error) {
+	var filter ContractFilter
+	var err error
+
+	if strikeMin != "" {
+		if filter.StrikeMin, err = strconv.ParseFloat(strikeMin, 64); err != nil || filter.StrikeMin < 0 {
+			return filter, fmt.Errorf("invalid strike_min %q, must be a non-negative number", strikeMin)
+		}
+	}
+	if strikeMax != "" {
+		if filter.StrikeMax, err = strconv.ParseFloat(strikeMax, 64); err != nil || filter.StrikeMax <= 0 {
+			return filter, fmt.Errorf("invalid strike_max %q, must be a positive number", strikeMax)
+		}
+		if filter.StrikeMax < filter.StrikeMin {
+			return filter, fmt.Errorf("strike_max must not be less than strike_min")
+		}
+	}
+	if expiration != "" {
+		if filter.Expiration, err = time.Parse("2006-01-02", expiration); err != nil {
+			return filter, fmt.Errorf("invalid expiration %q, expected YYYY-MM-DD", expiration)
+		}
+	}
+	switch optionType {
+	case "", "call", "put":
+		filter.Type = optionType
+	default:
+		return filter, fmt.Errorf("invalid type %q, expected \"call\" or \"put\"", optionType)
+	}
+
+	return filter, nil
+}
+
+// IsZero reports whether the filter matches every contract
+func (f ContractFilter) IsZero() bool {
+	return f == ContractFilter{}
+}
+
+// Matches reports whether a contract symbol passes the filter
+// Symbols that can't be parsed only match an empty filter
+func (f ContractFilter) Matches(symbol string) bool {
+	if f.IsZero() {
+		return true
+	}
+
+	parsed, err := ParseOptionSymbol(symbol)
+	if err != nil {
+		return false
+	}
+	if f.StrikeMin > 0 && parsed.Strike < f.StrikeMin {
+		return false
+	}
+	if f.StrikeMax > 0 && parsed.Strike > f.StrikeMax {
+		return false
+	}
+	if !f.Expiration.IsZero() && !parsed.Expiration.Equal(f.Expiration) {
+		return false
+	}
+	if f.Type != "" && parsed.Type != f.Type {
+		return false
+	}
+	return true
+}
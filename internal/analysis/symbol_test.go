@@ -0,0 +1,265 @@
+package analysis
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// corpusEntry is one line of testdata/option_symbols.txt
+type corpusEntry struct {
+	line             int
+	symbol           string
+	root             string
+	underlying       string
+	mergedUnderlying string
+	adjusted         bool
+	expiration       time.Time
+	optionType       string
+	strike           float64
+}
+
+// readSymbolCorpus parses testdata/option_symbols.txt
+func readSymbolCorpus(t *testing.T) []corpusEntry {
+	t.Helper()
+	file, err := os.Open("testdata/option_symbols.txt")
+	if err != nil {
+		t.Fatalf("open corpus: %v", err)
+	}
+	defer file.Close()
+
+	var entries []corpusEntry
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "|")
+		if len(fields) != 8 {
+			t.Fatalf("corpus line %d: want 8 fields, got %d", lineNum, len(fields))
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		entry := corpusEntry{
+			line:             lineNum,
+			symbol:           fields[0],
+			root:             fields[1],
+			underlying:       fields[2],
+			mergedUnderlying: fields[3],
+			optionType:       fields[6],
+		}
+		if entry.adjusted, err = strconv.ParseBool(fields[4]); err != nil {
+			t.Fatalf("corpus line %d: adjusted: %v", lineNum, err)
+		}
+		if entry.expiration, err = time.Parse("2006-01-02", fields[5]); err != nil {
+			t.Fatalf("corpus line %d: expiration: %v", lineNum, err)
+		}
+		if entry.strike, err = strconv.ParseFloat(fields[7], 64); err != nil {
+			t.Fatalf("corpus line %d: strike: %v", lineNum, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("read corpus: %v", err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("corpus is empty")
+	}
+	return entries
+}
+
+// withIndexRootMerging runs fn with index root merging set, restoring the default afterwards
+func withIndexRootMerging(t *testing.T, enabled bool, fn func()) {
+	t.Helper()
+	SetIndexRootMerging(enabled)
+	defer SetIndexRootMerging(false)
+	fn()
+}
+
+func TestParseOptionSymbolCorpus(t *testing.T) {
+	entries := readSymbolCorpus(t)
+	for _, merged := range []bool{false, true} {
+		withIndexRootMerging(t, merged, func() {
+			for _, entry := range entries {
+				contract, err := ParseOptionSymbol(entry.symbol)
+				if err != nil {
+					t.Errorf("line %d: ParseOptionSymbol(%q) (merged %v): %v", entry.line, entry.symbol, merged, err)
+					continue
+				}
+				want := Contract{
+					Root:       entry.root,
+					Underlying: entry.underlying,
+					Adjusted:   entry.adjusted,
+					Expiration: entry.expiration,
+					Strike:     entry.strike,
+					Type:       entry.optionType,
+				}
+				if merged {
+					want.Underlying = entry.mergedUnderlying
+				}
+				if contract != want {
+					t.Errorf("line %d: ParseOptionSymbol(%q) (merged %v) = %+v, want %+v", entry.line, entry.symbol, merged, contract, want)
+				}
+			}
+		})
+	}
+}
+
+func TestIndexRootsResolveToStandardSeries(t *testing.T) {
+	for root, index := range IndexRoots {
+		if _, ok := IndexRoots[index]; ok {
+			t.Errorf("IndexRoots[%q] = %q, which is itself an alternate series", root, index)
+		}
+		if !validRoot(root) || !validRoot(index) {
+			t.Errorf("IndexRoots[%q] = %q is not a valid root", root, index)
+		}
+	}
+}
+
+func TestParseOptionSymbol(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name   string
+		symbol string
+		merged bool
+		want   Contract
+	}{
+		{"standard call", "O:AAPL230616C00150000", false, Contract{"AAPL", "AAPL", false, date(2023, 6, 16), 150, "call"}},
+		{"standard put", "O:AAPL230616P00150000", false, Contract{"AAPL", "AAPL", false, date(2023, 6, 16), 150, "put"}},
+		{"without O: prefix", "TSLA250117C00250000", false, Contract{"TSLA", "TSLA", false, date(2025, 1, 17), 250, "call"}},
+		{"lowercase prefix", "o:SPY251219P00600000", false, Contract{"SPY", "SPY", false, date(2025, 12, 19), 600, "put"}},
+		{"fractional strike", "O:SPY251219C00600500", false, Contract{"SPY", "SPY", false, date(2025, 12, 19), 600.5, "call"}},
+		{"sub-dollar strike", "O:SNDL250117C00000500", false, Contract{"SNDL", "SNDL", false, date(2025, 1, 17), 0.5, "call"}},
+		{"unpadded strike", "O:AAPL230616C150000", false, Contract{"AAPL", "AAPL", false, date(2023, 6, 16), 150, "call"}},
+		{"single letter root", "O:F250117P00012000", false, Contract{"F", "F", false, date(2025, 1, 17), 12, "put"}},
+		{"dotted root", "O:BRK.B250117C00450000", false, Contract{"BRK.B", "BRK.B", false, date(2025, 1, 17), 450, "call"}},
+		{"OSI padding", "SPY   251219C00600000", false, Contract{"SPY", "SPY", false, date(2025, 12, 19), 600, "call"}},
+		{"OSI padding with prefix", "O:AAPL  230616P00150000", false, Contract{"AAPL", "AAPL", false, date(2023, 6, 16), 150, "put"}},
+		{"SPXW unmerged", "O:SPXW241220C05000000", false, Contract{"SPXW", "SPXW", false, date(2024, 12, 20), 5000, "call"}},
+		{"SPXW merged", "O:SPXW241220C05000000", true, Contract{"SPXW", "SPX", false, date(2024, 12, 20), 5000, "call"}},
+		{"SPXW OSI padded merged", "SPXW  241220P04800000", true, Contract{"SPXW", "SPX", false, date(2024, 12, 20), 4800, "put"}},
+		{"NDXP unmerged", "O:NDXP250321C20000000", false, Contract{"NDXP", "NDXP", false, date(2025, 3, 21), 20000, "call"}},
+		{"NDXP merged", "O:NDXP250321C20000000", true, Contract{"NDXP", "NDX", false, date(2025, 3, 21), 20000, "call"}},
+		{"standard index merged", "O:SPX241220C05000000", true, Contract{"SPX", "SPX", false, date(2024, 12, 20), 5000, "call"}},
+		{"adjusted root", "O:AAPL1230616C00150000", false, Contract{"AAPL1", "AAPL1", true, date(2023, 6, 16), 150, "call"}},
+		{"adjusted root merged", "O:AAPL1230616C00150000", true, Contract{"AAPL1", "AAPL1", true, date(2023, 6, 16), 150, "call"}},
+		{"adjusted OSI padded", "AAPL1 230616P00150000", false, Contract{"AAPL1", "AAPL1", true, date(2023, 6, 16), 150, "put"}},
+		{"leap day", "O:AAPL240229C00150000", false, Contract{"AAPL", "AAPL", false, date(2024, 2, 29), 150, "call"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withIndexRootMerging(t, tt.merged, func() {
+				got, err := ParseOptionSymbol(tt.symbol)
+				if err != nil {
+					t.Fatalf("ParseOptionSymbol(%q): %v", tt.symbol, err)
+				}
+				if got != tt.want {
+					t.Errorf("ParseOptionSymbol(%q) = %+v, want %+v", tt.symbol, got, tt.want)
+				}
+			})
+		})
+	}
+}
+
+func TestParseOptionSymbolErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		symbol string
+		want   string
+	}{
+		{"empty", "", "could not find call/put indicator"},
+		{"prefix only", "O:", "could not find call/put indicator"},
+		{"root only", "O:AAPL", "could not find call/put indicator"},
+		{"no strike", "O:AAPL230616C", "could not find call/put indicator"},
+		{"unknown type", "O:AAPL230616X00150000", "could not find call/put indicator"},
+		{"short", "O:A2306C1", "invalid option symbol format"},
+		{"no root", "O:230616C00150000", "invalid option symbol format"},
+		{"blank OSI root", "      230616C00150000", "invalid root"},
+		{"digit root", "O:123230616C00150000", "invalid root"},
+		{"punctuated root", "O:AA-PL230616C00150000", "invalid root"},
+		{"month 13", "O:AAPL231316C00150000", "invalid expiration"},
+		{"month 0", "O:AAPL230016C00150000", "invalid expiration"},
+		{"day 0", "O:AAPL230600C00150000", "invalid expiration"},
+		{"February 30", "O:AAPL230230C00150000", "invalid expiration"},
+		{"February 29 outside a leap year", "O:AAPL230229C00150000", "invalid expiration"},
+		{"letter in expiration", "O:AAPL23A616C00150000", "invalid expiration"},
+		{"letter in strike", "O:AAPL230616C0015000A", "invalid strike"},
+		{"decimal strike", "O:AAPL230616C00150.00", "invalid strike"},
+		{"strike too long", "O:AAPL230616C0000000000150000", "invalid strike"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOptionSymbol(tt.symbol)
+			if err == nil {
+				t.Fatalf("ParseOptionSymbol(%q) = %+v, want an error", tt.symbol, got)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseOptionSymbol(%q) error = %q, want it to contain %q", tt.symbol, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStrike(t *testing.T) {
+	strike, err := ParseStrike("O:AAPL230616C00152500")
+	if err != nil || strike != 152.5 {
+		t.Errorf("ParseStrike = %v, %v, want 152.5", strike, err)
+	}
+	if _, err := ParseStrike("O:AAPL"); err == nil {
+		t.Error("ParseStrike(\"O:AAPL\") succeeded, want an error")
+	}
+}
+
+func TestContractFilter(t *testing.T) {
+	tests := []struct {
+		name                                   string
+		strikeMin, strikeMax, expiration, kind string
+		symbol                                 string
+		want                                   bool
+	}{
+		{"empty filter", "", "", "", "", "O:AAPL230616C00150000", true},
+		{"empty filter matches unparseable", "", "", "", "", "garbage", true},
+		{"unparseable", "100", "", "", "", "garbage", false},
+		{"inside strike range", "100", "200", "", "", "O:AAPL230616C00150000", true},
+		{"strike range is inclusive", "150", "150", "", "", "O:AAPL230616C00150000", true},
+		{"below strike range", "160", "", "", "", "O:AAPL230616C00150000", false},
+		{"above strike range", "", "140", "", "", "O:AAPL230616C00150000", false},
+		{"expiration", "", "", "2023-06-16", "", "O:AAPL230616C00150000", true},
+		{"other expiration", "", "", "2023-06-23", "", "O:AAPL230616C00150000", false},
+		{"type", "", "", "", "put", "O:AAPL230616P00150000", true},
+		{"other type", "", "", "", "call", "O:AAPL230616P00150000", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := ParseContractFilter(tt.strikeMin, tt.strikeMax, tt.expiration, tt.kind)
+			if err != nil {
+				t.Fatalf("ParseContractFilter: %v", err)
+			}
+			if got := filter.Matches(tt.symbol); got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.symbol, got, tt.want)
+			}
+		})
+	}
+
+	invalid := [][4]string{
+		{"-1", "", "", ""},
+		{"", "0", "", ""},
+		{"200", "100", "", ""},
+		{"", "", "06/16/2023", ""},
+		{"", "", "", "both"},
+	}
+	for _, args := range invalid {
+		if _, err := ParseContractFilter(args[0], args[1], args[2], args[3]); err == nil {
+			t.Errorf("ParseContractFilter(%q) succeeded, want an error", args)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package analysis
+
+import (
+	"fmt"
+	"time"
+)
+
+// Upstream aggregate timespans
+// Minute aggregates cut data volume roughly 60x for deployments that don't need second resolution
+const (
+	TimespanSecond = "second" // Per-second aggregates (event type "A")
+	TimespanMinute = "minute" // Per-minute aggregates (event type "AM")
+)
+
+// ValidateTimespan checks that an aggregate timespan is supported (empty means TimespanSecond)
+func ValidateTimespan(timespan string) error {
+	switch timespan {
+	case "", TimespanSecond, TimespanMinute:
+		return nil
+	default:
+		return fmt.Errorf("invalid timespan %q (must be %s or %s)", timespan, TimespanSecond, TimespanMinute)
+	}
+}
+
+// TimespanDuration returns the length of one aggregate for a timespan
+func TimespanDuration(timespan string) time.Duration {
+	if timespan == TimespanMinute {
+		return time.Minute
+	}
+	return time.Second
+}
+
+// TimespanEventType returns the event type ("ev") of aggregates for a timespan
+func TimespanEventType(timespan string) string {
+	if timespan == TimespanMinute {
+		return "AM"
+	}
+	return "A"
+}
+
+// PeriodSettled reports whether all aggregates for a period ending at periodEnd should have arrived by now
+// An aggregate is published after its own interval closes, so the last one for a period
+// arrives up to one timespan after the period ends
+func PeriodSettled(periodEnd time.Time, now time.Time, timespan string) bool {
+	return !now.Before(periodEnd.Add(TimespanDuration(timespan)))
+}
@@ -0,0 +1,95 @@
+package analysis
+
+import "fmt"
+
+// Trade-size classes, by the premium of a single aggregate (volume × VWAP × 100)
+const (
+	SizeRetail        = "retail"        // Under $5K
+	SizeMid           = "mid"           // $5K to $100K
+	SizeInstitutional = "institutional" // Over $100K
+)
+
+// Trade-size class boundaries in dollars of premium
+const (
+	RetailMaxPremium = 5000
+	MidMaxPremium    = 100000
+)
+
+// ClassifyTradeSize returns the trade-size class for an aggregate's premium
+func ClassifyTradeSize(premium float64) string {
+	switch {
+	case premium < RetailMaxPremium:
+		return SizeRetail
+	case premium <= MidMaxPremium:
+		return SizeMid
+	default:
+		return SizeInstitutional
+	}
+}
+
+// ValidateSizeClass checks that a trade-size class name is supported
+func ValidateSizeClass(class string) error {
+	switch class {
+	case SizeRetail, SizeMid, SizeInstitutional:
+		return nil
+	}
+	return fmt.Errorf("invalid size class %q, expected %q, %q, or %q", class, SizeRetail, SizeMid, SizeInstitutional)
+}
+
+// SizePremium is the call and put premium attributed to one trade-size class
+type SizePremium struct {
+	CallPremium float64 `json:"call_premium"`
+	PutPremium  float64 `json:"put_premium"`
+}
+
+// SizeBuckets splits a period's premium by trade-size class
+type SizeBuckets struct {
+	Retail        SizePremium `json:"retail"`
+	Mid           SizePremium `json:"mid"`
+	Institutional SizePremium `json:"institutional"`
+}
+
+// Add attributes one aggregate's premium to its trade-size class
+func (b *SizeBuckets) Add(optionType string, premium float64) {
+	bucket := b.Class(ClassifyTradeSize(premium))
+	if optionType == "call" {
+		bucket.CallPremium += premium
+	} else if optionType == "put" {
+		bucket.PutPremium += premium
+	}
+}
+
+// Class returns the bucket for a trade-size class (nil for an unknown class)
+func (b *SizeBuckets) Class(class string) *SizePremium {
+	switch class {
+	case SizeRetail:
+		return &b.Retail
+	case SizeMid:
+		return &b.Mid
+	case SizeInstitutional:
+		return &b.Institutional
+	}
+	return nil
+}
+
+// LargestTrade is the single aggregate with the most premium in a period
+type LargestTrade struct {
+	Symbol    string  `json:"symbol"`
+	Premium   float64 `json:"premium"`
+	Volume    int64   `json:"volume"`
+	Timestamp int64   `json:"timestamp"` // Start of the aggregate (Unix ms)
+}
+
+// AddTrade records an aggregate as the period's largest trade if it has more premium than the current one
+// Ties keep the earlier trade
+func (s *TimePeriodSummary) AddTrade(agg Aggregate, premium float64) {
+	if s.LargestTrade != nil && (premium < s.LargestTrade.Premium || (premium == s.LargestTrade.Premium && agg.StartTimestamp >= s.LargestTrade.Timestamp)) {
+		return
+	}
+	s.LargestTrade = &LargestTrade{
+		Symbol:    agg.Symbol,
+		Premium:   premium,
+		Volume:    agg.Volume,
+		Timestamp: agg.StartTimestamp,
+	}
+}
@@ -0,0 +1,109 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+)
+
+// StrikePremium is the premium traded at one strike on one side of the chain
+type StrikePremium struct {
+	Strike  float64 `json:"strike"`
+	Premium float64 `json:"premium"`
+}
+
+// WallTracker accumulates call and put premium per strike to find the day's walls
+// The call wall is the strike with the most call premium, the put wall the strike with the most put premium
+type WallTracker struct {
+	calls map[float64]float64
+	puts  map[float64]float64
+}
+
+// NewWallTracker creates an empty wall tracker
+func NewWallTracker() *WallTracker {
+	return &WallTracker{
+		calls: make(map[float64]float64),
+		puts:  make(map[float64]float64),
+	}
+}
+
+// Add records an aggregate's premium at its strike; aggregates with unparseable symbols are skipped
+func (t *WallTracker) Add(agg Aggregate) {
+	parsed, err := ParseOptionSymbol(agg.Symbol)
+	if err != nil {
+		return
+	}
+
+	premium := CalculatePremium(agg.Volume, agg.VWAP)
+	if parsed.Type == "call" {
+		t.calls[parsed.Strike] += premium
+	} else {
+		t.puts[parsed.Strike] += premium
+	}
+}
+
+// Merge adds another tracker's per-strike premium into t
+func (t *WallTracker) Merge(other *WallTracker) {
+	for strike, premium := range other.calls {
+		t.calls[strike] += premium
+	}
+	for strike, premium := range other.puts {
+		t.puts[strike] += premium
+	}
+}
+
+// Walls returns the current call and put walls (nil when that side has no premium)
+func (t *WallTracker) Walls() (callWall *StrikePremium, putWall *StrikePremium) {
+	return topStrike(t.calls), topStrike(t.puts)
+}
+
+// Top returns up to n strikes per side ordered by premium, largest first
+func (t *WallTracker) Top(n int) (calls []StrikePremium, puts []StrikePremium) {
+	return rankStrikes(t.calls, n), rankStrikes(t.puts, n)
+}
+
+// Apply sets a summary's call and put walls from the tracker
+func (t *WallTracker) Apply(summary *TimePeriodSummary) {
+	summary.CallWall, summary.PutWall = t.Walls()
+}
+
+// topStrike returns the strike with the most premium, preferring the lower strike on ties
+func topStrike(premiums map[float64]float64) *StrikePremium {
+	var top *StrikePremium
+	for strike, premium := range premiums {
+		if premium <= 0 {
+			continue
+		}
+		if top == nil || premium > top.Premium || (premium == top.Premium && strike < top.Strike) {
+			top = &StrikePremium{Strike: strike, Premium: premium}
+		}
+	}
+	return top
+}
+
+// rankStrikes returns up to n strikes ordered by premium, largest first (n <= 0 returns all)
+func rankStrikes(premiums map[float64]float64, n int) []StrikePremium {
+	ranked := make([]StrikePremium, 0, len(premiums))
+	for strike, premium := range premiums {
+		if premium > 0 {
+			ranked = append(ranked, StrikePremium{Strike: strike, Premium: premium})
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Premium != ranked[j].Premium {
+			return ranked[i].Premium > ranked[j].Premium
+		}
+		return ranked[i].Strike < ranked[j].Strike
+	})
+	if n > 0 && len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
+
+// WallDistancePct returns how far spot is from a wall's strike as a percentage of spot
+func WallDistancePct(spot float64, wall *StrikePremium) float64 {
+	if wall == nil || spot <= 0 {
+		return math.Inf(1)
+	}
+	return math.Abs(wall.Strike-spot) / spot * 100
+}
@@ -0,0 +1,59 @@
+package analysis
+
+import (
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// ZeroFill returns the summaries with an empty summary inserted for every period without trades, so clients can chart
+// them on a fixed time axis. Periods are filled from the regular session open (or the first traded period, if earlier)
+// to the later of the last traded period and until, which is capped at the session close; pass the zero time to stop at
+// the last trade. Session bounds come from the trading-days calendar: on days the market is closed, or when opts
+// excludes the regular session, only the gaps between traded periods are filled
+// Filled periods carry the day's walls so far; anomaly scores and the other period metrics are left empty
+// The input is not modified, and an empty input is returned as is since it doesn't say which day to fill
+func ZeroFill(summaries []TimePeriodSummary, opts AggregateOptions, until time.Time) []TimePeriodSummary {
+	if len(summaries) == 0 || opts.PeriodMinutes <= 0 {
+		return summaries
+	}
+	periodMillis := int64(opts.PeriodMinutes) * 60 * 1000
+
+	first := summaries[0].PeriodStart
+	start := first.UnixMilli()
+	end := summaries[len(summaries)-1].PeriodEnd.UnixMilli()
+	if market.IsTradingDay(first) && (opts.Sessions == 0 || opts.Sessions.Contains(market.SessionRegular)) {
+		open := RoundDownToAnchoredPeriod(market.OpenTime(first).UnixMilli(), opts.PeriodMinutes, opts.Anchor)
+		if open < start {
+			start = open
+		}
+		if !until.IsZero() {
+			if sessionClose := market.SessionCloseTime(first); until.After(sessionClose) {
+				until = sessionClose
+			}
+			if until.UnixMilli() > end {
+				end = until.UnixMilli()
+			}
+		}
+	}
+
+	filled := make([]TimePeriodSummary, 0, int((end-start)/periodMillis)+1)
+	next := 0
+	for periodStart := start; periodStart < end; periodStart += periodMillis {
+		// Traded periods are kept as they are, including any that don't sit on the grid
+		for next < len(summaries) && summaries[next].PeriodStart.UnixMilli() <= periodStart {
+			filled = append(filled, summaries[next])
+			next++
+		}
+		if last := len(filled) - 1; last >= 0 && filled[last].PeriodEnd.UnixMilli() > periodStart {
+			continue
+		}
+
+		empty := NewPeriodSummary(periodStart, periodStart+periodMillis)
+		if last := len(filled) - 1; last >= 0 {
+			empty.CallWall, empty.PutWall = filled[last].CallWall, filled[last].PutWall
+		}
+		filled = append(filled, *empty)
+	}
+	return append(filled, summaries[next:]...)
+}
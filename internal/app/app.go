@@ -0,0 +1,81 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// SetupLogging applies the shared logging setup for all commands
+// When name is non-empty, log lines are prefixed with it so multiplexed output stays readable
+// Setting LOG_FORMAT=json switches to one JSON object per line on stdout, for container log collectors
+func SetupLogging(name string) {
+	// Load .env once up front so every subcommand sees the same environment
+	_ = godotenv.Load()
+
+	if strings.EqualFold(os.Getenv("LOG_FORMAT"), "json") {
+		service := name
+		if service == "" {
+			service = strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+		}
+		log.SetFlags(0)
+		log.SetPrefix("")
+		log.SetOutput(&jsonLogWriter{out: os.Stdout, service: service})
+		return
+	}
+
+	log.SetFlags(log.LstdFlags)
+	if name != "" {
+		log.SetPrefix(fmt.Sprintf("[%s] ", name))
+	}
+}
+
+// jsonLogWriter converts each standard log line into a JSON object
+type jsonLogWriter struct {
+	out     io.Writer
+	service string
+}
+
+// jsonLogLine is the structure written for each log line
+type jsonLogLine struct {
+	Time    string `json:"time"`
+	Level   string `json:"level"`
+	Service string `json:"service"`
+	Message string `json:"msg"`
+}
+
+// Write implements io.Writer; the log package calls it once per log line
+func (w *jsonLogWriter) Write(p []byte) (int, error) {
+	message := strings.TrimRight(string(p), "\n")
+
+	// Infer level from the message conventions used across the codebase
+	level := "info"
+	upper := strings.ToUpper(message)
+	if strings.HasPrefix(upper, "ERROR") || strings.HasPrefix(upper, "FAILED") || strings.Contains(upper, "ERROR:") {
+		level = "error"
+	} else if strings.HasPrefix(upper, "WARNING") {
+		level = "warn"
+	}
+
+	data, err := json.Marshal(jsonLogLine{
+		Time:    time.Now().UTC().Format(time.RFC3339Nano),
+		Level:   level,
+		Service: w.service,
+		Message: message,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := w.out.Write(append(data, '\n')); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
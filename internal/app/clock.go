@@ -0,0 +1,49 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/clock"
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// SimulatedClockFlags holds the --sim-start and --sim-speed values registered by ClockFlags
+type SimulatedClockFlags struct {
+	Start *string
+	Speed *float64
+}
+
+// ClockFlags registers --sim-start and --sim-speed on fs; pass them to EnableSimulatedClock after parsing and after
+// the analysis timezone is set
+// Every service in one simulation (mock-logger, server, notifications) should be started with the same values
+func ClockFlags(fs *flag.FlagSet) SimulatedClockFlags {
+	return SimulatedClockFlags{
+		Start: fs.String("sim-start", "", "Run on a simulated clock starting at this time, RFC 3339 or YYYY-MM-DDTHH:MM in the analysis timezone (default: wall clock)"),
+		Speed: fs.Float64("sim-speed", 1, "Simulated clock speed as a multiple of real time, e.g. 60 for one market minute per second (default: 1)"),
+	}
+}
+
+// EnableSimulatedClock switches the process to a simulated clock when --sim-start is set
+func EnableSimulatedClock(flags SimulatedClockFlags) error {
+	if *flags.Start == "" {
+		return nil
+	}
+	if *flags.Speed <= 0 {
+		return fmt.Errorf("--sim-speed must be positive")
+	}
+
+	start, err := time.Parse(time.RFC3339, *flags.Start)
+	if err != nil {
+		start, err = time.ParseInLocation("2006-01-02T15:04", *flags.Start, market.AnalysisLocation())
+	}
+	if err != nil {
+		return fmt.Errorf("invalid --sim-start %q, expected RFC 3339 or YYYY-MM-DDTHH:MM", *flags.Start)
+	}
+
+	clock.Set(clock.NewSimulated(start, *flags.Speed))
+	log.Printf("Running on a simulated clock from %s at %gx", start.In(market.AnalysisLocation()).Format(time.RFC3339), *flags.Speed)
+	return nil
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"expvar"
+	"log"
+	"net/http"
+	"net/http/pprof"
+)
+
+// StartDiagnostics serves net/http/pprof and expvar on addr in the background (no-op when addr is empty)
+// It uses its own listener and mux so profiles are only reachable where addr is bound (e.g. localhost:6060),
+// never on a service's public port
+func StartDiagnostics(addr string) {
+	if addr == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	go func() {
+		log.Printf("Diagnostics listening on %s (/debug/pprof/, /debug/vars)", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf("Diagnostics listener failed: %v", err)
+		}
+	}()
+}
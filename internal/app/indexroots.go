@@ -0,0 +1,10 @@
+package app
+
+import "flag"
+
+// IndexRootsFlag registers --merge-index-roots on fs; pass its value to analysis.SetIndexRootMerging after parsing
+// It changes which log file index series contracts are written to and read from, so every service sharing a log
+// directory must use the same value
+func IndexRootsFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("merge-index-roots", false, "File alternate index series (SPXW, NDXP, RUTW, ...) under their index (SPX, NDX, RUT, ...) instead of their own root (default: false)")
+}
@@ -0,0 +1,346 @@
+// Package loggerapp implements the logger command (websocket data logger service)
+// It is shared by cmd/logger and the unified jax-ov binary
+package loggerapp
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/app"
+	"github.com/ekinolik/jax-ov/internal/config"
+	"github.com/ekinolik/jax-ov/internal/latency"
+	"github.com/ekinolik/jax-ov/internal/logger"
+	"github.com/ekinolik/jax-ov/internal/market"
+	"github.com/ekinolik/jax-ov/internal/marketdata"
+	"github.com/ekinolik/jax-ov/internal/websocket"
+)
+
+// Run runs the logger command with the given command-line arguments (excluding the program name)
+func Run(args []string) {
+	// Parse command-line flags
+	fs := flag.NewFlagSet("logger", flag.ExitOnError)
+	ticker := fs.String("ticker", "", "Underlying stock ticker (optional, e.g., AAPL). If not provided, logs all symbols")
+	mode := fs.String("mode", "all", "Subscription mode: 'all' or 'contract' (default: 'all')")
+	contract := fs.String("contract", "", "Specific option contract symbol (required if mode is 'contract')")
+	logDir := fs.String("log-dir", "./logs", "Log directory path (default: ./logs)")
+	statusFile := fs.String("status-file", "", "Heartbeat status file path (default: <log-dir>/logger-status.json)")
+	statusInterval := fs.Duration("status-interval", 10*time.Second, "How often to write the heartbeat status file (default: 10s)")
+	vendor := fs.String("vendor", marketdata.VendorMassive, "Market-data vendor: massive or stub (default: massive)")
+	timespan := fs.String("timespan", analysis.TimespanSecond, "Aggregate timespan: second or minute (default: second)")
+	symbolFilterFile := fs.String("symbol-filter", "", "JSON file of allow/deny underlying patterns for 'all' mode, reloaded when it changes (default: disabled)")
+	symbolFilterInterval := fs.Duration("symbol-filter-interval", 10*time.Second, "How often the symbol filter file is checked for changes (default: 10s)")
+	diskWarn := fs.Float64("disk-warn", 10, "Free disk space (percent) below which older daily files are compressed and operators alerted; 0 disables (default: 10)")
+	diskCritical := fs.Float64("disk-critical", 5, "Free disk space (percent) below which only --disk-priority symbols are logged; 0 disables (default: 5)")
+	diskEmergency := fs.Float64("disk-emergency", 1, "Free disk space (percent) below which writing stops until space is freed; 0 disables (default: 1)")
+	diskPriority := fs.String("disk-priority", "", "Comma-separated underlyings still logged below --disk-critical (default: none)")
+	diskCompressAfter := fs.Int("disk-compress-after", 7, "Age in days of the daily files compressed below --disk-warn (default: 7)")
+	slowWrite := fs.Duration("slow-write", logger.DefaultSlowWriteThreshold, "Append latency above which a log write is reported as slow and /healthz degraded; 0 disables (default: 250ms)")
+	diskInterval := fs.Duration("disk-interval", 30*time.Second, "How often free disk space is checked (default: 30s)")
+	outliersDir := fs.String("outliers-dir", "", "Directory detected premium outliers are written to, one file per ticker and day (default: <log-dir>/outliers)")
+	outlierPercentile := fs.Float64("outlier-percentile", analysis.DefaultOutlierPercentile, "Premium percentile (0-100) of the ticker and side's trades so far that day that outliers are measured against (default: 90.0)")
+	outlierMultiple := fs.Float64("outlier-multiple", analysis.DefaultOutlierMultiple, "Multiple of --outlier-percentile a trade's premium must reach to be logged as an outlier; 0 disables (default: 10.0)")
+	outlierMinSamples := fs.Int("outlier-min-samples", analysis.DefaultOutlierMinSamples, "Trades a ticker and side must have that day before outliers are flagged (default: 100)")
+	chaosSpec := fs.String("chaos", "", "Inject faults into the stub stream, e.g. drop-every=2m,drop-for=10s,delay=250ms,duplicate=0.05,seed=42; requires --vendor stub (default: disabled)")
+	diagAddr := fs.String("diag-addr", "", "Bind address for the pprof/expvar diagnostics listener, e.g. localhost:6060 (default: disabled)")
+	quiet := app.QuietFlag(fs)
+	timezone := app.TimezoneFlag(fs)
+	mergeIndexRoots := app.IndexRootsFlag(fs)
+	fs.Parse(args)
+	if err := config.ApplyFlagEnv(fs); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
+	if err := market.SetAnalysisTimezone(*timezone); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	analysis.SetIndexRootMerging(*mergeIndexRoots)
+	progress := app.NewProgress(*quiet)
+	app.StartDiagnostics(*diagAddr)
+
+	// Validate flags
+	if *mode != "all" && *mode != "contract" {
+		log.Fatal("Error: --mode must be either 'all' or 'contract'")
+	}
+
+	if *mode == "contract" && *contract == "" {
+		log.Fatal("Error: --contract is required when --mode is 'contract'")
+	}
+
+	if *statusFile == "" {
+		*statusFile = filepath.Join(*logDir, logger.StatusFileName)
+	}
+	if *outliersDir == "" {
+		*outliersDir = filepath.Join(*logDir, logger.OutliersDirName)
+	}
+
+	if *outlierPercentile < 0 || *outlierPercentile > 100 {
+		log.Fatal("Error: --outlier-percentile must be between 0 and 100")
+	}
+	if *outlierMultiple < 0 {
+		log.Fatal("Error: --outlier-multiple must not be negative")
+	}
+
+	if err := marketdata.ValidateVendor(*vendor); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	if err := analysis.ValidateTimespan(*timespan); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	faults, err := websocket.ParseFaults(*chaosSpec)
+	if err != nil {
+		log.Fatalf("Invalid --chaos: %v", err)
+	}
+	if *chaosSpec != "" && *vendor != marketdata.VendorStub {
+		log.Fatal("Error: --chaos requires --vendor stub")
+	}
+
+	diskThresholds := logger.DiskThresholds{Warn: *diskWarn, Critical: *diskCritical, Emergency: *diskEmergency}
+	if err := diskThresholds.Validate(); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if *diskCompressAfter < 1 {
+		log.Fatal("Error: --disk-compress-after must be at least 1")
+	}
+	if *diskInterval <= 0 {
+		log.Fatal("Error: --disk-interval must be greater than 0")
+	}
+
+	// Load the symbol filter before connecting so a bad file fails fast
+	var symbolFilter *logger.SymbolFilter
+	if *symbolFilterFile != "" {
+		if *mode != "all" {
+			log.Fatal("Error: --symbol-filter requires --mode all")
+		}
+		if *symbolFilterInterval <= 0 {
+			log.Fatal("Error: --symbol-filter-interval must be greater than 0")
+		}
+		var err error
+		symbolFilter, err = logger.NewSymbolFilter(*symbolFilterFile)
+		if err != nil {
+			log.Fatalf("Failed to load symbol filter: %v", err)
+		}
+	}
+
+	// Load configuration (the stub vendor needs no API key)
+	var apiKey string
+	if *vendor != marketdata.VendorStub {
+		cfg, err := config.Load()
+		if err != nil {
+			log.Fatalf("Failed to load configuration: %v", err)
+		}
+		apiKey = cfg.APIKey
+	}
+
+	// Create file logger
+	fileLogger, err := logger.NewDailyLogger(*logDir)
+	if err != nil {
+		log.Fatalf("Failed to create logger: %v", err)
+	}
+	fileLogger.SetSlowWriteThreshold(*slowWrite)
+
+	// End-to-end latency from receiving each aggregate to logging it, on the diagnostics listener's /debug/vars
+	latencies := latency.NewRecorder()
+	latencies.Publish()
+
+	// Flag premium outliers as they are logged, for the server's outlier feed
+	var outlierDetector *analysis.OutlierDetector
+	var outlierLogger *logger.OutlierLogger
+	if *outlierMultiple > 0 {
+		outlierLogger, err = logger.NewOutlierLogger(*outliersDir)
+		if err != nil {
+			log.Fatalf("Failed to create outlier logger: %v", err)
+		}
+		outlierDetector = analysis.NewOutlierDetector(*outlierPercentile, *outlierMultiple, *outlierMinSamples)
+	}
+
+	// Check free space before subscribing, so a nearly full disk is throttled from the first message
+	var diskGuard *logger.DiskGuard
+	if diskThresholds != (logger.DiskThresholds{}) {
+		diskGuard = logger.NewDiskGuard(*logDir, diskThresholds, strings.Split(*diskPriority, ","), *diskCompressAfter)
+		if _, err := diskGuard.Check(); err != nil {
+			log.Printf("Warning: disk guard disabled: %v", err)
+			diskGuard = nil
+		}
+	}
+
+	// Create live stream for the configured vendor
+	stream, err := marketdata.NewStreamSource(*vendor, apiKey, *timespan)
+	if err != nil {
+		log.Fatalf("Failed to create stream: %v", err)
+	}
+
+	// Inject faults for chaos testing in mock environments
+	var chaos *marketdata.ChaosStream
+	if *chaosSpec != "" {
+		chaos = marketdata.NewChaosStream(stream, faults)
+		stream = chaos
+		progress.Printf("Chaos mode: injecting %s\n", faults)
+	}
+	defer stream.Close()
+
+	// Connect to the vendor
+	if err := stream.Connect(); err != nil {
+		log.Fatalf("Failed to connect: %v", err)
+	}
+
+	// Determine subscription ticker
+	var subscriptionTicker string
+	var filterTicker string // Underlying ticker to filter by (empty means log all)
+	if *mode == "all" {
+		// Always subscribe to all options
+		subscriptionTicker = "*"
+		// If ticker is provided, filter to that underlying symbol
+		if *ticker != "" {
+			filterTicker = strings.ToUpper(*ticker)
+		}
+	} else {
+		// Use the specific contract symbol
+		subscriptionTicker = *contract
+		filterTicker = "" // No filtering needed for specific contract
+	}
+
+	// Subscribe
+	if err := stream.Subscribe(subscriptionTicker); err != nil {
+		log.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if *mode == "all" {
+		if filterTicker != "" {
+			progress.Printf("Logger started - Subscribed to: %s (filtering for %s options)\n", subscriptionTicker, filterTicker)
+		} else {
+			progress.Printf("Logger started - Subscribed to: %s (logging all symbols)\n", subscriptionTicker)
+		}
+	} else {
+		progress.Printf("Logger started - Subscribed to: %s\n", subscriptionTicker)
+	}
+	if symbolFilter != nil {
+		rules := symbolFilter.Rules()
+		progress.Printf("Symbol filter: %s (%d allow, %d deny patterns)\n", *symbolFilterFile, len(rules.Allow), len(rules.Deny))
+	}
+	progress.Printf("Logging to directory: %s\n", *logDir)
+	progress.Printf("Writing heartbeat status to: %s\n", *statusFile)
+	if outlierDetector != nil {
+		progress.Printf("Writing premium outliers (%.1fx P%.1f) to: %s\n", *outlierMultiple, *outlierPercentile, *outliersDir)
+	}
+	progress.Println("Press Ctrl+C to stop")
+
+	// Set up context for graceful shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Write heartbeat status periodically so the server and monitoring can check logger health
+	statusTracker := logger.NewStatusTracker(subscriptionTicker)
+	go func() {
+		statusTicker := time.NewTicker(*statusInterval)
+		defer statusTicker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-statusTicker.C:
+				status := statusTracker.Snapshot()
+				if diskGuard != nil {
+					disk := diskGuard.Status()
+					status.Disk = &disk
+				}
+				writes := fileLogger.WriteStatus()
+				status.Writes = &writes
+				if err := logger.WriteStatusFile(*statusFile, status); err != nil {
+					log.Printf("Error writing status file: %v", err)
+				}
+			}
+		}
+	}()
+
+	// Throttle as the disk fills
+	if diskGuard != nil {
+		go diskGuard.Watch(ctx, *diskInterval)
+	}
+
+	// Pick up edits to the symbol filter file without a restart
+	if symbolFilter != nil {
+		go symbolFilter.Watch(ctx, *symbolFilterInterval)
+	}
+
+	// Handle interrupt signal
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-sigChan
+		progress.Println("\nShutting down logger...")
+		cancel()
+	}()
+
+	// Define handler for incoming messages
+	handler := func(agg analysis.Aggregate) {
+		// Stamp the ingest time, which the server and notifications service measure their latency from
+		agg.IngestedAt = time.Now().UnixMilli()
+		statusTracker.RecordMessage(subscriptionTicker)
+
+		// Extract underlying symbol for filtering (and for the disk guard, which sheds symbols by priority)
+		var underlyingSymbol string
+		if filterTicker != "" || symbolFilter != nil || diskGuard != nil {
+			var err error
+			underlyingSymbol, err = logger.ExtractUnderlyingSymbol(agg.Symbol)
+			if err != nil && (filterTicker != "" || symbolFilter != nil) {
+				// Skip aggregates we can't parse
+				return
+			}
+		}
+
+		if *mode == "all" {
+			// Filter by underlying ticker if specified
+			if filterTicker != "" && strings.ToUpper(underlyingSymbol) != filterTicker {
+				return // Skip this message, it doesn't match our filter
+			}
+			// Skip roots the operator excluded
+			if symbolFilter != nil && !symbolFilter.Allows(underlyingSymbol) {
+				statusTracker.RecordFiltered()
+				return
+			}
+		}
+
+		// Skip symbols the disk guard is shedding to save space
+		if diskGuard != nil && !diskGuard.Allows(underlyingSymbol) {
+			statusTracker.RecordThrottled()
+			return
+		}
+
+		// Write to log file (will automatically route to correct symbol file)
+		if err := fileLogger.Write(agg); err != nil {
+			log.Printf("Error writing to log file: %v", err)
+			statusTracker.RecordDrop()
+			return
+		}
+		latencies.Record(latency.StageLogged, agg.IngestTime())
+
+		// Only logged trades are checked, so the outliers match what analysis of the log files sees
+		if outlierDetector != nil {
+			if outlier, ok := outlierDetector.Check(agg); ok {
+				if err := outlierLogger.Write(*outlier); err != nil {
+					log.Printf("Error writing outlier: %v", err)
+				}
+			}
+		}
+	}
+
+	// Run the stream
+	if err := stream.Run(ctx, handler); err != nil && err != context.Canceled {
+		log.Printf("Error running stream: %v", err)
+	}
+	if chaos != nil {
+		stats := chaos.Stats()
+		log.Printf("Chaos mode: %d drops (%d messages lost), %d delayed, %d duplicated", stats.Drops, stats.Lost, stats.Delayed, stats.Duplicated)
+	}
+}
@@ -0,0 +1,14 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+)
+
+// MetricsFlag registers --metrics on fs; pass its value to analysis.EnablePeriodMetrics after parsing
+func MetricsFlag(fs *flag.FlagSet) *string {
+	return fs.String("metrics", "", fmt.Sprintf("Comma-separated plugin metrics added to every period's metrics object: %s (default: none)", strings.Join(analysis.PeriodMetricNames(), ", ")))
+}
@@ -0,0 +1,1119 @@
+// Package notificationsapp implements the notifications command (push notifications service)
+// It is shared by cmd/notifications and the unified jax-ov binary
+package notificationsapp
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/app"
+	"github.com/ekinolik/jax-ov/internal/auth"
+	"github.com/ekinolik/jax-ov/internal/clock"
+	"github.com/ekinolik/jax-ov/internal/config"
+	"github.com/ekinolik/jax-ov/internal/latency"
+	"github.com/ekinolik/jax-ov/internal/market"
+	"github.com/ekinolik/jax-ov/internal/marketdata"
+	"github.com/ekinolik/jax-ov/internal/metering"
+	"github.com/ekinolik/jax-ov/internal/notifications"
+	"github.com/ekinolik/jax-ov/internal/server"
+	"github.com/ekinolik/jax-ov/internal/sheets"
+	"github.com/fsnotify/fsnotify"
+	apns2 "github.com/sideshow/apns2"
+	"github.com/sideshow/apns2/token"
+)
+
+// basePeriodMinutes is the length of the periods kept per ticker; evaluation periods are resampled from them
+const basePeriodMinutes = 1
+
+// formatNumberWithCommas formats a number with thousands separators
+func formatNumberWithCommas(num float64) string {
+	// Convert to integer for formatting (premiums are typically whole numbers)
+	intNum := int64(num)
+	str := strconv.FormatInt(intNum, 10)
+
+	// Add commas every 3 digits from right to left
+	n := len(str)
+	if n <= 3 {
+		return str
+	}
+
+	var result strings.Builder
+	for i, char := range str {
+		if i > 0 && (n-i)%3 == 0 {
+			result.WriteRune(',')
+		}
+		result.WriteRune(char)
+	}
+	return result.String()
+}
+
+// startInternalAPI serves the endpoints the server pushes newly saved notification configs and device registrations to
+// Pushed state is written to this service's own directories (a no-op rewrite when they are shared with the server),
+// and a notification change triggers an immediate reload
+func startInternalAPI(addr string, secret string, notificationsDir string, devicesDir string, reload func()) {
+	mux := http.NewServeMux()
+
+	mux.Handle(notifications.SyncNotificationsPath, auth.SharedSecretMiddleware(secret, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var userConfig notifications.UserNotifications
+		if err := json.NewDecoder(r.Body).Decode(&userConfig); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := notifications.ValidateUserID(userConfig.UserID); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if userConfig.Notifications == nil {
+			userConfig.Notifications = make(map[string]notifications.NotificationConfig)
+		}
+		for ticker, notificationConfig := range userConfig.Notifications {
+			if err := notificationConfig.Validate(); err != nil {
+				http.Error(w, fmt.Sprintf("invalid notification for %s: %v", ticker, err), http.StatusBadRequest)
+				return
+			}
+		}
+
+		if err := notifications.SaveUserNotifications(userConfig.UserID, notificationsDir, &userConfig); err != nil {
+			log.Printf("Error saving pushed notifications for user %s: %v", userConfig.UserID, err)
+			http.Error(w, "Error saving notifications", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Received notifications for user %s (%d tickers), reloading", userConfig.UserID, len(userConfig.Notifications))
+		reload()
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	mux.Handle(notifications.SyncDevicesPath, auth.SharedSecretMiddleware(secret, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var devices notifications.UserDevices
+		if err := json.NewDecoder(r.Body).Decode(&devices); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := notifications.ValidateUserID(devices.UserID); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Devices are read at send time, so saving them is enough
+		if err := notifications.SaveUserDevices(devices.UserID, devicesDir, &devices); err != nil {
+			log.Printf("Error saving pushed devices for user %s: %v", devices.UserID, err)
+			http.Error(w, "Error saving devices", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Received devices for user %s (%d devices)", devices.UserID, len(devices.Devices))
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	go func() {
+		log.Printf("Internal API listening on %s (%s, %s)", addr, notifications.SyncNotificationsPath, notifications.SyncDevicesPath)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Fatalf("Internal API listener failed: %v", err)
+		}
+	}()
+}
+
+// Run runs the notifications command with the given command-line arguments (excluding the program name)
+func Run(args []string) {
+	// Parse command-line flags
+	fs := flag.NewFlagSet("notifications", flag.ExitOnError)
+	logDir := fs.String("log-dir", "./logs", "Log directory path (default: ./logs)")
+	notificationsDir := fs.String("notifications-dir", "./notifications", "Notifications config directory (default: ./notifications)")
+	devicesDir := fs.String("devices-dir", "./devices", "Devices directory path (default: ./devices)")
+	period := fs.Int("period", 5, "Default analysis period in minutes, for notifications without period_minutes (default: 5)")
+	timespan := fs.String("timespan", analysis.TimespanSecond, "Timespan of the logged aggregates: second or minute (default: second)")
+	shadowRules := fs.Bool("shadow-rules", false, "Evaluate the rule engine alongside the current thresholds and log divergences without sending pushes (default: false)")
+	spotVendor := fs.String("spot-vendor", "", "Market-data vendor for underlying spot prices used by wall_proximity_pct and moneyness alerts: massive or stub (default: disabled)")
+	spotRefresh := fs.Duration("spot-refresh", 30*time.Second, "How long a fetched spot price is reused before it is refreshed (default: 30s)")
+	sheetsAlertsTab := fs.String("sheets-alerts-tab", "", "Google Sheet tab to append sent alerts to, using GOOGLE_SHEETS_* configuration (default: disabled)")
+	internalAddr := fs.String("internal-addr", "", "Bind address for the internal API the server pushes saved configs and devices to, e.g. localhost:8090; requires INTERNAL_API_SECRET (default: disabled)")
+	sessionSummaryDelay := fs.Duration("session-summary-delay", 15*time.Minute, "How long after the regular close to send session summaries to users who opted in, so after-close prints are settled (default: 15m)")
+	usageDir := fs.String("usage-dir", "./usage", "Per-user usage directory notifications received are metered to, shared with the server's --usage-dir (default: ./usage)")
+	diagAddr := fs.String("diag-addr", "", "Bind address for the pprof/expvar diagnostics listener, e.g. localhost:6060 (default: disabled)")
+	timezone := app.TimezoneFlag(fs)
+	metrics := app.MetricsFlag(fs)
+	simClock := app.ClockFlags(fs)
+	fs.Parse(args)
+	if err := config.ApplyFlagEnv(fs); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
+	if err := market.SetAnalysisTimezone(*timezone); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if err := app.EnableSimulatedClock(simClock); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if err := analysis.EnablePeriodMetrics(*metrics); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	app.StartDiagnostics(*diagAddr)
+
+	// Session labels and trading-day checks use the built-in trading days; extend them daily as years roll over
+	market.StartTradingDaysRefresh(24 * time.Hour)
+
+	// Encrypt per-user devices and notifications files at rest (optional)
+	if err := app.EnableUserDataEncryption(); err != nil {
+		log.Fatalf("Failed to enable user data encryption: %v", err)
+	}
+
+	// Shadow-mode evaluation of the rule engine (logs only)
+	var shadow *notifications.ShadowEvaluator
+	if *shadowRules {
+		shadow = notifications.NewShadowEvaluator()
+		expvar.Publish("shadow_rules", expvar.Func(func() interface{} {
+			evaluations, divergences := shadow.Stats()
+			return map[string]int64{"evaluations": evaluations, "divergences": divergences}
+		}))
+		log.Printf("Shadow mode enabled: rule engine divergences will be logged")
+	}
+
+	// End-to-end latency from the logger's ingest to reading, evaluating, and pushing aggregates, on /debug/vars
+	latencies := latency.NewRecorder()
+	latencies.Publish()
+
+	if err := analysis.ValidateTimespan(*timespan); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if *sessionSummaryDelay < 0 {
+		log.Fatal("Error: --session-summary-delay must not be negative")
+	}
+
+	// Meter delivered pushes per user; the server reads them back for /me/usage
+	usage := metering.NewMeter(*usageDir, metering.SourceNotifications, metering.DefaultRetentionDays)
+	usage.Start(time.Minute)
+
+	// Spot prices for wall proximity alerts (optional)
+	var spotSource marketdata.SpotSource
+	if *spotVendor != "" {
+		if err := marketdata.ValidateVendor(*spotVendor); err != nil {
+			log.Fatalf("Invalid --spot-vendor: %v", err)
+		}
+		var apiKey string
+		if *spotVendor != marketdata.VendorStub {
+			cfg, err := config.Load()
+			if err != nil {
+				log.Fatalf("Failed to load configuration: %v", err)
+			}
+			apiKey = cfg.APIKey
+		}
+		source, err := marketdata.NewSpotSource(*spotVendor, apiKey)
+		if err != nil {
+			log.Fatalf("Failed to create spot source: %v", err)
+		}
+		spotSource = marketdata.NewCachedSpot(source, *spotRefresh)
+		log.Printf("Wall proximity and moneyness alerts enabled using %s spot prices (refresh: %s)", *spotVendor, *spotRefresh)
+	}
+
+	// Alert history export to Google Sheets (optional)
+	var alertHistory *sheets.Appender
+	if *sheetsAlertsTab != "" {
+		sheetsConfig, err := config.LoadSheets()
+		if err != nil {
+			log.Fatalf("Failed to load Google Sheets configuration: %v", err)
+		}
+		creds, err := sheets.LoadCredentials(sheetsConfig.CredentialsFile)
+		if err != nil {
+			log.Fatalf("Failed to load Google Sheets credentials: %v", err)
+		}
+		alertHistory = sheets.NewAppender(sheets.NewClient(creds, sheetsConfig.SpreadsheetID), *sheetsAlertsTab, 30*time.Second, func(err error, rows int) {
+			log.Printf("ERROR: Failed to export %d alerts to Google Sheets: %v", rows, err)
+		})
+		log.Printf("Exporting sent alerts to Google Sheets tab %q", *sheetsAlertsTab)
+	}
+
+	// Load APNS configuration
+	apnsConfig, err := config.LoadAPNS()
+	if err != nil {
+		log.Fatalf("Failed to load APNS configuration: %v", err)
+	}
+	log.Printf("APNS configuration loaded (topic: %s, environment: %s)", apnsConfig.Topic, apnsConfig.Environment)
+
+	// Load APNS private key and create client
+	authKey, err := token.AuthKeyFromFile(apnsConfig.KeyPath)
+	if err != nil {
+		log.Fatalf("Failed to load APNS key: %v", err)
+	}
+
+	apnsToken := &token.Token{
+		AuthKey: authKey,
+		KeyID:   apnsConfig.KeyID,
+		TeamID:  apnsConfig.TeamID,
+	}
+
+	// Create APNS client
+	var apnsClient *apns2.Client
+	if apnsConfig.Environment == "production" {
+		apnsClient = apns2.NewTokenClient(apnsToken).Production()
+	} else {
+		apnsClient = apns2.NewTokenClient(apnsToken).Development()
+	}
+
+	// TickerState tracks monitoring state for each ticker
+	// EvaluationKey identifies the summaries a group of notifications is evaluated over
+	type EvaluationKey struct {
+		Minutes int    // Period length
+		Band    string // Moneyness band (analysis.MoneynessBand.String), empty for every strike
+	}
+
+	type TickerState struct {
+		CurrentDate             string                                     // Current date being monitored (YYYY-MM-DD)
+		LastFilePosition        int64                                      // Position at end of last completed period
+		NotifiedPeriods         map[string]map[int64]bool                  // Map: userID -> map[periodEnd]bool (deduplication)
+		MonitoringStartTime     time.Time                                  // When we started monitoring this ticker (simulated time under --sim-start)
+		WatchStartedAt          time.Time                                  // Wall-clock time monitoring started, for latency, which is always measured in real time
+		LastProcessedPeriodEnds map[EvaluationKey]time.Time                // Map: evaluation key -> last period end time we processed
+		BasePeriods             *analysis.IncrementalAggregator            // Base (1-minute) summaries of the day's aggregates
+		BandPeriods             map[string]*analysis.IncrementalAggregator // Map: moneyness band -> base summaries of the band's aggregates
+		Walls                   *analysis.WallTracker                      // Strike premiums for the current date (for wall proximity alerts)
+		RatioStates             *notifications.RatioStates                 // Ratio alert hysteresis for the current date, keyed by user
+		mu                      sync.Mutex
+	}
+
+	// newBaseAggregator creates an aggregator for a ticker's base periods
+	newBaseAggregator := func() *analysis.IncrementalAggregator {
+		return analysis.NewIncrementalAggregator(analysis.AggregateOptions{PeriodMinutes: basePeriodMinutes}, *timespan)
+	}
+
+	// State management
+	tickerStates := make(map[string]*TickerState)
+	statesMu := sync.RWMutex{}
+
+	// Load all notifications and build ticker map
+	loadNotifications := func() (map[string][]notifications.UserNotification, error) {
+		return notifications.LoadAllNotifications(*notificationsDir)
+	}
+
+	// Get or create ticker state
+	getTickerState := func(ticker string) *TickerState {
+		statesMu.Lock()
+		defer statesMu.Unlock()
+
+		state, exists := tickerStates[ticker]
+		if !exists {
+			state = &TickerState{
+				CurrentDate:             "",
+				LastFilePosition:        0,
+				NotifiedPeriods:         make(map[string]map[int64]bool),
+				MonitoringStartTime:     clock.Now(),
+				WatchStartedAt:          time.Now(),
+				LastProcessedPeriodEnds: make(map[EvaluationKey]time.Time),
+				BasePeriods:             newBaseAggregator(),
+				BandPeriods:             make(map[string]*analysis.IncrementalAggregator),
+				Walls:                   analysis.NewWallTracker(),
+				RatioStates:             notifications.NewRatioStates(),
+			}
+			tickerStates[ticker] = state
+		}
+		return state
+	}
+
+	// Initialize: load notifications and set up initial file positions
+	allNotifications, err := loadNotifications()
+	if err != nil {
+		log.Fatalf("Failed to load notifications: %v", err)
+	}
+
+	log.Printf("Loaded notifications for %d tickers", len(allNotifications))
+
+	// Initialize file positions for each ticker with notifications
+	now := clock.Now()
+	periodDuration := time.Duration(*period) * time.Minute
+
+	for ticker := range allNotifications {
+		// Today, or on weekends and holidays the most recent trading session, so baselines start from its periods
+		dateStr := server.ResolveDate(*logDir, ticker)
+		logFile := server.GetLogFileForTickerAndDate(*logDir, ticker, dateStr)
+		state := getTickerState(ticker)
+
+		// Set current date for this ticker
+		state.mu.Lock()
+		state.CurrentDate = dateStr
+		state.mu.Unlock()
+
+		// Check if file exists
+		if fileInfo, err := os.Stat(logFile); err == nil {
+			// Read file to find position at end of last completed period
+			summaries, err := server.AnalyzeTickerAndDate(*logDir, ticker, dateStr, *period)
+			if err == nil && len(summaries) > 0 {
+				// Find the last completed period
+				var lastCompletedPeriod *analysis.TimePeriodSummary
+				for i := len(summaries) - 1; i >= 0; i-- {
+					if now.Sub(summaries[i].PeriodEnd) >= periodDuration {
+						lastCompletedPeriod = &summaries[i]
+						break
+					}
+				}
+
+				if lastCompletedPeriod != nil {
+					// Find file position at end of this period
+					// We'll approximate by reading the file and finding where this period ends
+					// For now, set to file size (we'll refine this when processing)
+					state.mu.Lock()
+					state.LastFilePosition = fileInfo.Size()
+					state.mu.Unlock()
+					log.Printf("Initialized ticker %s: file position at %d (end of last completed period)", ticker, state.LastFilePosition)
+				} else {
+					// No completed periods yet, start from beginning of current period
+					// Read all data to find current period start
+					state.mu.Lock()
+					state.LastFilePosition = 0
+					state.mu.Unlock()
+					log.Printf("Initialized ticker %s: no completed periods yet, starting from beginning", ticker)
+				}
+			} else {
+				state.mu.Lock()
+				state.LastFilePosition = fileInfo.Size()
+				state.mu.Unlock()
+			}
+
+			// Data before the starting position is never re-read, so seed the walls from it now
+			state.mu.Lock()
+			if state.LastFilePosition > 0 {
+				if walls, err := server.ReadWallsForTickerAndDate(*logDir, ticker, dateStr, analysis.AggregateOptions{}, nil); err == nil {
+					state.Walls = walls
+				} else {
+					log.Printf("Error loading walls for ticker %s: %v", ticker, err)
+				}
+			}
+			state.mu.Unlock()
+		}
+	}
+
+	// Create file watcher
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Fatalf("Failed to create file watcher: %v", err)
+	}
+	defer watcher.Close()
+
+	// Watch the log directory
+	if err := watcher.Add(*logDir); err != nil {
+		log.Fatalf("Failed to watch log directory: %v", err)
+	}
+
+	log.Printf("Watching log directory: %s", *logDir)
+
+	// Reload notifications periodically, or immediately when the server pushes a change
+	reloadNow := make(chan struct{}, 1)
+	go func() {
+		reloadTicker := time.NewTicker(30 * time.Second)
+		defer reloadTicker.Stop()
+
+		for {
+			select {
+			case <-reloadTicker.C:
+			case <-reloadNow:
+			}
+
+			newNotifications, err := loadNotifications()
+			if err != nil {
+				log.Printf("Error reloading notifications: %v", err)
+				continue
+			}
+
+			// Update ticker states (add new tickers, remove tickers with no notifications, check date changes)
+			statesMu.Lock()
+			newTickerSet := make(map[string]bool)
+			for ticker := range newNotifications {
+				newTickerSet[ticker] = true
+
+				// Current date in the analysis timezone (the most recent trading session on weekends and holidays)
+				currentDate := server.ResolveDate(*logDir, ticker)
+				state, exists := tickerStates[ticker]
+				if !exists {
+					// New ticker - initialize
+					state = &TickerState{
+						CurrentDate:             currentDate,
+						LastFilePosition:        0,
+						NotifiedPeriods:         make(map[string]map[int64]bool),
+						MonitoringStartTime:     clock.Now(),
+						WatchStartedAt:          time.Now(),
+						LastProcessedPeriodEnds: make(map[EvaluationKey]time.Time),
+						BasePeriods:             newBaseAggregator(),
+						BandPeriods:             make(map[string]*analysis.IncrementalAggregator),
+						Walls:                   analysis.NewWallTracker(),
+						RatioStates:             notifications.NewRatioStates(),
+					}
+					tickerStates[ticker] = state
+					log.Printf("Started monitoring ticker %s (reload)", ticker)
+				} else {
+					// Existing ticker - check if date changed
+					state.mu.Lock()
+					if state.CurrentDate != currentDate {
+						oldDate := state.CurrentDate
+						// Reset state for new date
+						state.CurrentDate = currentDate
+						state.LastFilePosition = 0
+						state.MonitoringStartTime = clock.Now()
+						state.WatchStartedAt = time.Now()
+						state.LastProcessedPeriodEnds = make(map[EvaluationKey]time.Time)
+						state.BasePeriods = newBaseAggregator()
+						state.BandPeriods = make(map[string]*analysis.IncrementalAggregator)
+						state.Walls = analysis.NewWallTracker()
+						state.RatioStates = notifications.NewRatioStates()
+						state.NotifiedPeriods = make(map[string]map[int64]bool)
+						state.mu.Unlock()
+						log.Printf("Date changed for ticker %s: %s -> %s, reset monitoring state", ticker, oldDate, currentDate)
+					} else {
+						state.mu.Unlock()
+					}
+				}
+			}
+
+			// Remove tickers that no longer have notifications
+			for ticker := range tickerStates {
+				if !newTickerSet[ticker] {
+					delete(tickerStates, ticker)
+					log.Printf("Stopped monitoring ticker %s (no notifications)", ticker)
+				}
+			}
+
+			log.Printf("Reloaded notifications: %d tickers being monitored", len(newNotifications))
+			statesMu.Unlock()
+		}
+	}()
+
+	// Internal API for the server to push saved configs and devices (optional)
+	if *internalAddr != "" {
+		secret, err := config.LoadInternalSecret()
+		if err != nil {
+			log.Fatalf("Failed to load internal API configuration: %v", err)
+		}
+		startInternalAPI(*internalAddr, secret, *notificationsDir, *devicesDir, func() {
+			// Coalesce pushes that arrive while a reload is already pending
+			select {
+			case reloadNow <- struct{}{}:
+			default:
+			}
+		})
+	}
+
+	// Debounce file events to avoid processing the same file multiple times in quick succession
+	type pendingFile struct {
+		path      string
+		ticker    string
+		lastEvent time.Time
+	}
+	pendingFiles := make(map[string]*pendingFile)
+	pendingMu := sync.Mutex{}
+
+	// Process file events with debouncing
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				// Only process write events
+				if event.Op&fsnotify.Write == fsnotify.Write {
+					// Extract ticker and date from filename: SYMBOL_YYYY-MM-DD.jsonl
+					filename := filepath.Base(event.Name)
+					if !strings.HasSuffix(filename, ".jsonl") {
+						continue
+					}
+
+					// Parse ticker and date from filename
+					parts := strings.Split(filename, "_")
+					if len(parts) < 2 {
+						continue
+					}
+					ticker := strings.ToUpper(parts[0])
+
+					// Extract date from filename (last part before .jsonl)
+					datePart := strings.TrimSuffix(parts[len(parts)-1], ".jsonl")
+
+					// Validate date format and check if it matches current date for this ticker
+					state := getTickerState(ticker)
+					state.mu.Lock()
+					currentDate := state.CurrentDate
+					state.mu.Unlock()
+
+					if datePart != currentDate {
+						// File is for a different date, skip it
+						continue
+					}
+
+					// Debounce: only process if we haven't seen this file recently
+					pendingMu.Lock()
+					now := time.Now()
+					pending, exists := pendingFiles[event.Name]
+					if !exists {
+						pending = &pendingFile{
+							path:      event.Name,
+							ticker:    ticker,
+							lastEvent: now,
+						}
+						pendingFiles[event.Name] = pending
+					} else {
+						pending.lastEvent = now
+					}
+					pendingMu.Unlock()
+
+					// Process after a short delay to batch multiple rapid writes
+					go func(filePath string, fileTicker string) {
+						time.Sleep(500 * time.Millisecond) // Wait 500ms to batch writes
+
+						pendingMu.Lock()
+						pending, exists := pendingFiles[filePath]
+						if !exists {
+							pendingMu.Unlock()
+							return
+						}
+
+						// Check if this event is still recent (within last 1 second)
+						if time.Since(pending.lastEvent) > 1*time.Second {
+							// Too old, probably already processed
+							delete(pendingFiles, filePath)
+							pendingMu.Unlock()
+							return
+						}
+
+						// Remove from pending so we don't process it again
+						delete(pendingFiles, filePath)
+						pendingMu.Unlock()
+
+						// Check if this ticker has active notifications (reload fresh each time)
+						allNotifications, err := loadNotifications()
+						if err != nil {
+							log.Printf("Error loading notifications in file handler: %v", err)
+							return
+						}
+						userNotifications, hasNotifications := allNotifications[fileTicker]
+						if !hasNotifications || len(userNotifications) == 0 {
+							// No notifications for this ticker, skip
+							return
+						}
+
+						// Get or create state for this ticker
+						state := getTickerState(fileTicker)
+
+						// Process new data
+						state.mu.Lock()
+						aggregates, newPosition, err := server.ReadLogFileIncremental(filePath, state.LastFilePosition)
+						if err != nil {
+							log.Printf("Error reading incremental data for ticker %s: %v", fileTicker, err)
+							state.mu.Unlock()
+							return
+						}
+
+						if len(aggregates) == 0 {
+							// No new complete lines
+							log.Printf("Ticker %s: No new aggregates read (position: %d -> %d)", fileTicker, state.LastFilePosition, newPosition)
+							state.mu.Unlock()
+							return
+						}
+
+						// Update file position
+						state.LastFilePosition = newPosition
+
+						// Only aggregates logged since monitoring started count toward latency, not the catch-up of earlier ones
+						recordLatency := func(stage string, ingestedAt time.Time) {
+							if ingestedAt.After(state.WatchStartedAt) {
+								latencies.Record(stage, ingestedAt)
+							}
+						}
+						for _, agg := range aggregates {
+							recordLatency(latency.StageRead, agg.IngestTime())
+						}
+
+						// Accounts each user shares their rules with, loaded the first time a notification triggers
+						var shares *notifications.ShareRecords
+						shareRecipients := func(owner string) []string {
+							if shares == nil {
+								loaded, err := notifications.LoadShares(*notificationsDir)
+								if err != nil {
+									log.Printf("Error loading shares: %v", err)
+									loaded = &notifications.ShareRecords{}
+								}
+								shares = loaded
+							}
+							return shares.Recipients(owner)
+						}
+
+						// Process new aggregates and update period summaries incrementally
+						// We need to maintain state for in-progress periods and accumulate data
+						now := clock.Now()
+
+						// Moneyness bands the ticker's notifications filter on, which need the underlying's spot price
+						// Live aggregates are tagged against the latest (cached) spot price rather than the price at each trade
+						bands := make(map[string]analysis.MoneynessBand)
+						for _, userNotif := range userNotifications {
+							if band := userNotif.Config.MoneynessBand(); !band.IsZero() {
+								bands[band.String()] = band
+							}
+						}
+						var spot float64
+						if len(bands) > 0 && spotSource != nil {
+							if spot, err = spotSource.LastPrice(context.Background(), fileTicker); err != nil {
+								log.Printf("Error fetching spot price for ticker %s: %v", fileTicker, err)
+							}
+						}
+
+						// Process each new aggregate into its 1-minute base period, and those of the bands it falls in
+						// Each notification's evaluation period is resampled from these, so every period length sees the same data
+						for _, agg := range aggregates {
+							state.BasePeriods.AddAggregate(agg)
+							state.Walls.Add(agg)
+
+							if spot <= 0 {
+								continue
+							}
+							strike, err := analysis.ParseStrike(agg.Symbol)
+							if err != nil {
+								continue
+							}
+							pct := analysis.MoneynessPct(strike, spot)
+							for key, band := range bands {
+								if !band.Contains(pct) {
+									continue
+								}
+								bandPeriods, exists := state.BandPeriods[key]
+								if !exists {
+									bandPeriods = newBaseAggregator()
+									state.BandPeriods[key] = bandPeriods
+								}
+								bandPeriods.AddAggregate(agg)
+							}
+						}
+
+						// Group notifications by the period length and moneyness band they evaluate over
+						periodNotifications := make(map[EvaluationKey][]notifications.UserNotification)
+						longestPeriod := basePeriodMinutes
+						for _, userNotif := range userNotifications {
+							key := EvaluationKey{Minutes: userNotif.Config.EvaluationPeriod(*period)}
+							if band := userNotif.Config.MoneynessBand(); !band.IsZero() {
+								if spotSource == nil {
+									log.Printf("Skipping moneyness notification for user %s on ticker %s: requires --spot-vendor", userNotif.UserID, fileTicker)
+									continue
+								}
+								key.Band = band.String()
+							}
+							periodNotifications[key] = append(periodNotifications[key], userNotif)
+							if key.Minutes > longestPeriod {
+								longestPeriod = key.Minutes
+							}
+						}
+						evaluationKeys := make([]EvaluationKey, 0, len(periodNotifications))
+						for key := range periodNotifications {
+							evaluationKeys = append(evaluationKeys, key)
+						}
+						sort.Slice(evaluationKeys, func(i, j int) bool {
+							if evaluationKeys[i].Minutes != evaluationKeys[j].Minutes {
+								return evaluationKeys[i].Minutes < evaluationKeys[j].Minutes
+							}
+							return evaluationKeys[i].Band < evaluationKeys[j].Band
+						})
+
+						// Base periods to resample into each evaluation period
+						basePeriods := state.BasePeriods.Periods()
+						bandBasePeriods := make(map[string][]analysis.TimePeriodSummary, len(state.BandPeriods))
+						for key, bandPeriods := range state.BandPeriods {
+							bandBasePeriods[key] = bandPeriods.Periods()
+						}
+
+						// Clean up completed periods that are old (keep only recent periods)
+						// Remove periods beyond the anomaly baseline of the longest evaluation period, or the rate-of-change look-back if longer
+						retention := time.Duration(longestPeriod*analysis.DefaultAnomalyWindow) * time.Minute
+						if rateWindow := time.Duration(notifications.MaxRateWindowMinutes) * time.Minute; rateWindow > retention {
+							retention = rateWindow
+						}
+						cutoffTime := now.Add(-retention)
+						state.BasePeriods.Prune(cutoffTime)
+						for key, bandPeriods := range state.BandPeriods {
+							if _, exists := bands[key]; !exists {
+								delete(state.BandPeriods, key)
+								continue
+							}
+							bandPeriods.Prune(cutoffTime)
+						}
+
+						// Process each period summary
+						monitoringStartTime := state.MonitoringStartTime
+
+						processedCount := 0
+						evaluatedCount := 0
+						triggeredCount := 0
+
+						for _, key := range evaluationKeys {
+							// Banded notifications only see the aggregates in their band
+							periods := basePeriods
+							if key.Band != "" {
+								periods = bandBasePeriods[key.Band]
+							}
+
+							// Every period carries the day's walls so far, since proximity is judged against the current spot
+							summaries := analysis.Resample(periods, key.Minutes)
+							for i := range summaries {
+								state.Walls.Apply(&summaries[i])
+							}
+							analysis.ApplyAnomalyScores(summaries, analysis.DefaultAnomalyWindow)
+
+							for _, summary := range summaries {
+								periodEnd := summary.PeriodEnd.UnixMilli()
+								periodEndTime := summary.PeriodEnd
+								// A period only counts as complete once its last aggregate can have arrived
+								// (up to a minute after the period ends when logging minute aggregates)
+								isComplete := analysis.PeriodSettled(periodEndTime, now, *timespan)
+
+								// Process both completed and in-progress periods
+								// For in-progress periods, we check thresholds immediately
+								// For completed periods, we also check thresholds
+
+								// Only skip periods that completed BEFORE we started monitoring
+								// This prevents sending notifications for historical periods on initial load
+								if isComplete && periodEndTime.Before(monitoringStartTime) {
+									continue
+								}
+
+								// For completed periods, check if we've already processed it
+								// For in-progress periods, we process them every time to check for threshold changes
+								if isComplete {
+									if !state.LastProcessedPeriodEnds[key].IsZero() && !periodEndTime.After(state.LastProcessedPeriodEnds[key]) {
+										continue
+									}
+								}
+
+								processedCount++
+								periodStatus := "completed"
+								if !isComplete {
+									periodStatus = "in-progress"
+								}
+
+								// Check notifications for this period (both completed and in-progress)
+								for _, userNotif := range periodNotifications[key] {
+									evaluatedCount++
+
+									// Check deduplication - we only send one notification per period
+									userPeriods, exists := state.NotifiedPeriods[userNotif.UserID]
+									if !exists {
+										userPeriods = make(map[int64]bool)
+										state.NotifiedPeriods[userNotif.UserID] = userPeriods
+									}
+
+									// Use period end timestamp as the notification key for deduplication
+									// This ensures we only send one notification per period, regardless of whether
+									// it's in-progress or completed
+									notificationKey := periodEnd
+									if userPeriods[notificationKey] {
+										// Already notified for this period, skip
+										continue
+									}
+
+									// Evaluate thresholds (ratio hysteresis first, so its state sees every evaluated period)
+									thresholdsMet := notifications.EvaluateRatioHysteresis(state.RatioStates, userNotif.UserID, summary, userNotif.Config)
+									thresholdsMet = notifications.EvaluateThresholds(summary, userNotif.Config) ||
+										notifications.EvaluateRateOfChange(summary, summaries, userNotif.Config) || thresholdsMet
+									if shadow != nil {
+										shadow.Compare(userNotif.UserID, fileTicker, summary, summaries, userNotif.Config, thresholdsMet)
+									}
+
+									// Wall proximity needs the underlying's spot price, which the rule engine does not model
+									if !thresholdsMet && spotSource != nil && userNotif.Config.WallProximityPct > 0 {
+										spot, err := spotSource.LastPrice(context.Background(), fileTicker)
+										if err != nil {
+											log.Printf("Error fetching spot price for ticker %s: %v", fileTicker, err)
+										} else if side, near := notifications.EvaluateWallProximity(summary, spot, userNotif.Config); near {
+											log.Printf("Ticker %s spot %.2f is within %.2f%% of the %s wall", fileTicker, spot, userNotif.Config.WallProximityPct, side)
+											thresholdsMet = true
+										}
+									}
+
+									if thresholdsMet {
+										triggeredCount++
+
+										// Send push notification via APNS
+										err := sendPushNotification(apnsClient, apnsConfig, *devicesDir, userNotif.UserID, fileTicker, periodStatus, summary, "")
+										if err != nil {
+											log.Printf("ERROR: Failed to send push notification to user %s for ticker %s: %v", userNotif.UserID, fileTicker, err)
+										} else {
+											log.Printf("Notification sent: User %s, Ticker %s, %s Period %s", userNotif.UserID, fileTicker, periodStatus, summary.PeriodEnd.Format("15:04:05"))
+											recordLatency(latency.StagePushed, summary.IngestedAt())
+											usage.RecordNotification(userNotif.UserID)
+											if alertHistory != nil {
+												// An infinite call/put ratio (no put premium) is left blank
+												var ratio interface{} = ""
+												if summary.CallPutRatio != nil {
+													ratio = *summary.CallPutRatio
+												}
+												alertHistory.Add([]interface{}{
+													clock.Now().UTC().Format(time.RFC3339), userNotif.UserID, fileTicker, periodStatus,
+													summary.PeriodStart.UTC().Format(time.RFC3339), summary.PeriodEnd.UTC().Format(time.RFC3339),
+													summary.CallPremium, summary.PutPremium, summary.TotalPremium, ratio,
+												})
+											}
+										}
+
+										// Accounts the user shares their rules with receive the same alert on their own devices
+										for _, recipient := range shareRecipients(userNotif.UserID) {
+											if err := sendPushNotification(apnsClient, apnsConfig, *devicesDir, recipient, fileTicker, periodStatus, summary, userNotif.UserID); err != nil {
+												log.Printf("ERROR: Failed to send shared push notification from user %s to user %s for ticker %s: %v", userNotif.UserID, recipient, fileTicker, err)
+												continue
+											}
+											log.Printf("Shared notification sent: User %s (rules of %s), Ticker %s, %s Period %s", recipient, userNotif.UserID, fileTicker, periodStatus, summary.PeriodEnd.Format("15:04:05"))
+											usage.RecordNotification(recipient)
+										}
+
+										// Mark as notified using the appropriate key
+										userPeriods[notificationKey] = true
+									}
+								}
+
+								// Update last processed period end (only for completed periods)
+								if isComplete {
+									if state.LastProcessedPeriodEnds[key].IsZero() || periodEndTime.After(state.LastProcessedPeriodEnds[key]) {
+										state.LastProcessedPeriodEnds[key] = periodEndTime
+									}
+								}
+							}
+						}
+						for _, agg := range aggregates {
+							recordLatency(latency.StageEvaluated, agg.IngestTime())
+						}
+
+						state.mu.Unlock()
+					}(event.Name, ticker)
+				}
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("File watcher error: %v", err)
+			}
+		}
+	}()
+
+	// alertsFired counts the periods a user was alerted for on a ticker's current date
+	alertsFired := func(ticker string, userID string, date string) int {
+		statesMu.RLock()
+		state, exists := tickerStates[ticker]
+		statesMu.RUnlock()
+		if !exists {
+			return 0
+		}
+
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		if state.CurrentDate != date {
+			return 0
+		}
+		return len(state.NotifiedPeriods[userID])
+	}
+
+	// sendSessionSummaries pushes the day's summary to every user who opted in, covering each ticker in their rule list
+	sendSessionSummaries := func(date string) {
+		users, err := notifications.LoadSessionSummaryUsers(*notificationsDir)
+		if err != nil {
+			log.Printf("Error loading session summary users: %v", err)
+			return
+		}
+
+		// Day totals are shared by every user watching a ticker, so each log file is read once
+		dailies := make(map[string]analysis.DailySummary)
+		opts := analysis.AggregateOptions{PeriodMinutes: *period}
+		sent := 0
+		for _, user := range users {
+			var tickers []notifications.SessionTicker
+			for _, ticker := range user.ActiveTickers() {
+				daily, exists := dailies[ticker]
+				if !exists {
+					summaries, err := server.AnalyzeTickerAndDateWithOptions(*logDir, ticker, date, opts)
+					if err != nil {
+						log.Printf("Error analyzing ticker %s for session summary: %v", ticker, err)
+					}
+					daily = analysis.SummarizeDaily(ticker, date, *period, summaries)
+					dailies[ticker] = daily
+				}
+				tickers = append(tickers, notifications.NewSessionTicker(daily, alertsFired(ticker, user.UserID, date)))
+			}
+
+			if err := sendSessionSummary(apnsClient, apnsConfig, *devicesDir, user.UserID, date, tickers); err != nil {
+				log.Printf("ERROR: Failed to send session summary to user %s: %v", user.UserID, err)
+				continue
+			}
+			usage.RecordNotification(user.UserID)
+			sent++
+		}
+		log.Printf("Sent %s session summaries to %d of %d users", date, sent, len(users))
+	}
+
+	// Send session summaries once per trading day, --session-summary-delay after the regular (or early) close
+	// A summary already due when the service starts is skipped, so a restart after the close doesn't send it twice
+	go func() {
+		summaryDue := func(now time.Time) bool {
+			return market.IsTradingDay(now) && !now.Before(market.SessionCloseTime(now).Add(*sessionSummaryDelay))
+		}
+		lastSent := ""
+		if now := clock.Now(); summaryDue(now) {
+			lastSent = market.DateOf(market.SessionCloseTime(now))
+		}
+
+		checkTicker := clock.NewTicker(time.Minute)
+		defer checkTicker.Stop()
+		for now := range checkTicker.C {
+			if !summaryDue(now) {
+				continue
+			}
+			// The session's log files are dated in the analysis timezone, so name the day by the date containing the close
+			date := market.DateOf(market.SessionCloseTime(now))
+			if date == lastSent {
+				continue
+			}
+			lastSent = date
+			sendSessionSummaries(date)
+		}
+	}()
+
+	// Keep service running
+	log.Printf("Notifications service started. Press Ctrl+C to stop.")
+	select {} // Block forever
+}
+
+// sendPushNotification sends a push notification via APNS
+// sharedBy names the account whose rule triggered the alert when it was shared with userID ("" for the user's own rules)
+func sendPushNotification(apnsClient *apns2.Client, apnsConfig *config.APNSConfig, devicesDir string, userID string, ticker string, periodStatus string, summary analysis.TimePeriodSummary, sharedBy string) error {
+	// Name the largest print in the body so recipients can see whether one trade drove the period
+	ratio := "calls only"
+	if summary.CallPutRatio != nil {
+		ratio = fmt.Sprintf("%.2f", *summary.CallPutRatio)
+	}
+	body := fmt.Sprintf("%s period - Call: $%.2f, Put: $%.2f, Ratio: %s", periodStatus, summary.CallPremium, summary.PutPremium, ratio)
+	if summary.LargestTrade != nil && summary.TotalPremium > 0 {
+		body += fmt.Sprintf(", Largest: $%.2f %s (%.0f%%)", summary.LargestTrade.Premium, strings.TrimPrefix(summary.LargestTrade.Symbol, "O:"), summary.LargestTrade.Premium/summary.TotalPremium*100)
+	}
+
+	// Create notification payload with full details
+	payload := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]interface{}{
+				"title": fmt.Sprintf("Options Alert: %s", ticker),
+				"body":  body,
+			},
+			"sound": "default",
+			"badge": 1,
+		},
+		"ticker":                ticker,
+		"period_status":         periodStatus,
+		"period_end":            summary.PeriodEnd.Format(time.RFC3339),
+		"call_premium":          summary.CallPremium,
+		"put_premium":           summary.PutPremium,
+		"total_premium":         summary.TotalPremium,
+		"call_put_ratio":        summary.CallPutRatio,
+		"call_put_volume_ratio": summary.CallPutVolumeRatio,
+		"call_put_log_ratio":    summary.CallPutLogRatio,
+		"call_volume":           summary.CallVolume,
+		"put_volume":            summary.PutVolume,
+		"session":               summary.Session,
+		"size_buckets":          summary.SizeBuckets,
+	}
+	if summary.LargestTrade != nil {
+		payload["largest_trade"] = summary.LargestTrade
+	}
+	if summary.Anomaly != nil {
+		payload["anomaly"] = summary.Anomaly
+	}
+	if summary.CallWall != nil {
+		payload["call_wall"] = summary.CallWall.Strike
+	}
+	if summary.PutWall != nil {
+		payload["put_wall"] = summary.PutWall.Strike
+	}
+	if sharedBy != "" {
+		payload["shared_by"] = sharedBy
+	}
+
+	return pushToDevices(apnsClient, apnsConfig, devicesDir, userID, payload)
+}
+
+// sendSessionSummary sends a user's end-of-session summary via APNS, one line per ticker in their rule list
+func sendSessionSummary(apnsClient *apns2.Client, apnsConfig *config.APNSConfig, devicesDir string, userID string, date string, tickers []notifications.SessionTicker) error {
+	payload := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]interface{}{
+				"title": fmt.Sprintf("Session Summary: %s", date),
+				"body":  notifications.SessionSummaryBody(tickers),
+			},
+			"sound": "default",
+		},
+		"type":    "session_summary",
+		"date":    date,
+		"tickers": tickers,
+	}
+	return pushToDevices(apnsClient, apnsConfig, devicesDir, userID, payload)
+}
+
+// pushToDevices sends a notification payload to all of a user's active devices
+// It fails only when no device accepted the notification
+func pushToDevices(apnsClient *apns2.Client, apnsConfig *config.APNSConfig, devicesDir string, userID string, payload map[string]interface{}) error {
+	// Load user devices
+	devices, err := notifications.LoadUserDevices(userID, devicesDir)
+	if err != nil {
+		return fmt.Errorf("failed to load devices for user %s: %w", userID, err)
+	}
+
+	// Get all active device tokens
+	deviceTokens := notifications.GetActiveDeviceTokens(devices)
+	if len(deviceTokens) == 0 {
+		return fmt.Errorf("no active devices found for user %s", userID)
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification payload: %w", err)
+	}
+
+	// Send notification to all active devices
+	successCount := 0
+
+	for _, deviceToken := range deviceTokens {
+		notification := &apns2.Notification{}
+		notification.DeviceToken = deviceToken
+		notification.Topic = apnsConfig.Topic
+		notification.Payload = payloadJSON
+		notification.Priority = apns2.PriorityHigh
+
+		// Send notification
+		res, err := apnsClient.Push(notification)
+		if err != nil {
+			log.Printf("ERROR: Failed to send push notification to user %s: %v", userID, err)
+			continue
+		}
+
+		if res.Sent() {
+			successCount++
+		} else {
+			log.Printf("ERROR: APNS rejected notification for user %s: StatusCode=%d, Reason=%s", userID, res.StatusCode, res.Reason)
+		}
+	}
+
+	// Return error if no devices were successfully notified
+	if successCount == 0 {
+		return fmt.Errorf("failed to send notification to any device for user %s", userID)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// QuietFlag registers --quiet and its --porcelain alias on fs
+// Both names set the same value, so either may be used in scripts
+func QuietFlag(fs *flag.FlagSet) *bool {
+	quiet := fs.Bool("quiet", false, "Machine-readable mode: suppress progress messages and write only structured output (JSON) to stdout, with diagnostics on stderr")
+	fs.BoolVar(quiet, "porcelain", false, "Alias for --quiet")
+	return quiet
+}
+
+// ParseTickers splits a comma-separated --tickers value into an uppercase set (nil means all tickers)
+func ParseTickers(value string) map[string]bool {
+	if value == "" {
+		return nil
+	}
+	tickers := make(map[string]bool)
+	for _, ticker := range strings.Split(value, ",") {
+		if ticker = strings.ToUpper(strings.TrimSpace(ticker)); ticker != "" {
+			tickers[ticker] = true
+		}
+	}
+	return tickers
+}
+
+// Progress prints human-oriented progress messages ("Reading file...", "Loaded N aggregates")
+// In quiet mode they are dropped so stdout carries only a command's structured output
+type Progress struct {
+	quiet bool
+}
+
+// NewProgress creates a progress printer for the given --quiet setting
+// In quiet mode it also moves LOG_FORMAT=json log lines from stdout to stderr, keeping stdout parseable
+func NewProgress(quiet bool) *Progress {
+	if quiet {
+		if w, ok := log.Writer().(*jsonLogWriter); ok {
+			w.out = os.Stderr
+		}
+	}
+	return &Progress{quiet: quiet}
+}
+
+// Quiet reports whether progress messages are suppressed
+func (p *Progress) Quiet() bool {
+	return p.quiet
+}
+
+// Printf prints a progress message unless quiet
+func (p *Progress) Printf(format string, args ...interface{}) {
+	if !p.quiet {
+		fmt.Printf(format, args...)
+	}
+}
+
+// Println prints a progress line unless quiet
+func (p *Progress) Println(args ...interface{}) {
+	if !p.quiet {
+		fmt.Println(args...)
+	}
+}
+
+// PrintJSON writes a value to stdout as indented JSON, the structured output of quiet mode
+func PrintJSON(value interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
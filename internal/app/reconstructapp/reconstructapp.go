@@ -0,0 +1,149 @@
+// Package reconstructapp implements the reconstruct command (historical feed reconstruction)
+// It is shared by cmd/reconstruct and the unified jax-ov binary
+package reconstructapp
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/app"
+	"github.com/ekinolik/jax-ov/internal/config"
+	"github.com/ekinolik/jax-ov/internal/marketdata"
+)
+
+// Result is the summary printed on stdout in --quiet mode
+type Result struct {
+	Ticker     string `json:"ticker"`
+	Date       string `json:"date"`
+	Output     string `json:"output"`
+	Contracts  int    `json:"contracts"`
+	Aggregates int    `json:"aggregates"`
+	Errors     int    `json:"errors"` // Contracts whose aggregates could not be fetched
+}
+
+// Run runs the reconstruct command with the given command-line arguments (excluding the program name)
+func Run(args []string) {
+	// Parse command-line flags
+	fs := flag.NewFlagSet("reconstruct", flag.ExitOnError)
+	ticker := fs.String("ticker", "", "Underlying stock ticker (required, e.g., AAPL)")
+	dateStr := fs.String("date", "", "Date in YYYY-MM-DD format (required, e.g., 2025-11-30)")
+	output := fs.String("output", "", "Output JSON file path (default: {ticker}_options_{date}.json)")
+	workers := fs.Int("workers", 10, "Number of concurrent workers for fetching aggregates")
+	vendor := fs.String("vendor", marketdata.VendorMassive, "Market-data vendor: massive or stub (default: massive)")
+	timespan := fs.String("timespan", analysis.TimespanSecond, "Aggregate timespan: second or minute (default: second)")
+	quiet := app.QuietFlag(fs)
+	fs.Parse(args)
+	if err := config.ApplyFlagEnv(fs); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
+	progress := app.NewProgress(*quiet)
+
+	// Validate flags
+	if *ticker == "" {
+		log.Fatal("Error: --ticker is required")
+	}
+
+	if *dateStr == "" {
+		log.Fatal("Error: --date is required")
+	}
+
+	// Parse date
+	date, err := time.Parse("2006-01-02", *dateStr)
+	if err != nil {
+		log.Fatalf("Error: invalid date format. Use YYYY-MM-DD format: %v", err)
+	}
+
+	// Set default output filename if not provided
+	if *output == "" {
+		*output = fmt.Sprintf("%s_options_%s.json", *ticker, *dateStr)
+	}
+
+	if err := marketdata.ValidateVendor(*vendor); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	if err := analysis.ValidateTimespan(*timespan); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	// Load configuration (the stub vendor needs no API key)
+	var apiKey string
+	if *vendor != marketdata.VendorStub {
+		cfg, err := config.Load()
+		if err != nil {
+			log.Fatalf("Failed to load configuration: %v", err)
+		}
+		apiKey = cfg.APIKey
+	}
+
+	// Create historical data source for the configured vendor
+	history, err := marketdata.NewHistorySource(*vendor, apiKey, *timespan)
+	if err != nil {
+		log.Fatalf("Failed to create history source: %v", err)
+	}
+	ctx := context.Background()
+
+	progress.Printf("Fetching option contracts for %s...\n", *ticker)
+
+	// Fetch all option contracts
+	contracts, err := history.ListOptionContracts(ctx, *ticker)
+	if err != nil {
+		log.Fatalf("Failed to list option contracts: %v", err)
+	}
+
+	progress.Printf("Found %d option contracts\n", len(contracts))
+	progress.Printf("Fetching %s aggregates for %s on %s...\n", *timespan, *ticker, *dateStr)
+	progress.Printf("Using %d concurrent workers\n", *workers)
+
+	// Fetch aggregates for every contract concurrently (sorted by start timestamp)
+	allAggregates, errorCount, err := marketdata.FetchContracts(ctx, history, contracts, date, *workers, func(idx int, total int) {
+		if idx%100 == 0 && idx > 0 {
+			progress.Printf("Processing contract %d/%d...\n", idx, total)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Failed to fetch aggregates: %v", err)
+	}
+
+	progress.Printf("\nCollected %d aggregates from %d contracts", len(allAggregates), len(contracts))
+	if errorCount > 0 {
+		progress.Printf(" (%d errors)", errorCount)
+	}
+	progress.Println()
+
+	// Write to JSON file
+	progress.Printf("Writing to %s...\n", *output)
+	file, err := os.Create(*output)
+	if err != nil {
+		log.Fatalf("Failed to create output file: %v", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(allAggregates); err != nil {
+		log.Fatalf("Failed to write JSON: %v", err)
+	}
+
+	progress.Printf("Successfully wrote %d aggregates to %s\n", len(allAggregates), *output)
+
+	if progress.Quiet() {
+		result := Result{
+			Ticker:     *ticker,
+			Date:       *dateStr,
+			Output:     *output,
+			Contracts:  len(contracts),
+			Aggregates: len(allAggregates),
+			Errors:     errorCount,
+		}
+		if err := app.PrintJSON(result); err != nil {
+			log.Fatalf("Failed to encode JSON: %v", err)
+		}
+	}
+}
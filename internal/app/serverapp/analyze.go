@@ -0,0 +1,569 @@
+package serverapp
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/auth"
+	"github.com/ekinolik/jax-ov/internal/market"
+	"github.com/ekinolik/jax-ov/internal/server"
+	"github.com/gorilla/websocket"
+)
+
+// newAnalyzeHandler handles /analyze WebSocket connections, streaming a ticker's history and live updates
+func newAnalyzeHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Validate JWT before upgrading to WebSocket
+		// In demo mode, requests without one are admitted anonymously, subject to the demo's per-address limits
+		var claims *auth.SessionClaims
+		release := func() {}
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" && d.demo != nil {
+			addr := d.demo.ClientAddress(r)
+			var err error
+			release, err = d.demo.Acquire(addr)
+			if err != nil {
+				w.Header().Set("Retry-After", "60")
+				http.Error(w, err.Error(), http.StatusTooManyRequests)
+				return
+			}
+			claims = d.demo.Claims(addr)
+		} else {
+			if authHeader == "" {
+				http.Error(w, "Authorization header required", http.StatusUnauthorized)
+				return
+			}
+
+			parts := strings.SplitN(authHeader, " ", 2)
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+				return
+			}
+
+			var err error
+			claims, err = auth.ParseSessionToken(parts[1], d.authConfig.JWTSecret)
+			if err != nil {
+				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+		}
+		isDemo := server.IsDemoSubject(claims.Subject)
+
+		// Demo slots are released when the connection closes, or here if the request ends before it streams
+		streaming := false
+		defer func() {
+			if !streaming {
+				release()
+			}
+		}()
+
+		conn, err := d.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Printf("WebSocket upgrade error: %v", err)
+			return
+		}
+
+		compressed := d.cfg.wsCompression && server.CompressionRequested(r)
+		if compressed {
+			conn.SetCompressionLevel(d.cfg.wsCompressionLevel)
+		}
+
+		// Reject clients that only speak subprotocols we don't support
+		protocol, ok := server.NegotiatedProtocol(conn, r)
+		if !ok {
+			log.Printf("Rejecting client: unsupported subprotocols %v", websocket.Subprotocols(r))
+			server.CloseWithError(conn, server.CloseUnsupportedProtocol, server.ErrorUnsupportedProtocol, "unsupported subprotocol, supported: "+strings.Join(server.SupportedSubprotocols, ","))
+			return
+		}
+
+		// Query parameters are validated after the upgrade so clients receive a structured error frame
+
+		// Get ticker from query parameter (required): a ticker, a configured group, or a comma-separated list
+		group, err := d.groups.Resolve(r.URL.Query().Get("ticker"))
+		if err != nil {
+			log.Printf("Rejecting client: %v", err)
+			server.CloseWithError(conn, server.CloseInvalidRequest, server.ErrorInvalidTicker, err.Error())
+			return
+		}
+		ticker := group.Name
+		if isDemo && !d.demo.Allows(ticker) {
+			server.CloseWithError(conn, server.CloseAuthRequired, server.ErrorAuthRequired, fmt.Sprintf("sign in to stream %s (the demo covers %s)", ticker, strings.Join(d.demo.Tickers(), ", ")))
+			return
+		}
+
+		// Get period anchor from query parameter (optional): "midnight" or "open", default --anchor
+		anchor := anchorParam(r, d.cfg.defaultAnchor)
+		if err := analysis.ValidateAnchor(anchor); err != nil {
+			server.CloseWithError(conn, server.CloseInvalidRequest, server.ErrorInvalidParameter, err.Error())
+			return
+		}
+
+		// Get session filter from query parameter (optional), e.g. "regular" or "premarket,regular"
+		sessions, err := market.ParseSessionSet(r.URL.Query().Get("session"))
+		if err != nil {
+			server.CloseWithError(conn, server.CloseInvalidRequest, server.ErrorInvalidParameter, err.Error())
+			return
+		}
+		// Get days-to-expiration limit from query parameter (optional), e.g. 0 for same-day expirations only
+		maxDTE, err := analysis.ParseDTELimit(r.URL.Query().Get("max_dte"))
+		if err != nil {
+			server.CloseWithError(conn, server.CloseInvalidRequest, server.ErrorInvalidParameter, err.Error())
+			return
+		}
+		// Get period length from query parameter (optional): one of the cached resolutions, default --period
+		periodMinutes := d.cfg.period
+		if periodStr := r.URL.Query().Get("period"); periodStr != "" {
+			periodMinutes, err = strconv.Atoi(periodStr)
+			if err != nil || !d.historyCache.Supports(periodMinutes) {
+				server.CloseWithError(conn, server.CloseInvalidRequest, server.ErrorInvalidParameter, fmt.Sprintf("invalid period %q (must be one of %v)", periodStr, d.historyCache.Periods()))
+				return
+			}
+		}
+		// Get strike distance limit from query parameter (optional): only strikes within this percent of spot
+		maxMoneyness, err := analysis.ParseMaxMoneyness(r.URL.Query().Get("max_moneyness"))
+		if err != nil {
+			server.CloseWithError(conn, server.CloseInvalidRequest, server.ErrorInvalidParameter, err.Error())
+			return
+		}
+		if maxMoneyness > 0 && d.spot == nil {
+			server.CloseWithError(conn, server.CloseInvalidRequest, server.ErrorInvalidParameter, "max_moneyness is not enabled on this server (requires --spot-vendor)")
+			return
+		}
+		if maxMoneyness > 0 && group.Combined() {
+			server.CloseWithError(conn, server.CloseInvalidRequest, server.ErrorInvalidParameter, "max_moneyness is not supported for combined tickers")
+			return
+		}
+		opts := analysis.AggregateOptions{PeriodMinutes: periodMinutes, Anchor: anchor, Sessions: sessions, MaxDTE: maxDTE, MaxMoneyness: maxMoneyness}
+
+		// Get premium floor for live updates (optional): skip in-progress updates that moved total premium less than this
+		minPremiumChange, err := server.ParseMinPremiumChange(r.URL.Query().Get("min_premium_change"))
+		if err != nil {
+			server.CloseWithError(conn, server.CloseInvalidRequest, server.ErrorInvalidParameter, err.Error())
+			return
+		}
+
+		// Get date from query parameter, default to the current date in the analysis timezone
+		// (or the most recent trading session on weekends and holidays)
+		today := market.Today()
+		requestedDate := r.URL.Query().Get("date")
+		dateStr := requestedDate
+		if dateStr == "" {
+			dateStr = server.ResolveGroupDate(d.cfg.logDir, group)
+		}
+
+		// Validate date format (YYYY-MM-DD)
+		if _, err := time.Parse("2006-01-02", dateStr); err != nil {
+			log.Printf("Rejecting client for ticker %s: invalid date %s", ticker, dateStr)
+			server.CloseWithError(conn, server.CloseInvalidRequest, server.ErrorInvalidDate, "invalid date format, use YYYY-MM-DD")
+			return
+		}
+
+		// Get point-in-time cutoff from query parameter (optional): history as it stood at HH:MM, with no live updates
+		var asOf time.Time
+		if asOfStr := r.URL.Query().Get("as_of"); asOfStr != "" {
+			asOf, err = server.ParseTimeOfDay(dateStr, asOfStr)
+			if err != nil {
+				server.CloseWithError(conn, server.CloseInvalidRequest, server.ErrorInvalidParameter, fmt.Sprintf("invalid as_of %q: %v", asOfStr, err))
+				return
+			}
+		}
+
+		// Get zero fill from query parameter (optional): include empty periods in the history so it sits on a fixed time axis
+		var zeroFill bool
+		if zeroFillStr := r.URL.Query().Get("zero_fill"); zeroFillStr != "" {
+			if zeroFill, err = strconv.ParseBool(zeroFillStr); err != nil {
+				server.CloseWithError(conn, server.CloseInvalidRequest, server.ErrorInvalidParameter, fmt.Sprintf("invalid zero_fill %q", zeroFillStr))
+				return
+			}
+		}
+
+		// Get connection mode (optional): "live" (default) or "replay" with an optional speed
+		mode := r.URL.Query().Get("mode")
+		if mode == "" {
+			mode = server.ModeLive
+		}
+		if mode != server.ModeLive && mode != server.ModeReplay {
+			server.CloseWithError(conn, server.CloseInvalidRequest, server.ErrorInvalidParameter, fmt.Sprintf("invalid mode %q (must be %s or %s)", mode, server.ModeLive, server.ModeReplay))
+			return
+		}
+		// Get resume point from query parameter (optional): a reconnecting client only needs the periods after it
+		lastPeriodEnd, err := server.ParseLastPeriodEnd(r.URL.Query().Get("last_period_end"))
+		if err != nil {
+			server.CloseWithError(conn, server.CloseInvalidRequest, server.ErrorInvalidParameter, err.Error())
+			return
+		}
+		if !lastPeriodEnd.IsZero() && mode == server.ModeReplay {
+			server.CloseWithError(conn, server.CloseInvalidRequest, server.ErrorInvalidParameter, "last_period_end is not supported in replay mode")
+			return
+		}
+		speed := server.DefaultReplaySpeed
+		if speedStr := r.URL.Query().Get("speed"); speedStr != "" {
+			speed, err = strconv.ParseFloat(speedStr, 64)
+			if err == nil {
+				err = server.ValidateReplaySpeed(speed)
+			}
+			if err != nil {
+				server.CloseWithError(conn, server.CloseInvalidRequest, server.ErrorInvalidParameter, fmt.Sprintf("invalid speed %q", speedStr))
+				return
+			}
+		}
+
+		// Tell the client which date the stream covers before anything else
+		if err := server.SendDate(conn, requestedDate, dateStr); err != nil {
+			conn.Close()
+			return
+		}
+
+		// Load historical data for the specified ticker and date, cut off at as_of when requested
+		loadHistory := func() ([]analysis.TimePeriodSummary, error) {
+			if group.Combined() {
+				return server.AnalyzeGroupAndDate(d.cfg.logDir, group, dateStr, opts, asOf)
+			}
+			if asOf.IsZero() {
+				return d.historyCache.Summaries(ticker, dateStr, opts)
+			}
+			return server.AnalyzeTickerAndDateAsOf(d.cfg.logDir, ticker, dateStr, opts, asOf)
+		}
+		summaries, err := loadHistory()
+		if err != nil {
+			log.Printf("Error getting historical data for ticker %s, date %s: %v", ticker, dateStr, err)
+		}
+
+		// Past dates with no local data can be reconstructed upstream when backfill is enabled
+		// The client is told the backfill is pending and receives the history once it completes
+		if len(summaries) == 0 && dateStr != today && d.backfiller != nil && !isDemo && !group.Combined() {
+			job, err := d.backfiller.Start(ticker, dateStr, today)
+			if err != nil {
+				log.Printf("Not backfilling %s on %s: %v", ticker, dateStr, err)
+			} else {
+				state := server.BackfillMessage{State: server.BackfillPending, Ticker: ticker, Date: dateStr}
+				if err := server.SendBackfillState(conn, state); err != nil {
+					conn.Close()
+					return
+				}
+				if err := server.AwaitBackfill(conn, job, 54*time.Second); err != nil {
+					conn.Close()
+					return
+				}
+
+				state.State = server.BackfillComplete
+				if err := job.Err(); err != nil {
+					state.State = server.BackfillFailed
+					state.Message = err.Error()
+				} else if summaries, err = loadHistory(); err != nil {
+					log.Printf("Error getting backfilled data for ticker %s, date %s: %v", ticker, dateStr, err)
+				}
+				if err := server.SendBackfillState(conn, state); err != nil {
+					conn.Close()
+					return
+				}
+			}
+		}
+
+		if zeroFill {
+			summaries = analysis.ZeroFill(summaries, opts, server.ZeroFillUntil(dateStr, asOf))
+		}
+
+		// Measure each period's volume against the ticker's average daily volume (combined tickers have no baselines)
+		if !group.Combined() {
+			summaries, err = d.baselines.Apply(ticker, dateStr, summaries)
+			if err != nil {
+				log.Printf("Error getting average daily volume for ticker %s, date %s: %v", ticker, dateStr, err)
+			}
+			summaries = d.applyNormals(ticker, dateStr, opts, summaries)
+		}
+		analysis.ApplyPeriodChanges(summaries)
+
+		// Past dates will never receive live updates and replays and point-in-time views only cover stored data,
+		// so an empty history means there is nothing to stream
+		if len(summaries) == 0 && (dateStr != today || mode == server.ModeReplay || !asOf.IsZero()) {
+			log.Printf("Rejecting client for ticker %s: no data for date %s", ticker, dateStr)
+			server.CloseWithError(conn, server.CloseNoData, server.ErrorNoData, fmt.Sprintf("no data for %s on %s", ticker, dateStr))
+			return
+		}
+
+		// Register connection with ticker, applying the duplicate-connection policy
+		clientInfo := server.ClientInfo{
+			Subject:    claims.Subject,
+			Ticker:     ticker,
+			Date:       dateStr,
+			Protocol:   protocol,
+			Compressed: compressed,
+			Options:    opts,
+			Replay:     mode == server.ModeReplay,
+			AsOf:       asOf,
+
+			MinPremiumChange: minPremiumChange,
+		}
+		if err := d.wsServer.Register(conn, clientInfo); err != nil {
+			log.Printf("Rejecting client for ticker %s: %v", ticker, err)
+			server.CloseWithError(conn, server.CloseDuplicateConnection, server.ErrorDuplicateConnection, err.Error())
+			return
+		}
+		// Other goroutines replacing or shutting down the connection hand their close to the writer below
+		closeRequests := d.wsServer.CloseRequests(conn)
+
+		// Live clients get the full history immediately; replay clients get it period-by-period from the writer below
+		var replay *server.Replay
+		if mode == server.ModeReplay {
+			replay = server.NewReplay(summaries, speed)
+			if err := server.SendReplayState(conn, replay.State()); err != nil {
+				log.Printf("Error sending replay state: %v", err)
+			}
+			log.Printf("Started replay of %d periods for ticker %s, date %s at %gx", len(summaries), ticker, dateStr, speed)
+		} else {
+			// The history burst is most of a connection's traffic, so log its size before and after compression
+			history := server.ResumeAfter(summaries, lastPeriodEnd)
+			payloadBefore, wireBefore := d.wsServer.BytesSent(conn)
+			if err := d.wsServer.SendHistory(conn, history); err != nil {
+				log.Printf("Error sending history: %v", err)
+			} else {
+				payload, wire := d.wsServer.BytesSent(conn)
+				size := fmt.Sprintf("%d bytes, %d on the wire", payload-payloadBefore, wire-wireBefore)
+				if compressed {
+					size += ", compressed"
+				}
+				if len(history) < len(summaries) {
+					log.Printf("Resumed client for ticker %s, date %s after %s: sent %d of %d historical periods (%s)", ticker, dateStr, lastPeriodEnd.Format(time.RFC3339), len(history), len(summaries), size)
+				} else {
+					log.Printf("Sent %d historical periods to new client for ticker %s, date %s (%s)", len(summaries), ticker, dateStr, size)
+				}
+			}
+		}
+
+		// Read client control messages (token refresh, replay controls) until the connection closes
+		messages := make(chan server.ClientMessage)
+		readerDone := make(chan struct{})
+		writerDone := make(chan struct{})
+		go func() {
+			defer close(readerDone)
+			for {
+				frame, data, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+
+				msg, err := server.DecodeClientMessage(conn, frame, data)
+				if err != nil {
+					log.Printf("Ignoring malformed client message for ticker %s: %v", ticker, err)
+					continue
+				}
+				select {
+				case messages <- msg:
+				case <-writerDone:
+					return
+				}
+			}
+		}()
+
+		// Handle connection (ping/pong, token expiry, cleanup on disconnect)
+		streaming = true
+		go func() {
+			// Stream time is metered on every ping and at disconnect, so long-lived connections count as they go
+			meteredAt := time.Now()
+			meterStream := func() {
+				now := time.Now()
+				if !isDemo {
+					d.usage.RecordStream(claims.Subject, now.Sub(meteredAt))
+				}
+				meteredAt = now
+			}
+
+			defer func() {
+				meterStream()
+				close(writerDone)
+				d.wsServer.Unregister(conn)
+				conn.Close()
+				release()
+			}()
+
+			pingTicker := time.NewTicker(54 * time.Second)
+			defer pingTicker.Stop()
+
+			// Live updates are queued by the file watcher and written here, so this goroutine is the connection's only writer
+			updates := d.wsServer.Updates(conn)
+
+			// Warn the client before its session token expires, then close at expiry
+			var warnTimer, expiryTimer *time.Timer
+			var warnC, expiryC <-chan time.Time
+			var expiresAt time.Time
+			scheduleExpiry := func(c *auth.SessionClaims) {
+				if warnTimer != nil {
+					warnTimer.Stop()
+					expiryTimer.Stop()
+				}
+				if c.ExpiresAt == nil {
+					warnC, expiryC = nil, nil
+					return
+				}
+				expiresAt = c.ExpiresAt.Time
+				warnTimer = time.NewTimer(time.Until(expiresAt.Add(-d.cfg.tokenExpiryWarning)))
+				expiryTimer = time.NewTimer(time.Until(expiresAt))
+				warnC, expiryC = warnTimer.C, expiryTimer.C
+			}
+			scheduleExpiry(claims)
+
+			// Pace replay periods with a timer that only runs while the replay is playing
+			var replayTimer *time.Timer
+			var replayC <-chan time.Time
+			scheduleReplay := func() {
+				if replayTimer != nil {
+					replayTimer.Stop()
+				}
+				replayC = nil
+				if replay != nil && replay.Active() {
+					replayTimer = time.NewTimer(replay.Delay())
+					replayC = replayTimer.C
+				}
+			}
+			scheduleReplay()
+
+			for {
+				select {
+				case <-readerDone:
+					return
+				case req := <-closeRequests:
+					req.Send(conn)
+					return
+				case <-pingTicker.C:
+					meterStream()
+					if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+						return
+					}
+				case summary := <-updates:
+					if err := d.wsServer.WriteSummary(conn, server.MessageTypeUpdate, summary); err != nil {
+						log.Printf("Error writing to client: %v", err)
+						return
+					}
+				case <-warnC:
+					if err := server.SendTokenExpiring(conn, expiresAt); err != nil {
+						return
+					}
+				case <-expiryC:
+					log.Printf("Session token expired for client on ticker %s, closing connection", ticker)
+					server.CloseWithError(conn, server.CloseAuthExpired, server.ErrorAuthExpired, "session token expired")
+					return
+				case <-replayC:
+					if summary, ok := replay.Next(); ok {
+						if err := d.wsServer.WriteSummary(conn, server.MessageTypeReplay, summary); err != nil {
+							return
+						}
+					}
+					if !replay.Active() {
+						if err := server.SendReplayState(conn, replay.State()); err != nil {
+							return
+						}
+					}
+					scheduleReplay()
+				case msg := <-messages:
+					switch msg.Type {
+					case server.MessageTypeAuth:
+						refreshed, err := auth.ParseSessionToken(msg.Token, d.authConfig.JWTSecret)
+						if err == nil && refreshed.Subject != claims.Subject {
+							err = fmt.Errorf("token subject does not match connection")
+						}
+						if err != nil {
+							log.Printf("Rejected token refresh for ticker %s: %v", ticker, err)
+							if err := server.SendError(conn, server.ErrorInvalidToken, "token refresh rejected"); err != nil {
+								return
+							}
+							continue
+						}
+						scheduleExpiry(refreshed)
+					case server.MessageTypeSubscribe, server.MessageTypeUnsubscribe, server.MessageTypeChangePeriod:
+						if err := d.wsServer.HandleSubscription(conn, msg); err != nil {
+							return
+						}
+					case server.MessageTypeResume:
+						if err := d.wsServer.HandleResume(conn, msg); err != nil {
+							return
+						}
+					case server.MessageTypeHeartbeat:
+						// Resend the latest state of periods a lagging client is missing
+						for _, summary := range d.wsServer.Heartbeat(conn, msg.Seq) {
+							if err := d.wsServer.WriteSummary(conn, server.MessageTypeUpdate, summary); err != nil {
+								return
+							}
+						}
+					case server.MessageTypePlay, server.MessageTypePause, server.MessageTypeSeek:
+						if replay == nil {
+							if err := server.SendError(conn, server.ErrorInvalidParameter, msg.Type+" is only supported in replay mode"); err != nil {
+								return
+							}
+							continue
+						}
+
+						var rebuild []analysis.TimePeriodSummary
+						switch msg.Type {
+						case server.MessageTypePlay:
+							if err := replay.Play(msg.Speed); err != nil {
+								if err := server.SendError(conn, server.ErrorInvalidParameter, err.Error()); err != nil {
+									return
+								}
+								continue
+							}
+						case server.MessageTypePause:
+							replay.Pause()
+						case server.MessageTypeSeek:
+							rebuild = replay.Seek(msg.Time)
+						}
+
+						// After a seek the client clears its chart and rebuilds it from the periods that follow
+						state := replay.State()
+						state.Reset = msg.Type == server.MessageTypeSeek
+						if err := server.SendReplayState(conn, state); err != nil {
+							return
+						}
+						if err := d.wsServer.SendHistory(conn, rebuild); err != nil {
+							return
+						}
+						scheduleReplay()
+					}
+				}
+			}
+		}()
+	}
+}
+
+// newSubscriptionLoader loads the history for tickers added to a live /analyze connection with subscribe (or moved to
+// another period with change_period): the same history a new connection would get, without backfill or zero fill
+func newSubscriptionLoader(d *handlerDeps) server.SubscriptionLoader {
+	return func(info server.ClientInfo, value string, opts analysis.AggregateOptions) (server.Subscription, error) {
+		group, err := d.groups.Resolve(value)
+		if err != nil {
+			return server.Subscription{}, &server.ProtocolError{Code: server.ErrorInvalidTicker, Message: err.Error()}
+		}
+		if d.demo != nil && server.IsDemoSubject(info.Subject) && !d.demo.Allows(group.Name) {
+			return server.Subscription{}, &server.ProtocolError{Code: server.ErrorAuthRequired, Message: fmt.Sprintf("sign in to stream %s (the demo covers %s)", group.Name, strings.Join(d.demo.Tickers(), ", "))}
+		}
+		if !d.historyCache.Supports(opts.PeriodMinutes) {
+			return server.Subscription{}, fmt.Errorf("invalid period %d (must be one of %v)", opts.PeriodMinutes, d.historyCache.Periods())
+		}
+		if opts.MaxMoneyness > 0 && group.Combined() {
+			return server.Subscription{}, fmt.Errorf("max_moneyness is not supported for combined tickers")
+		}
+
+		var summaries []analysis.TimePeriodSummary
+		if group.Combined() {
+			summaries, err = server.AnalyzeGroupAndDate(d.cfg.logDir, group, info.Date, opts, time.Time{})
+		} else {
+			summaries, err = d.historyCache.Summaries(group.Name, info.Date, opts)
+		}
+		if err != nil {
+			log.Printf("Error getting historical data for ticker %s, date %s: %v", group.Name, info.Date, err)
+		}
+		if !group.Combined() {
+			if summaries, err = d.baselines.Apply(group.Name, info.Date, summaries); err != nil {
+				log.Printf("Error getting average daily volume for ticker %s, date %s: %v", group.Name, info.Date, err)
+			}
+			summaries = d.applyNormals(group.Name, info.Date, opts, summaries)
+		}
+		analysis.ApplyPeriodChanges(summaries)
+		return server.Subscription{Ticker: group.Name, History: summaries}, nil
+	}
+}
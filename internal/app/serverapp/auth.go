@@ -0,0 +1,145 @@
+package serverapp
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ekinolik/jax-ov/internal/auth"
+	"github.com/ekinolik/jax-ov/internal/notifications"
+)
+
+// newRegisterHandler handles POST /auth/register, which saves a device token for the user's notifications
+func newRegisterHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Extract user sub from JWT token
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Authorization header required", http.StatusUnauthorized)
+			return
+		}
+
+		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+			return
+		}
+
+		sub, _, err := auth.ValidateSessionToken(parts[1], d.authConfig.JWTSecret)
+		if err != nil {
+			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+
+		// Parse request body
+		var registerRequest struct {
+			DeviceToken string `json:"device_token"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&registerRequest); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if registerRequest.DeviceToken == "" {
+			http.Error(w, "device_token is required", http.StatusBadRequest)
+			return
+		}
+
+		// Load existing devices for user
+		devices, err := notifications.LoadUserDevices(sub, d.cfg.devicesDir)
+		if err != nil {
+			log.Printf("Error loading devices for user %s: %v", sub, err)
+			http.Error(w, "Error loading devices", http.StatusInternalServerError)
+			return
+		}
+
+		// Add or update device token
+		notifications.AddOrUpdateDevice(devices, registerRequest.DeviceToken)
+
+		// Save devices back to file
+		if err := notifications.SaveUserDevices(sub, d.cfg.devicesDir, devices); err != nil {
+			log.Printf("Error saving devices for user %s: %v", sub, err)
+			http.Error(w, "Error saving device", http.StatusInternalServerError)
+			return
+		}
+
+		// The device is saved either way; a failed push is picked up by the next periodic reload
+		if d.notificationsSync != nil {
+			go func() {
+				if err := d.notificationsSync.PushDevices(context.Background(), devices); err != nil {
+					log.Printf("Error pushing devices for user %s to notifications service: %v", sub, err)
+				}
+			}()
+		}
+
+		// Return success response
+		w.Header().Set("Content-Type", "application/json")
+		response := map[string]interface{}{
+			"success": true,
+			"message": "Device registered",
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// newLoginHandler handles POST /auth/login, which exchanges an Apple identity token for a session token
+func newLoginHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Parse request body
+		var loginRequest struct {
+			IdentityToken     string `json:"identity_token"`
+			AuthorizationCode string `json:"authorization_code"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&loginRequest); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if loginRequest.IdentityToken == "" {
+			http.Error(w, "identity_token is required", http.StatusBadRequest)
+			return
+		}
+
+		// Validate Apple identity token
+		sub, err := auth.ValidateAppleIdentityToken(loginRequest.IdentityToken, d.authConfig.AppleClientID)
+		if err != nil {
+			log.Printf("Apple identity token validation failed: %v", err)
+			http.Error(w, "Invalid identity token", http.StatusUnauthorized)
+			return
+		}
+
+		// Create session JWT
+		sessionToken, err := auth.CreateSessionTokenWithScopes(sub, d.authConfig.JWTSecret, d.authConfig.JWTExpiryDuration(), d.authConfig.ScopesFor(sub))
+		if err != nil {
+			log.Printf("Failed to create session token: %v", err)
+			http.Error(w, "Failed to create session", http.StatusInternalServerError)
+			return
+		}
+
+		// Return session token
+		w.Header().Set("Content-Type", "application/json")
+		response := map[string]interface{}{
+			"token":      sessionToken,
+			"expires_in": int(d.authConfig.JWTExpiryDuration().Seconds()),
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("Failed to encode response: %v", err)
+		}
+	}
+}
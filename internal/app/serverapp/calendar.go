@@ -0,0 +1,159 @@
+package serverapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/auth"
+	"github.com/ekinolik/jax-ov/internal/calendar"
+	"github.com/ekinolik/jax-ov/internal/clock"
+	"github.com/ekinolik/jax-ov/internal/market"
+	"github.com/ekinolik/jax-ov/internal/notifications"
+)
+
+// newCalendarURLHandler handles /calendar/url (protected by JWT): GET returns the user's calendar feed URL, and POST
+// regenerates it, revoking the URLs handed out before
+func newCalendarURLHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+		sub, _, err := auth.ValidateSessionToken(parts[1], d.authConfig.JWTSecret)
+		if err != nil {
+			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+
+		// The feed key is derived from a nonce stored with the user's notifications, so replacing it revokes old URLs
+		userConfig, err := notifications.LoadUserNotifications(sub, d.cfg.notificationsDir)
+		if err != nil {
+			log.Printf("Error loading notifications for user %s: %v", sub, err)
+			http.Error(w, "Error loading notifications", http.StatusInternalServerError)
+			return
+		}
+		if r.Method == http.MethodPost {
+			if userConfig.Notifications == nil {
+				userConfig.Notifications = make(map[string]notifications.NotificationConfig)
+			}
+			if err := userConfig.RotateFeedNonce(); err != nil {
+				log.Printf("Error regenerating calendar feed URL for user %s: %v", sub, err)
+				http.Error(w, "Error regenerating feed URL", http.StatusInternalServerError)
+				return
+			}
+			if err := notifications.SaveUserNotifications(sub, d.cfg.notificationsDir, userConfig); err != nil {
+				log.Printf("Error saving notifications for user %s: %v", sub, err)
+				http.Error(w, "Error regenerating feed URL", http.StatusInternalServerError)
+				return
+			}
+			log.Printf("User %s regenerated their calendar feed URL", sub)
+
+			// The config is saved either way; a failed push is picked up by the next periodic reload
+			if d.notificationsSync != nil {
+				go func() {
+					if err := d.notificationsSync.PushNotifications(context.Background(), userConfig); err != nil {
+						log.Printf("Error pushing notifications for user %s to notifications service: %v", sub, err)
+					}
+				}()
+			}
+		}
+
+		scheme := "http"
+		if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		query := url.Values{"user": {sub}, "key": {auth.FeedKey(sub, userConfig.FeedNonce, d.authConfig.JWTSecret)}}
+		feedURL := fmt.Sprintf("%s://%s/calendar.ics?%s", scheme, r.Host, query.Encode())
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"url": feedURL})
+	}
+}
+
+// newCalendarFeedHandler serves /calendar.ics, authenticated by the feed key in the URL from /calendar/url since
+// calendar apps can't send headers
+func newCalendarFeedHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// The key is checked against the nonce in the user's notifications, so the file is read first; user IDs that
+		// could name a file outside the notifications directory are rejected before that
+		sub := r.URL.Query().Get("user")
+		if err := notifications.ValidateUserID(sub); err != nil {
+			http.Error(w, "Invalid feed key", http.StatusUnauthorized)
+			return
+		}
+		userNotifications, err := notifications.LoadUserNotifications(sub, d.cfg.notificationsDir)
+		if err != nil {
+			log.Printf("Error loading notifications for calendar feed (user %s): %v", sub, err)
+			http.Error(w, "Error loading watchlist", http.StatusInternalServerError)
+			return
+		}
+		if !auth.ValidateFeedKey(sub, userNotifications.FeedNonce, r.URL.Query().Get("key"), d.authConfig.JWTSecret) {
+			http.Error(w, "Invalid feed key", http.StatusUnauthorized)
+			return
+		}
+
+		var earnings map[string][]time.Time
+		if d.cfg.earningsFile != "" {
+			if earnings, err = calendar.LoadEarnings(d.cfg.earningsFile); err != nil {
+				log.Printf("Error loading earnings calendar: %v", err)
+			}
+		}
+
+		// Expiration dates are calendar dates; compare them against today's date in ET
+		now := clock.Now()
+		todayET := now.In(market.Location)
+		from := time.Date(todayET.Year(), todayET.Month(), todayET.Day(), 0, 0, 0, 0, time.UTC)
+		until := from.AddDate(0, 0, d.cfg.calendarDays)
+		today := market.DateOf(now)
+
+		var events []calendar.Event
+		for ticker := range userNotifications.Notifications {
+			expirations, err := d.expirationCache.Upcoming(ticker, today, from, until)
+			if err != nil {
+				log.Printf("Error listing expirations for ticker %s: %v", ticker, err)
+			}
+			for _, expiration := range expirations {
+				kind := "Weekly"
+				if expiration.Date.Weekday() == time.Friday && expiration.Date.Day() >= 15 && expiration.Date.Day() <= 21 {
+					kind = "Monthly"
+				}
+				events = append(events, calendar.Event{
+					UID:         fmt.Sprintf("%s-expiration-%s@jax-ov", ticker, expiration.Date.Format("20060102")),
+					Date:        expiration.Date,
+					Summary:     fmt.Sprintf("%s options expiration", ticker),
+					Description: fmt.Sprintf("%s expiration. %d %s contracts traded in the latest session.", kind, expiration.Contracts, ticker),
+				})
+			}
+
+			for _, date := range earnings[ticker] {
+				if date.Before(from) || date.After(until) {
+					continue
+				}
+				events = append(events, calendar.Event{
+					UID:     fmt.Sprintf("%s-earnings-%s@jax-ov", ticker, date.Format("20060102")),
+					Date:    date,
+					Summary: fmt.Sprintf("%s earnings", ticker),
+				})
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `inline; filename="jax-ov.ics"`)
+		if err := calendar.WriteICS(w, "jax-ov watchlist", events, now); err != nil {
+			log.Printf("Error writing calendar feed: %v", err)
+		}
+	}
+}
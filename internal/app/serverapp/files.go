@@ -0,0 +1,132 @@
+package serverapp
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/auth"
+	"github.com/ekinolik/jax-ov/internal/logger"
+	"github.com/ekinolik/jax-ov/internal/market"
+	"github.com/ekinolik/jax-ov/internal/server"
+)
+
+// newDownloadHandler handles GET /download, raw log downloads (requires the download scope)
+func newDownloadHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		ticker := strings.ToUpper(r.URL.Query().Get("ticker"))
+		if err := server.ValidateTicker(ticker); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		dateStr := r.URL.Query().Get("date")
+		if dateStr == "" {
+			http.Error(w, "date parameter is required (format: YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		if _, err := time.Parse("2006-01-02", dateStr); err != nil {
+			http.Error(w, "invalid date format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		logFile := server.GetLogFileForTickerAndDate(d.cfg.logDir, ticker, dateStr)
+		file, err := os.Open(logFile)
+		if os.IsNotExist(err) {
+			http.Error(w, "No data for ticker and date", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("Error opening log file %s: %v", logFile, err)
+			http.Error(w, "Error reading log file", http.StatusInternalServerError)
+			return
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil {
+			log.Printf("Error reading log file %s: %v", logFile, err)
+			http.Error(w, "Error reading log file", http.StatusInternalServerError)
+			return
+		}
+
+		// Bandwidth is limited per user, so parallel downloads share one --download-rate budget
+		parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+		sub, _, err := auth.ValidateSessionToken(parts[1], d.authConfig.JWTSecret)
+		if err != nil {
+			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+		fileName := filepath.Base(logFile)
+		w, release := d.downloads.Writer(w, sub)
+		defer release()
+
+		// Gzipped downloads are streamed; uncompressed downloads support Range requests for resuming
+		if r.URL.Query().Get("gzip") == "true" {
+			w.Header().Set("Content-Type", "application/gzip")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName+".gz"))
+			gz := gzip.NewWriter(w)
+			if _, err := io.Copy(gz, file); err != nil {
+				log.Printf("Error streaming %s: %v", fileName, err)
+				return
+			}
+			if err := gz.Close(); err != nil {
+				log.Printf("Error streaming %s: %v", fileName, err)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+		http.ServeContent(w, r, fileName, info.ModTime(), file)
+	}
+}
+
+// newImportHandler handles POST /import, importing external aggregate data (requires the admin scope)
+func newImportHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body := http.MaxBytesReader(w, r.Body, d.cfg.importMaxBytes)
+		aggregates, err := logger.DecodeAggregates(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(aggregates) == 0 {
+			http.Error(w, "no aggregates in request body", http.StatusBadRequest)
+			return
+		}
+
+		// Files are dated in the analysis timezone, matching the logger and the server's default dates
+		result, err := logger.ImportAggregates(d.cfg.logDir, aggregates, market.AnalysisLocation())
+		if err != nil {
+			log.Printf("Import failed: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Imported %d of %d aggregates (%d duplicates) into %d files", result.Imported, result.Received, result.Duplicates, len(result.Files))
+
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			log.Printf("Error encoding JSON: %v", err)
+		}
+	}
+}
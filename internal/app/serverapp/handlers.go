@@ -0,0 +1,95 @@
+package serverapp
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/config"
+	"github.com/ekinolik/jax-ov/internal/marketdata"
+	"github.com/ekinolik/jax-ov/internal/metering"
+	"github.com/ekinolik/jax-ov/internal/notifications"
+	"github.com/ekinolik/jax-ov/internal/server"
+	"github.com/gorilla/websocket"
+)
+
+// serverConfig holds the command-line settings the handlers use, after defaults are applied
+type serverConfig struct {
+	logDir             string
+	notificationsDir   string
+	devicesDir         string
+	period             int    // Default analysis period in minutes
+	defaultAnchor      string // Period anchor when a request doesn't set one
+	host               string
+	port               string
+	loggerStatusFile   string
+	loggerStaleAfter   time.Duration
+	tokenExpiryWarning time.Duration
+	wsCompression      bool
+	wsCompressionLevel int
+	importMaxBytes     int64
+	outliersDir        string
+	earningsFile       string
+	calendarDays       int
+	timespan           string
+	lateGrace          time.Duration
+	maxStreamStates    int
+}
+
+// handlerDeps holds the configuration and shared state the HTTP and WebSocket handlers are built from
+// Optional features (backfill, spot prices, open interest, normals, demo mode, notifications sync) are nil when disabled
+type handlerDeps struct {
+	cfg               serverConfig
+	authConfig        *config.AuthConfig
+	wsServer          *server.Server
+	upgrader          *websocket.Upgrader
+	groups            *server.TickerGroups
+	demo              *server.Demo
+	backfiller        *server.Backfiller
+	correlations      *server.CorrelationAnalyzer
+	spot              marketdata.SpotSource
+	liveSpot          marketdata.SpotSource // spot, cached for --spot-refresh
+	openInterest      *marketdata.OpenInterestCache
+	historyCache      *server.HistoryCache
+	rollupCache       *server.RollupCache
+	baselines         *server.VolumeBaselines
+	normals           *server.PremiumBaselines
+	expirationCache   *server.ExpirationCache
+	downloads         *server.DownloadLimiter
+	usage             *metering.Meter
+	notificationsSync *notifications.SyncClient
+
+	// Share records are read, changed, and saved as a whole, so changes are serialized
+	sharesMu sync.Mutex
+}
+
+// applyNormals compares summaries with each time of day's normal premium, when normals are enabled
+func (d *handlerDeps) applyNormals(ticker string, dateStr string, opts analysis.AggregateOptions, summaries []analysis.TimePeriodSummary) []analysis.TimePeriodSummary {
+	if d.normals == nil {
+		return summaries
+	}
+	compared, err := d.normals.Apply(ticker, dateStr, opts, summaries)
+	if err != nil {
+		log.Printf("Error getting normal premium for ticker %s, date %s: %v", ticker, dateStr, err)
+	}
+	return compared
+}
+
+// collectStats gathers per-connection and cache statistics
+func (d *handlerDeps) collectStats(streams *streamWatcher) server.StatsSnapshot {
+	stats := d.wsServer.Stats()
+	stats.Caches = map[string]server.CacheStats{
+		"rollups":     d.rollupCache.CacheStats(),
+		"history":     d.historyCache.CacheStats(),
+		"streams":     streams.CacheStats(),
+		"expirations": d.expirationCache.CacheStats(),
+	}
+	if d.correlations != nil {
+		stats.Caches["correlation"] = d.correlations.CacheStats()
+	}
+	if d.normals != nil {
+		stats.Caches["normals"] = d.normals.CacheStats()
+	}
+	return stats
+}
@@ -0,0 +1,422 @@
+package serverapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/clock"
+	"github.com/ekinolik/jax-ov/internal/market"
+	"github.com/ekinolik/jax-ov/internal/server"
+)
+
+// newTransactionsHandler handles GET /transactions (protected by JWT)
+func newTransactionsHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only allow GET requests
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Get query parameters
+		ticker := r.URL.Query().Get("ticker")
+		dateStr := r.URL.Query().Get("date")
+		timeStr := r.URL.Query().Get("time")
+		periodStr := r.URL.Query().Get("period")
+		sessionStr := r.URL.Query().Get("session")
+		maxDTEStr := r.URL.Query().Get("max_dte")
+		strikeMinStr := r.URL.Query().Get("strike_min")
+		strikeMaxStr := r.URL.Query().Get("strike_max")
+		expirationStr := r.URL.Query().Get("expiration")
+		typeStr := r.URL.Query().Get("type")
+		moneynessMinStr := r.URL.Query().Get("moneyness_min")
+		moneynessMaxStr := r.URL.Query().Get("moneyness_max")
+		sortBy := r.URL.Query().Get("sort")
+		aggregateBy := r.URL.Query().Get("aggregate")
+
+		// Ticker is required
+		if ticker == "" {
+			http.Error(w, "ticker parameter is required", http.StatusBadRequest)
+			return
+		}
+		ticker = strings.ToUpper(ticker)
+
+		// Time is required
+		if timeStr == "" {
+			http.Error(w, "time parameter is required (format: HH:MM)", http.StatusBadRequest)
+			return
+		}
+
+		// Default period to 1 minute if not provided
+		periodMinutes := 1
+		if periodStr != "" {
+			period, err := strconv.Atoi(periodStr)
+			if err != nil || period <= 0 {
+				http.Error(w, "invalid period, must be a positive integer", http.StatusBadRequest)
+				return
+			}
+			periodMinutes = period
+		}
+
+		// Session filter is optional (default: all sessions)
+		sessions, err := market.ParseSessionSet(sessionStr)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Days-to-expiration limit is optional (default: every expiration)
+		maxDTE, err := analysis.ParseDTELimit(maxDTEStr)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Contract filters are optional (default: the whole chain)
+		contracts, err := analysis.ParseContractFilter(strikeMinStr, strikeMaxStr, expirationStr, typeStr)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Moneyness band is optional (default: every strike) and needs spot prices
+		moneyness, err := analysis.ParseMoneynessBand(moneynessMinStr, moneynessMaxStr)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !moneyness.IsZero() && d.spot == nil {
+			http.Error(w, "moneyness filters are not enabled on this server (requires --spot-vendor)", http.StatusServiceUnavailable)
+			return
+		}
+
+		// Sort and aggregation are optional (default: raw transactions in log order)
+		if sortBy != "" {
+			if err := analysis.ValidateSort(sortBy); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if aggregateBy != "" {
+			if err := analysis.ValidateAggregation(aggregateBy); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		// Default to the current date, or the most recent trading session on weekends and holidays
+		if dateStr == "" {
+			dateStr = server.ResolveDate(d.cfg.logDir, ticker)
+		}
+
+		// Get transactions for the time period and ticker
+		transactions, err := server.GetTransactionsForTickerAndTimePeriod(d.cfg.logDir, ticker, dateStr, timeStr, periodMinutes)
+		if err != nil {
+			log.Printf("Error getting transactions: %v", err)
+			http.Error(w, fmt.Sprintf("Error getting transactions: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// Tag each transaction with its strike's distance from spot (optional)
+		// Without a moneyness filter, transactions are still returned untagged if prices can't be fetched
+		if d.spot != nil {
+			if err := server.TagMoneynessForDate(r.Context(), d.spot, ticker, dateStr, transactions); err != nil {
+				log.Printf("Error tagging moneyness: %v", err)
+				if !moneyness.IsZero() {
+					http.Error(w, fmt.Sprintf("Error fetching prices: %v", err), http.StatusBadGateway)
+					return
+				}
+			}
+		}
+
+		// Apply session, days-to-expiration, contract, and moneyness filters
+		if sessions != 0 || maxDTE.Set || !contracts.IsZero() || !moneyness.IsZero() {
+			filterOpts := analysis.AggregateOptions{Sessions: sessions, MaxDTE: maxDTE}
+			filtered := make([]analysis.Aggregate, 0, len(transactions))
+			for _, agg := range transactions {
+				if filterOpts.Includes(agg) && contracts.Matches(agg.Symbol) && moneyness.Matches(agg) {
+					filtered = append(filtered, agg)
+				}
+			}
+			transactions = filtered
+		}
+
+		// Per-contract totals (by premium unless sorted otherwise) or raw transactions
+		var response interface{} = transactions
+		if aggregateBy == analysis.AggregateByContract {
+			totals := analysis.TotalByContract(transactions)
+			if d.openInterest != nil {
+				// Totals are still returned without open interest when no snapshot is available for the date
+				if snapshot, err := d.openInterest.Get(r.Context(), ticker, dateStr); err != nil {
+					log.Printf("Error loading open interest: %v", err)
+				} else {
+					analysis.ApplyContractOpenInterest(totals, snapshot.OpenInterest)
+				}
+			}
+			if sortBy != "" {
+				analysis.SortContractTotals(totals, sortBy)
+			}
+			response = totals
+		} else if sortBy != "" {
+			analysis.SortAggregates(transactions, sortBy)
+		}
+
+		// Set content type and return JSON array, with the date it covers in a header
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(server.ResolvedDateHeader, dateStr)
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(response); err != nil {
+			log.Printf("Error encoding JSON: %v", err)
+			http.Error(w, "Error encoding response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// newSummariesHandler handles GET /summaries (protected by JWT): the period history the WebSocket sends on connect
+func newSummariesHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// A ticker, a configured group, or a comma-separated list merged into one series
+		group, err := d.groups.Resolve(r.URL.Query().Get("ticker"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ticker := group.Name
+
+		// Period options match /analyze: anchor, session filter, and one of the cached resolutions
+		anchor := anchorParam(r, d.cfg.defaultAnchor)
+		if err := analysis.ValidateAnchor(anchor); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sessions, err := market.ParseSessionSet(r.URL.Query().Get("session"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		periodMinutes := d.cfg.period
+		if periodStr := r.URL.Query().Get("period"); periodStr != "" {
+			periodMinutes, err = strconv.Atoi(periodStr)
+			if err != nil || !d.historyCache.Supports(periodMinutes) {
+				http.Error(w, fmt.Sprintf("invalid period %q (must be one of %v)", periodStr, d.historyCache.Periods()), http.StatusBadRequest)
+				return
+			}
+		}
+		opts := analysis.AggregateOptions{PeriodMinutes: periodMinutes, Anchor: anchor, Sessions: sessions}
+
+		// Default to the current date, or the most recent trading session on weekends and holidays
+		dateStr := r.URL.Query().Get("date")
+		if dateStr == "" {
+			dateStr = server.ResolveGroupDate(d.cfg.logDir, group)
+		}
+		if _, err := time.Parse("2006-01-02", dateStr); err != nil {
+			http.Error(w, "invalid date format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		// Optional empty periods, so the summaries sit on a fixed time axis
+		var zeroFill bool
+		if zeroFillStr := r.URL.Query().Get("zero_fill"); zeroFillStr != "" {
+			if zeroFill, err = strconv.ParseBool(zeroFillStr); err != nil {
+				http.Error(w, fmt.Sprintf("invalid zero_fill %q", zeroFillStr), http.StatusBadRequest)
+				return
+			}
+		}
+
+		// Optional point-in-time cutoff (HH:MM): only aggregates that started before it are included
+		var asOf time.Time
+		if asOfStr := r.URL.Query().Get("as_of"); asOfStr != "" {
+			if asOf, err = server.ParseTimeOfDay(dateStr, asOfStr); err != nil {
+				http.Error(w, fmt.Sprintf("invalid as_of %q: %v", asOfStr, err), http.StatusBadRequest)
+				return
+			}
+		}
+
+		var summaries []analysis.TimePeriodSummary
+		if group.Combined() {
+			summaries, err = server.AnalyzeGroupAndDate(d.cfg.logDir, group, dateStr, opts, asOf)
+		} else if asOf.IsZero() {
+			summaries, err = d.historyCache.Summaries(ticker, dateStr, opts)
+		} else {
+			summaries, err = server.AnalyzeTickerAndDateAsOf(d.cfg.logDir, ticker, dateStr, opts, asOf)
+		}
+		if err != nil {
+			log.Printf("Error getting summaries for ticker %s, date %s: %v", ticker, dateStr, err)
+			http.Error(w, "Error getting summaries", http.StatusInternalServerError)
+			return
+		}
+		if zeroFill {
+			summaries = analysis.ZeroFill(summaries, opts, server.ZeroFillUntil(dateStr, asOf))
+		}
+
+		// Measure each period's volume against the ticker's average daily volume (combined tickers have no baselines)
+		if !group.Combined() {
+			summaries, err = d.baselines.Apply(ticker, dateStr, summaries)
+			if err != nil {
+				log.Printf("Error getting average daily volume for ticker %s, date %s: %v", ticker, dateStr, err)
+			}
+			summaries = d.applyNormals(ticker, dateStr, opts, summaries)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(server.ResolvedDateHeader, dateStr)
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(summaries); err != nil {
+			log.Printf("Error encoding JSON: %v", err)
+			http.Error(w, "Error encoding response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// newSnapshotHandler handles GET /snapshot (protected by JWT): each ticker's latest periods for a watchlist
+func newSnapshotHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		tickers, err := server.ParseTickerList(r.URL.Query().Get("tickers"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Period options match /analyze: anchor, session filter, and one of the cached resolutions
+		anchor := anchorParam(r, d.cfg.defaultAnchor)
+		if err := analysis.ValidateAnchor(anchor); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sessions, err := market.ParseSessionSet(r.URL.Query().Get("session"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		periodMinutes := d.cfg.period
+		if periodStr := r.URL.Query().Get("period"); periodStr != "" {
+			periodMinutes, err = strconv.Atoi(periodStr)
+			if err != nil || !d.historyCache.Supports(periodMinutes) {
+				http.Error(w, fmt.Sprintf("invalid period %q (must be one of %v)", periodStr, d.historyCache.Periods()), http.StatusBadRequest)
+				return
+			}
+		}
+		opts := analysis.AggregateOptions{PeriodMinutes: periodMinutes, Anchor: anchor, Sessions: sessions}
+
+		// Every ticker is read from the history cache, which only re-aggregates a day when its log file has changed
+		// Configured group names are merged from their members' logs instead
+		now := clock.Now()
+		snapshot := server.Snapshot{GeneratedAt: now.UTC(), PeriodMinutes: periodMinutes, Tickers: make([]server.TickerSnapshot, 0, len(tickers))}
+		for _, ticker := range tickers {
+			group, err := d.groups.Resolve(ticker)
+			if err != nil {
+				snapshot.Tickers = append(snapshot.Tickers, server.TickerSnapshot{Ticker: ticker, Error: err.Error()})
+				continue
+			}
+			dateStr := server.ResolveGroupDate(d.cfg.logDir, group)
+			var summaries []analysis.TimePeriodSummary
+			if group.Combined() {
+				summaries, err = server.AnalyzeGroupAndDate(d.cfg.logDir, group, dateStr, opts, time.Time{})
+			} else {
+				summaries, err = d.historyCache.Summaries(ticker, dateStr, opts)
+			}
+			if err != nil {
+				log.Printf("Error getting summaries for ticker %s, date %s: %v", ticker, dateStr, err)
+				snapshot.Tickers = append(snapshot.Tickers, server.TickerSnapshot{Ticker: ticker, Date: dateStr, Error: "failed to load summaries"})
+				continue
+			}
+			if !group.Combined() {
+				summaries, err = d.baselines.Apply(ticker, dateStr, summaries)
+				if err != nil {
+					log.Printf("Error getting average daily volume for ticker %s, date %s: %v", ticker, dateStr, err)
+				}
+				summaries = d.applyNormals(ticker, dateStr, opts, summaries)
+			}
+			snapshot.Tickers = append(snapshot.Tickers, server.NewTickerSnapshot(ticker, dateStr, summaries, now))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(snapshot); err != nil {
+			log.Printf("Error encoding JSON: %v", err)
+		}
+	}
+}
+
+// newDailyHandler handles GET /daily, the daily cumulative summary (protected by JWT)
+func newDailyHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		ticker := strings.ToUpper(r.URL.Query().Get("ticker"))
+		if err := server.ValidateTicker(ticker); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Date defaults to the current date in the analysis timezone, or the most recent trading session
+		dateStr := r.URL.Query().Get("date")
+		if dateStr == "" {
+			dateStr = server.ResolveDate(d.cfg.logDir, ticker)
+		}
+		if _, err := time.Parse("2006-01-02", dateStr); err != nil {
+			http.Error(w, "invalid date format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		// Period only affects which period is reported as the peak; anchor and session match /analyze
+		periodMinutes := d.cfg.period
+		if periodStr := r.URL.Query().Get("period"); periodStr != "" {
+			p, err := strconv.Atoi(periodStr)
+			if err != nil || p <= 0 {
+				http.Error(w, "invalid period, must be a positive integer", http.StatusBadRequest)
+				return
+			}
+			periodMinutes = p
+		}
+		anchor := anchorParam(r, d.cfg.defaultAnchor)
+		if err := analysis.ValidateAnchor(anchor); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sessions, err := market.ParseSessionSet(r.URL.Query().Get("session"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts := analysis.AggregateOptions{PeriodMinutes: periodMinutes, Anchor: anchor, Sessions: sessions}
+
+		summaries, err := d.historyCache.Summaries(ticker, dateStr, opts)
+		if err != nil {
+			log.Printf("Error computing daily summary for ticker %s: %v", ticker, err)
+			http.Error(w, "Error computing daily summary", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(analysis.SummarizeDaily(ticker, dateStr, periodMinutes, summaries)); err != nil {
+			log.Printf("Error encoding JSON: %v", err)
+		}
+	}
+}
@@ -0,0 +1,506 @@
+package serverapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/auth"
+	"github.com/ekinolik/jax-ov/internal/market"
+	"github.com/ekinolik/jax-ov/internal/notifications"
+	"github.com/ekinolik/jax-ov/internal/server"
+)
+
+// newGetNotificationsHandler handles GET /notifications (protected by JWT)
+func newGetNotificationsHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Extract user sub from JWT (already validated by middleware)
+		// We need to get it from the request context or re-validate
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Authorization header required", http.StatusUnauthorized)
+			return
+		}
+
+		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+			return
+		}
+
+		sub, _, err := auth.ValidateSessionToken(parts[1], d.authConfig.JWTSecret)
+		if err != nil {
+			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+
+		// Load user notifications
+		userConfig, err := notifications.LoadUserNotifications(sub, d.cfg.notificationsDir)
+		if err != nil {
+			log.Printf("Error loading notifications for user %s: %v", sub, err)
+			http.Error(w, "Error loading notifications", http.StatusInternalServerError)
+			return
+		}
+
+		// Rule sets other accounts share with the user, read-only
+		shares, err := notifications.LoadShares(d.cfg.notificationsDir)
+		if err != nil {
+			log.Printf("Error loading shares for user %s: %v", sub, err)
+			http.Error(w, "Error loading notifications", http.StatusInternalServerError)
+			return
+		}
+		shared := []notifications.SharedRules{}
+		for _, owner := range shares.Owners(sub) {
+			ownerConfig, err := notifications.LoadUserNotifications(owner, d.cfg.notificationsDir)
+			if err != nil {
+				log.Printf("Error loading notifications shared by user %s: %v", owner, err)
+				continue
+			}
+			shared = append(shared, notifications.SharedRules{OwnerID: owner, Notifications: ownerConfig.Notifications})
+		}
+
+		// Return response
+		w.Header().Set("Content-Type", "application/json")
+		response := map[string]interface{}{
+			"notifications":   userConfig.Notifications,
+			"session_summary": userConfig.SessionSummary,
+			"shared":          shared,
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("Error encoding response: %v", err)
+		}
+	}
+}
+
+// newPutNotificationsHandler handles PUT /notifications (protected by JWT)
+func newPutNotificationsHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Extract user sub from JWT
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Authorization header required", http.StatusUnauthorized)
+			return
+		}
+
+		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+			return
+		}
+
+		sub, _, err := auth.ValidateSessionToken(parts[1], d.authConfig.JWTSecret)
+		if err != nil {
+			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+
+		// Parse request body
+		var newConfig notifications.NotificationConfig
+		if err := json.NewDecoder(r.Body).Decode(&newConfig); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		// Validate required fields
+		if newConfig.Ticker == "" {
+			http.Error(w, "ticker is required", http.StatusBadRequest)
+			return
+		}
+		newConfig.Ticker = strings.ToUpper(newConfig.Ticker)
+
+		// Validate session filter (empty means all sessions)
+		if _, err := market.ParseSessionSet(strings.Join(newConfig.Sessions, ",")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Validate rate-of-change settings
+		if err := newConfig.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Disabled defaults to false (active) if not provided (Go's zero value)
+
+		// Load existing user notifications
+		userConfig, err := notifications.LoadUserNotifications(sub, d.cfg.notificationsDir)
+		if err != nil {
+			log.Printf("Error loading notifications for user %s: %v", sub, err)
+			http.Error(w, "Error loading notifications", http.StatusInternalServerError)
+			return
+		}
+
+		// Ensure notifications map exists
+		if userConfig.Notifications == nil {
+			userConfig.Notifications = make(map[string]notifications.NotificationConfig)
+		}
+
+		// Overwrite notification for this ticker (only one per ticker)
+		userConfig.Notifications[newConfig.Ticker] = newConfig
+
+		// Save user notifications
+		if err := notifications.SaveUserNotifications(sub, d.cfg.notificationsDir, userConfig); err != nil {
+			log.Printf("Error saving notifications for user %s: %v", sub, err)
+			http.Error(w, "Error saving notifications", http.StatusInternalServerError)
+			return
+		}
+
+		// The config is saved either way; a failed push is picked up by the next periodic reload
+		if d.notificationsSync != nil {
+			go func() {
+				if err := d.notificationsSync.PushNotifications(context.Background(), userConfig); err != nil {
+					log.Printf("Error pushing notifications for user %s to notifications service: %v", sub, err)
+				}
+			}()
+		}
+
+		// Return success
+		w.Header().Set("Content-Type", "application/json")
+		response := map[string]interface{}{
+			"success": true,
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("Error encoding response: %v", err)
+		}
+	}
+}
+
+// newSharesHandler handles /notifications/shares (protected by JWT), which manages sharing the user's rule set with
+// other accounts: GET lists shares and pending invites, POST creates an invite code, and DELETE revokes a share or
+// invite (recipient=<sub> or code=<code>) or leaves a rule set shared with the user (owner=<sub>)
+func newSharesHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost && r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+		sub, _, err := auth.ValidateSessionToken(parts[1], d.authConfig.JWTSecret)
+		if err != nil {
+			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+
+		d.sharesMu.Lock()
+		defer d.sharesMu.Unlock()
+
+		shares, err := notifications.LoadShares(d.cfg.notificationsDir)
+		if err != nil {
+			log.Printf("Error loading shares for user %s: %v", sub, err)
+			http.Error(w, "Error loading shares", http.StatusInternalServerError)
+			return
+		}
+		now := time.Now()
+		shares.PruneExpired(now)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodPost:
+			invite, err := shares.CreateInvite(sub, now)
+			if errors.Is(err, notifications.ErrShareLimit) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			if err == nil {
+				err = notifications.SaveShares(d.cfg.notificationsDir, shares)
+			}
+			if err != nil {
+				log.Printf("Error creating share invite for user %s: %v", sub, err)
+				http.Error(w, "Error creating invite", http.StatusInternalServerError)
+				return
+			}
+			log.Printf("User %s created a share invite expiring %s", sub, invite.ExpiresAt.Format(time.RFC3339))
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(invite)
+
+		case http.MethodDelete:
+			query := r.URL.Query()
+			var removed bool
+			switch {
+			case query.Get("recipient") != "":
+				removed = shares.Remove(sub, query.Get("recipient"))
+			case query.Get("owner") != "":
+				removed = shares.Remove(query.Get("owner"), sub)
+			case query.Get("code") != "":
+				removed = shares.RevokeInvite(sub, query.Get("code"))
+			default:
+				http.Error(w, "one of recipient, owner, or code is required", http.StatusBadRequest)
+				return
+			}
+			if !removed {
+				http.Error(w, "share not found", http.StatusNotFound)
+				return
+			}
+			if err := notifications.SaveShares(d.cfg.notificationsDir, shares); err != nil {
+				log.Printf("Error saving shares for user %s: %v", sub, err)
+				http.Error(w, "Error saving shares", http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
+
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"shared_with":   shares.Recipients(sub),
+				"shared_by":     shares.Owners(sub),
+				"invites":       shares.PendingInvites(sub),
+				"max_shares":    notifications.MaxShareRecipients,
+				"invite_expiry": notifications.ShareInviteTTL.String(),
+			})
+		}
+	}
+}
+
+// newAcceptShareHandler handles POST /notifications/shares/accept (protected by JWT), which accepts an invite code,
+// so the user's devices receive the alerts of the inviting account's rules and GET /notifications lists them under shared
+func newAcceptShareHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+		sub, _, err := auth.ValidateSessionToken(parts[1], d.authConfig.JWTSecret)
+		if err != nil {
+			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+
+		var request struct {
+			Code string `json:"code"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Code == "" {
+			http.Error(w, "Invalid request body: code is required", http.StatusBadRequest)
+			return
+		}
+
+		d.sharesMu.Lock()
+		defer d.sharesMu.Unlock()
+
+		shares, err := notifications.LoadShares(d.cfg.notificationsDir)
+		if err != nil {
+			log.Printf("Error loading shares for user %s: %v", sub, err)
+			http.Error(w, "Error loading shares", http.StatusInternalServerError)
+			return
+		}
+		share, err := shares.AcceptInvite(strings.ToUpper(strings.TrimSpace(request.Code)), sub, time.Now())
+		switch {
+		case errors.Is(err, notifications.ErrInviteNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, notifications.ErrShareSelf):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, notifications.ErrShareExists):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err := notifications.SaveShares(d.cfg.notificationsDir, shares); err != nil {
+			log.Printf("Error saving shares for user %s: %v", sub, err)
+			http.Error(w, "Error saving shares", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("User %s accepted a share of user %s's notification rules", sub, share.OwnerID)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(share)
+	}
+}
+
+// newSessionSummaryHandler handles PUT /notifications/session-summary (protected by JWT), which opts the user in or
+// out of the end-of-session summary push
+func newSessionSummaryHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+		sub, _, err := auth.ValidateSessionToken(parts[1], d.authConfig.JWTSecret)
+		if err != nil {
+			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+
+		var request struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Enabled == nil {
+			http.Error(w, "Invalid request body: enabled is required", http.StatusBadRequest)
+			return
+		}
+
+		userConfig, err := notifications.LoadUserNotifications(sub, d.cfg.notificationsDir)
+		if err != nil {
+			log.Printf("Error loading notifications for user %s: %v", sub, err)
+			http.Error(w, "Error loading notifications", http.StatusInternalServerError)
+			return
+		}
+		if userConfig.Notifications == nil {
+			userConfig.Notifications = make(map[string]notifications.NotificationConfig)
+		}
+		userConfig.SessionSummary = *request.Enabled
+
+		if err := notifications.SaveUserNotifications(sub, d.cfg.notificationsDir, userConfig); err != nil {
+			log.Printf("Error saving notifications for user %s: %v", sub, err)
+			http.Error(w, "Error saving notifications", http.StatusInternalServerError)
+			return
+		}
+
+		// The config is saved either way; a failed push is picked up by the next periodic reload
+		if d.notificationsSync != nil {
+			go func() {
+				if err := d.notificationsSync.PushNotifications(context.Background(), userConfig); err != nil {
+					log.Printf("Error pushing notifications for user %s to notifications service: %v", sub, err)
+				}
+			}()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":         true,
+			"session_summary": userConfig.SessionSummary,
+		})
+	}
+}
+
+// newNotificationReportHandler handles GET /notifications/report (protected by JWT), which replays each of the user's
+// notification configs over the last N sessions' logs and reports how often it would have fired, so users can prune
+// rules that fire constantly or never
+func newNotificationReportHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+		sub, _, err := auth.ValidateSessionToken(parts[1], d.authConfig.JWTSecret)
+		if err != nil {
+			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+
+		ticker := strings.ToUpper(r.URL.Query().Get("ticker"))
+		if ticker != "" {
+			if err := server.ValidateTicker(ticker); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		days := notifications.DefaultReportDays
+		if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+			days, err = strconv.Atoi(daysStr)
+			if err != nil || days <= 0 || days > notifications.MaxReportDays {
+				http.Error(w, fmt.Sprintf("invalid days, must be between 1 and %d", notifications.MaxReportDays), http.StatusBadRequest)
+				return
+			}
+		}
+
+		userConfig, err := notifications.LoadUserNotifications(sub, d.cfg.notificationsDir)
+		if err != nil {
+			log.Printf("Error loading notifications for user %s: %v", sub, err)
+			http.Error(w, "Error loading notifications", http.StatusInternalServerError)
+			return
+		}
+		tickers := make([]string, 0, len(userConfig.Notifications))
+		for configTicker := range userConfig.Notifications {
+			if ticker == "" || configTicker == ticker {
+				tickers = append(tickers, configTicker)
+			}
+		}
+		if ticker != "" && len(tickers) == 0 {
+			http.Error(w, fmt.Sprintf("no notification configured for %s", ticker), http.StatusNotFound)
+			return
+		}
+		sort.Strings(tickers)
+
+		// Configs without period_minutes are replayed at --period, which should match the notifications service's
+		dates := market.CurrentTradingDays().Past(market.DefaultDate(), days)
+		reports := make([]*notifications.RuleReport, 0, len(tickers))
+		for _, configTicker := range tickers {
+			config := userConfig.Notifications[configTicker]
+			if config.Ticker == "" {
+				config.Ticker = configTicker
+			}
+			opts := analysis.AggregateOptions{PeriodMinutes: config.EvaluationPeriod(d.cfg.period)}
+			band := config.MoneynessBand()
+			report := notifications.NewRuleReport(config, opts.PeriodMinutes)
+			if !band.IsZero() && d.spot == nil {
+				// Without prices the band can't be applied, so the conditions would be judged on every strike
+				report.Unevaluated = append(report.Unevaluated, "moneyness band (requires --spot-vendor)")
+				report.Finish()
+				reports = append(reports, report)
+				continue
+			}
+
+			for _, date := range dates {
+				var summaries []analysis.TimePeriodSummary
+				if band.IsZero() {
+					summaries, err = d.historyCache.Summaries(configTicker, date, opts)
+				} else {
+					summaries, err = server.AnalyzeBandForDate(r.Context(), d.cfg.logDir, d.spot, configTicker, date, band, opts)
+				}
+				if err != nil {
+					log.Printf("Error replaying notification for user %s on ticker %s, date %s: %v", sub, configTicker, date, err)
+					report.AddError(date, err)
+					continue
+				}
+				report.AddSession(config, date, summaries)
+			}
+			report.Finish()
+			reports = append(reports, report)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		response := map[string]interface{}{
+			"days":  len(dates),
+			"rules": reports,
+		}
+		if len(dates) > 0 {
+			response["from"] = dates[0]
+			response["to"] = dates[len(dates)-1]
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(response); err != nil {
+			log.Printf("Error encoding JSON: %v", err)
+		}
+	}
+}
+
+// newNotificationsHandler handles /notifications (protected by JWT): GET lists the user's notification configs and
+// PUT saves one
+func newNotificationsHandler(d *handlerDeps) http.HandlerFunc {
+	getNotifications := newGetNotificationsHandler(d)
+	putNotifications := newPutNotificationsHandler(d)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			getNotifications(w, r)
+		} else if r.Method == http.MethodPut {
+			putNotifications(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package serverapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/logger"
+	"github.com/ekinolik/jax-ov/internal/market"
+	"github.com/ekinolik/jax-ov/internal/server"
+)
+
+// newRollupsHandler handles GET /rollups (protected by JWT)
+func newRollupsHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		ticker := strings.ToUpper(r.URL.Query().Get("ticker"))
+		if err := server.ValidateTicker(ticker); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Default granularity to daily
+		granularity := r.URL.Query().Get("granularity")
+		if granularity == "" {
+			granularity = analysis.GranularityDaily
+		}
+		if err := analysis.ValidateGranularity(granularity); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Optional date range (YYYY-MM-DD, inclusive)
+		fromDate := r.URL.Query().Get("from")
+		toDate := r.URL.Query().Get("to")
+		for _, dateStr := range []string{fromDate, toDate} {
+			if dateStr == "" {
+				continue
+			}
+			if _, err := time.Parse("2006-01-02", dateStr); err != nil {
+				http.Error(w, "invalid date format, expected YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+		}
+
+		rollups, err := d.rollupCache.Rollup(ticker, fromDate, toDate, granularity)
+		if err != nil {
+			log.Printf("Error computing rollups for ticker %s: %v", ticker, err)
+			http.Error(w, "Error computing rollups", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(rollups); err != nil {
+			log.Printf("Error encoding JSON: %v", err)
+		}
+	}
+}
+
+// newAvailabilityHandler handles GET /availability (protected by JWT)
+func newAvailabilityHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		ticker := strings.ToUpper(r.URL.Query().Get("ticker"))
+		if err := server.ValidateTicker(ticker); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		availability, err := d.rollupCache.Availability(ticker)
+		if err != nil {
+			log.Printf("Error computing availability for ticker %s: %v", ticker, err)
+			http.Error(w, "Error computing availability", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(availability); err != nil {
+			log.Printf("Error encoding JSON: %v", err)
+		}
+	}
+}
+
+// newWallsHandler handles GET /walls, the put/call walls (protected by JWT)
+func newWallsHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		ticker := strings.ToUpper(r.URL.Query().Get("ticker"))
+		if err := server.ValidateTicker(ticker); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Date defaults to the current date in the analysis timezone, or the most recent trading session
+		dateStr := r.URL.Query().Get("date")
+		if dateStr == "" {
+			dateStr = server.ResolveDate(d.cfg.logDir, ticker)
+		}
+		if _, err := time.Parse("2006-01-02", dateStr); err != nil {
+			http.Error(w, "invalid date format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		// Bucketing options match /analyze so per-period walls line up with the stream
+		anchor := anchorParam(r, d.cfg.defaultAnchor)
+		if err := analysis.ValidateAnchor(anchor); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sessions, err := market.ParseSessionSet(r.URL.Query().Get("session"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts := analysis.AggregateOptions{PeriodMinutes: d.cfg.period, Anchor: anchor, Sessions: sessions}
+
+		// Number of top strikes per side (default 5)
+		top := 5
+		if topStr := r.URL.Query().Get("top"); topStr != "" {
+			top, err = strconv.Atoi(topStr)
+			if err != nil || top <= 0 {
+				http.Error(w, "invalid top, must be a positive integer", http.StatusBadRequest)
+				return
+			}
+		}
+
+		report, err := server.AnalyzeWalls(d.cfg.logDir, ticker, dateStr, opts, top)
+		if err != nil {
+			log.Printf("Error computing walls for ticker %s: %v", ticker, err)
+			http.Error(w, "Error computing walls", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Printf("Error encoding JSON: %v", err)
+		}
+	}
+}
+
+// newStrikesHandler handles GET /strikes, the per-period strike ladder (protected by JWT)
+func newStrikesHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		ticker := strings.ToUpper(r.URL.Query().Get("ticker"))
+		if err := server.ValidateTicker(ticker); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Date defaults to the current date in the analysis timezone, or the most recent trading session
+		dateStr := r.URL.Query().Get("date")
+		if dateStr == "" {
+			dateStr = server.ResolveDate(d.cfg.logDir, ticker)
+		}
+		if _, err := time.Parse("2006-01-02", dateStr); err != nil {
+			http.Error(w, "invalid date format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		// Period defaults to the server's --period; anchor and session match /analyze so ladders line up with the stream
+		periodMinutes := d.cfg.period
+		if periodStr := r.URL.Query().Get("period"); periodStr != "" {
+			p, err := strconv.Atoi(periodStr)
+			if err != nil || p <= 0 {
+				http.Error(w, "invalid period, must be a positive integer", http.StatusBadRequest)
+				return
+			}
+			periodMinutes = p
+		}
+		anchor := anchorParam(r, d.cfg.defaultAnchor)
+		if err := analysis.ValidateAnchor(anchor); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sessions, err := market.ParseSessionSet(r.URL.Query().Get("session"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts := analysis.AggregateOptions{PeriodMinutes: periodMinutes, Anchor: anchor, Sessions: sessions}
+
+		report, err := server.AnalyzeStrikeLadder(d.cfg.logDir, ticker, dateStr, opts)
+		if err != nil {
+			log.Printf("Error computing strike ladder for ticker %s: %v", ticker, err)
+			http.Error(w, "Error computing strike ladder", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Printf("Error encoding JSON: %v", err)
+		}
+	}
+}
+
+// newChainHandler handles GET /chain (protected by JWT): one expiration's contracts traded on a date
+func newChainHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		ticker := strings.ToUpper(r.URL.Query().Get("ticker"))
+		if err := server.ValidateTicker(ticker); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		expiration := r.URL.Query().Get("expiration")
+		if expiration == "" {
+			http.Error(w, "expiration parameter is required", http.StatusBadRequest)
+			return
+		}
+		if _, err := time.Parse("2006-01-02", expiration); err != nil {
+			http.Error(w, "invalid expiration format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		// Date defaults to the current date in the analysis timezone, or the most recent trading session
+		dateStr := r.URL.Query().Get("date")
+		if dateStr == "" {
+			dateStr = server.ResolveDate(d.cfg.logDir, ticker)
+		}
+		if _, err := time.Parse("2006-01-02", dateStr); err != nil {
+			http.Error(w, "invalid date format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		report, err := server.AnalyzeChain(d.cfg.logDir, ticker, dateStr, expiration)
+		if err != nil {
+			log.Printf("Error building chain for ticker %s: %v", ticker, err)
+			http.Error(w, "Error building chain", http.StatusInternalServerError)
+			return
+		}
+
+		// Open interest and implied volatility are optional: the chain is still returned without them when the
+		// server has no source, or no snapshot or prices are available for the date
+		if d.openInterest != nil && len(report.Contracts) > 0 {
+			if snapshot, err := d.openInterest.Get(r.Context(), ticker, dateStr); err != nil {
+				log.Printf("Error loading open interest: %v", err)
+			} else {
+				analysis.ApplyChainOpenInterest(report.Contracts, snapshot.OpenInterest)
+			}
+		}
+		if d.spot != nil {
+			if err := server.ApplyChainVolatilityForDate(r.Context(), d.spot, ticker, dateStr, report.Contracts); err != nil {
+				log.Printf("Error computing implied volatility: %v", err)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(server.ResolvedDateHeader, dateStr)
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Printf("Error encoding JSON: %v", err)
+		}
+	}
+}
+
+// newCorrelationHandler handles GET /correlation, the flow/return correlation (protected by JWT)
+func newCorrelationHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if d.correlations == nil {
+			http.Error(w, "correlation is not enabled on this server", http.StatusServiceUnavailable)
+			return
+		}
+
+		ticker := strings.ToUpper(r.URL.Query().Get("ticker"))
+		if err := server.ValidateTicker(ticker); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Optional date range (YYYY-MM-DD, inclusive)
+		fromDate := r.URL.Query().Get("from")
+		toDate := r.URL.Query().Get("to")
+		for _, dateStr := range []string{fromDate, toDate} {
+			if dateStr == "" {
+				continue
+			}
+			if _, err := time.Parse("2006-01-02", dateStr); err != nil {
+				http.Error(w, "invalid date format, expected YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+		}
+
+		anchor := anchorParam(r, d.cfg.defaultAnchor)
+		if err := analysis.ValidateAnchor(anchor); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// Session defaults to regular hours, where the underlying trades with meaningful volume
+		sessionStr := r.URL.Query().Get("session")
+		if sessionStr == "" {
+			sessionStr = market.SessionRegular
+		}
+		sessions, err := market.ParseSessionSet(sessionStr)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts := analysis.AggregateOptions{PeriodMinutes: d.cfg.period, Anchor: anchor, Sessions: sessions}
+
+		// Forward return horizon in periods (default 1) and rolling window in samples (default 20)
+		params := map[string]int{"horizon": 1, "window": 20}
+		for _, name := range []string{"horizon", "window"} {
+			if value := r.URL.Query().Get(name); value != "" {
+				n, err := strconv.Atoi(value)
+				if err != nil || n <= 0 {
+					http.Error(w, fmt.Sprintf("invalid %s, must be a positive integer", name), http.StatusBadRequest)
+					return
+				}
+				params[name] = n
+			}
+		}
+		if params["window"] < 2 {
+			http.Error(w, "invalid window, must be at least 2", http.StatusBadRequest)
+			return
+		}
+
+		report, err := d.correlations.Analyze(r.Context(), ticker, fromDate, toDate, opts, params["horizon"], params["window"])
+		if err != nil {
+			log.Printf("Error computing correlation for ticker %s: %v", ticker, err)
+			http.Error(w, fmt.Sprintf("Error computing correlation: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Printf("Error encoding JSON: %v", err)
+		}
+	}
+}
+
+// newOutliersFeedHandler handles GET /outliers/feed, the market-wide premium outlier feed (protected by JWT)
+func newOutliersFeedHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Get date from query parameter (optional, defaults to today in the analysis timezone)
+		dateStr := r.URL.Query().Get("date")
+		if dateStr == "" {
+			dateStr = market.Today()
+		}
+		if _, err := time.Parse("2006-01-02", dateStr); err != nil {
+			http.Error(w, "invalid date format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		outliers, err := logger.ReadOutliers(d.cfg.outliersDir, dateStr)
+		if err != nil {
+			log.Printf("Error reading outliers for %s: %v", dateStr, err)
+			http.Error(w, "Error reading outliers", http.StatusInternalServerError)
+			return
+		}
+
+		// One outlier per line, oldest first, so clients can render the feed as it arrives
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		encoder := json.NewEncoder(w)
+		for _, outlier := range outliers {
+			if err := encoder.Encode(outlier); err != nil {
+				log.Printf("Error streaming outliers: %v", err)
+				return
+			}
+		}
+	}
+}
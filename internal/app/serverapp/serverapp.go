@@ -0,0 +1,487 @@
+// Package serverapp implements the server command (analysis websocket server)
+// It is shared by cmd/server and the unified jax-ov binary
+package serverapp
+
+import (
+	"context"
+	"expvar"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/app"
+	"github.com/ekinolik/jax-ov/internal/auth"
+	"github.com/ekinolik/jax-ov/internal/config"
+	"github.com/ekinolik/jax-ov/internal/logger"
+	"github.com/ekinolik/jax-ov/internal/market"
+	"github.com/ekinolik/jax-ov/internal/marketdata"
+	"github.com/ekinolik/jax-ov/internal/metering"
+	"github.com/ekinolik/jax-ov/internal/notifications"
+	"github.com/ekinolik/jax-ov/internal/server"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Run runs the server command with the given command-line arguments (excluding the program name)
+func Run(args []string) {
+	// Parse command-line flags
+	fs := flag.NewFlagSet("server", flag.ExitOnError)
+	logDir := fs.String("log-dir", "./logs", "Log directory path (default: ./logs)")
+	notificationsDir := fs.String("notifications-dir", "./notifications", "Notifications config directory (default: ./notifications)")
+	period := fs.Int("period", 5, "Analysis period in minutes (default: 5)")
+	defaultAnchor := fs.String("anchor", analysis.AnchorMidnight, "Default period boundary anchor when a request doesn't set one: midnight or open (default: midnight)")
+	port := fs.String("port", "8080", "WebSocket server port (default: 8080)")
+	host := fs.String("host", "localhost", "Bind address (default: localhost)")
+	devicesDir := fs.String("devices-dir", "./devices", "Devices directory path (default: ./devices)")
+	loggerStatusFile := fs.String("logger-status-file", "", "Logger heartbeat status file (default: <log-dir>/logger-status.json)")
+	tokenExpiryWarning := fs.Duration("token-expiry-warning", 5*time.Minute, "How long before session token expiry to send token_expiring to WebSocket clients (default: 5m)")
+	downloadRate := fs.Int64("download-rate", 1<<20, "Bandwidth limit for /download in bytes per second, shared by each user's parallel downloads, 0 for unlimited (default: 1048576)")
+	importMaxBytes := fs.Int64("import-max-bytes", 256<<20, "Maximum /import request body size in bytes (default: 268435456)")
+	duplicatePolicy := fs.String("duplicate-connections", server.DuplicatePolicyAllow, "Policy when a user opens several /analyze connections for one ticker: allow, replace-oldest, or reject (default: allow)")
+	maxPerUserTicker := fs.Int("max-connections-per-ticker", 1, "Connections per user and ticker before the duplicate-connections policy applies (default: 1)")
+	lagResendAfter := fs.Duration("lag-resend-after", 0, "Resend missed periods to /analyze clients whose heartbeats show messages unreceived for this long, 0 disables (default: 0)")
+	allowedOrigins := fs.String("allowed-origins", "", "Comma-separated WebSocket origins to allow (default: all)")
+	wsCompression := fs.Bool("ws-compression", false, "Compress /analyze messages with permessage-deflate for clients that offer it (default: false)")
+	wsCompressionLevel := fs.Int("ws-compression-level", server.DefaultCompressionLevel, "Deflate level for compressed WebSocket messages, 1 (fastest) to 9 (smallest) (default: 1)")
+	loggerStaleAfter := fs.Duration("logger-stale-after", 60*time.Second, "Report the logger as stale if its heartbeat is older than this (default: 60s)")
+	publicStatus := fs.Bool("public-status", false, "Serve an unauthenticated /status page with coarse system health: market session, tickers logged, feed freshness, and recent incidents (default: false)")
+	rollupCacheEntries := fs.Int("rollup-cache-entries", 5000, "Maximum ticker-days held in the rollup/availability cache, 0 for unlimited (default: 5000)")
+	historyCacheEntries := fs.Int("history-cache-entries", 200, "Maximum ticker-days of /analyze history (every resolution) held in memory, 0 for unlimited (default: 200)")
+	historySpillDir := fs.String("history-spill-dir", "", "Directory ticker-days evicted from the history cache are written to and reloaded from while their log file is unchanged (default: disabled)")
+	advDays := fs.Int("adv-days", server.DefaultBaselineDays, "Trading days of log files averaged into each ticker's average daily volume for relative flow (default: 20)")
+	normalDays := fs.Int("normal-days", server.DefaultBaselineDays, "Trading days of log files averaged into each time of day's normal premium for the summaries' vs_normal comparison, 0 disables (default: 20)")
+	normalCacheEntries := fs.Int("normal-cache-entries", 2000, "Maximum normal-premium baselines (ticker + date + bucketing options) held in memory, 0 for unlimited (default: 2000)")
+	timespan := fs.String("timespan", analysis.TimespanSecond, "Timespan of the logged aggregates, used to decide when a live period is complete: second or minute (default: second)")
+	lateGrace := fs.Duration("late-grace", analysis.DefaultLateGrace, "How long after a live period completes a late aggregate may still correct it; later aggregates are dropped (default: 5m)")
+	maxStreamStates := fs.Int("max-stream-states", 500, "Maximum live streams (ticker + bucketing options) tracked in memory before the least recently updated is evicted, 0 for unlimited (default: 500)")
+	backfillVendor := fs.String("backfill-vendor", "", "Market-data vendor used to reconstruct past dates with no local data on request: massive or stub (default: disabled)")
+	backfillTimespan := fs.String("backfill-timespan", analysis.TimespanSecond, "Aggregate timespan for backfilled data: second or minute (default: second)")
+	backfillMaxJobs := fs.Int("backfill-max-jobs", 2, "Backfills allowed to run at once (default: 2)")
+	backfillMaxAgeDays := fs.Int("backfill-max-age-days", 30, "Oldest date that may be backfilled, in days before today (default: 30)")
+	backfillWorkers := fs.Int("backfill-workers", 10, "Concurrent contract fetches per backfill (default: 10)")
+	backfillTimeout := fs.Duration("backfill-timeout", 10*time.Minute, "Upper bound on a single backfill (default: 10m)")
+	spotVendor := fs.String("spot-vendor", "", "Market-data vendor for underlying prices used by /correlation, /transactions moneyness, and /analyze max_moneyness: massive or stub (default: disabled)")
+	spotRefresh := fs.Duration("spot-refresh", 30*time.Second, "How long a spot price fetched for live max_moneyness streams is reused before it is refreshed (default: 30s)")
+	correlationCacheEntries := fs.Int("correlation-cache-entries", 2000, "Maximum ticker-days of flow samples held in the /correlation cache, 0 for unlimited (default: 2000)")
+	oiVendor := fs.String("oi-vendor", "", "Market-data vendor for open interest added to /transactions?aggregate=contract: massive or stub (default: disabled)")
+	oiDir := fs.String("oi-dir", "", "Directory open interest snapshots are saved to and read from (default: <log-dir>/open-interest)")
+	outliersDir := fs.String("outliers-dir", "", "Directory the logger writes detected premium outliers to, served by /outliers/feed (default: <log-dir>/outliers)")
+	earningsFile := fs.String("earnings-file", "", "JSON file of upcoming earnings dates per ticker for the calendar feed (default: none)")
+	calendarDays := fs.Int("calendar-days", 60, "How many days ahead the calendar feed lists expirations and earnings (default: 60)")
+	notificationsURL := fs.String("notifications-url", "", "Internal API URL of the notifications service (its --internal-addr), e.g. http://localhost:8090, to push saved configs and devices to immediately; requires INTERNAL_API_SECRET (default: disabled)")
+	usageDir := fs.String("usage-dir", "./usage", "Per-user usage directory for /me/usage, shared with the notifications service's --usage-dir (default: ./usage)")
+	tickerGroups := fs.String("ticker-groups", "", "Named ticker groups streamed and summarized as one combined series, e.g. \"SEMI=NVDA,AMD,AVGO;BANKS=JPM,BAC\" (default: none; inline lists such as ticker=NVDA,AMD work without it)")
+	demoTickers := fs.String("demo-tickers", "", "Comma-separated tickers anonymous clients may stream on /analyze without a session token (default: disabled)")
+	demoConnectionsPerIP := fs.Int("demo-connections-per-ip", server.DefaultDemoConnectionsPerIP, "Anonymous demo connections open at once per client address (default: 1)")
+	demoConnectsPerMinute := fs.Int("demo-connects-per-minute", server.DefaultDemoConnectsPerMinute, "Anonymous demo connections opened per minute per client address (default: 5)")
+	demoMaxDuration := fs.Duration("demo-max-duration", server.DefaultDemoMaxDuration, "How long an anonymous demo connection stays open before it is closed as expired (default: 10m)")
+	trustedProxies := fs.String("trusted-proxies", "", "Comma-separated addresses or CIDR ranges of reverse proxies whose X-Forwarded-For header identifies demo clients (default: none, demo limits use the connection's remote address)")
+	diagAddr := fs.String("diag-addr", "", "Bind address for the pprof/expvar diagnostics listener, e.g. localhost:6060 (default: disabled)")
+	timezone := app.TimezoneFlag(fs)
+	mergeIndexRoots := app.IndexRootsFlag(fs)
+	metrics := app.MetricsFlag(fs)
+	simClock := app.ClockFlags(fs)
+	fs.Parse(args)
+	if err := config.ApplyFlagEnv(fs); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
+	if err := market.SetAnalysisTimezone(*timezone); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	analysis.SetIndexRootMerging(*mergeIndexRoots)
+	if err := app.EnableSimulatedClock(simClock); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if err := analysis.EnablePeriodMetrics(*metrics); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if err := analysis.ValidateAnchor(*defaultAnchor); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if err := analysis.ValidateTimespan(*timespan); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if *lateGrace < 0 {
+		log.Fatalf("Error: --late-grace must not be negative")
+	}
+	if err := server.ValidateCompressionLevel(*wsCompressionLevel); err != nil {
+		log.Fatalf("Invalid --ws-compression-level: %v", err)
+	}
+	app.StartDiagnostics(*diagAddr)
+
+	// Session labels and trading-day checks use the built-in trading days; extend them daily as years roll over
+	market.StartTradingDaysRefresh(24 * time.Hour)
+
+	if *loggerStatusFile == "" {
+		*loggerStatusFile = filepath.Join(*logDir, logger.StatusFileName)
+	}
+	if *outliersDir == "" {
+		*outliersDir = filepath.Join(*logDir, logger.OutliersDirName)
+	}
+
+	// Load authentication configuration
+	authConfig, err := config.LoadAuth()
+	if err != nil {
+		log.Fatalf("Failed to load auth configuration: %v", err)
+	}
+
+	// Encrypt per-user devices and notifications files at rest (optional)
+	if err := app.EnableUserDataEncryption(); err != nil {
+		log.Fatalf("Failed to enable user data encryption: %v", err)
+	}
+
+	// Push saved notification configs and devices to the notifications service (optional)
+	// Without it the notifications service picks changes up on its next periodic reload
+	var notificationsSync *notifications.SyncClient
+	if *notificationsURL != "" {
+		secret, err := config.LoadInternalSecret()
+		if err != nil {
+			log.Fatalf("Failed to load internal API configuration: %v", err)
+		}
+		notificationsSync = notifications.NewSyncClient(*notificationsURL, secret)
+		log.Printf("Pushing notification configs and devices to %s", *notificationsURL)
+	}
+
+	// Meter each user's API calls and stream time for /me/usage; notifications received are metered by the notifications service
+	usage := metering.NewMeter(*usageDir, metering.SourceServer, metering.DefaultRetentionDays)
+	usage.Start(time.Minute)
+
+	// Create WebSocket upgrader (negotiates subprotocols and checks origins)
+	var origins []string
+	if *allowedOrigins != "" {
+		origins = strings.Split(*allowedOrigins, ",")
+	}
+	upgrader := server.NewUpgrader(origins, *wsCompression)
+	if *wsCompression {
+		log.Printf("WebSocket compression enabled (level %d)", *wsCompressionLevel)
+	}
+
+	// Create WebSocket server
+	wsServer := server.NewServer()
+	if err := wsServer.SetDuplicatePolicy(*duplicatePolicy, *maxPerUserTicker); err != nil {
+		log.Fatalf("Invalid --duplicate-connections: %v", err)
+	}
+	wsServer.SetLagResend(*lagResendAfter)
+	go wsServer.Run()
+
+	// Combined series: a ticker parameter naming a group (or listing several tickers) merges those underlyings' logs
+	groups, err := server.ParseTickerGroups(*tickerGroups)
+	if err != nil {
+		log.Fatalf("Invalid --ticker-groups: %v", err)
+	}
+	if names := groups.Names(); len(names) > 0 {
+		log.Printf("Ticker groups: %s", strings.Join(names, ", "))
+	}
+
+	// Anonymous read-only /analyze access for a few tickers, so the app can offer a preview before sign-in (optional)
+	var demo *server.Demo
+	if *demoTickers != "" {
+		tickers := strings.Split(*demoTickers, ",")
+		for i, ticker := range tickers {
+			tickers[i] = strings.ToUpper(strings.TrimSpace(ticker))
+			if err := server.ValidateTicker(tickers[i]); err != nil {
+				log.Fatalf("Invalid --demo-tickers: %v", err)
+			}
+		}
+		demo = server.NewDemo(tickers, server.DemoLimits{
+			ConnectionsPerIP:  *demoConnectionsPerIP,
+			ConnectsPerMinute: *demoConnectsPerMinute,
+			MaxDuration:       *demoMaxDuration,
+		})
+		proxies, err := server.ParseTrustedProxies(*trustedProxies)
+		if err != nil {
+			log.Fatalf("Invalid --trusted-proxies: %v", err)
+		}
+		demo.SetTrustedProxies(proxies)
+		if len(proxies) > 0 {
+			log.Printf("Demo limits use X-Forwarded-For from trusted proxies: %s", *trustedProxies)
+		}
+		log.Printf("Demo mode enabled for %s (%d connections per address, %d per minute, %v each)", strings.Join(demo.Tickers(), ","), *demoConnectionsPerIP, *demoConnectsPerMinute, *demoMaxDuration)
+	}
+
+	// Public endpoints get their own mux so nothing registered on http.DefaultServeMux
+	// (such as the pprof handlers behind --diag-addr) is exposed on this port
+	mux := http.NewServeMux()
+
+	// Create backfiller for past dates with no local data (optional)
+	var backfiller *server.Backfiller
+	if *backfillVendor != "" {
+		if err := marketdata.ValidateVendor(*backfillVendor); err != nil {
+			log.Fatalf("Invalid --backfill-vendor: %v", err)
+		}
+		var apiKey string
+		if *backfillVendor != marketdata.VendorStub {
+			cfg, err := config.Load()
+			if err != nil {
+				log.Fatalf("Failed to load configuration: %v", err)
+			}
+			apiKey = cfg.APIKey
+		}
+		history, err := marketdata.NewHistorySource(*backfillVendor, apiKey, *backfillTimespan)
+		if err != nil {
+			log.Fatalf("Failed to create backfill source: %v", err)
+		}
+		// Files are dated in the analysis timezone, matching the logger and the server's default dates
+		backfiller = server.NewBackfiller(*logDir, history, server.BackfillConfig{
+			MaxJobs:    *backfillMaxJobs,
+			MaxAgeDays: *backfillMaxAgeDays,
+			Workers:    *backfillWorkers,
+			Timeout:    *backfillTimeout,
+		}, market.AnalysisLocation())
+		log.Printf("Backfill enabled using %s (max %d jobs, %d days)", *backfillVendor, *backfillMaxJobs, *backfillMaxAgeDays)
+	}
+
+	// Create correlation analyzer and moneyness tagging from underlying prices (optional)
+	var correlations *server.CorrelationAnalyzer
+	var spot marketdata.SpotSource
+	if *spotVendor != "" {
+		if err := marketdata.ValidateVendor(*spotVendor); err != nil {
+			log.Fatalf("Invalid --spot-vendor: %v", err)
+		}
+		var apiKey string
+		if *spotVendor != marketdata.VendorStub {
+			cfg, err := config.Load()
+			if err != nil {
+				log.Fatalf("Failed to load configuration: %v", err)
+			}
+			apiKey = cfg.APIKey
+		}
+		spot, err = marketdata.NewSpotSource(*spotVendor, apiKey)
+		if err != nil {
+			log.Fatalf("Failed to create spot source: %v", err)
+		}
+		correlations = server.NewCorrelationAnalyzer(*logDir, spot, *correlationCacheEntries)
+		log.Printf("Flow correlation enabled using %s prices", *spotVendor)
+	}
+
+	// Open interest snapshots for per-contract volume/OI ratios (optional)
+	var openInterest *marketdata.OpenInterestCache
+	if *oiVendor != "" {
+		if err := marketdata.ValidateVendor(*oiVendor); err != nil {
+			log.Fatalf("Invalid --oi-vendor: %v", err)
+		}
+		var apiKey string
+		if *oiVendor != marketdata.VendorStub {
+			cfg, err := config.Load()
+			if err != nil {
+				log.Fatalf("Failed to load configuration: %v", err)
+			}
+			apiKey = cfg.APIKey
+		}
+		source, err := marketdata.NewOpenInterestSource(*oiVendor, apiKey)
+		if err != nil {
+			log.Fatalf("Failed to create open interest source: %v", err)
+		}
+		if *oiDir == "" {
+			*oiDir = filepath.Join(*logDir, "open-interest")
+		}
+		openInterest = marketdata.NewOpenInterestCache(source, *oiDir)
+		log.Printf("Open interest enabled using %s (snapshots: %s)", *oiVendor, *oiDir)
+	}
+
+	// History is computed at every resolution clients may pick in one pass over the log file
+	historyCache := server.NewHistoryCacheWithLimit(*logDir, append([]int{*period}, analysis.MultiPeriodMinutes...), *historyCacheEntries)
+	if *historySpillDir != "" {
+		if err := historyCache.EnableSpill(*historySpillDir); err != nil {
+			log.Fatalf("Invalid --history-spill-dir: %v", err)
+		}
+		log.Printf("Spilling evicted history to %s", *historySpillDir)
+	}
+	// Underlying prices let /analyze limit strikes to near spot; live updates use the latest price, cached for --spot-refresh
+	var liveSpot marketdata.SpotSource
+	if spot != nil {
+		historyCache.EnableSpot(spot)
+		liveSpot = marketdata.NewCachedSpot(spot, *spotRefresh)
+	}
+
+	// Daily totals back /rollups, /availability, and the average daily volume that summaries' relative flow is measured against
+	rollupCache := server.NewRollupCacheWithLimit(*logDir, *rollupCacheEntries)
+	baselines := server.NewVolumeBaselines(rollupCache, *advDays)
+
+	// Each time of day's normal premium over the trailing days, which summaries are compared with (optional)
+	var normals *server.PremiumBaselines
+	if *normalDays > 0 {
+		normals = server.NewPremiumBaselines(*logDir, *normalDays, *normalCacheEntries)
+	}
+	// Upcoming expirations listed by the calendar feed
+	expirationCache := server.NewExpirationCache(*logDir, *rollupCacheEntries)
+
+	// Handlers are built from the settings and shared state they use
+	d := &handlerDeps{
+		cfg: serverConfig{
+			logDir:             *logDir,
+			notificationsDir:   *notificationsDir,
+			devicesDir:         *devicesDir,
+			period:             *period,
+			defaultAnchor:      *defaultAnchor,
+			host:               *host,
+			port:               *port,
+			loggerStatusFile:   *loggerStatusFile,
+			loggerStaleAfter:   *loggerStaleAfter,
+			tokenExpiryWarning: *tokenExpiryWarning,
+			wsCompression:      *wsCompression,
+			wsCompressionLevel: *wsCompressionLevel,
+			importMaxBytes:     *importMaxBytes,
+			outliersDir:        *outliersDir,
+			earningsFile:       *earningsFile,
+			calendarDays:       *calendarDays,
+			timespan:           *timespan,
+			lateGrace:          *lateGrace,
+			maxStreamStates:    *maxStreamStates,
+		},
+		authConfig:        authConfig,
+		wsServer:          wsServer,
+		upgrader:          upgrader,
+		groups:            groups,
+		demo:              demo,
+		backfiller:        backfiller,
+		correlations:      correlations,
+		spot:              spot,
+		liveSpot:          liveSpot,
+		openInterest:      openInterest,
+		historyCache:      historyCache,
+		rollupCache:       rollupCache,
+		baselines:         baselines,
+		normals:           normals,
+		expirationCache:   expirationCache,
+		downloads:         server.NewDownloadLimiter(*downloadRate),
+		usage:             usage,
+		notificationsSync: notificationsSync,
+	}
+	wsServer.SetSubscriptionLoader(newSubscriptionLoader(d))
+
+	mux.Handle("/auth/register", auth.JWTMiddleware(authConfig.JWTSecret, newRegisterHandler(d)))
+	mux.HandleFunc("/auth/login", newLoginHandler(d))
+	mux.HandleFunc("/analyze", newAnalyzeHandler(d))
+	mux.Handle("/transactions", auth.JWTMiddleware(authConfig.JWTSecret, newTransactionsHandler(d)))
+	mux.Handle("/summaries", auth.JWTMiddleware(authConfig.JWTSecret, newSummariesHandler(d)))
+	mux.Handle("/snapshot", auth.JWTMiddleware(authConfig.JWTSecret, newSnapshotHandler(d)))
+	mux.Handle("/rollups", auth.JWTMiddleware(authConfig.JWTSecret, newRollupsHandler(d)))
+	mux.Handle("/availability", auth.JWTMiddleware(authConfig.JWTSecret, newAvailabilityHandler(d)))
+	mux.Handle("/walls", auth.JWTMiddleware(authConfig.JWTSecret, newWallsHandler(d)))
+	mux.Handle("/strikes", auth.JWTMiddleware(authConfig.JWTSecret, newStrikesHandler(d)))
+	mux.Handle("/chain", auth.JWTMiddleware(authConfig.JWTSecret, newChainHandler(d)))
+	mux.Handle("/daily", auth.JWTMiddleware(authConfig.JWTSecret, newDailyHandler(d)))
+	mux.Handle("/correlation", auth.JWTMiddleware(authConfig.JWTSecret, newCorrelationHandler(d)))
+	mux.Handle("/outliers/feed", auth.JWTMiddleware(authConfig.JWTSecret, newOutliersFeedHandler(d)))
+	mux.Handle("/download", auth.RequireScope(authConfig.JWTSecret, auth.ScopeDownload, newDownloadHandler(d)))
+	mux.Handle("/import", auth.RequireScope(authConfig.JWTSecret, auth.ScopeAdmin, newImportHandler(d)))
+	mux.Handle("/notifications", auth.JWTMiddleware(authConfig.JWTSecret, newNotificationsHandler(d)))
+	mux.Handle("/notifications/shares", auth.JWTMiddleware(authConfig.JWTSecret, newSharesHandler(d)))
+	mux.Handle("/notifications/shares/accept", auth.JWTMiddleware(authConfig.JWTSecret, newAcceptShareHandler(d)))
+	mux.Handle("/notifications/session-summary", auth.JWTMiddleware(authConfig.JWTSecret, newSessionSummaryHandler(d)))
+	mux.Handle("/notifications/report", auth.JWTMiddleware(authConfig.JWTSecret, newNotificationReportHandler(d)))
+	mux.Handle("/calendar/url", auth.JWTMiddleware(authConfig.JWTSecret, newCalendarURLHandler(d)))
+	mux.HandleFunc("/calendar.ics", newCalendarFeedHandler(d))
+	mux.HandleFunc("/healthz", newHealthzHandler(d))
+	mux.Handle("/me/usage", auth.JWTMiddleware(authConfig.JWTSecret, newMeUsageHandler(d)))
+
+	// Public status page (no JWT required, only with --public-status)
+	// Health comes from the logger heartbeat, checked in the background so page views never touch the file
+	if *publicStatus {
+		statusMonitor := server.NewStatusMonitor(*logDir, *loggerStatusFile, *loggerStaleAfter)
+		go statusMonitor.Run(server.DefaultStatusCheckInterval)
+		mux.HandleFunc("/status", newStatusPageHandler(statusMonitor))
+		log.Printf("Public status page enabled at /status")
+	}
+
+	mux.HandleFunc("/", newRootHandler(d))
+
+	// Push live updates to subscribed streams as aggregates are appended to the log files
+	streams := newStreamWatcher(d)
+	// Create file watcher
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Fatalf("Failed to create file watcher: %v", err)
+	}
+	defer watcher.Close()
+
+	// Watch the log directory
+	if err := watcher.Add(*logDir); err != nil {
+		log.Fatalf("Failed to watch log directory: %v", err)
+	}
+	go streams.watch(watcher)
+	go streams.cleanup(30 * time.Second)
+
+	// Per-connection and cache statistics, also published on the diagnostics listener's /debug/vars
+	mux.Handle("/admin/stats", auth.RequireScope(authConfig.JWTSecret, auth.ScopeAdmin, newStatsHandler(d, streams)))
+	expvar.Publish("server_stats", expvar.Func(func() interface{} {
+		return d.collectStats(streams)
+	}))
+
+	// Start HTTP server
+	addr := fmt.Sprintf("%s:%s", *host, *port)
+	log.Printf("Starting server on %s", addr)
+	log.Printf("WebSocket endpoint: ws://%s/analyze", addr)
+	log.Printf("Transactions endpoint: http://%s/transactions?ticker=SYMBOL&date=YYYY-MM-DD&time=HH:MM&period=N", addr)
+	log.Printf("Auth endpoints: http://%s/v1/auth/login and http://%s/v1/auth/register", addr, addr)
+	// Every endpoint is also served under /v1; unversioned paths stay for clients built before versioning
+	httpServer := &http.Server{Addr: addr, Handler: server.VersionedAPI(meterRequests(mux, usage, authConfig.JWTSecret))}
+
+	// Handle interrupt signal: tell WebSocket clients we're going away before stopping the listener
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-sigChan
+		log.Printf("Shutting down server...")
+		wsServer.Shutdown("server shutting down")
+		if err := usage.Flush(); err != nil {
+			log.Printf("Error saving usage: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(ctx); err != nil {
+			log.Printf("Error shutting down HTTP server: %v", err)
+		}
+	}()
+
+	// Bytes written to each connection are counted for the wire sizes in /admin/stats and the history logs
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := httpServer.Serve(server.CountingListener(listener)); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
+
+// meterRequests counts each authenticated request against its user and route
+// Routes come from the mux's registered patterns, so unknown paths can't grow a user's usage file
+func meterRequests(mux *http.ServeMux, usage *metering.Meter, jwtSecret string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2); len(parts) == 2 && parts[0] == "Bearer" {
+			if _, pattern := mux.Handler(r); pattern != "" && pattern != "/" {
+				if sub, _, err := auth.ValidateSessionToken(parts[1], jwtSecret); err == nil {
+					usage.RecordAPICall(sub, pattern)
+				}
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// anchorParam returns the request's anchor query parameter, or fallback when it is unset
+func anchorParam(r *http.Request, fallback string) string {
+	if anchor := r.URL.Query().Get("anchor"); anchor != "" {
+		return anchor
+	}
+	return fallback
+}
+
+// anchorName returns a display name for a period anchor
+func anchorName(anchor string) string {
+	if anchor == "" {
+		return analysis.AnchorMidnight
+	}
+	return anchor
+}
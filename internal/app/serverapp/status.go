@@ -0,0 +1,153 @@
+package serverapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/auth"
+	"github.com/ekinolik/jax-ov/internal/logger"
+	"github.com/ekinolik/jax-ov/internal/metering"
+	"github.com/ekinolik/jax-ov/internal/server"
+)
+
+// newHealthzHandler handles the /healthz health check (no JWT required)
+// Reports "degraded" with HTTP 503 when the logger heartbeat is missing or stale, or the logger is shedding data
+// or its writes were slow or failed since its previous heartbeat
+func newHealthzHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := "ok"
+		httpStatus := http.StatusOK
+		response := map[string]interface{}{}
+
+		loggerStatus, err := logger.ReadStatusFile(d.cfg.loggerStatusFile)
+		if err != nil {
+			status = "degraded"
+			httpStatus = http.StatusServiceUnavailable
+			response["logger_error"] = err.Error()
+		} else {
+			age := loggerStatus.Age(time.Now())
+			stale := age > d.cfg.loggerStaleAfter
+			if stale {
+				status = "degraded"
+				httpStatus = http.StatusServiceUnavailable
+			}
+			// A logger shedding data to save disk space is up but incomplete
+			if loggerStatus.Disk != nil && loggerStatus.Disk.Degraded() {
+				status = "degraded"
+				httpStatus = http.StatusServiceUnavailable
+			}
+			// Slow or failing appends warn of a disk or network filesystem problem before data is lost
+			if loggerStatus.Writes != nil && loggerStatus.Writes.Degraded() {
+				status = "degraded"
+				httpStatus = http.StatusServiceUnavailable
+			}
+			response["logger"] = loggerStatus
+			response["logger_age_seconds"] = int(age.Seconds())
+			response["logger_stale"] = stale
+		}
+		response["status"] = status
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(httpStatus)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("Error encoding response: %v", err)
+		}
+	}
+}
+
+// newRootHandler serves the root page, which lists the main endpoints
+func newRootHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><body><h1>Options Analysis WebSocket Server</h1><p>Connect to ws://` + d.cfg.host + `:` + d.cfg.port + `/analyze?ticker=SYMBOL&date=YYYY-MM-DD</p><p>Get transactions: GET http://` + d.cfg.host + `:` + d.cfg.port + `/transactions?ticker=SYMBOL&date=YYYY-MM-DD&time=HH:MM&period=N</p></body></html>`))
+		}
+	}
+}
+
+// newStatsHandler handles GET /admin/stats, per-connection and cache statistics (requires the admin scope)
+func newStatsHandler(d *handlerDeps, streams *streamWatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		stats := d.collectStats(streams)
+
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(stats); err != nil {
+			log.Printf("Error encoding JSON: %v", err)
+		}
+	}
+}
+
+// newMeUsageHandler handles GET /me/usage, the caller's own API calls, stream minutes and notifications received
+func newMeUsageHandler(d *handlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+		sub, _, err := auth.ValidateSessionToken(parts[1], d.authConfig.JWTSecret)
+		if err != nil {
+			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+
+		days := 7
+		if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+			days, err = strconv.Atoi(daysStr)
+			if err != nil || days <= 0 || days > metering.DefaultRetentionDays {
+				http.Error(w, fmt.Sprintf("invalid days, must be between 1 and %d", metering.DefaultRetentionDays), http.StatusBadRequest)
+				return
+			}
+		}
+
+		daily, err := d.usage.Usage(sub, days)
+		if err != nil {
+			log.Printf("Error loading usage for user %s: %v", sub, err)
+			http.Error(w, "Failed to load usage", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(metering.NewReport(sub, daily)); err != nil {
+			log.Printf("Error encoding JSON: %v", err)
+		}
+	}
+}
+
+// newStatusPageHandler serves the public /status page (no JWT required), from the health monitor checks in the background
+func newStatusPageHandler(monitor *server.StatusMonitor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		report := monitor.Report(time.Now())
+		w.Header().Set("Cache-Control", "no-cache")
+		if r.URL.Query().Get("format") == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(report); err != nil {
+				log.Printf("Error encoding response: %v", err)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := server.WriteStatusPage(w, report); err != nil {
+			log.Printf("Error writing status page: %v", err)
+		}
+	}
+}
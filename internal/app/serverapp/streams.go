@@ -0,0 +1,314 @@
+package serverapp
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/clock"
+	"github.com/ekinolik/jax-ov/internal/latency"
+	"github.com/ekinolik/jax-ov/internal/market"
+	"github.com/ekinolik/jax-ov/internal/server"
+	"github.com/fsnotify/fsnotify"
+)
+
+// streamState tracks the state for each stream (ticker + bucketing options) being monitored
+type streamState struct {
+	Group         server.TickerGroup              // Underlyings merged into the stream (just the ticker for most streams)
+	FilePositions map[string]int64                // Position of last complete line read in each watched log file
+	Aggregator    *analysis.IncrementalAggregator // The day's periods, updated as aggregates are appended to the log
+	Walls         *analysis.WallTracker           // Strike premiums for the day, used to keep the current period's walls cumulative
+	Anomalies     *analysis.AnomalyTracker        // Recent periods' premium, used to score the current period against its baseline
+	Positions     *analysis.PositionTracker       // Per-period contract premiums, used to flag contracts building positions
+	Baseline      *analysis.VolumeBaseline        // Average daily volume before the day, for the periods' relative flow (nil if unknown)
+	Normal        *analysis.TimeOfDayBaseline     // Each time of day's normal premium before the day, for vs_normal (nil if unknown or disabled)
+	WatchedFiles  []string                        // Paths to the log files being watched, one per underlying
+	mu            sync.Mutex                      // Mutex for thread-safe access
+}
+
+// streamWatcher follows the log directory and pushes updates to the streams clients are subscribed to
+// States are kept in an LRU so a server monitoring many tickers stays bounded; an evicted stream
+// is rebuilt from its log file the next time it is written to
+type streamWatcher struct {
+	d      *handlerDeps
+	mu     sync.RWMutex
+	states *server.LRU[server.StreamKey, *streamState]
+}
+
+// newStreamWatcher creates a stream watcher holding at most --max-stream-states streams
+func newStreamWatcher(d *handlerDeps) *streamWatcher {
+	return &streamWatcher{
+		d: d,
+		states: server.NewLRU(d.cfg.maxStreamStates, func(key server.StreamKey, state *streamState) {
+			log.Printf("Evicted idle stream state for ticker %s (anchor: %s): %s", key.Ticker, anchorName(key.Options.Anchor), strings.Join(state.WatchedFiles, ", "))
+		}),
+	}
+}
+
+// CacheStats reports the stream states held in memory
+func (sw *streamWatcher) CacheStats() server.CacheStats {
+	sw.mu.RLock()
+	defer sw.mu.RUnlock()
+	return sw.states.Stats()
+}
+
+// newAggregator creates a stream's live aggregator, correcting completed periods within the late-data grace window
+func (sw *streamWatcher) newAggregator(opts analysis.AggregateOptions) *analysis.IncrementalAggregator {
+	aggregator := analysis.NewIncrementalAggregator(opts, sw.d.cfg.timespan)
+	aggregator.SetLateGrace(sw.d.cfg.lateGrace)
+	return aggregator
+}
+
+// state gets or creates a stream's state
+func (sw *streamWatcher) state(key server.StreamKey, dateStr string) *streamState {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	d := sw.d
+	state, exists := sw.states.Get(key)
+	if !exists {
+		// Initialize state; stream keys hold a resolved group name, so this only fails for groups removed since
+		group, err := d.groups.Resolve(key.Ticker)
+		if err != nil {
+			group = server.TickerGroup{Name: key.Ticker, Members: []string{key.Ticker}}
+		}
+		var logFiles []string
+		for _, member := range group.Members {
+			logFiles = append(logFiles, server.GetLogFileForTickerAndDate(d.cfg.logDir, member, dateStr))
+		}
+		state = &streamState{
+			Group:         group,
+			FilePositions: make(map[string]int64),
+			Aggregator:    sw.newAggregator(key.Options),
+			Walls:         analysis.NewWallTracker(),
+			Anomalies:     analysis.NewAnomalyTracker(analysis.DefaultAnomalyWindow),
+			Positions:     analysis.NewPositionTracker(),
+			WatchedFiles:  logFiles,
+		}
+		sw.states.Add(key, state)
+		log.Printf("Started monitoring log file for ticker %s (anchor: %s): %s", key.Ticker, anchorName(key.Options.Anchor), strings.Join(logFiles, ", "))
+
+		// Do initial load to establish baseline
+		// Combined streams are merged from their members' logs and have no walls or volume and premium baselines
+		go func() {
+			var summaries []analysis.TimePeriodSummary
+			var err error
+			if group.Combined() {
+				summaries, err = server.AnalyzeGroupAndDate(d.cfg.logDir, group, dateStr, key.Options, time.Time{})
+			} else {
+				summaries, err = d.historyCache.Summaries(key.Ticker, dateStr, key.Options)
+			}
+			if err != nil {
+				log.Printf("Error in initial load for ticker %s: %v", key.Ticker, err)
+				return
+			}
+			positions, err := server.ReadPositionsForGroupAndDate(d.cfg.logDir, group, dateStr, key.Options, d.spot)
+			if err != nil {
+				log.Printf("Error loading contract premiums for ticker %s: %v", key.Ticker, err)
+				positions = analysis.NewPositionTracker()
+			}
+			walls := analysis.NewWallTracker()
+			var baseline *analysis.VolumeBaseline
+			var normal *analysis.TimeOfDayBaseline
+			if !group.Combined() {
+				if walls, err = server.ReadWallsForTickerAndDate(d.cfg.logDir, key.Ticker, dateStr, key.Options, d.spot); err != nil {
+					log.Printf("Error loading walls for ticker %s: %v", key.Ticker, err)
+					walls = analysis.NewWallTracker()
+				}
+				if baseline, err = d.baselines.Baseline(key.Ticker, dateStr); err != nil {
+					log.Printf("Error loading average daily volume for ticker %s: %v", key.Ticker, err)
+				}
+				if d.normals != nil {
+					if normal, err = d.normals.Baseline(key.Ticker, dateStr, key.Options); err != nil {
+						log.Printf("Error loading normal premium for ticker %s: %v", key.Ticker, err)
+					}
+				}
+			}
+
+			state.mu.Lock()
+			defer state.mu.Unlock()
+
+			// Get file sizes to set last positions
+			for _, logFile := range logFiles {
+				if fileInfo, err := os.Stat(logFile); err == nil {
+					state.FilePositions[logFile] = fileInfo.Size()
+				}
+			}
+			state.Walls = walls
+			state.Positions = positions
+			state.Baseline = baseline
+			state.Normal = normal
+			state.Aggregator = sw.newAggregator(key.Options)
+			state.Aggregator.Restore(summaries, clock.Now())
+			state.Anomalies = analysis.NewAnomalyTracker(analysis.DefaultAnomalyWindow)
+			for _, summary := range summaries {
+				state.Anomalies.Record(summary)
+			}
+		}()
+	}
+	return state
+}
+
+// process reads new aggregates from a ticker's log file (one of the members' for combined streams) and pushes
+// updates for one stream
+func (sw *streamWatcher) process(key server.StreamKey, filePath string, dateStr string) {
+	d := sw.d
+	state := sw.state(key, dateStr)
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	lastPosition := state.FilePositions[filePath]
+	aggregates, newPosition, err := server.ReadLogFileIncremental(filePath, lastPosition)
+	if err != nil {
+		log.Printf("Error reading incremental data for ticker %s: %v", key.Ticker, err)
+		return
+	}
+
+	if len(aggregates) == 0 {
+		// No new complete lines
+		return
+	}
+
+	// Aggregates read from the start of the file are the day's backlog, which doesn't count toward latency
+	if lastPosition > 0 {
+		for _, agg := range aggregates {
+			d.wsServer.Latency().Record(latency.StageRead, agg.IngestTime())
+		}
+	}
+
+	// Update file position
+	state.FilePositions[filePath] = newPosition
+
+	// Streams limited to strikes near spot measure new aggregates against the latest spot price
+	if key.Options.MaxMoneyness > 0 && d.liveSpot != nil {
+		price, err := d.liveSpot.LastPrice(context.Background(), key.Ticker)
+		if err != nil {
+			log.Printf("Error fetching spot price for ticker %s: %v", key.Ticker, err)
+		} else {
+			analysis.TagMoneynessAtSpot(aggregates, price)
+		}
+	}
+
+	// Process aggregates
+	combined := state.Group.Combined()
+	lateDropped := state.Aggregator.LateDropped()
+	for _, agg := range aggregates {
+		// Skip aggregates excluded by the stream's filters
+		if !key.Options.Includes(agg) {
+			continue
+		}
+		// Aggregates dropped by the aggregator (e.g. past the late-data grace window) don't count toward walls either
+		period := state.Aggregator.AddAggregate(agg)
+		if period == nil {
+			continue
+		}
+		if !combined {
+			state.Walls.Add(agg)
+		}
+		state.Positions.Add(period.PeriodStart.UnixMilli(), agg)
+		if period != state.Aggregator.CurrentPeriod() {
+			// Late aggregates for earlier periods are sent as revisions once those periods complete
+			continue
+		}
+		if combined {
+			period.ClearStrikeLevels()
+		} else {
+			state.Walls.Apply(period)
+		}
+		state.Anomalies.Record(*period)
+		state.Anomalies.Apply(period)
+		state.Positions.Apply(period)
+		state.Baseline.Apply(period)
+		state.Normal.Apply(period)
+		period.Change = analysis.ChangeFrom(state.Aggregator.Previous(*period), *period)
+		d.wsServer.SendUpdateForStream(key, period.Clone())
+	}
+
+	if dropped := state.Aggregator.LateDropped() - lateDropped; dropped > 0 {
+		log.Printf("Dropped %d aggregates for ticker %s that arrived after their period's --late-grace window", dropped, key.Ticker)
+	}
+
+	// Send the periods that completed, including revisions of earlier ones corrected by late aggregates
+	for _, summary := range state.Aggregator.CompletedPeriods(clock.Now()) {
+		if combined {
+			summary.ClearStrikeLevels()
+		}
+		state.Positions.Apply(&summary)
+		state.Baseline.Apply(&summary)
+		state.Normal.Apply(&summary)
+		summary.Change = analysis.ChangeFrom(state.Aggregator.Previous(summary), summary)
+		d.wsServer.SendUpdateForStream(key, summary)
+	}
+}
+
+// watch processes the watcher's write events until it is closed
+func (sw *streamWatcher) watch(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+
+			// Only process write events
+			if event.Op&fsnotify.Write == fsnotify.Write {
+				// Extract ticker from filename: SYMBOL_YYYY-MM-DD.jsonl
+				filename := filepath.Base(event.Name)
+				if !strings.HasSuffix(filename, ".jsonl") {
+					continue
+				}
+
+				// Parse ticker from filename
+				parts := strings.Split(filename, "_")
+				if len(parts) < 2 {
+					continue
+				}
+				ticker := strings.ToUpper(parts[0])
+
+				// Get current date
+				dateStr := market.Today()
+
+				// Update every stream subscribed to this ticker, including combined streams it is a member of
+				for key := range sw.d.wsServer.GetSubscribedStreams() {
+					if group, err := sw.d.groups.Resolve(key.Ticker); err == nil && group.Includes(ticker) {
+						sw.process(key, event.Name, dateStr)
+					}
+				}
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("File watcher error: %v", err)
+		}
+	}
+}
+
+// cleanup removes the states of streams no client is subscribed to anymore, every interval
+func (sw *streamWatcher) cleanup(interval time.Duration) {
+	cleanupTicker := time.NewTicker(interval)
+	defer cleanupTicker.Stop()
+
+	for range cleanupTicker.C {
+		subscribedStreams := sw.d.wsServer.GetSubscribedStreams()
+		sw.mu.Lock()
+		var unsubscribed []server.StreamKey
+		sw.states.Each(func(key server.StreamKey, state *streamState) {
+			if !subscribedStreams[key] {
+				unsubscribed = append(unsubscribed, key)
+				log.Printf("Stopped monitoring log file for ticker %s (anchor: %s): %s", key.Ticker, anchorName(key.Options.Anchor), strings.Join(state.WatchedFiles, ", "))
+			}
+		})
+		for _, key := range unsubscribed {
+			sw.states.Remove(key)
+		}
+		sw.mu.Unlock()
+	}
+}
@@ -0,0 +1,12 @@
+package app
+
+import (
+	"flag"
+)
+
+// SessionFlag registers --session on fs
+// The value is a comma-separated list of trading sessions (see market.ParseSessionSet); empty includes every session,
+// and "regular" keeps prints outside 09:30-16:00 ET out of the first and last periods of the day
+func SessionFlag(fs *flag.FlagSet) *string {
+	return fs.String("session", "", "Comma-separated trading sessions to include: premarket, regular, afterhours, closed (default: all)")
+}
@@ -0,0 +1,14 @@
+package app
+
+import (
+	"flag"
+
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// TimezoneFlag registers --timezone on fs
+// The analysis timezone dates the daily log files and aligns midnight-anchored periods, so every
+// service sharing a log directory must use the same value
+func TimezoneFlag(fs *flag.FlagSet) *string {
+	return fs.String("timezone", market.DefaultTimezone, "IANA timezone that log files are dated in and periods are aligned to (default: America/Los_Angeles)")
+}
@@ -0,0 +1,26 @@
+package app
+
+import (
+	"log"
+
+	"github.com/ekinolik/jax-ov/internal/config"
+	"github.com/ekinolik/jax-ov/internal/notifications"
+)
+
+// EnableUserDataEncryption turns on at-rest encryption of the per-user devices and notifications files
+// when a user data key is configured; without one the files are stored as plaintext
+func EnableUserDataEncryption() error {
+	key, err := config.LoadUserDataKey()
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		log.Printf("User data encryption disabled (no USER_DATA_KEY); devices and notifications are stored as plaintext")
+		return nil
+	}
+	if err := notifications.EnableEncryption(key); err != nil {
+		return err
+	}
+	log.Printf("User data encryption enabled for devices and notifications files")
+	return nil
+}
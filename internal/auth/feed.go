@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// FeedKey returns the key that authenticates a user's subscription feeds (e.g. the calendar feed)
+// Calendar apps cannot send Authorization headers, so feed URLs carry this key instead of a session token.
+// It is derived from the JWT secret and the user's feed nonce, which is stored with the user's data and replaced to
+// revoke the user's old feed URLs; an empty nonce gives the key issued before users had one
+func FeedKey(sub string, nonce string, secret string) string {
+	message := "feed:" + sub
+	if nonce != "" {
+		message += ":" + nonce
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateFeedKey reports whether key is the feed key for sub and the user's current feed nonce
+func ValidateFeedKey(sub string, nonce string, key string, secret string) bool {
+	if sub == "" || key == "" {
+		return false
+	}
+	return hmac.Equal([]byte(key), []byte(FeedKey(sub, nonce, secret)))
+}
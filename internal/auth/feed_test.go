@@ -0,0 +1,29 @@
+package auth
+
+import "testing"
+
+func TestFeedKeyRotation(t *testing.T) {
+	const sub, secret = "001234.abcd.0001", "secret"
+
+	legacy := FeedKey(sub, "", secret)
+	if !ValidateFeedKey(sub, "", legacy, secret) {
+		t.Error("key issued without a nonce rejected")
+	}
+
+	rotated := FeedKey(sub, "n1", secret)
+	if ValidateFeedKey(sub, "n1", legacy, secret) {
+		t.Error("key issued before the nonce was set still accepted")
+	}
+	if !ValidateFeedKey(sub, "n1", rotated, secret) {
+		t.Error("key for the current nonce rejected")
+	}
+	if ValidateFeedKey(sub, "n2", rotated, secret) {
+		t.Error("key for a replaced nonce still accepted")
+	}
+	if ValidateFeedKey("001234.abcd.0002", "n1", rotated, secret) {
+		t.Error("key accepted for another user")
+	}
+	if ValidateFeedKey("", "", FeedKey("", "", secret), secret) {
+		t.Error("key accepted without a user")
+	}
+}
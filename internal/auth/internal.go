@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// SharedSecretMiddleware creates HTTP middleware for service-to-service endpoints
+// Requests must carry the shared secret as a bearer token; user session tokens are not accepted
+func SharedSecretMiddleware(secret string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Authorization header required", http.StatusUnauthorized)
+			return
+		}
+
+		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+			return
+		}
+
+		// Constant-time comparison so the secret can't be guessed from response timing
+		if subtle.ConstantTimeCompare([]byte(parts[1]), []byte(secret)) != 1 {
+			http.Error(w, "Invalid service credentials", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
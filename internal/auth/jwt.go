@@ -8,14 +8,36 @@ import (
 	"github.com/google/uuid"
 )
 
+// Access scopes carried in session tokens beyond basic read access
+const (
+	ScopeDownload = "download" // Raw log file downloads (/download)
+	ScopeAdmin    = "admin"    // Administrative operations such as data imports (/import)
+)
+
 // SessionClaims represents the claims in our session JWT
 type SessionClaims struct {
 	jwt.RegisteredClaims
-	SessionID string `json:"session_id"`
+	SessionID string   `json:"session_id"`
+	Scopes    []string `json:"scopes,omitempty"`
+}
+
+// HasScope reports whether the token grants a scope
+func (c *SessionClaims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
 }
 
 // CreateSessionToken creates a JWT session token for an authenticated user
 func CreateSessionToken(sub string, secret string, expiryDuration time.Duration) (string, error) {
+	return CreateSessionTokenWithScopes(sub, secret, expiryDuration, nil)
+}
+
+// CreateSessionTokenWithScopes creates a JWT session token carrying additional access scopes
+func CreateSessionTokenWithScopes(sub string, secret string, expiryDuration time.Duration, scopes []string) (string, error) {
 	// Generate a unique session ID
 	sessionID := uuid.New().String()
 
@@ -28,6 +50,7 @@ func CreateSessionToken(sub string, secret string, expiryDuration time.Duration)
 			ExpiresAt: jwt.NewNumericDate(now.Add(expiryDuration)),
 		},
 		SessionID: sessionID,
+		Scopes:    scopes,
 	}
 
 	// Create token
@@ -44,6 +67,16 @@ func CreateSessionToken(sub string, secret string, expiryDuration time.Duration)
 
 // ValidateSessionToken validates a session JWT token and returns the user's sub and session ID
 func ValidateSessionToken(tokenString string, secret string) (string, string, error) {
+	claims, err := ParseSessionToken(tokenString, secret)
+	if err != nil {
+		return "", "", err
+	}
+	return claims.Subject, claims.SessionID, nil
+}
+
+// ParseSessionToken validates a session JWT token and returns its claims
+// Callers that need the expiry (e.g. long-lived WebSocket connections) use this instead of ValidateSessionToken
+func ParseSessionToken(tokenString string, secret string) (*SessionClaims, error) {
 	// Parse and validate the token
 	claims := &SessionClaims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
@@ -55,27 +88,27 @@ func ValidateSessionToken(tokenString string, secret string) (string, string, er
 	})
 
 	if err != nil {
-		return "", "", fmt.Errorf("failed to parse token: %w", err)
+		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
 
 	if !token.Valid {
-		return "", "", fmt.Errorf("token is not valid")
+		return nil, fmt.Errorf("token is not valid")
 	}
 
 	// Verify expiration
 	if claims.ExpiresAt != nil && claims.ExpiresAt.Time.Before(time.Now()) {
-		return "", "", fmt.Errorf("token has expired")
+		return nil, fmt.Errorf("token has expired")
 	}
 
 	// Return sub and session ID
 	if claims.Subject == "" {
-		return "", "", fmt.Errorf("missing sub claim in token")
+		return nil, fmt.Errorf("missing sub claim in token")
 	}
 
 	if claims.SessionID == "" {
-		return "", "", fmt.Errorf("missing session_id claim in token")
+		return nil, fmt.Errorf("missing session_id claim in token")
 	}
 
-	return claims.Subject, claims.SessionID, nil
+	return claims, nil
 }
 
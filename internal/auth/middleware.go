@@ -36,3 +36,34 @@ func JWTMiddleware(jwtSecret string, next http.Handler) http.Handler {
 	})
 }
 
+// RequireScope creates HTTP middleware that validates JWT tokens and requires a specific scope
+// Valid tokens without the scope are rejected with 403 Forbidden
+func RequireScope(jwtSecret string, scope string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Extract token from Authorization header
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Authorization header required", http.StatusUnauthorized)
+			return
+		}
+
+		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := ParseSessionToken(parts[1], jwtSecret)
+		if err != nil {
+			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+
+		if !claims.HasScope(scope) {
+			http.Error(w, "Token lacks required scope: "+scope, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,104 @@
+// Package calendar builds iCalendar (RFC 5545) feeds of market events such as option expirations and earnings
+package calendar
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Event is an all-day calendar event
+type Event struct {
+	UID         string // Stable identifier so calendar apps update rather than duplicate events
+	Date        time.Time
+	Summary     string
+	Description string
+}
+
+// WriteICS writes events as an iCalendar feed named name, sorted by date
+func WriteICS(w io.Writer, name string, events []Event, now time.Time) error {
+	sorted := make([]Event, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	bw := bufio.NewWriter(w)
+	writeLine(bw, "BEGIN:VCALENDAR")
+	writeLine(bw, "VERSION:2.0")
+	writeLine(bw, "PRODID:-//jax-ov//Options Calendar//EN")
+	writeLine(bw, "CALSCALE:GREGORIAN")
+	writeLine(bw, "METHOD:PUBLISH")
+	writeLine(bw, "X-WR-CALNAME:"+escapeText(name))
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, event := range sorted {
+		writeLine(bw, "BEGIN:VEVENT")
+		writeLine(bw, "UID:"+escapeText(event.UID))
+		writeLine(bw, "DTSTAMP:"+stamp)
+		writeLine(bw, "DTSTART;VALUE=DATE:"+event.Date.Format("20060102"))
+		writeLine(bw, "DTEND;VALUE=DATE:"+event.Date.AddDate(0, 0, 1).Format("20060102"))
+		writeLine(bw, "SUMMARY:"+escapeText(event.Summary))
+		if event.Description != "" {
+			writeLine(bw, "DESCRIPTION:"+escapeText(event.Description))
+		}
+		writeLine(bw, "TRANSP:TRANSPARENT")
+		writeLine(bw, "END:VEVENT")
+	}
+	writeLine(bw, "END:VCALENDAR")
+
+	return bw.Flush()
+}
+
+// writeLine writes a content line with CRLF, folding it at 75 octets as RFC 5545 requires
+// Folds never split a multi-byte UTF-8 character
+func writeLine(w *bufio.Writer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = 74 // Continuation lines start with a space
+	}
+	w.WriteString(line + "\r\n")
+}
+
+// escapeText escapes a TEXT property value
+func escapeText(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(value)
+}
+
+// LoadEarnings reads an earnings calendar file mapping tickers to report dates (YYYY-MM-DD)
+// Example: {"AAPL": ["2026-01-29", "2026-04-30"], "TSLA": ["2026-01-28"]}
+func LoadEarnings(filename string) (map[string][]time.Time, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read earnings file: %w", err)
+	}
+
+	var raw map[string][]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse earnings file: %w", err)
+	}
+
+	earnings := make(map[string][]time.Time, len(raw))
+	for ticker, dates := range raw {
+		ticker = strings.ToUpper(ticker)
+		for _, dateStr := range dates {
+			date, err := time.Parse("2006-01-02", dateStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid earnings date %q for %s: %w", dateStr, ticker, err)
+			}
+			earnings[ticker] = append(earnings[ticker], date)
+		}
+	}
+	return earnings, nil
+}
@@ -0,0 +1,238 @@
+// Package clock is the time source for the services' market-time logic: dating log files, completing periods,
+// monitoring windows, and periodic schedulers read it instead of calling time.Now directly, so a simulated clock can
+// drive the server, notifications, and mock-logger pipeline through a past or accelerated session
+// Network deadlines, pings, and token expiry stay on the wall clock
+package clock
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Clock tells the time and schedules tickers and timers against it
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) *Ticker
+	NewTimer(d time.Duration) *Timer
+}
+
+// Ticker delivers the clock's time on C every period, dropping ticks for slow receivers like time.Ticker
+type Ticker struct {
+	C    <-chan time.Time
+	stop func()
+}
+
+// Stop turns off the ticker; no more ticks are sent after it returns
+func (t *Ticker) Stop() {
+	t.stop()
+}
+
+// Timer delivers the clock's time on C once, after its duration
+type Timer struct {
+	C    <-chan time.Time
+	stop func() bool
+}
+
+// Stop prevents the timer from firing, reporting whether it was still pending
+func (t *Timer) Stop() bool {
+	return t.stop()
+}
+
+// holder wraps the current clock so it can be swapped atomically
+type holder struct {
+	clock Clock
+}
+
+// current is the clock used by the package-level functions (the wall clock unless Set is called)
+var current atomic.Pointer[holder]
+
+func init() {
+	current.Store(&holder{clock: Real()})
+}
+
+// Set replaces the clock used by the package-level functions; call it once at startup, before services read the time
+func Set(c Clock) {
+	current.Store(&holder{clock: c})
+}
+
+// Get returns the clock in use
+func Get() Clock {
+	return current.Load().clock
+}
+
+// Now returns the current time of the clock in use
+func Now() time.Time {
+	return Get().Now()
+}
+
+// Since returns the time elapsed on the clock in use since t
+func Since(t time.Time) time.Duration {
+	return Now().Sub(t)
+}
+
+// NewTicker returns a ticker on the clock in use
+func NewTicker(d time.Duration) *Ticker {
+	return Get().NewTicker(d)
+}
+
+// NewTimer returns a timer on the clock in use
+func NewTimer(d time.Duration) *Timer {
+	return Get().NewTimer(d)
+}
+
+// realClock is the wall clock
+type realClock struct{}
+
+// Real returns the wall clock
+func Real() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTicker(d time.Duration) *Ticker {
+	t := time.NewTicker(d)
+	return &Ticker{C: t.C, stop: t.Stop}
+}
+
+func (realClock) NewTimer(d time.Duration) *Timer {
+	t := time.NewTimer(d)
+	return &Timer{C: t.C, stop: t.Stop}
+}
+
+// waiter is a pending ticker or timer on a simulated clock
+type waiter struct {
+	deadline time.Time
+	period   time.Duration // 0 for timers
+	c        chan time.Time
+}
+
+// Simulated is a clock that starts at a chosen time and runs at a multiple of real time, or only moves when it is
+// advanced (speed 0), so tests and replays can drive time-dependent logic deterministically or faster than real time
+type Simulated struct {
+	mu       sync.Mutex
+	base     time.Time // Simulated time at realBase
+	realBase time.Time
+	speed    float64 // Simulated seconds per real second; 0 stops the clock between Advance and Set calls
+	waiters  map[*waiter]bool
+}
+
+// NewSimulated returns a simulated clock at start, running at speed times real time (0 to only move when advanced)
+func NewSimulated(start time.Time, speed float64) *Simulated {
+	if speed < 0 {
+		speed = 0
+	}
+	return &Simulated{base: start, realBase: time.Now(), speed: speed, waiters: make(map[*waiter]bool)}
+}
+
+// Speed returns the clock's speed as a multiple of real time (0 when it only moves when advanced)
+func (s *Simulated) Speed() float64 {
+	return s.speed
+}
+
+// now returns the simulated time; the caller holds s.mu
+func (s *Simulated) now() time.Time {
+	if s.speed == 0 {
+		return s.base
+	}
+	return s.base.Add(time.Duration(float64(time.Since(s.realBase)) * s.speed))
+}
+
+// Now returns the simulated time
+func (s *Simulated) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now()
+}
+
+// Set moves the clock to t and fires the tickers and timers due by then
+func (s *Simulated) Set(t time.Time) {
+	s.mu.Lock()
+	s.base, s.realBase = t, time.Now()
+	s.mu.Unlock()
+	s.fire()
+}
+
+// Advance moves the clock forward by d and fires the tickers and timers due by then
+func (s *Simulated) Advance(d time.Duration) {
+	s.mu.Lock()
+	s.base, s.realBase = s.now().Add(d), time.Now()
+	s.mu.Unlock()
+	s.fire()
+}
+
+// NewTicker returns a ticker firing every d of simulated time
+func (s *Simulated) NewTicker(d time.Duration) *Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	w := s.add(d, d)
+	return &Ticker{C: w.c, stop: func() { s.remove(w) }}
+}
+
+// NewTimer returns a timer firing after d of simulated time
+func (s *Simulated) NewTimer(d time.Duration) *Timer {
+	w := s.add(d, 0)
+	return &Timer{C: w.c, stop: func() bool { return s.remove(w) }}
+}
+
+// add registers a waiter due d from now, firing immediately if it is already due
+func (s *Simulated) add(d time.Duration, period time.Duration) *waiter {
+	s.mu.Lock()
+	w := &waiter{deadline: s.now().Add(d), period: period, c: make(chan time.Time, 1)}
+	s.waiters[w] = true
+	s.wake(w)
+	s.mu.Unlock()
+	if d <= 0 {
+		s.fire()
+	}
+	return w
+}
+
+// remove unregisters a waiter, reporting whether it was still registered
+func (s *Simulated) remove(w *waiter) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	registered := s.waiters[w]
+	delete(s.waiters, w)
+	return registered
+}
+
+// wake schedules a real-time check for when a running clock reaches the waiter's deadline; the caller holds s.mu
+// Stopped clocks only fire waiters from Advance and Set
+func (s *Simulated) wake(w *waiter) {
+	if s.speed == 0 {
+		return
+	}
+	delay := time.Duration(float64(w.deadline.Sub(s.now())) / s.speed)
+	time.AfterFunc(max(delay, 0), s.fire)
+}
+
+// fire sends the time to every waiter that is due, rescheduling tickers for their next period after now
+// Sends don't block: like time.Ticker, a receiver that hasn't taken the previous tick misses this one
+func (s *Simulated) fire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for w := range s.waiters {
+		if now.Before(w.deadline) {
+			continue
+		}
+		select {
+		case w.c <- now:
+		default:
+		}
+		if w.period == 0 {
+			delete(s.waiters, w)
+			continue
+		}
+		for !now.Before(w.deadline) {
+			w.deadline = w.deadline.Add(w.period)
+		}
+		s.wake(w)
+	}
+}
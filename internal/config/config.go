@@ -1,9 +1,11 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -21,6 +23,7 @@ type AuthConfig struct {
 	ApplePrivateKey string
 	JWTSecret       string
 	JWTExpiryHours  int
+	ScopeGrants     map[string][]string // Subject -> extra scopes granted at login (e.g. "download")
 }
 
 // Load loads configuration from environment variables
@@ -74,15 +77,52 @@ func LoadAuth() (*AuthConfig, error) {
 		jwtExpiryHours = expiry
 	}
 
+	// Optional scope grants, e.g. "download=sub1,sub2;admin=sub1"
+	scopeGrants, err := ParseScopeGrants(os.Getenv("AUTH_SCOPE_GRANTS"))
+	if err != nil {
+		return nil, fmt.Errorf("AUTH_SCOPE_GRANTS: %w", err)
+	}
+
 	return &AuthConfig{
 		AppleClientID:   clientID,
 		AppleTeamID:     teamID,
 		ApplePrivateKey: privateKey,
 		JWTSecret:       jwtSecret,
 		JWTExpiryHours:  jwtExpiryHours,
+		ScopeGrants:     scopeGrants,
 	}, nil
 }
 
+// ParseScopeGrants parses "scope=sub1,sub2;scope2=sub3" into a map of subject -> scopes
+func ParseScopeGrants(value string) (map[string][]string, error) {
+	grants := make(map[string][]string)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		scope, subjects, ok := strings.Cut(entry, "=")
+		scope = strings.TrimSpace(scope)
+		if !ok || scope == "" {
+			return nil, fmt.Errorf("invalid grant %q, expected scope=sub1,sub2", entry)
+		}
+
+		for _, sub := range strings.Split(subjects, ",") {
+			sub = strings.TrimSpace(sub)
+			if sub != "" {
+				grants[sub] = append(grants[sub], scope)
+			}
+		}
+	}
+	return grants, nil
+}
+
+// ScopesFor returns the scopes granted to a subject
+func (a *AuthConfig) ScopesFor(sub string) []string {
+	return a.ScopeGrants[sub]
+}
+
 // JWTExpiryDuration returns the JWT expiry as a time.Duration
 func (a *AuthConfig) JWTExpiryDuration() time.Duration {
 	return time.Duration(a.JWTExpiryHours) * time.Hour
@@ -143,3 +183,89 @@ func LoadAPNS() (*APNSConfig, error) {
 		Environment: environment,
 	}, nil
 }
+
+// SheetsConfig holds Google Sheets export configuration
+type SheetsConfig struct {
+	CredentialsFile string // Service-account key file (JSON)
+	SpreadsheetID   string
+}
+
+// LoadSheets loads Google Sheets configuration from environment variables
+func LoadSheets() (*SheetsConfig, error) {
+	// Try to load .env file (ignore error if it doesn't exist)
+	_ = godotenv.Load()
+
+	credentialsFile := os.Getenv("GOOGLE_SHEETS_CREDENTIALS_FILE")
+	if credentialsFile == "" {
+		return nil, fmt.Errorf("GOOGLE_SHEETS_CREDENTIALS_FILE environment variable is required")
+	}
+
+	spreadsheetID := os.Getenv("GOOGLE_SHEETS_SPREADSHEET_ID")
+	if spreadsheetID == "" {
+		return nil, fmt.Errorf("GOOGLE_SHEETS_SPREADSHEET_ID environment variable is required")
+	}
+
+	return &SheetsConfig{
+		CredentialsFile: credentialsFile,
+		SpreadsheetID:   spreadsheetID,
+	}, nil
+}
+
+// MinInternalSecretLength is the shortest INTERNAL_API_SECRET accepted
+const MinInternalSecretLength = 32
+
+// LoadInternalSecret loads the shared secret for the internal API between the server and notifications services
+func LoadInternalSecret() (string, error) {
+	// Try to load .env file (ignore error if it doesn't exist)
+	_ = godotenv.Load()
+
+	secret := os.Getenv("INTERNAL_API_SECRET")
+	if secret == "" {
+		return "", fmt.Errorf("INTERNAL_API_SECRET environment variable is required")
+	}
+	if len(secret) < MinInternalSecretLength {
+		return "", fmt.Errorf("INTERNAL_API_SECRET must be at least %d characters", MinInternalSecretLength)
+	}
+	return secret, nil
+}
+
+// LoadUserDataKey loads the optional key for encrypting per-user devices and notifications files at rest
+// USER_DATA_KEY holds the base64-encoded 32-byte key directly; USER_DATA_KEY_FILE names a file containing it
+// (e.g. a secret manager or Docker/Kubernetes secret mount). Returns nil when neither is set
+func LoadUserDataKey() ([]byte, error) {
+	// Try to load .env file (ignore error if it doesn't exist)
+	_ = godotenv.Load()
+
+	encoded := os.Getenv("USER_DATA_KEY")
+	if keyFile := os.Getenv("USER_DATA_KEY_FILE"); keyFile != "" {
+		if encoded != "" {
+			return nil, fmt.Errorf("set only one of USER_DATA_KEY and USER_DATA_KEY_FILE")
+		}
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read USER_DATA_KEY_FILE: %w", err)
+		}
+		encoded = strings.TrimSpace(string(data))
+	}
+	if encoded == "" {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("user data key must be base64: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("user data key must decode to 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+// LoadExportToken loads the optional token the export command sends to InfluxDB or a Prometheus remote-write endpoint
+// Returns an empty string when EXPORT_TOKEN is not set
+func LoadExportToken() string {
+	// Try to load .env file (ignore error if it doesn't exist)
+	_ = godotenv.Load()
+
+	return os.Getenv("EXPORT_TOKEN")
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FlagEnvPrefix is prepended to flag names to form their environment variable names
+const FlagEnvPrefix = "JAXOV_"
+
+// FlagEnvName returns the environment variable that mirrors a command-line flag
+// Example: "log-dir" -> "JAXOV_LOG_DIR"
+func FlagEnvName(flagName string) string {
+	return FlagEnvPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// ApplyFlagEnv fills flags that were not set on the command line from their mirrored environment variables
+// Precedence is: command-line flag, then environment variable, then the flag's default
+// Must be called after the flag set has been parsed
+func ApplyFlagEnv(fs *flag.FlagSet) error {
+	// Remember which flags were explicitly set on the command line
+	setOnCommandLine := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		setOnCommandLine[f.Name] = true
+	})
+
+	var firstErr error
+	fs.VisitAll(func(f *flag.Flag) {
+		if firstErr != nil || setOnCommandLine[f.Name] {
+			return
+		}
+
+		envName := FlagEnvName(f.Name)
+		value, ok := os.LookupEnv(envName)
+		if !ok {
+			return
+		}
+
+		if err := fs.Set(f.Name, value); err != nil {
+			firstErr = fmt.Errorf("invalid value %q for %s: %w", value, envName, err)
+		}
+	})
+
+	return firstErr
+}
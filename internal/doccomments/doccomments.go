@@ -0,0 +1,98 @@
+// Package doccomments reads the doc comments of Go struct types and fields from source, for the descriptions in the
+// generated /analyze protocol schema
+// It is only imported by cmd/protocol-schema and tests, so go/parser stays out of the server binary
+package doccomments
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// Read reads the doc comments of the struct types and fields declared in the Go packages in dirs, keyed
+// by type name ("Envelope") and type and field name ("Envelope.Payload"), for server.ProtocolSchema's descriptions
+// A field's trailing comment is used when it has one, and the comment above it otherwise
+func Read(dirs ...string) (map[string]string, error) {
+	comments := map[string]string{}
+	for _, dir := range dirs {
+		files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range files {
+			if strings.HasSuffix(path, "_test.go") {
+				continue
+			}
+			file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ParseComments)
+			if err != nil {
+				return nil, err
+			}
+			addComments(file, comments)
+		}
+	}
+	return comments, nil
+}
+
+// addComments adds the struct type and field comments declared in file
+func addComments(file *ast.File, comments map[string]string) {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			structType, ok := typeSpec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			doc := typeSpec.Doc
+			if doc == nil && len(gen.Specs) == 1 {
+				doc = gen.Doc
+			}
+			if text := commentText(doc); text != "" {
+				comments[typeSpec.Name.Name] = text
+			}
+			for _, field := range structType.Fields.List {
+				text := commentText(field.Comment)
+				if text == "" {
+					text = commentText(field.Doc)
+				}
+				for _, name := range field.Names {
+					if text != "" {
+						comments[typeSpec.Name.Name+"."+name.Name] = text
+					}
+				}
+			}
+		}
+	}
+}
+
+// commentText returns a comment group's text as one line
+// Comments here put each sentence on its own line without a full stop, so lines starting a new sentence (with a
+// capital letter, outside parentheses) are joined with one; wrapped lines are joined with a space
+func commentText(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+	var text strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(group.Text()), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if text.Len() > 0 {
+			prev := text.String()
+			open := strings.Count(prev, "(") > strings.Count(prev, ")")
+			if !open && unicode.IsUpper([]rune(line)[0]) && !strings.ContainsAny(prev[len(prev)-1:], ".:;,(") {
+				text.WriteString(".")
+			}
+			text.WriteString(" ")
+		}
+		text.WriteString(line)
+	}
+	return text.String()
+}
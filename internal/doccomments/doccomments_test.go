@@ -0,0 +1,20 @@
+package doccomments
+
+import "testing"
+
+func TestRead(t *testing.T) {
+	comments, err := Read("../server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"Envelope.Payload": "The message as SubprotocolV1JSON clients receive it",
+		"ErrorMessage": "ErrorMessage is a structured error frame sent to a client before its connection is closed. " +
+			"Clients distinguish it from summary messages by the \"type\" field",
+	}
+	for key, want := range tests {
+		if got := comments[key]; got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Package export writes period summaries to time-series databases so dashboards (e.g. Grafana) can chart premium flow
+// It produces InfluxDB line protocol and Prometheus remote-write requests directly, without client libraries
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+)
+
+// Export formats
+const (
+	FormatInflux     = "influx"     // InfluxDB line protocol
+	FormatPrometheus = "prometheus" // Prometheus remote-write
+)
+
+// ValidateFormat checks that an export format is supported
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatInflux, FormatPrometheus:
+		return nil
+	}
+	return fmt.Errorf("invalid format %q, expected %q or %q", format, FormatInflux, FormatPrometheus)
+}
+
+// Sample is one metric of a period summary
+type Sample struct {
+	Name  string // e.g. "call_premium"
+	Value float64
+}
+
+// Point holds a period summary's metrics for one ticker, timestamped at the period start
+type Point struct {
+	Ticker        string
+	PeriodMinutes int
+	Session       string
+	Time          time.Time
+	Samples       []Sample
+}
+
+// SummaryPoint converts a period summary to a point
+// Metrics that are undefined for the period (an infinite call/put premium or volume ratio, the average strike or trade premium distribution of a side with no volume,
+// anomaly scores early in the day, relative flow without a baseline) are left out rather than written as placeholders
+func SummaryPoint(ticker string, periodMinutes int, summary analysis.TimePeriodSummary) Point {
+	samples := []Sample{
+		{"call_premium", summary.CallPremium},
+		{"put_premium", summary.PutPremium},
+		{"total_premium", summary.TotalPremium},
+		{"call_volume", float64(summary.CallVolume)},
+		{"put_volume", float64(summary.PutVolume)},
+		{"unique_contracts", float64(summary.UniqueContracts)},
+		{"unique_call_contracts", float64(summary.UniqueCallContracts)},
+		{"unique_put_contracts", float64(summary.UniquePutContracts)},
+		{"new_contracts", float64(summary.NewContracts)},
+		{"call_trades", float64(summary.PremiumDistribution.Call.Count)},
+		{"put_trades", float64(summary.PremiumDistribution.Put.Count)},
+	}
+	if summary.CallPutRatio != nil {
+		samples = append(samples, Sample{"call_put_ratio", *summary.CallPutRatio})
+	}
+	if summary.CallPutVolumeRatio != nil {
+		samples = append(samples, Sample{"call_put_volume_ratio", *summary.CallPutVolumeRatio})
+	}
+	samples = append(samples, Sample{"call_put_log_ratio", summary.CallPutLogRatio})
+	if summary.CallVolume > 0 {
+		samples = append(samples, Sample{"call_avg_strike", summary.CallAvgStrike})
+	}
+	if summary.PutVolume > 0 {
+		samples = append(samples, Sample{"put_avg_strike", summary.PutAvgStrike})
+	}
+	if summary.LargestTrade != nil {
+		samples = append(samples, Sample{"largest_trade_premium", summary.LargestTrade.Premium})
+	}
+	if call := summary.PremiumDistribution.Call; call.Count > 0 {
+		samples = append(samples, Sample{"call_trade_median", call.Median}, Sample{"call_trade_p90", call.P90}, Sample{"call_trade_max", call.Max})
+	}
+	if put := summary.PremiumDistribution.Put; put.Count > 0 {
+		samples = append(samples, Sample{"put_trade_median", put.Median}, Sample{"put_trade_p90", put.P90}, Sample{"put_trade_max", put.Max})
+	}
+	if summary.Anomaly != nil {
+		samples = append(samples, Sample{"call_premium_z", summary.Anomaly.CallPremiumZ}, Sample{"put_premium_z", summary.Anomaly.PutPremiumZ})
+	}
+	if summary.Relative != nil {
+		samples = append(samples, Sample{"percent_of_adv", summary.Relative.PercentOfADV}, Sample{"flow_multiple", summary.Relative.FlowMultiple})
+	}
+
+	return Point{
+		Ticker:        ticker,
+		PeriodMinutes: periodMinutes,
+		Session:       summary.Session,
+		Time:          summary.PeriodStart,
+		Samples:       samples,
+	}
+}
+
+// Writer sends points to a time-series database or file
+type Writer interface {
+	Write(ctx context.Context, points []Point) error
+}
+
+// Config selects where and how a Writer sends points
+type Config struct {
+	Format      string
+	URL         string    // InfluxDB write URL or remote-write URL; empty writes line protocol to Output
+	Token       string    // Optional; sent as "Token <token>" to InfluxDB and "Bearer <token>" for remote-write
+	Measurement string    // InfluxDB measurement (default: DefaultMeasurement)
+	Output      io.Writer // Destination for line protocol when URL is empty
+}
+
+// DefaultMeasurement is the InfluxDB measurement points are written to unless configured
+const DefaultMeasurement = "premium_flow"
+
+// NewWriter creates the writer for a configuration
+// Remote-write needs a URL; line protocol goes to the URL when set, otherwise to Output
+func NewWriter(cfg Config) (Writer, error) {
+	if err := ValidateFormat(cfg.Format); err != nil {
+		return nil, err
+	}
+	if cfg.Measurement == "" {
+		cfg.Measurement = DefaultMeasurement
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	switch {
+	case cfg.Format == FormatPrometheus && cfg.URL == "":
+		return nil, fmt.Errorf("prometheus remote-write needs a URL")
+	case cfg.Format == FormatPrometheus:
+		return &remoteWriter{url: cfg.URL, token: cfg.Token, client: client}, nil
+	case cfg.URL != "":
+		return &influxHTTPWriter{url: cfg.URL, token: cfg.Token, measurement: cfg.Measurement, client: client}, nil
+	default:
+		return &influxWriter{out: cfg.Output, measurement: cfg.Measurement}, nil
+	}
+}
+
+// post sends a request body to an endpoint, treating any non-2xx response as an error
+func post(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send points: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("write to %s failed: %s: %s", url, resp.Status, bytes.TrimSpace(message))
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// influxWriter writes line protocol to a file or stdout
+type influxWriter struct {
+	out         io.Writer
+	measurement string
+}
+
+// Write implements Writer
+func (w *influxWriter) Write(ctx context.Context, points []Point) error {
+	if _, err := w.out.Write(LineProtocol(w.measurement, points)); err != nil {
+		return fmt.Errorf("failed to write line protocol: %w", err)
+	}
+	return nil
+}
+
+// influxHTTPWriter posts line protocol to an InfluxDB write endpoint (v1 /write or v2 /api/v2/write)
+type influxHTTPWriter struct {
+	url         string
+	token       string
+	measurement string
+	client      *http.Client
+}
+
+// Write implements Writer
+func (w *influxHTTPWriter) Write(ctx context.Context, points []Point) error {
+	if len(points) == 0 {
+		return nil
+	}
+	headers := map[string]string{"Content-Type": "text/plain; charset=utf-8"}
+	if w.token != "" {
+		headers["Authorization"] = "Token " + w.token
+	}
+	return post(ctx, w.client, w.url, LineProtocol(w.measurement, points), headers)
+}
+
+// LineProtocol formats points as InfluxDB line protocol, one line per point
+// Ticker and period length are tags, the session is a string field, and timestamps are in nanoseconds
+// (InfluxDB's default precision), e.g.
+// premium_flow,ticker=AAPL,period=5 call_premium=1500,put_premium=0,...,session="regular" 1764340200000000000
+func LineProtocol(measurement string, points []Point) []byte {
+	var b strings.Builder
+	for _, point := range points {
+		b.WriteString(escapeInflux(measurement, ", "))
+		b.WriteString(",ticker=")
+		b.WriteString(escapeInflux(point.Ticker, ", ="))
+		b.WriteString(",period=")
+		b.WriteString(strconv.Itoa(point.PeriodMinutes))
+		b.WriteByte(' ')
+		for i, sample := range point.Samples {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(escapeInflux(sample.Name, ", ="))
+			b.WriteByte('=')
+			b.WriteString(strconv.FormatFloat(sample.Value, 'f', -1, 64))
+		}
+		if len(point.Samples) > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(`session="`)
+		b.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(point.Session))
+		b.WriteString(`" `)
+		b.WriteString(strconv.FormatInt(point.Time.UnixNano(), 10))
+		b.WriteByte('\n')
+	}
+	return []byte(b.String())
+}
+
+// escapeInflux backslash-escapes the given special characters (and backslashes) in a measurement, tag, or field key
+func escapeInflux(value string, special string) string {
+	if !strings.ContainsAny(value, special+`\`) {
+		return value
+	}
+	var b strings.Builder
+	for _, r := range value {
+		if r == '\\' || strings.ContainsRune(special, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
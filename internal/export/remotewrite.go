@@ -0,0 +1,149 @@
+package export
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// MetricPrefix is prepended to sample names to form Prometheus metric names, e.g. jaxov_call_premium
+const MetricPrefix = "jaxov_"
+
+// remoteWriter sends points to a Prometheus remote-write endpoint (Prometheus, Mimir, VictoriaMetrics, ...)
+type remoteWriter struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// Write implements Writer
+func (w *remoteWriter) Write(ctx context.Context, points []Point) error {
+	if len(points) == 0 {
+		return nil
+	}
+	headers := map[string]string{
+		"Content-Type":                      "application/x-protobuf",
+		"Content-Encoding":                  "snappy",
+		"X-Prometheus-Remote-Write-Version": "0.1.0",
+	}
+	if w.token != "" {
+		headers["Authorization"] = "Bearer " + w.token
+	}
+	return post(ctx, w.client, w.url, snappyEncode(WriteRequest(points)), headers)
+}
+
+// label is a Prometheus label name and value
+type label struct {
+	name  string
+	value string
+}
+
+// series is one time series of a remote-write request
+type series struct {
+	metric string  // Sample name
+	labels []label // Sorted by name, as remote-write requires
+	points []Point // Points with a sample for the metric, oldest first
+}
+
+// WriteRequest encodes points as an uncompressed Prometheus remote-write WriteRequest protobuf
+// Each sample becomes a series labeled with the metric name (MetricPrefix + sample name), ticker, and
+// period length; the session is left out so a series doesn't split as the day moves between sessions
+func WriteRequest(points []Point) []byte {
+	sorted := make([]Point, len(points))
+	copy(sorted, points)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	index := make(map[string]int)
+	var all []*series
+	for _, point := range sorted {
+		period := strconv.Itoa(point.PeriodMinutes)
+		for _, sample := range point.Samples {
+			key := sample.Name + "\x00" + point.Ticker + "\x00" + period
+			i, ok := index[key]
+			if !ok {
+				i = len(all)
+				index[key] = i
+				all = append(all, &series{
+					metric: sample.Name,
+					labels: []label{
+						{"__name__", MetricPrefix + sample.Name},
+						{"period", period},
+						{"ticker", point.Ticker},
+					},
+				})
+			}
+			all[i].points = append(all[i].points, point)
+		}
+	}
+
+	// WriteRequest: repeated TimeSeries timeseries = 1
+	var request []byte
+	for _, s := range all {
+		// TimeSeries: repeated Label labels = 1; repeated Sample samples = 2
+		var timeSeries []byte
+		for _, l := range s.labels {
+			// Label: string name = 1; string value = 2
+			var encoded []byte
+			encoded = appendBytesField(encoded, 1, []byte(l.name))
+			encoded = appendBytesField(encoded, 2, []byte(l.value))
+			timeSeries = appendBytesField(timeSeries, 1, encoded)
+		}
+		for _, point := range s.points {
+			// Sample: double value = 1; int64 timestamp = 2 (Unix milliseconds)
+			var encoded []byte
+			encoded = binary.AppendUvarint(encoded, 1<<3|1) // Field 1, fixed 64-bit
+			encoded = binary.LittleEndian.AppendUint64(encoded, math.Float64bits(sampleValue(point, s.metric)))
+			encoded = binary.AppendUvarint(encoded, 2<<3|0) // Field 2, varint
+			encoded = binary.AppendUvarint(encoded, uint64(point.Time.UnixMilli()))
+			timeSeries = appendBytesField(timeSeries, 2, encoded)
+		}
+		request = appendBytesField(request, 1, timeSeries)
+	}
+	return request
+}
+
+// sampleValue returns the value of a point's sample with the given name
+func sampleValue(point Point, name string) float64 {
+	for _, sample := range point.Samples {
+		if sample.Name == name {
+			return sample.Value
+		}
+	}
+	return 0
+}
+
+// appendBytesField appends a length-delimited protobuf field (strings, bytes, and embedded messages)
+func appendBytesField(dst []byte, field int, value []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(field)<<3|2)
+	dst = binary.AppendUvarint(dst, uint64(len(value)))
+	return append(dst, value...)
+}
+
+// snappyEncode wraps data in the Snappy block format that remote-write requires, as literal chunks only
+// Nothing is actually compressed: export requests are small, and this avoids a compression dependency
+func snappyEncode(src []byte) []byte {
+	dst := binary.AppendUvarint(nil, uint64(len(src)))
+	for len(src) > 0 {
+		chunk := src
+		if len(chunk) > 1<<16 {
+			chunk = chunk[:1<<16]
+		}
+		src = src[len(chunk):]
+
+		// Literal tag: length-1 in the upper six bits, or in the following 1-2 bytes for longer literals
+		n := len(chunk) - 1
+		switch {
+		case n < 60:
+			dst = append(dst, byte(n)<<2)
+		case n < 1<<8:
+			dst = append(dst, 60<<2, byte(n))
+		default:
+			dst = append(dst, 61<<2, byte(n), byte(n>>8))
+		}
+		dst = append(dst, chunk...)
+	}
+	return dst
+}
@@ -0,0 +1,133 @@
+package latency
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// Stages of the path from the logger receiving an aggregate to a client or device seeing it
+// Each service records the stages it runs, measured from the aggregate's ingest time (see analysis.Aggregate)
+const (
+	StageLogged    = "logged"    // Logger: aggregate appended to its log file
+	StageRead      = "read"      // Server and notifications: aggregate read back from the log file
+	StageSent      = "sent"      // Server: live update carrying the aggregate written to a WebSocket client
+	StageEvaluated = "evaluated" // Notifications: every notification config evaluated against the aggregate's periods
+	StagePushed    = "pushed"    // Notifications: push for a period containing the aggregate accepted by APNS
+)
+
+// bucketBounds are the upper bounds of each stage's histogram buckets; slower samples fall in a final "+Inf" bucket
+var bucketBounds = []time.Duration{
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+}
+
+// Bucket counts the samples that took at most LE (and more than the previous bucket's bound)
+type Bucket struct {
+	LE    string `json:"le"` // Upper bound, e.g. "250ms", or "+Inf"
+	Count int64  `json:"count"`
+}
+
+// Stage describes the end-to-end latency of one stage since the process started
+type Stage struct {
+	Count   int64    `json:"count"`
+	MeanMs  float64  `json:"mean_ms"`
+	MaxMs   float64  `json:"max_ms"`
+	Buckets []Bucket `json:"buckets"`
+}
+
+// histogram accumulates one stage's samples
+type histogram struct {
+	count   int64
+	sum     time.Duration
+	max     time.Duration
+	buckets []int64 // One per bucketBounds entry, then +Inf
+}
+
+// Recorder records end-to-end latency histograms from ingest to each stage
+// It is safe for concurrent use
+type Recorder struct {
+	stages map[string]*histogram
+	mu     sync.Mutex
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{stages: make(map[string]*histogram)}
+}
+
+// Record adds a sample for stage, measured from ingestedAt to now
+// Zero ingest times (aggregates logged before ingest times were stamped, or summaries built from history) are
+// ignored, and negative latencies from clock skew between hosts count as 0
+func (r *Recorder) Record(stage string, ingestedAt time.Time) {
+	if ingestedAt.IsZero() {
+		return
+	}
+	took := max(time.Since(ingestedAt), 0)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, exists := r.stages[stage]
+	if !exists {
+		h = &histogram{buckets: make([]int64, len(bucketBounds)+1)}
+		r.stages[stage] = h
+	}
+	h.count++
+	h.sum += took
+	if took > h.max {
+		h.max = took
+	}
+	bucket := len(bucketBounds)
+	for i, bound := range bucketBounds {
+		if took <= bound {
+			bucket = i
+			break
+		}
+	}
+	h.buckets[bucket]++
+}
+
+// Snapshot returns every recorded stage's histogram, keyed by stage
+func (r *Recorder) Snapshot() map[string]Stage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stages := make(map[string]Stage, len(r.stages))
+	for name, h := range r.stages {
+		stage := Stage{
+			Count:   h.count,
+			MaxMs:   float64(h.max) / float64(time.Millisecond),
+			Buckets: make([]Bucket, 0, len(h.buckets)),
+		}
+		if h.count > 0 {
+			stage.MeanMs = float64(h.sum) / float64(h.count) / float64(time.Millisecond)
+		}
+		for i, count := range h.buckets {
+			le := "+Inf"
+			if i < len(bucketBounds) {
+				le = bucketBounds[i].String()
+			}
+			stage.Buckets = append(stage.Buckets, Bucket{LE: le, Count: count})
+		}
+		stages[name] = stage
+	}
+	return stages
+}
+
+// Publish publishes the recorder's snapshot as e2e_latency on the diagnostics listener's /debug/vars
+// Call it at most once per process
+func (r *Recorder) Publish() {
+	expvar.Publish("e2e_latency", expvar.Func(func() interface{} {
+		return r.Snapshot()
+	}))
+}
@@ -0,0 +1,348 @@
+package logger
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// Disk levels, from healthy to the point where the logger stops writing
+const (
+	DiskOK        = "ok"
+	DiskWarn      = "warn"      // Older daily files are compressed and operators are alerted
+	DiskCritical  = "critical"  // Every previous day is compressed and only priority symbols are logged
+	DiskEmergency = "emergency" // Nothing is written until space is freed
+)
+
+// diskHysteresis is how far (in percentage points) free space must rise above a threshold before the level improves,
+// so the logger doesn't flap between levels around a threshold
+const diskHysteresis = 1.0
+
+// DiskThresholds are the free-space percentages below which each disk level starts (0 disables a level)
+type DiskThresholds struct {
+	Warn      float64
+	Critical  float64
+	Emergency float64
+}
+
+// Validate checks that thresholds are percentages and that each level starts at less free space than the one before it
+func (t DiskThresholds) Validate() error {
+	previous := 100.0
+	for _, threshold := range []float64{t.Warn, t.Critical, t.Emergency} {
+		if threshold < 0 || threshold > 100 {
+			return fmt.Errorf("disk thresholds must be between 0 and 100 percent")
+		}
+		if threshold == 0 {
+			continue
+		}
+		if threshold > previous {
+			return fmt.Errorf("disk thresholds must decrease from warn to critical to emergency")
+		}
+		previous = threshold
+	}
+	return nil
+}
+
+// Level returns the disk level for the given free-space percentage, starting from the current level
+// A level is entered as soon as free space falls below its threshold but only left once free space is
+// diskHysteresis points above it
+func (t DiskThresholds) Level(freePercent float64, current string) string {
+	levels := []struct {
+		name      string
+		threshold float64
+	}{
+		{DiskEmergency, t.Emergency},
+		{DiskCritical, t.Critical},
+		{DiskWarn, t.Warn},
+	}
+	for i, level := range levels {
+		if level.threshold <= 0 {
+			continue
+		}
+		if freePercent < level.threshold {
+			return level.name
+		}
+		// Stay in the current (or a worse) level until free space clears the threshold by the hysteresis margin
+		if freePercent < level.threshold+diskHysteresis && diskLevelAtLeast(current, levels[i].name) {
+			return level.name
+		}
+	}
+	return DiskOK
+}
+
+// diskLevelAtLeast reports whether level is as severe as or more severe than other
+func diskLevelAtLeast(level string, other string) bool {
+	return diskSeverity(level) >= diskSeverity(other)
+}
+
+// diskSeverity orders disk levels from DiskOK (0) to DiskEmergency (3)
+func diskSeverity(level string) int {
+	switch level {
+	case DiskWarn:
+		return 1
+	case DiskCritical:
+		return 2
+	case DiskEmergency:
+		return 3
+	}
+	return 0
+}
+
+// DiskStatus reports free space on the log directory's filesystem, written to the heartbeat file
+type DiskStatus struct {
+	Level       string    `json:"level"`
+	FreeBytes   uint64    `json:"free_bytes"`
+	TotalBytes  uint64    `json:"total_bytes"`
+	FreePercent float64   `json:"free_percent"`
+	Compressed  int       `json:"compressed"` // Daily files compressed to make room since the logger started
+	CheckedAt   time.Time `json:"checked_at"`
+}
+
+// Degraded reports whether the logger is dropping data to save disk space
+func (s DiskStatus) Degraded() bool {
+	return diskLevelAtLeast(s.Level, DiskCritical)
+}
+
+// DiskGuard watches free space on the log directory's filesystem and throttles the logger as it runs low:
+// below the warn threshold it compresses older daily files and alerts, below the critical threshold it compresses
+// every previous day and logs only priority symbols, and below the emergency threshold it stops writing so the disk
+// never fills and active files are never left with partial lines
+type DiskGuard struct {
+	logDir        string
+	thresholds    DiskThresholds
+	priority      map[string]bool // Underlyings still logged at the critical level
+	compressAfter int             // At the warn level, compress daily files at least this many days old
+	status        DiskStatus
+	mu            sync.RWMutex
+}
+
+// NewDiskGuard creates a disk guard for a log directory
+// priority lists the underlyings kept at the critical level; compressAfter is the age in days of the daily files
+// compressed at the warn level
+func NewDiskGuard(logDir string, thresholds DiskThresholds, priority []string, compressAfter int) *DiskGuard {
+	prioritySet := make(map[string]bool, len(priority))
+	for _, ticker := range priority {
+		if ticker = strings.ToUpper(strings.TrimSpace(ticker)); ticker != "" {
+			prioritySet[ticker] = true
+		}
+	}
+	return &DiskGuard{
+		logDir:        logDir,
+		thresholds:    thresholds,
+		priority:      prioritySet,
+		compressAfter: compressAfter,
+		status:        DiskStatus{Level: DiskOK},
+	}
+}
+
+// Allows reports whether aggregates for an underlying should be written at the current disk level
+func (g *DiskGuard) Allows(underlying string) bool {
+	g.mu.RLock()
+	level := g.status.Level
+	g.mu.RUnlock()
+
+	switch level {
+	case DiskEmergency:
+		return false
+	case DiskCritical:
+		return g.priority[strings.ToUpper(underlying)]
+	}
+	return true
+}
+
+// Status returns the result of the latest check
+func (g *DiskGuard) Status() DiskStatus {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.status
+}
+
+// Check measures free space, updates the disk level, and compresses daily files when space is low
+// Level changes are logged so operators are alerted as the logger starts (or stops) throttling
+func (g *DiskGuard) Check() (DiskStatus, error) {
+	total, free, err := readDiskUsage(g.logDir)
+	if err != nil {
+		return g.Status(), err
+	}
+	freePercent := 0.0
+	if total > 0 {
+		freePercent = float64(free) / float64(total) * 100
+	}
+
+	g.mu.Lock()
+	previous := g.status.Level
+	g.status.Level = g.thresholds.Level(freePercent, previous)
+	g.status.FreeBytes = free
+	g.status.TotalBytes = total
+	g.status.FreePercent = freePercent
+	g.status.CheckedAt = time.Now()
+	level := g.status.Level
+	g.mu.Unlock()
+
+	if level != previous {
+		if diskSeverity(level) > diskSeverity(previous) {
+			log.Printf("ALERT: log directory disk space low (%.1f%% free, %s): level %s -> %s, %s", freePercent, formatBytes(free), previous, level, diskLevelAction(level))
+		} else {
+			log.Printf("Log directory disk space recovered (%.1f%% free, %s): level %s -> %s, %s", freePercent, formatBytes(free), previous, level, diskLevelAction(level))
+		}
+	}
+
+	// Compress completed days: at the warn level only older ones, beyond it every previous day
+	if level != DiskOK {
+		cutoff := market.Today()
+		if level == DiskWarn {
+			cutoff = time.Now().In(market.AnalysisLocation()).AddDate(0, 0, -g.compressAfter+1).Format("2006-01-02")
+		}
+		compressed, err := CompressLogFiles(g.logDir, cutoff)
+		if compressed > 0 {
+			log.Printf("Compressed %d daily log files dated before %s to free disk space", compressed, cutoff)
+		}
+		g.mu.Lock()
+		g.status.Compressed += compressed
+		g.mu.Unlock()
+		if err != nil {
+			return g.Status(), err
+		}
+	}
+
+	return g.Status(), nil
+}
+
+// Watch checks free space every interval until the context is canceled
+func (g *DiskGuard) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := g.Check(); err != nil {
+				log.Printf("Error checking disk space: %v", err)
+			}
+		}
+	}
+}
+
+// diskLevelAction describes what the logger does at a disk level, for alerts
+func diskLevelAction(level string) string {
+	switch level {
+	case DiskWarn:
+		return "compressing older daily files"
+	case DiskCritical:
+		return "compressing previous days and logging only priority symbols"
+	case DiskEmergency:
+		return "writing paused until space is freed"
+	}
+	return "logging normally"
+}
+
+// formatBytes formats a byte count with a binary unit, e.g. 1.5 GiB
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// CompressLogFiles gzips the daily log files (SYMBOL_YYYY-MM-DD.jsonl) in logDir dated before the given date,
+// replacing each with SYMBOL_YYYY-MM-DD.jsonl.gz, and returns how many were compressed
+// Today's files are never touched, since the logger and readers still have them open. Compressed files are not read
+// by the analysis tools or the server; decompress them (gunzip) to analyze those days again
+func CompressLogFiles(logDir string, before string) (int, error) {
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read log directory: %w", err)
+	}
+
+	today := market.Today()
+	compressed := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+
+		// Format: TICKER_YYYY-MM-DD.jsonl
+		separator := strings.LastIndex(name, "_")
+		if separator <= 0 {
+			continue
+		}
+		dateStr := strings.TrimSuffix(name[separator+1:], ".jsonl")
+		if _, err := time.Parse("2006-01-02", dateStr); err != nil {
+			continue
+		}
+		if dateStr >= before || dateStr >= today {
+			continue
+		}
+
+		if err := gzipFile(filepath.Join(logDir, name)); err != nil {
+			return compressed, err
+		}
+		compressed++
+	}
+	return compressed, nil
+}
+
+// gzipFile replaces a file with a gzipped copy (filename + ".gz")
+// The copy is written to a temp file and renamed, so a failure (e.g. the disk filling) leaves the original intact
+func gzipFile(filename string) error {
+	src, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer src.Close()
+
+	target := filename + ".gz"
+	tmpFile := filepath.Join(filepath.Dir(target), "."+filepath.Base(target)+".tmp")
+	dst, err := os.Create(tmpFile)
+	if err != nil {
+		return fmt.Errorf("failed to create compressed file: %w", err)
+	}
+
+	gz, err := gzip.NewWriterLevel(dst, gzip.BestCompression)
+	if err != nil {
+		dst.Close()
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to create gzip writer: %w", err)
+	}
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to compress %s: %w", filename, err)
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to compress %s: %w", filename, err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to write compressed file: %w", err)
+	}
+
+	if err := os.Rename(tmpFile, target); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to replace compressed file: %w", err)
+	}
+	if err := os.Remove(filename); err != nil {
+		return fmt.Errorf("failed to remove compressed log file: %w", err)
+	}
+	return nil
+}
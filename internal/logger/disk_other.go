@@ -0,0 +1,12 @@
+//go:build !(linux || darwin || freebsd)
+
+package logger
+
+import (
+	"fmt"
+)
+
+// readDiskUsage is not supported on this platform, so the disk guard never throttles
+func readDiskUsage(path string) (uint64, uint64, error) {
+	return 0, 0, fmt.Errorf("disk usage is not supported on this platform")
+}
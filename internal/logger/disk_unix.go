@@ -0,0 +1,17 @@
+//go:build linux || darwin || freebsd
+
+package logger
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// readDiskUsage returns the total and available bytes of the filesystem holding path
+func readDiskUsage(path string) (uint64, uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, 0, fmt.Errorf("failed to stat filesystem: %w", err)
+	}
+	return uint64(stat.Blocks) * uint64(stat.Bsize), uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
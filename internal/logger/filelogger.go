@@ -5,15 +5,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/market"
 )
 
 // DailyLogger logs aggregates to daily rotating files
+// Every append is timed, and appends slower than the slow-write threshold are logged (see WriteStatus)
 type DailyLogger struct {
-	logDir string
+	logDir  string
+	metrics *writeMetrics
 }
 
 // NewDailyLogger creates a new daily logger
@@ -24,58 +26,37 @@ func NewDailyLogger(logDir string) (*DailyLogger, error) {
 	}
 
 	return &DailyLogger{
-		logDir: logDir,
+		logDir:  logDir,
+		metrics: newWriteMetrics(DefaultSlowWriteThreshold),
 	}, nil
 }
 
+// SetSlowWriteThreshold sets how long an append may take before it is reported as slow (0 disables detection)
+// Call it before the logger is used
+func (l *DailyLogger) SetSlowWriteThreshold(threshold time.Duration) {
+	l.metrics.threshold = threshold
+}
+
+// WriteStatus returns the write path's latency histogram and slow/failed append counts, and resets the counts
+// since the previous call, so call it once per heartbeat
+func (l *DailyLogger) WriteStatus() WriteStatus {
+	return l.metrics.snapshot()
+}
+
 // ExtractUnderlyingSymbol extracts the underlying ticker from an option contract symbol
-// Format: O:{UNDERLYING}{EXPIRATION}{C|P}{STRIKE}
 // Example: O:AAPL230616C00150000 -> AAPL
+// Adjusted roots keep their own ticker (AAPL1), and index series keep theirs (SPXW) unless analysis.SetIndexRootMerging is on
 func ExtractUnderlyingSymbol(symbol string) (string, error) {
-	// Remove "O:" prefix if present
-	symbol = strings.TrimPrefix(symbol, "O:")
-
-	if len(symbol) < 7 {
-		return "", fmt.Errorf("invalid symbol format: %s", symbol)
-	}
-
-	// Find the C or P that indicates call/put
-	// It should be followed by digits (strike price)
-	var callPutIndex int = -1
-
-	for i := len(symbol) - 1; i >= 0; i-- {
-		if symbol[i] == 'C' {
-			if i+1 < len(symbol) && symbol[i+1] >= '0' && symbol[i+1] <= '9' {
-				callPutIndex = i
-				break
-			}
-		}
-		if symbol[i] == 'P' {
-			if i+1 < len(symbol) && symbol[i+1] >= '0' && symbol[i+1] <= '9' {
-				callPutIndex = i
-				break
-			}
-		}
-	}
-
-	if callPutIndex == -1 {
-		return "", fmt.Errorf("could not find call/put indicator in: %s", symbol)
-	}
-
-	// Extract components
-	// Everything before callPutIndex-6 is the underlying (expiration is 6 digits: YYMMDD)
-	expirationStart := callPutIndex - 6
-	if expirationStart < 0 {
-		return "", fmt.Errorf("invalid symbol format: %s", symbol)
+	contract, err := analysis.ParseOptionSymbol(symbol)
+	if err != nil {
+		return "", err
 	}
-
-	underlying := symbol[:expirationStart]
-	return underlying, nil
+	return contract.Underlying, nil
 }
 
-// getLogFilePath returns the log file path for a specific underlying symbol and current date
+// getLogFilePath returns the log file path for a specific underlying symbol and current date in the analysis timezone
 func (l *DailyLogger) getLogFilePath(underlyingSymbol string) string {
-	date := time.Now().Format("2006-01-02")
+	date := market.Today()
 	filename := fmt.Sprintf("%s_%s.jsonl", underlyingSymbol, date)
 	return filepath.Join(l.logDir, filename)
 }
@@ -91,18 +72,30 @@ func (l *DailyLogger) Write(agg analysis.Aggregate) error {
 
 	filePath := l.getLogFilePath(underlyingSymbol)
 
+	start := time.Now()
+	err = appendAggregate(filePath, agg)
+	l.metrics.record(filePath, time.Since(start), err)
+	return err
+}
+
+// appendAggregate appends an aggregate as one JSON line, closing the file before returning so close errors
+// (e.g. a network filesystem failing to flush) are reported
+func appendAggregate(filePath string, agg analysis.Aggregate) error {
 	// Open file in append mode, create if doesn't exist
 	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to open log file: %w", err)
 	}
-	defer file.Close()
 
 	// Encode aggregate as JSON
 	encoder := json.NewEncoder(file)
 	if err := encoder.Encode(agg); err != nil {
+		file.Close()
 		return fmt.Errorf("failed to encode aggregate: %w", err)
 	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
 
 	return nil
 }
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+)
+
+// TestExtractUnderlyingSymbolFileNames pins the ticker each kind of contract is logged under, since it names the
+// daily log files: changing it moves records to different files
+func TestExtractUnderlyingSymbolFileNames(t *testing.T) {
+	tests := []struct {
+		symbol string
+		want   string
+		merged string
+	}{
+		{"O:AAPL230616C00150000", "AAPL", "AAPL"},
+		{"O:SPX241220C05000000", "SPX", "SPX"},
+		{"O:SPXW241220C05000000", "SPXW", "SPX"},
+		{"SPXW  241220C05000000", "SPXW", "SPX"},
+		{"O:NDXP250321C20000000", "NDXP", "NDX"},
+		{"O:AAPL1230616C00150000", "AAPL1", "AAPL1"},
+		{"O:BRK.B250117C00450000", "BRK.B", "BRK.B"},
+	}
+	for _, merged := range []bool{false, true} {
+		analysis.SetIndexRootMerging(merged)
+		for _, tt := range tests {
+			want := tt.want
+			if merged {
+				want = tt.merged
+			}
+			got, err := ExtractUnderlyingSymbol(tt.symbol)
+			if err != nil {
+				t.Errorf("ExtractUnderlyingSymbol(%q) (merged %v): %v", tt.symbol, merged, err)
+				continue
+			}
+			if got != want {
+				t.Errorf("ExtractUnderlyingSymbol(%q) (merged %v) = %q, want %q", tt.symbol, merged, got, want)
+			}
+		}
+	}
+	analysis.SetIndexRootMerging(false)
+
+	if _, err := ExtractUnderlyingSymbol("O:AAPL"); err == nil {
+		t.Error("ExtractUnderlyingSymbol(\"O:AAPL\") succeeded, want an error")
+	}
+}
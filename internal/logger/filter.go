@@ -0,0 +1,150 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SymbolRules are the allowlist and denylist patterns of a symbol filter file
+// Patterns are matched against the underlying ticker and may use shell wildcards (*, ?, [...])
+// Example: {"allow": ["SPY", "QQQ", "AAPL", "TSLA"], "deny": ["SPX*", "*W"]}
+type SymbolRules struct {
+	Allow []string `json:"allow,omitempty"` // When set, only matching underlyings are logged
+	Deny  []string `json:"deny,omitempty"`  // Matching underlyings are never logged, even if allowed
+}
+
+// Allows reports whether an underlying ticker passes the rules
+// Deny patterns take precedence; an empty allowlist allows everything not denied
+func (r *SymbolRules) Allows(underlying string) bool {
+	underlying = strings.ToUpper(underlying)
+	for _, pattern := range r.Deny {
+		if matched, _ := path.Match(pattern, underlying); matched {
+			return false
+		}
+	}
+	if len(r.Allow) == 0 {
+		return true
+	}
+	for _, pattern := range r.Allow {
+		if matched, _ := path.Match(pattern, underlying); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadSymbolRules reads and validates a symbol filter file
+func LoadSymbolRules(filename string) (*SymbolRules, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read symbol filter file: %w", err)
+	}
+
+	var rules SymbolRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse symbol filter file: %w", err)
+	}
+
+	for _, patterns := range [][]string{rules.Allow, rules.Deny} {
+		for i, pattern := range patterns {
+			pattern = strings.ToUpper(strings.TrimSpace(pattern))
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid symbol pattern %q: %w", pattern, err)
+			}
+			patterns[i] = pattern
+		}
+	}
+	return &rules, nil
+}
+
+// SymbolFilter applies the rules of a symbol filter file and reloads them when the file changes,
+// so operators can adjust what the logger records without restarting it
+// If a reload fails (e.g. the file is mid-edit or invalid), the previous rules stay in effect
+type SymbolFilter struct {
+	filename string
+	rules    *SymbolRules
+	size     int64
+	modTime  time.Time
+	mu       sync.RWMutex
+}
+
+// NewSymbolFilter creates a filter from a symbol filter file, which must exist and be valid
+func NewSymbolFilter(filename string) (*SymbolFilter, error) {
+	f := &SymbolFilter{filename: filename}
+	if _, err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Allows reports whether an underlying ticker passes the current rules
+func (f *SymbolFilter) Allows(underlying string) bool {
+	f.mu.RLock()
+	rules := f.rules
+	f.mu.RUnlock()
+	return rules.Allows(underlying)
+}
+
+// Rules returns the rules currently in effect
+func (f *SymbolFilter) Rules() SymbolRules {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return *f.rules
+}
+
+// Reload re-reads the filter file if its size or modification time changed, reporting whether new rules were loaded
+func (f *SymbolFilter) Reload() (bool, error) {
+	info, err := os.Stat(f.filename)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat symbol filter file: %w", err)
+	}
+
+	f.mu.RLock()
+	unchanged := f.rules != nil && f.size == info.Size() && f.modTime.Equal(info.ModTime())
+	f.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	rules, err := LoadSymbolRules(f.filename)
+	if err != nil {
+		return false, err
+	}
+
+	f.mu.Lock()
+	f.rules = rules
+	f.size = info.Size()
+	f.modTime = info.ModTime()
+	f.mu.Unlock()
+	return true, nil
+}
+
+// Watch checks the filter file for changes every interval until the context is canceled
+func (f *SymbolFilter) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := f.Reload()
+			if err != nil {
+				log.Printf("Error reloading symbol filter (keeping previous rules): %v", err)
+				continue
+			}
+			if reloaded {
+				rules := f.Rules()
+				log.Printf("Reloaded symbol filter %s: %d allow, %d deny patterns", f.filename, len(rules.Allow), len(rules.Deny))
+			}
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+)
+
+// ImportResult summarizes an import of external aggregates into the log directory
+type ImportResult struct {
+	Received   int            `json:"received"`   // Aggregates in the upload
+	Imported   int            `json:"imported"`   // Aggregates appended to log files
+	Duplicates int            `json:"duplicates"` // Aggregates already present (same symbol, start, and end)
+	Files      map[string]int `json:"files"`      // Log file name -> aggregates appended
+}
+
+// aggregateKey identifies an aggregate record for de-duplication
+type aggregateKey struct {
+	symbol string
+	start  int64
+	end    int64
+}
+
+// DecodeAggregates reads aggregates from either a JSON array (e.g. cmd/reconstruct output) or JSONL (logger format)
+func DecodeAggregates(r io.Reader) ([]analysis.Aggregate, error) {
+	reader := bufio.NewReader(r)
+
+	// Peek at the first non-whitespace byte to detect the format
+	for {
+		b, err := reader.ReadByte()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read upload: %w", err)
+		}
+		if b == ' ' || b == '\t' || b == '\r' || b == '\n' {
+			continue
+		}
+		if err := reader.UnreadByte(); err != nil {
+			return nil, fmt.Errorf("failed to read upload: %w", err)
+		}
+
+		if b == '[' {
+			var aggregates []analysis.Aggregate
+			if err := json.NewDecoder(reader).Decode(&aggregates); err != nil {
+				return nil, fmt.Errorf("failed to parse JSON array: %w", err)
+			}
+			return aggregates, nil
+		}
+		break
+	}
+
+	var aggregates []analysis.Aggregate
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var agg analysis.Aggregate
+		if err := json.Unmarshal(line, &agg); err != nil {
+			return nil, fmt.Errorf("failed to parse line %d: %w", lineNum, err)
+		}
+		aggregates = append(aggregates, agg)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+
+	return aggregates, nil
+}
+
+// ValidateAggregate checks that an aggregate has an option symbol, sane timestamps, and non-negative values
+func ValidateAggregate(agg analysis.Aggregate) error {
+	if !strings.HasPrefix(agg.Symbol, "O:") {
+		return fmt.Errorf("symbol %q is not an option contract", agg.Symbol)
+	}
+	if _, err := ExtractUnderlyingSymbol(agg.Symbol); err != nil {
+		return err
+	}
+	if agg.StartTimestamp <= 0 || agg.EndTimestamp < agg.StartTimestamp {
+		return fmt.Errorf("invalid timestamps for %s: s=%d e=%d", agg.Symbol, agg.StartTimestamp, agg.EndTimestamp)
+	}
+	if agg.Volume < 0 || agg.VWAP < 0 {
+		return fmt.Errorf("negative volume or VWAP for %s", agg.Symbol)
+	}
+	return nil
+}
+
+// ImportAggregates validates aggregates and merges them into the daily log files in logDir
+// Each aggregate goes to SYMBOL_YYYY-MM-DD.jsonl for its underlying and its start date in loc
+// Records already present are skipped, and new records are appended so live readers tailing the file are unaffected
+// Validation runs over the whole upload first, so an invalid aggregate leaves every file untouched
+func ImportAggregates(logDir string, aggregates []analysis.Aggregate, loc *time.Location) (ImportResult, error) {
+	result := ImportResult{Received: len(aggregates), Files: make(map[string]int)}
+
+	// Validate and group by destination file
+	byFile := make(map[string][]analysis.Aggregate)
+	for i, agg := range aggregates {
+		if err := ValidateAggregate(agg); err != nil {
+			return result, fmt.Errorf("aggregate %d: %w", i, err)
+		}
+		underlying, _ := ExtractUnderlyingSymbol(agg.Symbol)
+		date := time.UnixMilli(agg.StartTimestamp).In(loc).Format("2006-01-02")
+		fileName := fmt.Sprintf("%s_%s.jsonl", underlying, date)
+		byFile[fileName] = append(byFile[fileName], agg)
+	}
+
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return result, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	fileNames := make([]string, 0, len(byFile))
+	for fileName := range byFile {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+
+	for _, fileName := range fileNames {
+		filePath := filepath.Join(logDir, fileName)
+		existing, err := readAggregateKeys(filePath)
+		if err != nil {
+			return result, err
+		}
+
+		// Drop records already in the file (or repeated within the upload), then append in time order
+		var pending []analysis.Aggregate
+		for _, agg := range byFile[fileName] {
+			key := aggregateKey{symbol: agg.Symbol, start: agg.StartTimestamp, end: agg.EndTimestamp}
+			if existing[key] {
+				result.Duplicates++
+				continue
+			}
+			existing[key] = true
+			pending = append(pending, agg)
+		}
+		if len(pending) == 0 {
+			continue
+		}
+		sort.SliceStable(pending, func(i, j int) bool {
+			return pending[i].StartTimestamp < pending[j].StartTimestamp
+		})
+
+		if err := appendAggregates(filePath, pending); err != nil {
+			return result, err
+		}
+		result.Imported += len(pending)
+		result.Files[fileName] = len(pending)
+	}
+
+	return result, nil
+}
+
+// readAggregateKeys returns the keys of all aggregates already in a log file (empty if the file doesn't exist)
+func readAggregateKeys(filePath string) (map[aggregateKey]bool, error) {
+	keys := make(map[aggregateKey]bool)
+
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		return keys, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		var agg analysis.Aggregate
+		if err := json.Unmarshal(scanner.Bytes(), &agg); err != nil {
+			continue // Skip malformed lines, matching the readers
+		}
+		keys[aggregateKey{symbol: agg.Symbol, start: agg.StartTimestamp, end: agg.EndTimestamp}] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read log file %s: %w", filepath.Base(filePath), err)
+	}
+
+	return keys, nil
+}
+
+// appendAggregates appends aggregates to a log file as JSONL in a single write
+func appendAggregates(filePath string, aggregates []analysis.Aggregate) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, agg := range aggregates {
+		if err := encoder.Encode(agg); err != nil {
+			return fmt.Errorf("failed to encode aggregate: %w", err)
+		}
+	}
+
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write log file %s: %w", filepath.Base(filePath), err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// OutliersDirName is the log directory's subdirectory premium outliers are written to by default
+const OutliersDirName = "outliers"
+
+// OutlierLogger appends detected premium outliers to per-ticker daily files (TICKER_YYYY-MM-DD.jsonl), dated like
+// the log files in the analysis timezone
+type OutlierLogger struct {
+	dir string
+}
+
+// NewOutlierLogger creates an outlier logger, creating its directory if needed
+func NewOutlierLogger(dir string) (*OutlierLogger, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create outliers directory: %w", err)
+	}
+	return &OutlierLogger{dir: dir}, nil
+}
+
+// Write appends an outlier to its ticker's file for the date it occurred
+func (l *OutlierLogger) Write(outlier analysis.Outlier) error {
+	date := outlier.Timestamp.In(market.AnalysisLocation()).Format("2006-01-02")
+	filePath := filepath.Join(l.dir, fmt.Sprintf("%s_%s.jsonl", outlier.Ticker, date))
+
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open outliers file: %w", err)
+	}
+	defer file.Close()
+
+	if err := json.NewEncoder(file).Encode(outlier); err != nil {
+		return fmt.Errorf("failed to encode outlier: %w", err)
+	}
+	return nil
+}
+
+// ReadOutliers returns every ticker's outliers for a date, in the order they occurred
+// A missing directory or date has no outliers; malformed lines are skipped, matching the log readers
+func ReadOutliers(dir string, dateStr string) ([]analysis.Outlier, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*_"+dateStr+".jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outliers files: %w", err)
+	}
+
+	var outliers []analysis.Outlier
+	for _, filePath := range files {
+		file, err := os.Open(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open outliers file: %w", err)
+		}
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var outlier analysis.Outlier
+			if err := json.Unmarshal(scanner.Bytes(), &outlier); err != nil {
+				continue
+			}
+			outliers = append(outliers, outlier)
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read outliers file %s: %w", filepath.Base(filePath), err)
+		}
+	}
+
+	// Files are sorted by ticker, so ties keep a stable ticker order
+	sort.SliceStable(outliers, func(i, j int) bool {
+		return outliers[i].Timestamp.Before(outliers[j].Timestamp)
+	})
+	return outliers, nil
+}
@@ -0,0 +1,172 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StatusFileName is the default name of the logger heartbeat file inside the log directory
+const StatusFileName = "logger-status.json"
+
+// SubscriptionStatus reports activity for a single upstream subscription
+type SubscriptionStatus struct {
+	Subscription    string    `json:"subscription"`
+	Messages        int64     `json:"messages"`
+	LastMessageTime time.Time `json:"last_message_time"`
+}
+
+// Status is the heartbeat record periodically written by the logger
+type Status struct {
+	PID               int                  `json:"pid"`
+	StartedAt         time.Time            `json:"started_at"`
+	UpdatedAt         time.Time            `json:"updated_at"`
+	MessagesTotal     int64                `json:"messages_total"`
+	MessagesPerSecond float64              `json:"messages_per_second"` // Rate since the previous heartbeat
+	Dropped           int64                `json:"dropped"`             // Messages that could not be written
+	Filtered          int64                `json:"filtered"`            // Messages skipped by the symbol filter
+	Throttled         int64                `json:"throttled"`           // Messages skipped by the disk guard to save space
+	Disk              *DiskStatus          `json:"disk,omitempty"`      // Free space on the log directory's filesystem
+	Writes            *WriteStatus         `json:"writes,omitempty"`    // Daily file append latency and slow writes
+	Subscriptions     []SubscriptionStatus `json:"subscriptions"`
+}
+
+// Age returns how long ago the status was written
+func (s *Status) Age(now time.Time) time.Duration {
+	return now.Sub(s.UpdatedAt)
+}
+
+// StatusTracker accumulates logger activity between heartbeats
+type StatusTracker struct {
+	startedAt     time.Time
+	lastSnapshot  time.Time
+	lastTotal     int64
+	total         int64
+	dropped       int64
+	filtered      int64
+	throttled     int64
+	subscriptions map[string]*SubscriptionStatus
+	mu            sync.Mutex
+}
+
+// NewStatusTracker creates a tracker for the given subscriptions
+func NewStatusTracker(subscriptions ...string) *StatusTracker {
+	now := time.Now()
+	t := &StatusTracker{
+		startedAt:     now,
+		lastSnapshot:  now,
+		subscriptions: make(map[string]*SubscriptionStatus),
+	}
+	for _, sub := range subscriptions {
+		t.subscriptions[sub] = &SubscriptionStatus{Subscription: sub}
+	}
+	return t
+}
+
+// RecordMessage records a message received on a subscription
+func (t *StatusTracker) RecordMessage(subscription string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sub, ok := t.subscriptions[subscription]
+	if !ok {
+		sub = &SubscriptionStatus{Subscription: subscription}
+		t.subscriptions[subscription] = sub
+	}
+	sub.Messages++
+	sub.LastMessageTime = time.Now()
+	t.total++
+}
+
+// RecordDrop records a message that could not be written
+func (t *StatusTracker) RecordDrop() {
+	t.mu.Lock()
+	t.dropped++
+	t.mu.Unlock()
+}
+
+// RecordFiltered records a message skipped by the symbol filter
+func (t *StatusTracker) RecordFiltered() {
+	t.mu.Lock()
+	t.filtered++
+	t.mu.Unlock()
+}
+
+// RecordThrottled records a message skipped by the disk guard
+func (t *StatusTracker) RecordThrottled() {
+	t.mu.Lock()
+	t.throttled++
+	t.mu.Unlock()
+}
+
+// Snapshot returns the current status and resets the messages/sec window
+func (t *StatusTracker) Snapshot() Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	rate := 0.0
+	if elapsed := now.Sub(t.lastSnapshot).Seconds(); elapsed > 0 {
+		rate = float64(t.total-t.lastTotal) / elapsed
+	}
+	t.lastSnapshot = now
+	t.lastTotal = t.total
+
+	subs := make([]SubscriptionStatus, 0, len(t.subscriptions))
+	for _, sub := range t.subscriptions {
+		subs = append(subs, *sub)
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].Subscription < subs[j].Subscription
+	})
+
+	return Status{
+		PID:               os.Getpid(),
+		StartedAt:         t.startedAt,
+		UpdatedAt:         now,
+		MessagesTotal:     t.total,
+		MessagesPerSecond: rate,
+		Dropped:           t.dropped,
+		Filtered:          t.filtered,
+		Throttled:         t.throttled,
+		Subscriptions:     subs,
+	}
+}
+
+// WriteStatusFile atomically writes a status record to a file
+func WriteStatusFile(filename string, status Status) error {
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal status: %w", err)
+	}
+
+	// Write to a temp file in the same directory and rename so readers never see a partial file
+	tmpFile := filepath.Join(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp")
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write status file: %w", err)
+	}
+	if err := os.Rename(tmpFile, filename); err != nil {
+		return fmt.Errorf("failed to replace status file: %w", err)
+	}
+
+	return nil
+}
+
+// ReadStatusFile reads a status record written by WriteStatusFile
+func ReadStatusFile(filename string) (*Status, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status file: %w", err)
+	}
+
+	var status Status
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse status file: %w", err)
+	}
+
+	return &status, nil
+}
@@ -0,0 +1,156 @@
+package logger
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultSlowWriteThreshold is how long an append may take before DailyLogger reports it as slow
+const DefaultSlowWriteThreshold = 250 * time.Millisecond
+
+// slowWriteLogInterval limits how often slow writes are logged, so a struggling disk doesn't also flood the log
+const slowWriteLogInterval = 10 * time.Second
+
+// writeLatencyBounds are the upper bounds of the write latency histogram's buckets; slower writes fall in a final
+// "+Inf" bucket
+var writeLatencyBounds = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// LatencyBucket counts the writes that took at most LE (and more than the previous bucket's bound)
+type LatencyBucket struct {
+	LE    string `json:"le"` // Upper bound, e.g. "10ms", or "+Inf"
+	Count int64  `json:"count"`
+}
+
+// WriteStatus reports the daily logger's write path: how long appends take, how many were slow or failed, and
+// whether that happened since the previous heartbeat
+type WriteStatus struct {
+	Writes          int64           `json:"writes"`
+	Errors          int64           `json:"errors"`        // Appends that failed (open, write, or close)
+	Slow            int64           `json:"slow"`          // Appends slower than the threshold
+	RecentErrors    int64           `json:"recent_errors"` // Failed appends since the previous heartbeat
+	RecentSlow      int64           `json:"recent_slow"`   // Slow appends since the previous heartbeat
+	SlowThresholdMs float64         `json:"slow_threshold_ms"`
+	MaxMs           float64         `json:"max_ms"` // Slowest append since the logger started
+	LastSlowAt      *time.Time      `json:"last_slow_at,omitempty"`
+	LastError       string          `json:"last_error,omitempty"`
+	Latency         []LatencyBucket `json:"latency"`
+}
+
+// Degraded reports whether appends failed or were slow since the previous heartbeat, e.g. a failing disk or a
+// stalled network filesystem that will lose data if it gets worse
+func (s WriteStatus) Degraded() bool {
+	return s.RecentErrors > 0 || s.RecentSlow > 0
+}
+
+// writeMetrics accumulates the daily logger's write latencies and detects slow writes
+type writeMetrics struct {
+	threshold    time.Duration // 0 disables slow-write detection
+	writes       int64
+	errors       int64
+	slow         int64
+	recentErrors int64
+	recentSlow   int64
+	max          time.Duration
+	lastSlowAt   time.Time
+	lastError    string
+	buckets      []int64 // One per writeLatencyBounds entry, then +Inf
+
+	lastReported time.Time // When a slow write was last logged
+	unreported   int64     // Slow writes since then that weren't logged
+	mu           sync.Mutex
+}
+
+// newWriteMetrics creates write metrics reporting appends slower than threshold
+func newWriteMetrics(threshold time.Duration) *writeMetrics {
+	return &writeMetrics{
+		threshold: threshold,
+		buckets:   make([]int64, len(writeLatencyBounds)+1),
+	}
+}
+
+// record adds one append to path that took took, logging it if it was slow
+func (m *writeMetrics) record(path string, took time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.writes++
+	bucket := len(writeLatencyBounds)
+	for i, bound := range writeLatencyBounds {
+		if took <= bound {
+			bucket = i
+			break
+		}
+	}
+	m.buckets[bucket]++
+	if took > m.max {
+		m.max = took
+	}
+	if err != nil {
+		m.errors++
+		m.recentErrors++
+		m.lastError = err.Error()
+	}
+
+	if m.threshold <= 0 || took <= m.threshold {
+		return
+	}
+	now := time.Now()
+	m.slow++
+	m.recentSlow++
+	m.lastSlowAt = now
+	if now.Sub(m.lastReported) < slowWriteLogInterval {
+		m.unreported++
+		return
+	}
+	if m.unreported > 0 {
+		log.Printf("Slow log write: %s took %s (threshold %s, %d more slow writes since the last report)", path, took, m.threshold, m.unreported)
+	} else {
+		log.Printf("Slow log write: %s took %s (threshold %s)", path, took, m.threshold)
+	}
+	m.lastReported = now
+	m.unreported = 0
+}
+
+// snapshot returns the current write status and resets the recent counts
+func (m *writeMetrics) snapshot() WriteStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := WriteStatus{
+		Writes:          m.writes,
+		Errors:          m.errors,
+		Slow:            m.slow,
+		RecentErrors:    m.recentErrors,
+		RecentSlow:      m.recentSlow,
+		SlowThresholdMs: float64(m.threshold) / float64(time.Millisecond),
+		MaxMs:           float64(m.max) / float64(time.Millisecond),
+		LastError:       m.lastError,
+		Latency:         make([]LatencyBucket, 0, len(m.buckets)),
+	}
+	if !m.lastSlowAt.IsZero() {
+		lastSlowAt := m.lastSlowAt
+		status.LastSlowAt = &lastSlowAt
+	}
+	for i, count := range m.buckets {
+		le := "+Inf"
+		if i < len(writeLatencyBounds) {
+			le = writeLatencyBounds[i].String()
+		}
+		status.Latency = append(status.Latency, LatencyBucket{LE: le, Count: count})
+	}
+	m.recentErrors = 0
+	m.recentSlow = 0
+	return status
+}
@@ -0,0 +1,40 @@
+package market
+
+import (
+	"fmt"
+	"time"
+
+	// Embed the IANA database so Eastern and analysis timezones observe DST on hosts without zoneinfo
+	_ "time/tzdata"
+)
+
+// Location is the exchange timezone (US options trade on Eastern Time)
+var Location = mustLoadLocation("America/New_York")
+
+// Regular trading session bounds, expressed as offsets from midnight Eastern Time
+const (
+	OpenOffset  = 9*time.Hour + 30*time.Minute
+	CloseOffset = 16 * time.Hour
+)
+
+// mustLoadLocation loads a timezone and panics if it cannot be found
+// A fixed-offset fallback would silently shift every session boundary by an hour during daylight saving time
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(fmt.Sprintf("market: failed to load timezone %q: %v", name, err))
+	}
+	return loc
+}
+
+// OpenTime returns the regular session open (09:30 ET) on the Eastern Time date containing t
+func OpenTime(t time.Time) time.Time {
+	et := t.In(Location)
+	return time.Date(et.Year(), et.Month(), et.Day(), 0, 0, 0, 0, Location).Add(OpenOffset)
+}
+
+// CloseTime returns the regular session close (16:00 ET) on the Eastern Time date containing t
+func CloseTime(t time.Time) time.Time {
+	et := t.In(Location)
+	return time.Date(et.Year(), et.Month(), et.Day(), 0, 0, 0, 0, Location).Add(CloseOffset)
+}
@@ -0,0 +1,63 @@
+package market
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocationObservesDaylightSaving(t *testing.T) {
+	tests := []struct {
+		name       string
+		at         time.Time
+		wantOffset int
+		wantOpen   time.Time
+		wantClose  time.Time
+	}{
+		{
+			name:       "EST",
+			at:         time.Date(2025, 11, 26, 17, 0, 0, 0, time.UTC),
+			wantOffset: -5 * 60 * 60,
+			wantOpen:   time.Date(2025, 11, 26, 14, 30, 0, 0, time.UTC),
+			wantClose:  time.Date(2025, 11, 26, 21, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "EDT",
+			at:         time.Date(2025, 7, 1, 17, 0, 0, 0, time.UTC),
+			wantOffset: -4 * 60 * 60,
+			wantOpen:   time.Date(2025, 7, 1, 13, 30, 0, 0, time.UTC),
+			wantClose:  time.Date(2025, 7, 1, 20, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, offset := tt.at.In(Location).Zone(); offset != tt.wantOffset {
+				t.Errorf("offset = %d, want %d", offset, tt.wantOffset)
+			}
+			if got := OpenTime(tt.at); !got.Equal(tt.wantOpen) {
+				t.Errorf("OpenTime = %v, want %v", got.UTC(), tt.wantOpen)
+			}
+			if got := CloseTime(tt.at); !got.Equal(tt.wantClose) {
+				t.Errorf("CloseTime = %v, want %v", got.UTC(), tt.wantClose)
+			}
+		})
+	}
+}
+
+func TestAnalysisLocationObservesDaylightSaving(t *testing.T) {
+	loc := AnalysisLocation()
+	_, summer := time.Date(2025, 7, 1, 12, 0, 0, 0, loc).Zone()
+	_, winter := time.Date(2025, 1, 15, 12, 0, 0, 0, loc).Zone()
+	if summer-winter != 60*60 {
+		t.Errorf("summer offset %d, winter offset %d, want a one-hour DST shift", summer, winter)
+	}
+}
+
+func TestMustLoadLocationPanicsOnUnknownZone(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("mustLoadLocation did not panic for an unknown timezone")
+		}
+	}()
+	mustLoadLocation("Not/AZone")
+}
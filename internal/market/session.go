@@ -0,0 +1,122 @@
+package market
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/scmhub/calendar"
+)
+
+// Trading session labels
+const (
+	SessionPremarket  = "premarket"  // Before the 09:30 ET open on a trading day
+	SessionRegular    = "regular"    // 09:30 ET to the close (16:00 ET, or 13:00 ET on early-close days)
+	SessionAfterHours = "afterhours" // After the close on a trading day
+	SessionClosed     = "closed"     // Weekends and exchange holidays
+)
+
+// nyse is the exchange calendar used for holidays and early closes
+var nyse = calendar.XNYS()
+
+// inCalendarRange reports whether t falls within the years covered by the exchange calendar
+// The calendar panics on out-of-range dates, so callers fall back to weekday checks outside it
+func inCalendarRange(t time.Time) bool {
+	start, end := nyse.Years()
+	return t.Year() >= start && t.Year() <= end
+}
+
+// IsTradingDay reports whether the Eastern Time date containing t is an exchange trading day
+// Years in the trading-days dataset are looked up there; others fall back to the exchange calendar
+func IsTradingDay(t time.Time) bool {
+	et := t.In(Location)
+	if days := CurrentTradingDays(); days.Covers(et.Year()) {
+		return days.Contains(et.Format("2006-01-02"))
+	}
+	if !inCalendarRange(et) {
+		return et.Weekday() != time.Saturday && et.Weekday() != time.Sunday
+	}
+	return nyse.IsBusinessDay(et)
+}
+
+// SessionCloseTime returns the regular session close on the Eastern Time date containing t,
+// accounting for early-close days (13:00 ET)
+func SessionCloseTime(t time.Time) time.Time {
+	et := t.In(Location)
+	if inCalendarRange(et) && nyse.IsBusinessDay(et) && nyse.IsEarlyClose(et) {
+		return time.Date(et.Year(), et.Month(), et.Day(), 0, 0, 0, 0, Location).Add(nyse.Session().EarlyClose)
+	}
+	return CloseTime(t)
+}
+
+// SessionForTime returns the trading session label for a point in time
+func SessionForTime(t time.Time) string {
+	if !IsTradingDay(t) {
+		return SessionClosed
+	}
+	if t.Before(OpenTime(t)) {
+		return SessionPremarket
+	}
+	if t.Before(SessionCloseTime(t)) {
+		return SessionRegular
+	}
+	return SessionAfterHours
+}
+
+// SessionSet is a set of trading sessions used for filtering (zero value matches every session)
+type SessionSet uint8
+
+const (
+	sessionPremarketBit SessionSet = 1 << iota
+	sessionRegularBit
+	sessionAfterHoursBit
+	sessionClosedBit
+)
+
+// sessionBits maps session labels to their set bit
+var sessionBits = map[string]SessionSet{
+	SessionPremarket:  sessionPremarketBit,
+	SessionRegular:    sessionRegularBit,
+	SessionAfterHours: sessionAfterHoursBit,
+	SessionClosed:     sessionClosedBit,
+}
+
+// ParseSessionSet parses a comma-separated list of session labels (e.g. "premarket,regular")
+// An empty string returns the zero set, which matches every session
+func ParseSessionSet(value string) (SessionSet, error) {
+	var set SessionSet
+	for _, part := range strings.Split(value, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		bit, ok := sessionBits[part]
+		if !ok {
+			return 0, fmt.Errorf("invalid session %q, expected one of: %s, %s, %s, %s", part, SessionPremarket, SessionRegular, SessionAfterHours, SessionClosed)
+		}
+		set |= bit
+	}
+	return set, nil
+}
+
+// Contains reports whether the set includes a session label (the zero set contains everything)
+func (s SessionSet) Contains(session string) bool {
+	if s == 0 {
+		return true
+	}
+	return s&sessionBits[session] != 0
+}
+
+// String returns the comma-separated session labels in the set
+func (s SessionSet) String() string {
+	if s == 0 {
+		return "all"
+	}
+	var labels []string
+	for _, label := range []string{SessionPremarket, SessionRegular, SessionAfterHours, SessionClosed} {
+		if s&sessionBits[label] != 0 {
+			labels = append(labels, label)
+		}
+	}
+	return strings.Join(labels, ",")
+}
@@ -0,0 +1,46 @@
+package market
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/clock"
+)
+
+// DefaultTimezone is the analysis timezone used unless a deployment configures another one
+const DefaultTimezone = "America/Los_Angeles"
+
+// analysisLocation is the timezone log files are dated in and wall-clock periods are aligned to
+var analysisLocation atomic.Pointer[time.Location]
+
+func init() {
+	analysisLocation.Store(mustLoadLocation(DefaultTimezone))
+}
+
+// SetAnalysisTimezone sets the timezone log files are dated in and midnight-anchored periods are aligned to
+// The name is an IANA timezone such as "America/New_York" or "Europe/London"; it should be set once at startup,
+// and every service reading the same log directory must use the same timezone
+func SetAnalysisTimezone(name string) error {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("invalid timezone %q: %w", name, err)
+	}
+	analysisLocation.Store(loc)
+	return nil
+}
+
+// AnalysisLocation returns the analysis timezone (America/Los_Angeles unless configured)
+func AnalysisLocation() *time.Location {
+	return analysisLocation.Load()
+}
+
+// DateOf returns the date (YYYY-MM-DD) containing t in the analysis timezone, i.e. the date of its log file
+func DateOf(t time.Time) string {
+	return t.In(AnalysisLocation()).Format("2006-01-02")
+}
+
+// Today returns the current date (YYYY-MM-DD) in the analysis timezone, on the clock in use (see package clock)
+func Today() string {
+	return DateOf(clock.Now())
+}
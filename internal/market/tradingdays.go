@@ -0,0 +1,196 @@
+package market
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/scmhub/calendar"
+)
+
+//go:generate go run ../../cmd/trading-days --from-year 2024 --to-year 2027 --output tradingdays.json --quiet
+
+// embeddedTradingDays is the trading-days dataset generated by cmd/trading-days at build time
+//
+//go:embed tradingdays.json
+var embeddedTradingDays []byte
+
+// TradingDaysYear lists one year's trading days
+type TradingDaysYear struct {
+	GeneratedDate string   `json:"generated_date"`
+	Year          int      `json:"year"`
+	TradingDays   []string `json:"trading_days"`
+}
+
+// TradingDays is the trading-days dataset written by cmd/trading-days (YYYY-MM-DD dates, NYSE calendar)
+type TradingDays struct {
+	GeneratedDate  string                     `json:"generated_date"`
+	Years          map[string]TradingDaysYear `json:"years"`            // Key: year
+	AllTradingDays []string                   `json:"all_trading_days"` // Every year's days, sorted
+
+	days map[string]bool // Index of AllTradingDays
+}
+
+// tradingDays is the dataset in use: the embedded one, extended by RefreshTradingDays as years roll over
+var tradingDays atomic.Pointer[TradingDays]
+
+func init() {
+	days, err := ParseTradingDays(embeddedTradingDays)
+	if err != nil {
+		// The embedded file is generated, so this only happens with a broken build; fall back to the calendar
+		now := time.Now()
+		days = GenerateTradingDays(now.Year(), now.Year()+1, now)
+	}
+	tradingDays.Store(days)
+}
+
+// GenerateTradingDays builds the dataset for fromYear through toYear from the NYSE calendar
+func GenerateTradingDays(fromYear int, toYear int, now time.Time) *TradingDays {
+	// Initialize the calendar with every year so holidays are calculated correctly
+	cal := calendar.XNYS(fromYear, toYear)
+	generated := now.Format("2006-01-02")
+
+	days := &TradingDays{GeneratedDate: generated, Years: make(map[string]TradingDaysYear)}
+	for year := fromYear; year <= toYear; year++ {
+		yearDays := tradingDaysForYear(cal, year)
+		days.Years[strconv.Itoa(year)] = TradingDaysYear{GeneratedDate: generated, Year: year, TradingDays: yearDays}
+		days.AllTradingDays = append(days.AllTradingDays, yearDays...)
+	}
+	sort.Strings(days.AllTradingDays)
+	days.index()
+	return days
+}
+
+// tradingDaysForYear lists the days of a year the exchange is open
+func tradingDaysForYear(cal *calendar.Calendar, year int) []string {
+	var days []string
+	for date := time.Date(year, time.January, 1, 0, 0, 0, 0, Location); date.Year() == year; date = date.AddDate(0, 0, 1) {
+		// Check if the market is open at 10:00 AM ET, which excludes weekends and holidays but not early closes
+		checkTime := time.Date(date.Year(), date.Month(), date.Day(), 10, 0, 0, 0, Location)
+		if cal.IsOpen(checkTime) {
+			days = append(days, date.Format("2006-01-02"))
+		}
+	}
+	return days
+}
+
+// ParseTradingDays parses a dataset written by cmd/trading-days
+func ParseTradingDays(data []byte) (*TradingDays, error) {
+	var days TradingDays
+	if err := json.Unmarshal(data, &days); err != nil {
+		return nil, fmt.Errorf("failed to parse trading days: %w", err)
+	}
+	if len(days.AllTradingDays) == 0 {
+		return nil, fmt.Errorf("trading days data has no all_trading_days")
+	}
+	sort.Strings(days.AllTradingDays)
+	days.index()
+	return &days, nil
+}
+
+// LoadTradingDays reads a dataset file written by cmd/trading-days
+func LoadTradingDays(path string) (*TradingDays, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trading days file: %w", err)
+	}
+	return ParseTradingDays(data)
+}
+
+// CurrentTradingDays returns the dataset in use (embedded, or refreshed since startup)
+func CurrentTradingDays() *TradingDays {
+	return tradingDays.Load()
+}
+
+// index builds the date lookup used by Contains
+func (d *TradingDays) index() {
+	d.days = make(map[string]bool, len(d.AllTradingDays))
+	for _, day := range d.AllTradingDays {
+		d.days[day] = true
+	}
+}
+
+// Covers reports whether the dataset includes the given year
+func (d *TradingDays) Covers(year int) bool {
+	_, ok := d.Years[strconv.Itoa(year)]
+	return ok
+}
+
+// Contains reports whether date (YYYY-MM-DD) is a trading day; only meaningful for years the dataset covers
+func (d *TradingDays) Contains(date string) bool {
+	return d.days[date]
+}
+
+// Past returns the last n trading days on or before asOf (YYYY-MM-DD), oldest first
+// Fewer than n days are returned when the dataset starts later
+func (d *TradingDays) Past(asOf string, n int) []string {
+	end := sort.Search(len(d.AllTradingDays), func(i int) bool { return d.AllTradingDays[i] > asOf })
+	start := end - n
+	if start < 0 {
+		start = 0
+	}
+	return d.AllTradingDays[start:end]
+}
+
+// LatestTradingDate returns date (YYYY-MM-DD) if it is a trading day, otherwise the most recent trading day before it
+// Dates that don't parse are returned unchanged, for the caller's own validation to reject
+func LatestTradingDate(date string) string {
+	day, err := time.ParseInLocation("2006-01-02", date, Location)
+	if err != nil {
+		return date
+	}
+	// No exchange closure lasts longer than a couple of weeks; the bound only guards against a broken calendar
+	for i := 0; i < 14 && !IsTradingDay(day); i++ {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day.Format("2006-01-02")
+}
+
+// DefaultDate returns the date used when a request doesn't give one: today in the analysis timezone,
+// or the most recent trading session when today is a weekend or exchange holiday
+func DefaultDate() string {
+	return LatestTradingDate(Today())
+}
+
+// RefreshTradingDays regenerates the dataset in use when it doesn't cover next year, keeping the years it has
+// It reports whether the dataset changed
+func RefreshTradingDays(now time.Time) bool {
+	current := tradingDays.Load()
+	nextYear := now.Year() + 1
+	if current.Covers(now.Year()) && current.Covers(nextYear) {
+		return false
+	}
+
+	fromYear := now.Year()
+	for key := range current.Years {
+		if year, err := strconv.Atoi(key); err == nil && year < fromYear {
+			fromYear = year
+		}
+	}
+	tradingDays.Store(GenerateTradingDays(fromYear, nextYear, now))
+	return true
+}
+
+// StartTradingDaysRefresh refreshes the dataset in use now and then every interval, so long-running services
+// pick up the next year without a rebuild
+func StartTradingDaysRefresh(interval time.Duration) {
+	refresh := func() {
+		if RefreshTradingDays(time.Now()) {
+			log.Printf("Regenerated trading days through %d", time.Now().Year()+1)
+		}
+	}
+	refresh()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			refresh()
+		}
+	}()
+}
@@ -0,0 +1,49 @@
+package marketdata
+
+import (
+	"context"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/websocket"
+)
+
+// ChaosStream is a StreamSource that injects connection drops, delays, and duplicate messages into another stream
+// It is meant for mock environments and tests: the logger only accepts it with the stub vendor
+type ChaosStream struct {
+	stream   StreamSource
+	injector *websocket.FaultInjector
+}
+
+// NewChaosStream wraps a stream with the given faults
+func NewChaosStream(stream StreamSource, faults websocket.Faults) *ChaosStream {
+	return &ChaosStream{stream: stream, injector: websocket.NewFaultInjector(faults)}
+}
+
+// Connect connects the wrapped stream
+func (s *ChaosStream) Connect() error {
+	return s.stream.Connect()
+}
+
+// Subscribe subscribes the wrapped stream
+func (s *ChaosStream) Subscribe(pattern string) error {
+	return s.stream.Subscribe(pattern)
+}
+
+// Run delivers the wrapped stream's aggregates through the fault injector
+func (s *ChaosStream) Run(ctx context.Context, handler func(analysis.Aggregate)) error {
+	return s.stream.Run(ctx, func(agg analysis.Aggregate) {
+		s.injector.Deliver(ctx, func() {
+			handler(agg)
+		})
+	})
+}
+
+// Close closes the wrapped stream
+func (s *ChaosStream) Close() {
+	s.stream.Close()
+}
+
+// Stats returns the faults injected so far
+func (s *ChaosStream) Stats() websocket.FaultStats {
+	return s.injector.Stats()
+}
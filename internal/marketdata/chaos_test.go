@@ -0,0 +1,143 @@
+package marketdata
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/websocket"
+)
+
+// sequenceStream is a StreamSource delivering n aggregates numbered by StartTimestamp, interval apart
+type sequenceStream struct {
+	n          int
+	interval   time.Duration
+	connected  bool
+	subscribed []string
+	closed     bool
+}
+
+func (s *sequenceStream) Connect() error {
+	s.connected = true
+	return nil
+}
+
+func (s *sequenceStream) Subscribe(pattern string) error {
+	s.subscribed = append(s.subscribed, pattern)
+	return nil
+}
+
+func (s *sequenceStream) Run(ctx context.Context, handler func(analysis.Aggregate)) error {
+	for i := 0; i < s.n; i++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		handler(analysis.Aggregate{Symbol: "O:AAPL251128C00150000", Volume: 1, VWAP: 1, StartTimestamp: int64(i)})
+		time.Sleep(s.interval)
+	}
+	return nil
+}
+
+func (s *sequenceStream) Close() {
+	s.closed = true
+}
+
+func TestChaosStreamPassesThrough(t *testing.T) {
+	inner := &sequenceStream{n: 50}
+	stream := NewChaosStream(inner, websocket.Faults{})
+	if err := stream.Connect(); err != nil || !inner.connected {
+		t.Errorf("Connect = %v, connected %v", err, inner.connected)
+	}
+	if err := stream.Subscribe("O:AAPL*"); err != nil || len(inner.subscribed) != 1 || inner.subscribed[0] != "O:AAPL*" {
+		t.Errorf("Subscribe = %v, subscribed %v", err, inner.subscribed)
+	}
+
+	received := 0
+	if err := stream.Run(context.Background(), func(agg analysis.Aggregate) {
+		if agg.StartTimestamp != int64(received) {
+			t.Fatalf("aggregate %d delivered as number %d", agg.StartTimestamp, received)
+		}
+		received++
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if received != 50 {
+		t.Errorf("received %d of 50 aggregates without faults", received)
+	}
+
+	stream.Close()
+	if !inner.closed {
+		t.Error("Close didn't close the wrapped stream")
+	}
+}
+
+// TestChaosStreamDropsLeaveGaps checks that a drop is a window of lost aggregates after which the stream carries on,
+// as when the vendor client reconnects on its own, and that duplicates repeat an aggregate in place
+func TestChaosStreamDropsLeaveGaps(t *testing.T) {
+	inner := &sequenceStream{n: 100, interval: time.Millisecond}
+	stream := NewChaosStream(inner, websocket.Faults{
+		DropEvery:     25 * time.Millisecond,
+		DropFor:       10 * time.Millisecond,
+		DuplicateRate: 0.1,
+		Seed:          5,
+	})
+
+	var received []int64
+	if err := stream.Run(context.Background(), func(agg analysis.Aggregate) {
+		received = append(received, agg.StartTimestamp)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := stream.Stats()
+	if stats.Drops == 0 || stats.Lost == 0 || stats.Duplicated == 0 {
+		t.Fatalf("stats = %+v, want drops, lost aggregates, and duplicates", stats)
+	}
+	if int64(len(received)) != 100-stats.Lost+stats.Duplicated {
+		t.Errorf("received %d aggregates with %d lost and %d duplicated, want %d", len(received), stats.Lost, stats.Duplicated, 100-stats.Lost+stats.Duplicated)
+	}
+
+	var missing, repeated int64
+	for i := 1; i < len(received); i++ {
+		switch step := received[i] - received[i-1]; {
+		case step == 0:
+			repeated++
+		case step < 0:
+			t.Fatalf("aggregate %d received after %d", received[i], received[i-1])
+		default:
+			missing += step - 1
+		}
+	}
+	missing += received[0] + (99 - received[len(received)-1])
+	if missing != stats.Lost || repeated != stats.Duplicated {
+		t.Errorf("%d aggregates missing and %d repeated, want the %d lost and %d duplicated", missing, repeated, stats.Lost, stats.Duplicated)
+	}
+	if received[len(received)-1] < 90 {
+		t.Errorf("last aggregate received is %d, want the stream to recover after drops", received[len(received)-1])
+	}
+}
+
+func TestChaosStreamStopsOnCancel(t *testing.T) {
+	inner := &sequenceStream{n: 1000}
+	stream := NewChaosStream(inner, websocket.Faults{Delay: time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	received := 0
+	go func() {
+		done <- stream.Run(ctx, func(analysis.Aggregate) { received++ })
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("Run = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't stop while an aggregate was delayed")
+	}
+	if received != 0 {
+		t.Errorf("received %d aggregates delayed by an hour", received)
+	}
+}
@@ -0,0 +1,71 @@
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+)
+
+// FetchDay fetches the aggregates of every option contract for an underlying on date, sorted by start time
+func FetchDay(ctx context.Context, history HistorySource, underlying string, date time.Time, workers int) ([]analysis.Aggregate, int, error) {
+	contracts, err := history.ListOptionContracts(ctx, underlying)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list option contracts: %w", err)
+	}
+	return FetchContracts(ctx, history, contracts, date, workers, nil)
+}
+
+// FetchContracts fetches the aggregates of contracts on date, sorted by start time
+// Contracts are fetched by up to workers goroutines; progress (if non-nil) is called as each contract starts
+// Contracts that fail are logged and skipped, and their count is returned alongside the aggregates
+func FetchContracts(ctx context.Context, history HistorySource, contracts []OptionContract, date time.Time, workers int, progress func(index int, total int)) ([]analysis.Aggregate, int, error) {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var (
+		mu         sync.Mutex
+		aggregates []analysis.Aggregate
+		errorCount int
+		wg         sync.WaitGroup
+	)
+	semaphore := make(chan struct{}, workers) // Limit concurrent requests
+
+	for i, contract := range contracts {
+		wg.Add(1)
+		go func(c OptionContract, idx int) {
+			defer wg.Done()
+			semaphore <- struct{}{}        // Acquire semaphore
+			defer func() { <-semaphore }() // Release semaphore
+
+			if progress != nil {
+				progress(idx, len(contracts))
+			}
+
+			aggs, err := history.GetOptionAggregates(ctx, c.Ticker, date)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log.Printf("Warning: error fetching aggregates for %s: %v", c.Ticker, err)
+				errorCount++
+				return
+			}
+			aggregates = append(aggregates, aggs...)
+		}(contract, i)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, errorCount, err
+	}
+
+	sort.Slice(aggregates, func(i, j int) bool {
+		return aggregates[i].StartTimestamp < aggregates[j].StartTimestamp
+	})
+	return aggregates, errorCount, nil
+}
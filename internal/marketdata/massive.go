@@ -0,0 +1,123 @@
+package marketdata
+
+import (
+	"context"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/rest"
+	"github.com/ekinolik/jax-ov/internal/websocket"
+	"github.com/massive-com/client-go/v2/websocket/models"
+)
+
+// MassiveStream is a StreamSource backed by the massive.com WebSocket API
+type MassiveStream struct {
+	client    *websocket.Client
+	eventType string
+}
+
+// NewMassiveStream creates a massive.com live stream of per-second or per-minute aggregates
+func NewMassiveStream(apiKey string, timespan string) (*MassiveStream, error) {
+	client, err := websocket.NewClientWithTimespan(apiKey, timespan)
+	if err != nil {
+		return nil, err
+	}
+	return &MassiveStream{client: client, eventType: analysis.TimespanEventType(timespan)}, nil
+}
+
+// Connect establishes the WebSocket connection
+func (s *MassiveStream) Connect() error {
+	return s.client.Connect()
+}
+
+// Subscribe subscribes to option aggregates for a contract or wildcard pattern
+func (s *MassiveStream) Subscribe(pattern string) error {
+	return s.client.Subscribe(pattern)
+}
+
+// Run delivers aggregates converted to analysis.Aggregate
+func (s *MassiveStream) Run(ctx context.Context, handler func(analysis.Aggregate)) error {
+	return s.client.Run(ctx, func(agg models.EquityAgg) {
+		handler(convertEquityAgg(agg, s.eventType))
+	})
+}
+
+// Close closes the WebSocket connection
+func (s *MassiveStream) Close() {
+	s.client.Close()
+}
+
+// convertEquityAgg converts a massive.com websocket EquityAgg to analysis.Aggregate
+// Per-second and per-minute aggregates share the EquityAgg shape and differ only in event type
+func convertEquityAgg(agg models.EquityAgg, eventType string) analysis.Aggregate {
+	return analysis.Aggregate{
+		EventType:         eventType,
+		Symbol:            agg.Symbol,
+		Volume:            int64(agg.Volume),
+		AccumulatedVolume: int64(agg.AccumulatedVolume),
+		OfficialOpenPrice: agg.OfficialOpenPrice,
+		VWAP:              agg.VWAP,
+		Open:              agg.Open,
+		High:              agg.High,
+		Low:               agg.Low,
+		Close:             agg.Close,
+		AggregateVWAP:     agg.AggregateVWAP,
+		AverageSize:       int64(agg.AverageSize),
+		StartTimestamp:    agg.StartTimestamp,
+		EndTimestamp:      agg.EndTimestamp,
+	}
+}
+
+// MassiveHistory is a HistorySource backed by the massive.com REST API
+type MassiveHistory struct {
+	client   *rest.Client
+	timespan string
+}
+
+// NewMassiveHistory creates a massive.com historical data source of per-second or per-minute aggregates
+func NewMassiveHistory(apiKey string, timespan string) *MassiveHistory {
+	return &MassiveHistory{client: rest.NewClient(apiKey), timespan: timespan}
+}
+
+// ListOptionContracts returns all option contracts for an underlying ticker
+func (h *MassiveHistory) ListOptionContracts(ctx context.Context, underlyingTicker string) ([]OptionContract, error) {
+	contracts, err := h.client.ListOptionContracts(ctx, underlyingTicker)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]OptionContract, len(contracts))
+	for i, contract := range contracts {
+		result[i] = OptionContract(contract)
+	}
+	return result, nil
+}
+
+// GetOptionAggregates returns aggregates at the configured timespan for a contract during the regular session on date
+func (h *MassiveHistory) GetOptionAggregates(ctx context.Context, contractTicker string, date time.Time) ([]analysis.Aggregate, error) {
+	aggregates, err := h.client.GetOptionAggregatesWithTimespan(ctx, contractTicker, date, h.timespan)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]analysis.Aggregate, len(aggregates))
+	for i, agg := range aggregates {
+		result[i] = analysis.Aggregate{
+			EventType:         agg.EventType,
+			Symbol:            agg.Symbol,
+			Volume:            agg.Volume,
+			AccumulatedVolume: agg.AccumulatedVolume,
+			OfficialOpenPrice: agg.OfficialOpenPrice,
+			VWAP:              agg.VWAP,
+			Open:              agg.Open,
+			High:              agg.High,
+			Low:               agg.Low,
+			Close:             agg.Close,
+			AggregateVWAP:     agg.AggregateVWAP,
+			AverageSize:       agg.AverageSize,
+			StartTimestamp:    agg.StartTimestamp,
+			EndTimestamp:      agg.EndTimestamp,
+		}
+	}
+	return result, nil
+}
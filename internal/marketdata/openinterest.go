@@ -0,0 +1,182 @@
+package marketdata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/market"
+	"github.com/ekinolik/jax-ov/internal/rest"
+)
+
+// OpenInterestSource fetches open interest for an underlying's option chain
+type OpenInterestSource interface {
+	// ChainOpenInterest returns the open interest of each contract on an underlying, keyed by option symbol,
+	// as of the start of the current (or most recent) session
+	ChainOpenInterest(ctx context.Context, underlying string) (map[string]int64, error)
+}
+
+// NewOpenInterestSource creates an open interest source for a vendor
+func NewOpenInterestSource(vendor string, apiKey string) (OpenInterestSource, error) {
+	switch vendor {
+	case VendorMassive:
+		return &MassiveOpenInterest{client: rest.NewClient(apiKey)}, nil
+	case VendorStub:
+		return StubOpenInterest{}, nil
+	default:
+		return nil, ValidateVendor(vendor)
+	}
+}
+
+// MassiveOpenInterest is an OpenInterestSource backed by the massive.com option chain snapshot
+type MassiveOpenInterest struct {
+	client *rest.Client
+}
+
+// ChainOpenInterest returns the open interest of every contract in the underlying's chain snapshot
+func (s *MassiveOpenInterest) ChainOpenInterest(ctx context.Context, underlying string) (map[string]int64, error) {
+	contracts, err := s.client.GetOptionChainOpenInterest(ctx, underlying)
+	if err != nil {
+		return nil, err
+	}
+
+	openInterest := make(map[string]int64, len(contracts))
+	for _, contract := range contracts {
+		openInterest[contract.Ticker] = contract.OpenInterest
+	}
+	return openInterest, nil
+}
+
+// StubOpenInterest is an OpenInterestSource for the stub chain
+// The at-the-money strike has little open interest, so its volume/OI ratio stands out as unusual activity
+type StubOpenInterest struct{}
+
+// ChainOpenInterest returns fixed open interest for today's stub chain
+func (StubOpenInterest) ChainOpenInterest(ctx context.Context, underlying string) (map[string]int64, error) {
+	contracts := stubContracts(strings.ToUpper(underlying), time.Now())
+	openInterest := make(map[string]int64, len(contracts))
+	for _, contract := range contracts {
+		openInterest[contract.Ticker] = 5000
+		if contract.StrikePrice == 100 {
+			openInterest[contract.Ticker] = 200
+		}
+	}
+	return openInterest, nil
+}
+
+// OpenInterestSnapshot is an underlying's open interest for one session, as saved by OpenInterestCache
+type OpenInterestSnapshot struct {
+	Underlying   string           `json:"underlying"`
+	Date         string           `json:"date"` // Session date (YYYY-MM-DD in the analysis timezone)
+	FetchedAt    time.Time        `json:"fetched_at"`
+	OpenInterest map[string]int64 `json:"open_interest"` // Map: option symbol -> open interest
+}
+
+// OpenInterestCache keeps one open interest snapshot per underlying and session date, in memory and as JSON files
+// (UNDERLYING_YYYY-MM-DD.oi.json) in a directory
+// Open interest is only published for the current session, so a date can be fetched while it is the most recent
+// session; after that, only a snapshot saved at the time is available
+type OpenInterestCache struct {
+	source OpenInterestSource // nil to serve saved snapshots only
+	dir    string
+
+	mu        sync.Mutex
+	snapshots map[string]*OpenInterestSnapshot // Key: underlying and date
+}
+
+// NewOpenInterestCache creates a cache that saves snapshots fetched from source to dir
+func NewOpenInterestCache(source OpenInterestSource, dir string) *OpenInterestCache {
+	return &OpenInterestCache{
+		source:    source,
+		dir:       dir,
+		snapshots: make(map[string]*OpenInterestSnapshot),
+	}
+}
+
+// Get returns the open interest snapshot for an underlying on a session date
+// It returns an error when no snapshot was saved for the date and the date is no longer the most recent session
+func (c *OpenInterestCache) Get(ctx context.Context, underlying string, date string) (*OpenInterestSnapshot, error) {
+	underlying = strings.ToUpper(underlying)
+	key := underlying + "_" + date
+
+	c.mu.Lock()
+	snapshot, ok := c.snapshots[key]
+	c.mu.Unlock()
+	if ok {
+		return snapshot, nil
+	}
+
+	filename := filepath.Join(c.dir, key+".oi.json")
+	snapshot, err := readOpenInterestSnapshot(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if snapshot == nil {
+		if c.source == nil || date != market.DefaultDate() {
+			return nil, fmt.Errorf("no open interest saved for %s on %s (open interest can only be fetched for the most recent session)", underlying, date)
+		}
+		openInterest, err := c.source.ChainOpenInterest(ctx, underlying)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch open interest for %s: %w", underlying, err)
+		}
+		snapshot = &OpenInterestSnapshot{
+			Underlying:   underlying,
+			Date:         date,
+			FetchedAt:    time.Now().UTC(),
+			OpenInterest: openInterest,
+		}
+		if err := writeOpenInterestSnapshot(filename, snapshot); err != nil {
+			return nil, err
+		}
+	}
+
+	c.mu.Lock()
+	c.snapshots[key] = snapshot
+	c.mu.Unlock()
+	return snapshot, nil
+}
+
+// readOpenInterestSnapshot reads a saved snapshot (nil if the file doesn't exist)
+func readOpenInterestSnapshot(filename string) (*OpenInterestSnapshot, error) {
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read open interest file: %w", err)
+	}
+
+	var snapshot OpenInterestSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse open interest file %s: %w", filename, err)
+	}
+	return &snapshot, nil
+}
+
+// writeOpenInterestSnapshot saves a snapshot via a temp file and rename, so readers never see a partial file
+func writeOpenInterestSnapshot(filename string, snapshot *OpenInterestSnapshot) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("failed to create open interest directory: %w", err)
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal open interest: %w", err)
+	}
+
+	tmpFile := filename + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write open interest file: %w", err)
+	}
+	if err := os.Rename(tmpFile, filename); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to replace open interest file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// Package marketdata defines vendor-neutral interfaces for live and historical option data
+// Commands depend on StreamSource and HistorySource rather than a specific vendor's client,
+// so adding a vendor means adding an implementation here instead of rewriting the commands
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+)
+
+// Supported vendors
+const (
+	VendorMassive = "massive" // massive.com WebSocket and REST APIs (default)
+	VendorStub    = "stub"    // Synthetic data for development and testing; needs no API key
+)
+
+// OptionContract describes an option contract listed for an underlying
+type OptionContract struct {
+	Ticker           string
+	ContractType     string // "call" or "put"
+	ExerciseStyle    string
+	ExpirationDate   string
+	StrikePrice      float64
+	UnderlyingTicker string
+}
+
+// StreamSource delivers live option aggregates
+type StreamSource interface {
+	// Connect establishes the connection to the vendor
+	Connect() error
+	// Subscribe subscribes to a contract symbol or wildcard pattern (e.g. "*" or "O:AAPL*")
+	Subscribe(pattern string) error
+	// Run delivers aggregates to handler until ctx is cancelled or the stream fails
+	Run(ctx context.Context, handler func(analysis.Aggregate)) error
+	// Close closes the connection
+	Close()
+}
+
+// HistorySource fetches historical option data
+type HistorySource interface {
+	// ListOptionContracts returns all option contracts for an underlying ticker
+	ListOptionContracts(ctx context.Context, underlyingTicker string) ([]OptionContract, error)
+	// GetOptionAggregates returns aggregates at the source's timespan for a contract during the regular session on date
+	GetOptionAggregates(ctx context.Context, contractTicker string, date time.Time) ([]analysis.Aggregate, error)
+}
+
+// ValidateVendor checks that a vendor name is supported
+func ValidateVendor(vendor string) error {
+	switch vendor {
+	case VendorMassive, VendorStub:
+		return nil
+	default:
+		return fmt.Errorf("unsupported market-data vendor %q (must be %s or %s)", vendor, VendorMassive, VendorStub)
+	}
+}
+
+// NewStreamSource creates a live stream of per-second or per-minute aggregates for a vendor
+func NewStreamSource(vendor string, apiKey string, timespan string) (StreamSource, error) {
+	if err := analysis.ValidateTimespan(timespan); err != nil {
+		return nil, err
+	}
+	switch vendor {
+	case VendorMassive:
+		return NewMassiveStream(apiKey, timespan)
+	case VendorStub:
+		return NewStubStream(timespan), nil
+	default:
+		return nil, ValidateVendor(vendor)
+	}
+}
+
+// NewHistorySource creates a historical data source of per-second or per-minute aggregates for a vendor
+func NewHistorySource(vendor string, apiKey string, timespan string) (HistorySource, error) {
+	if err := analysis.ValidateTimespan(timespan); err != nil {
+		return nil, err
+	}
+	switch vendor {
+	case VendorMassive:
+		return NewMassiveHistory(apiKey, timespan), nil
+	case VendorStub:
+		return NewStubHistory(timespan), nil
+	default:
+		return nil, ValidateVendor(vendor)
+	}
+}
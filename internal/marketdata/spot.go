@@ -0,0 +1,143 @@
+package marketdata
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/market"
+	"github.com/ekinolik/jax-ov/internal/rest"
+)
+
+// PriceBar is a one-minute bar for an underlying
+type PriceBar struct {
+	Start time.Time
+	Close float64
+}
+
+// SpotSource fetches current and intraday prices of an underlying
+type SpotSource interface {
+	// LastPrice returns the most recent traded price for a stock ticker
+	LastPrice(ctx context.Context, ticker string) (float64, error)
+	// PriceBars returns one-minute bars for a stock ticker covering the extended session on date (ET), oldest first
+	PriceBars(ctx context.Context, ticker string, date time.Time) ([]PriceBar, error)
+}
+
+// NewSpotSource creates a spot price source for a vendor
+func NewSpotSource(vendor string, apiKey string) (SpotSource, error) {
+	switch vendor {
+	case VendorMassive:
+		return &MassiveSpot{client: rest.NewClient(apiKey)}, nil
+	case VendorStub:
+		return StubSpot{}, nil
+	default:
+		return nil, ValidateVendor(vendor)
+	}
+}
+
+// MassiveSpot is a SpotSource backed by the massive.com last-trade REST API
+type MassiveSpot struct {
+	client *rest.Client
+}
+
+// LastPrice returns the price of the ticker's most recent trade
+func (s *MassiveSpot) LastPrice(ctx context.Context, ticker string) (float64, error) {
+	price, _, err := s.client.GetLastTradePrice(ctx, ticker)
+	return price, err
+}
+
+// PriceBars returns the ticker's one-minute bars on date
+func (s *MassiveSpot) PriceBars(ctx context.Context, ticker string, date time.Time) ([]PriceBar, error) {
+	bars, err := s.client.GetStockMinuteBars(ctx, ticker, date)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]PriceBar, len(bars))
+	for i, bar := range bars {
+		result[i] = PriceBar{Start: bar.Start, Close: bar.Close}
+	}
+	return result, nil
+}
+
+// StubSpot is a SpotSource that oscillates around 100, crossing the stub chain's 95/100/105 strikes during the day
+type StubSpot struct{}
+
+// LastPrice returns a synthetic price for the current minute
+func (StubSpot) LastPrice(ctx context.Context, ticker string) (float64, error) {
+	return stubPrice(time.Now()), nil
+}
+
+// PriceBars returns synthetic one-minute bars for the extended session on date
+func (StubSpot) PriceBars(ctx context.Context, ticker string, date time.Time) ([]PriceBar, error) {
+	start := time.Date(date.Year(), date.Month(), date.Day(), 4, 0, 0, 0, market.Location)
+	end := time.Date(date.Year(), date.Month(), date.Day(), 20, 0, 0, 0, market.Location)
+
+	var bars []PriceBar
+	for t := start; t.Before(end); t = t.Add(time.Minute) {
+		bars = append(bars, PriceBar{Start: t, Close: stubPrice(t)})
+	}
+	return bars, nil
+}
+
+// stubPrice is the synthetic price for the minute containing t
+func stubPrice(t time.Time) float64 {
+	t = t.In(market.Location)
+	minutes := float64(t.Hour()*60 + t.Minute())
+	return math.Round((100+6*math.Sin(minutes/30))*100) / 100
+}
+
+// CachedSpot wraps a SpotSource and reuses each ticker's price for a fixed interval
+// so per-aggregate evaluation does not turn into a request per aggregate; bars are not cached
+type CachedSpot struct {
+	source  SpotSource
+	maxAge  time.Duration
+	timeout time.Duration
+
+	mu     sync.Mutex
+	prices map[string]cachedPrice
+}
+
+// cachedPrice is a spot price and when it was fetched
+type cachedPrice struct {
+	price     float64
+	fetchedAt time.Time
+}
+
+// NewCachedSpot creates a cache over source that refetches a ticker's price once it is older than maxAge
+func NewCachedSpot(source SpotSource, maxAge time.Duration) *CachedSpot {
+	return &CachedSpot{
+		source:  source,
+		maxAge:  maxAge,
+		timeout: 10 * time.Second,
+		prices:  make(map[string]cachedPrice),
+	}
+}
+
+// LastPrice returns the cached price for ticker, refreshing it when stale
+func (c *CachedSpot) LastPrice(ctx context.Context, ticker string) (float64, error) {
+	c.mu.Lock()
+	cached, ok := c.prices[ticker]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < c.maxAge {
+		return cached.price, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	price, err := c.source.LastPrice(ctx, ticker)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	c.prices[ticker] = cachedPrice{price: price, fetchedAt: time.Now()}
+	c.mu.Unlock()
+	return price, nil
+}
+
+// PriceBars returns the underlying source's bars for date
+func (c *CachedSpot) PriceBars(ctx context.Context, ticker string, date time.Time) ([]PriceBar, error) {
+	return c.source.PriceBars(ctx, ticker, date)
+}
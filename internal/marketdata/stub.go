@@ -0,0 +1,169 @@
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// stubUnderlyings are the underlyings covered when a stub stream subscribes to every contract ("*")
+var stubUnderlyings = []string{"AAPL", "SPY", "TSLA"}
+
+// stubContracts returns a small deterministic chain: calls and puts at three strikes expiring the Friday after date
+func stubContracts(underlying string, date time.Time) []OptionContract {
+	expiration := date
+	for expiration.Weekday() != time.Friday {
+		expiration = expiration.AddDate(0, 0, 1)
+	}
+	expiration = expiration.AddDate(0, 0, 7)
+
+	var contracts []OptionContract
+	for _, strike := range []float64{95, 100, 105} {
+		for _, contractType := range []string{"call", "put"} {
+			cp := "C"
+			if contractType == "put" {
+				cp = "P"
+			}
+			contracts = append(contracts, OptionContract{
+				Ticker:           fmt.Sprintf("O:%s%s%s%08d", underlying, expiration.Format("060102"), cp, int(strike*1000)),
+				ContractType:     contractType,
+				ExerciseStyle:    "american",
+				ExpirationDate:   expiration.Format("2006-01-02"),
+				StrikePrice:      strike,
+				UnderlyingTicker: underlying,
+			})
+		}
+	}
+	return contracts
+}
+
+// stubAggregate creates a synthetic aggregate for a contract covering one timespan from start
+func stubAggregate(symbol string, start time.Time, timespan string, rng *rand.Rand) analysis.Aggregate {
+	price := 1 + rng.Float64()*4
+	volume := int64(1 + rng.Intn(50))
+	if timespan == analysis.TimespanMinute {
+		volume *= 10
+	}
+	return analysis.Aggregate{
+		EventType:         analysis.TimespanEventType(timespan),
+		Symbol:            symbol,
+		Volume:            volume,
+		AccumulatedVolume: volume,
+		OfficialOpenPrice: price,
+		VWAP:              price,
+		Open:              price,
+		High:              price * 1.01,
+		Low:               price * 0.99,
+		Close:             price,
+		AggregateVWAP:     price,
+		AverageSize:       volume,
+		StartTimestamp:    start.UnixMilli(),
+		EndTimestamp:      start.Add(analysis.TimespanDuration(timespan)).UnixMilli(),
+	}
+}
+
+// StubStream is a StreamSource that emits synthetic aggregates once per timespan
+type StubStream struct {
+	symbols  []string
+	timespan string
+}
+
+// NewStubStream creates a synthetic live stream of per-second or per-minute aggregates
+func NewStubStream(timespan string) *StubStream {
+	return &StubStream{timespan: timespan}
+}
+
+// Connect is a no-op for the stub stream
+func (s *StubStream) Connect() error {
+	return nil
+}
+
+// Subscribe adds synthetic contracts matching a pattern: "*", "O:SYMBOL*", or a single contract
+func (s *StubStream) Subscribe(pattern string) error {
+	switch {
+	case pattern == "*":
+		for _, underlying := range stubUnderlyings {
+			for _, contract := range stubContracts(underlying, time.Now()) {
+				s.symbols = append(s.symbols, contract.Ticker)
+			}
+		}
+	case strings.HasSuffix(pattern, "*"):
+		underlying := strings.TrimSuffix(strings.TrimPrefix(pattern, "O:"), "*")
+		for _, contract := range stubContracts(underlying, time.Now()) {
+			s.symbols = append(s.symbols, contract.Ticker)
+		}
+	default:
+		s.symbols = append(s.symbols, pattern)
+	}
+	return nil
+}
+
+// Run emits a few aggregates for random subscribed contracts once per timespan until ctx is cancelled
+func (s *StubStream) Run(ctx context.Context, handler func(analysis.Aggregate)) error {
+	if len(s.symbols) == 0 {
+		return fmt.Errorf("no subscriptions")
+	}
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	interval := analysis.TimespanDuration(s.timespan)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			start := now.Truncate(interval).Add(-interval)
+			for i := 0; i < 1+rng.Intn(5); i++ {
+				handler(stubAggregate(s.symbols[rng.Intn(len(s.symbols))], start, s.timespan, rng))
+			}
+		}
+	}
+}
+
+// Close is a no-op for the stub stream
+func (s *StubStream) Close() {}
+
+// StubHistory is a HistorySource that returns a deterministic synthetic chain and aggregates
+type StubHistory struct {
+	timespan string
+}
+
+// NewStubHistory creates a synthetic historical data source of per-second or per-minute aggregates
+func NewStubHistory(timespan string) *StubHistory {
+	return &StubHistory{timespan: timespan}
+}
+
+// ListOptionContracts returns a small synthetic chain for the underlying
+func (h *StubHistory) ListOptionContracts(ctx context.Context, underlyingTicker string) ([]OptionContract, error) {
+	return stubContracts(strings.ToUpper(underlyingTicker), time.Now()), nil
+}
+
+// GetOptionAggregates returns synthetic aggregates for roughly a third of the minutes in the regular session
+// The same contract and date always produce the same data
+func (h *StubHistory) GetOptionAggregates(ctx context.Context, contractTicker string, date time.Time) ([]analysis.Aggregate, error) {
+	seed := fnv.New64a()
+	seed.Write([]byte(contractTicker + date.Format("2006-01-02")))
+	rng := rand.New(rand.NewSource(int64(seed.Sum64())))
+
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, market.Location)
+	var aggregates []analysis.Aggregate
+	for t := market.OpenTime(day); t.Before(market.CloseTime(day)); t = t.Add(time.Minute) {
+		if rng.Intn(3) != 0 {
+			continue
+		}
+		start := t
+		if h.timespan != analysis.TimespanMinute {
+			start = t.Add(time.Duration(rng.Intn(60)) * time.Second)
+		}
+		aggregates = append(aggregates, stubAggregate(contractTicker, start, h.timespan, rng))
+	}
+	return aggregates, nil
+}
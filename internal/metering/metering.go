@@ -0,0 +1,302 @@
+package metering
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// Usage sources; each service meters into its own subdirectory so no two processes rewrite the same file
+const (
+	SourceServer        = "server"
+	SourceNotifications = "notifications"
+)
+
+// Sources lists every usage source, in the order reports merge them
+var Sources = []string{SourceServer, SourceNotifications}
+
+// DefaultRetentionDays is how many days of usage are kept per user
+const DefaultRetentionDays = 30
+
+// DayUsage is one user's metered activity on a date (YYYY-MM-DD in the analysis timezone)
+type DayUsage struct {
+	Date          string           `json:"date"`
+	APICalls      map[string]int64 `json:"api_calls,omitempty"` // Map: route -> authenticated requests
+	StreamSeconds int64            `json:"stream_seconds,omitempty"`
+	Notifications int64            `json:"notifications,omitempty"` // Pushes delivered to at least one device
+}
+
+// Meter counts per-user usage for one source and saves it to <dir>/<source>/<user>.json
+// Counts are kept in memory and written by Flush, so callers flush periodically and on shutdown
+type Meter struct {
+	dir           string
+	source        string
+	retentionDays int
+
+	mu    sync.Mutex
+	users map[string]map[string]*DayUsage // Key: user ID, then date
+	dirty map[string]bool                 // Users changed since the last flush
+}
+
+// NewMeter creates a meter for a source that keeps retentionDays of usage per user
+func NewMeter(dir string, source string, retentionDays int) *Meter {
+	if retentionDays <= 0 {
+		retentionDays = DefaultRetentionDays
+	}
+	return &Meter{
+		dir:           dir,
+		source:        source,
+		retentionDays: retentionDays,
+		users:         make(map[string]map[string]*DayUsage),
+		dirty:         make(map[string]bool),
+	}
+}
+
+// RecordAPICall counts an authenticated request to a route
+func (m *Meter) RecordAPICall(userID string, route string) {
+	m.record(userID, func(day *DayUsage) {
+		if day.APICalls == nil {
+			day.APICalls = make(map[string]int64)
+		}
+		day.APICalls[route]++
+	})
+}
+
+// RecordStream adds time a user spent connected to a stream
+// Callers record elapsed time as they go (not only at disconnect), so long-lived connections show up in usage
+// and time is counted on the day it was spent
+func (m *Meter) RecordStream(userID string, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	m.record(userID, func(day *DayUsage) {
+		day.StreamSeconds += int64(d.Round(time.Second) / time.Second)
+	})
+}
+
+// RecordNotification counts a push delivered to a user
+func (m *Meter) RecordNotification(userID string) {
+	m.record(userID, func(day *DayUsage) {
+		day.Notifications++
+	})
+}
+
+// record applies an update to the user's usage for today
+func (m *Meter) record(userID string, update func(day *DayUsage)) {
+	if userID == "" {
+		return
+	}
+	date := market.Today()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	days := m.loadLocked(userID)
+	day, ok := days[date]
+	if !ok {
+		day = &DayUsage{Date: date}
+		days[date] = day
+	}
+	update(day)
+	m.dirty[userID] = true
+}
+
+// loadLocked returns the user's usage, reading their saved file the first time so counts survive restarts
+// An unreadable file is logged and replaced on the next flush; metering never fails the request it counts
+func (m *Meter) loadLocked(userID string) map[string]*DayUsage {
+	if days, ok := m.users[userID]; ok {
+		return days
+	}
+
+	days := make(map[string]*DayUsage)
+	saved, err := readUsage(m.filename(userID))
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	for i := range saved {
+		days[saved[i].Date] = &saved[i]
+	}
+	m.users[userID] = days
+	return days
+}
+
+// Flush writes the usage of every user changed since the last flush, dropping days past the retention window
+func (m *Meter) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cutoff := retentionCutoff(m.retentionDays)
+	var firstErr error
+	for userID := range m.dirty {
+		days := m.users[userID]
+		for date := range days {
+			if date < cutoff {
+				delete(days, date)
+			}
+		}
+		if err := writeUsage(m.filename(userID), sortedDays(days)); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		delete(m.dirty, userID)
+	}
+	return firstErr
+}
+
+// Start flushes the meter every interval in the background
+// Services also call Flush on shutdown where they have one, so at most one interval of usage is lost on a crash
+func (m *Meter) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := m.Flush(); err != nil {
+				log.Printf("Warning: failed to save usage: %v", err)
+			}
+		}
+	}()
+}
+
+// Usage returns a user's usage for the last n days across every source, oldest first
+// The meter's own source is read from memory so unflushed counts are included; other sources are read from their files
+func (m *Meter) Usage(userID string, n int) ([]DayUsage, error) {
+	merged := make(map[string]*DayUsage)
+	for _, source := range Sources {
+		var days []DayUsage
+		if source == m.source {
+			m.mu.Lock()
+			for _, day := range m.loadLocked(userID) {
+				days = append(days, copyDay(*day))
+			}
+			m.mu.Unlock()
+		} else {
+			saved, err := readUsage(filepath.Join(m.dir, source, userID+".json"))
+			if err != nil {
+				return nil, err
+			}
+			days = saved
+		}
+		for _, day := range days {
+			mergeDay(merged, day)
+		}
+	}
+	return window(merged, n), nil
+}
+
+// filename returns the path of a user's usage file for the meter's source
+func (m *Meter) filename(userID string) string {
+	return filepath.Join(m.dir, m.source, userID+".json")
+}
+
+// window returns one entry per date in the last n days, oldest first, including days with no usage
+func window(days map[string]*DayUsage, n int) []DayUsage {
+	if n <= 0 {
+		n = 1
+	}
+	today := time.Now().In(market.AnalysisLocation())
+	result := make([]DayUsage, 0, n)
+	for i := n - 1; i >= 0; i-- {
+		date := market.DateOf(today.AddDate(0, 0, -i))
+		if day, ok := days[date]; ok {
+			result = append(result, *day)
+		} else {
+			result = append(result, DayUsage{Date: date})
+		}
+	}
+	return result
+}
+
+// mergeDay adds a source's usage for a date into merged
+func mergeDay(merged map[string]*DayUsage, day DayUsage) {
+	existing, ok := merged[day.Date]
+	if !ok {
+		copied := copyDay(day)
+		merged[day.Date] = &copied
+		return
+	}
+	for route, calls := range day.APICalls {
+		if existing.APICalls == nil {
+			existing.APICalls = make(map[string]int64)
+		}
+		existing.APICalls[route] += calls
+	}
+	existing.StreamSeconds += day.StreamSeconds
+	existing.Notifications += day.Notifications
+}
+
+// copyDay copies a day's usage so callers can't mutate the meter's counts
+func copyDay(day DayUsage) DayUsage {
+	if day.APICalls != nil {
+		calls := make(map[string]int64, len(day.APICalls))
+		for route, count := range day.APICalls {
+			calls[route] = count
+		}
+		day.APICalls = calls
+	}
+	return day
+}
+
+// sortedDays returns a user's usage sorted by date
+func sortedDays(days map[string]*DayUsage) []DayUsage {
+	result := make([]DayUsage, 0, len(days))
+	for _, day := range days {
+		result = append(result, *day)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Date < result[j].Date
+	})
+	return result
+}
+
+// retentionCutoff returns the oldest date kept for a retention window
+func retentionCutoff(retentionDays int) string {
+	return market.DateOf(time.Now().In(market.AnalysisLocation()).AddDate(0, 0, -(retentionDays - 1)))
+}
+
+// readUsage reads a usage file (nil if the file doesn't exist)
+func readUsage(filename string) ([]DayUsage, error) {
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage file: %w", err)
+	}
+
+	var days []DayUsage
+	if err := json.Unmarshal(data, &days); err != nil {
+		return nil, fmt.Errorf("failed to parse usage file %s: %w", filename, err)
+	}
+	return days, nil
+}
+
+// writeUsage saves a usage file via a temp file and rename, so the other service never reads a partial file
+func writeUsage(filename string, days []DayUsage) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("failed to create usage directory: %w", err)
+	}
+
+	data, err := json.Marshal(days)
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage: %w", err)
+	}
+
+	tmpFile := filename + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write usage file: %w", err)
+	}
+	if err := os.Rename(tmpFile, filename); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to replace usage file: %w", err)
+	}
+	return nil
+}
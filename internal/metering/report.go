@@ -0,0 +1,61 @@
+package metering
+
+import (
+	"math"
+)
+
+// Report is a user's usage over recent days, as returned to the user
+type Report struct {
+	UserID string      `json:"user_id"`
+	Days   int         `json:"days"`
+	Totals ReportDay   `json:"totals"`
+	Daily  []ReportDay `json:"daily"` // One entry per date, oldest first, including days with no usage
+}
+
+// ReportDay is usage on one date, or the report's totals (no date)
+type ReportDay struct {
+	Date            string           `json:"date,omitempty"`
+	APICalls        int64            `json:"api_calls"`
+	APICallsByRoute map[string]int64 `json:"api_calls_by_route,omitempty"`
+	StreamMinutes   float64          `json:"stream_minutes"` // Rounded to a tenth of a minute
+	Notifications   int64            `json:"notifications"`
+}
+
+// NewReport builds a report from a user's daily usage
+func NewReport(userID string, days []DayUsage) Report {
+	report := Report{
+		UserID: userID,
+		Days:   len(days),
+		Daily:  make([]ReportDay, 0, len(days)),
+	}
+
+	var totalSeconds int64
+	for _, day := range days {
+		entry := ReportDay{
+			Date:            day.Date,
+			APICallsByRoute: day.APICalls,
+			StreamMinutes:   streamMinutes(day.StreamSeconds),
+			Notifications:   day.Notifications,
+		}
+		for route, calls := range day.APICalls {
+			entry.APICalls += calls
+			if report.Totals.APICallsByRoute == nil {
+				report.Totals.APICallsByRoute = make(map[string]int64)
+			}
+			report.Totals.APICallsByRoute[route] += calls
+		}
+		report.Daily = append(report.Daily, entry)
+
+		report.Totals.APICalls += entry.APICalls
+		report.Totals.Notifications += entry.Notifications
+		totalSeconds += day.StreamSeconds
+	}
+	// Totals are rounded once from seconds so they don't drift from the sum of rounded days
+	report.Totals.StreamMinutes = streamMinutes(totalSeconds)
+	return report
+}
+
+// streamMinutes converts stream seconds to minutes, rounded to a tenth
+func streamMinutes(seconds int64) float64 {
+	return math.Round(float64(seconds)/6) / 10
+}
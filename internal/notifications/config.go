@@ -1,27 +1,177 @@
 package notifications
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
 )
 
 // NotificationConfig represents a single notification configuration for a ticker
 type NotificationConfig struct {
-	Ticker                string  `json:"ticker"`
-	Disabled              bool    `json:"disabled"`                // Whether notifications are disabled for this ticker (default: false, i.e., active)
-	RatioPremiumThreshold int     `json:"ratio_premium_threshold"` // Minimum total premium for ratio notifications
-	CallRatioThreshold    float64 `json:"call_ratio_threshold"`    // Notify if call/put ratio >= this AND total premium >= ratio_premium_threshold
-	PutRatioThreshold     float64 `json:"put_ratio_threshold"`     // Notify if put/call ratio >= this AND total premium >= ratio_premium_threshold
-	CallPremiumThreshold  int     `json:"call_premium_threshold"`  // Notify if call premium >= this (independent)
-	PutPremiumThreshold   int     `json:"put_premium_threshold"`   // Notify if put premium >= this (independent)
+	Ticker                string   `json:"ticker"`
+	Disabled              bool     `json:"disabled"`                // Whether notifications are disabled for this ticker (default: false, i.e., active)
+	RatioPremiumThreshold int      `json:"ratio_premium_threshold"` // Minimum total premium for ratio notifications
+	CallRatioThreshold    float64  `json:"call_ratio_threshold"`    // Notify if call/put ratio >= this AND total premium >= ratio_premium_threshold
+	PutRatioThreshold     float64  `json:"put_ratio_threshold"`     // Notify if put/call ratio >= this AND total premium >= ratio_premium_threshold
+	CallPremiumThreshold  int      `json:"call_premium_threshold"`  // Notify if call premium >= this (independent)
+	PutPremiumThreshold   int      `json:"put_premium_threshold"`   // Notify if put premium >= this (independent)
+	Sessions              []string `json:"sessions,omitempty"`      // Only notify for periods in these sessions (premarket, regular, afterhours); empty means all
+
+	// Ratio hysteresis: with any of these set, a ratio threshold must hold for ratio_confirm_periods consecutive periods
+	// before it notifies, and notifies again only after the ratio drops below the re-arm bound (see RatioStates)
+	RatioConfirmPeriods int     `json:"ratio_confirm_periods,omitempty"` // Consecutive periods a ratio threshold must hold (default 1, max MaxRatioConfirmPeriods)
+	CallRatioRearm      float64 `json:"call_ratio_rearm,omitempty"`      // Re-arm the call ratio alert once call/put ratio < this (default call_ratio_threshold)
+	PutRatioRearm       float64 `json:"put_ratio_rearm,omitempty"`       // Re-arm the put ratio alert once put/call ratio < this (default put_ratio_threshold)
+
+	// Evaluation period; users watching the same ticker may each use a different one
+	PeriodMinutes int `json:"period_minutes,omitempty"` // Period length in minutes (one of EvaluationPeriods); 0 uses the notifications service's --period
+
+	// Trade-size conditions, evaluated against premium from institutional-sized (> $100K) aggregates only
+	InstitutionalCallPremiumThreshold int `json:"institutional_call_premium_threshold,omitempty"` // Notify if institutional call premium >= this (independent)
+	InstitutionalPutPremiumThreshold  int `json:"institutional_put_premium_threshold,omitempty"`  // Notify if institutional put premium >= this (independent)
+
+	// Anomaly conditions, evaluated against the rolling baseline of prior periods (see analysis.AnomalyScores)
+	CallPremiumZScoreThreshold float64 `json:"call_premium_zscore_threshold,omitempty"` // Notify if call premium is >= this many standard deviations above the baseline
+	PutPremiumZScoreThreshold  float64 `json:"put_premium_zscore_threshold,omitempty"`  // Notify if put premium is >= this many standard deviations above the baseline
+
+	// Rate-of-change conditions, evaluated against recent prior periods
+	ChangeMinPremium          int     `json:"change_min_premium,omitempty"`           // Minimum premium on the changed side for premium-change notifications
+	CallPremiumChangeMultiple float64 `json:"call_premium_change_multiple,omitempty"` // Notify if call premium >= this multiple of the previous period's call premium
+	PutPremiumChangeMultiple  float64 `json:"put_premium_change_multiple,omitempty"`  // Notify if put premium >= this multiple of the previous period's put premium
+	RatioFlipFrom             float64 `json:"ratio_flip_from,omitempty"`              // Notify if call/put ratio was below this within the flip window...
+	RatioFlipTo               float64 `json:"ratio_flip_to,omitempty"`                // ...and is now above this (requires ratio_premium_threshold to be met)
+	RatioFlipWindowMinutes    int     `json:"ratio_flip_window_minutes,omitempty"`    // Look-back window for ratio flips (default 15, max MaxRateWindowMinutes)
+
+	// Wall proximity condition, evaluated against the underlying's spot price (requires the notifications service's --spot-vendor)
+	WallProximityPct float64 `json:"wall_proximity_pct,omitempty"` // Notify if spot is within this percent of the day's call or put wall
+	WallMinPremium   int     `json:"wall_min_premium,omitempty"`   // Minimum premium accumulated at the wall strike for proximity notifications
+
+	// Moneyness band: only aggregates whose strike is within this percent range of spot count toward the premium
+	// conditions above (requires the notifications service's --spot-vendor), e.g. a min of 5 with
+	// call_premium_threshold alerts on OTM calls more than 5% above spot
+	MoneynessMinPct *float64 `json:"moneyness_min_pct,omitempty"` // Inclusive lower bound, percent from spot (negative below spot)
+	MoneynessMaxPct *float64 `json:"moneyness_max_pct,omitempty"` // Inclusive upper bound, percent from spot (negative below spot)
+}
+
+// Rate-of-change look-back limits
+const (
+	DefaultRatioFlipWindowMinutes = 15
+	MaxRateWindowMinutes          = 60 // Longest history the notifications service keeps per ticker
+)
+
+// EvaluationPeriods lists the period lengths, in minutes, a notification config may evaluate over
+var EvaluationPeriods = []int{1, 5, 15}
+
+// EvaluationPeriod returns the period length in minutes the config is evaluated over
+func (c NotificationConfig) EvaluationPeriod(defaultMinutes int) int {
+	if c.PeriodMinutes > 0 {
+		return c.PeriodMinutes
+	}
+	return defaultMinutes
+}
+
+// RatioHysteresis reports whether the config's ratio thresholds are evaluated with hysteresis
+func (c NotificationConfig) RatioHysteresis() bool {
+	return c.RatioConfirmPeriods > 0 || c.CallRatioRearm > 0 || c.PutRatioRearm > 0
+}
+
+// RatioRearm returns the ratio a side's ratio alert re-arms below (SideCall or SidePut)
+func (c NotificationConfig) RatioRearm(side string) float64 {
+	if side == SidePut {
+		if c.PutRatioRearm > 0 {
+			return c.PutRatioRearm
+		}
+		return c.PutRatioThreshold
+	}
+	if c.CallRatioRearm > 0 {
+		return c.CallRatioRearm
+	}
+	return c.CallRatioThreshold
+}
+
+// Validate checks rate-of-change settings that can't be expressed by the JSON types alone
+func (c NotificationConfig) Validate() error {
+	if c.CallPremiumChangeMultiple < 0 || c.PutPremiumChangeMultiple < 0 {
+		return fmt.Errorf("premium change multiples must not be negative")
+	}
+	if c.RatioFlipWindowMinutes < 0 || c.RatioFlipWindowMinutes > MaxRateWindowMinutes {
+		return fmt.Errorf("ratio_flip_window_minutes must be between 0 and %d", MaxRateWindowMinutes)
+	}
+	if (c.RatioFlipFrom > 0) != (c.RatioFlipTo > 0) {
+		return fmt.Errorf("ratio_flip_from and ratio_flip_to must be set together")
+	}
+	if c.RatioConfirmPeriods < 0 || c.RatioConfirmPeriods > MaxRatioConfirmPeriods {
+		return fmt.Errorf("ratio_confirm_periods must be between 0 and %d", MaxRatioConfirmPeriods)
+	}
+	if c.CallRatioRearm < 0 || c.PutRatioRearm < 0 {
+		return fmt.Errorf("ratio re-arm bounds must not be negative")
+	}
+	if c.CallRatioRearm > 0 && c.CallRatioRearm > c.CallRatioThreshold {
+		return fmt.Errorf("call_ratio_rearm must not be greater than call_ratio_threshold")
+	}
+	if c.PutRatioRearm > 0 && c.PutRatioRearm > c.PutRatioThreshold {
+		return fmt.Errorf("put_ratio_rearm must not be greater than put_ratio_threshold")
+	}
+	if c.WallProximityPct < 0 || c.WallProximityPct > 100 {
+		return fmt.Errorf("wall_proximity_pct must be between 0 and 100")
+	}
+	if c.InstitutionalCallPremiumThreshold < 0 || c.InstitutionalPutPremiumThreshold < 0 {
+		return fmt.Errorf("institutional premium thresholds must not be negative")
+	}
+	if c.CallPremiumZScoreThreshold < 0 || c.PutPremiumZScoreThreshold < 0 {
+		return fmt.Errorf("z-score thresholds must not be negative")
+	}
+	if c.WallMinPremium < 0 {
+		return fmt.Errorf("wall_min_premium must not be negative")
+	}
+	if c.PeriodMinutes != 0 && !slices.Contains(EvaluationPeriods, c.PeriodMinutes) {
+		return fmt.Errorf("period_minutes must be one of %v", EvaluationPeriods)
+	}
+	if c.MoneynessMinPct != nil && c.MoneynessMaxPct != nil && *c.MoneynessMaxPct < *c.MoneynessMinPct {
+		return fmt.Errorf("moneyness_max_pct must not be less than moneyness_min_pct")
+	}
+	return nil
+}
+
+// MoneynessBand returns the strikes the config's conditions are evaluated over (the zero band for every strike)
+func (c NotificationConfig) MoneynessBand() analysis.MoneynessBand {
+	return analysis.MoneynessBand{Min: c.MoneynessMinPct, Max: c.MoneynessMaxPct}
 }
 
 // UserNotifications represents all notification configurations for a user
 type UserNotifications struct {
-	UserID        string                        `json:"user_id"`
-	Notifications map[string]NotificationConfig `json:"notifications"` // Map: ticker -> config
+	UserID         string                        `json:"user_id"`
+	Notifications  map[string]NotificationConfig `json:"notifications"`             // Map: ticker -> config
+	SessionSummary bool                          `json:"session_summary,omitempty"` // Send a summary of every active ticker after the close
+	FeedNonce      string                        `json:"feed_nonce,omitempty"`      // Mixed into the calendar feed key; replaced to revoke old feed URLs
+}
+
+// RotateFeedNonce replaces the user's feed nonce, so feed URLs issued with the old one stop working
+func (u *UserNotifications) RotateFeedNonce() error {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return fmt.Errorf("failed to generate feed nonce: %w", err)
+	}
+	u.FeedNonce = base64.RawURLEncoding.EncodeToString(random)
+	return nil
+}
+
+// ActiveTickers returns the tickers with notifications that are not disabled, sorted
+func (u *UserNotifications) ActiveTickers() []string {
+	tickers := make([]string, 0, len(u.Notifications))
+	for ticker, config := range u.Notifications {
+		if !config.Disabled {
+			tickers = append(tickers, ticker)
+		}
+	}
+	slices.Sort(tickers)
+	return tickers
 }
 
 // LoadUserNotifications loads notification configurations for a specific user
@@ -38,7 +188,7 @@ func LoadUserNotifications(sub string, dir string) (*UserNotifications, error) {
 	}
 
 	// Read file
-	data, err := os.ReadFile(filename)
+	data, err := readUserFile(filename, "notifications:"+sub)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read notifications file: %w", err)
 	}
@@ -61,7 +211,7 @@ func LoadUserNotifications(sub string, dir string) (*UserNotifications, error) {
 // SaveUserNotifications saves notification configurations for a specific user
 func SaveUserNotifications(sub string, dir string, config *UserNotifications) error {
 	// Ensure directory exists
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := os.MkdirAll(dir, 0700); err != nil {
 		return fmt.Errorf("failed to create notifications directory: %w", err)
 	}
 
@@ -76,7 +226,7 @@ func SaveUserNotifications(sub string, dir string, config *UserNotifications) er
 		return fmt.Errorf("failed to marshal notifications: %w", err)
 	}
 
-	if err := os.WriteFile(filename, data, 0644); err != nil {
+	if err := writeUserFile(filename, "notifications:"+sub, data); err != nil {
 		return fmt.Errorf("failed to write notifications file: %w", err)
 	}
 
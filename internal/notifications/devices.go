@@ -34,7 +34,7 @@ func LoadUserDevices(sub string, dir string) (*UserDevices, error) {
 		}, nil
 	}
 
-	data, err := os.ReadFile(filename)
+	data, err := readUserFile(filename, "devices:"+sub)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read devices file: %w", err)
 	}
@@ -50,7 +50,7 @@ func LoadUserDevices(sub string, dir string) (*UserDevices, error) {
 // SaveUserDevices saves device tokens for a specific user
 func SaveUserDevices(sub string, dir string, devices *UserDevices) error {
 	// Ensure directory exists
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := os.MkdirAll(dir, 0700); err != nil {
 		return fmt.Errorf("failed to create devices directory: %w", err)
 	}
 
@@ -64,7 +64,7 @@ func SaveUserDevices(sub string, dir string, devices *UserDevices) error {
 		return fmt.Errorf("failed to marshal devices: %w", err)
 	}
 
-	if err := os.WriteFile(filename, data, 0644); err != nil {
+	if err := writeUserFile(filename, "devices:"+sub, data); err != nil {
 		return fmt.Errorf("failed to write devices file: %w", err)
 	}
 
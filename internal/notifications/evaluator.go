@@ -1,12 +1,30 @@
 package notifications
 
 import (
+	"strings"
+	"time"
+
 	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/market"
 )
 
+// inSessions reports whether a period falls in the config's sessions (invalid session lists match nothing)
+func inSessions(summary analysis.TimePeriodSummary, config NotificationConfig) bool {
+	if len(config.Sessions) == 0 {
+		return true
+	}
+	sessions, err := market.ParseSessionSet(strings.Join(config.Sessions, ","))
+	return err == nil && sessions.Contains(summary.Session)
+}
+
 // EvaluateThresholds checks if a period summary triggers any notification thresholds
 // Returns true if any threshold is triggered
 func EvaluateThresholds(summary analysis.TimePeriodSummary, config NotificationConfig) bool {
+	// Skip periods outside the configured sessions
+	if !inSessions(summary, config) {
+		return false
+	}
+
 	// Check Call Premium Threshold (independent)
 	if config.CallPremiumThreshold > 0 && summary.CallPremium >= float64(config.CallPremiumThreshold) {
 		return true
@@ -17,13 +35,33 @@ func EvaluateThresholds(summary analysis.TimePeriodSummary, config NotificationC
 		return true
 	}
 
+	// Check Institutional-size Premium Thresholds (independent)
+	if config.InstitutionalCallPremiumThreshold > 0 && summary.SizeBuckets.Institutional.CallPremium >= float64(config.InstitutionalCallPremiumThreshold) {
+		return true
+	}
+	if config.InstitutionalPutPremiumThreshold > 0 && summary.SizeBuckets.Institutional.PutPremium >= float64(config.InstitutionalPutPremiumThreshold) {
+		return true
+	}
+
+	// Check Anomaly Thresholds (independent; periods without a baseline yet never trigger)
+	if config.CallPremiumZScoreThreshold > 0 && summary.Anomaly != nil && summary.Anomaly.CallPremiumZ >= config.CallPremiumZScoreThreshold {
+		return true
+	}
+	if config.PutPremiumZScoreThreshold > 0 && summary.Anomaly != nil && summary.Anomaly.PutPremiumZ >= config.PutPremiumZScoreThreshold {
+		return true
+	}
+
+	// Ratio thresholds with hysteresis need state across periods (see EvaluateRatioHysteresis)
+	if config.RatioHysteresis() {
+		return false
+	}
+
 	// Check Call Ratio Threshold (requires ratio_premium_threshold to be met)
 	if config.CallRatioThreshold > 0 && config.RatioPremiumThreshold > 0 {
 		if summary.TotalPremium >= float64(config.RatioPremiumThreshold) {
 			// Check if call/put ratio meets threshold
-			// Note: call_put_ratio = call_premium / put_premium
-			// If put_premium is 0, call_put_ratio is -1 (infinite)
-			if summary.CallPutRatio >= config.CallRatioThreshold {
+			// A period with calls but no puts has an infinite ratio, which meets any threshold
+			if premiumRatio(summary, SideCall) >= config.CallRatioThreshold {
 				return true
 			}
 		}
@@ -32,23 +70,90 @@ func EvaluateThresholds(summary analysis.TimePeriodSummary, config NotificationC
 	// Check Put Ratio Threshold (requires ratio_premium_threshold to be met)
 	if config.PutRatioThreshold > 0 && config.RatioPremiumThreshold > 0 {
 		if summary.TotalPremium >= float64(config.RatioPremiumThreshold) {
-			// Calculate put/call ratio (inverse of call_put_ratio)
-			// put_ratio = put_premium / call_premium
-			var putRatio float64
-			if summary.CallPremium > 0 {
-				putRatio = summary.PutPremium / summary.CallPremium
-			} else if summary.PutPremium > 0 {
-				// Infinite put ratio (all puts, no calls)
-				putRatio = -1 // Use -1 to indicate infinite
-			} else {
-				putRatio = 0
+			// Check if put/call ratio (inverse of call_put_ratio) meets threshold
+			// A period with puts but no calls has an infinite ratio, which meets any threshold
+			if premiumRatio(summary, SidePut) >= config.PutRatioThreshold {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// EvaluateRateOfChange checks if a period summary triggers any rate-of-change condition
+// history holds prior periods for the same ticker (any order); periods at or after summary are ignored
+// Returns true if any condition is triggered
+func EvaluateRateOfChange(summary analysis.TimePeriodSummary, history []analysis.TimePeriodSummary, config NotificationConfig) bool {
+	// Skip periods outside the configured sessions
+	if !inSessions(summary, config) {
+		return false
+	}
+
+	// Premium multiples compare against the immediately preceding period; a gap (no trades) never triggers
+	if config.CallPremiumChangeMultiple > 0 || config.PutPremiumChangeMultiple > 0 {
+		periodLength := summary.PeriodEnd.Sub(summary.PeriodStart)
+		for _, prev := range history {
+			if !prev.PeriodStart.Equal(summary.PeriodStart.Add(-periodLength)) {
+				continue
 			}
 
-			if putRatio >= config.PutRatioThreshold {
+			if config.CallPremiumChangeMultiple > 0 && prev.CallPremium > 0 &&
+				summary.CallPremium >= float64(config.ChangeMinPremium) &&
+				summary.CallPremium >= prev.CallPremium*config.CallPremiumChangeMultiple {
+				return true
+			}
+			if config.PutPremiumChangeMultiple > 0 && prev.PutPremium > 0 &&
+				summary.PutPremium >= float64(config.ChangeMinPremium) &&
+				summary.PutPremium >= prev.PutPremium*config.PutPremiumChangeMultiple {
 				return true
 			}
+			break
+		}
+	}
+
+	// Ratio flip: ratio was below RatioFlipFrom within the window and is now above RatioFlipTo
+	if config.RatioFlipFrom > 0 && config.RatioFlipTo > 0 && summary.TotalPremium >= float64(config.RatioPremiumThreshold) {
+		if premiumRatio(summary, SideCall) > config.RatioFlipTo {
+			window := time.Duration(config.RatioFlipWindowMinutes) * time.Minute
+			if window == 0 {
+				window = DefaultRatioFlipWindowMinutes * time.Minute
+			}
+			windowStart := summary.PeriodEnd.Add(-window)
+
+			for _, prev := range history {
+				if !prev.PeriodStart.Before(summary.PeriodStart) || prev.PeriodStart.Before(windowStart) {
+					continue
+				}
+				// Periods without premium have no meaningful ratio
+				if prev.TotalPremium > 0 && premiumRatio(prev, SideCall) < config.RatioFlipFrom {
+					return true
+				}
+			}
 		}
 	}
 
 	return false
 }
+
+// EvaluateWallProximity checks if spot is within the configured distance of the period's call or put wall
+// Walls below wall_min_premium are ignored; a non-positive spot never triggers
+// Returns the wall that was approached ("call" or "put") and whether the condition triggered
+func EvaluateWallProximity(summary analysis.TimePeriodSummary, spot float64, config NotificationConfig) (string, bool) {
+	if config.WallProximityPct <= 0 || spot <= 0 || !inSessions(summary, config) {
+		return "", false
+	}
+
+	for _, side := range []struct {
+		name string
+		wall *analysis.StrikePremium
+	}{{"call", summary.CallWall}, {"put", summary.PutWall}} {
+		if side.wall == nil || side.wall.Premium < float64(config.WallMinPremium) {
+			continue
+		}
+		if analysis.WallDistancePct(spot, side.wall) <= config.WallProximityPct {
+			return side.name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,161 @@
+package notifications
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+)
+
+// MaxRatioConfirmPeriods caps ratio_confirm_periods
+const MaxRatioConfirmPeriods = 10
+
+// RatioStates is the rule engine's state store for ratio alerts with hysteresis
+// A ratio alert fires once the ratio has been at or above its threshold for the configured number of consecutive
+// evaluated periods, then stays quiet until the ratio drops below the re-arm bound. Each alert is tracked under its
+// own key (e.g. user, ticker, evaluation period, and side), so one store can serve every config
+// Periods must be evaluated in time order; an in-progress period may be re-evaluated any number of times and counts
+// once, with its latest ratio
+type RatioStates struct {
+	mu     sync.Mutex
+	tracks map[string]*ratioTrack
+}
+
+// ratioTrack is one ratio alert's hysteresis state
+type ratioTrack struct {
+	disarmed  bool      // Fired and waiting for the ratio to drop below the re-arm bound
+	period    time.Time // End of the latest evaluated period
+	above     bool      // Whether the latest evaluated period met the threshold
+	streak    int       // Consecutive periods that met the threshold before the latest one
+	firedLast bool      // Whether the latest evaluated period fired (so re-evaluations keep firing)
+}
+
+// NewRatioStates creates an empty ratio state store
+func NewRatioStates() *RatioStates {
+	return &RatioStates{tracks: make(map[string]*ratioTrack)}
+}
+
+// Evaluate records a period's ratio for the alert under key and reports whether the alert fires for it
+// eligible is false when the period misses the alert's other conditions (e.g. ratio_premium_threshold); such a
+// period breaks the streak but re-arms only if it has premium and its ratio is below rearm. Periods older than the
+// latest one evaluated under key never fire and leave the state unchanged
+func (s *RatioStates) Evaluate(key string, periodEnd time.Time, ratio float64, hasPremium bool, eligible bool, threshold float64, rearm float64, confirm int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	track, exists := s.tracks[key]
+	if !exists {
+		track = &ratioTrack{}
+		s.tracks[key] = track
+	}
+	if periodEnd.Before(track.period) {
+		return false
+	}
+	if periodEnd.After(track.period) {
+		// The previous period is final: fold it into the streak
+		if track.above {
+			track.streak++
+		} else {
+			track.streak = 0
+		}
+		track.period = periodEnd
+		track.firedLast = false
+	}
+
+	track.above = eligible && ratio >= threshold
+	if hasPremium && ratio < rearm {
+		track.disarmed = false
+	}
+	if track.firedLast {
+		return true
+	}
+	if track.disarmed || !track.above || track.streak+1 < max(confirm, 1) {
+		return false
+	}
+	track.disarmed = true
+	track.firedLast = true
+	return true
+}
+
+// Prune forgets alerts whose latest period ended before cutoff, so they start over (armed, with no streak)
+func (s *RatioStates) Prune(cutoff time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, track := range s.tracks {
+		if track.period.Before(cutoff) {
+			delete(s.tracks, key)
+		}
+	}
+}
+
+// HysteresisRatioRule is a RatioRule that must hold for Confirm consecutive periods before it matches, and then
+// matches again only after the ratio has dropped below Rearm (see RatioStates)
+// Premium is the rule's other condition; a period that misses it breaks the streak
+type HysteresisRatioRule struct {
+	States  *RatioStates
+	Key     string // Alert key in States
+	Side    string // SideCall or SidePut
+	Min     float64
+	Rearm   float64
+	Confirm int
+	Premium Rule
+}
+
+// Match implements Rule
+func (r HysteresisRatioRule) Match(summary analysis.TimePeriodSummary, history []analysis.TimePeriodSummary) bool {
+	eligible := r.Premium == nil || r.Premium.Match(summary, history)
+	return r.States.Evaluate(r.Key, summary.PeriodEnd, premiumRatio(summary, r.Side), summary.TotalPremium > 0, eligible, r.Min, r.Rearm, r.Confirm)
+}
+
+// String implements Rule
+func (r HysteresisRatioRule) String() string {
+	description := fmt.Sprintf("%s ratio >= %g for %d periods, re-arm below %g", r.Side, r.Min, max(r.Confirm, 1), r.Rearm)
+	if r.Premium != nil {
+		description = r.Premium.String() + ", " + description
+	}
+	return description
+}
+
+// EvaluateRatioHysteresis checks a config's ratio thresholds with hysteresis, tracking each side in states under key
+// plus the side. Configs without ratio hysteresis never trigger here: EvaluateThresholds checks their ratios
+func EvaluateRatioHysteresis(states *RatioStates, key string, summary analysis.TimePeriodSummary, config NotificationConfig) bool {
+	if !config.RatioHysteresis() || !inSessions(summary, config) {
+		return false
+	}
+	// Both sides are evaluated so each side's state advances with every period
+	triggered := false
+	for _, rule := range ratioHysteresisRules(states, key, config) {
+		if rule.Match(summary, nil) {
+			triggered = true
+		}
+	}
+	return triggered
+}
+
+// ratioHysteresisRules builds the hysteresis rules for a config's ratio thresholds
+func ratioHysteresisRules(states *RatioStates, key string, config NotificationConfig) []HysteresisRatioRule {
+	if config.RatioPremiumThreshold <= 0 {
+		return nil
+	}
+	premium := PremiumRule{Side: SideTotal, Min: float64(config.RatioPremiumThreshold)}
+	var rules []HysteresisRatioRule
+	for _, side := range []struct {
+		name      string
+		threshold float64
+	}{{SideCall, config.CallRatioThreshold}, {SidePut, config.PutRatioThreshold}} {
+		if side.threshold <= 0 {
+			continue
+		}
+		rules = append(rules, HysteresisRatioRule{
+			States:  states,
+			Key:     key + "|" + side.name,
+			Side:    side.name,
+			Min:     side.threshold,
+			Rearm:   config.RatioRearm(side.name),
+			Confirm: config.RatioConfirmPeriods,
+			Premium: premium,
+		})
+	}
+	return rules
+}
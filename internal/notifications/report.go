@@ -0,0 +1,116 @@
+package notifications
+
+import (
+	"math"
+	"sort"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+)
+
+// Report limits for GET /notifications/report
+const (
+	DefaultReportDays = 10
+	MaxReportDays     = 60
+)
+
+// RuleReport replays one notification config over past sessions, so users can see how often it would have fired
+// and prune rules that fire constantly or never
+type RuleReport struct {
+	Ticker          string            `json:"ticker"`
+	Disabled        bool              `json:"disabled"`
+	PeriodMinutes   int               `json:"period_minutes"`             // Length of the periods the config was evaluated over
+	Periods         int               `json:"periods"`                    // Periods with trades across the sessions
+	Fired           int               `json:"fired"`                      // Periods that met the config
+	FireRate        float64           `json:"fire_rate"`                  // Fired / Periods (0 without periods)
+	Sessions        []SessionFires    `json:"sessions"`                   // One entry per session, oldest first
+	TriggerPremiums *TriggerPremiums  `json:"trigger_premiums,omitempty"` // Nil when the config never fired
+	Unevaluated     []string          `json:"unevaluated,omitempty"`      // Conditions that can't be replayed from stored data
+	Errors          map[string]string `json:"errors,omitempty"`           // Sessions that couldn't be evaluated, keyed by date
+	premiums        []float64         // Total premium of each triggering period, behind TriggerPremiums
+}
+
+// SessionFires counts a config's triggering periods in one session
+type SessionFires struct {
+	Date    string `json:"date"`
+	Periods int    `json:"periods"` // Periods with trades (0 when there is no log file for the session)
+	Fired   int    `json:"fired"`
+}
+
+// TriggerPremiums describes the total premium of the periods that met a config
+type TriggerPremiums struct {
+	Min    float64 `json:"min"`
+	Median float64 `json:"median"`
+	P90    float64 `json:"p90"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+}
+
+// NewRuleReport starts a report for a config evaluated over periods of periodMinutes
+func NewRuleReport(config NotificationConfig, periodMinutes int) *RuleReport {
+	report := &RuleReport{
+		Ticker:        config.Ticker,
+		Disabled:      config.Disabled,
+		PeriodMinutes: periodMinutes,
+		Sessions:      []SessionFires{},
+	}
+	// Wall proximity compares walls with the live spot price at the time, which isn't stored
+	if config.WallProximityPct > 0 {
+		report.Unevaluated = append(report.Unevaluated, "wall_proximity_pct")
+	}
+	return report
+}
+
+// AddSession evaluates the config against one session's period summaries (in time order), as the notifications
+// service would have at the close: every period is complete, so each period fires at most once
+func (r *RuleReport) AddSession(config NotificationConfig, date string, summaries []analysis.TimePeriodSummary) {
+	session := SessionFires{Date: date, Periods: len(summaries)}
+	ratios := NewRatioStates() // Ratio hysteresis starts over each session, as it does in the notifications service
+	for _, summary := range summaries {
+		ratioMet := EvaluateRatioHysteresis(ratios, "", summary, config)
+		if ratioMet || EvaluateThresholds(summary, config) || EvaluateRateOfChange(summary, summaries, config) {
+			session.Fired++
+			r.premiums = append(r.premiums, summary.TotalPremium)
+		}
+	}
+	r.Sessions = append(r.Sessions, session)
+	r.Periods += session.Periods
+	r.Fired += session.Fired
+}
+
+// AddError records a session that couldn't be evaluated
+func (r *RuleReport) AddError(date string, err error) {
+	if r.Errors == nil {
+		r.Errors = make(map[string]string)
+	}
+	r.Errors[date] = err.Error()
+}
+
+// Finish computes the fire rate and triggering premium distribution once every session has been added
+func (r *RuleReport) Finish() {
+	if r.Periods > 0 {
+		r.FireRate = float64(r.Fired) / float64(r.Periods)
+	}
+	if len(r.premiums) == 0 {
+		return
+	}
+
+	premiums := append([]float64(nil), r.premiums...)
+	sort.Float64s(premiums)
+	var sum float64
+	for _, premium := range premiums {
+		sum += premium
+	}
+	r.TriggerPremiums = &TriggerPremiums{
+		Min:    premiums[0],
+		Median: percentile(premiums, 0.5),
+		P90:    percentile(premiums, 0.9),
+		Max:    premiums[len(premiums)-1],
+		Mean:   sum / float64(len(premiums)),
+	}
+}
+
+// percentile returns the nearest-rank percentile (0 < p <= 1) of sorted, non-empty values
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(0, min(rank, len(sorted)-1))]
+}
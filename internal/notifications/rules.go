@@ -0,0 +1,342 @@
+package notifications
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// Rule is a composable notification condition evaluated against a period and the ticker's earlier periods
+// Rules are the candidate replacement for the fixed NotificationConfig thresholds; RulesFromConfig builds
+// the equivalent rule tree so both can run side by side in shadow mode before the migration
+type Rule interface {
+	// Match reports whether summary triggers the rule; history holds prior periods (any order)
+	Match(summary analysis.TimePeriodSummary, history []analysis.TimePeriodSummary) bool
+	// String describes the rule for logs
+	String() string
+}
+
+// Sides a premium or ratio rule applies to
+const (
+	SideCall  = "call"
+	SidePut   = "put"
+	SideTotal = "total"
+)
+
+// AnyRule matches when at least one of its rules matches
+// Every rule is evaluated, so stateful rules (see HysteresisRatioRule) see each period
+type AnyRule []Rule
+
+// Match implements Rule
+func (r AnyRule) Match(summary analysis.TimePeriodSummary, history []analysis.TimePeriodSummary) bool {
+	matched := false
+	for _, rule := range r {
+		if rule.Match(summary, history) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// String implements Rule
+func (r AnyRule) String() string {
+	return "any(" + joinRules(r) + ")"
+}
+
+// AllRule matches when every one of its rules matches
+type AllRule []Rule
+
+// Match implements Rule
+func (r AllRule) Match(summary analysis.TimePeriodSummary, history []analysis.TimePeriodSummary) bool {
+	for _, rule := range r {
+		if !rule.Match(summary, history) {
+			return false
+		}
+	}
+	return true
+}
+
+// String implements Rule
+func (r AllRule) String() string {
+	return "all(" + joinRules(r) + ")"
+}
+
+// joinRules joins rule descriptions with commas
+func joinRules(rules []Rule) string {
+	parts := make([]string, len(rules))
+	for i, rule := range rules {
+		parts[i] = rule.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SessionRule matches periods in a set of trading sessions
+type SessionRule struct {
+	Sessions market.SessionSet
+}
+
+// Match implements Rule
+func (r SessionRule) Match(summary analysis.TimePeriodSummary, history []analysis.TimePeriodSummary) bool {
+	return r.Sessions.Contains(summary.Session)
+}
+
+// String implements Rule
+func (r SessionRule) String() string {
+	return "session in " + r.Sessions.String()
+}
+
+// NeverRule never matches (e.g. a config with invalid sessions)
+type NeverRule struct{}
+
+// Match implements Rule
+func (NeverRule) Match(summary analysis.TimePeriodSummary, history []analysis.TimePeriodSummary) bool {
+	return false
+}
+
+// String implements Rule
+func (NeverRule) String() string {
+	return "never"
+}
+
+// PremiumRule matches when a side's premium is at least Min
+type PremiumRule struct {
+	Side string // SideCall, SidePut, or SideTotal
+	Min  float64
+}
+
+// Match implements Rule
+func (r PremiumRule) Match(summary analysis.TimePeriodSummary, history []analysis.TimePeriodSummary) bool {
+	return sidePremium(summary, r.Side) >= r.Min
+}
+
+// String implements Rule
+func (r PremiumRule) String() string {
+	return fmt.Sprintf("%s premium >= %.0f", r.Side, r.Min)
+}
+
+// SizeRule matches when a side's premium from one trade-size class is at least Min
+type SizeRule struct {
+	Class string // analysis.SizeRetail, analysis.SizeMid, or analysis.SizeInstitutional
+	Side  string // SideCall, SidePut, or SideTotal
+	Min   float64
+}
+
+// Match implements Rule
+func (r SizeRule) Match(summary analysis.TimePeriodSummary, history []analysis.TimePeriodSummary) bool {
+	bucket := summary.SizeBuckets.Class(r.Class)
+	if bucket == nil {
+		return false
+	}
+	premium := bucket.CallPremium + bucket.PutPremium
+	switch r.Side {
+	case SideCall:
+		premium = bucket.CallPremium
+	case SidePut:
+		premium = bucket.PutPremium
+	}
+	return premium >= r.Min
+}
+
+// String implements Rule
+func (r SizeRule) String() string {
+	return fmt.Sprintf("%s %s premium >= %.0f", r.Class, r.Side, r.Min)
+}
+
+// ZScoreRule matches when a side's premium is at least Min standard deviations above its rolling baseline
+// Periods without anomaly scores (too early in the day for a baseline) never match
+type ZScoreRule struct {
+	Side string // SideCall or SidePut
+	Min  float64
+}
+
+// Match implements Rule
+func (r ZScoreRule) Match(summary analysis.TimePeriodSummary, history []analysis.TimePeriodSummary) bool {
+	if summary.Anomaly == nil {
+		return false
+	}
+	z := summary.Anomaly.CallPremiumZ
+	if r.Side == SidePut {
+		z = summary.Anomaly.PutPremiumZ
+	}
+	return z >= r.Min
+}
+
+// String implements Rule
+func (r ZScoreRule) String() string {
+	return fmt.Sprintf("%s premium z-score >= %g", r.Side, r.Min)
+}
+
+// RatioRule matches when a side's premium ratio (call/put for SideCall, put/call for SidePut) is at least Min
+// A side with premium and no opposing premium has an infinite ratio and always matches
+type RatioRule struct {
+	Side string // SideCall or SidePut
+	Min  float64
+}
+
+// Match implements Rule
+func (r RatioRule) Match(summary analysis.TimePeriodSummary, history []analysis.TimePeriodSummary) bool {
+	return premiumRatio(summary, r.Side) >= r.Min
+}
+
+// String implements Rule
+func (r RatioRule) String() string {
+	return fmt.Sprintf("%s ratio >= %g", r.Side, r.Min)
+}
+
+// ChangeRule matches when a side's premium is at least Multiple times the immediately preceding period's
+// A gap before the period (no trades) or a preceding period without premium on that side never matches
+type ChangeRule struct {
+	Side       string // SideCall, SidePut, or SideTotal
+	Multiple   float64
+	MinPremium float64 // Minimum premium on the side in the current period
+}
+
+// Match implements Rule
+func (r ChangeRule) Match(summary analysis.TimePeriodSummary, history []analysis.TimePeriodSummary) bool {
+	current := sidePremium(summary, r.Side)
+	if current < r.MinPremium {
+		return false
+	}
+
+	previousStart := summary.PeriodStart.Add(-summary.PeriodEnd.Sub(summary.PeriodStart))
+	for _, prev := range history {
+		if prev.PeriodStart.Equal(previousStart) {
+			previous := sidePremium(prev, r.Side)
+			return previous > 0 && current >= previous*r.Multiple
+		}
+	}
+	return false
+}
+
+// String implements Rule
+func (r ChangeRule) String() string {
+	return fmt.Sprintf("%s premium >= %gx previous period (min %.0f)", r.Side, r.Multiple, r.MinPremium)
+}
+
+// RatioFlipRule matches when the call/put ratio is now above To and was below From in a period within Window
+// Prior periods without premium have no meaningful ratio and are ignored
+type RatioFlipRule struct {
+	From   float64
+	To     float64
+	Window time.Duration
+}
+
+// Match implements Rule
+func (r RatioFlipRule) Match(summary analysis.TimePeriodSummary, history []analysis.TimePeriodSummary) bool {
+	if premiumRatio(summary, SideCall) <= r.To {
+		return false
+	}
+
+	windowStart := summary.PeriodEnd.Add(-r.Window)
+	for _, prev := range history {
+		if !prev.PeriodStart.Before(summary.PeriodStart) || prev.PeriodStart.Before(windowStart) {
+			continue
+		}
+		if prev.TotalPremium > 0 && premiumRatio(prev, SideCall) < r.From {
+			return true
+		}
+	}
+	return false
+}
+
+// String implements Rule
+func (r RatioFlipRule) String() string {
+	return fmt.Sprintf("call ratio flipped from < %g to > %g within %s", r.From, r.To, r.Window)
+}
+
+// sidePremium returns the premium for a side of a period
+func sidePremium(summary analysis.TimePeriodSummary, side string) float64 {
+	switch side {
+	case SideCall:
+		return summary.CallPremium
+	case SidePut:
+		return summary.PutPremium
+	default:
+		return summary.TotalPremium
+	}
+}
+
+// premiumRatio returns call/put (SideCall) or put/call (SidePut) premium, +Inf when only that side traded
+func premiumRatio(summary analysis.TimePeriodSummary, side string) float64 {
+	numerator, denominator := summary.CallPremium, summary.PutPremium
+	if side == SidePut {
+		numerator, denominator = denominator, numerator
+	}
+	if denominator > 0 {
+		return numerator / denominator
+	}
+	if numerator > 0 {
+		return math.Inf(1)
+	}
+	return 0
+}
+
+// RulesFromConfig builds the rule tree equivalent to a NotificationConfig's thresholds and rate-of-change conditions
+// Ratio thresholds with hysteresis keep their state in states under key (see EvaluateRatioHysteresis); a nil states
+// evaluates them without hysteresis
+func RulesFromConfig(config NotificationConfig, states *RatioStates, key string) Rule {
+	var conditions AnyRule
+
+	if config.CallPremiumThreshold > 0 {
+		conditions = append(conditions, PremiumRule{Side: SideCall, Min: float64(config.CallPremiumThreshold)})
+	}
+	if config.PutPremiumThreshold > 0 {
+		conditions = append(conditions, PremiumRule{Side: SidePut, Min: float64(config.PutPremiumThreshold)})
+	}
+
+	if config.InstitutionalCallPremiumThreshold > 0 {
+		conditions = append(conditions, SizeRule{Class: analysis.SizeInstitutional, Side: SideCall, Min: float64(config.InstitutionalCallPremiumThreshold)})
+	}
+	if config.InstitutionalPutPremiumThreshold > 0 {
+		conditions = append(conditions, SizeRule{Class: analysis.SizeInstitutional, Side: SidePut, Min: float64(config.InstitutionalPutPremiumThreshold)})
+	}
+
+	if config.CallPremiumZScoreThreshold > 0 {
+		conditions = append(conditions, ZScoreRule{Side: SideCall, Min: config.CallPremiumZScoreThreshold})
+	}
+	if config.PutPremiumZScoreThreshold > 0 {
+		conditions = append(conditions, ZScoreRule{Side: SidePut, Min: config.PutPremiumZScoreThreshold})
+	}
+
+	ratioPremium := PremiumRule{Side: SideTotal, Min: float64(config.RatioPremiumThreshold)}
+	if config.RatioHysteresis() && states != nil {
+		for _, rule := range ratioHysteresisRules(states, key, config) {
+			conditions = append(conditions, rule)
+		}
+	} else if config.RatioPremiumThreshold > 0 {
+		if config.CallRatioThreshold > 0 {
+			conditions = append(conditions, AllRule{ratioPremium, RatioRule{Side: SideCall, Min: config.CallRatioThreshold}})
+		}
+		if config.PutRatioThreshold > 0 {
+			conditions = append(conditions, AllRule{ratioPremium, RatioRule{Side: SidePut, Min: config.PutRatioThreshold}})
+		}
+	}
+
+	if config.CallPremiumChangeMultiple > 0 {
+		conditions = append(conditions, ChangeRule{Side: SideCall, Multiple: config.CallPremiumChangeMultiple, MinPremium: float64(config.ChangeMinPremium)})
+	}
+	if config.PutPremiumChangeMultiple > 0 {
+		conditions = append(conditions, ChangeRule{Side: SidePut, Multiple: config.PutPremiumChangeMultiple, MinPremium: float64(config.ChangeMinPremium)})
+	}
+
+	if config.RatioFlipFrom > 0 && config.RatioFlipTo > 0 {
+		window := config.RatioFlipWindowMinutes
+		if window == 0 {
+			window = DefaultRatioFlipWindowMinutes
+		}
+		conditions = append(conditions, AllRule{ratioPremium, RatioFlipRule{From: config.RatioFlipFrom, To: config.RatioFlipTo, Window: time.Duration(window) * time.Minute}})
+	}
+
+	if len(config.Sessions) == 0 {
+		return conditions
+	}
+	sessions, err := market.ParseSessionSet(strings.Join(config.Sessions, ","))
+	if err != nil {
+		return NeverRule{}
+	}
+	return AllRule{SessionRule{Sessions: sessions}, conditions}
+}
@@ -0,0 +1,74 @@
+package notifications
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+)
+
+// shadowRetention is how long divergences are remembered so repeated in-progress evaluations log once
+const shadowRetention = 6 * time.Hour
+
+// ShadowEvaluator runs the rule engine alongside the current threshold evaluator and logs where they disagree
+// It never sends notifications; the current evaluator's decision is always the one acted on
+type ShadowEvaluator struct {
+	mu          sync.Mutex
+	logged      map[string]time.Time // Divergence key -> period end, for de-duplication
+	ratios      *RatioStates         // The rule engine's ratio hysteresis state, kept apart from the current evaluator's
+	evaluations int64
+	divergences int64
+}
+
+// NewShadowEvaluator creates a shadow evaluator
+func NewShadowEvaluator() *ShadowEvaluator {
+	return &ShadowEvaluator{logged: make(map[string]time.Time), ratios: NewRatioStates()}
+}
+
+// Compare evaluates the rule engine for a period the current evaluator has decided (current) and logs a divergence
+// Each divergence is logged once per user, ticker, period, and outcome; Compare returns the rule engine's decision
+func (e *ShadowEvaluator) Compare(userID string, ticker string, summary analysis.TimePeriodSummary, history []analysis.TimePeriodSummary, config NotificationConfig, current bool) bool {
+	// A user has one config per ticker, so user and ticker identify its ratio alerts
+	rule := RulesFromConfig(config, e.ratios, userID+"|"+ticker)
+	candidate := rule.Match(summary, history)
+	e.ratios.Prune(summary.PeriodEnd.Add(-shadowRetention))
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.evaluations++
+	if candidate == current {
+		return candidate
+	}
+
+	key := fmt.Sprintf("%s|%s|%d|%t", userID, ticker, summary.PeriodEnd.UnixMilli(), candidate)
+	if _, ok := e.logged[key]; ok {
+		return candidate
+	}
+	e.logged[key] = summary.PeriodEnd
+	e.divergences++
+	e.prune(summary.PeriodEnd.Add(-shadowRetention))
+
+	log.Printf("Shadow divergence: User %s, Ticker %s, Period %s: current=%t rules=%t (call %.0f, put %.0f, ratio %.2f) rules: %s",
+		userID, ticker, summary.PeriodEnd.Format("2006-01-02 15:04:05"), current, candidate,
+		summary.CallPremium, summary.PutPremium, analysis.RatioValue(summary.CallPutRatio), rule)
+	return candidate
+}
+
+// prune forgets divergences for periods that ended before cutoff
+func (e *ShadowEvaluator) prune(cutoff time.Time) {
+	for key, periodEnd := range e.logged {
+		if periodEnd.Before(cutoff) {
+			delete(e.logged, key)
+		}
+	}
+}
+
+// Stats returns the number of comparisons and distinct divergences so far
+func (e *ShadowEvaluator) Stats() (evaluations int64, divergences int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.evaluations, e.divergences
+}
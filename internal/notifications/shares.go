@@ -0,0 +1,223 @@
+package notifications
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Sharing limits
+const (
+	ShareInviteTTL     = 48 * time.Hour // How long an invite code can be accepted for
+	MaxShareRecipients = 5              // Accounts one user's rule set can be shared with, including pending invites
+	shareCodeLength    = 10
+	sharesDirName      = "shares" // Subdirectory of the notifications directory (skipped by LoadAllNotifications)
+)
+
+// shareCodeAlphabet leaves out characters that are easy to misread when an invite code is typed in by hand
+const shareCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// Errors returned when creating or accepting invites
+var (
+	ErrInviteNotFound = errors.New("invite code not found or expired")
+	ErrShareSelf      = errors.New("cannot accept your own invite")
+	ErrShareExists    = errors.New("rule set is already shared with this account")
+	ErrShareLimit     = fmt.Errorf("rule set is already shared with %d accounts", MaxShareRecipients)
+)
+
+// ShareInvite is a pending offer to share an owner's rule set; whoever accepts the code first becomes the recipient
+type ShareInvite struct {
+	Code      string    `json:"code"`
+	OwnerID   string    `json:"owner_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Share gives a recipient a read-only view of the owner's notification rules; the recipient's devices receive the
+// alerts the owner's rules trigger
+type Share struct {
+	OwnerID     string    `json:"owner_id"`
+	RecipientID string    `json:"recipient_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SharedRules is a read-only view of another account's rule set, as listed to a recipient
+type SharedRules struct {
+	OwnerID       string                        `json:"owner_id"`
+	Notifications map[string]NotificationConfig `json:"notifications"` // Map: ticker -> config
+}
+
+// ShareRecords holds every pending invite and accepted share, stored in one file shared by the server and the
+// notifications service
+type ShareRecords struct {
+	Invites []ShareInvite `json:"invites"`
+	Shares  []Share       `json:"shares"`
+}
+
+// sharesFile returns the path of the share records file under the notifications directory
+func sharesFile(dir string) string {
+	return filepath.Join(dir, sharesDirName, "shares.json")
+}
+
+// LoadShares loads the share records from the notifications directory (empty if none have been saved)
+func LoadShares(dir string) (*ShareRecords, error) {
+	filename := sharesFile(dir)
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return &ShareRecords{Invites: []ShareInvite{}, Shares: []Share{}}, nil
+	}
+
+	data, err := readUserFile(filename, "shares")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shares file: %w", err)
+	}
+
+	var records ShareRecords
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse shares file: %w", err)
+	}
+	return &records, nil
+}
+
+// SaveShares saves the share records to the notifications directory
+func SaveShares(dir string, records *ShareRecords) error {
+	filename := sharesFile(dir)
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return fmt.Errorf("failed to create shares directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal shares: %w", err)
+	}
+
+	if err := writeUserFile(filename, "shares", data); err != nil {
+		return fmt.Errorf("failed to write shares file: %w", err)
+	}
+	return nil
+}
+
+// PruneExpired drops invites that expired by now
+func (r *ShareRecords) PruneExpired(now time.Time) {
+	invites := r.Invites[:0]
+	for _, invite := range r.Invites {
+		if now.Before(invite.ExpiresAt) {
+			invites = append(invites, invite)
+		}
+	}
+	r.Invites = invites
+}
+
+// CreateInvite adds an invite to share owner's rule set, valid for ShareInviteTTL
+func (r *ShareRecords) CreateInvite(owner string, now time.Time) (ShareInvite, error) {
+	r.PruneExpired(now)
+	if len(r.Recipients(owner))+len(r.PendingInvites(owner)) >= MaxShareRecipients {
+		return ShareInvite{}, ErrShareLimit
+	}
+
+	code, err := newShareCode()
+	if err != nil {
+		return ShareInvite{}, err
+	}
+	invite := ShareInvite{Code: code, OwnerID: owner, CreatedAt: now, ExpiresAt: now.Add(ShareInviteTTL)}
+	r.Invites = append(r.Invites, invite)
+	return invite, nil
+}
+
+// AcceptInvite turns an unexpired invite into a share with recipient, consuming the code
+func (r *ShareRecords) AcceptInvite(code string, recipient string, now time.Time) (Share, error) {
+	r.PruneExpired(now)
+	for i, invite := range r.Invites {
+		if invite.Code != code {
+			continue
+		}
+		if invite.OwnerID == recipient {
+			return Share{}, ErrShareSelf
+		}
+		for _, existing := range r.Recipients(invite.OwnerID) {
+			if existing == recipient {
+				return Share{}, ErrShareExists
+			}
+		}
+
+		r.Invites = append(r.Invites[:i], r.Invites[i+1:]...)
+		share := Share{OwnerID: invite.OwnerID, RecipientID: recipient, CreatedAt: now}
+		r.Shares = append(r.Shares, share)
+		return share, nil
+	}
+	return Share{}, ErrInviteNotFound
+}
+
+// RevokeInvite drops owner's pending invite with the given code, reporting whether there was one
+func (r *ShareRecords) RevokeInvite(owner string, code string) bool {
+	for i, invite := range r.Invites {
+		if invite.OwnerID == owner && invite.Code == code {
+			r.Invites = append(r.Invites[:i], r.Invites[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Remove drops the share from owner to recipient, reporting whether there was one
+func (r *ShareRecords) Remove(owner string, recipient string) bool {
+	for i, share := range r.Shares {
+		if share.OwnerID == owner && share.RecipientID == recipient {
+			r.Shares = append(r.Shares[:i], r.Shares[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// PendingInvites returns owner's invites that haven't been accepted, oldest first
+func (r *ShareRecords) PendingInvites(owner string) []ShareInvite {
+	invites := []ShareInvite{}
+	for _, invite := range r.Invites {
+		if invite.OwnerID == owner {
+			invites = append(invites, invite)
+		}
+	}
+	return invites
+}
+
+// Recipients returns the accounts owner's rule set is shared with, sorted
+func (r *ShareRecords) Recipients(owner string) []string {
+	recipients := []string{}
+	for _, share := range r.Shares {
+		if share.OwnerID == owner {
+			recipients = append(recipients, share.RecipientID)
+		}
+	}
+	sort.Strings(recipients)
+	return recipients
+}
+
+// Owners returns the accounts whose rule sets are shared with recipient, sorted
+func (r *ShareRecords) Owners(recipient string) []string {
+	owners := []string{}
+	for _, share := range r.Shares {
+		if share.RecipientID == recipient {
+			owners = append(owners, share.OwnerID)
+		}
+	}
+	sort.Strings(owners)
+	return owners
+}
+
+// newShareCode returns a random invite code
+func newShareCode() (string, error) {
+	random := make([]byte, shareCodeLength)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate invite code: %w", err)
+	}
+	code := make([]byte, shareCodeLength)
+	for i, b := range random {
+		code[i] = shareCodeAlphabet[int(b)%len(shareCodeAlphabet)]
+	}
+	return string(code), nil
+}
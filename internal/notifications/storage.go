@@ -0,0 +1,111 @@
+package notifications
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// encryptedMagic prefixes user data files written with encryption enabled, followed by the nonce and ciphertext
+var encryptedMagic = []byte("JAXOVENC1\n")
+
+// storage holds the at-rest encryption for user data files (nil AEAD stores plaintext)
+var storage struct {
+	mu   sync.RWMutex
+	aead cipher.AEAD
+}
+
+// EnableEncryption turns on AES-GCM encryption for the devices and notifications files written by this package
+// The key must be 32 bytes (AES-256). Existing plaintext files stay readable and are encrypted the next time they are saved
+func EnableEncryption(key []byte) error {
+	if len(key) != 32 {
+		return fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	storage.mu.Lock()
+	storage.aead = aead
+	storage.mu.Unlock()
+	return nil
+}
+
+// readUserFile reads a user data file, decrypting it if it was written encrypted
+// label binds the ciphertext to its user and kind (e.g. "devices:<sub>") so files can't be swapped between users
+func readUserFile(filename string, label string) ([]byte, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, encryptedMagic) {
+		return data, nil
+	}
+
+	storage.mu.RLock()
+	aead := storage.aead
+	storage.mu.RUnlock()
+	if aead == nil {
+		return nil, fmt.Errorf("file is encrypted but no encryption key is configured")
+	}
+
+	sealed := data[len(encryptedMagic):]
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted file is truncated")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(label))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt file (wrong key or tampered data): %w", err)
+	}
+	return plaintext, nil
+}
+
+// writeUserFile atomically writes a user data file readable only by its owner, encrypting it when encryption is enabled
+func writeUserFile(filename string, label string, data []byte) error {
+	storage.mu.RLock()
+	aead := storage.aead
+	storage.mu.RUnlock()
+
+	if aead != nil {
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return fmt.Errorf("failed to generate nonce: %w", err)
+		}
+		sealed := append([]byte{}, encryptedMagic...)
+		sealed = append(sealed, nonce...)
+		data = aead.Seal(sealed, nonce, data, []byte(label))
+	}
+
+	// Other processes (the notifications service) read these files while they are saved, so the data is written to a
+	// temporary file in the same directory and renamed over the old one: readers see the old file or the new one,
+	// never a truncated one
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// CreateTemp creates the file readable only by its owner, and the rename replaces files created with a looser mode
+	return os.Rename(tmp.Name(), filename)
+}
@@ -0,0 +1,63 @@
+package notifications
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestWriteUserFileReplacesAtomically(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "shares.json")
+	if err := os.WriteFile(filename, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Readers polling while the file is rewritten see a whole version, never an empty or partial one
+	versions := [][]byte{[]byte(`{"version":"a"}`), []byte(`{"version":"bb"}`)}
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			data, err := readUserFile(filename, "shares")
+			if err != nil {
+				t.Errorf("read during write: %v", err)
+				return
+			}
+			if s := string(data); s != "old" && s != string(versions[0]) && s != string(versions[1]) {
+				t.Errorf("read a torn file: %q", s)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		if err := writeUserFile(filename, "shares", versions[i%2]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("mode = %v, want 0600", mode)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("%d files in the directory, want only shares.json (temporary files left behind)", len(entries))
+	}
+}
@@ -0,0 +1,114 @@
+package notifications
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+)
+
+// SessionTicker is one ticker's line in an end-of-session summary push
+type SessionTicker struct {
+	Ticker       string                 `json:"ticker"`
+	CallPremium  float64                `json:"call_premium"`
+	PutPremium   float64                `json:"put_premium"`
+	TotalPremium float64                `json:"total_premium"`
+	CallPutRatio *float64               `json:"call_put_ratio"` // Null when there is call premium but no put premium
+	LargestTrade *analysis.LargestTrade `json:"largest_trade,omitempty"`
+	AlertsFired  int                    `json:"alerts_fired"` // Periods the user was alerted for during the session
+}
+
+// NewSessionTicker builds a summary line from a ticker's day totals and the number of alerts the user received for it
+func NewSessionTicker(daily analysis.DailySummary, alertsFired int) SessionTicker {
+	return SessionTicker{
+		Ticker:       daily.Ticker,
+		CallPremium:  daily.CallPremium,
+		PutPremium:   daily.PutPremium,
+		TotalPremium: daily.TotalPremium,
+		CallPutRatio: daily.CallPutRatio,
+		LargestTrade: daily.LargestTrade,
+		AlertsFired:  alertsFired,
+	}
+}
+
+// SessionSummaryBody formats the alert body of a session summary push, one line per ticker
+// Example: "AAPL $12.4M (C/P 1.85), largest $1.3M, 2 alerts"
+func SessionSummaryBody(tickers []SessionTicker) string {
+	lines := make([]string, 0, len(tickers))
+	for _, ticker := range tickers {
+		if ticker.TotalPremium == 0 {
+			lines = append(lines, fmt.Sprintf("%s no trades", ticker.Ticker))
+			continue
+		}
+
+		ratio := "calls only"
+		if ticker.CallPutRatio != nil {
+			ratio = fmt.Sprintf("C/P %.2f", *ticker.CallPutRatio)
+		}
+		line := fmt.Sprintf("%s %s (%s)", ticker.Ticker, formatPremium(ticker.TotalPremium), ratio)
+		if ticker.LargestTrade != nil {
+			line += fmt.Sprintf(", largest %s", formatPremium(ticker.LargestTrade.Premium))
+		}
+		switch ticker.AlertsFired {
+		case 0:
+			line += ", no alerts"
+		case 1:
+			line += ", 1 alert"
+		default:
+			line += fmt.Sprintf(", %d alerts", ticker.AlertsFired)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatPremium abbreviates a dollar amount for push bodies, e.g. $12.4M or $850K
+func formatPremium(premium float64) string {
+	switch {
+	case premium >= 1e9:
+		return fmt.Sprintf("$%.1fB", premium/1e9)
+	case premium >= 1e6:
+		return fmt.Sprintf("$%.1fM", premium/1e6)
+	case premium >= 1e3:
+		return fmt.Sprintf("$%.0fK", premium/1e3)
+	}
+	return fmt.Sprintf("$%.0f", premium)
+}
+
+// LoadSessionSummaryUsers loads the users who opted in to session summaries and have at least one active notification,
+// sorted by user ID
+func LoadSessionSummaryUsers(dir string) ([]*UserNotifications, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notifications directory: %w", err)
+	}
+
+	var users []*UserNotifications
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		userConfig, err := LoadUserNotifications(strings.TrimSuffix(entry.Name(), ".json"), dir)
+		if err != nil {
+			// Skip unreadable files, as LoadAllNotifications does
+			continue
+		}
+		if !userConfig.SessionSummary || len(userConfig.ActiveTickers()) == 0 {
+			continue
+		}
+		users = append(users, userConfig)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].UserID < users[j].UserID
+	})
+	return users, nil
+}
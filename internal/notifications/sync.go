@@ -0,0 +1,84 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Internal API paths the notifications service serves for the server to push saved state to
+const (
+	SyncNotificationsPath = "/internal/notifications"
+	SyncDevicesPath       = "/internal/devices"
+)
+
+// SyncClient pushes newly saved notification configs and device registrations to the notifications service,
+// so new rules are monitored immediately instead of on its next 30-second reload
+type SyncClient struct {
+	baseURL    string
+	secret     string
+	httpClient *http.Client
+}
+
+// NewSyncClient creates a client for the notifications service's internal API at baseURL (e.g. http://localhost:8090)
+// Requests are authenticated with the shared secret as a bearer token
+func NewSyncClient(baseURL string, secret string) *SyncClient {
+	return &SyncClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		secret:     secret,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// PushNotifications sends a user's full notification config
+func (c *SyncClient) PushNotifications(ctx context.Context, config *UserNotifications) error {
+	return c.post(ctx, SyncNotificationsPath, config)
+}
+
+// PushDevices sends a user's full device list
+func (c *SyncClient) PushDevices(ctx context.Context, devices *UserDevices) error {
+	return c.post(ctx, SyncDevicesPath, devices)
+}
+
+// post sends a JSON body to an internal API path and checks for a 2xx response
+func (c *SyncClient) post(ctx context.Context, path string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.secret)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach notifications service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("notifications service returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// ValidateUserID checks that a pushed user ID is safe to use as a config file name
+func ValidateUserID(sub string) error {
+	if sub == "" {
+		return fmt.Errorf("user_id is required")
+	}
+	if strings.ContainsAny(sub, `/\`) || strings.Contains(sub, "..") {
+		return fmt.Errorf("invalid user_id %q", sub)
+	}
+	return nil
+}
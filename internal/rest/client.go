@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/ekinolik/jax-ov/internal/analysis"
 	massiverest "github.com/massive-com/client-go/v2/rest"
 	"github.com/massive-com/client-go/v2/rest/models"
 )
@@ -37,9 +38,9 @@ type OptionContract struct {
 	UnderlyingTicker string
 }
 
-// Aggregate represents a per-second aggregate matching the websocket format
+// Aggregate represents a per-second or per-minute aggregate matching the websocket format
 type Aggregate struct {
-	EventType         string  `json:"ev"` // "A" for aggregate
+	EventType         string  `json:"ev"` // "A" for per-second, "AM" for per-minute aggregates
 	Symbol            string  `json:"sym"`
 	Volume            int64   `json:"v"`
 	AccumulatedVolume int64   `json:"av"`
@@ -86,6 +87,21 @@ func (c *Client) ListOptionContracts(ctx context.Context, underlyingTicker strin
 
 // GetOptionAggregates fetches per-second aggregates for an option contract on a specific date
 func (c *Client) GetOptionAggregates(ctx context.Context, contractTicker string, date time.Time) ([]Aggregate, error) {
+	return c.GetOptionAggregatesWithTimespan(ctx, contractTicker, date, analysis.TimespanSecond)
+}
+
+// GetOptionAggregatesWithTimespan fetches per-second or per-minute aggregates for an option contract on a specific date
+func (c *Client) GetOptionAggregatesWithTimespan(ctx context.Context, contractTicker string, date time.Time, timespan string) ([]Aggregate, error) {
+	if err := analysis.ValidateTimespan(timespan); err != nil {
+		return nil, err
+	}
+	apiTimespan := models.Second
+	if timespan == analysis.TimespanMinute {
+		apiTimespan = models.Minute
+	}
+	eventType := analysis.TimespanEventType(timespan)
+	duration := analysis.TimespanDuration(timespan).Milliseconds()
+
 	// Calculate start and end of trading day (9:30 AM - 4:00 PM ET)
 	loc, err := time.LoadLocation("America/New_York")
 	if err != nil {
@@ -103,7 +119,7 @@ func (c *Client) GetOptionAggregates(ctx context.Context, contractTicker string,
 	params := models.ListAggsParams{
 		Ticker:     contractTicker,
 		Multiplier:  1,
-		Timespan:   models.Second,
+		Timespan:   apiTimespan,
 		From:       models.Millis(start),
 		To:         models.Millis(end),
 		Order:      &order,
@@ -130,7 +146,7 @@ func (c *Client) GetOptionAggregates(ctx context.Context, contractTicker string,
 
 		timestamp := int64(time.Time(agg.Timestamp).UnixMilli())
 		aggregates = append(aggregates, Aggregate{
-			EventType:         "A",
+			EventType:         eventType,
 			Symbol:            contractTicker,
 			Volume:            volume,
 			AccumulatedVolume: accumulatedVolume,
@@ -143,7 +159,7 @@ func (c *Client) GetOptionAggregates(ctx context.Context, contractTicker string,
 			AggregateVWAP:     agg.VWAP,
 			AverageSize:       avgSize,
 			StartTimestamp:    timestamp,
-			EndTimestamp:      timestamp + 1000, // 1 second later
+			EndTimestamp:      timestamp + duration, // One timespan later
 		})
 	}
 
@@ -154,3 +170,138 @@ func (c *Client) GetOptionAggregates(ctx context.Context, contractTicker string,
 	return aggregates, nil
 }
 
+
+// ContractDailyVolume represents the official session volume for an option contract
+type ContractDailyVolume struct {
+	Ticker       string
+	ContractType string // "call" or "put"
+	Volume       int64
+	LastUpdated  time.Time // When the day's figures were last updated (identifies the session)
+}
+
+// GetOptionChainDailyVolume fetches the most recent session's volume for every option contract on an underlying
+// The snapshot only covers the latest session, so callers should check LastUpdated against the date they expect
+func (c *Client) GetOptionChainDailyVolume(ctx context.Context, underlyingTicker string) ([]ContractDailyVolume, error) {
+	limit := 250
+	params := &models.ListOptionsChainParams{
+		UnderlyingAsset: underlyingTicker,
+		Limit:           &limit,
+	}
+
+	var volumes []ContractDailyVolume
+	iter := c.client.ListOptionsChainSnapshot(ctx, params)
+
+	for iter.Next() {
+		snapshot := iter.Item()
+		volumes = append(volumes, ContractDailyVolume{
+			Ticker:       snapshot.Details.Ticker,
+			ContractType: snapshot.Details.ContractType,
+			Volume:       int64(snapshot.Day.Volume),
+			LastUpdated:  time.Time(snapshot.Day.LastUpdated),
+		})
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("error fetching option chain snapshot: %w", err)
+	}
+
+	return volumes, nil
+}
+
+// ContractOpenInterest is the open interest of an option contract as of the previous session's close
+type ContractOpenInterest struct {
+	Ticker       string
+	ContractType string // "call" or "put"
+	OpenInterest int64
+}
+
+// GetOptionChainOpenInterest fetches the open interest of every option contract on an underlying
+// Open interest is published once a day, so the snapshot reflects positions at the start of the current (or most
+// recent) session; earlier days can't be fetched
+func (c *Client) GetOptionChainOpenInterest(ctx context.Context, underlyingTicker string) ([]ContractOpenInterest, error) {
+	limit := 250
+	params := &models.ListOptionsChainParams{
+		UnderlyingAsset: underlyingTicker,
+		Limit:           &limit,
+	}
+
+	var openInterest []ContractOpenInterest
+	iter := c.client.ListOptionsChainSnapshot(ctx, params)
+
+	for iter.Next() {
+		snapshot := iter.Item()
+		openInterest = append(openInterest, ContractOpenInterest{
+			Ticker:       snapshot.Details.Ticker,
+			ContractType: snapshot.Details.ContractType,
+			OpenInterest: int64(snapshot.OpenInterest),
+		})
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("error fetching option chain snapshot: %w", err)
+	}
+
+	return openInterest, nil
+}
+
+// GetLastTradePrice fetches the price of the most recent trade for a stock ticker
+func (c *Client) GetLastTradePrice(ctx context.Context, ticker string) (float64, time.Time, error) {
+	res, err := c.client.GetLastTrade(ctx, &models.GetLastTradeParams{Ticker: ticker})
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("error fetching last trade for %s: %w", ticker, err)
+	}
+	return res.Results.Price, time.Time(res.Results.Timestamp), nil
+}
+
+// StockBar is a one-minute bar for a stock
+type StockBar struct {
+	Start  time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	VWAP   float64
+	Volume float64
+}
+
+// GetStockMinuteBars fetches one-minute bars for a stock covering the extended session (4:00 AM - 8:00 PM ET) on a date
+func (c *Client) GetStockMinuteBars(ctx context.Context, ticker string, date time.Time) ([]StockBar, error) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load timezone: %w", err)
+	}
+	start := time.Date(date.Year(), date.Month(), date.Day(), 4, 0, 0, 0, loc)
+	end := time.Date(date.Year(), date.Month(), date.Day(), 20, 0, 0, 0, loc)
+
+	limit := 50000
+	order := models.Asc
+	params := models.ListAggsParams{
+		Ticker:     ticker,
+		Multiplier: 1,
+		Timespan:   models.Minute,
+		From:       models.Millis(start),
+		To:         models.Millis(end),
+		Order:      &order,
+		Limit:      &limit,
+	}
+
+	var bars []StockBar
+	iter := c.client.ListAggs(ctx, &params)
+	for iter.Next() {
+		agg := iter.Item()
+		bars = append(bars, StockBar{
+			Start:  time.Time(agg.Timestamp),
+			Open:   agg.Open,
+			High:   agg.High,
+			Low:    agg.Low,
+			Close:  agg.Close,
+			VWAP:   agg.VWAP,
+			Volume: agg.Volume,
+		})
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("error fetching bars for %s: %w", ticker, err)
+	}
+
+	return bars, nil
+}
@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/clock"
+	"github.com/ekinolik/jax-ov/internal/market"
 )
 
 // ReadLogFile reads a JSONL log file and returns all aggregates
@@ -40,11 +42,35 @@ func ReadLogFile(filename string) ([]analysis.Aggregate, error) {
 	return aggregates, nil
 }
 
+// ReadActiveLogFile reads a day's log file without the records of contracts that had expired before that day (see
+// analysis.ActiveAggregates), so the chain and snapshot views show the same contracts whether or not
+// expire-contracts has archived them yet
+func ReadActiveLogFile(filename string, dateStr string) ([]analysis.Aggregate, error) {
+	date, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: %w", dateStr, err)
+	}
+	aggregates, err := ReadLogFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return analysis.ActiveAggregates(aggregates, date), nil
+}
+
 // GetLogFileForTickerAndDate returns the log file path for a specific ticker and date
 // Format: SYMBOL_YYYY-MM-DD.jsonl
 func GetLogFileForTickerAndDate(logDir string, ticker string, dateStr string) string {
-	filename := fmt.Sprintf("%s_%s.jsonl", ticker, dateStr)
-	return filepath.Join(logDir, filename)
+	return analysis.LogFilePath(logDir, ticker, dateStr)
+}
+
+// ResolveDate returns the date used for a ticker when a request doesn't give one: today in the analysis timezone if
+// it's a trading day or the ticker already has a log file for it, otherwise the most recent trading session
+func ResolveDate(logDir string, ticker string) string {
+	today := market.Today()
+	if _, err := os.Stat(GetLogFileForTickerAndDate(logDir, ticker, today)); err == nil {
+		return today
+	}
+	return market.DefaultDate()
 }
 
 // GetLogFilesForDate returns all log file paths for a specific date
@@ -93,9 +119,8 @@ func ReadAllLogFilesForDate(logDir string, dateStr string) ([]analysis.Aggregate
 
 // AnalyzeCurrentDay reads and analyzes all aggregates for the current day
 func AnalyzeCurrentDay(logDir string, periodMinutes int) ([]analysis.TimePeriodSummary, error) {
-	// Get current date in Pacific timezone
-	pacificTZ, _ := time.LoadLocation("America/Los_Angeles")
-	dateStr := time.Now().In(pacificTZ).Format("2006-01-02")
+	// Get current date in the analysis timezone
+	dateStr := market.Today()
 
 	return AnalyzeDate(logDir, dateStr, periodMinutes)
 }
@@ -120,9 +145,31 @@ func AnalyzeDate(logDir string, dateStr string, periodMinutes int) ([]analysis.T
 	return summaries, nil
 }
 
+// ValidateTicker checks that a ticker is a plausible underlying symbol (1-10 letters, digits, or dots)
+// Tickers are used to build log file names, so anything else is rejected
+func ValidateTicker(ticker string) error {
+	if ticker == "" {
+		return fmt.Errorf("ticker parameter is required")
+	}
+	if len(ticker) > 10 {
+		return fmt.Errorf("invalid ticker: %s", ticker)
+	}
+	for _, r := range ticker {
+		if !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') && r != '.' {
+			return fmt.Errorf("invalid ticker: %s", ticker)
+		}
+	}
+	return nil
+}
+
 // AnalyzeTickerAndDate reads and analyzes aggregates for a specific ticker and date
 // Reads only the log file for that ticker: SYMBOL_YYYY-MM-DD.jsonl
 func AnalyzeTickerAndDate(logDir string, ticker string, dateStr string, periodMinutes int) ([]analysis.TimePeriodSummary, error) {
+	return AnalyzeTickerAndDateWithOptions(logDir, ticker, dateStr, analysis.AggregateOptions{PeriodMinutes: periodMinutes})
+}
+
+// AnalyzeTickerAndDateWithOptions reads and analyzes aggregates for a specific ticker and date using the given bucketing options
+func AnalyzeTickerAndDateWithOptions(logDir string, ticker string, dateStr string, opts analysis.AggregateOptions) ([]analysis.TimePeriodSummary, error) {
 	logFile := GetLogFileForTickerAndDate(logDir, ticker, dateStr)
 
 	// Check if file exists
@@ -140,7 +187,7 @@ func AnalyzeTickerAndDate(logDir string, ticker string, dateStr string, periodMi
 		return []analysis.TimePeriodSummary{}, nil
 	}
 
-	summaries, err := analysis.AggregatePremiums(aggregates, periodMinutes)
+	summaries, err := analysis.AggregatePremiumsWithOptions(aggregates, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to aggregate premiums: %w", err)
 	}
@@ -150,9 +197,8 @@ func AnalyzeTickerAndDate(logDir string, ticker string, dateStr string, periodMi
 
 // GetNewAggregatesSince reads all log files for the current day and returns aggregates with timestamps >= sinceTimestamp
 func GetNewAggregatesSince(logDir string, sinceTimestamp int64) ([]analysis.Aggregate, error) {
-	// Get current date in Pacific timezone
-	pacificTZ, _ := time.LoadLocation("America/Los_Angeles")
-	dateStr := time.Now().In(pacificTZ).Format("2006-01-02")
+	// Get current date in the analysis timezone
+	dateStr := market.Today()
 
 	aggregates, err := ReadAllLogFilesForDate(logDir, dateStr)
 	if err != nil {
@@ -170,65 +216,106 @@ func GetNewAggregatesSince(logDir string, sinceTimestamp int64) ([]analysis.Aggr
 	return newAggregates, nil
 }
 
-// GetTransactionsForTickerAndTimePeriod reads a log file for a specific ticker and returns all transactions within a time period
-func GetTransactionsForTickerAndTimePeriod(logDir string, ticker string, dateStr string, timeStr string, periodMinutes int) ([]analysis.Aggregate, error) {
-	// Load Pacific timezone
-	loc, err := time.LoadLocation("America/Los_Angeles")
-	if err != nil {
-		return nil, fmt.Errorf("failed to load timezone: %w", err)
-	}
+// ParseTimeOfDay parses an HH:MM time on a YYYY-MM-DD date, both interpreted in the analysis timezone
+// (the timezone log files are dated in)
+func ParseTimeOfDay(dateStr string, timeStr string) (time.Time, error) {
+	loc := market.AnalysisLocation()
 
 	// Parse time (HH:MM format)
 	timeParts := strings.Split(timeStr, ":")
 	if len(timeParts) != 2 {
-		return nil, fmt.Errorf("invalid time format, expected HH:MM")
+		return time.Time{}, fmt.Errorf("invalid time format, expected HH:MM")
 	}
 
 	var hour, minute int
 	if _, err := fmt.Sscanf(timeParts[0], "%d", &hour); err != nil {
-		return nil, fmt.Errorf("invalid hour in time: %w", err)
+		return time.Time{}, fmt.Errorf("invalid hour in time: %w", err)
 	}
 	if _, err := fmt.Sscanf(timeParts[1], "%d", &minute); err != nil {
-		return nil, fmt.Errorf("invalid minute in time: %w", err)
+		return time.Time{}, fmt.Errorf("invalid minute in time: %w", err)
 	}
 
 	if hour < 0 || hour > 23 {
-		return nil, fmt.Errorf("hour must be between 0 and 23")
+		return time.Time{}, fmt.Errorf("hour must be between 0 and 23")
 	}
 	if minute < 0 || minute > 59 {
-		return nil, fmt.Errorf("minute must be between 0 and 59")
+		return time.Time{}, fmt.Errorf("minute must be between 0 and 59")
 	}
 
-	// Parse date or use today
-	var date time.Time
-	if dateStr != "" {
-		// Parse date string and interpret it in Pacific Time
-		dateStrWithTime := dateStr + " 00:00:00"
-		date, err = time.ParseInLocation("2006-01-02 15:04:05", dateStrWithTime, loc)
-		if err != nil {
-			return nil, fmt.Errorf("invalid date format, expected YYYY-MM-DD: %w", err)
+	// Parse date string and interpret it in the analysis timezone
+	date, err := time.ParseInLocation("2006-01-02", dateStr, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date format, expected YYYY-MM-DD: %w", err)
+	}
+
+	return time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, loc), nil
+}
+
+// AnalyzeTickerAndDateAsOf analyzes a ticker's log file as it stood at asOf: aggregates starting at or after asOf
+// are excluded, so the last period is partial and day-so-far metrics only cover the session up to asOf
+// Point-in-time results are computed from the log each time rather than served from the history cache
+func AnalyzeTickerAndDateAsOf(logDir string, ticker string, dateStr string, opts analysis.AggregateOptions, asOf time.Time) ([]analysis.TimePeriodSummary, error) {
+	logFile := GetLogFileForTickerAndDate(logDir, ticker, dateStr)
+
+	// Check if file exists
+	if _, err := os.Stat(logFile); os.IsNotExist(err) {
+		return []analysis.TimePeriodSummary{}, nil
+	}
+
+	aggregates, err := ReadLogFile(logFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	cutoff := asOf.UnixMilli()
+	var filtered []analysis.Aggregate
+	for _, agg := range aggregates {
+		if agg.StartTimestamp < cutoff {
+			filtered = append(filtered, agg)
 		}
-	} else {
-		// Use today in Pacific Time
-		now := time.Now().In(loc)
-		date = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
 	}
 
-	// Create start time in Pacific Time
-	startTime := time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, loc)
+	if len(filtered) == 0 {
+		return []analysis.TimePeriodSummary{}, nil
+	}
+
+	summaries, err := analysis.AggregatePremiumsWithOptions(filtered, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate premiums: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// ZeroFillUntil returns how far zero-filled history (see analysis.ZeroFill) extends for a date: to asOf when set,
+// to now for today, whose remaining periods are still to come, and to the last trade for past dates
+func ZeroFillUntil(dateStr string, asOf time.Time) time.Time {
+	if !asOf.IsZero() {
+		return asOf
+	}
+	if dateStr == market.Today() {
+		return clock.Now()
+	}
+	return time.Time{}
+}
+
+// GetTransactionsForTickerAndTimePeriod reads a log file for a specific ticker and returns all transactions within a time period
+func GetTransactionsForTickerAndTimePeriod(logDir string, ticker string, dateStr string, timeStr string, periodMinutes int) ([]analysis.Aggregate, error) {
+	// Default to today, or the most recent trading session on weekends and holidays
+	if dateStr == "" {
+		dateStr = ResolveDate(logDir, ticker)
+	}
+
+	startTime, err := ParseTimeOfDay(dateStr, timeStr)
+	if err != nil {
+		return nil, err
+	}
 	endTime := startTime.Add(time.Duration(periodMinutes) * time.Minute)
 
 	// Convert to Unix milliseconds for comparison
 	startTimestamp := startTime.UnixMilli()
 	endTimestamp := endTime.UnixMilli()
 
-	// Get date string if not provided
-	if dateStr == "" {
-		loc, _ := time.LoadLocation("America/Los_Angeles")
-		now := time.Now().In(loc)
-		dateStr = now.Format("2006-01-02")
-	}
-
 	// Get log file for the specific ticker and date
 	logFile := GetLogFileForTickerAndDate(logDir, ticker, dateStr)
 
@@ -324,52 +411,3 @@ func ReadLogFileIncremental(filename string, lastPosition int64) ([]analysis.Agg
 
 	return aggregates, currentPos, nil
 }
-
-// UpdatePeriodSummaryIncremental updates a period summary with new aggregates incrementally
-func UpdatePeriodSummaryIncremental(summary *analysis.TimePeriodSummary, aggregates []analysis.Aggregate, periodMinutes int) error {
-	for _, agg := range aggregates {
-		// Determine option type
-		optionType, err := analysis.ParseOptionType(agg.Symbol)
-		if err != nil {
-			// Skip aggregates we can't parse
-			continue
-		}
-
-		// Calculate premium
-		premium := analysis.CalculatePremium(agg.Volume, agg.VWAP)
-
-		// Round down to time period to determine which period this belongs to
-		periodStart := analysis.RoundDownToPeriod(agg.StartTimestamp, periodMinutes)
-
-		// Check if this aggregate belongs to the summary's period
-		summaryPeriodStart := summary.PeriodStart.UnixMilli()
-		if periodStart != summaryPeriodStart {
-			// This aggregate belongs to a different period, skip it
-			// (This function only updates one period at a time)
-			continue
-		}
-
-		// Add premium and volume to appropriate type
-		if optionType == "call" {
-			summary.CallPremium += premium
-			summary.CallVolume += agg.Volume
-		} else if optionType == "put" {
-			summary.PutPremium += premium
-			summary.PutVolume += agg.Volume
-		}
-
-		// Update total
-		summary.TotalPremium = summary.CallPremium + summary.PutPremium
-
-		// Calculate call to put ratio
-		if summary.PutPremium > 0 {
-			summary.CallPutRatio = summary.CallPremium / summary.PutPremium
-		} else if summary.CallPremium > 0 {
-			summary.CallPutRatio = -1 // Infinite ratio
-		} else {
-			summary.CallPutRatio = 0
-		}
-	}
-
-	return nil
-}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// API versions of the HTTP and WebSocket endpoints
+const (
+	APIVersion1       = 1
+	CurrentAPIVersion = APIVersion1     // Newest version served
+	APIVersionHeader  = "X-API-Version" // Requests a version on unversioned paths; every response carries the version served
+)
+
+// SupportedAPIVersions lists the API versions served, oldest first
+var SupportedAPIVersions = []int{APIVersion1}
+
+// apiVersionKey is the request context key for the API version a request was routed under
+type apiVersionKey struct{}
+
+// APIVersionOf returns the API version a request was routed under by VersionedAPI (APIVersion1 for requests that
+// weren't), so handlers can change their responses in later versions
+func APIVersionOf(r *http.Request) int {
+	if version, ok := r.Context().Value(apiVersionKey{}).(int); ok {
+		return version
+	}
+	return APIVersion1
+}
+
+// VersionedAPI serves next under a /vN prefix for each supported version, e.g. /v1/auth/login for /auth/login
+// Unversioned paths are kept for clients built before versioning: they are served the version requested in the
+// X-API-Version header, or version 1 without one. Unsupported versions are rejected (404 in the path, 400 in the
+// header) and every other response carries the version served in X-API-Version
+func VersionedAPI(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := strings.TrimSpace(r.Header.Get(APIVersionHeader))
+		version, path, versioned := splitAPIVersion(r.URL.Path)
+		if versioned {
+			if !supportedAPIVersion(version) {
+				http.Error(w, fmt.Sprintf("unsupported API version v%d (supported: %s)", version, supportedAPIVersionList()), http.StatusNotFound)
+				return
+			}
+			if header != "" {
+				if requested, err := parseAPIVersion(header); err != nil || requested != version {
+					http.Error(w, fmt.Sprintf("%s %q conflicts with the v%d path", APIVersionHeader, header, version), http.StatusBadRequest)
+					return
+				}
+			}
+		} else {
+			version = APIVersion1
+			if header != "" {
+				requested, err := parseAPIVersion(header)
+				if err != nil || !supportedAPIVersion(requested) {
+					http.Error(w, fmt.Sprintf("unsupported %s %q (supported: %s)", APIVersionHeader, header, supportedAPIVersionList()), http.StatusBadRequest)
+					return
+				}
+				version = requested
+			}
+		}
+
+		w.Header().Set(APIVersionHeader, strconv.Itoa(version))
+		routed := r.WithContext(context.WithValue(r.Context(), apiVersionKey{}, version))
+		if versioned {
+			// Route the request as its unversioned path, as http.StripPrefix does
+			routed.URL = new(url.URL)
+			*routed.URL = *r.URL
+			routed.URL.Path = path
+			routed.URL.RawPath = ""
+		}
+		next.ServeHTTP(w, routed)
+	})
+}
+
+// splitAPIVersion splits a /vN prefix off a request path, returning the version and the rest of the path ("/" for
+// the bare prefix); versioned is false for paths without one
+func splitAPIVersion(path string) (version int, rest string, versioned bool) {
+	if !strings.HasPrefix(path, "/v") {
+		return 0, path, false
+	}
+	prefix, rest, _ := strings.Cut(path[1:], "/")
+	version, err := parseAPIVersion(prefix)
+	if err != nil {
+		return 0, path, false
+	}
+	return version, "/" + rest, true
+}
+
+// parseAPIVersion parses an API version as N or vN
+func parseAPIVersion(value string) (int, error) {
+	digits := strings.TrimPrefix(strings.TrimPrefix(value, "v"), "V")
+	if digits == "" || strings.TrimLeft(digits, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid API version %q", value)
+	}
+	return strconv.Atoi(digits)
+}
+
+// supportedAPIVersion reports whether version is served
+func supportedAPIVersion(version int) bool {
+	for _, supported := range SupportedAPIVersions {
+		if version == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// supportedAPIVersionList formats the supported versions for error messages, e.g. "v1, v2"
+func supportedAPIVersionList() string {
+	names := make([]string, len(SupportedAPIVersions))
+	for i, version := range SupportedAPIVersions {
+		names[i] = fmt.Sprintf("v%d", version)
+	}
+	return strings.Join(names, ", ")
+}
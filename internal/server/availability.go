@@ -0,0 +1,62 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+)
+
+// DateAvailability describes the data logged for a ticker on a single date
+type DateAvailability struct {
+	Date           string     `json:"date"`            // YYYY-MM-DD
+	Aggregates     int        `json:"aggregates"`      // Number of aggregate records in the log file
+	FirstTimestamp *time.Time `json:"first_timestamp"` // Earliest aggregate start (nil if the file is empty)
+	LastTimestamp  *time.Time `json:"last_timestamp"`  // Latest aggregate end (nil if the file is empty)
+}
+
+// summarizeAvailability computes the aggregate count and time span for one day's aggregates
+func summarizeAvailability(dateStr string, aggregates []analysis.Aggregate) DateAvailability {
+	availability := DateAvailability{Date: dateStr, Aggregates: len(aggregates)}
+	if len(aggregates) == 0 {
+		return availability
+	}
+
+	first := aggregates[0].StartTimestamp
+	last := aggregates[0].EndTimestamp
+	for _, agg := range aggregates[1:] {
+		if agg.StartTimestamp < first {
+			first = agg.StartTimestamp
+		}
+		if agg.EndTimestamp > last {
+			last = agg.EndTimestamp
+		}
+	}
+
+	firstTime := time.UnixMilli(first).UTC()
+	lastTime := time.UnixMilli(last).UTC()
+	availability.FirstTimestamp = &firstTime
+	availability.LastTimestamp = &lastTime
+	return availability
+}
+
+// Availability returns, for every date with a log file for the ticker, the aggregate count and first/last timestamps
+// Dates are sorted ascending; days with empty log files are included with zero aggregates
+func (c *RollupCache) Availability(ticker string) ([]DateAvailability, error) {
+	dates, err := analysis.ListDatesForTicker(c.logDir, ticker)
+	if err != nil {
+		return nil, err
+	}
+
+	availability := make([]DateAvailability, 0, len(dates))
+	for _, dateStr := range dates {
+		day, err := c.loadDay(ticker, dateStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(GetLogFileForTickerAndDate(c.logDir, ticker, dateStr)), err)
+		}
+		availability = append(availability, day.availability)
+	}
+
+	return availability, nil
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/logger"
+	"github.com/ekinolik/jax-ov/internal/market"
+	"github.com/ekinolik/jax-ov/internal/marketdata"
+	"github.com/gorilla/websocket"
+)
+
+// Backfill states reported in BackfillMessage
+const (
+	BackfillPending  = "pending"  // Reconstruction started (or joined); the history follows when it completes
+	BackfillComplete = "complete" // Data was written to the log directory
+	BackfillFailed   = "failed"   // Reconstruction failed or found no data
+)
+
+// ErrBackfillBusy is returned when the maximum number of reconstructions is already running
+var ErrBackfillBusy = errors.New("too many backfills in progress")
+
+// BackfillMessage tells a client that the requested date is being reconstructed from the upstream API
+type BackfillMessage struct {
+	Type    string `json:"type"`  // Always "backfill"
+	State   string `json:"state"` // pending, complete, or failed
+	Ticker  string `json:"ticker"`
+	Date    string `json:"date"`
+	Message string `json:"message,omitempty"`
+}
+
+// BackfillConfig bounds on-demand reconstruction
+type BackfillConfig struct {
+	MaxJobs    int           // Reconstructions allowed to run at once
+	MaxAgeDays int           // Oldest date that may be backfilled, in calendar days before today
+	Workers    int           // Concurrent contract fetches per reconstruction
+	Timeout    time.Duration // Upper bound on a single reconstruction
+}
+
+// BackfillJob is one asynchronous reconstruction of a ticker and date
+// Every client requesting the same ticker and date while it runs shares the job
+type BackfillJob struct {
+	Ticker string
+	Date   string
+	done   chan struct{}
+	err    error
+}
+
+// Done returns a channel that is closed when the job finishes
+func (j *BackfillJob) Done() <-chan struct{} {
+	return j.done
+}
+
+// Err returns the job's error; only valid after Done is closed
+func (j *BackfillJob) Err() error {
+	return j.err
+}
+
+// Backfiller reconstructs missing dates from a history source into the log directory on demand
+type Backfiller struct {
+	logDir  string
+	history marketdata.HistorySource
+	config  BackfillConfig
+	loc     *time.Location // Location log files are dated in
+
+	mu   sync.Mutex
+	jobs map[string]*BackfillJob // Running jobs keyed by ticker and date
+}
+
+// NewBackfiller creates a backfiller writing log files dated in loc
+func NewBackfiller(logDir string, history marketdata.HistorySource, config BackfillConfig, loc *time.Location) *Backfiller {
+	return &Backfiller{
+		logDir:  logDir,
+		history: history,
+		config:  config,
+		loc:     loc,
+		jobs:    make(map[string]*BackfillJob),
+	}
+}
+
+// Eligible checks that a date can be backfilled: a past trading day within MaxAgeDays of today
+func (b *Backfiller) Eligible(dateStr string, today string) error {
+	date, err := time.ParseInLocation("2006-01-02", dateStr, market.Location)
+	if err != nil {
+		return fmt.Errorf("invalid date %q: %w", dateStr, err)
+	}
+	todayDate, err := time.ParseInLocation("2006-01-02", today, market.Location)
+	if err != nil {
+		return fmt.Errorf("invalid date %q: %w", today, err)
+	}
+
+	if !date.Before(todayDate) {
+		return fmt.Errorf("%s is not in the past", dateStr)
+	}
+	if b.config.MaxAgeDays > 0 && date.Before(todayDate.AddDate(0, 0, -b.config.MaxAgeDays)) {
+		return fmt.Errorf("%s is more than %d days ago", dateStr, b.config.MaxAgeDays)
+	}
+	if !market.IsTradingDay(date) {
+		return fmt.Errorf("%s is not a trading day", dateStr)
+	}
+	return nil
+}
+
+// Start starts reconstructing a ticker and date in the background, or joins the job already running for it
+func (b *Backfiller) Start(ticker string, dateStr string, today string) (*BackfillJob, error) {
+	if err := b.Eligible(dateStr, today); err != nil {
+		return nil, err
+	}
+
+	key := ticker + "_" + dateStr
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if job, ok := b.jobs[key]; ok {
+		return job, nil
+	}
+	if b.config.MaxJobs > 0 && len(b.jobs) >= b.config.MaxJobs {
+		return nil, ErrBackfillBusy
+	}
+
+	job := &BackfillJob{Ticker: ticker, Date: dateStr, done: make(chan struct{})}
+	b.jobs[key] = job
+	go b.run(key, job)
+	return job, nil
+}
+
+// run reconstructs a job's date and appends it to the log directory
+// The job isn't tied to any one client, so the data lands even if the requesting client disconnects
+func (b *Backfiller) run(key string, job *BackfillJob) {
+	defer func() {
+		b.mu.Lock()
+		delete(b.jobs, key)
+		b.mu.Unlock()
+		close(job.done)
+	}()
+
+	ctx := context.Background()
+	if b.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.config.Timeout)
+		defer cancel()
+	}
+
+	log.Printf("Backfilling %s on %s", job.Ticker, job.Date)
+	start := time.Now()
+
+	date, _ := time.ParseInLocation("2006-01-02", job.Date, market.Location)
+	aggregates, errorCount, err := marketdata.FetchDay(ctx, b.history, job.Ticker, date, b.config.Workers)
+	if err != nil {
+		job.err = fmt.Errorf("failed to reconstruct %s on %s: %w", job.Ticker, job.Date, err)
+		log.Printf("Backfill failed: %v", job.err)
+		return
+	}
+	if len(aggregates) == 0 {
+		job.err = fmt.Errorf("no upstream data for %s on %s", job.Ticker, job.Date)
+		log.Printf("Backfill failed: %v", job.err)
+		return
+	}
+
+	result, err := logger.ImportAggregates(b.logDir, aggregates, b.loc)
+	if err != nil {
+		job.err = fmt.Errorf("failed to write backfilled data: %w", err)
+		log.Printf("Backfill failed: %v", job.err)
+		return
+	}
+
+	log.Printf("Backfilled %s on %s: %d aggregates (%d duplicates, %d contract errors) in %s",
+		job.Ticker, job.Date, result.Imported, result.Duplicates, errorCount, time.Since(start).Round(time.Millisecond))
+}
+
+// SendBackfillState writes a backfill message to a client
+func SendBackfillState(conn *websocket.Conn, msg BackfillMessage) error {
+	msg.Type = MessageTypeBackfill
+	return writeMessage(conn, MessageTypeBackfill, msg.Ticker, msg)
+}
+
+// AwaitBackfill waits for a job to finish, pinging the client so idle proxies keep the connection open
+// It returns an error if the client can no longer be written to
+func AwaitBackfill(conn *websocket.Conn, job *BackfillJob, pingInterval time.Duration) error {
+	pingTicker := time.NewTicker(pingInterval)
+	defer pingTicker.Stop()
+
+	for {
+		select {
+		case <-job.Done():
+			return nil
+		case <-pingTicker.C:
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// DefaultBaselineDays is how many trading days the average daily volume covers unless configured
+const DefaultBaselineDays = 20
+
+// VolumeBaselines computes tickers' average daily option volume from the trailing days' log files
+// Daily totals come from the rollup cache, so unchanged files are only read once
+type VolumeBaselines struct {
+	rollups *RollupCache
+	days    int
+}
+
+// NewVolumeBaselines creates baselines over the given number of trading days (0 or less uses DefaultBaselineDays)
+func NewVolumeBaselines(rollups *RollupCache, days int) *VolumeBaselines {
+	if days <= 0 {
+		days = DefaultBaselineDays
+	}
+	return &VolumeBaselines{rollups: rollups, days: days}
+}
+
+// Baseline returns a ticker's average daily volume over the trading days before dateStr
+// It returns nil when none of those days have a log file
+func (b *VolumeBaselines) Baseline(ticker string, dateStr string) (*analysis.VolumeBaseline, error) {
+	date, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: %w", dateStr, err)
+	}
+
+	dayBefore := date.AddDate(0, 0, -1).Format("2006-01-02")
+	var days []analysis.RollupSummary
+	for _, day := range market.CurrentTradingDays().Past(dayBefore, b.days) {
+		totals, err := b.rollups.DailyTotals(ticker, day)
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize %s on %s: %w", ticker, day, err)
+		}
+		days = append(days, totals)
+	}
+
+	baseline := analysis.AverageDailyVolume(days)
+	if baseline.Days == 0 {
+		return nil, nil
+	}
+	return &baseline, nil
+}
+
+// Apply returns a copy of summaries with relative flow set from the ticker's baseline for dateStr
+// The input is left unchanged (e.g. summaries shared with the history cache); on error the copies have no relative flow
+func (b *VolumeBaselines) Apply(ticker string, dateStr string, summaries []analysis.TimePeriodSummary) ([]analysis.TimePeriodSummary, error) {
+	relative := make([]analysis.TimePeriodSummary, len(summaries))
+	copy(relative, summaries)
+
+	baseline, err := b.Baseline(ticker, dateStr)
+	analysis.ApplyVolumeBaseline(relative, baseline)
+	return relative, err
+}
+
+// premiumBaselineKey identifies a ticker's time-of-day baseline for a date and set of bucketing options
+type premiumBaselineKey struct {
+	ticker string
+	date   string
+	opts   analysis.AggregateOptions
+}
+
+// dayFile is the state of a log file when it was read into a baseline (zero for a missing file)
+type dayFile struct {
+	size    int64
+	modTime time.Time
+}
+
+// cachedPremiumBaseline holds a baseline along with the state of the log files it was computed from
+type cachedPremiumBaseline struct {
+	baseline *analysis.TimeOfDayBaseline
+	files    map[string]dayFile // Key: log file path
+}
+
+// PremiumBaselines computes tickers' normal premium for each time of day from the trailing days' log files
+// Baselines are cached per ticker, date, and bucketing options; an entry is recomputed when any of its log files
+// changes, and the least recently used entries are evicted once the cache holds its maximum number of entries
+// Cached baselines are shared between callers and must not be modified
+type PremiumBaselines struct {
+	logDir    string
+	days      int
+	baselines *LRU[premiumBaselineKey, cachedPremiumBaseline]
+	mu        sync.Mutex
+}
+
+// NewPremiumBaselines creates baselines over the given number of trading days (0 or less uses DefaultBaselineDays),
+// caching at most maxEntries of them (0 for unbounded)
+func NewPremiumBaselines(logDir string, days int, maxEntries int) *PremiumBaselines {
+	if days <= 0 {
+		days = DefaultBaselineDays
+	}
+	return &PremiumBaselines{
+		logDir:    logDir,
+		days:      days,
+		baselines: NewLRU[premiumBaselineKey, cachedPremiumBaseline](maxEntries, nil),
+	}
+}
+
+// CacheStats returns the cache's size and hit, miss, and eviction counters
+func (b *PremiumBaselines) CacheStats() CacheStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.baselines.Stats()
+}
+
+// Baseline returns a ticker's normal premium per time of day over the trading days before dateStr, bucketed by opts
+// It returns nil when none of those days have a log file, and for strikes limited to near spot (opts.MaxMoneyness),
+// since past days' spot prices aren't available to filter them the same way
+func (b *PremiumBaselines) Baseline(ticker string, dateStr string, opts analysis.AggregateOptions) (*analysis.TimeOfDayBaseline, error) {
+	if opts.MaxMoneyness > 0 {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: %w", dateStr, err)
+	}
+
+	dayBefore := date.AddDate(0, 0, -1).Format("2006-01-02")
+	days := market.CurrentTradingDays().Past(dayBefore, b.days)
+	files := make(map[string]dayFile, len(days))
+	for _, day := range days {
+		logFile := GetLogFileForTickerAndDate(b.logDir, ticker, day)
+		info, err := os.Stat(logFile)
+		if os.IsNotExist(err) {
+			files[logFile] = dayFile{}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat log file: %w", err)
+		}
+		files[logFile] = dayFile{size: info.Size(), modTime: info.ModTime()}
+	}
+
+	key := premiumBaselineKey{ticker: ticker, date: dateStr, opts: opts}
+	b.mu.Lock()
+	cached, ok := b.baselines.Get(key)
+	b.mu.Unlock()
+	if ok && sameFiles(cached.files, files) {
+		return cached.baseline, nil
+	}
+
+	summaries := make([][]analysis.TimePeriodSummary, 0, len(days))
+	for _, day := range days {
+		daySummaries, err := AnalyzeTickerAndDateWithOptions(b.logDir, ticker, day, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize %s on %s: %w", ticker, day, err)
+		}
+		summaries = append(summaries, daySummaries)
+	}
+	var baseline *analysis.TimeOfDayBaseline
+	if average := analysis.AverageTimeOfDay(summaries); average.Days > 0 {
+		baseline = &average
+	}
+
+	b.mu.Lock()
+	b.baselines.Add(key, cachedPremiumBaseline{baseline: baseline, files: files})
+	b.mu.Unlock()
+
+	return baseline, nil
+}
+
+// sameFiles reports whether two sets of log file states match
+func sameFiles(a map[string]dayFile, b map[string]dayFile) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, file := range a {
+		other, exists := b[path]
+		if !exists || other.size != file.size || !other.modTime.Equal(file.modTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns a copy of summaries compared with the ticker's normal premium for dateStr
+// The input is left unchanged (e.g. summaries shared with the history cache); on error the copies have no comparison
+func (b *PremiumBaselines) Apply(ticker string, dateStr string, opts analysis.AggregateOptions, summaries []analysis.TimePeriodSummary) ([]analysis.TimePeriodSummary, error) {
+	compared := make([]analysis.TimePeriodSummary, len(summaries))
+	copy(compared, summaries)
+
+	baseline, err := b.Baseline(ticker, dateStr, opts)
+	analysis.ApplyTimeOfDayBaseline(compared, baseline)
+	return compared, err
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/marketdata"
+)
+
+// ChainReport holds the contracts traded on a date for one expiration of a ticker's chain
+type ChainReport struct {
+	Ticker     string                   `json:"ticker"`
+	Date       string                   `json:"date"`
+	Expiration string                   `json:"expiration"`
+	Contracts  []analysis.ChainContract `json:"contracts"` // By strike, call before put
+}
+
+// AnalyzeChain totals every contract of a ticker traded on a date that expires on expiration (YYYY-MM-DD)
+// A missing log file yields an empty report, and contracts that had expired before the date are left out
+func AnalyzeChain(logDir string, ticker string, dateStr string, expiration string) (ChainReport, error) {
+	report := ChainReport{
+		Ticker:     ticker,
+		Date:       dateStr,
+		Expiration: expiration,
+		Contracts:  []analysis.ChainContract{},
+	}
+	expiry, err := time.Parse("2006-01-02", expiration)
+	if err != nil {
+		return report, fmt.Errorf("invalid expiration %q, expected YYYY-MM-DD", expiration)
+	}
+
+	logFile := GetLogFileForTickerAndDate(logDir, ticker, dateStr)
+	if _, err := os.Stat(logFile); os.IsNotExist(err) {
+		return report, nil
+	}
+	aggregates, err := ReadActiveLogFile(logFile, dateStr)
+	if err != nil {
+		return report, fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	report.Contracts = analysis.BuildChain(aggregates, expiry)
+	return report, nil
+}
+
+// ApplyChainVolatilityForDate sets the implied volatility of a date's chain contracts, using the underlying's
+// one-minute closes from spot (see analysis.ApplyChainVolatility)
+func ApplyChainVolatilityForDate(ctx context.Context, spot marketdata.SpotSource, ticker string, dateStr string, chain []analysis.ChainContract) error {
+	if len(chain) == 0 {
+		return nil
+	}
+	date, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return fmt.Errorf("invalid date %q: %w", dateStr, err)
+	}
+	bars, err := spot.PriceBars(ctx, ticker, date)
+	if err != nil {
+		return fmt.Errorf("failed to fetch prices for %s on %s: %w", ticker, dateStr, err)
+	}
+	closes := make(map[int64]float64, len(bars))
+	for _, bar := range bars {
+		closes[bar.Start.UnixMilli()] = bar.Close
+	}
+	analysis.ApplyChainVolatility(chain, closes, analysis.DefaultRiskFreeRate)
+	return nil
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeChainLeavesOutExpiredContracts(t *testing.T) {
+	logDir := t.TempDir()
+	// 2025-11-26: the 11-21 contract had already expired, the 11-26 one expires that day
+	lines := []string{
+		`{"sym":"O:AAPL251121C00150000","v":10,"vw":1.5,"s":1764167400000}`,
+		`{"sym":"O:AAPL251126C00150000","v":20,"vw":2.5,"s":1764167400000}`,
+		`{"sym":"O:AAPL251126P00150000","v":5,"vw":1.0,"s":1764167460000}`,
+	}
+	logFile := filepath.Join(logDir, "AAPL_2025-11-26.jsonl")
+	if err := os.WriteFile(logFile, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := AnalyzeChain(logDir, "AAPL", "2025-11-26", "2025-11-26")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Contracts) != 2 {
+		t.Fatalf("got %d contracts, want 2: %+v", len(report.Contracts), report.Contracts)
+	}
+	for _, contract := range report.Contracts {
+		if strings.Contains(contract.Symbol, "251121") {
+			t.Errorf("expired contract %s in chain", contract.Symbol)
+		}
+	}
+
+	report, err = AnalyzeChain(logDir, "AAPL", "2025-11-26", "2025-11-21")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Contracts) != 0 {
+		t.Errorf("expired expiration returned %d contracts, want none", len(report.Contracts))
+	}
+
+	aggregates, err := ReadActiveLogFile(logFile, "2025-11-26")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aggregates) != 2 {
+		t.Errorf("ReadActiveLogFile returned %d aggregates, want 2", len(aggregates))
+	}
+	if _, err := ReadActiveLogFile(logFile, "11/26/2025"); err == nil {
+		t.Error("ReadActiveLogFile accepted an invalid date")
+	}
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"compress/flate"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultCompressionLevel is the deflate level for compressed WebSocket messages: the repetitive JSON of a history
+// burst compresses nearly as well at the fastest level as at the slowest, for much less CPU per connection
+const DefaultCompressionLevel = flate.BestSpeed
+
+// ValidateCompressionLevel checks that a deflate level is between 1 (fastest) and 9 (smallest)
+func ValidateCompressionLevel(level int) error {
+	if level < flate.BestSpeed || level > flate.BestCompression {
+		return fmt.Errorf("invalid compression level %d (must be %d-%d)", level, flate.BestSpeed, flate.BestCompression)
+	}
+	return nil
+}
+
+// CompressionRequested reports whether a client offered permessage-deflate (RFC 7692) in its upgrade request; an
+// upgrader with compression enabled negotiates it for these clients
+func CompressionRequested(r *http.Request) bool {
+	for _, header := range r.Header.Values("Sec-WebSocket-Extensions") {
+		for _, extension := range strings.Split(header, ",") {
+			name, _, _ := strings.Cut(extension, ";")
+			if strings.EqualFold(strings.TrimSpace(name), "permessage-deflate") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CountingListener wraps a listener so the bytes written to each accepted connection are counted, after WebSocket
+// framing and compression (see Server.BytesSent)
+func CountingListener(listener net.Listener) net.Listener {
+	return countingListener{listener}
+}
+
+type countingListener struct {
+	net.Listener
+}
+
+// Accept implements net.Listener
+func (l countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &countingConn{Conn: conn}, nil
+}
+
+// countingConn counts the bytes written to a network connection
+type countingConn struct {
+	net.Conn
+	written atomic.Int64
+}
+
+// Write implements net.Conn
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.written.Add(int64(n))
+	return n, err
+}
+
+// ReadFrom keeps the wrapped connection's io.ReaderFrom (sendfile for file downloads) available through the wrapper
+func (c *countingConn) ReadFrom(r io.Reader) (int64, error) {
+	n, err := io.Copy(c.Conn, r)
+	c.written.Add(n)
+	return n, err
+}
+
+// wireCounter returns the byte counter of a WebSocket connection accepted through CountingListener, or nil
+func wireCounter(conn *websocket.Conn) *countingConn {
+	counter, _ := conn.NetConn().(*countingConn)
+	return counter
+}
+
+// BytesSent returns the payload bytes of the summary messages written to a connection, and every byte written to its
+// network connection (0 unless the server listens through CountingListener), which also counts framing, the
+// upgrade response, and other messages, and is smaller than the payload when messages are compressed
+func (s *Server) BytesSent(conn *websocket.Conn) (payload int64, wire int64) {
+	s.mu.RLock()
+	if info, ok := s.clients[conn]; ok && info != nil && info.stats != nil {
+		payload = info.stats.BytesSent.Load()
+	}
+	s.mu.RUnlock()
+	if counter := wireCounter(conn); counter != nil {
+		wire = counter.written.Load()
+	}
+	return payload, wire
+}
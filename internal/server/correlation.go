@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/marketdata"
+)
+
+// MaxCorrelationDays is the most log dates a single correlation request may span
+const MaxCorrelationDays = 90
+
+// CorrelationReport describes how well a ticker's net premium flow has predicted its underlying's returns
+type CorrelationReport struct {
+	Ticker        string                      `json:"ticker"`
+	From          string                      `json:"from"`
+	To            string                      `json:"to"`
+	PeriodMinutes int                         `json:"period_minutes"`
+	Horizon       int                         `json:"horizon"` // Periods ahead the forward return is measured over
+	Window        int                         `json:"window"`  // Samples per rolling correlation
+	Days          int                         `json:"days"`    // Dates with at least one sample
+	Samples       int                         `json:"samples"`
+	Correlation   *float64                    `json:"correlation"` // Over all samples (null when undefined)
+	Points        []analysis.CorrelationPoint `json:"points"`
+}
+
+// correlationKey identifies one day's samples for a ticker, bucketing, and horizon
+type correlationKey struct {
+	ticker  string
+	date    string
+	options analysis.AggregateOptions
+	horizon int
+}
+
+// cachedSamples holds a day's samples along with the log file state they were computed from
+type cachedSamples struct {
+	samples []analysis.FlowSample
+	size    int64
+	modTime time.Time
+}
+
+// CorrelationAnalyzer pairs logged premium flow with underlying prices from a spot source
+// Per-day samples are cached and recomputed when the day's log file changes
+type CorrelationAnalyzer struct {
+	logDir string
+	spot   marketdata.SpotSource
+	days   *LRU[correlationKey, cachedSamples]
+	mu     sync.Mutex
+}
+
+// NewCorrelationAnalyzer creates a correlation analyzer caching at most maxEntries days (0 for unbounded)
+func NewCorrelationAnalyzer(logDir string, spot marketdata.SpotSource, maxEntries int) *CorrelationAnalyzer {
+	return &CorrelationAnalyzer{
+		logDir: logDir,
+		spot:   spot,
+		days:   NewLRU[correlationKey, cachedSamples](maxEntries, nil),
+	}
+}
+
+// CacheStats reports the analyzer's cache size and hit rate
+func (a *CorrelationAnalyzer) CacheStats() CacheStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.days.Stats()
+}
+
+// Analyze computes the rolling and overall correlation between each period's net premium and the
+// underlying's return over the next horizon periods, across every logged date in [fromDate, toDate]
+// Empty fromDate or toDate leave that end of the range open
+func (a *CorrelationAnalyzer) Analyze(ctx context.Context, ticker string, fromDate string, toDate string, opts analysis.AggregateOptions, horizon int, window int) (CorrelationReport, error) {
+	report := CorrelationReport{
+		Ticker:        ticker,
+		From:          fromDate,
+		To:            toDate,
+		PeriodMinutes: opts.PeriodMinutes,
+		Horizon:       horizon,
+		Window:        window,
+		Points:        []analysis.CorrelationPoint{},
+	}
+
+	dates, err := analysis.ListDatesForTicker(a.logDir, ticker)
+	if err != nil {
+		return report, err
+	}
+	var selected []string
+	for _, dateStr := range dates {
+		if (fromDate == "" || dateStr >= fromDate) && (toDate == "" || dateStr <= toDate) {
+			selected = append(selected, dateStr)
+		}
+	}
+	if len(selected) > MaxCorrelationDays {
+		return report, fmt.Errorf("range covers %d days, at most %d are allowed", len(selected), MaxCorrelationDays)
+	}
+
+	var samples []analysis.FlowSample
+	for _, dateStr := range selected {
+		day, err := a.daySamples(ctx, ticker, dateStr, opts, horizon)
+		if err != nil {
+			return report, err
+		}
+		if len(day) > 0 {
+			report.Days++
+		}
+		samples = append(samples, day...)
+	}
+
+	report.Samples = len(samples)
+	if correlation, ok := analysis.FlowCorrelation(samples); ok {
+		report.Correlation = &correlation
+	}
+	if points := analysis.RollingCorrelation(samples, window); len(points) > 0 {
+		report.Points = points
+	}
+	return report, nil
+}
+
+// daySamples returns one date's samples, from the cache when the log file is unchanged
+func (a *CorrelationAnalyzer) daySamples(ctx context.Context, ticker string, dateStr string, opts analysis.AggregateOptions, horizon int) ([]analysis.FlowSample, error) {
+	logFile := GetLogFileForTickerAndDate(a.logDir, ticker, dateStr)
+	info, err := os.Stat(logFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", logFile, err)
+	}
+
+	key := correlationKey{ticker: ticker, date: dateStr, options: opts, horizon: horizon}
+	a.mu.Lock()
+	cached, ok := a.days.Get(key)
+	a.mu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.samples, nil
+	}
+
+	summaries, err := AnalyzeTickerAndDateWithOptions(a.logDir, ticker, dateStr, opts)
+	if err != nil {
+		return nil, err
+	}
+	var samples []analysis.FlowSample
+	if len(summaries) > 0 {
+		date, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %s: %w", dateStr, err)
+		}
+		bars, err := a.spot.PriceBars(ctx, ticker, date)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch prices for %s on %s: %w", ticker, dateStr, err)
+		}
+
+		// The last bar in each period is the period's close
+		closes := make(map[int64]float64)
+		for _, bar := range bars {
+			closes[analysis.RoundDownToAnchoredPeriod(bar.Start.UnixMilli(), opts.PeriodMinutes, opts.Anchor)] = bar.Close
+		}
+		samples = analysis.FlowSamples(summaries, closes, horizon)
+	}
+
+	a.mu.Lock()
+	a.days.Add(key, cachedSamples{samples: samples, size: info.Size(), modTime: info.ModTime()})
+	a.mu.Unlock()
+	return samples, nil
+}
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DemoSubjectPrefix prefixes the subject of anonymous demo connections, followed by the client's address
+const DemoSubjectPrefix = "demo:"
+
+// Demo limits applied when a flag leaves them unset
+const (
+	DefaultDemoConnectionsPerIP  = 1
+	DefaultDemoConnectsPerMinute = 5
+	DefaultDemoMaxDuration       = 10 * time.Minute
+)
+
+// ErrDemoRateLimited is returned by Demo.Acquire when a client address is over its demo limits
+var ErrDemoRateLimited = errors.New("too many demo connections, sign in for full access")
+
+// DemoLimits caps what anonymous demo clients may do, per client address
+type DemoLimits struct {
+	ConnectionsPerIP  int           // Demo connections open at once
+	ConnectsPerMinute int           // Demo connections opened in any one minute
+	MaxDuration       time.Duration // Demo connections are closed after this long, as if their session expired
+}
+
+// Demo admits anonymous read-only /analyze connections for a fixed set of tickers, so the app can offer a preview
+// before sign-in. Limits are tracked per client address, since there is no user to attribute connections to
+type Demo struct {
+	tickers map[string]bool
+	limits  DemoLimits
+	proxies TrustedProxies
+
+	mu       sync.Mutex
+	active   map[string]int
+	connects map[string][]time.Time // Connection times within the last minute, oldest first
+}
+
+// NewDemo creates a demo gate for the given tickers (upper-cased); zero limits fall back to the defaults
+func NewDemo(tickers []string, limits DemoLimits) *Demo {
+	if limits.ConnectionsPerIP <= 0 {
+		limits.ConnectionsPerIP = DefaultDemoConnectionsPerIP
+	}
+	if limits.ConnectsPerMinute <= 0 {
+		limits.ConnectsPerMinute = DefaultDemoConnectsPerMinute
+	}
+	if limits.MaxDuration <= 0 {
+		limits.MaxDuration = DefaultDemoMaxDuration
+	}
+
+	set := make(map[string]bool, len(tickers))
+	for _, ticker := range tickers {
+		if ticker = strings.ToUpper(strings.TrimSpace(ticker)); ticker != "" {
+			set[ticker] = true
+		}
+	}
+	return &Demo{
+		tickers:  set,
+		limits:   limits,
+		active:   make(map[string]int),
+		connects: make(map[string][]time.Time),
+	}
+}
+
+// Tickers returns the demo tickers, sorted
+func (d *Demo) Tickers() []string {
+	tickers := make([]string, 0, len(d.tickers))
+	for ticker := range d.tickers {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+	return tickers
+}
+
+// Allows reports whether anonymous clients may stream the ticker
+func (d *Demo) Allows(ticker string) bool {
+	return d.tickers[ticker]
+}
+
+// Acquire admits a demo connection from addr, or returns ErrDemoRateLimited
+// The returned release function must be called once the connection closes
+func (d *Demo) Acquire(addr string) (func(), error) {
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Forget connection times older than a minute
+	recent := d.connects[addr]
+	for len(recent) > 0 && now.Sub(recent[0]) >= time.Minute {
+		recent = recent[1:]
+	}
+	if len(recent) == 0 {
+		delete(d.connects, addr)
+	}
+
+	if d.active[addr] >= d.limits.ConnectionsPerIP || len(recent) >= d.limits.ConnectsPerMinute {
+		if len(recent) > 0 {
+			d.connects[addr] = recent
+		}
+		return nil, ErrDemoRateLimited
+	}
+	d.connects[addr] = append(recent, now)
+	d.active[addr]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			if d.active[addr]--; d.active[addr] <= 0 {
+				delete(d.active, addr)
+			}
+		})
+	}, nil
+}
+
+// Claims returns session claims for an anonymous demo connection from addr, expiring after the demo's MaxDuration
+func (d *Demo) Claims(addr string) *auth.SessionClaims {
+	now := time.Now()
+	return &auth.SessionClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   DemoSubjectPrefix + addr,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(d.limits.MaxDuration)),
+		},
+	}
+}
+
+// IsDemoSubject reports whether a connection's subject belongs to an anonymous demo client
+func IsDemoSubject(subject string) bool {
+	return strings.HasPrefix(subject, DemoSubjectPrefix)
+}
+
+// SetTrustedProxies sets the reverse proxies whose X-Forwarded-For header ClientAddress believes
+func (d *Demo) SetTrustedProxies(proxies TrustedProxies) {
+	d.proxies = proxies
+}
+
+// ClientAddress returns the address demo limits are tracked by for a request, see TrustedProxies.ClientAddress
+func (d *Demo) ClientAddress(r *http.Request) string {
+	return d.proxies.ClientAddress(r)
+}
+
+// TrustedProxies lists the networks of reverse proxies in front of the server
+// X-Forwarded-For is set by clients as easily as by proxies, so it is only believed for hops added by a trusted proxy
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses a comma-separated list of IP addresses and CIDR ranges, e.g. "10.0.0.0/8,192.0.2.1"
+func ParseTrustedProxies(value string) (TrustedProxies, error) {
+	var proxies TrustedProxies
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid proxy address: %s", entry)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy range: %s", entry)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// Trusts reports whether addr belongs to a trusted proxy
+func (p TrustedProxies) Trusts(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientAddress returns the IP address of the request's client, without its port
+// When the connection comes from a trusted proxy, X-Forwarded-For is read from the right, skipping trusted proxies,
+// and the first other address is the client; with no trusted proxies it is always the connection's remote address
+func (p TrustedProxies) ClientAddress(r *http.Request) string {
+	addr, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		addr = r.RemoteAddr
+	}
+	if !p.Trusts(addr) {
+		return addr
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(header, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		if net.ParseIP(hops[i]) == nil {
+			// A malformed hop can't be attributed, so the last trusted address is used
+			return addr
+		}
+		if !p.Trusts(hops[i]) {
+			return hops[i]
+		}
+		addr = hops[i]
+	}
+	return addr
+}
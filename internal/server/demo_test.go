@@ -0,0 +1,64 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 192.0.2.1,,2001:db8::/32")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for addr, want := range map[string]bool{
+		"10.1.2.3":    true,
+		"192.0.2.1":   true,
+		"192.0.2.2":   false,
+		"2001:db8::1": true,
+		"not-an-ip":   false,
+	} {
+		if got := proxies.Trusts(addr); got != want {
+			t.Errorf("Trusts(%s) = %v, want %v", addr, got, want)
+		}
+	}
+
+	for _, value := range []string{"10.0.0.0/33", "proxy.internal"} {
+		if _, err := ParseTrustedProxies(value); err == nil {
+			t.Errorf("ParseTrustedProxies(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestTrustedProxiesClientAddress(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		proxies       TrustedProxies
+		remoteAddr    string
+		forwardedFor  string
+		clientAddress string
+	}{
+		{"no trusted proxies ignores the header", nil, "10.0.0.1:5000", "203.0.113.7", "10.0.0.1"},
+		{"untrusted peer ignores the header", proxies, "198.51.100.4:5000", "203.0.113.7", "198.51.100.4"},
+		{"trusted proxy", proxies, "10.0.0.1:5000", "203.0.113.7", "203.0.113.7"},
+		{"spoofed hops before the client are skipped", proxies, "10.0.0.1:5000", "192.0.2.99, 203.0.113.7, 10.0.0.2", "203.0.113.7"},
+		{"trusted proxy without the header", proxies, "10.0.0.1:5000", "", "10.0.0.1"},
+		{"malformed hop", proxies, "10.0.0.1:5000", "203.0.113.7, garbage", "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/analyze", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if got := tt.proxies.ClientAddress(r); got != tt.clientAddress {
+				t.Errorf("ClientAddress = %s, want %s", got, tt.clientAddress)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// bandwidth is a rate budget shared by the writers drawing on it
+// Each write reserves its bytes after the ones already reserved, so together the writers never exceed the rate
+type bandwidth struct {
+	bytesPerSecond int64
+	mu             sync.Mutex
+	next           time.Time // When the bytes reserved so far have been paid for
+	users          int       // Writers drawing on the budget, so DownloadLimiter can drop idle ones
+}
+
+// reserve reserves n bytes and returns how long to wait before writing them
+func (b *bandwidth) reserve(n int, now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.next.Before(now) {
+		b.next = now
+	}
+	wait := b.next.Sub(now)
+	b.next = b.next.Add(time.Duration(float64(n) / float64(b.bytesPerSecond) * float64(time.Second)))
+	return wait
+}
+
+// ThrottledResponseWriter limits the rate at which a response body is written
+// Writers created by a DownloadLimiter share their subject's budget; NewThrottledResponseWriter gives the
+// response a budget of its own
+type ThrottledResponseWriter struct {
+	http.ResponseWriter
+	budget    *bandwidth
+	chunkSize int
+}
+
+// NewThrottledResponseWriter wraps w so the body is written at no more than bytesPerSecond
+// A non-positive rate returns w unchanged
+func NewThrottledResponseWriter(w http.ResponseWriter, bytesPerSecond int64) http.ResponseWriter {
+	if bytesPerSecond <= 0 {
+		return w
+	}
+	return newThrottledResponseWriter(w, &bandwidth{bytesPerSecond: bytesPerSecond})
+}
+
+func newThrottledResponseWriter(w http.ResponseWriter, budget *bandwidth) *ThrottledResponseWriter {
+	// Write in roughly 100ms slices so throughput stays smooth
+	chunkSize := int(budget.bytesPerSecond / 10)
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+	return &ThrottledResponseWriter{ResponseWriter: w, budget: budget, chunkSize: chunkSize}
+}
+
+// Write writes p in chunks, waiting before each one until it fits within the rate budget
+func (t *ThrottledResponseWriter) Write(p []byte) (int, error) {
+	total := 0
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > t.chunkSize {
+			chunk = p[:t.chunkSize]
+		}
+		if wait := t.budget.reserve(len(chunk), time.Now()); wait > 0 {
+			time.Sleep(wait)
+		}
+
+		n, err := t.ResponseWriter.Write(chunk)
+		total += n
+		if err != nil {
+			return total, err
+		}
+		p = p[n:]
+	}
+	return total, nil
+}
+
+// DownloadLimiter limits the combined bandwidth of each subject's downloads, so parallel downloads share one
+// budget instead of each getting the full rate
+type DownloadLimiter struct {
+	bytesPerSecond int64
+	mu             sync.Mutex
+	budgets        map[string]*bandwidth
+}
+
+// NewDownloadLimiter creates a limiter allowing each subject bytesPerSecond across their downloads
+// A non-positive rate disables throttling
+func NewDownloadLimiter(bytesPerSecond int64) *DownloadLimiter {
+	return &DownloadLimiter{bytesPerSecond: bytesPerSecond, budgets: make(map[string]*bandwidth)}
+}
+
+// Writer wraps w so it draws on subject's budget; call release when the response is written
+func (l *DownloadLimiter) Writer(w http.ResponseWriter, subject string) (http.ResponseWriter, func()) {
+	if l.bytesPerSecond <= 0 {
+		return w, func() {}
+	}
+
+	l.mu.Lock()
+	budget, ok := l.budgets[subject]
+	if !ok {
+		budget = &bandwidth{bytesPerSecond: l.bytesPerSecond}
+		l.budgets[subject] = budget
+	}
+	budget.users++
+	l.mu.Unlock()
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if budget.users--; budget.users == 0 {
+				delete(l.budgets, subject)
+			}
+		})
+	}
+	return newThrottledResponseWriter(w, budget), release
+}
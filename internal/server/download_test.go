@@ -0,0 +1,86 @@
+package server
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBandwidthReserve(t *testing.T) {
+	b := &bandwidth{bytesPerSecond: 1000}
+	now := time.Date(2025, 11, 26, 14, 30, 0, 0, time.UTC)
+
+	if wait := b.reserve(500, now); wait != 0 {
+		t.Errorf("first reservation waits %v, want 0", wait)
+	}
+	if wait := b.reserve(500, now); wait != 500*time.Millisecond {
+		t.Errorf("second reservation waits %v, want 500ms", wait)
+	}
+	// An idle budget doesn't bank unused bandwidth
+	if wait := b.reserve(100, now.Add(5*time.Second)); wait != 0 {
+		t.Errorf("reservation after idling waits %v, want 0", wait)
+	}
+}
+
+func TestDownloadLimiterSharesBudgetPerSubject(t *testing.T) {
+	limiter := NewDownloadLimiter(2000)
+
+	// Two parallel 500-byte downloads by one subject take as long as one 1000-byte download
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		w, release := limiter.Writer(httptest.NewRecorder(), "alice")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer release()
+			if _, err := w.Write(bytes.Repeat([]byte("x"), 500)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	// The last 200-byte chunk can start once 800 bytes are paid for (400ms); separate budgets would finish in 200ms
+	if elapsed := time.Since(start); elapsed < 350*time.Millisecond {
+		t.Errorf("parallel downloads took %v, want at least 350ms at a shared 2000 B/s", elapsed)
+	}
+	if len(limiter.budgets) != 0 {
+		t.Errorf("%d budgets left after every download finished, want 0", len(limiter.budgets))
+	}
+}
+
+func TestDownloadLimiterSeparatesSubjects(t *testing.T) {
+	limiter := NewDownloadLimiter(1000)
+	alice, releaseAlice := limiter.Writer(httptest.NewRecorder(), "alice")
+	alice2, releaseAlice2 := limiter.Writer(httptest.NewRecorder(), "alice")
+	bob, releaseBob := limiter.Writer(httptest.NewRecorder(), "bob")
+	defer releaseBob()
+
+	if alice.(*ThrottledResponseWriter).budget != alice2.(*ThrottledResponseWriter).budget {
+		t.Error("one subject's downloads have separate budgets")
+	}
+	if alice.(*ThrottledResponseWriter).budget == bob.(*ThrottledResponseWriter).budget {
+		t.Error("two subjects share a budget")
+	}
+
+	releaseAlice()
+	releaseAlice() // Releasing twice only counts once
+	if _, ok := limiter.budgets["alice"]; !ok {
+		t.Error("budget dropped while a download is still running")
+	}
+	releaseAlice2()
+	if _, ok := limiter.budgets["alice"]; ok {
+		t.Error("budget kept after the subject's downloads finished")
+	}
+}
+
+func TestDownloadLimiterUnlimited(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	w, release := NewDownloadLimiter(0).Writer(recorder, "alice")
+	defer release()
+	if w != recorder {
+		t.Error("a zero rate wrapped the response writer")
+	}
+}
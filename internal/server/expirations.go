@@ -0,0 +1,126 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+)
+
+// Expiration is an option expiration date and how many of its contracts traded
+type Expiration struct {
+	Date      time.Time
+	Contracts int // Distinct contracts for the expiration in the log the date was found in
+}
+
+// cachedExpirations holds a log file's expirations along with the file state they were computed from
+type cachedExpirations struct {
+	expirations []Expiration
+	size        int64
+	modTime     time.Time
+}
+
+// ExpirationCache lists the expirations traded for a ticker, read from its most recent completed log
+// Results are cached per log file and recomputed when the file changes
+type ExpirationCache struct {
+	logDir string
+	files  *LRU[string, cachedExpirations] // Key: log file path
+	mu     sync.Mutex
+}
+
+// NewExpirationCache creates an expiration cache holding at most maxEntries log files (0 for unbounded)
+func NewExpirationCache(logDir string, maxEntries int) *ExpirationCache {
+	return &ExpirationCache{
+		logDir: logDir,
+		files:  NewLRU[string, cachedExpirations](maxEntries, nil),
+	}
+}
+
+// CacheStats reports the cache's size and hit rate
+func (c *ExpirationCache) CacheStats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.files.Stats()
+}
+
+// Upcoming returns expirations on or after from and no later than until, oldest first
+// It reads the most recent log before today (a complete session) and falls back to today's log;
+// a ticker with no logs has no expirations
+func (c *ExpirationCache) Upcoming(ticker string, today string, from time.Time, until time.Time) ([]Expiration, error) {
+	dates, err := analysis.ListDatesForTicker(c.logDir, ticker)
+	if err != nil {
+		return nil, err
+	}
+	if len(dates) == 0 {
+		return nil, nil
+	}
+
+	dateStr := dates[len(dates)-1]
+	for i := len(dates) - 1; i >= 0; i-- {
+		if dates[i] < today {
+			dateStr = dates[i]
+			break
+		}
+	}
+
+	all, err := c.load(GetLogFileForTickerAndDate(c.logDir, ticker, dateStr))
+	if err != nil {
+		return nil, err
+	}
+
+	var upcoming []Expiration
+	for _, expiration := range all {
+		if !expiration.Date.Before(from) && !expiration.Date.After(until) {
+			upcoming = append(upcoming, expiration)
+		}
+	}
+	return upcoming, nil
+}
+
+// load returns every expiration in a log file, from the cache when the file is unchanged
+func (c *ExpirationCache) load(logFile string) ([]Expiration, error) {
+	info, err := os.Stat(logFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", logFile, err)
+	}
+
+	c.mu.Lock()
+	cached, ok := c.files.Get(logFile)
+	c.mu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.expirations, nil
+	}
+
+	aggregates, err := ReadLogFile(logFile)
+	if err != nil {
+		return nil, err
+	}
+
+	contracts := make(map[time.Time]map[string]bool)
+	for _, agg := range aggregates {
+		date, err := analysis.ParseExpiration(agg.Symbol)
+		if err != nil {
+			continue
+		}
+		if contracts[date] == nil {
+			contracts[date] = make(map[string]bool)
+		}
+		contracts[date][agg.Symbol] = true
+	}
+
+	expirations := make([]Expiration, 0, len(contracts))
+	for date, symbols := range contracts {
+		expirations = append(expirations, Expiration{Date: date, Contracts: len(symbols)})
+	}
+	sort.Slice(expirations, func(i, j int) bool {
+		return expirations[i].Date.Before(expirations[j].Date)
+	})
+
+	c.mu.Lock()
+	c.files.Add(logFile, cachedExpirations{expirations: expirations, size: info.Size(), modTime: info.ModTime()})
+	c.mu.Unlock()
+	return expirations, nil
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+)
+
+// ParseMinPremiumChange parses a client's min_premium_change query parameter (empty means no floor)
+func ParseMinPremiumChange(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	floor, err := strconv.ParseFloat(value, 64)
+	if err != nil || floor < 0 || math.IsInf(floor, 0) || math.IsNaN(floor) {
+		return 0, fmt.Errorf("invalid min_premium_change %q, must be a non-negative number", value)
+	}
+	return floor, nil
+}
+
+// premiumFloor holds back a client's in-progress period updates until total premium has moved by at least min
+// since the last update sent. The latest held-back update is always delivered before the next period's first
+// update, so the client still ends every period with its final totals
+type premiumFloor struct {
+	min float64
+
+	mu          sync.Mutex
+	lastStart   time.Time // Period of the last update sent
+	lastPremium float64   // Total premium of the last update sent
+	sent        bool
+	pending     *analysis.TimePeriodSummary // Latest update held back for the last sent period
+}
+
+// filter returns the updates to queue for a new summary, oldest first
+func (f *premiumFloor) filter(summary analysis.TimePeriodSummary) []analysis.TimePeriodSummary {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.sent && summary.PeriodStart.Equal(f.lastStart) {
+		if math.Abs(summary.TotalPremium-f.lastPremium) < f.min {
+			f.pending = &summary
+			return nil
+		}
+		f.pending = nil
+		f.lastPremium = summary.TotalPremium
+		return []analysis.TimePeriodSummary{summary}
+	}
+
+	// A different period: flush the held-back final state of the previous one first
+	var updates []analysis.TimePeriodSummary
+	if f.pending != nil {
+		updates = append(updates, *f.pending)
+		f.pending = nil
+	}
+	f.sent = true
+	f.lastStart = summary.PeriodStart
+	f.lastPremium = summary.TotalPremium
+	return append(updates, summary)
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// MaxGroupMembers caps how many underlyings one combined series may merge
+const MaxGroupMembers = 20
+
+// TickerGroup is the underlyings behind a ticker parameter: a single ticker, a named group configured with
+// --ticker-groups (e.g. SEMI), or an inline comma-separated list (e.g. NVDA,AMD,AVGO)
+type TickerGroup struct {
+	Name    string   // Stream and response name: the ticker, the group name, or the normalized list
+	Members []string // Underlyings whose log files are merged, in order
+}
+
+// Combined reports whether the group merges several underlyings (or is a named group), rather than being one ticker
+func (g TickerGroup) Combined() bool {
+	return len(g.Members) != 1 || g.Members[0] != g.Name
+}
+
+// Includes reports whether ticker is one of the group's underlyings
+func (g TickerGroup) Includes(ticker string) bool {
+	for _, member := range g.Members {
+		if member == ticker {
+			return true
+		}
+	}
+	return false
+}
+
+// TickerGroups resolves ticker parameters against the named groups configured on the server
+type TickerGroups struct {
+	groups map[string][]string // Key: group name
+}
+
+// ParseTickerGroups parses named groups in the form NAME=TICKER,TICKER;NAME=TICKER,... (empty for none)
+// Names follow the ticker rules and shadow a ticker of the same name
+func ParseTickerGroups(value string) (*TickerGroups, error) {
+	groups := &TickerGroups{groups: make(map[string][]string)}
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, list, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid group %q, expected NAME=TICKER,TICKER", entry)
+		}
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if err := ValidateTicker(name); err != nil {
+			return nil, fmt.Errorf("invalid group name: %w", err)
+		}
+		if _, exists := groups.groups[name]; exists {
+			return nil, fmt.Errorf("group %s is defined more than once", name)
+		}
+		members, err := parseGroupMembers(list)
+		if err != nil {
+			return nil, fmt.Errorf("group %s: %w", name, err)
+		}
+		groups.groups[name] = members
+	}
+	return groups, nil
+}
+
+// Names returns the configured group names, sorted
+func (g *TickerGroups) Names() []string {
+	names := make([]string, 0, len(g.groups))
+	for name := range g.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the underlyings behind a ticker parameter: an inline comma-separated list, a configured group name,
+// or a single ticker
+func (g *TickerGroups) Resolve(value string) (TickerGroup, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if strings.Contains(value, ",") {
+		members, err := parseGroupMembers(value)
+		if err != nil {
+			return TickerGroup{}, err
+		}
+		if len(members) == 1 {
+			return TickerGroup{Name: members[0], Members: members}, nil
+		}
+		return TickerGroup{Name: strings.Join(members, ","), Members: members}, nil
+	}
+	if members, ok := g.groups[value]; ok {
+		return TickerGroup{Name: value, Members: members}, nil
+	}
+	if err := ValidateTicker(value); err != nil {
+		return TickerGroup{}, err
+	}
+	return TickerGroup{Name: value, Members: []string{value}}, nil
+}
+
+// parseGroupMembers parses a comma-separated list of underlyings, upper-casing and de-duplicating it in order
+func parseGroupMembers(value string) ([]string, error) {
+	seen := make(map[string]bool)
+	var members []string
+	for _, ticker := range strings.Split(value, ",") {
+		ticker = strings.ToUpper(strings.TrimSpace(ticker))
+		if ticker == "" || seen[ticker] {
+			continue
+		}
+		if err := ValidateTicker(ticker); err != nil {
+			return nil, err
+		}
+		seen[ticker] = true
+		members = append(members, ticker)
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("ticker parameter is required")
+	}
+	if len(members) > MaxGroupMembers {
+		return nil, fmt.Errorf("too many tickers in group (%d), at most %d", len(members), MaxGroupMembers)
+	}
+	return members, nil
+}
+
+// ResolveGroupDate returns the date used for a group when a request doesn't give one: today if any member has a log
+// file for it, otherwise as ResolveDate
+func ResolveGroupDate(logDir string, group TickerGroup) string {
+	today := market.Today()
+	for _, member := range group.Members {
+		if _, err := os.Stat(GetLogFileForTickerAndDate(logDir, member, today)); err == nil {
+			return today
+		}
+	}
+	return market.DefaultDate()
+}
+
+// AnalyzeGroupAndDate analyzes the merged aggregates of every member's log file for a date as one series, cut off at
+// asOf when it is set
+// Members without a log file are skipped. Strike levels are cleared (see ClearStrikeLevels), since strikes of
+// different underlyings can't be averaged. Combined series are computed from the logs each time rather than served
+// from the history cache
+func AnalyzeGroupAndDate(logDir string, group TickerGroup, dateStr string, opts analysis.AggregateOptions, asOf time.Time) ([]analysis.TimePeriodSummary, error) {
+	var aggregates []analysis.Aggregate
+	for _, member := range group.Members {
+		logFile := GetLogFileForTickerAndDate(logDir, member, dateStr)
+		if _, err := os.Stat(logFile); os.IsNotExist(err) {
+			continue
+		}
+		memberAggregates, err := ReadActiveLogFile(logFile, dateStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read log file for %s: %w", member, err)
+		}
+		aggregates = append(aggregates, memberAggregates...)
+	}
+
+	if !asOf.IsZero() {
+		cutoff := asOf.UnixMilli()
+		filtered := aggregates[:0]
+		for _, agg := range aggregates {
+			if agg.StartTimestamp < cutoff {
+				filtered = append(filtered, agg)
+			}
+		}
+		aggregates = filtered
+	}
+
+	if len(aggregates) == 0 {
+		return []analysis.TimePeriodSummary{}, nil
+	}
+
+	// Interleave the members' aggregates as if they had been logged to one file
+	sort.SliceStable(aggregates, func(i, j int) bool {
+		return aggregates[i].StartTimestamp < aggregates[j].StartTimestamp
+	})
+
+	summaries, err := analysis.AggregatePremiumsWithOptions(aggregates, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate premiums: %w", err)
+	}
+	for i := range summaries {
+		summaries[i].ClearStrikeLevels()
+	}
+	return summaries, nil
+}
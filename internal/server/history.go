@@ -0,0 +1,280 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/market"
+	"github.com/ekinolik/jax-ov/internal/marketdata"
+)
+
+// historyKey identifies a day's history for one set of bucketing options other than the period length
+type historyKey struct {
+	logFile  string
+	anchor   string
+	sessions market.SessionSet
+	maxDTE   analysis.DTELimit
+	maxMoney float64
+}
+
+// cachedHistory holds a day's summaries at every cached resolution along with the file state they were computed from
+type cachedHistory struct {
+	ticker    string
+	date      string
+	opts      analysis.AggregateOptions            // Bucketing other than the period length
+	summaries map[int][]analysis.TimePeriodSummary // Key: period length in minutes
+	size      int64
+	modTime   time.Time
+}
+
+// HistoryCache caches a day's period summaries at several resolutions, computed together in one pass over the log file,
+// so clients can switch resolution without the file being re-read for each one
+// Entries are invalidated when the underlying log file's size or modification time changes, and the least recently
+// used days are evicted once the cache holds its maximum number of entries
+// Cached summaries are shared between callers and must not be modified
+// With a spill directory (see EnableSpill), evicted days are written there as summary sidecars and read back on
+// their next request while the log file is unchanged, instead of re-aggregating it
+// With a spot source (see EnableSpot), days can also be limited to strikes near spot (AggregateOptions.MaxMoneyness)
+type HistoryCache struct {
+	logDir  string
+	periods []int // Resolutions computed for each day, in minutes
+	days    *LRU[historyKey, cachedHistory]
+	mu      sync.Mutex
+
+	spillDir string          // Directory evicted days are spilled to ("" disables spilling)
+	evicted  []cachedHistory // Days evicted under mu, spilled once it is released
+	spilled  int64
+	reloaded int64
+
+	spot marketdata.SpotSource // Underlying prices for the moneyness filter (nil disables it)
+}
+
+// NewHistoryCacheWithLimit creates a history cache for the given resolutions (minutes) holding at most maxEntries days
+// (0 for unbounded)
+func NewHistoryCacheWithLimit(logDir string, periods []int, maxEntries int) *HistoryCache {
+	unique := make([]int, 0, len(periods))
+	seen := make(map[int]bool, len(periods))
+	for _, minutes := range periods {
+		if minutes > 0 && !seen[minutes] {
+			seen[minutes] = true
+			unique = append(unique, minutes)
+		}
+	}
+	sort.Ints(unique)
+
+	c := &HistoryCache{
+		logDir:  logDir,
+		periods: unique,
+	}
+	c.days = NewLRU(maxEntries, func(key historyKey, history cachedHistory) {
+		if c.spillDir != "" {
+			c.evicted = append(c.evicted, history)
+		}
+	})
+	return c
+}
+
+// EnableSpill spills evicted days to dir as summary sidecars (one per resolution) and reloads them on demand
+// Call it before the cache is used
+func (c *HistoryCache) EnableSpill(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create spill directory: %w", err)
+	}
+	c.spillDir = dir
+	return nil
+}
+
+// EnableSpot lets days be limited to strikes near spot, tagging their aggregates with prices from spot
+// Call it before the cache is used
+func (c *HistoryCache) EnableSpot(spot marketdata.SpotSource) {
+	c.spot = spot
+}
+
+// Periods returns the cached resolutions in minutes, shortest first
+func (c *HistoryCache) Periods() []int {
+	return c.periods
+}
+
+// Supports reports whether the cache computes summaries for the given period length
+func (c *HistoryCache) Supports(minutes int) bool {
+	for _, period := range c.periods {
+		if period == minutes {
+			return true
+		}
+	}
+	return false
+}
+
+// CacheStats returns the cache's size and hit, miss, and eviction counters
+func (c *HistoryCache) CacheStats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.days.Stats()
+	stats.Spilled = c.spilled
+	stats.Reloaded = c.reloaded
+	return stats
+}
+
+// Summaries returns the period summaries for a ticker and date, using the cache when the file is unchanged
+// Period lengths the cache doesn't support are analyzed directly, without caching
+// Limiting strikes to near spot (opts.MaxMoneyness) needs a spot source (see EnableSpot)
+func (c *HistoryCache) Summaries(ticker string, dateStr string, opts analysis.AggregateOptions) ([]analysis.TimePeriodSummary, error) {
+	if opts.MaxMoneyness > 0 && c.spot == nil {
+		return nil, fmt.Errorf("moneyness filter requires a spot source")
+	}
+	if !c.Supports(opts.PeriodMinutes) && opts.MaxMoneyness == 0 {
+		return AnalyzeTickerAndDateWithOptions(c.logDir, ticker, dateStr, opts)
+	}
+
+	logFile := GetLogFileForTickerAndDate(c.logDir, ticker, dateStr)
+	info, err := os.Stat(logFile)
+	if os.IsNotExist(err) {
+		// Return empty results if no log file exists
+		return []analysis.TimePeriodSummary{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	if !c.Supports(opts.PeriodMinutes) {
+		aggregates, err := c.readAggregates(ticker, dateStr, logFile, opts)
+		if err != nil {
+			return nil, err
+		}
+		summaries, err := analysis.AggregatePremiumsWithOptions(aggregates, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to aggregate premiums: %w", err)
+		}
+		return summaries, nil
+	}
+
+	key := historyKey{logFile: logFile, anchor: opts.Anchor, sessions: opts.Sessions, maxDTE: opts.MaxDTE, maxMoney: opts.MaxMoneyness}
+	c.mu.Lock()
+	cached, ok := c.days.Get(key)
+	c.mu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.summaries[opts.PeriodMinutes], nil
+	}
+
+	history, ok := c.reload(ticker, dateStr, opts, info)
+	if !ok {
+		aggregates, err := c.readAggregates(ticker, dateStr, logFile, opts)
+		if err != nil {
+			return nil, err
+		}
+		summaries, err := analysis.AggregateMultiPeriod(aggregates, opts, c.periods...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to aggregate premiums: %w", err)
+		}
+		history = cachedHistory{ticker: ticker, date: dateStr, opts: opts, summaries: summaries, size: info.Size(), modTime: info.ModTime()}
+	}
+
+	c.mu.Lock()
+	c.days.Add(key, history)
+	evicted := c.evicted
+	c.evicted = nil
+	c.mu.Unlock()
+
+	// Write spilled days outside the lock so other requests aren't held up by the disk
+	for _, day := range evicted {
+		c.spill(day)
+	}
+
+	return history.summaries[opts.PeriodMinutes], nil
+}
+
+// readAggregates reads a day's log file without expired contracts (see ReadActiveLogFile), tagging aggregates with
+// their distance from spot when opts filters on it
+func (c *HistoryCache) readAggregates(ticker string, dateStr string, logFile string, opts analysis.AggregateOptions) ([]analysis.Aggregate, error) {
+	aggregates, err := ReadActiveLogFile(logFile, dateStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+	if opts.MaxMoneyness > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), spotFetchTimeout)
+		defer cancel()
+		if err := TagMoneynessForDate(ctx, c.spot, ticker, dateStr, aggregates); err != nil {
+			return nil, err
+		}
+	}
+	return aggregates, nil
+}
+
+// spill writes an evicted day's summaries to the spill directory, one sidecar per resolution
+// Days limited to strikes near spot aren't spilled, since their summaries also depend on the spot prices fetched
+func (c *HistoryCache) spill(history cachedHistory) {
+	if history.opts.MaxMoneyness > 0 {
+		return
+	}
+	for _, minutes := range c.periods {
+		summaries := history.summaries[minutes]
+		opts := history.opts
+		opts.PeriodMinutes = minutes
+		symbols, contracts := analysis.ContractIndex(summaries)
+
+		sidecar := analysis.SummarySidecar{
+			Version:       analysis.SummaryVersion,
+			Ticker:        history.ticker,
+			Date:          history.date,
+			PeriodMinutes: minutes,
+			Anchor:        opts.Anchor,
+			Metrics:       analysis.EnabledPeriodMetrics(),
+			SourceSize:    history.size,
+			SourceModTime: history.modTime,
+			GeneratedAt:   time.Now(),
+			Summaries:     summaries,
+			Symbols:       symbols,
+			Contracts:     contracts,
+		}
+		if opts.Sessions != 0 {
+			sidecar.Sessions = opts.Sessions.String()
+		}
+		sidecar.MaxDTE = opts.MaxDTE.String()
+		if err := analysis.WriteSidecar(filepath.Join(c.spillDir, analysis.SidecarFileName(history.ticker, history.date, opts)), sidecar); err != nil {
+			log.Printf("Error spilling history for ticker %s on %s: %v", history.ticker, history.date, err)
+			return
+		}
+	}
+
+	c.mu.Lock()
+	c.spilled++
+	c.mu.Unlock()
+}
+
+// reload reads a day spilled earlier, if every resolution's sidecar is current for the log file's size and modification time
+// Sidecars without contract sets (e.g. written by reprocess), with greeks, or with plugin metrics (whose state
+// can't be restored for live updates) aren't used, so reloaded summaries match freshly computed ones
+func (c *HistoryCache) reload(ticker string, dateStr string, opts analysis.AggregateOptions, info os.FileInfo) (cachedHistory, bool) {
+	if c.spillDir == "" || opts.MaxMoneyness > 0 {
+		return cachedHistory{}, false
+	}
+
+	history := cachedHistory{ticker: ticker, date: dateStr, opts: opts, summaries: make(map[int][]analysis.TimePeriodSummary, len(c.periods)), size: info.Size(), modTime: info.ModTime()}
+	for _, minutes := range c.periods {
+		periodOpts := opts
+		periodOpts.PeriodMinutes = minutes
+		sidecar, err := analysis.ReadSidecar(filepath.Join(c.spillDir, analysis.SidecarFileName(ticker, dateStr, periodOpts)))
+		if err != nil || !sidecar.Current(info.Size(), info.ModTime()) || sidecar.Greeks || len(sidecar.Metrics) > 0 {
+			return cachedHistory{}, false
+		}
+		if sidecar.Summaries == nil {
+			sidecar.Summaries = []analysis.TimePeriodSummary{}
+		}
+		if err := analysis.RestoreContracts(sidecar.Summaries, sidecar.Symbols, sidecar.Contracts); err != nil {
+			return cachedHistory{}, false
+		}
+		history.summaries[minutes] = sidecar.Summaries
+	}
+
+	c.mu.Lock()
+	c.reloaded++
+	c.mu.Unlock()
+	return history, true
+}
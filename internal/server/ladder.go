@@ -0,0 +1,41 @@
+package server
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+)
+
+// StrikeLadderReport holds the per-period strike ladders for a ticker and date
+type StrikeLadderReport struct {
+	Ticker  string                  `json:"ticker"`
+	Date    string                  `json:"date"`
+	Periods []analysis.StrikeLadder `json:"periods"`
+}
+
+// AnalyzeStrikeLadder reports call/put premium and volume at each strike for every period of a ticker and date
+// A missing log file yields an empty report
+func AnalyzeStrikeLadder(logDir string, ticker string, dateStr string, opts analysis.AggregateOptions) (StrikeLadderReport, error) {
+	report := StrikeLadderReport{
+		Ticker:  ticker,
+		Date:    dateStr,
+		Periods: []analysis.StrikeLadder{},
+	}
+
+	logFile := GetLogFileForTickerAndDate(logDir, ticker, dateStr)
+	if _, err := os.Stat(logFile); os.IsNotExist(err) {
+		return report, nil
+	}
+
+	aggregates, err := ReadLogFile(logFile)
+	if err != nil {
+		return report, fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	report.Periods, err = analysis.AggregateByStrike(aggregates, opts)
+	if err != nil {
+		return report, fmt.Errorf("failed to aggregate by strike: %w", err)
+	}
+	return report, nil
+}
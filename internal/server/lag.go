@@ -0,0 +1,136 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+)
+
+// sentHistorySize is the number of recent summary messages kept per connection for resending to a lagging client
+const sentHistorySize = 256
+
+// sentSummary is a summary message written to a connection
+type sentSummary struct {
+	at      time.Time
+	summary analysis.TimePeriodSummary // With its Seq
+}
+
+// lagTracker numbers the summary messages written to one connection and records the client's heartbeats, which
+// carry the last sequence number it received, so the server can tell how far behind the client is
+type lagTracker struct {
+	mu            sync.Mutex
+	seq           int64         // Last sequence number sent
+	acked         int64         // Last sequence number the client reported receiving
+	heartbeats    int64         // Heartbeats received
+	heartbeatAt   time.Time     // When the last heartbeat arrived
+	resentThrough int64         // Messages up to this sequence number have already been resent once
+	resent        int64         // Summaries resent to the client
+	sent          []sentSummary // Most recent messages, oldest first (at most sentHistorySize)
+}
+
+// next numbers a summary about to be written and remembers it for resending
+func (t *lagTracker) next(summary analysis.TimePeriodSummary) analysis.TimePeriodSummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.seq++
+	summary.Seq = t.seq
+	if len(t.sent) == sentHistorySize {
+		copy(t.sent, t.sent[1:])
+		t.sent = t.sent[:sentHistorySize-1]
+	}
+	t.sent = append(t.sent, sentSummary{at: time.Now(), summary: summary})
+	return summary
+}
+
+// heartbeat records the last sequence number the client received and returns the periods to resend: when
+// resendAfter is positive and the oldest message the client hasn't acknowledged was sent at least resendAfter ago,
+// the latest state of every period among the unacknowledged messages, oldest first. Each message is only resent once
+func (t *lagTracker) heartbeat(seq int64, resendAfter time.Duration) []analysis.TimePeriodSummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.heartbeats++
+	t.heartbeatAt = now
+	// Acknowledgements never move backwards, and can't be ahead of what was sent
+	if seq > t.acked {
+		t.acked = min(seq, t.seq)
+	}
+
+	if resendAfter <= 0 {
+		return nil
+	}
+	from := max(t.acked, t.resentThrough)
+	var missed []sentSummary
+	for _, sent := range t.sent {
+		if sent.summary.Seq > from {
+			missed = append(missed, sent)
+		}
+	}
+	if len(missed) == 0 || now.Sub(missed[0].at) < resendAfter {
+		return nil
+	}
+
+	// Summaries are complete period states, so only each ticker's latest state of a period needs resending
+	type periodKey struct {
+		ticker string
+		start  time.Time
+	}
+	latest := make(map[periodKey]int, len(missed))
+	var resend []analysis.TimePeriodSummary
+	for _, sent := range missed {
+		summary := sent.summary
+		summary.Seq = 0
+		key := periodKey{ticker: summary.Ticker, start: summary.PeriodStart}
+		if i, ok := latest[key]; ok {
+			resend[i] = summary
+			continue
+		}
+		latest[key] = len(resend)
+		resend = append(resend, summary)
+	}
+	// The copies are written next and get the following sequence numbers; they aren't resent again either
+	t.resentThrough = t.seq + int64(len(resend))
+	t.resent += int64(len(resend))
+	return resend
+}
+
+// LagSnapshot is a connection's heartbeat state for the admin stats endpoint
+type LagSnapshot struct {
+	LastSeq       int64      `json:"last_seq"`                 // Last summary sequence number sent
+	AckedSeq      int64      `json:"acked_seq"`                // Last sequence number the client reported receiving
+	Lag           int64      `json:"lag"`                      // Messages sent but not yet acknowledged (0 without heartbeats)
+	LagSeconds    float64    `json:"lag_seconds"`              // Age of the oldest unacknowledged message (0 without heartbeats)
+	Heartbeats    int64      `json:"heartbeats"`               // Heartbeats received
+	LastHeartbeat *time.Time `json:"last_heartbeat,omitempty"` // When the last heartbeat arrived
+	Resent        int64      `json:"resent"`                   // Summaries resent because the client fell behind
+}
+
+// snapshot returns the connection's heartbeat state
+// Clients that don't send heartbeats report no lag, since nothing is known about what they received
+func (t *lagTracker) snapshot(now time.Time) LagSnapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshot := LagSnapshot{
+		LastSeq:    t.seq,
+		AckedSeq:   t.acked,
+		Heartbeats: t.heartbeats,
+		Resent:     t.resent,
+	}
+	if t.heartbeats == 0 {
+		return snapshot
+	}
+	heartbeatAt := t.heartbeatAt
+	snapshot.LastHeartbeat = &heartbeatAt
+	snapshot.Lag = t.seq - t.acked
+	for _, sent := range t.sent {
+		if sent.summary.Seq > t.acked {
+			snapshot.LagSeconds = now.Sub(sent.at).Seconds()
+			break
+		}
+	}
+	return snapshot
+}
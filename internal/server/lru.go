@@ -0,0 +1,103 @@
+package server
+
+import "container/list"
+
+// CacheStats reports the size and effectiveness of an in-memory cache
+type CacheStats struct {
+	Entries    int   `json:"entries"`
+	MaxEntries int   `json:"max_entries"` // 0 means unbounded
+	Hits       int64 `json:"hits"`
+	Misses     int64 `json:"misses"`
+	Evictions  int64 `json:"evictions"`
+	Spilled    int64 `json:"spilled,omitempty"`  // Evicted entries written to disk instead of dropped
+	Reloaded   int64 `json:"reloaded,omitempty"` // Misses served from disk instead of recomputed
+}
+
+// lruEntry is a key/value pair stored in an LRU's list
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// LRU is a map bounded to maxEntries that evicts the least recently used entry when full
+// It is not safe for concurrent use; callers guard it with their own mutex
+type LRU[K comparable, V any] struct {
+	maxEntries int
+	order      *list.List // Front is most recently used
+	items      map[K]*list.Element
+	onEvict    func(key K, value V)
+	stats      CacheStats
+}
+
+// NewLRU creates an LRU holding at most maxEntries (0 for unbounded)
+// onEvict, if non-nil, is called for every entry evicted to make room (not for Remove)
+func NewLRU[K comparable, V any](maxEntries int, onEvict func(key K, value V)) *LRU[K, V] {
+	return &LRU[K, V]{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		items:      make(map[K]*list.Element),
+		onEvict:    onEvict,
+	}
+}
+
+// Get returns the value for key and marks it most recently used
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	if elem, ok := c.items[key]; ok {
+		c.order.MoveToFront(elem)
+		c.stats.Hits++
+		return elem.Value.(*lruEntry[K, V]).value, true
+	}
+	c.stats.Misses++
+	var zero V
+	return zero, false
+}
+
+// Add inserts or replaces the value for key, evicting the least recently used entries over the limit
+func (c *LRU[K, V]) Add(key K, value V) {
+	if elem, ok := c.items[key]; ok {
+		c.order.MoveToFront(elem)
+		elem.Value.(*lruEntry[K, V]).value = value
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value})
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*lruEntry[K, V])
+		c.order.Remove(oldest)
+		delete(c.items, entry.key)
+		c.stats.Evictions++
+		if c.onEvict != nil {
+			c.onEvict(entry.key, entry.value)
+		}
+	}
+}
+
+// Remove deletes key without counting an eviction
+func (c *LRU[K, V]) Remove(key K) {
+	if elem, ok := c.items[key]; ok {
+		c.order.Remove(elem)
+		delete(c.items, key)
+	}
+}
+
+// Each calls fn for every entry, most recently used first; fn must not modify the LRU
+func (c *LRU[K, V]) Each(fn func(key K, value V)) {
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*lruEntry[K, V])
+		fn(entry.key, entry.value)
+	}
+}
+
+// Len returns the number of entries
+func (c *LRU[K, V]) Len() int {
+	return c.order.Len()
+}
+
+// Stats returns the cache's size and hit, miss, and eviction counters
+func (c *LRU[K, V]) Stats() CacheStats {
+	stats := c.stats
+	stats.Entries = c.order.Len()
+	stats.MaxEntries = c.maxEntries
+	return stats
+}
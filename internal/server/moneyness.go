@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/marketdata"
+)
+
+// spotFetchTimeout bounds fetching a day's underlying prices when no request context is available
+const spotFetchTimeout = 30 * time.Second
+
+// TagMoneynessForDate tags a day's aggregates with their strike's distance from spot, using the underlying's
+// one-minute closes from spot (see analysis.TagMoneyness)
+func TagMoneynessForDate(ctx context.Context, spot marketdata.SpotSource, ticker string, dateStr string, aggregates []analysis.Aggregate) error {
+	if len(aggregates) == 0 {
+		return nil
+	}
+	date, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return fmt.Errorf("invalid date %q: %w", dateStr, err)
+	}
+	bars, err := spot.PriceBars(ctx, ticker, date)
+	if err != nil {
+		return fmt.Errorf("failed to fetch prices for %s on %s: %w", ticker, dateStr, err)
+	}
+	closes := make(map[int64]float64, len(bars))
+	for _, bar := range bars {
+		closes[bar.Start.UnixMilli()] = bar.Close
+	}
+	analysis.TagMoneyness(aggregates, closes)
+	return nil
+}
+
+// AnalyzeBandForDate aggregates the day's aggregates whose strike was within band of the underlying's price at the
+// time of each trade, using the one-minute closes from spot
+func AnalyzeBandForDate(ctx context.Context, logDir string, spot marketdata.SpotSource, ticker string, dateStr string, band analysis.MoneynessBand, opts analysis.AggregateOptions) ([]analysis.TimePeriodSummary, error) {
+	logFile := GetLogFileForTickerAndDate(logDir, ticker, dateStr)
+	if _, err := os.Stat(logFile); os.IsNotExist(err) {
+		return []analysis.TimePeriodSummary{}, nil
+	}
+	aggregates, err := ReadLogFile(logFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+	if err := TagMoneynessForDate(ctx, spot, ticker, dateStr, aggregates); err != nil {
+		return nil, err
+	}
+
+	inBand := aggregates[:0]
+	for _, agg := range aggregates {
+		if band.Matches(agg) {
+			inBand = append(inBand, agg)
+		}
+	}
+	if len(inBand) == 0 {
+		return []analysis.TimePeriodSummary{}, nil
+	}
+	summaries, err := analysis.AggregatePremiumsWithOptions(inBand, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate premiums: %w", err)
+	}
+	return summaries, nil
+}
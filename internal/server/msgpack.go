@@ -0,0 +1,336 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MessagePack encoding for SubprotocolV2MsgPack connections
+// Messages are transcoded from their JSON encoding, so they have the same fields and names as on SubprotocolV2JSON
+// (the json tags are the schema) and new fields need no separate encoder. Integers and floats are sent as binary
+// numbers, floats as float32 when that is exact; timestamps stay RFC 3339 strings
+
+// jsonToMsgPack transcodes a JSON document to MessagePack, keeping object keys in order
+func jsonToMsgPack(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	out := make([]byte, 0, len(data))
+	out, err := appendJSONValue(out, decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("msgpack: trailing data after JSON value")
+	}
+	return out, nil
+}
+
+// appendJSONValue appends the next JSON value read from decoder as MessagePack
+func appendJSONValue(out []byte, decoder *json.Decoder) ([]byte, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch v := token.(type) {
+	case nil:
+		return append(out, 0xc0), nil
+	case bool:
+		if v {
+			return append(out, 0xc3), nil
+		}
+		return append(out, 0xc2), nil
+	case string:
+		return appendMsgPackString(out, v), nil
+	case json.Number:
+		return appendMsgPackNumber(out, v)
+	case json.Delim:
+		// Containers are buffered because MessagePack puts the element count before the elements
+		var items []byte
+		count := 0
+		for decoder.More() {
+			if v == '{' {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				items = appendMsgPackString(items, key.(string))
+			}
+			if items, err = appendJSONValue(items, decoder); err != nil {
+				return nil, err
+			}
+			count++
+		}
+		if _, err := decoder.Token(); err != nil { // Closing delimiter
+			return nil, err
+		}
+		if v == '{' {
+			out = appendMsgPackHeader(out, count, 0x80, 0xde, 0xdf)
+		} else {
+			out = appendMsgPackHeader(out, count, 0x90, 0xdc, 0xdd)
+		}
+		return append(out, items...), nil
+	}
+	return nil, fmt.Errorf("msgpack: unexpected JSON token %v", token)
+}
+
+// appendMsgPackHeader appends a map or array header: fixed (up to 15 elements), 16-bit, or 32-bit count
+func appendMsgPackHeader(out []byte, count int, fixed, code16, code32 byte) []byte {
+	switch {
+	case count < 16:
+		return append(out, fixed|byte(count))
+	case count <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(out, code16), uint16(count))
+	default:
+		return binary.BigEndian.AppendUint32(append(out, code32), uint32(count))
+	}
+}
+
+// appendMsgPackString appends a UTF-8 string in its shortest form
+func appendMsgPackString(out []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		out = append(out, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		out = append(out, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		out = binary.BigEndian.AppendUint16(append(out, 0xda), uint16(n))
+	default:
+		out = binary.BigEndian.AppendUint32(append(out, 0xdb), uint32(n))
+	}
+	return append(out, s...)
+}
+
+// appendMsgPackNumber appends a JSON number as the smallest MessagePack integer that holds it, or as a float
+func appendMsgPackNumber(out []byte, n json.Number) ([]byte, error) {
+	if !strings.ContainsAny(string(n), ".eE") {
+		if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+			return appendMsgPackInt(out, i), nil
+		}
+		if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+			return binary.BigEndian.AppendUint64(append(out, 0xcf), u), nil
+		}
+	}
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil {
+		return nil, fmt.Errorf("msgpack: invalid number %q", n)
+	}
+	if float64(float32(f)) == f {
+		return binary.BigEndian.AppendUint32(append(out, 0xca), math.Float32bits(float32(f))), nil
+	}
+	return binary.BigEndian.AppendUint64(append(out, 0xcb), math.Float64bits(f)), nil
+}
+
+// appendMsgPackInt appends a signed integer in its shortest form
+func appendMsgPackInt(out []byte, i int64) []byte {
+	switch {
+	case i >= 0 && i <= math.MaxInt8:
+		return append(out, byte(i)) // Positive fixint
+	case i < 0 && i >= -32:
+		return append(out, byte(i)) // Negative fixint
+	case i >= 0 && i <= math.MaxUint8:
+		return append(out, 0xcc, byte(i))
+	case i >= 0 && i <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(out, 0xcd), uint16(i))
+	case i >= 0 && i <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(out, 0xce), uint32(i))
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		return append(out, 0xd0, byte(i))
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		return binary.BigEndian.AppendUint16(append(out, 0xd1), uint16(i))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		return binary.BigEndian.AppendUint32(append(out, 0xd2), uint32(i))
+	default:
+		return binary.BigEndian.AppendUint64(append(out, 0xd3), uint64(i))
+	}
+}
+
+// msgPackToJSON transcodes a MessagePack document from a client to JSON, so client messages decode the same way on
+// every subprotocol. Map keys must be strings; binary and extension types are rejected
+func msgPackToJSON(data []byte) ([]byte, error) {
+	reader := &msgPackReader{data: data}
+	value, err := reader.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if reader.pos != len(data) {
+		return nil, errors.New("msgpack: trailing data after value")
+	}
+	return json.Marshal(value)
+}
+
+// maxMsgPackDepth bounds nesting in client messages, which are flat
+const maxMsgPackDepth = 32
+
+// msgPackReader decodes MessagePack values into the types encoding/json produces
+type msgPackReader struct {
+	data []byte
+	pos  int
+}
+
+// next returns the next n bytes
+func (r *msgPackReader) next(n int) ([]byte, error) {
+	if n < 0 || len(r.data)-r.pos < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// length reads a big-endian length or count of size bytes
+func (r *msgPackReader) length(size int) (int, error) {
+	b, err := r.next(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return int(b[0]), nil
+	case 2:
+		return int(binary.BigEndian.Uint16(b)), nil
+	default:
+		return int(binary.BigEndian.Uint32(b)), nil
+	}
+}
+
+// value decodes the next value
+func (r *msgPackReader) value(depth int) (interface{}, error) {
+	if depth > maxMsgPackDepth {
+		return nil, errors.New("msgpack: nesting too deep")
+	}
+	b, err := r.next(1)
+	if err != nil {
+		return nil, err
+	}
+	code := b[0]
+	switch {
+	case code <= 0x7f:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil
+	case code >= 0x80 && code <= 0x8f:
+		return r.mapValue(int(code&0x0f), depth)
+	case code >= 0x90 && code <= 0x9f:
+		return r.arrayValue(int(code&0x0f), depth)
+	case code >= 0xa0 && code <= 0xbf:
+		return r.stringValue(int(code & 0x1f))
+	}
+
+	switch code {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xca:
+		b, err := r.next(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+	case 0xcb:
+		b, err := r.next(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	case 0xcc, 0xcd, 0xce:
+		n, err := r.length(1 << (code - 0xcc))
+		return int64(n), err
+	case 0xcf:
+		b, err := r.next(8)
+		if err != nil {
+			return nil, err
+		}
+		return binary.BigEndian.Uint64(b), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		b, err := r.next(1 << (code - 0xd0))
+		if err != nil {
+			return nil, err
+		}
+		switch code {
+		case 0xd0:
+			return int64(int8(b[0])), nil
+		case 0xd1:
+			return int64(int16(binary.BigEndian.Uint16(b))), nil
+		case 0xd2:
+			return int64(int32(binary.BigEndian.Uint32(b))), nil
+		default:
+			return int64(binary.BigEndian.Uint64(b)), nil
+		}
+	case 0xd9, 0xda, 0xdb:
+		n, err := r.length(1 << (code - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return r.stringValue(n)
+	case 0xdc, 0xdd:
+		n, err := r.length(2 << (code - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return r.arrayValue(n, depth)
+	case 0xde, 0xdf:
+		n, err := r.length(2 << (code - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return r.mapValue(n, depth)
+	}
+	return nil, fmt.Errorf("msgpack: unsupported type 0x%02x", code)
+}
+
+// stringValue decodes a string of n bytes
+func (r *msgPackReader) stringValue(n int) (interface{}, error) {
+	b, err := r.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// arrayValue decodes an array of n elements
+func (r *msgPackReader) arrayValue(n int, depth int) (interface{}, error) {
+	if n > len(r.data)-r.pos { // Every element takes at least a byte
+		return nil, io.ErrUnexpectedEOF
+	}
+	values := make([]interface{}, n)
+	for i := range values {
+		value, err := r.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// mapValue decodes a map of n string-keyed entries
+func (r *msgPackReader) mapValue(n int, depth int) (interface{}, error) {
+	if n > len(r.data)-r.pos {
+		return nil, io.ErrUnexpectedEOF
+	}
+	values := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		key, err := r.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		name, ok := key.(string)
+		if !ok {
+			return nil, errors.New("msgpack: map keys must be strings")
+		}
+		if values[name], err = r.value(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,24 @@
+package server
+
+import (
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/marketdata"
+)
+
+// ReadPositionsForGroupAndDate builds a position tracker from every aggregate logged for a date by the group's
+// members, bucketed into opts' periods
+// Aggregates excluded by opts (e.g. session filter) are skipped, and members without a log file contribute nothing
+// spot supplies underlying prices when opts limits strikes to near spot (MaxMoneyness), and may be nil otherwise
+func ReadPositionsForGroupAndDate(logDir string, group TickerGroup, dateStr string, opts analysis.AggregateOptions, spot marketdata.SpotSource) (*analysis.PositionTracker, error) {
+	positions := analysis.NewPositionTracker()
+	for _, member := range group.Members {
+		aggregates, err := readIncludedAggregates(logDir, member, dateStr, opts, spot)
+		if err != nil {
+			return nil, err
+		}
+		for _, agg := range aggregates {
+			positions.Add(analysis.RoundDownToAnchoredPeriod(agg.StartTimestamp, opts.PeriodMinutes, opts.Anchor), agg)
+		}
+	}
+	return positions, nil
+}
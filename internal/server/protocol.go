@@ -0,0 +1,272 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket subprotocols negotiated via Sec-WebSocket-Protocol during the /analyze upgrade
+// Clients that don't request a subprotocol are treated as SubprotocolV1JSON for compatibility
+const (
+	SubprotocolV1JSON    = "jaxov.v1.json"    // Bare summary objects; other messages carry a "type" field
+	SubprotocolV2JSON    = "jaxov.v2.json"    // Every message wrapped in an Envelope
+	SubprotocolV2MsgPack = "jaxov.v2.msgpack" // SubprotocolV2JSON's envelopes encoded as MessagePack, in binary frames
+)
+
+// SupportedSubprotocols lists subprotocols in server preference order
+// MessagePack comes first, so clients offering it alongside JSON get the smaller encoding
+var SupportedSubprotocols = []string{SubprotocolV2MsgPack, SubprotocolV2JSON, SubprotocolV1JSON}
+
+// EnvelopeVersion is the schema version carried in every Envelope
+const EnvelopeVersion = 2
+
+// Envelope wraps every message sent on a SubprotocolV2JSON connection, so clients can tell message kinds apart
+// (including summaries, which have no type of their own) and skip types they don't know
+type Envelope struct {
+	Type    string      `json:"type"`             // One of the server -> client MessageType* values
+	Version int         `json:"version"`          // EnvelopeVersion
+	Ticker  string      `json:"ticker,omitempty"` // Stream the message belongs to, when it belongs to one
+	Payload interface{} `json:"payload"`          // The message as SubprotocolV1JSON clients receive it
+}
+
+// Enveloped reports whether a connection's messages are wrapped in an Envelope
+// The subprotocol is known from the upgrade, so this also holds for messages sent before the client is registered
+func Enveloped(conn *websocket.Conn) bool {
+	return conn.Subprotocol() == SubprotocolV2JSON || conn.Subprotocol() == SubprotocolV2MsgPack
+}
+
+// wrap returns msg in an Envelope for enveloped connections, and msg itself otherwise
+func wrap(conn *websocket.Conn, msgType string, ticker string, msg interface{}) interface{} {
+	if !Enveloped(conn) {
+		return msg
+	}
+	return Envelope{Type: msgType, Version: EnvelopeVersion, Ticker: ticker, Payload: msg}
+}
+
+// encodeMessage encodes msg for a connection's subprotocol, wrapped in an Envelope where needed, and returns the
+// WebSocket frame type to send it in: text for JSON, binary for MessagePack
+func encodeMessage(conn *websocket.Conn, msgType string, ticker string, msg interface{}) (int, []byte, error) {
+	data, err := json.Marshal(wrap(conn, msgType, ticker, msg))
+	if err != nil || conn.Subprotocol() != SubprotocolV2MsgPack {
+		return websocket.TextMessage, data, err
+	}
+	data, err = jsonToMsgPack(data)
+	return websocket.BinaryMessage, data, err
+}
+
+// writeMessage encodes msg for a connection's subprotocol and writes it
+func writeMessage(conn *websocket.Conn, msgType string, ticker string, msg interface{}) error {
+	frame, data, err := encodeMessage(conn, msgType, ticker, msg)
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(frame, data)
+}
+
+// DecodeClientMessage decodes a message from a client: JSON in text frames, and MessagePack in binary frames on
+// SubprotocolV2MsgPack connections (clients on that subprotocol may send either)
+func DecodeClientMessage(conn *websocket.Conn, frame int, data []byte) (ClientMessage, error) {
+	var msg ClientMessage
+	if frame == websocket.BinaryMessage {
+		if conn.Subprotocol() != SubprotocolV2MsgPack {
+			return msg, errors.New("binary messages require the " + SubprotocolV2MsgPack + " subprotocol")
+		}
+		var err error
+		if data, err = msgPackToJSON(data); err != nil {
+			return msg, err
+		}
+	}
+	err := json.Unmarshal(data, &msg)
+	return msg, err
+}
+
+// Application close codes (RFC 6455 reserves 4000-4999 for private use)
+// Server shutdown uses the standard websocket.CloseGoingAway (1001)
+const (
+	CloseInvalidRequest      = 4000 // Missing or malformed query parameters (ticker, date, anchor, session)
+	CloseAuthExpired         = 4001 // Session token expired while the stream was open
+	CloseUnsupportedProtocol = 4002 // Client requested only subprotocols the server doesn't speak
+	CloseReplaced            = 4003 // A newer connection from the same user for the same ticker took this one's place
+	CloseNoData              = 4004 // No data exists for the requested ticker and date
+	CloseDuplicateConnection = 4005 // The user already has the maximum connections for this ticker
+	CloseAuthRequired        = 4006 // An anonymous demo client requested a ticker outside the demo set
+)
+
+// Error codes carried in ErrorMessage frames
+const (
+	ErrorInvalidTicker       = "invalid_ticker"
+	ErrorInvalidDate         = "invalid_date"
+	ErrorInvalidParameter    = "invalid_parameter"
+	ErrorNoData              = "no_data"
+	ErrorAuthExpired         = "auth_expired"
+	ErrorInvalidToken        = "invalid_token"
+	ErrorUnsupportedProtocol = "unsupported_protocol"
+	ErrorServerShutdown      = "server_shutdown"
+	ErrorConnectionReplaced  = "connection_replaced"
+	ErrorDuplicateConnection = "duplicate_connection"
+	ErrorAuthRequired        = "auth_required"
+)
+
+// Message types carried in the "type" field of non-summary frames
+// Summaries only have a type inside an Envelope: MessageTypeHistory, MessageTypeUpdate, or MessageTypeReplay
+const (
+	MessageTypeHistory       = "history"        // Server -> client: stored period (history, a subscription's history, or a replay seek)
+	MessageTypeUpdate        = "update"         // Server -> client: live update to a period, or a resend of one (see Heartbeat)
+	MessageTypeReplay        = "replay"         // Server -> client: period played back by a replay
+	MessageTypeError         = "error"          // Server -> client: ErrorMessage
+	MessageTypeTokenExpiring = "token_expiring" // Server -> client: TokenExpiringMessage
+	MessageTypeReplayState   = "replay_state"   // Server -> client: ReplayStateMessage
+	MessageTypeBackfill      = "backfill"       // Server -> client: BackfillMessage
+	MessageTypeDate          = "date"           // Server -> client: DateMessage
+	MessageTypeSubscribed    = "subscribed"     // Server -> client: SubscriptionMessage, followed by the stream's history
+	MessageTypeUnsubscribed  = "unsubscribed"   // Server -> client: SubscriptionMessage
+	MessageTypeAuth          = "auth"           // Client -> server: refreshed session token
+	MessageTypePlay          = "play"           // Client -> server: resume a replay, optionally at a new speed
+	MessageTypePause         = "pause"          // Client -> server: pause a replay
+	MessageTypeSeek          = "seek"           // Client -> server: move a replay to a time
+	MessageTypeHeartbeat     = "heartbeat"      // Client -> server: last summary sequence number received
+	MessageTypeSubscribe     = "subscribe"      // Client -> server: follow another ticker's live stream
+	MessageTypeUnsubscribe   = "unsubscribe"    // Client -> server: stop following a ticker
+	MessageTypeChangePeriod  = "change_period"  // Client -> server: switch a followed ticker to another period length
+	MessageTypeResume        = "resume"         // Client -> server: resend a followed ticker's periods after the last one received
+)
+
+// ClientMessage is a control message sent by a client over the WebSocket
+type ClientMessage struct {
+	Type  string    `json:"type"`
+	Token string    `json:"token,omitempty"` // Session token for MessageTypeAuth
+	Speed float64   `json:"speed,omitempty"` // Replay speed for MessageTypePlay (multiple of real time)
+	Time  time.Time `json:"time,omitempty"`  // Target period start for MessageTypeSeek
+	Seq   int64     `json:"seq,omitempty"`   // Last summary sequence number received, for MessageTypeHeartbeat
+
+	// Stream to change for MessageTypeSubscribe, MessageTypeUnsubscribe, and MessageTypeChangePeriod: a ticker, a
+	// configured group, or a comma-separated list, and the period length in minutes
+	Ticker string `json:"ticker,omitempty"`
+	Period int    `json:"period,omitempty"`
+
+	// End of the last complete period the client has, for MessageTypeResume (the zero time resends every period)
+	LastPeriodEnd time.Time `json:"last_period_end,omitempty"`
+}
+
+// TokenExpiringMessage warns a client that its session token is about to expire
+// Clients should obtain a new token and send it in a MessageTypeAuth message to keep the stream open
+type TokenExpiringMessage struct {
+	Type      string    `json:"type"`       // Always "token_expiring"
+	ExpiresAt time.Time `json:"expires_at"` // When the connection will be closed with CloseAuthExpired
+}
+
+// DateMessage tells a client which date its stream covers, sent before the history
+// Without a date parameter it is today, or the most recent trading session on weekends and exchange holidays
+type DateMessage struct {
+	Type          string `json:"type"`                     // Always "date"
+	RequestedDate string `json:"requested_date,omitempty"` // The date parameter, if one was given
+	ResolvedDate  string `json:"resolved_date"`            // YYYY-MM-DD
+}
+
+// ResolvedDateHeader carries the date an HTTP response covers, for endpoints whose body has no date field
+const ResolvedDateHeader = "X-Resolved-Date"
+
+// ErrorMessage is a structured error frame sent to a client before its connection is closed
+// Clients distinguish it from summary messages by the "type" field
+type ErrorMessage struct {
+	Type    string `json:"type"`    // Always "error"
+	Code    string `json:"code"`    // One of the Error* codes
+	Message string `json:"message"` // Human-readable detail
+}
+
+// SendError writes a structured error frame to a client
+func SendError(conn *websocket.Conn, code string, message string) error {
+	conn.SetWriteDeadline(time.Now().Add(time.Second))
+	defer conn.SetWriteDeadline(time.Time{})
+	return writeMessage(conn, MessageTypeError, "", ErrorMessage{Type: MessageTypeError, Code: code, Message: message})
+}
+
+// SendTokenExpiring writes a token_expiring warning to a client
+func SendTokenExpiring(conn *websocket.Conn, expiresAt time.Time) error {
+	conn.SetWriteDeadline(time.Now().Add(time.Second))
+	defer conn.SetWriteDeadline(time.Time{})
+	return writeMessage(conn, MessageTypeTokenExpiring, "", TokenExpiringMessage{Type: MessageTypeTokenExpiring, ExpiresAt: expiresAt})
+}
+
+// SendDate writes a date frame to a client
+func SendDate(conn *websocket.Conn, requestedDate string, resolvedDate string) error {
+	conn.SetWriteDeadline(time.Now().Add(time.Second))
+	defer conn.SetWriteDeadline(time.Time{})
+	return writeMessage(conn, MessageTypeDate, "", DateMessage{Type: MessageTypeDate, RequestedDate: requestedDate, ResolvedDate: resolvedDate})
+}
+
+// CloseWithError sends a structured error frame followed by a close frame, then closes the connection
+func CloseWithError(conn *websocket.Conn, closeCode int, errorCode string, message string) {
+	if err := SendError(conn, errorCode, message); err != nil {
+		log.Printf("Error sending error frame: %v", err)
+	}
+	CloseWithCode(conn, closeCode, errorCode)
+}
+
+// NewUpgrader creates a WebSocket upgrader that negotiates SupportedSubprotocols
+// allowedOrigins restricts browser origins (host or scheme://host); empty allows all origins
+// Requests without an Origin header (native clients) are always allowed
+// With compression, permessage-deflate is negotiated with clients that offer it (see CompressionRequested)
+func NewUpgrader(allowedOrigins []string, compression bool) *websocket.Upgrader {
+	allowed := make(map[string]bool)
+	for _, origin := range allowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		if origin != "" {
+			allowed[origin] = true
+		}
+	}
+
+	return &websocket.Upgrader{
+		Subprotocols:      SupportedSubprotocols,
+		EnableCompression: compression,
+		CheckOrigin: func(r *http.Request) bool {
+			if len(allowed) == 0 {
+				return true // Allow all origins
+			}
+
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				return true
+			}
+
+			u, err := url.Parse(origin)
+			if err != nil {
+				return false
+			}
+			if allowed[strings.ToLower(u.Host)] || allowed[strings.ToLower(u.Scheme+"://"+u.Host)] {
+				return true
+			}
+
+			log.Printf("Rejected WebSocket origin: %s", origin)
+			return false
+		},
+	}
+}
+
+// NegotiatedProtocol returns the subprotocol in use for a connection and whether it is supported
+// Clients that requested subprotocols but matched none of ours are unsupported
+func NegotiatedProtocol(conn *websocket.Conn, r *http.Request) (string, bool) {
+	if protocol := conn.Subprotocol(); protocol != "" {
+		return protocol, true
+	}
+	if len(websocket.Subprotocols(r)) > 0 {
+		return "", false
+	}
+	return SubprotocolV1JSON, true
+}
+
+// CloseWithCode sends a close frame with an application close code and reason, then closes the connection
+func CloseWithCode(conn *websocket.Conn, code int, reason string) {
+	message := websocket.FormatCloseMessage(code, reason)
+	if err := conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second)); err != nil {
+		log.Printf("Error sending close frame: %v", err)
+	}
+	conn.Close()
+}
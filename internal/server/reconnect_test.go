@@ -0,0 +1,543 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	jaxws "github.com/ekinolik/jax-ov/internal/websocket"
+	"github.com/gorilla/websocket"
+)
+
+// testPeriodMinutes is the period length of the streams in these tests
+const testPeriodMinutes = 5
+
+// testDayStart is the first period's start
+var testDayStart = time.Date(2025, 11, 26, 14, 30, 0, 0, time.UTC)
+
+// testPeriod returns the i-th complete period of the test stream
+func testPeriod(i int) analysis.TimePeriodSummary {
+	start := testDayStart.Add(time.Duration(i*testPeriodMinutes) * time.Minute).UnixMilli()
+	summary := analysis.NewPeriodSummary(start, start+testPeriodMinutes*60*1000)
+	summary.CallPremium = float64(1000 * (i + 1))
+	summary.TotalPremium = summary.CallPremium
+	return *summary
+}
+
+// testFeed is the day's periods so far, standing in for the log file and the live stream built from it
+type testFeed struct {
+	mu      sync.Mutex
+	periods []analysis.TimePeriodSummary
+}
+
+// snapshot returns the periods published so far
+func (f *testFeed) snapshot() []analysis.TimePeriodSummary {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]analysis.TimePeriodSummary(nil), f.periods...)
+}
+
+// add adds a period to the feed without sending it, as a period logged before any client connected
+func (f *testFeed) add(summary analysis.TimePeriodSummary) {
+	f.mu.Lock()
+	f.periods = append(f.periods, summary)
+	f.mu.Unlock()
+}
+
+// publish adds a period to the feed and sends it to live clients, as the server does when a period completes
+func (f *testFeed) publish(s *Server, summary analysis.TimePeriodSummary) {
+	f.add(summary)
+	s.SendUpdateForTicker("AAPL", summary)
+}
+
+// newTestServer starts an /analyze endpoint on an httptest server, following serverapp's connection lifecycle:
+// register, send the history after last_period_end, then hand every write to one writer goroutine
+func newTestServer(t *testing.T, feed *testFeed) (*Server, *httptest.Server) {
+	t.Helper()
+	wsServer := NewServer()
+	go wsServer.Run()
+	wsServer.SetSubscriptionLoader(func(info ClientInfo, ticker string, opts analysis.AggregateOptions) (Subscription, error) {
+		return Subscription{Ticker: ticker, History: feed.snapshot()}, nil
+	})
+	upgrader := NewUpgrader(nil, false)
+
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastPeriodEnd, err := ParseLastPeriodEnd(r.URL.Query().Get("last_period_end"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		opts := analysis.AggregateOptions{PeriodMinutes: testPeriodMinutes}
+		if err := wsServer.Register(conn, ClientInfo{Subject: "tester", Ticker: "AAPL", Protocol: conn.Subprotocol(), Options: opts}); err != nil {
+			conn.Close()
+			return
+		}
+		defer wsServer.Unregister(conn)
+		updates := wsServer.Updates(conn)
+		closeRequests := wsServer.CloseRequests(conn)
+
+		for _, summary := range ResumeAfter(feed.snapshot(), lastPeriodEnd) {
+			if err := wsServer.WriteSummary(conn, MessageTypeHistory, summary); err != nil {
+				return
+			}
+		}
+
+		messages := make(chan ClientMessage)
+		readerDone := make(chan struct{})
+		go func() {
+			defer close(readerDone)
+			for {
+				frame, data, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+				msg, err := DecodeClientMessage(conn, frame, data)
+				if err != nil {
+					continue
+				}
+				select {
+				case messages <- msg:
+				case <-r.Context().Done():
+					return
+				}
+			}
+		}()
+
+		for {
+			var err error
+			select {
+			case summary := <-updates:
+				err = wsServer.WriteSummary(conn, MessageTypeUpdate, summary)
+			case msg := <-messages:
+				switch msg.Type {
+				case MessageTypeResume:
+					err = wsServer.HandleResume(conn, msg)
+				case MessageTypeHeartbeat:
+					for _, summary := range wsServer.Heartbeat(conn, msg.Seq) {
+						if err = wsServer.WriteSummary(conn, MessageTypeUpdate, summary); err != nil {
+							break
+						}
+					}
+				}
+			case req := <-closeRequests:
+				req.Send(conn)
+				return
+			case <-readerDone:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(httpServer.Close)
+	return wsServer, httpServer
+}
+
+// faultyProxy forwards TCP connections to a backend, writing the backend's bytes to the client through a fault
+// injector: delays hold data back, and a drop severs the connection, losing whatever was in flight
+type faultyProxy struct {
+	listener net.Listener
+	backend  string
+	injector *jaxws.FaultInjector
+	ctx      context.Context
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+// newFaultyProxy starts a proxy to backend (host:port) with the given faults; duplicates aren't supported, since a
+// repeated chunk would corrupt the WebSocket framing
+func newFaultyProxy(t *testing.T, backend string, faults jaxws.Faults) *faultyProxy {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &faultyProxy{listener: listener, backend: backend, injector: jaxws.NewFaultInjector(faults), ctx: ctx}
+	t.Cleanup(func() {
+		cancel()
+		listener.Close()
+		p.mu.Lock()
+		for _, conn := range p.conns {
+			conn.Close()
+		}
+		p.mu.Unlock()
+	})
+	go p.serve()
+	return p
+}
+
+// serve accepts client connections until the listener is closed
+func (p *faultyProxy) serve() {
+	for {
+		client, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		backend, err := net.Dial("tcp", p.backend)
+		if err != nil {
+			client.Close()
+			continue
+		}
+		p.mu.Lock()
+		p.conns = append(p.conns, client, backend)
+		p.mu.Unlock()
+
+		go func() {
+			io.Copy(backend, client)
+			backend.Close()
+		}()
+		go p.forward(backend, client)
+	}
+}
+
+// forward copies the backend's bytes to the client through the injector, severing both connections on a drop
+func (p *faultyProxy) forward(backend net.Conn, client net.Conn) {
+	defer client.Close()
+	defer backend.Close()
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := backend.Read(buf)
+		if err != nil {
+			return
+		}
+		delivered := false
+		var writeErr error
+		p.injector.Deliver(p.ctx, func() {
+			delivered = true
+			_, writeErr = client.Write(buf[:n])
+		})
+		if !delivered || writeErr != nil {
+			return
+		}
+	}
+}
+
+// url returns the /analyze URL through the proxy
+func (p *faultyProxy) url() string {
+	return "ws://" + p.listener.Addr().String() + "/analyze"
+}
+
+// received is a summary as a test client received it
+type received struct {
+	kind    string // Envelope type
+	summary analysis.TimePeriodSummary
+}
+
+// dialEnveloped opens a jaxov.v2.json connection, resuming after lastPeriodEnd when it is set
+func dialEnveloped(rawURL string, lastPeriodEnd time.Time) (*websocket.Conn, error) {
+	if !lastPeriodEnd.IsZero() {
+		rawURL += "?last_period_end=" + url.QueryEscape(strconv.FormatInt(lastPeriodEnd.UnixMilli(), 10))
+	}
+	dialer := websocket.Dialer{Subprotocols: []string{SubprotocolV2JSON}, HandshakeTimeout: time.Second}
+	conn, _, err := dialer.Dial(rawURL, nil)
+	return conn, err
+}
+
+// readSummaries reads summary envelopes from conn into a channel until the connection fails
+func readSummaries(conn *websocket.Conn) <-chan received {
+	out := make(chan received, 64)
+	go func() {
+		defer close(out)
+		for {
+			var envelope struct {
+				Type    string          `json:"type"`
+				Payload json.RawMessage `json:"payload"`
+			}
+			if err := conn.ReadJSON(&envelope); err != nil {
+				return
+			}
+			if envelope.Type != MessageTypeHistory && envelope.Type != MessageTypeUpdate {
+				continue
+			}
+			var summary analysis.TimePeriodSummary
+			if err := json.Unmarshal(envelope.Payload, &summary); err != nil {
+				return
+			}
+			out <- received{kind: envelope.Type, summary: summary}
+		}
+	}()
+	return out
+}
+
+// expectSummaries reads n summaries, failing the test if they don't arrive in time
+func expectSummaries(t *testing.T, summaries <-chan received, n int) []received {
+	t.Helper()
+	var got []received
+	timeout := time.After(10 * time.Second)
+	for len(got) < n {
+		select {
+		case r, ok := <-summaries:
+			if !ok {
+				t.Fatalf("connection closed after %d of %d summaries", len(got), n)
+			}
+			got = append(got, r)
+		case <-timeout:
+			t.Fatalf("received %d of %d summaries", len(got), n)
+		}
+	}
+	return got
+}
+
+// resumingClient keeps an /analyze stream open through drops, reconnecting with the end of the last period it has
+type resumingClient struct {
+	url string
+
+	mu         sync.Mutex
+	periods    map[int64]analysis.TimePeriodSummary // By period start (Unix ms)
+	lastEnd    time.Time                            // End of the latest period received
+	connects   int
+	violations []string // History periods a resumed connection sent that the client already had
+}
+
+// run connects, and reconnects after every drop, until ctx is cancelled
+func (c *resumingClient) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		c.mu.Lock()
+		resumeAfter := c.lastEnd
+		c.mu.Unlock()
+
+		conn, err := dialEnveloped(c.url, resumeAfter)
+		if err != nil {
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+		c.mu.Lock()
+		c.connects++
+		c.mu.Unlock()
+		stop := context.AfterFunc(ctx, func() { conn.Close() })
+
+		for r := range readSummaries(conn) {
+			c.mu.Lock()
+			if r.kind == MessageTypeHistory && !resumeAfter.IsZero() && !r.summary.PeriodEnd.After(resumeAfter) {
+				c.violations = append(c.violations, r.summary.PeriodStart.Format(time.RFC3339))
+			}
+			c.periods[r.summary.PeriodStart.UnixMilli()] = r.summary
+			if r.summary.PeriodEnd.After(c.lastEnd) {
+				c.lastEnd = r.summary.PeriodEnd
+			}
+			c.mu.Unlock()
+		}
+		stop()
+		conn.Close()
+	}
+}
+
+// missing returns the first n periods of the test stream the client doesn't have
+func (c *resumingClient) missing(n int) []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var missing []int
+	for i := 0; i < n; i++ {
+		if _, ok := c.periods[testPeriod(i).PeriodStart.UnixMilli()]; !ok {
+			missing = append(missing, i)
+		}
+	}
+	return missing
+}
+
+// TestReconnectResumesAfterDroppedConnections streams periods through a proxy that delays data and repeatedly drops
+// the connection, and checks that a client reconnecting with last_period_end ends up with every period, and that
+// each resumed history only holds periods it was missing
+func TestReconnectResumesAfterDroppedConnections(t *testing.T) {
+	feed := &testFeed{}
+	for i := 0; i < 5; i++ {
+		feed.add(testPeriod(i))
+	}
+	wsServer, httpServer := newTestServer(t, feed)
+	proxy := newFaultyProxy(t, strings.TrimPrefix(httpServer.URL, "http://"), jaxws.Faults{
+		DropEvery: 120 * time.Millisecond,
+		DropFor:   40 * time.Millisecond,
+		Delay:     3 * time.Millisecond,
+		Seed:      11,
+	})
+
+	client := &resumingClient{url: proxy.url(), periods: make(map[int64]analysis.TimePeriodSummary)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go client.run(ctx)
+
+	const total = 80
+	for i := 5; i < total; i++ {
+		time.Sleep(8 * time.Millisecond)
+		feed.publish(wsServer, testPeriod(i))
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for len(client.missing(total)) > 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if missing := client.missing(total); len(missing) > 0 {
+		t.Fatalf("client is missing periods %v after reconnecting", missing)
+	}
+
+	stats := proxy.injector.Stats()
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if stats.Drops == 0 || client.connects < 2 {
+		t.Fatalf("%d drops and %d connections, want the connection dropped and re-established", stats.Drops, client.connects)
+	}
+	if len(client.violations) > 0 {
+		t.Errorf("resumed histories resent periods the client already had: %v", client.violations)
+	}
+	for start, summary := range client.periods {
+		i := int(time.UnixMilli(start).Sub(testDayStart) / (testPeriodMinutes * time.Minute))
+		if summary.CallPremium != testPeriod(i).CallPremium {
+			t.Errorf("period %d has call premium %v, want %v", i, summary.CallPremium, testPeriod(i).CallPremium)
+		}
+	}
+}
+
+// TestResumeMessageFillsGap checks that a client on an open, slow connection can ask for the periods after the last
+// one it has with a resume message, without reconnecting
+func TestResumeMessageFillsGap(t *testing.T) {
+	feed := &testFeed{}
+	for i := 0; i < 8; i++ {
+		feed.add(testPeriod(i))
+	}
+	_, httpServer := newTestServer(t, feed)
+	proxy := newFaultyProxy(t, strings.TrimPrefix(httpServer.URL, "http://"), jaxws.Faults{Delay: 10 * time.Millisecond, Seed: 3})
+
+	conn, err := dialEnveloped(proxy.url(), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	summaries := readSummaries(conn)
+	history := expectSummaries(t, summaries, 8)
+	for i, r := range history {
+		if r.kind != MessageTypeHistory || !r.summary.PeriodStart.Equal(testPeriod(i).PeriodStart) {
+			t.Fatalf("history message %d = %s %v, want history for period %d", i, r.kind, r.summary.PeriodStart, i)
+		}
+	}
+
+	// The client only kept the first three periods (say it was suspended) and asks for the rest
+	resume := ClientMessage{Type: MessageTypeResume, LastPeriodEnd: testPeriod(2).PeriodEnd}
+	if err := conn.WriteJSON(resume); err != nil {
+		t.Fatal(err)
+	}
+	resent := expectSummaries(t, summaries, 5)
+	for i, r := range resent {
+		if r.kind != MessageTypeHistory || !r.summary.PeriodStart.Equal(testPeriod(i+3).PeriodStart) {
+			t.Errorf("resumed message %d = %s %v, want history for period %d", i, r.kind, r.summary.PeriodStart, i+3)
+		}
+		if r.summary.Seq <= history[len(history)-1].summary.Seq {
+			t.Errorf("resumed period %d has seq %d, want it numbered after the history", i+3, r.summary.Seq)
+		}
+	}
+}
+
+// TestHeartbeatLagResendsMissedUpdates checks that a client whose heartbeats show it missed live updates gets the
+// latest state of each missed period again, once
+func TestHeartbeatLagResendsMissedUpdates(t *testing.T) {
+	feed := &testFeed{}
+	wsServer, httpServer := newTestServer(t, feed)
+	wsServer.SetLagResend(50 * time.Millisecond)
+	proxy := newFaultyProxy(t, strings.TrimPrefix(httpServer.URL, "http://"), jaxws.Faults{Delay: 5 * time.Millisecond, Seed: 9})
+
+	conn, err := dialEnveloped(proxy.url(), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	summaries := readSummaries(conn)
+
+	// Wait for the connection to register before publishing, so every update is queued for it
+	deadline := time.Now().Add(5 * time.Second)
+	for len(wsServer.GetSubscribedTickers()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Period 1 is updated twice: only its latest state is resent
+	feed.publish(wsServer, testPeriod(0))
+	feed.publish(wsServer, testPeriod(1))
+	updated := testPeriod(1)
+	updated.CallPremium += 500
+	feed.publish(wsServer, updated)
+	feed.publish(wsServer, testPeriod(2))
+	updates := expectSummaries(t, summaries, 4)
+	for i, r := range updates {
+		if r.kind != MessageTypeUpdate || r.summary.Seq != int64(i+1) {
+			t.Fatalf("update %d = %s seq %d, want update seq %d", i, r.kind, r.summary.Seq, i+1)
+		}
+	}
+
+	// The client acknowledges only the first update, as if the rest were lost, once they are overdue
+	time.Sleep(60 * time.Millisecond)
+	if err := conn.WriteJSON(ClientMessage{Type: MessageTypeHeartbeat, Seq: 1}); err != nil {
+		t.Fatal(err)
+	}
+	resent := expectSummaries(t, summaries, 2)
+	sort.Slice(resent, func(i, j int) bool { return resent[i].summary.PeriodStart.Before(resent[j].summary.PeriodStart) })
+	if !resent[0].summary.PeriodStart.Equal(testPeriod(1).PeriodStart) || resent[0].summary.CallPremium != updated.CallPremium {
+		t.Errorf("first resend = %v with $%v calls, want period 1's latest state ($%v)", resent[0].summary.PeriodStart, resent[0].summary.CallPremium, updated.CallPremium)
+	}
+	if !resent[1].summary.PeriodStart.Equal(testPeriod(2).PeriodStart) {
+		t.Errorf("second resend = %v, want period 2", resent[1].summary.PeriodStart)
+	}
+	for _, r := range resent {
+		if r.kind != MessageTypeUpdate || r.summary.Seq <= 4 {
+			t.Errorf("resent %v as %s seq %d, want an update numbered after the originals", r.summary.PeriodStart, r.kind, r.summary.Seq)
+		}
+	}
+
+	// Repeating the stale heartbeat doesn't resend them again
+	time.Sleep(60 * time.Millisecond)
+	if err := conn.WriteJSON(ClientMessage{Type: MessageTypeHeartbeat, Seq: 1}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case r := <-summaries:
+		t.Errorf("received %s for %v after a repeated heartbeat, want nothing", r.kind, r.summary.PeriodStart)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+// TestResumeAfter checks the periods a reconnecting client is sent for each last_period_end
+func TestResumeAfter(t *testing.T) {
+	periods := []analysis.TimePeriodSummary{testPeriod(0), testPeriod(1), testPeriod(2)}
+	tests := []struct {
+		name          string
+		lastPeriodEnd time.Time
+		want          int // Index of the first period resent; len(periods) for none
+	}{
+		{"nothing received", time.Time{}, 0},
+		{"before the first period", testDayStart.Add(-time.Hour), 0},
+		{"after the first period", periods[0].PeriodEnd, 1},
+		{"mid-period", periods[1].PeriodStart.Add(time.Minute), 1},
+		{"everything received", periods[2].PeriodEnd, 3},
+		{"later than the stream", periods[2].PeriodEnd.Add(time.Hour), 3},
+	}
+	for _, tt := range tests {
+		got := ResumeAfter(periods, tt.lastPeriodEnd)
+		if len(got) != len(periods)-tt.want || (len(got) > 0 && !got[0].PeriodStart.Equal(periods[tt.want].PeriodStart)) {
+			t.Errorf("%s: ResumeAfter returned %d periods, want them from period %d", tt.name, len(got), tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "1764167400000", "2025-11-26T14:35:00Z"} {
+		if _, err := ParseLastPeriodEnd(value); err != nil {
+			t.Errorf("ParseLastPeriodEnd(%q): %v", value, err)
+		}
+	}
+	if _, err := ParseLastPeriodEnd("yesterday"); err == nil {
+		t.Error("ParseLastPeriodEnd(\"yesterday\") succeeded, want an error")
+	}
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/gorilla/websocket"
+)
+
+// Connection modes for /analyze
+const (
+	ModeLive   = "live"   // History followed by live updates (default)
+	ModeReplay = "replay" // A stored day streamed period-by-period at a client-controlled pace
+)
+
+// Replay speed limits, as multiples of real time (60 = one market minute per second)
+const (
+	DefaultReplaySpeed = 60.0
+	MaxReplaySpeed     = 3600.0
+)
+
+// Replay states reported in ReplayStateMessage
+const (
+	ReplayPlaying  = "playing"
+	ReplayPaused   = "paused"
+	ReplayFinished = "finished"
+)
+
+// ReplayStateMessage reports the replay position after every play, pause, seek, or when the day runs out
+// When Reset is true the client should clear its chart; the periods before Position follow immediately
+type ReplayStateMessage struct {
+	Type     string    `json:"type"`     // Always "replay_state"
+	State    string    `json:"state"`    // playing, paused, or finished
+	Position time.Time `json:"position"` // Start of the next period to be sent
+	Speed    float64   `json:"speed"`    // Multiple of real time
+	Reset    bool      `json:"reset,omitempty"`
+}
+
+// ValidateReplaySpeed checks that a replay speed is within (0, MaxReplaySpeed]
+func ValidateReplaySpeed(speed float64) error {
+	if speed <= 0 || speed > MaxReplaySpeed {
+		return fmt.Errorf("invalid replay speed %g (must be greater than 0 and at most %g)", speed, MaxReplaySpeed)
+	}
+	return nil
+}
+
+// Replay steps through a day's period summaries for one connection
+// It is not safe for concurrent use; the connection's writer goroutine owns it
+type Replay struct {
+	summaries []analysis.TimePeriodSummary
+	position  int // Index of the next summary to send
+	speed     float64
+	playing   bool
+}
+
+// NewReplay creates a playing replay over summaries (sorted by period start) at the given speed
+func NewReplay(summaries []analysis.TimePeriodSummary, speed float64) *Replay {
+	return &Replay{summaries: summaries, speed: speed, playing: true}
+}
+
+// Play resumes the replay, optionally changing speed (0 keeps the current speed)
+func (r *Replay) Play(speed float64) error {
+	if speed != 0 {
+		if err := ValidateReplaySpeed(speed); err != nil {
+			return err
+		}
+		r.speed = speed
+	}
+	r.playing = true
+	return nil
+}
+
+// Pause stops the replay at its current position
+func (r *Replay) Pause() {
+	r.playing = false
+}
+
+// Seek moves the replay to the first period starting at or after t
+// It returns the periods before the new position so the client can rebuild its chart
+func (r *Replay) Seek(t time.Time) []analysis.TimePeriodSummary {
+	r.position = len(r.summaries)
+	for i, summary := range r.summaries {
+		if !summary.PeriodStart.Before(t) {
+			r.position = i
+			break
+		}
+	}
+	return r.summaries[:r.position]
+}
+
+// Next returns the next period to send and advances the replay
+func (r *Replay) Next() (analysis.TimePeriodSummary, bool) {
+	if r.position >= len(r.summaries) {
+		return analysis.TimePeriodSummary{}, false
+	}
+	summary := r.summaries[r.position]
+	r.position++
+	return summary, true
+}
+
+// Delay returns how long to wait before sending the next period: its length scaled down by the speed
+func (r *Replay) Delay() time.Duration {
+	if r.position >= len(r.summaries) {
+		return 0
+	}
+	summary := r.summaries[r.position]
+	return time.Duration(float64(summary.PeriodEnd.Sub(summary.PeriodStart)) / r.speed)
+}
+
+// Active reports whether the replay is playing and has periods left to send
+func (r *Replay) Active() bool {
+	return r.playing && r.position < len(r.summaries)
+}
+
+// State returns the replay's current state message
+func (r *Replay) State() ReplayStateMessage {
+	state := ReplayPaused
+	if r.position >= len(r.summaries) {
+		state = ReplayFinished
+	} else if r.playing {
+		state = ReplayPlaying
+	}
+
+	var position time.Time
+	if r.position < len(r.summaries) {
+		position = r.summaries[r.position].PeriodStart
+	} else if len(r.summaries) > 0 {
+		position = r.summaries[len(r.summaries)-1].PeriodEnd
+	}
+
+	return ReplayStateMessage{Type: MessageTypeReplayState, State: state, Position: position, Speed: r.speed}
+}
+
+// SendReplayState writes a replay_state message to a client
+func SendReplayState(conn *websocket.Conn, state ReplayStateMessage) error {
+	return writeMessage(conn, MessageTypeReplayState, "", state)
+}
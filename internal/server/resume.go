@@ -0,0 +1,94 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/gorilla/websocket"
+)
+
+// ParseLastPeriodEnd parses a client's last_period_end query parameter: the period_end of the last complete period
+// it already has, as RFC 3339 or Unix milliseconds (empty means the client has nothing)
+func ParseLastPeriodEnd(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil && ms > 0 {
+		return time.UnixMilli(ms), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid last_period_end %q, use RFC 3339 or Unix milliseconds", value)
+	}
+	return t, nil
+}
+
+// ResumeAfter returns the periods that end after lastPeriodEnd, which a resuming client doesn't have yet (every
+// period for the zero time). The summaries share the slice's backing array
+func ResumeAfter(summaries []analysis.TimePeriodSummary, lastPeriodEnd time.Time) []analysis.TimePeriodSummary {
+	if lastPeriodEnd.IsZero() {
+		return summaries
+	}
+	for i, summary := range summaries {
+		if summary.PeriodEnd.After(lastPeriodEnd) {
+			return summaries[i:]
+		}
+	}
+	return nil
+}
+
+// HandleResume answers a resume message from a live client by sending the periods of a followed stream that end
+// after msg.LastPeriodEnd, as history; the ticker defaults to the one the client connected with. Clients send it
+// when they may have missed messages on an open connection (e.g. after the app was suspended)
+// It must be called from the connection's writer goroutine. Invalid requests are answered with an error frame and
+// leave the connection open; the returned error is a failed write
+func (s *Server) HandleResume(conn *websocket.Conn, msg ClientMessage) error {
+	s.mu.RLock()
+	info, ok := s.clients[conn]
+	loader := s.loader
+	var current ClientInfo
+	var sub *subscription
+	if ok && info != nil {
+		current = *info
+		ticker := strings.ToUpper(strings.TrimSpace(msg.Ticker))
+		if ticker == "" {
+			ticker = info.Ticker
+		}
+		sub = info.subs[ticker]
+		msg.Ticker = ticker
+	}
+	s.mu.RUnlock()
+	if !ok || info == nil {
+		return nil
+	}
+
+	if !current.live() {
+		return SendError(conn, ErrorInvalidParameter, "resume is only supported on live connections")
+	}
+	if loader == nil {
+		return SendError(conn, ErrorInvalidParameter, "resume is not enabled on this server")
+	}
+	if sub == nil {
+		return SendError(conn, ErrorInvalidTicker, "not subscribed to "+msg.Ticker)
+	}
+
+	resolved, err := loader(current, msg.Ticker, sub.options)
+	if err != nil {
+		var protocolErr *ProtocolError
+		if errors.As(err, &protocolErr) {
+			return SendError(conn, protocolErr.Code, protocolErr.Message)
+		}
+		return SendError(conn, ErrorInvalidParameter, err.Error())
+	}
+	for _, summary := range ResumeAfter(resolved.History, msg.LastPeriodEnd) {
+		summary.Ticker = resolved.Ticker
+		if err := s.WriteSummary(conn, MessageTypeHistory, summary); err != nil {
+			return err
+		}
+	}
+	return nil
+}
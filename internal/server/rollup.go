@@ -0,0 +1,104 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+)
+
+// cachedDay holds daily totals and availability along with the file state they were computed from
+type cachedDay struct {
+	summary      analysis.RollupSummary
+	availability DateAvailability
+	size         int64
+	modTime      time.Time
+}
+
+// RollupCache caches per-ticker daily totals so rollups and availability don't re-read unchanged files
+// Entries are invalidated when the underlying log file's size or modification time changes,
+// and the least recently used days are evicted once the cache holds its maximum number of entries
+type RollupCache struct {
+	logDir string
+	days   *LRU[string, cachedDay] // Key: log file path
+	mu     sync.Mutex
+}
+
+// NewRollupCache creates an unbounded rollup cache for a log directory
+func NewRollupCache(logDir string) *RollupCache {
+	return NewRollupCacheWithLimit(logDir, 0)
+}
+
+// NewRollupCacheWithLimit creates a rollup cache holding at most maxEntries days (0 for unbounded)
+func NewRollupCacheWithLimit(logDir string, maxEntries int) *RollupCache {
+	return &RollupCache{
+		logDir: logDir,
+		days:   NewLRU[string, cachedDay](maxEntries, nil),
+	}
+}
+
+// CacheStats returns the cache's size and hit, miss, and eviction counters
+func (c *RollupCache) CacheStats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.days.Stats()
+}
+
+// DailyTotals returns the daily totals for a ticker and date, using the cache when the file is unchanged
+func (c *RollupCache) DailyTotals(ticker string, dateStr string) (analysis.RollupSummary, error) {
+	day, err := c.loadDay(ticker, dateStr)
+	if err != nil {
+		return analysis.RollupSummary{}, err
+	}
+	return day.summary, nil
+}
+
+// loadDay returns the cached entry for a ticker and date, re-reading the log file if it changed
+func (c *RollupCache) loadDay(ticker string, dateStr string) (cachedDay, error) {
+	logFile := GetLogFileForTickerAndDate(c.logDir, ticker, dateStr)
+
+	info, err := os.Stat(logFile)
+	if os.IsNotExist(err) {
+		return cachedDay{
+			summary:      analysis.SummarizeDay(dateStr, nil),
+			availability: summarizeAvailability(dateStr, nil),
+		}, nil
+	}
+	if err != nil {
+		return cachedDay{}, fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	c.mu.Lock()
+	cached, ok := c.days.Get(logFile)
+	c.mu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached, nil
+	}
+
+	aggregates, err := ReadLogFile(logFile)
+	if err != nil {
+		return cachedDay{}, err
+	}
+	day := cachedDay{
+		summary:      analysis.SummarizeDay(dateStr, aggregates),
+		availability: summarizeAvailability(dateStr, aggregates),
+		size:         info.Size(),
+		modTime:      info.ModTime(),
+	}
+
+	c.mu.Lock()
+	c.days.Add(logFile, day)
+	c.mu.Unlock()
+
+	return day, nil
+}
+
+// Rollup returns daily, weekly, or monthly totals for a ticker between fromDate and toDate (inclusive)
+// Empty fromDate/toDate leave the range open on that side
+func (c *RollupCache) Rollup(ticker string, fromDate string, toDate string, granularity string) ([]analysis.RollupSummary, error) {
+	return analysis.RollupLogDir(c.logDir, ticker, fromDate, toDate, granularity, func(dateStr string) (analysis.RollupSummary, error) {
+		return c.DailyTotals(ticker, dateStr)
+	})
+}
@@ -0,0 +1,209 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+)
+
+//go:generate go run ../../cmd/protocol-schema --output analyze.schema.json --source .,../analysis
+
+// envelopePayloads maps each server -> client Envelope type to the payload it carries
+var envelopePayloads = []struct {
+	Type    string
+	Payload interface{}
+}{
+	{MessageTypeHistory, analysis.TimePeriodSummary{}},
+	{MessageTypeUpdate, analysis.TimePeriodSummary{}},
+	{MessageTypeReplay, analysis.TimePeriodSummary{}},
+	{MessageTypeError, ErrorMessage{}},
+	{MessageTypeTokenExpiring, TokenExpiringMessage{}},
+	{MessageTypeReplayState, ReplayStateMessage{}},
+	{MessageTypeBackfill, BackfillMessage{}},
+	{MessageTypeDate, DateMessage{}},
+	{MessageTypeSubscribed, SubscriptionMessage{}},
+	{MessageTypeUnsubscribed, SubscriptionMessage{}},
+}
+
+// clientMessageTypes lists the message types a client may send
+var clientMessageTypes = []string{
+	MessageTypeAuth, MessageTypePlay, MessageTypePause, MessageTypeSeek, MessageTypeHeartbeat,
+	MessageTypeSubscribe, MessageTypeUnsubscribe, MessageTypeChangePeriod, MessageTypeResume,
+}
+
+// ProtocolSchema returns a JSON Schema (draft 2020-12) for the SubprotocolV2JSON and SubprotocolV2MsgPack feeds:
+// the Envelope, the payload of each message type, and ClientMessage. It is built from the json tags of the Go
+// types, so it is what the server actually sends; comments (see doccomments.Read) become descriptions when given
+func ProtocolSchema(comments map[string]string) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(protocolSchema(comments)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// protocolSchema builds the schema document ProtocolSchema encodes
+func protocolSchema(comments map[string]string) map[string]interface{} {
+	g := &schemaGenerator{defs: map[string]interface{}{}, types: map[string]reflect.Type{}, comments: comments}
+
+	messageTypes := make([]interface{}, 0, len(envelopePayloads))
+	var payloads []interface{}
+	for _, p := range envelopePayloads {
+		messageTypes = append(messageTypes, p.Type)
+		payloads = append(payloads, map[string]interface{}{
+			"if":   map[string]interface{}{"properties": map[string]interface{}{"type": map[string]interface{}{"const": p.Type}}},
+			"then": map[string]interface{}{"properties": map[string]interface{}{"payload": g.schemaFor(reflect.TypeOf(p.Payload))}},
+		})
+	}
+
+	envelope := g.schemaFor(reflect.TypeOf(Envelope{}))
+	envelopeDef := g.defs["Envelope"].(map[string]interface{})
+	envelopeDef["allOf"] = payloads
+	property(envelopeDef, "type")["enum"] = messageTypes
+	property(envelopeDef, "version")["const"] = EnvelopeVersion
+
+	// Clients only have to send a type; the other fields depend on it
+	g.schemaFor(reflect.TypeOf(ClientMessage{}))
+	clientDef := g.defs["ClientMessage"].(map[string]interface{})
+	clientTypes := make([]interface{}, len(clientMessageTypes))
+	for i, t := range clientMessageTypes {
+		clientTypes[i] = t
+	}
+	property(clientDef, "type")["enum"] = clientTypes
+	clientDef["required"] = []string{"type"}
+
+	return map[string]interface{}{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   "/analyze WebSocket feed (" + SubprotocolV2JSON + " and " + SubprotocolV2MsgPack + ")",
+		"description": "Every server message is an Envelope; clients send ClientMessage. " + SubprotocolV2MsgPack +
+			" frames carry the same documents as MessagePack maps, with numbers as MessagePack integers or floats " +
+			"(float32 when exact) and timestamps as RFC 3339 strings. Generated by cmd/protocol-schema; do not edit.",
+		"$ref":  envelope["$ref"],
+		"$defs": g.defs,
+	}
+}
+
+// schemaGenerator builds JSON Schema definitions for Go types, one $defs entry per named struct
+type schemaGenerator struct {
+	defs     map[string]interface{}
+	types    map[string]reflect.Type // Go type behind each $defs entry, to catch name collisions
+	comments map[string]string
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor returns the schema for values of type t as encoding/json marshals them
+func (g *schemaGenerator) schemaFor(t reflect.Type) map[string]interface{} {
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Ptr:
+		return nullable(g.schemaFor(t.Elem()))
+	case t.Kind() == reflect.Struct:
+		return g.structRef(t)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schemaFor(t.Elem())}
+	case reflect.Interface:
+		return map[string]interface{}{}
+	}
+	panic(fmt.Sprintf("schema: unsupported type %s", t))
+}
+
+// structRef adds t to the definitions if it isn't there yet and returns a reference to it
+func (g *schemaGenerator) structRef(t reflect.Type) map[string]interface{} {
+	name := t.Name()
+	ref := map[string]interface{}{"$ref": "#/$defs/" + name}
+	if existing, ok := g.types[name]; ok {
+		if existing != t {
+			panic(fmt.Sprintf("schema: %s and %s share the definition name %s", existing.PkgPath(), t.PkgPath(), name))
+		}
+		return ref
+	}
+	g.types[name] = t
+
+	def := map[string]interface{}{"type": "object"}
+	g.defs[name] = def // Before the fields, so recursive types terminate
+	if doc := g.comments[name]; doc != "" {
+		def["description"] = doc
+	}
+	properties := map[string]interface{}{}
+	required := []string{}
+	g.addFields(t, name, properties, &required)
+	def["properties"] = properties
+	sort.Strings(required)
+	def["required"] = required
+	return ref
+}
+
+// addFields adds the JSON fields of struct t to properties, flattening embedded structs as encoding/json does
+// Fields without omitempty are always present; so are struct fields, which omitempty never omits
+func (g *schemaGenerator) addFields(t reflect.Type, name string, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		key, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && key == "" && field.Type.Kind() == reflect.Struct {
+			g.addFields(field.Type, field.Type.Name(), properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if key == "" {
+			key = field.Name
+		}
+
+		omitEmpty := strings.Contains(","+options+",", ",omitempty,")
+		fieldType := field.Type
+		if omitEmpty && fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem() // Omitted rather than null when nil
+		}
+		schema := g.schemaFor(fieldType)
+		if !omitEmpty && (fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Map) {
+			schema = nullable(schema) // nil slices and maps marshal as null
+		}
+		if doc := g.comments[name+"."+field.Name]; doc != "" {
+			schema["description"] = doc
+		}
+		properties[key] = schema
+		if !omitEmpty || field.Type.Kind() == reflect.Struct {
+			*required = append(*required, key)
+		}
+	}
+}
+
+// property returns the schema of a property in a struct definition
+func property(def map[string]interface{}, key string) map[string]interface{} {
+	return def["properties"].(map[string]interface{})[key].(map[string]interface{})
+}
+
+// nullable returns a schema that also accepts null
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+}
@@ -0,0 +1,313 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/doccomments"
+)
+
+func TestProtocolSchemaUpToDate(t *testing.T) {
+	comments, err := doccomments.Read(".", "../analysis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ProtocolSchema(comments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("analyze.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("analyze.schema.json is out of date; run go generate ./internal/server")
+	}
+}
+
+func TestProtocolSchemaDescribesMessages(t *testing.T) {
+	data, err := ProtocolSchema(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	var full analysis.TimePeriodSummary
+	populate(reflect.ValueOf(&full).Elem())
+	for _, p := range envelopePayloads {
+		payloads := map[string]interface{}{"empty": reflect.Zero(reflect.TypeOf(p.Payload)).Interface()}
+		if _, ok := p.Payload.(analysis.TimePeriodSummary); ok {
+			payloads["full"] = full
+		} else {
+			filled := reflect.New(reflect.TypeOf(p.Payload)).Elem()
+			populate(filled)
+			payloads["full"] = filled.Interface()
+		}
+		for name, payload := range payloads {
+			t.Run(p.Type+"/"+name, func(t *testing.T) {
+				data, err := json.Marshal(Envelope{Type: p.Type, Version: EnvelopeVersion, Ticker: "AAPL", Payload: payload})
+				if err != nil {
+					t.Fatal(err)
+				}
+				validateDocument(t, schema, data)
+
+				// MessagePack frames carry the same document
+				packed, err := jsonToMsgPack(data)
+				if err != nil {
+					t.Fatal(err)
+				}
+				unpacked, err := msgPackToJSON(packed)
+				if err != nil {
+					t.Fatal(err)
+				}
+				validateDocument(t, schema, unpacked)
+			})
+		}
+	}
+
+	t.Run("unknown type", func(t *testing.T) {
+		data, _ := json.Marshal(Envelope{Type: "bogus", Version: EnvelopeVersion, Payload: ErrorMessage{}})
+		if errs := validate(schema, schema, decodeJSON(t, data), "$"); len(errs) == 0 {
+			t.Error("envelope with an unknown type validated")
+		}
+	})
+	t.Run("mismatched payload", func(t *testing.T) {
+		data, _ := json.Marshal(Envelope{Type: MessageTypeError, Version: EnvelopeVersion, Payload: DateMessage{ResolvedDate: "2025-11-26"}})
+		if errs := validate(schema, schema, decodeJSON(t, data), "$"); len(errs) == 0 {
+			t.Error("error envelope carrying a date payload validated")
+		}
+	})
+}
+
+func TestProtocolSchemaClientMessages(t *testing.T) {
+	data, err := ProtocolSchema(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	clientSchema := schema["$defs"].(map[string]interface{})["ClientMessage"]
+
+	messages := []string{
+		`{"type":"auth","token":"abc"}`,
+		`{"type":"play","speed":2.5}`,
+		`{"type":"seek","time":"2025-11-26T14:30:00Z"}`,
+		`{"type":"heartbeat","seq":42}`,
+		`{"type":"subscribe","ticker":"MSFT","period":5}`,
+		`{"type":"resume","ticker":"AAPL","last_period_end":"2025-11-26T15:00:00Z"}`,
+	}
+	for _, message := range messages {
+		if errs := validate(schema, clientSchema, decodeJSON(t, []byte(message)), "$"); len(errs) > 0 {
+			t.Errorf("%s: %s", message, strings.Join(errs, "; "))
+		}
+	}
+	for _, message := range []string{`{"token":"abc"}`, `{"type":"history"}`, `{"type":"heartbeat","seq":"42"}`} {
+		if errs := validate(schema, clientSchema, decodeJSON(t, []byte(message)), "$"); len(errs) == 0 {
+			t.Errorf("%s validated", message)
+		}
+	}
+}
+
+// validateDocument checks a JSON document against the schema and reports every violation
+func validateDocument(t *testing.T, schema map[string]interface{}, data []byte) {
+	t.Helper()
+	for _, err := range validate(schema, schema, decodeJSON(t, data), "$") {
+		t.Error(err)
+	}
+}
+
+func decodeJSON(t *testing.T, data []byte) interface{} {
+	t.Helper()
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// validate checks value against the subset of JSON Schema ProtocolSchema generates, and returns the violations
+// Unlike the schema, which lets clients ignore fields added later, it also rejects properties the schema doesn't
+// list, so a field missing from the schema fails the test
+func validate(root, schema interface{}, value interface{}, path string) []string {
+	s := schema.(map[string]interface{})
+	var errs []string
+	if ref, ok := s["$ref"].(string); ok {
+		def := root.(map[string]interface{})["$defs"].(map[string]interface{})[strings.TrimPrefix(ref, "#/$defs/")]
+		errs = append(errs, validate(root, def, value, path)...)
+	}
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, option := range anyOf {
+			if len(validate(root, option, value, path)) == 0 {
+				matched = true
+			}
+		}
+		if !matched {
+			errs = append(errs, path+": matches no anyOf option")
+		}
+	}
+	if allOf, ok := s["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			sub := sub.(map[string]interface{})
+			if cond, ok := sub["if"]; ok {
+				if len(validate(root, cond, value, path)) == 0 {
+					errs = append(errs, validate(root, sub["then"], value, path)...)
+				}
+				continue
+			}
+			errs = append(errs, validate(root, sub, value, path)...)
+		}
+	}
+	if c, ok := s["const"]; ok && fmt.Sprint(c) != fmt.Sprint(value) {
+		errs = append(errs, fmt.Sprintf("%s: %v is not %v", path, value, c))
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			found = found || e == value
+		}
+		if !found {
+			errs = append(errs, fmt.Sprintf("%s: %v is not in the enum", path, value))
+		}
+	}
+	if typ, ok := s["type"].(string); ok {
+		errs = append(errs, validateType(root, s, typ, value, path)...)
+	} else if properties, ok := s["properties"].(map[string]interface{}); ok {
+		// Untyped schemas (the envelope's if and then clauses) only constrain the properties they list
+		if obj, ok := value.(map[string]interface{}); ok {
+			for key, property := range properties {
+				if v, ok := obj[key]; ok {
+					errs = append(errs, validate(root, property, v, path+"."+key)...)
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// validateType checks value against a schema's type and the keywords that go with it
+func validateType(root interface{}, s map[string]interface{}, typ string, value interface{}, path string) []string {
+	switch typ {
+	case "null":
+		if value != nil {
+			return []string{path + ": not null"}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []string{path + ": not a boolean"}
+		}
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			return []string{path + ": not a number"}
+		}
+		if _, err := n.Int64(); typ == "integer" && err != nil {
+			return []string{path + ": not an integer"}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return []string{path + ": not a string"}
+		}
+		if s["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				return []string{path + ": not an RFC 3339 timestamp"}
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return []string{path + ": not an array"}
+		}
+		var errs []string
+		for i, item := range items {
+			errs = append(errs, validate(root, s["items"], item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return errs
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return []string{path + ": not an object"}
+		}
+		var errs []string
+		if required, ok := s["required"].([]interface{}); ok {
+			for _, key := range required {
+				if _, ok := obj[key.(string)]; !ok {
+					errs = append(errs, fmt.Sprintf("%s: missing %s", path, key))
+				}
+			}
+		}
+		properties, hasProperties := s["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			switch {
+			case hasProperties && properties[key] != nil:
+				errs = append(errs, validate(root, properties[key], obj[key], path+"."+key)...)
+			case s["additionalProperties"] != nil:
+				errs = append(errs, validate(root, s["additionalProperties"], obj[key], path+"."+key)...)
+			default:
+				errs = append(errs, fmt.Sprintf("%s: %s is not in the schema", path, key))
+			}
+		}
+		return errs
+	}
+	return nil
+}
+
+// populate sets every exported field reachable from v to a non-zero value, so each one appears when marshaled
+func populate(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		populate(v.Elem())
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			v.Set(reflect.ValueOf(time.Date(2025, 11, 26, 14, 30, 0, 0, time.UTC)))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				populate(v.Field(i))
+			}
+		}
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		populate(v.Index(0))
+	case reflect.Map:
+		v.Set(reflect.MakeMap(v.Type()))
+		elem := reflect.New(v.Type().Elem()).Elem()
+		populate(elem)
+		v.SetMapIndex(reflect.ValueOf("2025-11-28").Convert(v.Type().Key()), elem)
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(1.5))
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(7)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(7)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.25)
+	case reflect.String:
+		v.SetString("x")
+	}
+}
@@ -1,12 +1,16 @@
 package server
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/latency"
 	"github.com/gorilla/websocket"
 )
 
@@ -18,7 +22,57 @@ var upgrader = websocket.Upgrader{
 
 // ClientInfo stores information about a connected client
 type ClientInfo struct {
-	Ticker string
+	Subject     string                    // Authenticated user (JWT sub)
+	Ticker      string                    // Ticker the client connected with; later subscriptions are tracked separately
+	Date        string                    // Date the connection covers (YYYY-MM-DD), for the history of later subscriptions
+	Protocol    string                    // Negotiated subprotocol (e.g. jaxov.v1.json)
+	Compressed  bool                      // Messages are compressed with permessage-deflate
+	Options     analysis.AggregateOptions // Period bucketing requested by the client
+	Replay      bool                      // Replay connections stream stored data and never receive live updates
+	AsOf        time.Time                 // Point-in-time connections see history up to AsOf and never receive live updates
+	ConnectedAt time.Time
+
+	// Live in-progress updates are held back until total premium moves by at least this much (0 sends every update)
+	MinPremiumChange float64
+
+	updates chan analysis.TimePeriodSummary // Live updates waiting for the connection's writer
+	stats   *ConnectionStats
+	wire    *countingConn            // Bytes written to the network (nil without CountingListener)
+	lag     *lagTracker              // Summary sequence numbers and heartbeats
+	subs    map[string]*subscription // Live streams the connection follows, by ticker (see HandleSubscription)
+	closing chan CloseRequest        // Closes requested by other goroutines, handled by the connection's writer
+}
+
+// live reports whether the connection follows the log as it grows, rather than a stored or point-in-time view of it
+func (info *ClientInfo) live() bool {
+	return !info.Replay && info.AsOf.IsZero()
+}
+
+// Duplicate-connection policies for a user opening several streams for the same ticker
+const (
+	DuplicatePolicyAllow         = "allow"          // No limit
+	DuplicatePolicyReplaceOldest = "replace-oldest" // Close the user's oldest connections for the ticker to make room
+	DuplicatePolicyReject        = "reject"         // Refuse new connections over the limit
+)
+
+// ErrDuplicateConnection is returned by Register when the reject policy refuses a connection
+var ErrDuplicateConnection = errors.New("too many connections for this user and ticker")
+
+// ValidateDuplicatePolicy checks that a duplicate-connection policy is supported
+func ValidateDuplicatePolicy(policy string) error {
+	switch policy {
+	case DuplicatePolicyAllow, DuplicatePolicyReplaceOldest, DuplicatePolicyReject:
+		return nil
+	default:
+		return fmt.Errorf("invalid duplicate-connection policy %q (must be %s, %s, or %s)", policy, DuplicatePolicyAllow, DuplicatePolicyReplaceOldest, DuplicatePolicyReject)
+	}
+}
+
+// StreamKey identifies a live summary stream: a ticker analyzed with specific bucketing options
+// Clients that share a StreamKey receive identical updates
+type StreamKey struct {
+	Ticker  string
+	Options analysis.AggregateOptions
 }
 
 // Server manages WebSocket connections and broadcasts messages
@@ -28,6 +82,15 @@ type Server struct {
 	register   chan *websocket.Conn
 	unregister chan *websocket.Conn
 	mu         sync.RWMutex
+
+	duplicatePolicy  string // One of the DuplicatePolicy* constants
+	maxPerUserTicker int    // Connections allowed per user+ticker under replace-oldest and reject
+
+	resendAfter time.Duration // Resend missed periods to clients whose heartbeats lag this long (0 disables)
+
+	loader SubscriptionLoader // Resolves subscribe and change_period requests (nil disables them)
+
+	latency *latency.Recorder // End-to-end latency from the logger's ingest to reading and sending live updates
 }
 
 // NewServer creates a new WebSocket server
@@ -37,9 +100,45 @@ func NewServer() *Server {
 		broadcast:  make(chan analysis.TimePeriodSummary, 256),
 		register:   make(chan *websocket.Conn),
 		unregister: make(chan *websocket.Conn),
+
+		duplicatePolicy:  DuplicatePolicyAllow,
+		maxPerUserTicker: 1,
+
+		latency: latency.NewRecorder(),
 	}
 }
 
+// Latency returns the server's end-to-end latency recorder; WriteSummary records sent live updates in it, and the
+// caller records the stages before that (e.g. reading aggregates from the log file)
+func (s *Server) Latency() *latency.Recorder {
+	return s.latency
+}
+
+// SetDuplicatePolicy configures how Register treats a user's extra connections for the same ticker
+// maxPerUserTicker is the number of concurrent connections allowed before the policy applies (minimum 1)
+func (s *Server) SetDuplicatePolicy(policy string, maxPerUserTicker int) error {
+	if err := ValidateDuplicatePolicy(policy); err != nil {
+		return err
+	}
+	if maxPerUserTicker < 1 {
+		maxPerUserTicker = 1
+	}
+
+	s.mu.Lock()
+	s.duplicatePolicy = policy
+	s.maxPerUserTicker = maxPerUserTicker
+	s.mu.Unlock()
+	return nil
+}
+
+// SetLagResend resends missed periods to a client whose heartbeat shows it hasn't received messages sent at least
+// after ago (0 disables resending; lag is still recorded)
+func (s *Server) SetLagResend(after time.Duration) {
+	s.mu.Lock()
+	s.resendAfter = after
+	s.mu.Unlock()
+}
+
 // Run starts the server's connection management goroutine
 func (s *Server) Run() {
 	for {
@@ -52,12 +151,19 @@ func (s *Server) Run() {
 
 		case conn := <-s.unregister:
 			s.mu.Lock()
-			if _, ok := s.clients[conn]; ok {
+			info, ok := s.clients[conn]
+			if ok {
 				delete(s.clients, conn)
 				conn.Close()
 			}
+			clientCount := len(s.clients)
 			s.mu.Unlock()
-			log.Printf("Client disconnected. Total clients: %d", len(s.clients))
+			if ok && info != nil {
+				c := info.snapshot(time.Now())
+				log.Printf("Client disconnected for ticker %s (user %s, connected %s): %d messages, %d bytes, %d send errors, %d queue drops",
+					c.Ticker, c.Subject, time.Since(c.ConnectedAt).Round(time.Second), c.MessagesSent, c.BytesSent, c.SendErrors, c.QueueDrops)
+			}
+			log.Printf("Client disconnected. Total clients: %d", clientCount)
 
 		case message := <-s.broadcast:
 			// Broadcast is now handled per-ticker in SendUpdateForTicker
@@ -120,9 +226,9 @@ func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 
 // SendHistory sends historical data to a specific client
 func (s *Server) SendHistory(conn *websocket.Conn, summaries []analysis.TimePeriodSummary) error {
-	// Send each summary as a separate message (just the summary object, no wrapper)
+	// Send each summary as a separate message (just the summary object, unless the connection uses envelopes)
 	for _, summary := range summaries {
-		if err := conn.WriteJSON(summary); err != nil {
+		if err := s.WriteSummary(conn, MessageTypeHistory, summary); err != nil {
 			return err
 		}
 	}
@@ -131,54 +237,227 @@ func (s *Server) SendHistory(conn *websocket.Conn, summaries []analysis.TimePeri
 
 // SendUpdate sends an update to all clients subscribed to a specific ticker
 func (s *Server) SendUpdateForTicker(ticker string, summary analysis.TimePeriodSummary) {
+	s.sendUpdate(func(subTicker string, opts analysis.AggregateOptions) bool {
+		return subTicker == ticker
+	}, summary)
+}
+
+// SendUpdateForStream sends an update to all clients subscribed to a specific stream
+func (s *Server) SendUpdateForStream(key StreamKey, summary analysis.TimePeriodSummary) {
+	s.sendUpdate(func(subTicker string, opts analysis.AggregateOptions) bool {
+		return subTicker == key.Ticker && opts == key.Options
+	}, summary)
+}
+
+// sendUpdate queues a summary, tagged with the ticker, for every subscription accepted by match, subject to the
+// subscription's premium floor
+// Each connection's writer goroutine sends queued updates, so a slow client never blocks the others;
+// when its queue is full the update is dropped and counted in the connection's statistics
+func (s *Server) sendUpdate(match func(ticker string, opts analysis.AggregateOptions) bool, summary analysis.TimePeriodSummary) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	for conn, info := range s.clients {
-		if info != nil && info.Ticker == ticker {
-			err := conn.WriteJSON(summary)
-			if err != nil {
-				log.Printf("Error writing to client: %v", err)
-				conn.Close()
-				s.mu.RUnlock()
-				s.mu.Lock()
-				delete(s.clients, conn)
-				s.mu.Unlock()
-				s.mu.RLock()
+	for _, info := range s.clients {
+		if info == nil || !info.live() {
+			continue
+		}
+		for ticker, sub := range info.subs {
+			if !match(ticker, sub.options) {
+				continue
+			}
+
+			tagged := summary
+			tagged.Ticker = ticker
+			updates := []analysis.TimePeriodSummary{tagged}
+			if sub.floor != nil {
+				updates = sub.floor.filter(tagged)
+			}
+			for _, update := range updates {
+				select {
+				case info.updates <- update:
+				default:
+					info.stats.QueueDrops.Add(1)
+				}
 			}
 		}
 	}
 }
 
-// GetSubscribedTickers returns a map of all tickers that have active subscriptions
+// GetSubscribedTickers returns a map of all tickers that have active live subscriptions
 func (s *Server) GetSubscribedTickers() map[string]bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	tickers := make(map[string]bool)
 	for _, info := range s.clients {
-		if info != nil && info.Ticker != "" {
-			tickers[info.Ticker] = true
+		if info == nil || !info.live() {
+			continue
+		}
+		for ticker := range info.subs {
+			tickers[ticker] = true
 		}
 	}
 	return tickers
 }
 
-// Register registers a new client connection with a ticker
-func (s *Server) Register(conn *websocket.Conn, ticker string) {
+// GetSubscribedStreams returns a map of all streams that have active live subscriptions
+func (s *Server) GetSubscribedStreams() map[StreamKey]bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	streams := make(map[StreamKey]bool)
+	for _, info := range s.clients {
+		if info == nil || !info.live() {
+			continue
+		}
+		for ticker, sub := range info.subs {
+			streams[StreamKey{Ticker: ticker, Options: sub.options}] = true
+		}
+	}
+	return streams
+}
+
+// Register registers a new client connection described by info (user, ticker, subprotocol, options)
+// The duplicate-connection policy is applied first: under reject it returns ErrDuplicateConnection,
+// under replace-oldest the user's oldest connections for the ticker are closed with CloseReplaced
+func (s *Server) Register(conn *websocket.Conn, info ClientInfo) error {
+	subject, ticker := info.Subject, info.Ticker
 	s.mu.Lock()
-	s.clients[conn] = &ClientInfo{Ticker: ticker}
+	replaced := make(map[*websocket.Conn]*ClientInfo)
+	if s.duplicatePolicy != DuplicatePolicyAllow && subject != "" {
+		// Find the user's existing connections for this ticker, oldest first
+		var existing []*websocket.Conn
+		for c, other := range s.clients {
+			if other != nil && other.Subject == subject && other.Ticker == ticker {
+				existing = append(existing, c)
+			}
+		}
+
+		if excess := len(existing) - s.maxPerUserTicker + 1; excess > 0 {
+			if s.duplicatePolicy == DuplicatePolicyReject {
+				s.mu.Unlock()
+				return ErrDuplicateConnection
+			}
+
+			sort.Slice(existing, func(i, j int) bool {
+				return s.clients[existing[i]].ConnectedAt.Before(s.clients[existing[j]].ConnectedAt)
+			})
+			for _, c := range existing[:excess] {
+				replaced[c] = s.clients[c]
+				delete(s.clients, c)
+			}
+		}
+	}
+
+	info.ConnectedAt = time.Now()
+	info.updates = make(chan analysis.TimePeriodSummary, updateQueueSize)
+	info.stats = &ConnectionStats{}
+	info.wire = wireCounter(conn)
+	info.lag = &lagTracker{}
+	info.subs = make(map[string]*subscription)
+	info.closing = make(chan CloseRequest, 1)
+	if info.Ticker != "" {
+		info.subs[info.Ticker] = newSubscription(info.Options, info.MinPremiumChange)
+	}
+	s.clients[conn] = &info
 	clientCount := len(s.clients)
 	s.mu.Unlock()
+
+	for c, other := range replaced {
+		requestClose(c, other, CloseRequest{CloseCode: CloseReplaced, ErrorCode: ErrorConnectionReplaced, Message: "replaced by a newer connection for " + ticker})
+	}
+	if len(replaced) > 0 {
+		log.Printf("Replaced %d older connections for ticker %s", len(replaced), ticker)
+	}
+
 	log.Printf("Client connected for ticker %s. Total clients: %d", ticker, clientCount)
 	// Send to register channel to trigger any other handlers
 	select {
 	case s.register <- conn:
 	default:
 	}
+	return nil
+}
+
+// closeGrace is how long a connection's writer has to handle a CloseRequest before the connection is closed without it
+const closeGrace = 2 * time.Second
+
+// CloseRequest asks a connection's writer goroutine to close it with an error frame
+// gorilla/websocket allows one writer at a time, so other goroutines (replacing or shutting down connections) must
+// not write the error frame themselves
+type CloseRequest struct {
+	CloseCode int    // One of the Close* codes, or websocket.CloseGoingAway
+	ErrorCode string // One of the Error* codes
+	Message   string
+	done      chan struct{}
+}
+
+// Send writes the request's error frame and close frame and closes the connection
+// It must be called from the connection's writer goroutine
+func (r CloseRequest) Send(conn *websocket.Conn) {
+	CloseWithError(conn, r.CloseCode, r.ErrorCode, r.Message)
+	close(r.done)
+}
+
+// CloseRequests returns the channel a registered connection's writer receives CloseRequests on (nil if the
+// connection isn't registered); the writer should Send the request and stop
+func (s *Server) CloseRequests(conn *websocket.Conn) <-chan CloseRequest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if info, ok := s.clients[conn]; ok && info != nil {
+		return info.closing
+	}
+	return nil
+}
+
+// requestClose hands a close to a connection's writer and returns a channel closed once the writer has sent it
+// If the writer doesn't send it within closeGrace (it is stuck on a slow client, has already stopped, or another
+// close was requested first), the connection is closed with only a close frame, which is safe from any goroutine
+func requestClose(conn *websocket.Conn, info *ClientInfo, req CloseRequest) <-chan struct{} {
+	req.done = make(chan struct{})
+	if info != nil && info.closing != nil {
+		select {
+		case info.closing <- req:
+		default:
+		}
+	}
+	go func() {
+		select {
+		case <-req.done:
+		case <-time.After(closeGrace):
+			CloseWithCode(conn, req.CloseCode, req.ErrorCode)
+		}
+	}()
+	return req.done
 }
 
 // Unregister unregisters a client connection
 func (s *Server) Unregister(conn *websocket.Conn) {
 	s.unregister <- conn
 }
+
+// Shutdown notifies every connected client that the server is going away and closes their connections
+// Each connection's writer sends the close (see CloseRequest); Shutdown returns once they all have, or after closeGrace
+func (s *Server) Shutdown(reason string) {
+	s.mu.Lock()
+	clients := make(map[*websocket.Conn]*ClientInfo, len(s.clients))
+	for conn, info := range s.clients {
+		clients[conn] = info
+		delete(s.clients, conn)
+	}
+	s.mu.Unlock()
+
+	var pending []<-chan struct{}
+	for conn, info := range clients {
+		pending = append(pending, requestClose(conn, info, CloseRequest{CloseCode: websocket.CloseGoingAway, ErrorCode: ErrorServerShutdown, Message: reason}))
+	}
+	deadline := time.After(closeGrace)
+	for _, done := range pending {
+		select {
+		case <-done:
+		case <-deadline:
+		}
+	}
+	log.Printf("Closed %d client connections: %s", len(clients), reason)
+}
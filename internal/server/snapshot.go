@@ -0,0 +1,69 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+)
+
+// MaxSnapshotTickers caps how many tickers one /snapshot request may ask for
+const MaxSnapshotTickers = 50
+
+// Snapshot is the latest period of each requested ticker, for refreshing a watchlist with one call
+type Snapshot struct {
+	GeneratedAt   time.Time        `json:"generated_at"`
+	PeriodMinutes int              `json:"period_minutes"`
+	Tickers       []TickerSnapshot `json:"tickers"` // In request order
+}
+
+// TickerSnapshot is one ticker's in-progress and last completed period
+type TickerSnapshot struct {
+	Ticker        string                      `json:"ticker"`
+	Date          string                      `json:"date"`                     // Resolved date the periods come from
+	InProgress    *analysis.TimePeriodSummary `json:"in_progress,omitempty"`    // Period containing the current time, once it has trades
+	LastCompleted *analysis.TimePeriodSummary `json:"last_completed,omitempty"` // Most recent period that has ended
+	Error         string                      `json:"error,omitempty"`          // Set when the ticker's summaries couldn't be loaded
+}
+
+// ParseTickerList parses a comma-separated ticker list, upper-casing and de-duplicating it in order
+func ParseTickerList(value string) ([]string, error) {
+	seen := make(map[string]bool)
+	var tickers []string
+	for _, ticker := range strings.Split(value, ",") {
+		ticker = strings.ToUpper(strings.TrimSpace(ticker))
+		if ticker == "" || seen[ticker] {
+			continue
+		}
+		if err := ValidateTicker(ticker); err != nil {
+			return nil, err
+		}
+		seen[ticker] = true
+		tickers = append(tickers, ticker)
+	}
+	if len(tickers) == 0 {
+		return nil, fmt.Errorf("tickers parameter is required")
+	}
+	if len(tickers) > MaxSnapshotTickers {
+		return nil, fmt.Errorf("too many tickers (%d), at most %d per request", len(tickers), MaxSnapshotTickers)
+	}
+	return tickers, nil
+}
+
+// NewTickerSnapshot picks the in-progress and last completed periods at now from a day's summaries (sorted by period start)
+// For past dates every period has ended, so only the day's last period is returned
+func NewTickerSnapshot(ticker string, dateStr string, summaries []analysis.TimePeriodSummary, now time.Time) TickerSnapshot {
+	snapshot := TickerSnapshot{Ticker: ticker, Date: dateStr}
+	for i := len(summaries) - 1; i >= 0; i-- {
+		summary := summaries[i]
+		if !summary.PeriodEnd.After(now) {
+			snapshot.LastCompleted = &summary
+			break
+		}
+		if snapshot.InProgress == nil && !summary.PeriodStart.After(now) {
+			snapshot.InProgress = &summary
+		}
+	}
+	return snapshot
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/latency"
+	"github.com/gorilla/websocket"
+)
+
+// updateQueueSize is the number of live updates buffered per connection before new ones are dropped
+const updateQueueSize = 64
+
+// ConnectionStats counts the traffic sent to one connection
+// Counters are updated atomically and may be read while the connection is active
+type ConnectionStats struct {
+	MessagesSent atomic.Int64 // Summary messages written (history, replay, and live updates)
+	BytesSent    atomic.Int64 // Payload bytes of those messages
+	SendErrors   atomic.Int64 // Failed writes
+	QueueDrops   atomic.Int64 // Live updates dropped because the connection's queue was full
+}
+
+// ConnectionStatsSnapshot is a point-in-time view of one connection for the admin stats endpoint
+type ConnectionStatsSnapshot struct {
+	Subject         string    `json:"subject"`
+	Ticker          string    `json:"ticker"`
+	Subscriptions   []string  `json:"subscriptions"` // Tickers the connection follows live (see HandleSubscription)
+	Protocol        string    `json:"protocol"`
+	Compressed      bool      `json:"compressed"` // permessage-deflate was negotiated
+	Replay          bool      `json:"replay"`
+	ConnectedAt     time.Time `json:"connected_at"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	MessagesSent    int64     `json:"messages_sent"`
+	BytesSent       int64     `json:"bytes_sent"`
+	WireBytesSent   int64     `json:"wire_bytes_sent"` // Bytes written to the network for every message, after framing and compression
+	SendErrors      int64     `json:"send_errors"`
+	QueueDrops      int64     `json:"queue_drops"`
+	QueueLength     int       `json:"queue_length"`
+
+	Lag LagSnapshot `json:"lag"` // Heartbeat-reported lag (see MessageTypeHeartbeat)
+}
+
+// StatsTotals aggregates connection counters for a ticker, a user, or the whole server
+type StatsTotals struct {
+	Connections   int   `json:"connections"`
+	MessagesSent  int64 `json:"messages_sent"`
+	BytesSent     int64 `json:"bytes_sent"`
+	WireBytesSent int64 `json:"wire_bytes_sent"`
+	SendErrors    int64 `json:"send_errors"`
+	QueueDrops    int64 `json:"queue_drops"`
+}
+
+// add adds a connection's counters to the totals
+func (t *StatsTotals) add(c ConnectionStatsSnapshot) {
+	t.Connections++
+	t.MessagesSent += c.MessagesSent
+	t.BytesSent += c.BytesSent
+	t.WireBytesSent += c.WireBytesSent
+	t.SendErrors += c.SendErrors
+	t.QueueDrops += c.QueueDrops
+}
+
+// StatsSnapshot is the admin view of every active connection, aggregated by ticker and by user
+type StatsSnapshot struct {
+	GeneratedAt time.Time                 `json:"generated_at"`
+	Totals      StatsTotals               `json:"totals"`
+	Tickers     map[string]StatsTotals    `json:"tickers"`
+	Users       map[string]StatsTotals    `json:"users"`
+	Connections []ConnectionStatsSnapshot `json:"connections"`      // Most bytes sent first
+	Caches      map[string]CacheStats     `json:"caches,omitempty"` // In-memory caches by name, filled in by the caller
+
+	// End-to-end latency from the logger's ingest, by stage (see latency.StageRead and latency.StageSent)
+	Latency map[string]latency.Stage `json:"latency"`
+}
+
+// snapshot returns a point-in-time view of a connection's counters
+func (info *ClientInfo) snapshot(now time.Time) ConnectionStatsSnapshot {
+	snapshot := ConnectionStatsSnapshot{
+		Subject:         info.Subject,
+		Ticker:          info.Ticker,
+		Protocol:        info.Protocol,
+		Compressed:      info.Compressed,
+		Replay:          info.Replay,
+		ConnectedAt:     info.ConnectedAt,
+		DurationSeconds: now.Sub(info.ConnectedAt).Seconds(),
+		QueueLength:     len(info.updates),
+		Subscriptions:   info.subscriptions(),
+	}
+	if info.stats != nil {
+		snapshot.MessagesSent = info.stats.MessagesSent.Load()
+		snapshot.BytesSent = info.stats.BytesSent.Load()
+		snapshot.SendErrors = info.stats.SendErrors.Load()
+		snapshot.QueueDrops = info.stats.QueueDrops.Load()
+	}
+	if info.wire != nil {
+		snapshot.WireBytesSent = info.wire.written.Load()
+	}
+	if info.lag != nil {
+		snapshot.Lag = info.lag.snapshot(now)
+	}
+	return snapshot
+}
+
+// Stats returns counters for every active connection, aggregated by ticker and by user
+func (s *Server) Stats() StatsSnapshot {
+	now := time.Now()
+	stats := StatsSnapshot{
+		GeneratedAt: now.UTC(),
+		Tickers:     make(map[string]StatsTotals),
+		Users:       make(map[string]StatsTotals),
+		Connections: []ConnectionStatsSnapshot{},
+		Latency:     s.latency.Snapshot(),
+	}
+
+	s.mu.RLock()
+	for _, info := range s.clients {
+		if info != nil {
+			stats.Connections = append(stats.Connections, info.snapshot(now))
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, c := range stats.Connections {
+		stats.Totals.add(c)
+		ticker := stats.Tickers[c.Ticker]
+		ticker.add(c)
+		stats.Tickers[c.Ticker] = ticker
+		user := stats.Users[c.Subject]
+		user.add(c)
+		stats.Users[c.Subject] = user
+	}
+
+	sort.Slice(stats.Connections, func(i, j int) bool {
+		return stats.Connections[i].BytesSent > stats.Connections[j].BytesSent
+	})
+	return stats
+}
+
+// WriteSummary writes a summary message to a client, numbering it with the connection's next sequence number and
+// tagging it with the connection's ticker unless it already carries one, and records it in the connection's statistics
+// kind (MessageTypeHistory, MessageTypeUpdate, or MessageTypeReplay) is the Envelope type on enveloped connections
+func (s *Server) WriteSummary(conn *websocket.Conn, kind string, summary analysis.TimePeriodSummary) error {
+	s.mu.RLock()
+	var stats *ConnectionStats
+	var lag *lagTracker
+	if info, ok := s.clients[conn]; ok && info != nil {
+		stats = info.stats
+		lag = info.lag
+		if summary.Ticker == "" {
+			summary.Ticker = info.Ticker
+		}
+	}
+	s.mu.RUnlock()
+
+	if lag != nil {
+		summary = lag.next(summary)
+	}
+	frame, data, err := encodeMessage(conn, kind, summary.Ticker, summary)
+	if err == nil {
+		err = conn.WriteMessage(frame, data)
+	}
+	if err == nil {
+		s.latency.Record(latency.StageSent, summary.IngestedAt())
+	}
+	if stats != nil {
+		if err != nil {
+			stats.SendErrors.Add(1)
+		} else {
+			stats.MessagesSent.Add(1)
+			stats.BytesSent.Add(int64(len(data)))
+		}
+	}
+	return err
+}
+
+// Heartbeat records a client heartbeat carrying the last summary sequence number it received, and returns the
+// periods to resend if the client has fallen behind (see SetLagResend); the connection's writer sends them with
+// WriteSummary
+func (s *Server) Heartbeat(conn *websocket.Conn, seq int64) []analysis.TimePeriodSummary {
+	s.mu.RLock()
+	resendAfter := s.resendAfter
+	var lag *lagTracker
+	if info, ok := s.clients[conn]; ok && info != nil {
+		lag = info.lag
+	}
+	s.mu.RUnlock()
+
+	if lag == nil {
+		return nil
+	}
+	return lag.heartbeat(seq, resendAfter)
+}
+
+// Updates returns the queue of live updates for a registered connection (nil if it isn't registered)
+// The connection's writer goroutine drains it; updates are dropped rather than blocking when it is full
+func (s *Server) Updates(conn *websocket.Conn) <-chan analysis.TimePeriodSummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if info, ok := s.clients[conn]; ok && info != nil {
+		return info.updates
+	}
+	return nil
+}
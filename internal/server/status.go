@@ -0,0 +1,249 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/clock"
+	"github.com/ekinolik/jax-ov/internal/logger"
+	"github.com/ekinolik/jax-ov/internal/market"
+)
+
+// Public status page limits
+const (
+	MaxStatusIncidents         = 20               // Incidents kept for the public status page, most recent first
+	DefaultStatusCheckInterval = 15 * time.Second // How often the status monitor checks the logger heartbeat
+)
+
+// Overall states reported by the public status page
+const (
+	StatusOperational = "operational"
+	StatusDegraded    = "degraded"
+	StatusDown        = "down"
+)
+
+// Feed states reported by the public status page
+const (
+	FeedOK    = "ok"
+	FeedStale = "stale" // No messages within the stale threshold during the regular session
+	FeedIdle  = "idle"  // No recent messages outside the regular session, when options don't trade
+)
+
+// Incident is a period during which a health check failed
+type Incident struct {
+	Kind       string     `json:"kind"`    // logger_down, disk, writes, or feed_stale
+	Message    string     `json:"message"` // Short public description
+	StartedAt  time.Time  `json:"started_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"` // Nil while the incident is ongoing
+}
+
+// FeedStatus is the freshness of one upstream feed the logger subscribes to
+type FeedStatus struct {
+	Name          string     `json:"name"`
+	State         string     `json:"state"` // FeedOK, FeedStale, or FeedIdle
+	LastMessageAt *time.Time `json:"last_message_at,omitempty"`
+	AgeSeconds    *int       `json:"age_seconds,omitempty"` // Seconds since the last message (unset without messages)
+}
+
+// MarketState is the trading session at the time of a status report
+type MarketState struct {
+	Date       string `json:"date"`    // Today in the analysis timezone
+	Session    string `json:"session"` // premarket, regular, afterhours, or closed
+	TradingDay bool   `json:"trading_day"`
+}
+
+// PublicStatus is the coarse system health served on the public status page
+// It deliberately leaves out operational detail (paths, process IDs, disk space, users)
+type PublicStatus struct {
+	Status        string       `json:"status"` // StatusOperational, StatusDegraded, or StatusDown
+	UpdatedAt     time.Time    `json:"updated_at"`
+	Market        MarketState  `json:"market"`
+	TickersLogged int          `json:"tickers_logged"` // Tickers with a log file for today
+	Feeds         []FeedStatus `json:"feeds"`
+	Incidents     []Incident   `json:"incidents"` // Most recent first, ongoing ones included
+}
+
+// StatusMonitor periodically checks the logger heartbeat for the public status page, recording when each check
+// starts and stops failing as an incident
+type StatusMonitor struct {
+	logDir     string
+	statusFile string
+	staleAfter time.Duration
+
+	mu        sync.Mutex
+	incidents []*Incident          // Most recent first, at most MaxStatusIncidents
+	open      map[string]*Incident // Ongoing incidents by check key
+	feeds     []FeedStatus
+	down      bool // The logger heartbeat is missing or stale
+	checked   bool // Check has run at least once
+}
+
+// NewStatusMonitor creates a monitor for the logger heartbeat in statusFile; feeds and the heartbeat count as stale
+// after staleAfter without activity
+func NewStatusMonitor(logDir string, statusFile string, staleAfter time.Duration) *StatusMonitor {
+	return &StatusMonitor{
+		logDir:     logDir,
+		statusFile: statusFile,
+		staleAfter: staleAfter,
+		open:       make(map[string]*Incident),
+	}
+}
+
+// Run checks the logger heartbeat now and then every interval; it never returns
+func (m *StatusMonitor) Run(interval time.Duration) {
+	m.Check(time.Now())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		m.Check(now)
+	}
+}
+
+// Check reads the logger heartbeat and opens or resolves incidents; failed checks are keyed so each ongoing problem
+// is one incident however many checks it spans
+func (m *StatusMonitor) Check(now time.Time) {
+	failing := make(map[string]Incident)
+	var feeds []FeedStatus
+	down := false
+
+	status, err := logger.ReadStatusFile(m.statusFile)
+	if err != nil || status.Age(now) > m.staleAfter {
+		down = true
+		failing["logger_down"] = Incident{Kind: "logger_down", Message: "The market data logger is not reporting"}
+	}
+	if err == nil {
+		if status.Disk != nil && status.Disk.Degraded() {
+			failing["disk"] = Incident{Kind: "disk", Message: "Log storage is low on space and some data is being skipped"}
+		}
+		if status.Writes != nil && status.Writes.Degraded() {
+			failing["writes"] = Incident{Kind: "writes", Message: "Writes to the data logs are slow or failing"}
+		}
+
+		// Options only trade in the regular session, so quiet feeds outside it are expected
+		regular := market.SessionForTime(clock.Now()) == market.SessionRegular
+		for _, sub := range status.Subscriptions {
+			feed := FeedStatus{Name: sub.Subscription, State: FeedOK}
+			age := now.Sub(sub.LastMessageTime)
+			if !sub.LastMessageTime.IsZero() {
+				last := sub.LastMessageTime
+				seconds := int(age.Seconds())
+				feed.LastMessageAt = &last
+				feed.AgeSeconds = &seconds
+			}
+			if down || sub.LastMessageTime.IsZero() || age > m.staleAfter {
+				feed.State = FeedIdle
+				if regular {
+					feed.State = FeedStale
+				}
+			}
+			if feed.State == FeedStale && !down {
+				failing["feed_stale:"+sub.Subscription] = Incident{Kind: "feed_stale", Message: fmt.Sprintf("No recent data from the %s feed", sub.Subscription)}
+			}
+			feeds = append(feeds, feed)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checked = true
+	m.down = down
+	m.feeds = feeds
+	for key, incident := range m.open {
+		if _, still := failing[key]; !still {
+			resolved := now
+			incident.ResolvedAt = &resolved
+			delete(m.open, key)
+		}
+	}
+	keys := make([]string, 0, len(failing))
+	for key := range failing {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, exists := m.open[key]; exists {
+			continue
+		}
+		incident := failing[key]
+		incident.StartedAt = now
+		m.open[key] = &incident
+		m.incidents = append([]*Incident{&incident}, m.incidents...)
+	}
+	m.trim()
+}
+
+// trim drops the oldest resolved incidents beyond MaxStatusIncidents; the caller holds m.mu
+func (m *StatusMonitor) trim() {
+	for i := len(m.incidents) - 1; i >= 0 && len(m.incidents) > MaxStatusIncidents; i-- {
+		if m.incidents[i].ResolvedAt != nil {
+			m.incidents = append(m.incidents[:i], m.incidents[i+1:]...)
+		}
+	}
+}
+
+// Report returns the public status as of the latest check
+// Heartbeat ages are measured on the wall clock and the market state on the clock in use (see package clock)
+func (m *StatusMonitor) Report(now time.Time) PublicStatus {
+	marketNow := clock.Now()
+	today := market.DateOf(marketNow)
+	report := PublicStatus{
+		Status:    StatusOperational,
+		UpdatedAt: now,
+		Market: MarketState{
+			Date:       today,
+			Session:    market.SessionForTime(marketNow),
+			TradingDay: market.IsTradingDay(marketNow),
+		},
+		Feeds:     []FeedStatus{},
+		Incidents: []Incident{},
+	}
+	if files, err := GetLogFilesForDate(m.logDir, today); err == nil {
+		report.TickersLogged = len(files)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.checked {
+		return report
+	}
+	report.Feeds = append(report.Feeds, m.feeds...)
+	for _, incident := range m.incidents {
+		report.Incidents = append(report.Incidents, *incident)
+	}
+	switch {
+	case m.down:
+		report.Status = StatusDown
+	case len(m.open) > 0:
+		report.Status = StatusDegraded
+	}
+	return report
+}
+
+// statusPage renders a PublicStatus as a small self-contained HTML page
+var statusPage = template.Must(template.New("status").Funcs(template.FuncMap{
+	"since": func(t time.Time) string { return t.UTC().Format("Jan 2 15:04 MST") },
+}).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta http-equiv="refresh" content="30"><title>jax-ov status</title>
+<style>body{font-family:sans-serif;max-width:40em;margin:2em auto}td,th{padding:.2em .8em;text-align:left}.operational,.ok{color:green}.degraded,.stale{color:darkorange}.down{color:red}.idle{color:gray}</style>
+</head><body>
+<h1>Status: <span class="{{.Status}}">{{.Status}}</span></h1>
+<p>Market: {{.Market.Session}} ({{.Market.Date}}{{if not .Market.TradingDay}}, not a trading day{{end}}). Tickers logged today: {{.TickersLogged}}.</p>
+<h2>Feeds</h2>
+{{if .Feeds}}<table><tr><th>Feed</th><th>State</th><th>Last message</th></tr>
+{{range .Feeds}}<tr><td>{{.Name}}</td><td class="{{.State}}">{{.State}}</td><td>{{with .LastMessageAt}}{{since .}}{{else}}never{{end}}</td></tr>
+{{end}}</table>{{else}}<p>No feed activity reported yet.</p>{{end}}
+<h2>Recent incidents</h2>
+{{if .Incidents}}<ul>{{range .Incidents}}<li>{{.Message}}: {{since .StartedAt}}{{with .ResolvedAt}} to {{since .}}{{else}}, ongoing{{end}}</li>
+{{end}}</ul>{{else}}<p>No recent incidents.</p>{{end}}
+<p><small>Updated {{since .UpdatedAt}}. <a href="?format=json">JSON</a></small></p>
+</body></html>
+`))
+
+// WriteStatusPage writes the HTML status page for a report
+func WriteStatusPage(w io.Writer, status PublicStatus) error {
+	return statusPage.Execute(w, status)
+}
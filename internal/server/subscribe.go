@@ -0,0 +1,188 @@
+package server
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/gorilla/websocket"
+)
+
+// MaxSubscriptions caps the live streams one /analyze connection may follow, including the one it connected with
+const MaxSubscriptions = 10
+
+// subscription is one live stream a connection follows
+type subscription struct {
+	options analysis.AggregateOptions
+	floor   *premiumFloor // Set when the connection has a premium floor (see ClientInfo.MinPremiumChange)
+}
+
+// newSubscription creates a subscription with its own premium floor, so each ticker's updates are held back
+// independently
+func newSubscription(opts analysis.AggregateOptions, minPremiumChange float64) *subscription {
+	sub := &subscription{options: opts}
+	if minPremiumChange > 0 {
+		sub.floor = &premiumFloor{min: minPremiumChange}
+	}
+	return sub
+}
+
+// Subscription is a stream resolved by a SubscriptionLoader
+type Subscription struct {
+	Ticker  string                       // Name the stream's messages are tagged with (e.g. a group name for a group)
+	History []analysis.TimePeriodSummary // The stream's periods so far on the connection's date, oldest first
+}
+
+// SubscriptionLoader resolves a ticker parameter for a connection (a ticker, a configured group, or a
+// comma-separated list) and loads the stream's history with opts. Errors are sent to the client as error frames, with
+// the code of a ProtocolError or ErrorInvalidParameter for other errors
+type SubscriptionLoader func(info ClientInfo, ticker string, opts analysis.AggregateOptions) (Subscription, error)
+
+// ProtocolError is a request error reported to a client with one of the Error* codes
+type ProtocolError struct {
+	Code    string
+	Message string
+}
+
+func (e *ProtocolError) Error() string {
+	return e.Message
+}
+
+// SubscriptionMessage confirms a subscription change; a subscribed message is followed by the stream's history
+type SubscriptionMessage struct {
+	Type          string   `json:"type"`             // "subscribed" or "unsubscribed"
+	Ticker        string   `json:"ticker"`           // Stream the change applies to; its messages carry this ticker
+	Period        int      `json:"period,omitempty"` // Period length in minutes (subscribed only)
+	Date          string   `json:"date,omitempty"`   // Date the history covers (subscribed only)
+	Subscriptions []string `json:"subscriptions"`    // Every ticker the connection follows after the change, sorted
+}
+
+// SetSubscriptionLoader enables subscribe and change_period messages, resolving and loading streams with loader
+func (s *Server) SetSubscriptionLoader(loader SubscriptionLoader) {
+	s.mu.Lock()
+	s.loader = loader
+	s.mu.Unlock()
+}
+
+// HandleSubscription applies a subscribe, unsubscribe, or change_period message from a live client and writes the
+// reply. Subscriptions share the connection's date and bucketing options, except for the period length; a ticker
+// that is already followed is replaced. Tickers default to the one the client connected with, except for subscribe
+// It must be called from the connection's writer goroutine. Invalid requests are answered with an error frame and
+// leave the connection open; the returned error is a failed write
+func (s *Server) HandleSubscription(conn *websocket.Conn, msg ClientMessage) error {
+	s.mu.RLock()
+	info, ok := s.clients[conn]
+	loader := s.loader
+	var current ClientInfo
+	if ok && info != nil {
+		current = *info
+	}
+	s.mu.RUnlock()
+	if !ok || info == nil {
+		return nil
+	}
+
+	if !current.live() {
+		return SendError(conn, ErrorInvalidParameter, msg.Type+" is only supported on live connections")
+	}
+	if loader == nil {
+		return SendError(conn, ErrorInvalidParameter, "subscriptions are not enabled on this server")
+	}
+	ticker := strings.ToUpper(strings.TrimSpace(msg.Ticker))
+	if ticker == "" && msg.Type != MessageTypeSubscribe {
+		ticker = current.Ticker
+	}
+	if ticker == "" {
+		return SendError(conn, ErrorInvalidTicker, "ticker is required")
+	}
+
+	if msg.Type == MessageTypeUnsubscribe {
+		s.mu.Lock()
+		_, subscribed := info.subs[ticker]
+		delete(info.subs, ticker)
+		tickers := info.subscriptions()
+		s.mu.Unlock()
+		if !subscribed {
+			return SendError(conn, ErrorInvalidTicker, "not subscribed to "+ticker)
+		}
+		return writeSubscriptionMessage(conn, SubscriptionMessage{Type: MessageTypeUnsubscribed, Ticker: ticker, Subscriptions: tickers})
+	}
+
+	// New subscriptions take the connection's options; period changes keep the subscription's own
+	opts := current.Options
+	if msg.Type == MessageTypeChangePeriod {
+		s.mu.RLock()
+		sub, subscribed := info.subs[ticker]
+		if subscribed {
+			opts = sub.options
+		}
+		s.mu.RUnlock()
+		if !subscribed {
+			return SendError(conn, ErrorInvalidTicker, "not subscribed to "+ticker)
+		}
+		if msg.Period == 0 {
+			return SendError(conn, ErrorInvalidParameter, "period is required")
+		}
+	}
+	if msg.Period < 0 {
+		return SendError(conn, ErrorInvalidParameter, "period must be positive")
+	}
+	if msg.Period > 0 {
+		opts.PeriodMinutes = msg.Period
+	}
+
+	resolved, err := loader(current, ticker, opts)
+	if err != nil {
+		var protocolErr *ProtocolError
+		if errors.As(err, &protocolErr) {
+			return SendError(conn, protocolErr.Code, protocolErr.Message)
+		}
+		return SendError(conn, ErrorInvalidParameter, err.Error())
+	}
+
+	s.mu.Lock()
+	if _, subscribed := info.subs[resolved.Ticker]; !subscribed && len(info.subs) >= MaxSubscriptions {
+		s.mu.Unlock()
+		return SendError(conn, ErrorInvalidParameter, "too many subscriptions, unsubscribe from a ticker first")
+	}
+	info.subs[resolved.Ticker] = newSubscription(opts, current.MinPremiumChange)
+	tickers := info.subscriptions()
+	s.mu.Unlock()
+
+	reply := SubscriptionMessage{
+		Type:          MessageTypeSubscribed,
+		Ticker:        resolved.Ticker,
+		Period:        opts.PeriodMinutes,
+		Date:          current.Date,
+		Subscriptions: tickers,
+	}
+	if err := writeSubscriptionMessage(conn, reply); err != nil {
+		return err
+	}
+	for _, summary := range resolved.History {
+		summary.Ticker = resolved.Ticker
+		if err := s.WriteSummary(conn, MessageTypeHistory, summary); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subscriptions returns the tickers the connection follows, sorted; the caller holds the server's lock
+func (info *ClientInfo) subscriptions() []string {
+	tickers := make([]string, 0, len(info.subs))
+	for ticker := range info.subs {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+	return tickers
+}
+
+// writeSubscriptionMessage writes a subscribed or unsubscribed frame to a client
+func writeSubscriptionMessage(conn *websocket.Conn, msg SubscriptionMessage) error {
+	conn.SetWriteDeadline(time.Now().Add(time.Second))
+	defer conn.SetWriteDeadline(time.Time{})
+	return writeMessage(conn, msg.Type, msg.Ticker, msg)
+}
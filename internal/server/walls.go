@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ekinolik/jax-ov/internal/analysis"
+	"github.com/ekinolik/jax-ov/internal/marketdata"
+)
+
+// PeriodWalls holds the cumulative call and put walls at the end of one period
+type PeriodWalls struct {
+	PeriodStart time.Time               `json:"period_start"`
+	PeriodEnd   time.Time               `json:"period_end"`
+	CallWall    *analysis.StrikePremium `json:"call_wall,omitempty"`
+	PutWall     *analysis.StrikePremium `json:"put_wall,omitempty"`
+}
+
+// WallsReport describes the put and call walls for a ticker and date
+type WallsReport struct {
+	Ticker   string                   `json:"ticker"`
+	Date     string                   `json:"date"`
+	CallWall *analysis.StrikePremium  `json:"call_wall,omitempty"` // Strike with the most call premium for the day
+	PutWall  *analysis.StrikePremium  `json:"put_wall,omitempty"`  // Strike with the most put premium for the day
+	TopCalls []analysis.StrikePremium `json:"top_calls"`           // Strikes with the most call premium, largest first
+	TopPuts  []analysis.StrikePremium `json:"top_puts"`            // Strikes with the most put premium, largest first
+	Periods  []PeriodWalls            `json:"periods"`             // How the walls moved through the day
+}
+
+// ReadWallsForTickerAndDate builds a wall tracker from every aggregate logged for a ticker and date
+// Aggregates excluded by opts (e.g. session filter) are skipped; a missing log file yields an empty tracker
+// spot supplies underlying prices when opts limits strikes to near spot (MaxMoneyness), and may be nil otherwise
+func ReadWallsForTickerAndDate(logDir string, ticker string, dateStr string, opts analysis.AggregateOptions, spot marketdata.SpotSource) (*analysis.WallTracker, error) {
+	walls := analysis.NewWallTracker()
+	aggregates, err := readIncludedAggregates(logDir, ticker, dateStr, opts, spot)
+	if err != nil {
+		return nil, err
+	}
+	for _, agg := range aggregates {
+		walls.Add(agg)
+	}
+	return walls, nil
+}
+
+// readIncludedAggregates reads the aggregates logged for a ticker and date that pass opts, tagging their moneyness
+// first when opts limits strikes to near spot; a missing log file yields no aggregates
+func readIncludedAggregates(logDir string, ticker string, dateStr string, opts analysis.AggregateOptions, spot marketdata.SpotSource) ([]analysis.Aggregate, error) {
+	logFile := GetLogFileForTickerAndDate(logDir, ticker, dateStr)
+	if _, err := os.Stat(logFile); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	aggregates, err := ReadLogFile(logFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+	if opts.MaxMoneyness > 0 {
+		if spot == nil {
+			return nil, fmt.Errorf("moneyness filter requires a spot source")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), spotFetchTimeout)
+		defer cancel()
+		if err := TagMoneynessForDate(ctx, spot, ticker, dateStr, aggregates); err != nil {
+			return nil, err
+		}
+	}
+	return opts.Filter(aggregates), nil
+}
+
+// AnalyzeWalls reports the day's walls, the top strikes on each side, and the walls at the end of every period
+func AnalyzeWalls(logDir string, ticker string, dateStr string, opts analysis.AggregateOptions, top int) (WallsReport, error) {
+	report := WallsReport{
+		Ticker:   ticker,
+		Date:     dateStr,
+		TopCalls: []analysis.StrikePremium{},
+		TopPuts:  []analysis.StrikePremium{},
+		Periods:  []PeriodWalls{},
+	}
+
+	logFile := GetLogFileForTickerAndDate(logDir, ticker, dateStr)
+	if _, err := os.Stat(logFile); os.IsNotExist(err) {
+		return report, nil
+	}
+
+	aggregates, err := ReadLogFile(logFile)
+	if err != nil {
+		return report, fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	walls := analysis.NewWallTracker()
+	for _, agg := range aggregates {
+		if opts.Includes(agg) {
+			walls.Add(agg)
+		}
+	}
+	report.CallWall, report.PutWall = walls.Walls()
+	report.TopCalls, report.TopPuts = walls.Top(top)
+
+	summaries, err := analysis.AggregatePremiumsWithOptions(aggregates, opts)
+	if err != nil {
+		return report, fmt.Errorf("failed to aggregate premiums: %w", err)
+	}
+	for _, summary := range summaries {
+		report.Periods = append(report.Periods, PeriodWalls{
+			PeriodStart: summary.PeriodStart,
+			PeriodEnd:   summary.PeriodEnd,
+			CallWall:    summary.CallWall,
+			PutWall:     summary.PutWall,
+		})
+	}
+
+	return report, nil
+}
@@ -0,0 +1,234 @@
+// Package sheets appends rows to a Google Sheet using service-account credentials
+// It talks to the Sheets REST API directly: a signed JWT is exchanged for an access token,
+// which is cached until shortly before it expires
+package sheets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// Scope grants read/write access to spreadsheets shared with the service account
+	Scope = "https://www.googleapis.com/auth/spreadsheets"
+
+	defaultTokenURI = "https://oauth2.googleapis.com/token"
+	apiBaseURL      = "https://sheets.googleapis.com/v4/spreadsheets"
+)
+
+// Credentials holds the fields of a Google service-account key file used for authentication
+type Credentials struct {
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// LoadCredentials reads a service-account key file downloaded from the Google Cloud console
+func LoadCredentials(filename string) (*Credentials, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	var creds Credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file: %w", err)
+	}
+	if creds.ClientEmail == "" || creds.PrivateKey == "" {
+		return nil, fmt.Errorf("credentials file %s is missing client_email or private_key", filename)
+	}
+	if creds.TokenURI == "" {
+		creds.TokenURI = defaultTokenURI
+	}
+	return &creds, nil
+}
+
+// Client appends rows to one spreadsheet
+// The spreadsheet must be shared with the service account's client_email as an editor
+type Client struct {
+	creds         *Credentials
+	spreadsheetID string
+	httpClient    *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewClient creates a client for a spreadsheet (the ID is the long segment of the sheet's URL)
+func NewClient(creds *Credentials, spreadsheetID string) *Client {
+	return &Client{
+		creds:         creds,
+		spreadsheetID: spreadsheetID,
+		httpClient:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// AppendRows appends rows after the last row with data in a sheet (tab), e.g. "Daily"
+// Values are interpreted as if typed by a user, so numbers and dates keep their types in the sheet
+func (c *Client) AppendRows(ctx context.Context, sheet string, rows [][]interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	token, err := c.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"values": rows})
+	if err != nil {
+		return fmt.Errorf("failed to encode rows: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/values/%s:append?valueInputOption=USER_ENTERED&insertDataOption=INSERT_ROWS",
+		apiBaseURL, url.PathEscape(c.spreadsheetID), url.PathEscape(sheet))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create append request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to append rows: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("sheets API returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// token returns a cached access token, requesting a new one when it is missing or about to expire
+func (c *Client) token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.accessToken != "" && time.Until(c.expiresAt) > time.Minute {
+		return c.accessToken, nil
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(c.creds.PrivateKey))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse service account private key: %w", err)
+	}
+
+	now := time.Now()
+	assertion := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   c.creds.ClientEmail,
+		"scope": Scope,
+		"aud":   c.creds.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if c.creds.PrivateKeyID != "" {
+		assertion.Header["kid"] = c.creds.PrivateKeyID
+	}
+	signed, err := assertion.SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token request: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {signed},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.creds.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("token endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	var tokenResponse struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	c.accessToken = tokenResponse.AccessToken
+	c.expiresAt = now.Add(time.Duration(tokenResponse.ExpiresIn) * time.Second)
+	return c.accessToken, nil
+}
+
+// Appender batches rows and appends them to a sheet in the background
+// Rows are flushed every interval; a failed flush is logged by the caller-provided onError and the rows are dropped
+type Appender struct {
+	client   *Client
+	sheet    string
+	interval time.Duration
+	onError  func(err error, rows int)
+
+	mu      sync.Mutex
+	pending [][]interface{}
+}
+
+// NewAppender creates an appender for a sheet and starts its flush loop
+func NewAppender(client *Client, sheet string, interval time.Duration, onError func(err error, rows int)) *Appender {
+	a := &Appender{
+		client:   client,
+		sheet:    sheet,
+		interval: interval,
+		onError:  onError,
+	}
+	go a.run()
+	return a
+}
+
+// Add queues a row for the next flush
+func (a *Appender) Add(row []interface{}) {
+	a.mu.Lock()
+	a.pending = append(a.pending, row)
+	a.mu.Unlock()
+}
+
+// run flushes pending rows every interval
+func (a *Appender) run() {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		a.mu.Lock()
+		rows := a.pending
+		a.pending = nil
+		a.mu.Unlock()
+
+		if len(rows) == 0 {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		err := a.client.AppendRows(ctx, a.sheet, rows)
+		cancel()
+		if err != nil && a.onError != nil {
+			a.onError(err, len(rows))
+		}
+	}
+}
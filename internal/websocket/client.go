@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/ekinolik/jax-ov/internal/analysis"
 	massivews "github.com/massive-com/client-go/v2/websocket"
 	"github.com/massive-com/client-go/v2/websocket/models"
 )
@@ -12,10 +13,24 @@ import (
 // Client wraps the massive.com WebSocket client
 type Client struct {
 	client *massivews.Client
+	topic  massivews.Topic
 }
 
-// NewClient creates a new WebSocket client
+// NewClient creates a new WebSocket client for per-second aggregates
 func NewClient(apiKey string) (*Client, error) {
+	return NewClientWithTimespan(apiKey, analysis.TimespanSecond)
+}
+
+// NewClientWithTimespan creates a new WebSocket client for per-second or per-minute aggregates
+func NewClientWithTimespan(apiKey string, timespan string) (*Client, error) {
+	if err := analysis.ValidateTimespan(timespan); err != nil {
+		return nil, err
+	}
+	topic := massivews.OptionsSecAggs
+	if timespan == analysis.TimespanMinute {
+		topic = massivews.OptionsMinAggs
+	}
+
 	c, err := massivews.New(massivews.Config{
 		APIKey: apiKey,
 		Feed:   massivews.RealTime,
@@ -27,6 +42,7 @@ func NewClient(apiKey string) (*Client, error) {
 
 	return &Client{
 		client: c,
+		topic:  topic,
 	}, nil
 }
 
@@ -45,11 +61,11 @@ func (c *Client) Close() {
 	}
 }
 
-// Subscribe subscribes to options aggregates per second for the given ticker(s)
+// Subscribe subscribes to options aggregates (per second or per minute) for the given ticker(s)
 // ticker can be a specific option contract (e.g., "O:AAPL230616C00150000")
 // or a wildcard pattern (e.g., "*" for all options, or "O:AAPL*" for all AAPL options)
 func (c *Client) Subscribe(ticker string) error {
-	if err := c.client.Subscribe(massivews.OptionsSecAggs, ticker); err != nil {
+	if err := c.client.Subscribe(c.topic, ticker); err != nil {
 		return fmt.Errorf("failed to subscribe: %w", err)
 	}
 	return nil
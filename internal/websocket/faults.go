@@ -0,0 +1,174 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Faults configures failures injected into a live message stream, to exercise reconnect and gap handling
+// without waiting for the vendor to misbehave
+type Faults struct {
+	DropEvery     time.Duration // How often the connection drops (0 never drops)
+	DropFor       time.Duration // How long each drop lasts; messages sent meanwhile are lost, as during a vendor reconnect
+	Delay         time.Duration // Upper bound of a random delay before each message is delivered
+	DuplicateRate float64       // Fraction of messages delivered twice, as when a frame is replayed after a reconnect
+	Seed          int64         // Random seed, so a failure sequence can be reproduced (0 seeds from the clock)
+}
+
+// DefaultDropFor is how long a drop lasts when the spec sets drop-every without drop-for
+const DefaultDropFor = 5 * time.Second
+
+// ParseFaults parses a comma-separated fault spec, e.g. "drop-every=2m,drop-for=10s,delay=250ms,duplicate=0.05,seed=42"
+// An empty spec injects no faults
+func ParseFaults(spec string) (Faults, error) {
+	var faults Faults
+	if strings.TrimSpace(spec) == "" {
+		return faults, nil
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return Faults{}, fmt.Errorf("invalid fault %q (expected key=value)", part)
+		}
+
+		var err error
+		switch key {
+		case "drop-every":
+			faults.DropEvery, err = time.ParseDuration(value)
+		case "drop-for":
+			faults.DropFor, err = time.ParseDuration(value)
+		case "delay":
+			faults.Delay, err = time.ParseDuration(value)
+		case "duplicate":
+			faults.DuplicateRate, err = strconv.ParseFloat(value, 64)
+			if err == nil && (faults.DuplicateRate < 0 || faults.DuplicateRate > 1) {
+				err = fmt.Errorf("must be between 0 and 1")
+			}
+		case "seed":
+			faults.Seed, err = strconv.ParseInt(value, 10, 64)
+		default:
+			return Faults{}, fmt.Errorf("unknown fault %q (must be drop-every, drop-for, delay, duplicate, or seed)", key)
+		}
+		if err != nil {
+			return Faults{}, fmt.Errorf("invalid %s %q: %w", key, value, err)
+		}
+	}
+
+	if faults.DropEvery < 0 || faults.DropFor < 0 || faults.Delay < 0 {
+		return Faults{}, fmt.Errorf("fault durations must not be negative")
+	}
+	if faults.DropEvery > 0 && faults.DropFor == 0 {
+		faults.DropFor = DefaultDropFor
+	}
+	return faults, nil
+}
+
+// String formats the faults as a spec ParseFaults accepts
+func (f Faults) String() string {
+	var parts []string
+	if f.DropEvery > 0 {
+		parts = append(parts, "drop-every="+f.DropEvery.String(), "drop-for="+f.DropFor.String())
+	}
+	if f.Delay > 0 {
+		parts = append(parts, "delay="+f.Delay.String())
+	}
+	if f.DuplicateRate > 0 {
+		parts = append(parts, "duplicate="+strconv.FormatFloat(f.DuplicateRate, 'g', -1, 64))
+	}
+	if f.Seed != 0 {
+		parts = append(parts, "seed="+strconv.FormatInt(f.Seed, 10))
+	}
+	return strings.Join(parts, ",")
+}
+
+// FaultStats counts the faults injected so far
+type FaultStats struct {
+	Drops      int64 `json:"drops"`      // Connection drops started
+	Lost       int64 `json:"lost"`       // Messages lost during drops
+	Delayed    int64 `json:"delayed"`    // Messages delivered late
+	Duplicated int64 `json:"duplicated"` // Messages delivered twice
+}
+
+// FaultInjector applies Faults to messages as they are delivered
+// It is type-agnostic: callers wrap the delivery of each message in Deliver
+type FaultInjector struct {
+	faults Faults
+
+	mu        sync.Mutex
+	rng       *rand.Rand
+	nextDrop  time.Time
+	dropUntil time.Time
+	stats     FaultStats
+}
+
+// NewFaultInjector creates an injector whose first drop (if any) is DropEvery from now
+func NewFaultInjector(faults Faults) *FaultInjector {
+	seed := faults.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	f := &FaultInjector{
+		faults: faults,
+		rng:    rand.New(rand.NewSource(seed)),
+	}
+	if faults.DropEvery > 0 {
+		f.nextDrop = time.Now().Add(faults.DropEvery)
+	}
+	return f
+}
+
+// Deliver calls deliver for one message unless the connection is dropped, after an optional delay,
+// and sometimes twice; it returns early without delivering if ctx is cancelled during the delay
+func (f *FaultInjector) Deliver(ctx context.Context, deliver func()) {
+	now := time.Now()
+
+	f.mu.Lock()
+	if !f.nextDrop.IsZero() && !now.Before(f.nextDrop) {
+		f.dropUntil = now.Add(f.faults.DropFor)
+		f.nextDrop = now.Add(f.faults.DropEvery)
+		f.stats.Drops++
+	}
+	if now.Before(f.dropUntil) {
+		f.stats.Lost++
+		f.mu.Unlock()
+		return
+	}
+	var delay time.Duration
+	if f.faults.Delay > 0 {
+		delay = time.Duration(f.rng.Int63n(int64(f.faults.Delay) + 1))
+		f.stats.Delayed++
+	}
+	duplicate := f.faults.DuplicateRate > 0 && f.rng.Float64() < f.faults.DuplicateRate
+	if duplicate {
+		f.stats.Duplicated++
+	}
+	f.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+
+	deliver()
+	if duplicate {
+		deliver()
+	}
+}
+
+// Stats returns the faults injected so far
+func (f *FaultInjector) Stats() FaultStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stats
+}
@@ -0,0 +1,191 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseFaults(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Faults
+	}{
+		{"", Faults{}},
+		{"  ", Faults{}},
+		{"drop-every=2m,drop-for=10s", Faults{DropEvery: 2 * time.Minute, DropFor: 10 * time.Second}},
+		{"drop-every=1m", Faults{DropEvery: time.Minute, DropFor: DefaultDropFor}},
+		{"delay=250ms, duplicate=0.05, seed=42", Faults{Delay: 250 * time.Millisecond, DuplicateRate: 0.05, Seed: 42}},
+		{"duplicate=1", Faults{DuplicateRate: 1}},
+	}
+	for _, tt := range tests {
+		got, err := ParseFaults(tt.spec)
+		if err != nil {
+			t.Errorf("ParseFaults(%q): %v", tt.spec, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseFaults(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+		// String is a spec that parses back to the same faults
+		if again, err := ParseFaults(got.String()); err != nil || again != got {
+			t.Errorf("ParseFaults(%q) = %+v, %v, want %+v", got.String(), again, err, got)
+		}
+	}
+
+	for _, spec := range []string{"drop-every", "drop-every=soon", "delay=-1s", "duplicate=1.5", "duplicate=-0.1", "seed=x", "jitter=1s"} {
+		if faults, err := ParseFaults(spec); err == nil {
+			t.Errorf("ParseFaults(%q) = %+v, want an error", spec, faults)
+		}
+	}
+}
+
+// deliverAll passes n numbered messages through the injector, spaced by interval, and returns the numbers delivered
+// in delivery order
+func deliverAll(ctx context.Context, injector *FaultInjector, n int, interval time.Duration) []int {
+	var delivered []int
+	for i := 0; i < n; i++ {
+		injector.Deliver(ctx, func() {
+			delivered = append(delivered, i)
+		})
+		time.Sleep(interval)
+	}
+	return delivered
+}
+
+func TestFaultInjectorNoFaults(t *testing.T) {
+	injector := NewFaultInjector(Faults{})
+	delivered := deliverAll(context.Background(), injector, 100, 0)
+	if len(delivered) != 100 {
+		t.Errorf("delivered %d of 100 messages without faults", len(delivered))
+	}
+	if stats := injector.Stats(); stats != (FaultStats{}) {
+		t.Errorf("stats without faults = %+v, want zero", stats)
+	}
+}
+
+func TestFaultInjectorDropsAndRecovers(t *testing.T) {
+	injector := NewFaultInjector(Faults{DropEvery: 40 * time.Millisecond, DropFor: 20 * time.Millisecond})
+	delivered := deliverAll(context.Background(), injector, 60, 2*time.Millisecond)
+
+	stats := injector.Stats()
+	if stats.Drops == 0 || stats.Lost == 0 {
+		t.Fatalf("stats = %+v, want drops with lost messages", stats)
+	}
+	if int64(len(delivered))+stats.Lost != 60 {
+		t.Errorf("delivered %d and lost %d, want every one of the 60 messages counted once", len(delivered), stats.Lost)
+	}
+
+	// Each drop is a gap in the delivered sequence, after which delivery resumes in order
+	gaps := 0
+	for i := 1; i < len(delivered); i++ {
+		switch {
+		case delivered[i] <= delivered[i-1]:
+			t.Fatalf("message %d delivered after %d", delivered[i], delivered[i-1])
+		case delivered[i] > delivered[i-1]+1:
+			gaps++
+		}
+	}
+	if gaps == 0 || int64(gaps) > stats.Drops {
+		t.Errorf("%d gaps in delivery for %d drops, want at least one and no more than the drops", gaps, stats.Drops)
+	}
+	if last := delivered[len(delivered)-1]; last < 50 {
+		t.Errorf("last delivered message is %d, want delivery to recover after the drops", last)
+	}
+}
+
+func TestFaultInjectorDelay(t *testing.T) {
+	const delay = 20 * time.Millisecond
+	injector := NewFaultInjector(Faults{Delay: delay, Seed: 1})
+	var longest time.Duration
+	for i := 0; i < 20; i++ {
+		start := time.Now()
+		injector.Deliver(context.Background(), func() {})
+		longest = max(longest, time.Since(start))
+	}
+	if longest == 0 || longest > delay+time.Second {
+		t.Errorf("longest delay = %v, want up to about %v", longest, delay)
+	}
+	if stats := injector.Stats(); stats.Delayed != 20 {
+		t.Errorf("delayed = %d, want 20", stats.Delayed)
+	}
+
+	// Cancelling the context during a delay abandons the message
+	injector = NewFaultInjector(Faults{Delay: time.Hour, Seed: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan bool)
+	go func() {
+		delivered := false
+		injector.Deliver(ctx, func() { delivered = true })
+		done <- delivered
+	}()
+	cancel()
+	select {
+	case delivered := <-done:
+		if delivered {
+			t.Error("message delivered after the context was cancelled")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Deliver didn't return when the context was cancelled")
+	}
+}
+
+func TestFaultInjectorDuplicates(t *testing.T) {
+	injector := NewFaultInjector(Faults{DuplicateRate: 1})
+	if delivered := deliverAll(context.Background(), injector, 10, 0); len(delivered) != 20 {
+		t.Errorf("delivered %d messages at duplicate rate 1, want 20", len(delivered))
+	}
+
+	injector = NewFaultInjector(Faults{DuplicateRate: 0.25, Seed: 3})
+	delivered := deliverAll(context.Background(), injector, 1000, 0)
+	duplicated := injector.Stats().Duplicated
+	if int64(len(delivered)) != 1000+duplicated {
+		t.Errorf("delivered %d messages with %d duplicated, want %d", len(delivered), duplicated, 1000+duplicated)
+	}
+	if duplicated < 150 || duplicated > 350 {
+		t.Errorf("duplicated %d of 1000 messages at rate 0.25", duplicated)
+	}
+}
+
+func TestFaultInjectorSeedReproducesFaults(t *testing.T) {
+	run := func() []int {
+		injector := NewFaultInjector(Faults{DuplicateRate: 0.3, Seed: 42})
+		return deliverAll(context.Background(), injector, 200, 0)
+	}
+	first, second := run(), run()
+	if len(first) != len(second) {
+		t.Fatalf("runs with the same seed delivered %d and %d messages", len(first), len(second))
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("runs with the same seed differ at delivery %d: %d and %d", i, first[i], second[i])
+		}
+	}
+}
+
+func TestFaultInjectorConcurrentDelivery(t *testing.T) {
+	injector := NewFaultInjector(Faults{DropEvery: 5 * time.Millisecond, DropFor: time.Millisecond, DuplicateRate: 0.1, Delay: time.Millisecond})
+	var mu sync.Mutex
+	delivered := 0
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				injector.Deliver(context.Background(), func() {
+					mu.Lock()
+					delivered++
+					mu.Unlock()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := injector.Stats()
+	if int64(delivered) != 400-stats.Lost+stats.Duplicated {
+		t.Errorf("delivered %d with %d lost and %d duplicated, want %d", delivered, stats.Lost, stats.Duplicated, 400-stats.Lost+stats.Duplicated)
+	}
+}
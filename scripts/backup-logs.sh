@@ -193,7 +193,7 @@ find_log_analyze_binary() {
     exit 1
 }
 
-# Find calendar/trading-days.json file
+# Find calendar/trading-days.json file (optional override for the trading days built into the binary)
 find_trading_days_file() {
     # Find calendar file relative to this script's location
     local script_dir
@@ -215,8 +215,8 @@ find_trading_days_file() {
         fi
     fi
     
-    error "calendar/trading-days.json not found. Expected at: ${script_dir}/calendar/trading-days.json"
-    exit 1
+    # Not found: trading-days uses its built-in data
+    echo ""
 }
 
 # Get trading days to keep
@@ -226,8 +226,12 @@ get_trading_days_to_keep() {
     local retention_days="$3"
     
     # Run trading-days command to get past N trading days
+    local load_args=()
+    if [[ -n "$trading_days_file" ]]; then
+        load_args=(--load "$trading_days_file")
+    fi
     local output
-    if ! output=$("$trading_days_binary" --load "$trading_days_file" --past "$retention_days" 2>&1); then
+    if ! output=$("$trading_days_binary" ${load_args[@]+"${load_args[@]}"} --past "$retention_days" 2>&1); then
         error "Failed to get trading days: $output"
         exit 1
     fi
@@ -445,7 +449,11 @@ main() {
     
     local trading_days_file
     trading_days_file=$(find_trading_days_file)
-    info "Found trading days file: $trading_days_file"
+    if [[ -n "$trading_days_file" ]]; then
+        info "Found trading days file: $trading_days_file"
+    else
+        info "No calendar/trading-days.json, using the trading days built into trading-days"
+    fi
     
     # Get trading days to keep
     local keep_dates